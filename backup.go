@@ -0,0 +1,191 @@
+package chai
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/expr/functions"
+	"github.com/chaisql/chai/internal/objstore"
+	"github.com/chaisql/chai/internal/query"
+)
+
+// A BackupManifest lists the files of a database when a backup was made.
+// It can be stored, e.g. encoded in JSON, to make incremental backups later.
+type BackupManifest = database.BackupManifest
+
+// A BackupFile is a file of the database listed in a BackupManifest.
+type BackupFile = database.BackupFile
+
+// Backup writes a consistent copy of an on-disk database to dst, as a tar archive.
+// Extracting the archive in a directory creates a database that can be opened with Open.
+// Reads and writes can continue while the backup is written,
+// changes of transactions not yet committed are not part of the backup.
+// The SQL statement BACKUP TO 'path' writes the copy to a directory instead.
+func (db *DB) Backup(ctx context.Context, dst io.Writer) error {
+	return db.DB.Backup(ctx, dst)
+}
+
+// BackupIncremental writes a backup of an on-disk database to dst, like Backup,
+// but skips the data files that were already part of the backup described by since.
+// If since is nil, a full backup is written.
+// It returns the manifest of the backup, to pass to the next incremental backup.
+// Incremental backups are restored with RestoreBackup.
+func (db *DB) BackupIncremental(ctx context.Context, dst io.Writer, since *BackupManifest) (*BackupManifest, error) {
+	return db.DB.BackupIncremental(ctx, dst, since)
+}
+
+// ReadBackupManifest reads the manifest of a backup written by Backup or BackupIncremental.
+// Only the beginning of the backup is read.
+func ReadBackupManifest(r io.Reader) (*BackupManifest, error) {
+	return database.ReadBackupManifest(r)
+}
+
+// RestoreBackup creates a database at the given path, which must not exist,
+// from a full backup followed by the incremental backups made after it,
+// in the order they were made.
+func RestoreBackup(path string, backups ...io.Reader) error {
+	return database.RestoreBackup(path, backups...)
+}
+
+// OpenBackup opens the database stored in the backup files at the given paths,
+// a full backup followed by the incremental backups made after it,
+// in the order they were made. The backups are read in place, without being
+// restored nor loaded in memory, and must not be modified while the database is open.
+// The database is read-only: write transactions fail with ErrReadOnly.
+func OpenBackup(ctx context.Context, paths ...string) (*DB, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var files []*os.File
+	closeFiles := func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}
+
+	backups := make([]io.ReaderAt, 0, len(paths))
+	for _, p := range paths {
+		f, err := os.Open(p)
+		if err != nil {
+			closeFiles()
+			return nil, err
+		}
+		files = append(files, f)
+		backups = append(backups, f)
+	}
+
+	fns := functions.NewRegistry()
+	_, opts, err := openOptions("", nil, fns)
+	if err != nil {
+		closeFiles()
+		return nil, err
+	}
+
+	db, err := database.OpenBackup(ctx, opts, backups...)
+	if err != nil {
+		closeFiles()
+		return nil, err
+	}
+
+	return &DB{
+		DB:        db,
+		cache:     query.NewCache(queryCacheSize),
+		functions: fns,
+	}, nil
+}
+
+// ErrReadOnly is returned when writing to a database opened with OpenBackup.
+var ErrReadOnly = database.ErrReadOnly
+
+// RestoreToTime creates a database at the given path, which must not exist,
+// from backups restored like RestoreBackup, then replays the changes archived
+// in walArchiveDir that were committed after the backups and until the given time.
+// The database and its backups must have been written with Options.WALArchiveDir set.
+// It returns the time of the last replayed commit, or the zero time if none was replayed.
+func RestoreToTime(path, walArchiveDir string, until time.Time, backups ...io.Reader) (time.Time, error) {
+	return database.RestoreToTime(path, walArchiveDir, until, backups...)
+}
+
+// An ObjectStore stores the backups written by BackupToObjectStore.
+// Put stores an object whose size is known in advance,
+// Get returns the content of an object or an error wrapping ErrObjectNotFound.
+type ObjectStore = objstore.Store
+
+// ErrObjectNotFound must be returned by ObjectStore.Get when an object doesn't exist.
+var ErrObjectNotFound = objstore.ErrNotFound
+
+// S3 is an ObjectStore using the S3 API, supported by AWS S3 and by
+// S3-compatible object stores, such as Google Cloud Storage or MinIO.
+// Objects are addressed with path-style URLs: Endpoint/Bucket/key.
+type S3 = objstore.S3
+
+// Azure is an ObjectStore using the Azure Blob Storage API.
+// Objects are stored as block blobs of the container, and requests
+// are signed with the key of the storage account.
+type Azure = objstore.Azure
+
+// BackupToObjectStore writes a backup of an on-disk database to the object store,
+// like BackupIncremental, under the given prefix.
+// The backup is uploaded in parts, followed by a manifest listing the parts
+// and their checksums: backups without a manifest are incomplete.
+// It returns the manifest of the backup, to pass to the next incremental backup.
+func (db *DB) BackupToObjectStore(ctx context.Context, store ObjectStore, prefix string, since *BackupManifest) (*BackupManifest, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+	var manifest *BackupManifest
+	var berr error
+	go func() {
+		defer close(done)
+
+		manifest, berr = db.BackupIncremental(ctx, pw, since)
+		pw.CloseWithError(berr)
+	}()
+
+	_, err := objstore.Upload(ctx, store, prefix, pr, objstore.DefaultPartSize)
+	// stop the backup if the upload failed
+	pr.CloseWithError(err)
+	cancel()
+	<-done
+
+	if berr != nil {
+		return nil, berr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// RestoreFromObjectStore creates a database at the given path, which must not exist,
+// from the backups stored under the given prefixes, like RestoreBackup:
+// a full backup followed by the incremental backups made after it, in order.
+// The parts of each backup are verified using the checksums of its manifest.
+func RestoreFromObjectStore(ctx context.Context, store ObjectStore, path string, prefixes ...string) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	backups := make([]io.Reader, len(prefixes))
+	for i, prefix := range prefixes {
+		rc, err := objstore.Download(ctx, store, prefix)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+
+		backups[i] = rc
+	}
+
+	return RestoreBackup(path, backups...)
+}
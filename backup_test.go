@@ -0,0 +1,402 @@
+package chai_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/kv"
+	"github.com/chaisql/chai/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackup(t *testing.T) {
+	db, err := chai.Open(filepath.Join(t.TempDir(), "db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test(a INTEGER PRIMARY KEY, b TEXT);
+		CREATE INDEX test_b ON test(b);
+		INSERT INTO test (a, b) VALUES (1, 'foo'), (2, 'bar');
+	`)
+	require.NoError(t, err)
+
+	// changes of uncommitted transactions are not part of the backup
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+	tx, err := conn.Begin(true)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	err = tx.Exec("INSERT INTO test (a, b) VALUES (3, 'baz')")
+	require.NoError(t, err)
+
+	requireBackup := func(t *testing.T, path string) {
+		t.Helper()
+
+		bdb, err := chai.Open(path)
+		require.NoError(t, err)
+		defer bdb.Close()
+
+		r, err := bdb.QueryRow("SELECT COUNT(*) AS n FROM test")
+		require.NoError(t, err)
+		testutil.RequireJSONEq(t, r, `{"n": 2}`)
+
+		r, err = bdb.QueryRow("SELECT a FROM test WHERE b = 'bar'")
+		require.NoError(t, err)
+		testutil.RequireJSONEq(t, r, `{"a": 2}`)
+	}
+
+	t.Run("Archive", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := db.Backup(context.Background(), &buf)
+		require.NoError(t, err)
+
+		dir := filepath.Join(t.TempDir(), "restored")
+		err = chai.RestoreBackup(dir, &buf)
+		require.NoError(t, err)
+		requireBackup(t, dir)
+	})
+
+	t.Run("Statement", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "backup")
+		err := db.Exec("BACKUP TO ?", path)
+		require.Error(t, err)
+
+		err = db.Exec(fmt.Sprintf("BACKUP TO '%s'", path))
+		require.NoError(t, err)
+		requireBackup(t, path)
+
+		// the directory must not exist
+		err = db.Exec(fmt.Sprintf("BACKUP TO '%s'", path))
+		require.Error(t, err)
+	})
+
+	t.Run("In-memory", func(t *testing.T) {
+		mdb, err := chai.Open(":memory:")
+		require.NoError(t, err)
+		defer mdb.Close()
+
+		err = mdb.Backup(context.Background(), io.Discard)
+		require.Error(t, err)
+	})
+}
+
+func TestIncrementalBackup(t *testing.T) {
+	db, err := chai.Open(filepath.Join(t.TempDir(), "db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	ng := db.DB.Engine.(*kv.PebbleEngine)
+
+	insert := func(from, to int) {
+		t.Helper()
+
+		for i := from; i < to; i++ {
+			err := db.Exec("INSERT INTO test (a) VALUES (?)", i)
+			require.NoError(t, err)
+		}
+
+		// write the rows to sstables
+		err := ng.DB().Flush()
+		require.NoError(t, err)
+	}
+
+	err = db.Exec("CREATE TABLE test(a INTEGER PRIMARY KEY)")
+	require.NoError(t, err)
+	insert(0, 100)
+
+	var full bytes.Buffer
+	m1, err := db.BackupIncremental(context.Background(), &full, nil)
+	require.NoError(t, err)
+
+	m, err := chai.ReadBackupManifest(bytes.NewReader(full.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, m1, m)
+
+	insert(100, 200)
+
+	var inc1 bytes.Buffer
+	m2, err := db.BackupIncremental(context.Background(), &inc1, m1)
+	require.NoError(t, err)
+
+	insert(200, 300)
+
+	var inc2 bytes.Buffer
+	_, err = db.BackupIncremental(context.Background(), &inc2, m2)
+	require.NoError(t, err)
+
+	// the sstables of the previous backups are not written again
+	var sst int
+	for _, f := range m2.Files {
+		if strings.HasSuffix(f.Name, ".sst") {
+			sst++
+		}
+	}
+	require.NotZero(t, sst)
+	tr := tar.NewReader(bytes.NewReader(inc2.Bytes()))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		if strings.HasSuffix(hdr.Name, ".sst") {
+			require.NotContains(t, m2.Files, chai.BackupFile{Name: hdr.Name, Size: hdr.Size})
+		}
+	}
+
+	restore := func(t *testing.T, n int, backups ...*bytes.Buffer) {
+		t.Helper()
+
+		readers := make([]io.Reader, len(backups))
+		for i, b := range backups {
+			readers[i] = bytes.NewReader(b.Bytes())
+		}
+
+		path := filepath.Join(t.TempDir(), "restored")
+		err := chai.RestoreBackup(path, readers...)
+		require.NoError(t, err)
+
+		rdb, err := chai.Open(path)
+		require.NoError(t, err)
+		defer rdb.Close()
+
+		r, err := rdb.QueryRow("SELECT COUNT(*) AS n FROM test")
+		require.NoError(t, err)
+		testutil.RequireJSONEq(t, r, fmt.Sprintf(`{"n": %d}`, n))
+	}
+
+	restore(t, 100, &full)
+	restore(t, 200, &full, &inc1)
+	restore(t, 300, &full, &inc1, &inc2)
+
+	// incremental backups cannot be restored without the previous ones
+	err = chai.RestoreBackup(filepath.Join(t.TempDir(), "restored"), bytes.NewReader(inc2.Bytes()))
+	require.Error(t, err)
+}
+
+func TestOpenBackup(t *testing.T) {
+	db, err := chai.OpenWith(filepath.Join(t.TempDir(), "db"), &chai.Options{BlobThreshold: 1024})
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test(a INTEGER PRIMARY KEY, b TEXT);
+		CREATE INDEX test_b ON test(b);
+		INSERT INTO test (a, b) VALUES (1, 'foo'), (2, 'bar');
+		INSERT INTO test (a, b) VALUES (3, ?);
+	`, strings.Repeat("x", 4096))
+	require.NoError(t, err)
+	err = db.DB.Engine.(*kv.PebbleEngine).DB().Flush()
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	backup := func(name string, since *chai.BackupManifest) (string, *chai.BackupManifest) {
+		t.Helper()
+
+		var buf bytes.Buffer
+		m, err := db.BackupIncremental(context.Background(), &buf, since)
+		require.NoError(t, err)
+
+		p := filepath.Join(dir, name)
+		err = os.WriteFile(p, buf.Bytes(), 0600)
+		require.NoError(t, err)
+		return p, m
+	}
+
+	full, m := backup("full.tar", nil)
+	err = db.Exec("INSERT INTO test (a, b) VALUES (4, 'baz')")
+	require.NoError(t, err)
+	inc, _ := backup("inc.tar", m)
+	data, err := os.ReadFile(full)
+	require.NoError(t, err)
+
+	bdb, err := chai.OpenBackup(context.Background(), full, inc)
+	require.NoError(t, err)
+
+	r, err := bdb.QueryRow("SELECT COUNT(*) AS n FROM test")
+	require.NoError(t, err)
+	testutil.RequireJSONEq(t, r, `{"n": 4}`)
+
+	r, err = bdb.QueryRow("SELECT a FROM test WHERE b = 'baz'")
+	require.NoError(t, err)
+	testutil.RequireJSONEq(t, r, `{"a": 4}`)
+
+	// large values are read from the blob log of the backup
+	r, err = bdb.QueryRow("SELECT LENGTH(b) AS n FROM test WHERE a = 3")
+	require.NoError(t, err)
+	testutil.RequireJSONEq(t, r, `{"n": 4096}`)
+
+	err = bdb.Exec("INSERT INTO test (a, b) VALUES (5, 'qux')")
+	require.ErrorIs(t, err, chai.ErrReadOnly)
+
+	err = bdb.Close()
+	require.NoError(t, err)
+
+	// the backups are not modified
+	after, err := os.ReadFile(full)
+	require.NoError(t, err)
+	require.Equal(t, data, after)
+
+	bdb, err = chai.OpenBackup(context.Background(), full)
+	require.NoError(t, err)
+	r, err = bdb.QueryRow("SELECT COUNT(*) AS n FROM test")
+	require.NoError(t, err)
+	testutil.RequireJSONEq(t, r, `{"n": 3}`)
+	require.NoError(t, bdb.Close())
+
+	// incremental backups cannot be opened without the previous ones
+	_, err = chai.OpenBackup(context.Background(), inc)
+	require.Error(t, err)
+}
+
+func TestRestoreToTime(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "archive")
+
+	db, err := chai.OpenWith(filepath.Join(dir, "db"), &chai.Options{WALArchiveDir: archive})
+	require.NoError(t, err)
+
+	insert := func(from, to int) {
+		t.Helper()
+
+		for i := from; i < to; i++ {
+			err := db.Exec("INSERT INTO test (a) VALUES (?)", i)
+			require.NoError(t, err)
+		}
+	}
+
+	err = db.Exec("CREATE TABLE test(a INTEGER PRIMARY KEY)")
+	require.NoError(t, err)
+	insert(0, 10)
+
+	var full bytes.Buffer
+	err = db.Backup(context.Background(), &full)
+	require.NoError(t, err)
+	beforeBackup := time.Now().Add(-time.Hour)
+
+	insert(10, 20)
+	beforeDelete := time.Now()
+	time.Sleep(time.Millisecond)
+
+	err = db.Exec("DELETE FROM test")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	restore := func(t *testing.T, until time.Time, n int) time.Time {
+		t.Helper()
+
+		path := filepath.Join(t.TempDir(), "restored")
+		last, err := chai.RestoreToTime(path, archive, until, bytes.NewReader(full.Bytes()))
+		require.NoError(t, err)
+
+		rdb, err := chai.Open(path)
+		require.NoError(t, err)
+		defer rdb.Close()
+
+		r, err := rdb.QueryRow("SELECT COUNT(*) AS n FROM test")
+		require.NoError(t, err)
+		testutil.RequireJSONEq(t, r, fmt.Sprintf(`{"n": %d}`, n))
+		return last
+	}
+
+	last := restore(t, beforeDelete, 20)
+	require.False(t, last.After(beforeDelete))
+	require.False(t, last.IsZero())
+
+	restore(t, time.Now(), 0)
+
+	// commits made before the backup are not replayed
+	last = restore(t, beforeBackup, 10)
+	require.True(t, last.IsZero())
+}
+
+type memObjectStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (m *memObjectStore) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.objects == nil {
+		m.objects = make(map[string][]byte)
+	}
+	m.objects[key] = data
+	return nil
+}
+
+func (m *memObjectStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	data, ok := m.objects[key]
+	if !ok {
+		return nil, chai.ErrObjectNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func TestObjectStoreBackup(t *testing.T) {
+	db, err := chai.Open(filepath.Join(t.TempDir(), "db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test(a INTEGER PRIMARY KEY);
+		INSERT INTO test (a) VALUES (1), (2);
+	`)
+	require.NoError(t, err)
+
+	var store memObjectStore
+	m, err := db.BackupToObjectStore(context.Background(), &store, "backups/full", nil)
+	require.NoError(t, err)
+	require.Contains(t, store.objects, "backups/full/manifest.json")
+
+	err = db.Exec("INSERT INTO test (a) VALUES (3)")
+	require.NoError(t, err)
+
+	_, err = db.BackupToObjectStore(context.Background(), &store, "backups/inc", m)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "restored")
+	err = chai.RestoreFromObjectStore(context.Background(), &store, path, "backups/full", "backups/inc")
+	require.NoError(t, err)
+
+	rdb, err := chai.Open(path)
+	require.NoError(t, err)
+	defer rdb.Close()
+
+	r, err := rdb.QueryRow("SELECT COUNT(*) AS n FROM test")
+	require.NoError(t, err)
+	testutil.RequireJSONEq(t, r, `{"n": 3}`)
+
+	// corrupted backups are rejected
+	for k, v := range store.objects {
+		if strings.HasPrefix(k, "backups/full/part-") {
+			v[len(v)/2]++
+		}
+	}
+	err = chai.RestoreFromObjectStore(context.Background(), &store, filepath.Join(t.TempDir(), "restored"), "backups/full")
+	require.Error(t, err)
+
+	// incomplete backups are rejected
+	err = chai.RestoreFromObjectStore(context.Background(), &store, filepath.Join(t.TempDir(), "restored"), "backups/missing")
+	require.Error(t, err)
+}
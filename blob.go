@@ -0,0 +1,77 @@
+package chai
+
+import (
+	"io"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// WriteBlob sets the BLOB column of the row of the table whose primary key is pk
+// to the content of r. If the database stores large values outside of the rows
+// (see Options.BlobThreshold), the content is streamed to disk without being held
+// in memory. Otherwise, it is read entirely and stored in the row.
+// The column can't be part of the primary key, of an index or of a CHECK constraint.
+func (tx *Tx) WriteBlob(table, column string, r io.Reader, pk ...any) error {
+	t, key, err := tx.blobRow(table, pk)
+	if err != nil {
+		return err
+	}
+	if !t.Tx.Writable {
+		return errors.New("cannot write a blob in a read-only transaction")
+	}
+
+	return t.WriteBlob(key, column, r)
+}
+
+// OpenBlob returns a reader of the BLOB or TEXT column of the row of the table
+// whose primary key is pk. Values stored outside of the rows (see Options.BlobThreshold)
+// are read in chunks, without being held in memory. The reader remains valid
+// until the database is closed.
+func (tx *Tx) OpenBlob(table, column string, pk ...any) (io.Reader, error) {
+	t, key, err := tx.blobRow(table, pk)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.OpenBlob(key, column)
+}
+
+// blobRow returns the table and the key of the row whose primary key is pk.
+func (tx *Tx) blobRow(table string, pk []any) (*database.Table, *tree.Key, error) {
+	t := tx.conn.Conn.GetTx()
+	if t == nil {
+		return nil, nil, errors.New("transaction has already been committed or rolled back")
+	}
+
+	tb, err := t.Catalog.GetTable(t, table)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info := tb.Info.PrimaryKey
+	if info == nil {
+		return nil, nil, errors.Errorf("table %s has no primary key", table)
+	}
+	if len(pk) != len(info.Columns) {
+		return nil, nil, errors.Errorf("table %s has a primary key of %d columns, got %d values", table, len(info.Columns), len(pk))
+	}
+
+	values := make([]types.Value, len(pk))
+	for i, x := range pk {
+		v, err := row.NewValue(x)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		values[i], err = types.CastIn(v, info.Types[i], t.Location())
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "primary key column %s", info.Columns[i])
+		}
+	}
+
+	return tb, tree.NewKey(values...), nil
+}
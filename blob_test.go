@@ -0,0 +1,183 @@
+package chai_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlobThreshold(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "db")
+	opts := chai.Options{BlobThreshold: 1024}
+
+	db, err := chai.OpenWith(path, &opts)
+	require.NoError(t, err)
+
+	large := strings.Repeat("a", 10000)
+	err = db.Exec("CREATE TABLE test(a INTEGER PRIMARY KEY, b TEXT, c TEXT)")
+	require.NoError(t, err)
+	err = db.Exec("INSERT INTO test (a, b, c) VALUES (1, ?, 'small')", large)
+	require.NoError(t, err)
+
+	var b, c string
+	r, err := db.QueryRow("SELECT b, c FROM test WHERE a = 1")
+	require.NoError(t, err)
+	require.NoError(t, r.Scan(&b, &c))
+	require.Equal(t, large, b)
+	require.Equal(t, "small", c)
+
+	// the large value is stored in the blob log
+	segments, err := filepath.Glob(filepath.Join(path, "blobs", "*.blob"))
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+	fi, err := os.Stat(segments[0])
+	require.NoError(t, err)
+	require.EqualValues(t, len(large), fi.Size())
+
+	// updating another column keeps the value
+	err = db.Exec("UPDATE test SET c = 'other' WHERE a = 1")
+	require.NoError(t, err)
+	err = db.Exec("UPDATE test SET b = b || 'b' WHERE a = 1")
+	require.NoError(t, err)
+	large += "b"
+
+	var buf bytes.Buffer
+	err = db.Backup(context.Background(), &buf)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	check := func(t *testing.T, db *chai.DB) {
+		t.Helper()
+
+		var b, c string
+		r, err := db.QueryRow("SELECT b, c FROM test WHERE a = 1")
+		require.NoError(t, err)
+		require.NoError(t, r.Scan(&b, &c))
+		require.Equal(t, large, b)
+		require.Equal(t, "other", c)
+	}
+
+	t.Run("Reopen", func(t *testing.T) {
+		// existing blobs can be read without the option
+		db, err := chai.Open(path)
+		require.NoError(t, err)
+		defer db.Close()
+
+		check(t, db)
+	})
+
+	t.Run("Restore", func(t *testing.T) {
+		restored := filepath.Join(dir, "restored")
+		err := chai.RestoreBackup(restored, &buf)
+		require.NoError(t, err)
+
+		db, err := chai.Open(restored)
+		require.NoError(t, err)
+		defer db.Close()
+
+		check(t, db)
+	})
+
+	t.Run("Memory", func(t *testing.T) {
+		_, err := chai.OpenWith(":memory:", &opts)
+		require.Error(t, err)
+	})
+}
+
+func TestBlobStream(t *testing.T) {
+	test := func(t *testing.T, db *chai.DB) {
+		t.Helper()
+
+		err := db.Exec("CREATE TABLE test(a INTEGER, b TEXT, c BLOB, d BLOB, PRIMARY KEY (a, b))")
+		require.NoError(t, err)
+		err = db.Exec("CREATE INDEX on test(d)")
+		require.NoError(t, err)
+		err = db.Exec("INSERT INTO test (a, b) VALUES (1, 'x')")
+		require.NoError(t, err)
+
+		large := bytes.Repeat([]byte("0123456789"), 500_000)
+
+		conn, err := db.Connect()
+		require.NoError(t, err)
+		defer conn.Close()
+
+		tx, err := conn.Begin(true)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		err = tx.WriteBlob("test", "c", bytes.NewReader(large), 1, "x")
+		require.NoError(t, err)
+
+		// invalid targets
+		err = tx.WriteBlob("test", "b", bytes.NewReader(large), 1, "x")
+		require.Error(t, err)
+		err = tx.WriteBlob("test", "d", bytes.NewReader(large), 1, "x")
+		require.Error(t, err)
+		err = tx.WriteBlob("test", "c", bytes.NewReader(large), 1)
+		require.Error(t, err)
+		err = tx.WriteBlob("test", "c", bytes.NewReader(large), 2, "x")
+		require.Error(t, err)
+		_, err = tx.OpenBlob("test", "d", 1, "x")
+		require.Error(t, err)
+
+		require.NoError(t, tx.Commit())
+
+		tx, err = conn.Begin(false)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		r, err := tx.OpenBlob("test", "c", int64(1), "x")
+		require.NoError(t, err)
+		got, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, large, got)
+
+		r, err = tx.OpenBlob("test", "b", 1, "x")
+		require.NoError(t, err)
+		got, err = io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, "x", string(got))
+
+		err = tx.WriteBlob("test", "c", bytes.NewReader(large), 1, "x")
+		require.Error(t, err)
+
+		row, err := db.QueryRow("SELECT c FROM test WHERE a = 1")
+		require.NoError(t, err)
+		var c []byte
+		require.NoError(t, row.Scan(&c))
+		require.Equal(t, large, c)
+	}
+
+	t.Run("Memory", func(t *testing.T) {
+		db, err := chai.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		test(t, db)
+	})
+
+	t.Run("External", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "db")
+		db, err := chai.OpenWith(path, &chai.Options{BlobThreshold: 1024})
+		require.NoError(t, err)
+		defer db.Close()
+
+		test(t, db)
+
+		// the value was streamed to the blob log
+		segments, err := filepath.Glob(filepath.Join(path, "blobs", "*.blob"))
+		require.NoError(t, err)
+		require.Len(t, segments, 1)
+		fi, err := os.Stat(segments[0])
+		require.NoError(t, err)
+		require.EqualValues(t, 5_000_000, fi.Size())
+	})
+}
@@ -0,0 +1,249 @@
+package chai
+
+import (
+	"context"
+	"iter"
+	"reflect"
+	"strings"
+
+	"github.com/chaisql/chai/internal/query"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/stringutil"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// DefaultBulkInsertBatchSize is the number of rows inserted by each transaction
+// of BulkInsert, if BulkInsertOptions.BatchSize is not set,
+// and of the INSERT INTO ... FROM READER statements.
+const DefaultBulkInsertBatchSize = query.BulkInsertBatchSize
+
+// BulkInsertOptions configures how rows are inserted by BulkInsert.
+type BulkInsertOptions struct {
+	// Maximum number of rows inserted by each transaction.
+	// If zero, DefaultBulkInsertBatchSize is used.
+	BatchSize int
+
+	// If set, Progress is called after each committed transaction
+	// with the number of rows inserted so far.
+	Progress func(inserted int)
+
+	// If true, the indexes of the table that are not unique aren't updated
+	// while the rows are inserted, and are rebuilt once all the rows are
+	// inserted, or once the insertion fails. Until then, queries using these
+	// indexes don't see the rows inserted by BulkInsert.
+	// If they can't be rebuilt, for instance because ctx is done or the
+	// process stops, they are rebuilt the next time the database is opened.
+	// Unique indexes are always updated, to enforce their constraint.
+	DeferIndexes bool
+}
+
+// BulkInsert inserts the rows into the table in several transactions,
+// each of them inserting at most opts.BatchSize rows.
+// The rows can be structs or pointers to structs, whose fields are mapped
+// to columns like with Row.StructScan, maps with string keys, or rows
+// returned by queries.
+// It returns the number of inserted rows. If the insertion fails, the rows
+// inserted by the transactions committed before the failure are kept.
+// The queries stop when ctx is done.
+// Rows encoded as a sequence of JSON objects can be inserted from an io.Reader
+// passed as parameter with the INSERT INTO table FROM READER ? statement.
+func BulkInsert[T any](ctx context.Context, db *DB, table string, rows iter.Seq[T], opts *BulkInsertOptions) (inserted int, err error) {
+	if opts == nil {
+		opts = new(BulkInsertOptions)
+	}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBulkInsertBatchSize
+	}
+
+	var deferred []string
+	if opts.DeferIndexes {
+		catalog := db.DB.Catalog()
+		if _, err := catalog.GetTableInfo(table); err != nil {
+			return 0, err
+		}
+
+		for _, name := range catalog.ListIndexes(table) {
+			info, err := catalog.GetIndexInfo(name)
+			if err != nil {
+				return 0, err
+			}
+			if !info.Unique {
+				deferred = append(deferred, name)
+			}
+		}
+
+		defer func() {
+			if inserted == 0 {
+				return
+			}
+
+			for _, name := range deferred {
+				rerr := db.DB.ReIndex(ctx, name, nil)
+				if rerr != nil {
+					err = errors.CombineErrors(err, errors.Wrapf(rerr, "failed to rebuild index %s", name))
+				}
+			}
+		}()
+	}
+
+	conn, err := db.Connect()
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	// statements are prepared for each set of columns
+	stmts := make(map[string]*Statement)
+	defer func() {
+		for _, stmt := range stmts {
+			if cerr := stmt.Close(); err == nil {
+				err = cerr
+			}
+		}
+	}()
+
+	var tx *Tx
+	var n int
+	commit := func() error {
+		err := tx.Commit()
+		tx = nil
+		if err != nil {
+			return err
+		}
+
+		inserted += n
+		n = 0
+		if opts.Progress != nil {
+			opts.Progress(inserted)
+		}
+		return nil
+	}
+	defer func() {
+		if tx != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	for r := range rows {
+		columns, values, err := bulkRow(r)
+		if err != nil {
+			return inserted, err
+		}
+
+		if tx == nil {
+			tx, err = conn.BeginContext(ctx, true)
+			if err != nil {
+				return inserted, err
+			}
+			err = conn.Conn.GetTx().DeferIndexes(deferred...)
+			if err != nil {
+				return inserted, err
+			}
+		}
+
+		key := strings.Join(columns, "\x00")
+		stmt, ok := stmts[key]
+		if !ok {
+			stmt, err = conn.Prepare(insertQuery(table, columns))
+			if err != nil {
+				return inserted, err
+			}
+			stmts[key] = stmt
+		}
+
+		err = stmt.ExecContext(ctx, values...)
+		if err != nil {
+			return inserted, err
+		}
+
+		n++
+		if n == batchSize {
+			if err := commit(); err != nil {
+				return inserted, err
+			}
+		}
+	}
+
+	if tx != nil {
+		if err := commit(); err != nil {
+			return inserted, err
+		}
+	}
+
+	return inserted, nil
+}
+
+// bulkRow returns the columns and values of a row passed to BulkInsert.
+// The columns of maps are sorted, so that rows with the same columns
+// use the same statement.
+func bulkRow(x any) ([]string, []any, error) {
+	var r row.Row
+	switch t := x.(type) {
+	case *Row:
+		r = t.Row
+	case row.Row:
+		r = t
+	default:
+		ref := reflect.Indirect(reflect.ValueOf(x))
+		if ref.Kind() != reflect.Map {
+			var err error
+			r, err = row.NewFromStruct(x)
+			if err != nil {
+				return nil, nil, err
+			}
+			break
+		}
+		if ref.Type().Key().Kind() != reflect.String {
+			return nil, nil, errors.Errorf("cannot insert map of type %s: keys must be strings", ref.Type())
+		}
+
+		cb := row.NewColumnBuffer()
+		for it := ref.MapRange(); it.Next(); {
+			v, err := row.NewValue(it.Value().Interface())
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "column %s", it.Key().String())
+			}
+			cb.Add(it.Key().String(), v)
+		}
+		r = row.SortColumns(cb)
+	}
+
+	var columns []string
+	var values []any
+	err := r.Iterate(func(column string, v types.Value) error {
+		columns = append(columns, column)
+		values = append(values, v)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return columns, values, nil
+}
+
+func insertQuery(table string, columns []string) string {
+	var sb strings.Builder
+
+	sb.WriteString("INSERT INTO ")
+	sb.WriteString(stringutil.NormalizeIdentifier(table, '`'))
+	sb.WriteString(" (")
+	for i, c := range columns {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(stringutil.NormalizeIdentifier(c, '`'))
+	}
+	sb.WriteString(") VALUES (")
+	for i := range columns {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteByte('?')
+	}
+	sb.WriteByte(')')
+
+	return sb.String()
+}
@@ -0,0 +1,151 @@
+package chai_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkInsert(t *testing.T) {
+	type user struct {
+		ID   int
+		Name string
+		Age  int `chai:"age,omitempty"`
+	}
+
+	users := func(n int) iter.Seq[user] {
+		return func(yield func(user) bool) {
+			for i := range n {
+				if !yield(user{ID: i, Name: fmt.Sprintf("user%d", i%10), Age: i % 3}) {
+					return
+				}
+			}
+		}
+	}
+
+	for _, deferIndexes := range []bool{false, true} {
+		t.Run(fmt.Sprintf("DeferIndexes=%v", deferIndexes), func(t *testing.T) {
+			db, err := chai.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec(`
+				CREATE TABLE test(id INT PRIMARY KEY, name TEXT, age INT DEFAULT 100);
+				CREATE INDEX test_name ON test(name);
+			`)
+			require.NoError(t, err)
+
+			var progress []int
+			n, err := chai.BulkInsert(context.Background(), db, "test", users(2500), &chai.BulkInsertOptions{
+				BatchSize:    1000,
+				Progress:     func(inserted int) { progress = append(progress, inserted) },
+				DeferIndexes: deferIndexes,
+			})
+			require.NoError(t, err)
+			require.Equal(t, 2500, n)
+			require.Equal(t, []int{1000, 2000, 2500}, progress)
+
+			// the index contains all the rows
+			r, err := db.QueryRow("SELECT COUNT(*) FROM test WHERE name = 'user3'")
+			require.NoError(t, err)
+			var count int
+			require.NoError(t, r.Scan(&count))
+			require.Equal(t, 250, count)
+
+			// omitted columns use their default value
+			r, err = db.QueryRow("SELECT COUNT(*) FROM test WHERE age = 100")
+			require.NoError(t, err)
+			require.NoError(t, r.Scan(&count))
+			require.Equal(t, 834, count)
+
+			// maps
+			n, err = chai.BulkInsert(context.Background(), db, "test", slices.Values([]map[string]any{
+				{"id": 5000, "name": "user3"},
+			}), nil)
+			require.NoError(t, err)
+			require.Equal(t, 1, n)
+
+			// the committed batches are kept when the insertion fails
+			// and the deferred indexes are rebuilt
+			rows := func(yield func(user) bool) {
+				for i := 10000; i < 10003; i++ {
+					if !yield(user{ID: i, Name: "user3"}) {
+						return
+					}
+				}
+				yield(user{ID: 0})
+			}
+			n, err = chai.BulkInsert(context.Background(), db, "test", rows, &chai.BulkInsertOptions{
+				BatchSize:    2,
+				DeferIndexes: deferIndexes,
+			})
+			require.Error(t, err)
+			require.Equal(t, 2, n)
+
+			r, err = db.QueryRow("SELECT COUNT(*) FROM test WHERE name = 'user3'")
+			require.NoError(t, err)
+			require.NoError(t, r.Scan(&count))
+			require.Equal(t, 253, count)
+		})
+	}
+}
+
+func TestInsertFromReader(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE test(a INT PRIMARY KEY, b TEXT DEFAULT 'x')")
+	require.NoError(t, err)
+
+	count := func() int {
+		r, err := db.QueryRow("SELECT COUNT(*) FROM test")
+		require.NoError(t, err)
+		var n int
+		require.NoError(t, r.Scan(&n))
+		return n
+	}
+
+	err = db.Exec("INSERT INTO test FROM READER ?", strings.NewReader(`{"a": 1} {"a": 2, "b": "y"}`))
+	require.NoError(t, err)
+	require.Equal(t, 2, count())
+
+	r, err := db.QueryRow("SELECT b FROM test WHERE a = 1")
+	require.NoError(t, err)
+	var b string
+	require.NoError(t, r.Scan(&b))
+	require.Equal(t, "x", b)
+
+	err = db.Exec("INSERT INTO test FROM READER $r", sql.Named("r", strings.NewReader(`{"a": 3}`)))
+	require.NoError(t, err)
+	require.Equal(t, 3, count())
+
+	// the rows are inserted by the transaction
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+	tx, err := conn.Begin(true)
+	require.NoError(t, err)
+	err = tx.Exec("INSERT INTO test FROM READER ?", strings.NewReader(`{"a": 4}`))
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback())
+	require.Equal(t, 3, count())
+
+	// invalid rows and parameters
+	err = db.Exec("INSERT INTO test FROM READER ?", strings.NewReader(`{"a": 5} {"a": 1}`))
+	require.Error(t, err)
+	require.Equal(t, 3, count())
+
+	err = db.Exec("INSERT INTO test FROM READER ?", strings.NewReader(`[1]`))
+	require.Error(t, err)
+
+	err = db.Exec("INSERT INTO test FROM READER ?", `{"a": 6}`)
+	require.Error(t, err)
+}
@@ -24,6 +24,10 @@ func NewApp() *cli.App {
 		NewRestoreCommand(),
 		NewBenchCommand(),
 		NewPebbleCommand(),
+		NewUpgradeCommand(),
+		NewArchiveCommand(),
+		NewCheckCommand(),
+		NewQueryCommand(),
 	}
 
 	// inject cancelable context to all commands (except the shell command)
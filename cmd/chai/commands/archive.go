@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/chaisql/chai/cmd/chai/dbutil"
+	"github.com/cockroachdb/errors"
+	"github.com/urfave/cli/v2"
+)
+
+// NewArchiveCommand returns a cli.Command for "chai archive".
+func NewArchiveCommand() *cli.Command {
+	cmd := cli.Command{
+		Name:      "archive",
+		Usage:     "Move expired rows of a table to an archive table",
+		UsageText: `chai archive [options] dbpath`,
+		Description: `The archive command moves the rows matching the --where expression
+from a table to an archive table, instead of deleting them.
+
+	$ chai archive -t events --to events_archive --where "created_at < '2024-01-01'" my.db
+
+The archive table must already exist. Rows are moved in batches (1000 by default, --batch-size option),
+each batch is moved in its own transaction. The progress is printed after each batch.`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "table",
+				Aliases:  []string{"t"},
+				Usage:    "name of the table containing the rows to archive.",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:     "to",
+				Usage:    "name of the archive table.",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:     "where",
+				Usage:    "expression selecting the expired rows.",
+				Required: true,
+			},
+			&cli.IntFlag{
+				Name:  "batch-size",
+				Usage: "maximum number of rows moved per transaction.",
+				Value: dbutil.DefaultArchiveBatchSize,
+			},
+		},
+	}
+
+	cmd.Action = func(c *cli.Context) error {
+		dbPath := c.Args().First()
+		if dbPath == "" {
+			return errors.New(cmd.UsageText)
+		}
+
+		db, err := dbutil.OpenDB(c.Context, dbPath)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		p, err := dbutil.Archive(c.Context, db, dbutil.ArchiveOptions{
+			Table:        c.String("table"),
+			ArchiveTable: c.String("to"),
+			Where:        c.String("where"),
+			BatchSize:    c.Int("batch-size"),
+			Progress: func(p dbutil.ArchiveProgress) {
+				fmt.Fprintf(os.Stderr, "batch %d: %d rows moved\n", p.Batches, p.Moved)
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%d rows moved to %s\n", p.Moved, c.String("to"))
+		return nil
+	}
+
+	return &cmd
+}
@@ -0,0 +1,117 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/cmd/chai/dbutil"
+	"github.com/cockroachdb/errors"
+	"github.com/urfave/cli/v2"
+)
+
+// NewCheckCommand returns a cli.Command for "chai check".
+func NewCheckCommand() *cli.Command {
+	cmd := cli.Command{
+		Name:      "check",
+		Usage:     "Check the integrity of the tables and indexes of a database",
+		UsageText: `chai check [options] dbpath`,
+		Description: `The check command decodes every row of every table and verifies that
+every index contains exactly the entries built from the rows of its table.
+
+	$ chai check my.db
+
+With --repair, the indexes failing the check are rebuilt. Corrupted rows
+are reported but never modified. The command fails if problems remain.`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "repair",
+				Usage: "rebuild the damaged indexes.",
+			},
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "print the report as JSON.",
+			},
+		},
+	}
+
+	cmd.Action = func(c *cli.Context) error {
+		dbPath := c.Args().First()
+		if dbPath == "" {
+			return errors.New(cmd.UsageText)
+		}
+
+		db, err := dbutil.OpenDB(c.Context, dbPath)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		report, err := db.Verify(c.Context, &chai.VerifyOptions{Repair: c.Bool("repair")})
+		if err != nil {
+			return err
+		}
+
+		if c.Bool("json") {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(report)
+		} else {
+			err = printVerifyReport(os.Stdout, report)
+		}
+		if err != nil {
+			return err
+		}
+
+		if !report.OK() {
+			return errors.New("the database is corrupted")
+		}
+		return nil
+	}
+
+	return &cmd
+}
+
+func printVerifyReport(w io.Writer, report *chai.VerifyReport) error {
+	for _, t := range report.Tables {
+		status := "ok"
+		if t.Corrupted > 0 {
+			status = fmt.Sprintf("%d corrupted rows", t.Corrupted)
+		}
+		_, err := fmt.Fprintf(w, "table %s: %d rows, %s\n", t.Name, t.Rows, status)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range t.CorruptedRows {
+			_, err = fmt.Fprintf(w, "  key %s: %s\n", r.Key, r.Error)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, idx := range report.Indexes {
+		var status string
+		switch {
+		case idx.Skipped:
+			status = "skipped, the table has corrupted rows"
+		case idx.OK():
+			status = "ok"
+		default:
+			status = fmt.Sprintf("%d missing, %d dangling entries", idx.Missing, idx.Dangling)
+			if idx.Rebuilt {
+				status += ", rebuilt"
+			}
+		}
+
+		_, err := fmt.Fprintf(w, "index %s on %s: %d entries, %s\n", idx.Name, idx.Table, idx.Entries, status)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
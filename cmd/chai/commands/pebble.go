@@ -3,6 +3,7 @@ package commands
 import (
 	"github.com/chaisql/chai/cmd/chai/dbutil"
 	"github.com/chaisql/chai/internal/kv"
+	"github.com/cockroachdb/errors"
 	"github.com/urfave/cli/v2"
 )
 
@@ -36,7 +37,11 @@ func NewPebbleCommand() *cli.Command {
 		}
 		defer db.Close()
 
-		ng := db.DB.Engine.(*kv.PebbleEngine)
+		ng, ok := db.DB.Engine.(*kv.PebbleEngine)
+		if !ok {
+			return errors.New("the database is not stored in Pebble")
+		}
+
 		return dbutil.DumpPebble(c.Context, ng.DB(), dbutil.DumpPebbleOptions{
 			KeysOnly: c.Bool("keys-only"),
 		})
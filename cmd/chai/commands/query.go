@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"os"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/cmd/chai/dbutil"
+	"github.com/cockroachdb/errors"
+	"github.com/urfave/cli/v2"
+)
+
+// NewQueryCommand returns a cli.Command for "chai query".
+func NewQueryCommand() *cli.Command {
+	cmd := cli.Command{
+		Name:      "query",
+		Usage:     "Run a read-only query against a database or a backup",
+		UsageText: `chai query [options] [dbPath] query`,
+		Description: `The query command runs a query in a read-only transaction and prints the results as JSON.
+
+	$ chai query mydb "SELECT * FROM users WHERE id = 10"
+
+With the --backup option, the query is run against a tar backup of a database.
+An incremental backup is queried by repeating the option, starting with the full backup
+it depends on. The backups are read in place, nothing is written to disk.
+
+	$ chai query --backup full.tar --backup incr.tar "SELECT * FROM users WHERE id = 10"`,
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:    "backup",
+				Aliases: []string{"b"},
+				Usage:   "tar backup to query instead of a database, can be repeated for incremental backups.",
+			},
+		},
+	}
+
+	cmd.Action = func(c *cli.Context) error {
+		var db *chai.DB
+		var err error
+
+		args := c.Args()
+		backups := c.StringSlice("backup")
+
+		switch {
+		case len(backups) > 0 && args.Len() == 1:
+			db, err = dbutil.OpenBackup(c.Context, backups...)
+		case len(backups) == 0 && args.Len() == 2:
+			db, err = dbutil.OpenDB(c.Context, args.First())
+		default:
+			return errors.New(cmd.UsageText)
+		}
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		return dbutil.QueryReadOnly(c.Context, db, args.Get(args.Len()-1), os.Stdout)
+	}
+
+	return &cmd
+}
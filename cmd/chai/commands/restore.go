@@ -1,6 +1,8 @@
 package commands
 
 import (
+	"os"
+
 	"github.com/chaisql/chai/cmd/chai/dbutil"
 	"github.com/cockroachdb/errors"
 	"github.com/urfave/cli/v2"
@@ -11,17 +13,51 @@ func NewRestoreCommand() (cmd *cli.Command) {
 	return &cli.Command{
 		Name:      "restore",
 		Usage:     "Restore a database from a file created by chai dump",
-		UsageText: `chai restore dumpFile dbPath`,
+		UsageText: `chai restore [options] dumpFile dbPath`,
 		Description: `The restore command can restore a database from a text file.
 
-	$ chai restore dump.sql mydb`,
-		Flags: []cli.Flag{},
+	$ chai restore dump.sql mydb
+
+By default, the restore stops at the first row that can't be inserted.
+The --on-conflict option controls what happens to rows conflicting with existing ones:
+
+	$ chai restore --on-conflict skip dump.sql mydb
+	$ chai restore --on-conflict upsert dump.sql mydb
+	$ chai restore --on-conflict side-table --conflict-table conflicts dump.sql mydb
+
+With any policy other than fail, a summary of inserted, skipped and failed rows
+is printed once the restore is done.`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "on-conflict",
+				Usage: "conflict policy: fail, skip, upsert or side-table.",
+				Value: string(dbutil.ConflictFail),
+			},
+			&cli.StringFlag{
+				Name:  "conflict-table",
+				Usage: "name of the table receiving conflicting rows when using the side-table policy.",
+				Value: dbutil.DefaultConflictTable,
+			},
+		},
 		Action: func(c *cli.Context) error {
 			args := c.Args()
 			if args.Len() != 2 {
 				return errors.New(cmd.UsageText)
 			}
-			return dbutil.Restore(c.Context, nil, args.First(), args.Get(args.Len()-1))
+
+			policy, err := dbutil.ParseConflictPolicy(c.String("on-conflict"))
+			if err != nil {
+				return err
+			}
+
+			report, err := dbutil.RestoreWithOptions(c.Context, nil, args.First(), args.Get(args.Len()-1), dbutil.RestoreOptions{
+				OnConflict:    policy,
+				ConflictTable: c.String("conflict-table"),
+			})
+			if report != nil && policy != dbutil.ConflictFail {
+				_, _ = report.WriteTo(os.Stderr)
+			}
+			return err
 		},
 	}
 }
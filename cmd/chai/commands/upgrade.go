@@ -0,0 +1,31 @@
+package commands
+
+import (
+	"github.com/chaisql/chai"
+	"github.com/cockroachdb/errors"
+	"github.com/urfave/cli/v2"
+)
+
+// NewUpgradeCommand returns a cli.Command for "chai upgrade".
+func NewUpgradeCommand() *cli.Command {
+	cmd := cli.Command{
+		Name:      "upgrade",
+		Usage:     "Migrate a database to the format used by this version of Chai",
+		UsageText: `chai upgrade dbPath`,
+		Description: `The upgrade command migrates the catalog and the stored data
+of a database created by an older version of Chai.
+
+	$ chai upgrade mydb`,
+	}
+
+	cmd.Action = func(c *cli.Context) error {
+		dbPath := c.Args().First()
+		if dbPath == "" {
+			return errors.New(cmd.UsageText)
+		}
+
+		return chai.Upgrade(dbPath)
+	}
+
+	return &cmd
+}
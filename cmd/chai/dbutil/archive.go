@@ -0,0 +1,178 @@
+package dbutil
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/stringutil"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// DefaultArchiveBatchSize is the number of rows moved per transaction
+// if ArchiveOptions.BatchSize is not set.
+const DefaultArchiveBatchSize = 1000
+
+// ArchiveOptions describes which rows are expired and where to move them.
+type ArchiveOptions struct {
+	// Table containing the rows to archive.
+	Table string
+	// Table receiving the archived rows. It must already exist
+	// and have columns compatible with Table.
+	ArchiveTable string
+	// SQL expression selecting the expired rows, i.e. "created_at < '2024-01-01'".
+	Where string
+	// Maximum number of rows moved per transaction.
+	BatchSize int
+	// If set, Progress is called after each committed batch.
+	Progress func(ArchiveProgress)
+}
+
+// ArchiveProgress reports the progress of Archive.
+type ArchiveProgress struct {
+	Batches int
+	Moved   int
+}
+
+// Archive moves the rows of a table matching the Where expression into the archive table,
+// instead of deleting them.
+// The table must have a primary key. Rows are moved in batches: the keys of the rows
+// of each batch are selected, then the rows are copied and deleted by key in the same
+// transaction, so that a row is always visible in exactly one of the tables.
+// To move the expired rows of a table automatically, use the archive_table option
+// of CREATE TABLE instead.
+// If the context is canceled, batches that have already been committed are kept.
+func Archive(ctx context.Context, db *chai.DB, opts ArchiveOptions) (ArchiveProgress, error) {
+	var p ArchiveProgress
+
+	if opts.Table == "" {
+		return p, errors.New("table expected")
+	}
+	if opts.ArchiveTable == "" {
+		return p, errors.New("archive table expected")
+	}
+	if opts.Where == "" {
+		return p, errors.New("expiration expression expected")
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultArchiveBatchSize
+	}
+
+	info, err := db.DB.Catalog().GetTableInfo(opts.Table)
+	if err != nil {
+		return p, err
+	}
+	if info.PrimaryKey == nil {
+		return p, errors.Errorf("table %s has no primary key", opts.Table)
+	}
+
+	table := stringutil.NormalizeIdentifier(opts.Table, '`')
+	archive := stringutil.NormalizeIdentifier(opts.ArchiveTable, '`')
+
+	// the keys of the batch are selected once, and the rows
+	// are then moved one by one using their key.
+	pk := make([]string, len(info.PrimaryKey.Columns))
+	conds := make([]string, len(pk))
+	for i, c := range info.PrimaryKey.Columns {
+		pk[i] = stringutil.NormalizeIdentifier(c, '`')
+		conds[i] = pk[i] + " = ?"
+	}
+	byKey := strings.Join(conds, " AND ")
+
+	q := archiveQueries{
+		selectKeys: fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY %s LIMIT %d", strings.Join(pk, ", "), table, opts.Where, strings.Join(pk, ", "), opts.BatchSize),
+		insertRow:  fmt.Sprintf("INSERT INTO %s SELECT * FROM %s WHERE %s", archive, table, byKey),
+		deleteRow:  fmt.Sprintf("DELETE FROM %s WHERE %s", table, byKey),
+	}
+
+	conn, err := db.Connect()
+	if err != nil {
+		return p, err
+	}
+	defer conn.Close()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return p, err
+		}
+
+		n, err := archiveBatch(conn, &q)
+		if err != nil {
+			return p, err
+		}
+		if n == 0 {
+			return p, nil
+		}
+
+		p.Batches++
+		p.Moved += n
+		if opts.Progress != nil {
+			opts.Progress(p)
+		}
+
+		if n < opts.BatchSize {
+			return p, nil
+		}
+	}
+}
+
+// archiveQueries are the queries used to move a batch of rows.
+type archiveQueries struct {
+	// selects the primary keys of the rows of the batch.
+	selectKeys string
+	// copy and delete a row, given its primary key.
+	insertRow string
+	deleteRow string
+}
+
+func archiveBatch(conn *chai.Connection, q *archiveQueries) (int, error) {
+	tx, err := conn.Begin(true)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Query(q.selectKeys)
+	if err != nil {
+		return 0, err
+	}
+
+	var keys [][]any
+	err = res.Iterate(func(r *chai.Row) error {
+		var key []any
+		err := r.Row.Iterate(func(_ string, v types.Value) error {
+			key = append(key, v)
+			return nil
+		})
+		keys = append(keys, key)
+		return err
+	})
+	if err != nil {
+		res.Close()
+		return 0, err
+	}
+	err = res.Close()
+	if err != nil {
+		return 0, err
+	}
+
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	for _, key := range keys {
+		err = tx.Exec(q.insertRow, key...)
+		if err != nil {
+			return 0, err
+		}
+
+		err = tx.Exec(q.deleteRow, key...)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(keys), tx.Commit()
+}
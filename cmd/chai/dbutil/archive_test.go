@@ -0,0 +1,72 @@
+package dbutil
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchive(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test(a INT PRIMARY KEY, b INT);
+		CREATE TABLE test_archive(a INT PRIMARY KEY, b INT);
+	`)
+	require.NoError(t, err)
+
+	for i := 0; i < 25; i++ {
+		err = db.Exec("INSERT INTO test (a, b) VALUES (?, ?)", i, i%2)
+		require.NoError(t, err)
+	}
+
+	var batches []ArchiveProgress
+	p, err := Archive(context.Background(), db, ArchiveOptions{
+		Table:        "test",
+		ArchiveTable: "test_archive",
+		Where:        "b = 0",
+		BatchSize:    5,
+		Progress: func(p ArchiveProgress) {
+			batches = append(batches, p)
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, ArchiveProgress{Batches: 3, Moved: 13}, p)
+	require.Len(t, batches, 3)
+	require.Equal(t, 10, batches[1].Moved)
+
+	count := func(table string, where string) int {
+		var n int
+		r, err := db.QueryRow("SELECT COUNT(*) FROM " + table + " WHERE " + where)
+		require.NoError(t, err)
+		require.NoError(t, r.Scan(&n))
+		return n
+	}
+
+	require.Equal(t, 0, count("test", "b = 0"))
+	require.Equal(t, 12, count("test", "b = 1"))
+	require.Equal(t, 13, count("test_archive", "b = 0"))
+
+	// nothing left to archive
+	p, err = Archive(context.Background(), db, ArchiveOptions{
+		Table:        "test",
+		ArchiveTable: "test_archive",
+		Where:        "b = 0",
+	})
+	require.NoError(t, err)
+	require.Zero(t, p.Moved)
+
+	// rows are moved by primary key
+	err = db.Exec("CREATE TABLE nopk(a INT)")
+	require.NoError(t, err)
+	_, err = Archive(context.Background(), db, ArchiveOptions{
+		Table:        "nopk",
+		ArchiveTable: "test_archive",
+		Where:        "a = 0",
+	})
+	require.EqualError(t, err, "table nopk has no primary key")
+}
@@ -0,0 +1,201 @@
+package dbutil
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/stringutil"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// number of rows inserted by each statement of ImportCSV
+// with the ConflictFail policy.
+const csvBatchSize = 1000
+
+// ImportCSV inserts the records of a CSV file into the table, creating it if it doesn't exist.
+// The first record contains the names of the columns.
+// With the ConflictFail policy, the records are inserted in batches in a single transaction,
+// which is rolled back on the first error. With the other policies, the records are
+// inserted one at a time and the policy applies to each of them.
+// It returns a report of the inserted, skipped and failed rows.
+func ImportCSV(ctx context.Context, db *chai.DB, r io.Reader, table string, opts RestoreOptions) (*RestoreReport, error) {
+	if opts.OnConflict == "" || opts.OnConflict == ConflictFail {
+		return importCSVBatches(db, r, table)
+	}
+
+	ri, err := newRowInserter(db, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer ri.close()
+
+	cr := csv.NewReader(r)
+
+	headers, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	err = ri.conn.Exec(createCSVTableQuery(table, headers))
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return &ri.report, ctx.Err()
+		default:
+		}
+
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return &ri.report, err
+		}
+
+		// the reader ensures every record has a value for each header
+		values := make(expr.LiteralExprList, len(record))
+		for i := range values {
+			values[i] = expr.LiteralValue{Value: types.NewTextValue(record[i])}
+		}
+
+		err = ri.insert(ctx, table, headers, values, 0)
+		if err != nil {
+			return &ri.report, err
+		}
+	}
+
+	return &ri.report, nil
+}
+
+// importCSVBatches inserts the records of the CSV file in a single transaction,
+// csvBatchSize rows per statement.
+func importCSVBatches(db *chai.DB, r io.Reader, table string) (*RestoreReport, error) {
+	conn, err := db.Connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	tx, err := conn.Begin(true)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	cr := csv.NewReader(r)
+
+	headers, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Exec(createCSVTableQuery(table, headers))
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]string, len(headers))
+	placeholders := make([]string, len(headers))
+	for i, h := range headers {
+		columns[i] = stringutil.NormalizeIdentifier(h, '`')
+		placeholders[i] = "?"
+	}
+	baseQ := fmt.Sprintf("INSERT INTO %s (%s) VALUES ", stringutil.NormalizeIdentifier(table, '`'), strings.Join(columns, ", "))
+	rowQ := "(" + strings.Join(placeholders, ", ") + ")"
+
+	buf := make([][]string, csvBatchSize)
+	args := make([]any, 0, csvBatchSize*len(headers))
+
+	var report RestoreReport
+	var sb strings.Builder
+	var stop bool
+	var stmt *chai.Statement
+
+	for !stop {
+		sb.Reset()
+		n, err := csvReadN(cr, csvBatchSize, buf)
+		if errors.Is(err, io.EOF) {
+			stop = true
+		} else if err != nil {
+			return nil, err
+		}
+
+		if n == 0 {
+			break
+		}
+
+		args = args[:0]
+		for i := 0; i < n; i++ {
+			for _, v := range buf[i] {
+				args = append(args, v)
+			}
+		}
+
+		if stmt == nil || n < csvBatchSize {
+			sb.WriteString(baseQ)
+			for i := 0; i < n; i++ {
+				if i > 0 {
+					sb.WriteString(",")
+				}
+				sb.WriteString(rowQ)
+			}
+
+			stmt, err = tx.Prepare(sb.String())
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		err = stmt.Exec(args...)
+		if err != nil {
+			return nil, err
+		}
+		report.Inserted += n
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+// createCSVTableQuery returns a query creating the table, if it doesn't exist,
+// with a TEXT column for each header of the CSV file.
+func createCSVTableQuery(table string, headers []string) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "CREATE TABLE IF NOT EXISTS %s (", stringutil.NormalizeIdentifier(table, '`'))
+	for i, h := range headers {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(stringutil.NormalizeIdentifier(h, '`'))
+		sb.WriteString(" TEXT")
+	}
+	sb.WriteByte(')')
+
+	return sb.String()
+}
+
+func csvReadN(r *csv.Reader, n int, dst [][]string) (int, error) {
+	for i := 0; i < n; i++ {
+		record, err := r.Read()
+		if err != nil {
+			return i, err
+		}
+		dst[i] = record
+	}
+	return n, nil
+}
@@ -0,0 +1,98 @@
+package dbutil
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportCSVConflictPolicies(t *testing.T) {
+	data := "a,b\n1,10\n2,20\n3,30\n"
+
+	tests := []struct {
+		policy   ConflictPolicy
+		fails    bool
+		inserted int
+		skipped  int
+		sum      int
+	}{
+		{ConflictFail, true, 0, 0, 3},
+		{ConflictSkip, false, 2, 1, 53},
+		{ConflictUpsert, false, 3, 0, 60},
+		{ConflictSideTable, false, 2, 1, 53},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.policy), func(t *testing.T) {
+			db, err := chai.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec(`CREATE TABLE test(a INT PRIMARY KEY, b INT); INSERT INTO test (a, b) VALUES (1, 3)`)
+			require.NoError(t, err)
+
+			report, err := ImportCSV(context.Background(), db, strings.NewReader(data), "test", RestoreOptions{
+				OnConflict:    test.policy,
+				ConflictTable: "import conflicts",
+			})
+			if test.fails {
+				require.ErrorContains(t, err, "PRIMARY KEY constraint")
+				require.Nil(t, report)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.inserted, report.Inserted)
+				require.Equal(t, test.skipped, report.Skipped)
+				require.Zero(t, report.Failed)
+			}
+
+			var sum int
+			r, err := db.QueryRow("SELECT SUM(b) FROM test")
+			require.NoError(t, err)
+			require.NoError(t, r.Scan(&sum))
+			require.Equal(t, test.sum, sum)
+
+			if test.policy == ConflictSideTable {
+				var n int
+				r, err := db.QueryRow("SELECT COUNT(*) FROM `import conflicts`")
+				require.NoError(t, err)
+				require.NoError(t, r.Scan(&n))
+				require.Equal(t, 1, n)
+			}
+		})
+	}
+
+	t.Run("new table", func(t *testing.T) {
+		db, err := chai.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		report, err := ImportCSV(context.Background(), db, strings.NewReader(data), "test", RestoreOptions{})
+		require.NoError(t, err)
+		require.Equal(t, 3, report.Inserted)
+
+		var b string
+		r, err := db.QueryRow("SELECT b FROM test WHERE a = '2'")
+		require.NoError(t, err)
+		require.NoError(t, r.Scan(&b))
+		require.Equal(t, "20", b)
+	})
+
+	t.Run("table name with spaces", func(t *testing.T) {
+		db, err := chai.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		report, err := ImportCSV(context.Background(), db, strings.NewReader(data), "my data", RestoreOptions{})
+		require.NoError(t, err)
+		require.Equal(t, 3, report.Inserted)
+
+		var n int
+		r, err := db.QueryRow("SELECT COUNT(*) FROM `my data`")
+		require.NoError(t, err)
+		require.NoError(t, r.Scan(&n))
+		require.Equal(t, 3, n)
+	})
+}
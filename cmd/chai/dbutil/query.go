@@ -0,0 +1,61 @@
+package dbutil
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/chaisql/chai"
+	"github.com/cockroachdb/errors"
+)
+
+// OpenBackup opens the database stored in tar backups created by DB.Backup
+// and DB.BackupIncremental: a full backup followed by the incremental backups
+// made after it. The backups are read in place, without being restored
+// nor loaded in memory, and nothing is written to disk.
+// It is the caller's responsibility to close the database.
+func OpenBackup(ctx context.Context, backups ...string) (*chai.DB, error) {
+	db, err := chai.OpenBackup(ctx, backups...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open backup")
+	}
+
+	return db.WithContext(ctx), nil
+}
+
+// QueryReadOnly runs the query in a read-only transaction
+// and writes the resulting rows to w, as JSON.
+// Statements modifying the database return an error.
+func QueryReadOnly(ctx context.Context, db *chai.DB, q string, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+
+	conn, err := db.Connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	tx, err := conn.Begin(false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Query(q)
+	if err != nil {
+		return err
+	}
+	defer res.Close()
+
+	return res.Iterate(func(r *chai.Row) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		return enc.Encode(r)
+	})
+}
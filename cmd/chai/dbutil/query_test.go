@@ -0,0 +1,47 @@
+package dbutil
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryBackup(t *testing.T) {
+	db, err := chai.Open(filepath.Join(t.TempDir(), "db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test(a INT PRIMARY KEY, b TEXT);
+		INSERT INTO test (a, b) VALUES (1, 'foo'), (2, 'bar');
+	`)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = db.Backup(context.Background(), &buf)
+	require.NoError(t, err)
+
+	backupFile := filepath.Join(t.TempDir(), "backup.tar")
+	err = os.WriteFile(backupFile, buf.Bytes(), 0o600)
+	require.NoError(t, err)
+
+	backup, err := OpenBackup(context.Background(), backupFile)
+	require.NoError(t, err)
+	defer backup.Close()
+
+	var got bytes.Buffer
+	err = QueryReadOnly(context.Background(), backup, "SELECT b FROM test WHERE a = 2", &got)
+	require.NoError(t, err)
+	require.Equal(t, "{\n  \"b\": \"bar\"\n}\n", got.String())
+
+	// writes are rejected
+	err = QueryReadOnly(context.Background(), backup, "DELETE FROM test", &got)
+	require.Error(t, err)
+	err = backup.Exec("DELETE FROM test")
+	require.ErrorIs(t, err, chai.ErrReadOnly)
+}
@@ -2,38 +2,304 @@ package dbutil
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/query"
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/sql/parser"
+	"github.com/chaisql/chai/internal/stringutil"
 	"github.com/cockroachdb/errors"
 )
 
+// ConflictPolicy determines what happens when a restored row
+// conflicts with an existing one.
+type ConflictPolicy string
+
+const (
+	// ConflictFail aborts the restore on the first error.
+	ConflictFail ConflictPolicy = "fail"
+	// ConflictSkip ignores conflicting rows.
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictUpsert replaces existing rows with the restored ones.
+	ConflictUpsert ConflictPolicy = "upsert"
+	// ConflictSideTable writes conflicting rows to a separate table.
+	ConflictSideTable ConflictPolicy = "side-table"
+)
+
+// DefaultConflictTable is the name of the table used by ConflictSideTable
+// if none is provided.
+const DefaultConflictTable = "restore_conflicts"
+
+// ParseConflictPolicy returns the policy matching s.
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	switch p := ConflictPolicy(strings.ToLower(s)); p {
+	case "":
+		return ConflictFail, nil
+	case ConflictFail, ConflictSkip, ConflictUpsert, ConflictSideTable:
+		return p, nil
+	}
+
+	return "", errors.Errorf("unknown conflict policy %q, expected one of fail, skip, upsert, side-table", s)
+}
+
+// RestoreOptions configures the behavior of RestoreWithOptions.
+type RestoreOptions struct {
+	OnConflict ConflictPolicy
+	// Name of the table receiving conflicting rows when
+	// OnConflict is ConflictSideTable.
+	ConflictTable string
+}
+
+// RowError describes a row that couldn't be restored.
+type RowError struct {
+	Table  string
+	Row    string
+	Reason string
+}
+
+// RestoreReport summarizes the outcome of a restore.
+type RestoreReport struct {
+	Inserted int
+	Skipped  int
+	Failed   int
+	Errors   []RowError
+}
+
+// WriteTo writes a human readable summary of the report to w.
+func (r *RestoreReport) WriteTo(w io.Writer) (int64, error) {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "inserted: %d, skipped: %d, failed: %d\n", r.Inserted, r.Skipped, r.Failed)
+	for _, e := range r.Errors {
+		fmt.Fprintf(&sb, "%s %s: %s\n", e.Table, e.Row, e.Reason)
+	}
+
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
+
 // Restore a database from a file created by chai dump.
 // This function can be provided with an existing database (chai cli use case),
 // otherwise new database is being created.
 func Restore(ctx context.Context, db *chai.DB, dumpFile, dbPath string) error {
+	_, err := RestoreWithOptions(ctx, db, dumpFile, dbPath, RestoreOptions{})
+	return err
+}
+
+// RestoreWithOptions restores a database from a file created by chai dump,
+// applying the conflict policy to every inserted row.
+// It returns a report of the inserted, skipped and failed rows.
+func RestoreWithOptions(ctx context.Context, db *chai.DB, dumpFile, dbPath string, opts RestoreOptions) (*RestoreReport, error) {
 	if dbPath == "" {
-		return errors.New("database path expected")
+		return nil, errors.New("database path expected")
 	}
 
 	if dumpFile == "" {
-		return errors.New("dump file expected")
+		return nil, errors.New("dump file expected")
 	}
 
 	file, err := os.Open(dumpFile)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer file.Close()
 
 	if db == nil {
 		db, err = OpenDB(ctx, dbPath)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		defer db.Close()
 	}
 
-	return ExecSQL(ctx, db, file, io.Discard)
+	return restoreSQL(ctx, db, file, opts)
+}
+
+func restoreSQL(ctx context.Context, db *chai.DB, r io.Reader, opts RestoreOptions) (*RestoreReport, error) {
+	ri, err := newRowInserter(db, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer ri.close()
+
+	err = parser.NewParser(r).Parse(func(s statement.Statement) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		ins, ok := s.(*statement.InsertStmt)
+		if !ok || ins.Values == nil {
+			_, err := runStatement(ctx, db, ri.conn, s)
+			return err
+		}
+
+		// with the fail and upsert policies, the statement is run as is:
+		// the first error aborts the restore anyway.
+		if ri.opts.OnConflict == ConflictFail || ri.opts.OnConflict == ConflictUpsert {
+			if ri.opts.OnConflict == ConflictUpsert {
+				ins.OnConflict = database.OnConflictDoReplace
+			}
+
+			_, err := runStatement(ctx, db, ri.conn, ins)
+			if err != nil {
+				return err
+			}
+			ri.report.Inserted += len(ins.Values)
+			return nil
+		}
+
+		// otherwise, run each row separately so that a conflict
+		// only affects the row that caused it.
+		for _, v := range ins.Values {
+			err := ri.insert(ctx, ins.TableName, ins.Columns, v, ins.OnConflict)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return &ri.report, err
+}
+
+// rowInserter inserts rows one at a time, applying the conflict policy
+// to each of them, and reports the outcome.
+type rowInserter struct {
+	db   *chai.DB
+	conn *chai.Connection
+	opts RestoreOptions
+
+	report           RestoreReport
+	sideTableCreated bool
+}
+
+func newRowInserter(db *chai.DB, opts RestoreOptions) (*rowInserter, error) {
+	if opts.OnConflict == "" {
+		opts.OnConflict = ConflictFail
+	}
+	if opts.OnConflict == ConflictSideTable && opts.ConflictTable == "" {
+		opts.ConflictTable = DefaultConflictTable
+	}
+
+	conn, err := db.Connect()
+	if err != nil {
+		return nil, err
+	}
+
+	return &rowInserter{
+		db:   db,
+		conn: conn,
+		opts: opts,
+	}, nil
+}
+
+func (ri *rowInserter) close() error {
+	return ri.conn.Close()
+}
+
+// insert inserts the row v into the columns of the table.
+// onConflict is the conflict clause of the statement the row comes from,
+// it is overridden by the skip, upsert and side-table policies.
+// With the fail policy, the error of the insertion is returned,
+// otherwise it is recorded in the report.
+func (ri *rowInserter) insert(ctx context.Context, table string, columns []string, v expr.Expr, onConflict database.OnConflictAction) error {
+	stmt := statement.NewInsertStatement()
+	stmt.TableName = table
+	stmt.Columns = columns
+	stmt.Values = []expr.Expr{v}
+	stmt.OnConflict = onConflict
+	switch ri.opts.OnConflict {
+	case ConflictUpsert:
+		stmt.OnConflict = database.OnConflictDoReplace
+	case ConflictSkip, ConflictSideTable:
+		// conflicting rows are not returned
+		stmt.OnConflict = database.OnConflictDoNothing
+		stmt.Returning = []expr.Expr{expr.Wildcard{}}
+	}
+
+	n, err := runStatement(ctx, ri.db, ri.conn, stmt)
+	if err != nil {
+		if ri.opts.OnConflict == ConflictFail || errors.Is(err, context.Canceled) {
+			return err
+		}
+
+		ri.report.Failed++
+		ri.report.Errors = append(ri.report.Errors, RowError{
+			Table:  table,
+			Row:    v.String(),
+			Reason: err.Error(),
+		})
+		return nil
+	}
+
+	if stmt.Returning == nil || n > 0 {
+		ri.report.Inserted++
+		return nil
+	}
+
+	rerr := RowError{
+		Table:  table,
+		Row:    v.String(),
+		Reason: "conflict with an existing row",
+	}
+
+	if ri.opts.OnConflict == ConflictSideTable {
+		name := stringutil.NormalizeIdentifier(ri.opts.ConflictTable, '`')
+		if !ri.sideTableCreated {
+			err = ri.conn.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (table_name TEXT, row_data TEXT, reason TEXT)", name))
+			if err != nil {
+				return err
+			}
+			ri.sideTableCreated = true
+		}
+
+		err = ri.conn.Exec(fmt.Sprintf("INSERT INTO %s (table_name, row_data, reason) VALUES (?, ?, ?)", name), rerr.Table, rerr.Row, rerr.Reason)
+		if err != nil {
+			return err
+		}
+	}
+
+	ri.report.Skipped++
+	ri.report.Errors = append(ri.report.Errors, rerr)
+	return nil
+}
+
+// runStatement executes s and returns the number of rows it returned.
+func runStatement(ctx context.Context, db *chai.DB, conn *chai.Connection, s statement.Statement) (int, error) {
+	qq := query.New(s)
+	qctx := query.Context{
+		Ctx:  ctx,
+		DB:   db.DB,
+		Conn: conn.Conn,
+	}
+	err := qq.Prepare(&qctx)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := qq.Run(&qctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+	err = res.Iterate(func(r database.Row) error {
+		n++
+		return nil
+	})
+	if err != nil {
+		res.Close()
+		return 0, err
+	}
+
+	return n, res.Close()
 }
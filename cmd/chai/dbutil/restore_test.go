@@ -0,0 +1,65 @@
+package dbutil
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestoreConflictPolicies(t *testing.T) {
+	dump := `
+		INSERT INTO test (a, b) VALUES (1, 10);
+		INSERT INTO test (a, b) VALUES (2, 20), (3, 30);
+	`
+
+	tests := []struct {
+		policy   ConflictPolicy
+		fails    bool
+		inserted int
+		skipped  int
+		sum      int
+	}{
+		{ConflictFail, true, 0, 0, 3},
+		{ConflictSkip, false, 2, 1, 53},
+		{ConflictUpsert, false, 3, 0, 60},
+		{ConflictSideTable, false, 2, 1, 53},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.policy), func(t *testing.T) {
+			db, err := chai.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec(`CREATE TABLE test(a INT PRIMARY KEY, b INT); INSERT INTO test (a, b) VALUES (1, 3)`)
+			require.NoError(t, err)
+
+			report, err := restoreSQL(context.Background(), db, strings.NewReader(dump), RestoreOptions{OnConflict: test.policy})
+			if test.fails {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, test.inserted, report.Inserted)
+			require.Equal(t, test.skipped, report.Skipped)
+			require.Zero(t, report.Failed)
+
+			var sum int
+			r, err := db.QueryRow("SELECT SUM(b) FROM test")
+			require.NoError(t, err)
+			require.NoError(t, r.Scan(&sum))
+			require.Equal(t, test.sum, sum)
+
+			if test.policy == ConflictSideTable {
+				var n int
+				r, err := db.QueryRow("SELECT COUNT(*) FROM " + DefaultConflictTable)
+				require.NoError(t, err)
+				require.NoError(t, r.Scan(&n))
+				require.Equal(t, 1, n)
+			}
+		})
+	}
+}
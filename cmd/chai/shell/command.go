@@ -3,7 +3,6 @@ package shell
 import (
 	"bytes"
 	"context"
-	"encoding/csv"
 	"fmt"
 	"io"
 	"os"
@@ -14,7 +13,6 @@ import (
 	"github.com/chaisql/chai"
 	"github.com/chaisql/chai/cmd/chai/dbutil"
 	errs "github.com/chaisql/chai/internal/errors"
-	"github.com/chaisql/chai/internal/row"
 )
 
 type command struct {
@@ -73,9 +71,9 @@ var commands = []command{
 	},
 	{
 		Name:        ".import",
-		Options:     "TYPE FILE table",
+		Options:     "TYPE FILE table [policy [conflict_table]]",
 		DisplayName: ".import",
-		Description: "Import data from a file. Only supported type is 'csv'",
+		Description: "Import data from a file. Only supported type is 'csv'. The policy applies to conflicting rows: fail, skip, upsert or side-table.",
 	},
 	{
 		Name:        ".timer",
@@ -85,9 +83,9 @@ var commands = []command{
 	},
 	{
 		Name:        ".restore",
-		Options:     "[dumpFile]",
+		Options:     "dumpFile [policy [conflict_table]]",
 		DisplayName: ".restore",
-		Description: "The restore command can restore a database from a text file.",
+		Description: "The restore command can restore a database from a text file. The policy applies to conflicting rows, like with .import.",
 	},
 }
 
@@ -188,9 +186,7 @@ func runSaveCmd(ctx context.Context, db *chai.DB, dbPath string) error {
 	return otherDB.Exec(dbDump.String())
 }
 
-const csvBatchSize = 1000
-
-func runImportCmd(db *chai.DB, fileType, path, table string) error {
+func runImportCmd(ctx context.Context, db *chai.DB, fileType, path, table string, opts dbutil.RestoreOptions, out io.Writer) error {
 	if strings.ToLower(fileType) != "csv" {
 		return errors.New("TYPE should be csv")
 	}
@@ -201,95 +197,9 @@ func runImportCmd(db *chai.DB, fileType, path, table string) error {
 	}
 	defer f.Close()
 
-	conn, err := db.Connect()
-	if err != nil {
-		return err
-	}
-	defer conn.Close()
-
-	tx, err := conn.Begin(true)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	r := csv.NewReader(f)
-
-	err = tx.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s", table))
-	if err != nil {
-		return err
-	}
-
-	headers, err := r.Read()
-	if err != nil {
-		return err
-	}
-
-	baseQ := fmt.Sprintf("INSERT INTO %s VALUES ", table)
-
-	buf := make([][]string, csvBatchSize)
-	fbs := make([]*row.ColumnBuffer, csvBatchSize)
-	for i := range fbs {
-		fbs[i] = row.NewColumnBuffer()
-	}
-	args := make([]any, csvBatchSize)
-	for i := range args {
-		args[i] = fbs[i]
-	}
-
-	var sb strings.Builder
-	var stop bool
-	var stmt *chai.Statement
-
-	for !stop {
-		sb.Reset()
-		n, err := csvReadN(r, csvBatchSize, buf)
-		if errors.Is(err, io.EOF) {
-			stop = true
-		} else if err != nil {
-			return err
-		}
-
-		if n == 0 {
-			break
-		}
-
-		for i := 0; i < n; i++ {
-			fbs[i].Reset()
-			fbs[i].ScanCSV(headers, buf[i])
-		}
-
-		if stmt == nil || n < csvBatchSize {
-			sb.WriteString(baseQ)
-			for i := 0; i < n; i++ {
-				if i > 0 {
-					sb.WriteString(",")
-				}
-				sb.WriteString("?")
-			}
-
-			stmt, err = tx.Prepare(sb.String())
-			if err != nil {
-				return err
-			}
-		}
-
-		err = stmt.Exec(args[:n]...)
-		if err != nil {
-			return err
-		}
-	}
-
-	return tx.Commit()
-}
-
-func csvReadN(r *csv.Reader, n int, dst [][]string) (int, error) {
-	for i := 0; i < n; i++ {
-		record, err := r.Read()
-		if err != nil {
-			return i, err
-		}
-		dst[i] = record
+	report, err := dbutil.ImportCSV(ctx, db, f, table, opts)
+	if report != nil && opts.OnConflict != dbutil.ConflictFail {
+		_, _ = report.WriteTo(out)
 	}
-	return n, nil
+	return err
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/csv"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
@@ -160,7 +161,7 @@ func BenchmarkImportCSV(b *testing.B) {
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		err = runImportCmd(db, "csv", fp, "foo")
+		err = runImportCmd(context.Background(), db, "csv", fp, "foo", dbutil.RestoreOptions{}, io.Discard)
 		require.NoError(b, err)
 
 		b.StopTimer()
@@ -343,21 +343,60 @@ func (sh *Shell) runCommand(ctx context.Context, in string, out io.Writer) error
 	case ".schema":
 		return dbutil.DumpSchema(sh.db, out, cmd[1:]...)
 	case ".import":
-		if len(cmd) != 4 {
+		if len(cmd) < 4 || len(cmd) > 6 {
 			return fmt.Errorf(getUsage(".import"))
 		}
 
-		return runImportCmd(sh.db, cmd[1], cmd[2], cmd[3])
+		opts, err := parseConflictArgs(cmd[4:], ".import")
+		if err != nil {
+			return err
+		}
+
+		return runImportCmd(ctx, sh.db, cmd[1], cmd[2], cmd[3], opts, out)
 	case ".restore":
-		if len(cmd) != 2 {
+		if len(cmd) < 2 || len(cmd) > 4 {
 			return fmt.Errorf(getUsage(".restore"))
 		}
-		return dbutil.Restore(ctx, sh.db, cmd[1], "./")
+
+		opts, err := parseConflictArgs(cmd[2:], ".restore")
+		if err != nil {
+			return err
+		}
+
+		report, err := dbutil.RestoreWithOptions(ctx, sh.db, cmd[1], "./", opts)
+		if report != nil && opts.OnConflict != dbutil.ConflictFail {
+			_, _ = report.WriteTo(out)
+		}
+		return err
 	default:
 		return displaySuggestions(in, out)
 	}
 }
 
+// parseConflictArgs parses the optional conflict policy of the .import
+// and .restore commands, followed by the name of the side table.
+func parseConflictArgs(args []string, cmdName string) (dbutil.RestoreOptions, error) {
+	var opts dbutil.RestoreOptions
+	if len(args) == 0 {
+		return opts, nil
+	}
+
+	policy, err := dbutil.ParseConflictPolicy(args[0])
+	if err != nil {
+		return opts, err
+	}
+	opts.OnConflict = policy
+
+	if len(args) > 1 {
+		if policy != dbutil.ConflictSideTable {
+			return opts, fmt.Errorf(getUsage(cmdName))
+		}
+		opts.ConflictTable = args[1]
+	}
+
+	return opts, nil
+}
+
 func (sh *Shell) runQuery(ctx context.Context, q string, out io.Writer) error {
 	err := dbutil.ExecSQL(ctx, sh.db, strings.NewReader(q), out)
 	if errors.Is(err, context.Canceled) {
@@ -10,11 +10,17 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"io"
+	"iter"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/chaisql/chai/internal/database"
-	"github.com/chaisql/chai/internal/database/catalogstore"
 	"github.com/chaisql/chai/internal/environment"
 	errs "github.com/chaisql/chai/internal/errors"
+	"github.com/chaisql/chai/internal/expr/functions"
+	"github.com/chaisql/chai/internal/kv"
 	"github.com/chaisql/chai/internal/query"
 	"github.com/chaisql/chai/internal/query/statement"
 	"github.com/chaisql/chai/internal/row"
@@ -28,30 +34,234 @@ import (
 type DB struct {
 	DB  *database.Database
 	ctx context.Context
+
+	// maximum duration of the queries run by the connections.
+	statementTimeout time.Duration
+
+	// work memory of the transactions run by the connections.
+	workMemory int
+
+	// cache stores the queries prepared by the connections.
+	cache *query.Cache
+
+	// functions registered with RegisterFunction.
+	functions *functions.Registry
 }
 
+// maximum number of prepared queries cached by a database.
+const queryCacheSize = 256
+
 // Open creates a Chai database at the given path.
 // If path is equal to ":memory:" it will open an in-memory database,
 // otherwise it will create an on-disk database.
+// If path is prefixed with the name of an engine registered with RegisterEngine
+// followed by "://", the database is stored using that engine.
 func Open(path string) (*DB, error) {
-	db, err := database.Open(path, &database.Options{
-		CatalogLoader: catalogstore.LoadCatalog,
-	})
+	return OpenWith(path, nil)
+}
+
+// Options configure how a database is opened by OpenWith.
+type Options struct {
+	// Compression of the data written on disk.
+	// If empty, CompressionSnappy is used.
+	// It only applies to on-disk databases and only affects
+	// the data written after the database is opened.
+	Compression Compression
+
+	// If set, the changes committed to an on-disk database are also
+	// appended to segment files stored in this directory.
+	// RestoreToTime uses them to restore the database as it was
+	// at any time after a backup. Segments are never deleted by Chai.
+	WALArchiveDir string
+
+	// An on-disk database can only be opened by one process at a time.
+	// LockTimeout is the maximum duration to wait for the process
+	// using the database to close it. If zero, OpenWith fails immediately
+	// with an error wrapping ErrDatabaseLocked, which reports
+	// the PID of that process.
+	LockTimeout time.Duration
+
+	// If positive, TEXT and BLOB values larger than BlobThreshold bytes
+	// are written to a separate blob log, in the blobs directory of the database,
+	// and the rows only store a pointer to them. This keeps the main store small
+	// when the database holds large documents. Such values are only read
+	// when a query accesses their column.
+	// The space of the values that are updated or deleted is reclaimed by Compact.
+	// It requires an on-disk database and cannot be used with WALArchiveDir.
+	BlobThreshold int
+
+	// Durability controls when the commits are synced to disk.
+	// If empty, DurabilityAlways is used.
+	// Transactions can override it with BEGIN ... WITH DURABILITY FULL or RELAXED,
+	// for example to let bulk loads commit without waiting for the disk.
+	Durability Durability
+
+	// SyncInterval is the maximum time between a commit
+	// and its sync to disk with DurabilityPeriodic.
+	// If zero, it defaults to 100ms.
+	SyncInterval time.Duration
+
+	// The following options tune the storage engine of on-disk databases.
+	// Zero values select the defaults of Pebble. OpenWith fails if they
+	// are negative or inconsistent.
+
+	// CacheSize is the size of the block cache, in bytes, shared by
+	// the reads of the database. Pebble uses 8MB by default.
+	CacheSize int64
+
+	// MemTableSize is the size, in bytes, of the in-memory tables buffering
+	// the writes before they are flushed to disk. It must be between 1MB and 4GB.
+	// Pebble uses 4MB by default.
+	MemTableSize int64
+
+	// MaxOpenFiles is the maximum number of files the engine keeps open.
+	// Pebble uses 1000 by default.
+	MaxOpenFiles int
+
+	// L0CompactionThreshold is the number of files of the first level
+	// of the LSM tree that triggers a compaction. Pebble uses 4 by default.
+	L0CompactionThreshold int
+
+	// L0StopWritesThreshold is the number of files of the first level
+	// at which writes are stopped until compactions catch up.
+	// It can't be lower than L0CompactionThreshold. Pebble uses 12 by default.
+	L0StopWritesThreshold int
+
+	// Only one write transaction can run at a time.
+	// BusyTimeout is the maximum duration to wait for the current one
+	// to finish when starting another. If zero, Begin waits as long as needed.
+	// If negative, Begin fails immediately with an error wrapping ErrBusy.
+	BusyTimeout time.Duration
+
+	// BusyHandler, if set, replaces BusyTimeout. It is called every time
+	// a write transaction can't be started because another one is running,
+	// with the number of times it was already called for this transaction.
+	// It is expected to wait before returning true to try again,
+	// or to return false to fail with ErrBusy.
+	BusyHandler func(n int) bool
+
+	// TimeZone is the time zone of the database. Texts without zone offset
+	// converted to timestamps are interpreted in it, and the timestamps returned
+	// by the queries are formatted in it. Timestamps are always stored as
+	// instants, in UTC. If nil, UTC is used.
+	TimeZone *time.Location
+
+	// If set, inserting or updating a row with a NaN or infinite DOUBLE fails.
+	// Otherwise these values are stored, and compared and sorted in this order:
+	// -Infinity < finite values < +Infinity < NaN, NaN being equal to itself.
+	// In JSON, they are represented by the strings "NaN", "Infinity" and "-Infinity",
+	// which are converted back to doubles when inserted in a DOUBLE column.
+	RejectNonFiniteDoubles bool
+
+	// If set, the rows returned by the queries are encoded in JSON and MessagePack
+	// with their columns in the order of the query, which is the order of the table
+	// for SELECT *. Otherwise, the columns are sorted by name.
+	// The JSON documents stored in TEXT columns always keep the order of their keys.
+	PreserveColumnOrder bool
+
+	// Limits on the rows written by INSERT and UPDATE, to protect the database
+	// from hostile payloads. Writing a row exceeding them fails with an error
+	// wrapping ErrLimitExceeded. Zero values disable them.
+
+	// MaxRowSize is the maximum size of a row, as encoded in the table, in bytes.
+	// The TEXT and BLOB values stored outside of the rows because of BlobThreshold
+	// are counted.
+	MaxRowSize int
+
+	// MaxDocumentDepth is the maximum nesting depth of the objects and arrays
+	// of the JSON documents stored in TEXT columns. The depth of {"a": [1]} is 2.
+	MaxDocumentDepth int
+
+	// MaxDocumentFields is the maximum total number of object members, at any depth,
+	// of the JSON documents stored in TEXT columns.
+	MaxDocumentFields int
+}
+
+// Durability describes when the commits are synced to disk.
+// Commits are written to the write-ahead log in all cases:
+// a crash can lose the last commits that were not synced, but never
+// partially, and a crash of the process alone loses nothing.
+type Durability string
+
+const (
+	// DurabilityAlways syncs every commit before it returns.
+	DurabilityAlways Durability = kv.DurabilityAlways
+	// DurabilityPeriodic syncs the commits in the background, every SyncInterval.
+	DurabilityPeriodic Durability = kv.DurabilityPeriodic
+	// DurabilityNever leaves the commits to be synced by the operating system,
+	// or when the database is closed.
+	DurabilityNever Durability = kv.DurabilityNever
+)
+
+// Compression is an algorithm used to compress the data written on disk.
+type Compression string
+
+const (
+	CompressionNone   Compression = kv.CompressionNone
+	CompressionSnappy Compression = kv.CompressionSnappy
+	CompressionZstd   Compression = kv.CompressionZstd
+)
+
+// OpenWith creates a Chai database at the given path, like Open,
+// configured by the given options. If opts is nil, default options are used.
+func OpenWith(path string, opts *Options) (*DB, error) {
+	return OpenContext(context.Background(), path, opts)
+}
+
+// OpenContext creates a Chai database at the given path, like OpenWith.
+// Waiting for another process to release the database, according
+// to Options.LockTimeout, stops when ctx is done.
+// ctx is only used to open the database, see DB.WithContext to run
+// the queries with a context.
+func OpenContext(ctx context.Context, path string, opts *Options) (*DB, error) {
+	fns := functions.NewRegistry()
+	path, dopts, err := openOptions(path, opts, fns)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := database.OpenContext(ctx, path, dopts)
 	if err != nil {
 		return nil, err
 	}
 
 	return &DB{
-		DB: db,
+		DB:        db,
+		cache:     query.NewCache(queryCacheSize),
+		functions: fns,
 	}, nil
 }
 
+// Upgrade migrates the database at the given path to the format
+// used by this version of Chai.
+func Upgrade(path string) error {
+	path, opts, err := openOptions(path, nil, functions.NewRegistry())
+	if err != nil {
+		return err
+	}
+	opts.Upgrade = true
+
+	db, err := database.Open(path, opts)
+	if err != nil {
+		return err
+	}
+
+	return db.Close()
+}
+
 func (db *DB) Connect() (*Connection, error) {
 	conn, err := db.DB.Connect()
 	if err != nil {
 		return nil, err
 	}
 
+	if db.statementTimeout != 0 {
+		conn.SetStatementTimeout(db.statementTimeout)
+	}
+	if db.workMemory != 0 {
+		conn.SetWorkMemory(db.workMemory)
+	}
+
 	return &Connection{
 		db:   db,
 		Conn: conn,
@@ -64,6 +274,21 @@ func (db DB) WithContext(ctx context.Context) *DB {
 	return &db
 }
 
+// WithStatementTimeout creates a new database handle whose connections
+// cancel the queries running for longer than d with ErrStatementTimeout.
+func (db DB) WithStatementTimeout(d time.Duration) *DB {
+	db.statementTimeout = d
+	return &db
+}
+
+// WithWorkMemory creates a new database handle whose transactions
+// spill the values of their sorts and aggregations to temporary storage
+// once they hold more than n bytes in memory.
+func (db DB) WithWorkMemory(n int) *DB {
+	db.workMemory = n
+	return &db
+}
+
 func (db *DB) withConn(fn func(*Connection) error) error {
 	conn, err := db.Connect()
 	if err != nil {
@@ -83,28 +308,182 @@ func (db *DB) QueryRow(q string, args ...any) (r *Row, err error) {
 	return
 }
 
+// QueryRowContext runs the query like QueryRow, cancelling it when ctx is done.
+func (db *DB) QueryRowContext(ctx context.Context, q string, args ...any) (*Row, error) {
+	return db.WithContext(ctx).QueryRow(q, args...)
+}
+
 // Exec a query against the database without returning the result.
+// Each call uses its own connection: a transaction started with BEGIN
+// is rolled back once the call returns unless it is committed by the same query.
+// Use Connect to run transaction control statements across multiple calls.
 func (db *DB) Exec(q string, args ...any) error {
 	return db.withConn(func(c *Connection) error {
 		return c.Exec(q, args...)
 	})
 }
 
+// ExecContext runs the query like Exec, cancelling it when ctx is done.
+func (db *DB) ExecContext(ctx context.Context, q string, args ...any) error {
+	return db.WithContext(ctx).Exec(q, args...)
+}
+
 // Close the database.
 func (db *DB) Close() error {
 	return db.DB.Close()
 }
 
+// PreparedTransaction returns the id of the transaction prepared
+// with Tx.PrepareCommit and left unresolved when its connection
+// or the database was closed.
+// Write transactions can't start until it is resolved with CommitPrepared
+// or RollbackPrepared.
+func (db *DB) PreparedTransaction() (string, bool) {
+	return db.DB.PreparedTransaction()
+}
+
+// CommitPrepared commits the transaction returned by PreparedTransaction.
+func (db *DB) CommitPrepared(id string) error {
+	return db.DB.CommitPrepared(id)
+}
+
+// RollbackPrepared rolls back the transaction returned by PreparedTransaction.
+func (db *DB) RollbackPrepared(id string) error {
+	return db.DB.RollbackPrepared(id)
+}
+
+// RunningQuery describes a query run by a connection of the database.
+type RunningQuery struct {
+	ID      uint64
+	SQL     string
+	Started time.Time
+}
+
+// RunningQueries returns the queries currently run by the connections of the database.
+// A query is running until its result is closed.
+func (db *DB) RunningQueries() []RunningQuery {
+	running := db.DB.RunningQueries()
+
+	queries := make([]RunningQuery, len(running))
+	for i, q := range running {
+		queries[i] = RunningQuery{
+			ID:      q.ID,
+			SQL:     q.SQL,
+			Started: q.Started,
+		}
+	}
+
+	return queries
+}
+
+// CancelQuery cancels the running query with the given id.
+// The query returns ErrQueryCanceled the next time it reads a row.
+func (db *DB) CancelQuery(id uint64) error {
+	return db.DB.CancelQuery(id)
+}
+
+// Stats describes the tables and indexes of a database
+// and the activity of its storage engine.
+type Stats = database.Stats
+
+// TableStats describes the rows of a table.
+type TableStats = database.TableStats
+
+// IndexStats describes the entries of an index.
+type IndexStats = database.IndexStats
+
+// TTLStats reports the progress of the deletion of expired rows.
+type TTLStats = database.TTLStats
+
+// EngineStats describes the disk usage, caches and compactions of the storage engine.
+type EngineStats = kv.EngineStats
+
+// Stats counts the rows of every table and the entries of every index,
+// and reports the estimated disk space they use along with the metrics
+// of the storage engine. Disk usage and engine metrics are only
+// reported for on-disk databases.
+func (db *DB) Stats(ctx context.Context) (*Stats, error) {
+	return db.DB.Stats(ctx)
+}
+
+// CompactionStats reports the size of a database before and after a compaction.
+type CompactionStats = kv.CompactionStats
+
+// Compact rewrites the data of an on-disk database to reclaim the space
+// used by deleted rows and dropped tables and indexes, and reports
+// the size of the database before and after. The values of the blob log
+// (see Options.BlobThreshold) that are no longer referenced are deleted as well.
+// Reads and writes can continue during the compaction.
+// The SQL statement VACUUM compacts the database as well.
+func (db *DB) Compact(ctx context.Context) (CompactionStats, error) {
+	return db.DB.Compact(ctx)
+}
+
+// CompressionStats describes how much the data of a database was compressed on disk.
+type CompressionStats = kv.CompressionStats
+
+// CompressionStats returns the compression achieved by the data written on disk.
+// Recent writes, still buffered in memory, are not included.
+// It returns an error if the database is not stored on disk.
+func (db *DB) CompressionStats() (CompressionStats, error) {
+	ng, ok := db.DB.Engine.(*kv.PebbleEngine)
+	if !ok {
+		return CompressionStats{}, errors.New("compression stats are only available for on-disk databases")
+	}
+
+	return ng.CompressionStats()
+}
+
+var (
+	// ErrQueryCanceled is returned by queries canceled with CancelQuery.
+	ErrQueryCanceled = database.ErrQueryCanceled
+
+	// ErrStatementTimeout is returned by queries running
+	// for longer than the statement timeout of their connection.
+	ErrStatementTimeout = database.ErrStatementTimeout
+
+	// ErrOutOfRange is returned when an arithmetic operation overflows,
+	// or when a value doesn't fit in the type or the width of its column.
+	ErrOutOfRange = types.ErrOutOfRange
+)
+
 type Connection struct {
 	db   *DB
 	Conn *database.Connection
 }
 
+// SetStatementTimeout sets the maximum duration of the queries run by the connection.
+// Queries running for longer return ErrStatementTimeout.
+// If negative, queries have no timeout.
+func (c *Connection) SetStatementTimeout(d time.Duration) {
+	c.Conn.SetStatementTimeout(d)
+}
+
+// SetWorkMemory sets the number of bytes the sorts and aggregations
+// of the transactions started by the connection can hold in memory
+// before spilling their values to temporary storage.
+// If zero, the work memory of the database is used.
+func (c *Connection) SetWorkMemory(n int) {
+	c.Conn.SetWorkMemory(n)
+}
+
 // Begin starts a new transaction.
 // The returned transaction must be closed either by calling Rollback or Commit.
 func (c *Connection) Begin(writable bool) (*Tx, error) {
+	return c.begin(c.db.ctx, writable)
+}
+
+// BeginContext starts a new transaction, like Begin.
+// Waiting for the current write transaction stops when ctx is done,
+// and the queries of the transaction are cancelled when ctx is done.
+func (c *Connection) BeginContext(ctx context.Context, writable bool) (*Tx, error) {
+	return c.begin(ctx, writable)
+}
+
+func (c *Connection) begin(ctx context.Context, writable bool) (*Tx, error) {
 	_, err := c.Conn.BeginTx(&database.TxOptions{
 		ReadOnly: !writable,
+		Context:  ctx,
 	})
 	if err != nil {
 		return nil, err
@@ -112,6 +491,7 @@ func (c *Connection) Begin(writable bool) (*Tx, error) {
 
 	return &Tx{
 		conn: c,
+		ctx:  ctx,
 	}, nil
 }
 
@@ -160,6 +540,23 @@ func (c *Connection) Query(q string, args ...any) (*Result, error) {
 	return res, nil
 }
 
+// QueryContext runs the query like Query, cancelling it when ctx is done.
+func (c *Connection) QueryContext(ctx context.Context, q string, args ...any) (*Result, error) {
+	stmt, err := c.Prepare(q)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	res.conn = c
+
+	return res, nil
+}
+
 // QueryRow runs the query and returns the first row.
 func (c *Connection) QueryRow(q string, args ...any) (*Row, error) {
 	stmt, err := c.Prepare(q)
@@ -170,6 +567,16 @@ func (c *Connection) QueryRow(q string, args ...any) (*Row, error) {
 	return stmt.QueryRow(args...)
 }
 
+// QueryRowContext runs the query like QueryRow, cancelling it when ctx is done.
+func (c *Connection) QueryRowContext(ctx context.Context, q string, args ...any) (*Row, error) {
+	stmt, err := c.Prepare(q)
+	if err != nil {
+		return nil, err
+	}
+
+	return stmt.QueryRowContext(ctx, args...)
+}
+
 // Exec a query against the database without returning the result.
 func (c *Connection) Exec(q string, args ...any) error {
 	stmt, err := c.Prepare(q)
@@ -180,22 +587,73 @@ func (c *Connection) Exec(q string, args ...any) error {
 	return stmt.Exec(args...)
 }
 
+// ExecContext runs the query like Exec, cancelling it when ctx is done.
+func (c *Connection) ExecContext(ctx context.Context, q string, args ...any) error {
+	stmt, err := c.Prepare(q)
+	if err != nil {
+		return err
+	}
+
+	return stmt.ExecContext(ctx, args...)
+}
+
 // Prepare parses the query and returns a prepared statement.
 func (c *Connection) Prepare(q string) (*Statement, error) {
-	pq, err := parser.ParseQuery(q)
+	pq, err := c.prepare(q)
 	if err != nil {
 		return nil, err
 	}
 
+	return &Statement{
+		pq:     pq,
+		sql:    q,
+		conn:   c,
+		closed: new(atomic.Bool),
+	}, nil
+}
+
+// prepare parses and prepares the query, or returns the query
+// cached by a previous call if the schema hasn't changed since.
+// Queries run by transactions that modified the schema are never cached.
+func (c *Connection) prepare(q string) (query.Query, error) {
+	version := c.catalogVersion()
+	if version != 0 {
+		if pq, ok := c.db.cache.Get(q, version); ok {
+			return pq, nil
+		}
+	}
+
+	pq, err := parser.NewParser(strings.NewReader(q)).WithFunctions(c.db.functions).ParseQuery()
+	if err != nil {
+		return query.Query{}, err
+	}
+
 	err = pq.Prepare(newQueryContext(c, nil))
 	if err != nil {
-		return nil, err
+		return query.Query{}, err
 	}
 
-	return &Statement{
-		pq:   pq,
-		conn: c,
-	}, nil
+	// queries using temporary indexes are specific to the connection,
+	// and the schema may have been modified while preparing the query.
+	if version != 0 && !c.Conn.HasTempIndexes() && version == c.catalogVersion() {
+		c.db.cache.Put(q, version, pq)
+	}
+
+	return pq, nil
+}
+
+// catalogVersion returns the version of the catalog seen by the connection,
+// or zero if it is being modified by the current transaction.
+func (c *Connection) catalogVersion() uint64 {
+	if c.db.cache == nil {
+		return 0
+	}
+
+	if tx := c.Conn.GetTx(); tx != nil {
+		return tx.Catalog.Version
+	}
+
+	return c.db.DB.Catalog().Version
 }
 
 func (c *Connection) Close() error {
@@ -208,6 +666,8 @@ func (c *Connection) Close() error {
 // and read/write can be used to read, create, delete and modify tables.
 type Tx struct {
 	conn *Connection
+	// context of the queries, set by Connection.BeginContext.
+	ctx context.Context
 }
 
 // Rollback the transaction. Can be used safely after commit.
@@ -231,6 +691,54 @@ func (tx *Tx) Commit() error {
 	return t.Commit()
 }
 
+// PrepareCommit durably records the changes of the transaction under the given id,
+// the first phase of a two-phase commit. The transaction must then be resolved
+// with Commit or Rollback, which can't fail because of a conflict. Until then,
+// its changes are not visible and other write transactions can't start.
+// If the connection or the database is closed before the transaction is resolved,
+// it can be resolved with DB.CommitPrepared or DB.RollbackPrepared.
+func (tx *Tx) PrepareCommit(id string) error {
+	t := tx.conn.Conn.GetTx()
+	if t == nil {
+		return errors.New("transaction has already been committed or rolled back")
+	}
+
+	return t.PrepareCommit(id)
+}
+
+// Savepoint creates a savepoint with the given name.
+// Changes made after it can be undone with RollbackTo without
+// aborting the whole transaction.
+func (tx *Tx) Savepoint(name string) error {
+	t := tx.conn.Conn.GetTx()
+	if t == nil {
+		return errors.New("transaction has already been committed or rolled back")
+	}
+
+	return t.Savepoint(name)
+}
+
+// RollbackTo undoes all the changes made since the savepoint was created.
+// The savepoint remains active and can be rolled back to again.
+func (tx *Tx) RollbackTo(name string) error {
+	t := tx.conn.Conn.GetTx()
+	if t == nil {
+		return errors.New("transaction has already been committed or rolled back")
+	}
+
+	return t.RollbackToSavepoint(name)
+}
+
+// Release destroys the savepoint, keeping the changes made since it was created.
+func (tx *Tx) Release(name string) error {
+	t := tx.conn.Conn.GetTx()
+	if t == nil {
+		return errors.New("transaction has already been committed or rolled back")
+	}
+
+	return t.ReleaseSavepoint(name)
+}
+
 // Query the database withing the transaction and returns the result.
 // Closing the returned result after usage is not mandatory.
 func (tx *Tx) Query(q string, args ...any) (*Result, error) {
@@ -242,6 +750,16 @@ func (tx *Tx) Query(q string, args ...any) (*Result, error) {
 	return stmt.Query(args...)
 }
 
+// QueryContext runs the query like Query, cancelling it when ctx is done.
+func (tx *Tx) QueryContext(ctx context.Context, q string, args ...any) (*Result, error) {
+	stmt, err := tx.Prepare(q)
+	if err != nil {
+		return nil, err
+	}
+
+	return stmt.QueryContext(ctx, args...)
+}
+
 // QueryRow runs the query and returns the first row.
 func (tx *Tx) QueryRow(q string, args ...any) (*Row, error) {
 	stmt, err := tx.Prepare(q)
@@ -252,6 +770,16 @@ func (tx *Tx) QueryRow(q string, args ...any) (*Row, error) {
 	return stmt.QueryRow(args...)
 }
 
+// QueryRowContext runs the query like QueryRow, cancelling it when ctx is done.
+func (tx *Tx) QueryRowContext(ctx context.Context, q string, args ...any) (*Row, error) {
+	stmt, err := tx.Prepare(q)
+	if err != nil {
+		return nil, err
+	}
+
+	return stmt.QueryRowContext(ctx, args...)
+}
+
 // Exec a query against the database within tx and without returning the result.
 func (tx *Tx) Exec(q string, args ...any) (err error) {
 	stmt, err := tx.Prepare(q)
@@ -262,22 +790,30 @@ func (tx *Tx) Exec(q string, args ...any) (err error) {
 	return stmt.Exec(args...)
 }
 
-// Prepare parses the query and returns a prepared statement.
-func (tx *Tx) Prepare(q string) (*Statement, error) {
-	pq, err := parser.ParseQuery(q)
+// ExecContext runs the query like Exec, cancelling it when ctx is done.
+func (tx *Tx) ExecContext(ctx context.Context, q string, args ...any) error {
+	stmt, err := tx.Prepare(q)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	err = pq.Prepare(newQueryContext(tx.conn, nil))
+	return stmt.ExecContext(ctx, args...)
+}
+
+// Prepare parses the query and returns a prepared statement.
+func (tx *Tx) Prepare(q string) (*Statement, error) {
+	pq, err := tx.conn.prepare(q)
 	if err != nil {
 		return nil, err
 	}
 
 	return &Statement{
-		pq:   pq,
-		conn: tx.conn,
-		tx:   tx,
+		pq:     pq,
+		sql:    q,
+		conn:   tx.conn,
+		tx:     tx,
+		ctx:    tx.ctx,
+		closed: new(atomic.Bool),
 	}, nil
 }
 
@@ -287,8 +823,20 @@ func (tx *Tx) Prepare(q string) (*Statement, error) {
 // It's safe for concurrent use by multiple goroutines.
 type Statement struct {
 	pq   query.Query
+	sql  string
 	conn *Connection
 	tx   *Tx
+	ctx  context.Context
+
+	// shared with the copies returned by WithContext
+	closed *atomic.Bool
+}
+
+// WithContext returns a copy of the statement whose queries are cancelled
+// when ctx is done, instead of using the context of the database.
+func (s Statement) WithContext(ctx context.Context) *Statement {
+	s.ctx = ctx
+	return &s
 }
 
 // Query the database and return the result.
@@ -297,12 +845,27 @@ func (s *Statement) Query(args ...any) (*Result, error) {
 	var r *statement.Result
 	var err error
 
-	r, err = s.pq.Run(newQueryContext(s.conn, argsToParams(args)))
+	if s.closed.Load() {
+		return nil, errors.New("statement is closed")
+	}
+
+	qctx := newQueryContext(s.conn, argsToParams(args))
+	qctx.SQL = s.sql
+	if s.ctx != nil {
+		qctx.Ctx = s.ctx
+	}
+
+	r, err = s.pq.Run(qctx)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Result{result: r, ctx: s.conn.db.ctx}, nil
+	return &Result{result: r, ctx: qctx.Ctx}, nil
+}
+
+// QueryContext runs the statement like Query, cancelling it when ctx is done.
+func (s *Statement) QueryContext(ctx context.Context, args ...any) (*Result, error) {
+	return s.WithContext(ctx).Query(args...)
 }
 
 func argsToParams(args []interface{}) []environment.Param {
@@ -349,6 +912,11 @@ func (s *Statement) QueryRow(args ...any) (r *Row, err error) {
 	return res.GetFirst()
 }
 
+// QueryRowContext runs the statement like QueryRow, cancelling it when ctx is done.
+func (s *Statement) QueryRowContext(ctx context.Context, args ...any) (*Row, error) {
+	return s.WithContext(ctx).QueryRow(args...)
+}
+
 // Exec a query against the database without returning the result.
 func (s *Statement) Exec(args ...any) (err error) {
 	res, err := s.Query(args...)
@@ -367,16 +935,96 @@ func (s *Statement) Exec(args ...any) (err error) {
 	})
 }
 
+// ExecContext runs the statement like Exec, cancelling it when ctx is done.
+func (s *Statement) ExecContext(ctx context.Context, args ...any) error {
+	return s.WithContext(ctx).Exec(args...)
+}
+
+// Close closes the statement and the copies returned by WithContext.
+// Running them afterwards returns an error. The results
+// returned before aren't closed.
+func (s *Statement) Close() error {
+	s.closed.Store(true)
+	return nil
+}
+
 // Result of a query.
+// Its rows can be read with Iterate, with a for range loop over Rows,
+// or with a cursor:
+//
+//	for res.Next() {
+//		err := res.Scan(&a, &b)
+//		...
+//	}
+//	err := res.Err()
 type Result struct {
 	result *statement.Result
 	ctx    context.Context
 	conn   *Connection
+
+	// state of the cursor
+	next func() (*Row, error, bool)
+	stop func()
+	cur  *Row
+	err  error
+	done bool
 }
 
+// Iterate calls fn for each row of the result.
+// It stops when fn returns an error, or when the query is cancelled.
 func (r *Result) Iterate(fn func(r *Row) error) error {
+	return r.iterate(r.ctx, fn)
+}
+
+// IterateContext calls fn for each row of the result, like Iterate,
+// and also stops when ctx is done, including while the rows are read
+// from the storage.
+func (r *Result) IterateContext(ctx context.Context, fn func(r *Row) error) error {
+	if ctx == nil {
+		return r.Iterate(fn)
+	}
+
+	if r.ctx != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = mergeContexts(ctx, r.ctx)
+		defer cancel()
+	}
+
+	// the operators of the stream read the context of the query
+	if it, ok := r.result.Iterator.(*statement.StreamStmtIterator); ok {
+		prev := it.Context.Ctx
+		if prev != nil {
+			var cancel context.CancelFunc
+			it.Context.Ctx, cancel = mergeContexts(ctx, prev)
+			defer cancel()
+		} else {
+			it.Context.Ctx = ctx
+		}
+		defer func() {
+			it.Context.Ctx = prev
+		}()
+	}
+
+	return r.iterate(ctx, fn)
+}
+
+// mergeContexts returns a context derived from ctx that is also cancelled
+// when other is done, with the cause of other.
+func mergeContexts(ctx, other context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	stop := context.AfterFunc(other, func() {
+		cancel(context.Cause(other))
+	})
+
+	return ctx, func() {
+		stop()
+		cancel(nil)
+	}
+}
+
+func (r *Result) iterate(ctx context.Context, fn func(r *Row) error) error {
 	var row Row
-	if r.ctx == nil {
+	if ctx == nil {
 		return r.result.Iterate(func(dr database.Row) error {
 			row.Row = dr
 			return fn(&row)
@@ -384,8 +1032,8 @@ func (r *Result) Iterate(fn func(r *Row) error) error {
 	}
 
 	return r.result.Iterate(func(dr database.Row) error {
-		if err := r.ctx.Err(); err != nil {
-			return err
+		if err := ctx.Err(); err != nil {
+			return context.Cause(ctx)
 		}
 
 		row.Row = dr
@@ -393,6 +1041,80 @@ func (r *Result) Iterate(fn func(r *Row) error) error {
 	})
 }
 
+// Rows returns an iterator over the rows of the result, for use in a for range loop.
+// Breaking out of the loop stops the iteration. If the iteration fails,
+// the error is yielded with a nil row, as the last element.
+// Each row is only valid until the next one is yielded, see Row.Clone.
+func (r *Result) Rows() iter.Seq2[*Row, error] {
+	return func(yield func(*Row, error) bool) {
+		var stopped bool
+		err := r.Iterate(func(row *Row) error {
+			if !yield(row, nil) {
+				stopped = true
+				return stream.ErrStreamClosed
+			}
+			return nil
+		})
+		if err != nil && !stopped {
+			yield(nil, err)
+		}
+	}
+}
+
+// Next moves the cursor to the next row of the result, which can then be read
+// with Scan or Row. It returns false at the end of the result, or if the iteration
+// failed, in which case Err returns the error.
+// The cursor can't be used along with Iterate or Rows.
+func (r *Result) Next() bool {
+	if r.done {
+		return false
+	}
+	if r.next == nil {
+		r.next, r.stop = iter.Pull2(r.Rows())
+	}
+
+	row, err, ok := r.next()
+	if !ok || err != nil {
+		r.err = err
+		r.stopCursor()
+		return false
+	}
+
+	r.cur = row
+	return true
+}
+
+// stopCursor ends the iteration of the cursor.
+func (r *Result) stopCursor() {
+	if r.stop != nil {
+		r.stop()
+	}
+	r.next, r.stop = nil, nil
+	r.cur = nil
+	r.done = true
+}
+
+// Row returns the current row of the cursor, or nil if Next wasn't called
+// or returned false. The row is only valid until the next call to Next, see Row.Clone.
+func (r *Result) Row() *Row {
+	return r.cur
+}
+
+// Scan copies the columns of the current row of the cursor
+// into the values pointed at by dest, like Row.Scan.
+func (r *Result) Scan(dest ...any) error {
+	if r.cur == nil {
+		return errors.New("no current row: Next must be called first")
+	}
+
+	return r.cur.Scan(dest...)
+}
+
+// Err returns the error that stopped the cursor, if any.
+func (r *Result) Err() error {
+	return r.err
+}
+
 func (r *Result) GetFirst() (*Row, error) {
 	var rr *Row
 	err := r.Iterate(func(row *Row) error {
@@ -434,6 +1156,8 @@ func (r *Result) Close() (err error) {
 		return nil
 	}
 
+	r.stopCursor()
+
 	err = r.result.Close()
 
 	return err
@@ -478,6 +1202,51 @@ func (r *Result) MarshalJSONTo(w io.Writer) error {
 	return buf.Flush()
 }
 
+// ScanAll scans all the rows of the result into a slice of T, and closes it.
+// T can be a struct, or a pointer to a struct, scanned like with Row.StructScan,
+// a map[string]T, scanned like with Row.MapScan, or any other type
+// supported by Row.Scan if the rows have a single column.
+// Maps, slices and structs stored as JSON text, such as nested structs
+// and slices of structs, are decoded with encoding/json.
+func ScanAll[T any](res *Result) (_ []T, err error) {
+	defer func() {
+		if cerr := res.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	var all []T
+	err = res.Iterate(func(r *Row) error {
+		var t T
+		if err := r.scanAny(&t); err != nil {
+			return err
+		}
+
+		all = append(all, t)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return all, nil
+}
+
+// QueryAs runs the query and scans all the rows into a slice of T.
+// See ScanAll for the supported types.
+func QueryAs[T any](db *DB, q string, args ...any) (all []T, err error) {
+	err = db.withConn(func(c *Connection) error {
+		res, err := c.Query(q, args...)
+		if err != nil {
+			return err
+		}
+
+		all, err = ScanAll[T](res)
+		return err
+	})
+	return
+}
+
 func newQueryContext(conn *Connection, params []environment.Param) *query.Context {
 	return &query.Context{
 		Ctx:    conn.db.ctx,
@@ -491,15 +1260,38 @@ type Row struct {
 	Row database.Row
 }
 
+// A ValueMarshaler is a type that converts itself to a value when passed
+// as a parameter to a query, or used as a struct field, like json.Marshaler.
+// MarshalChaiValue returns a bool, an integer, a float, a string, a []byte,
+// a time.Time or a [16]byte.
+type ValueMarshaler = row.ValueMarshaler
+
+// A ValueUnmarshaler is a type that decodes a value into itself when scanned,
+// like json.Unmarshaler. UnmarshalChaiValue receives nil for NULL, or a bool, an int64,
+// a float64, a string, a []byte, a time.Time, or a [16]byte for UUIDs.
+// Decimals are passed as strings.
+type ValueUnmarshaler = row.ValueUnmarshaler
+
+// A RowScanner is a type that scans rows itself, instead of having its fields
+// set by Row.StructScan.
+type RowScanner interface {
+	ScanRow(r *Row) error
+}
+
+// Clone returns a deep copy of the row, which remains valid after the iteration
+// and can't be modified.
 func (r *Row) Clone() *Row {
 	var rr Row
 	cb := row.NewColumnBuffer()
-	err := cb.Copy(r.Row)
+	err := cb.DeepCopy(r.Row)
 	if err != nil {
 		panic(err)
 	}
 	var br database.BasicRow
-	br.ResetWith(r.Row.TableName(), r.Row.Key(), cb)
+	br.ResetWith(r.Row.TableName(), r.Row.Key().Clone(), cb.Freeze())
+	if o, ok := r.Row.(row.OrderedRow); ok {
+		br.SetOrderedColumns(o.OrderedColumns())
+	}
 	rr.Row = &br
 
 	return &rr
@@ -534,14 +1326,109 @@ func (r *Row) Scan(dest ...any) error {
 	return row.Scan(r.Row, dest...)
 }
 
+// StructScan scans the row into dest, which must be a pointer to a struct.
+// If dest implements RowScanner, its ScanRow method is called instead.
 func (r *Row) StructScan(dest any) error {
+	if s, ok := dest.(RowScanner); ok {
+		return s.ScanRow(r)
+	}
+
 	return row.StructScan(r.Row, dest)
 }
 
+// StructScanStrict is like StructScan, but returns an error if a column
+// is not mapped to a field of dest.
+func (r *Row) StructScanStrict(dest any) error {
+	if s, ok := dest.(RowScanner); ok {
+		return s.ScanRow(r)
+	}
+
+	return row.StructScanStrict(r.Row, dest)
+}
+
 func (r *Row) MapScan(dest map[string]any) error {
 	return row.MapScan(r.Row, dest)
 }
 
+// scanAny scans the row into dest, which is a pointer to a struct, a pointer
+// to a pointer to a struct, a pointer to a map, or any pointer
+// accepted by Scan. RowScanners and ValueUnmarshalers are always used.
+func (r *Row) scanAny(dest any) error {
+	t := reflect.TypeOf(dest).Elem()
+	if t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct {
+		p := reflect.New(t.Elem())
+		reflect.ValueOf(dest).Elem().Set(p)
+		dest = p.Interface()
+		t = t.Elem()
+	}
+
+	switch dest.(type) {
+	case RowScanner:
+		return r.StructScan(dest)
+	case ValueUnmarshaler:
+		return r.Scan(dest)
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if t != reflect.TypeOf(time.Time{}) {
+			return r.StructScan(dest)
+		}
+	case reflect.Map:
+		return row.MapScan(r.Row, dest)
+	}
+
+	return r.Scan(dest)
+}
+
 func (r *Row) MarshalJSON() ([]byte, error) {
 	return r.Row.MarshalJSON()
 }
+
+// MarshalCBOR encodes the row as a CBOR map (RFC 8949), whose keys are its columns.
+// Unlike JSON, it keeps integers, blobs, decimals and UUIDs as they are stored.
+func (r *Row) MarshalCBOR() ([]byte, error) {
+	return row.MarshalCBOR(r.Row)
+}
+
+// MarshalMsgpack encodes the row as a MessagePack map, whose keys are its columns.
+func (r *Row) MarshalMsgpack() ([]byte, error) {
+	return row.MarshalMsgpack(r.Row)
+}
+
+// CBORArgs decodes a CBOR map into named arguments, one per member,
+// so that CBOR documents can be inserted without being converted to JSON:
+//
+//	args, err := chai.CBORArgs(data)
+//	...
+//	err = db.Exec("INSERT INTO users (id, name) VALUES ($id, $name)", args...)
+func CBORArgs(data []byte) ([]any, error) {
+	var cb row.ColumnBuffer
+	if err := cb.UnmarshalCBOR(data); err != nil {
+		return nil, err
+	}
+
+	return namedArgs(&cb)
+}
+
+// MsgpackArgs decodes a MessagePack map into named arguments, one per member,
+// like CBORArgs. The values are converted like JSON values, except
+// for binary data and timestamps, converted to blobs and timestamps.
+func MsgpackArgs(data []byte) ([]any, error) {
+	r, err := row.NewFromMsgpack(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return namedArgs(r)
+}
+
+// namedArgs returns the columns of the row as named arguments.
+func namedArgs(r row.Row) ([]any, error) {
+	var args []any
+	err := r.Iterate(func(column string, v types.Value) error {
+		args = append(args, sql.Named(column, v))
+		return nil
+	})
+	return args, err
+}
@@ -1,10 +1,12 @@
 package chai_test
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/chaisql/chai"
 	"github.com/chaisql/chai/internal/testutil"
@@ -210,6 +212,196 @@ func TestQueryRow(t *testing.T) {
 	})
 }
 
+func TestQueryCache(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test(a INTEGER PRIMARY KEY, b TEXT);
+		INSERT INTO test (a, b) VALUES (1, 'foo');
+	`)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		r, err := db.QueryRow("SELECT * FROM test")
+		require.NoError(t, err)
+		testutil.RequireJSONEq(t, r, `{"a": 1, "b": "foo"}`)
+	}
+
+	// cached queries run with different parameters
+	// must not see the parameters of the previous runs
+	for i, b := range []string{"bar", "baz"} {
+		err = db.Exec("INSERT INTO test (a, b) VALUES (?, ?)", i+2, b)
+		require.NoError(t, err)
+	}
+
+	for i, b := range []string{"foo", "bar", "baz"} {
+		r, err := db.QueryRow("SELECT b FROM test WHERE a = ?", i+1)
+		require.NoError(t, err)
+		testutil.RequireJSONEq(t, r, fmt.Sprintf(`{"b": %q}`, b))
+	}
+
+	// the cached query must not be reused once the schema changes
+	err = db.Exec("ALTER TABLE test ADD COLUMN c INTEGER DEFAULT 10")
+	require.NoError(t, err)
+
+	r, err := db.QueryRow("SELECT * FROM test")
+	require.NoError(t, err)
+	testutil.RequireJSONEq(t, r, `{"a": 1, "b": "foo", "c": 10}`)
+
+	// queries prepared by a transaction modifying the schema
+	// see its changes
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	tx, err := conn.Begin(true)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	err = tx.Exec("ALTER TABLE test ADD COLUMN d INTEGER DEFAULT 20")
+	require.NoError(t, err)
+
+	r, err = tx.QueryRow("SELECT * FROM test")
+	require.NoError(t, err)
+	testutil.RequireJSONEq(t, r, `{"a": 1, "b": "foo", "c": 10, "d": 20}`)
+}
+
+func TestStatementClose(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	stmt, err := conn.Prepare("SELECT 1")
+	require.NoError(t, err)
+	withCtx := stmt.WithContext(context.Background())
+
+	require.NoError(t, stmt.Close())
+	require.Error(t, stmt.Exec())
+	require.Error(t, withCtx.Exec())
+
+	// the query cached by the statement can still be prepared
+	stmt, err = conn.Prepare("SELECT 1")
+	require.NoError(t, err)
+	require.NoError(t, stmt.Exec())
+}
+
+func TestRunningQueries(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test(a INTEGER PRIMARY KEY);
+		INSERT INTO test (a) VALUES (1), (2), (3);
+	`)
+	require.NoError(t, err)
+	require.Empty(t, db.RunningQueries())
+
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// the query is running until its result is closed
+	res, err := conn.Query("SELECT * FROM test")
+	require.NoError(t, err)
+
+	queries := db.RunningQueries()
+	require.Len(t, queries, 1)
+	require.Equal(t, "SELECT * FROM test", queries[0].SQL)
+
+	err = db.CancelQuery(queries[0].ID)
+	require.NoError(t, err)
+
+	err = res.Iterate(func(r *chai.Row) error {
+		return nil
+	})
+	require.ErrorIs(t, err, chai.ErrQueryCanceled)
+	require.NoError(t, res.Close())
+	require.Empty(t, db.RunningQueries())
+
+	err = db.CancelQuery(queries[0].ID)
+	require.Error(t, err)
+}
+
+func TestStatementTimeout(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test(a INTEGER PRIMARY KEY);
+		INSERT INTO test (a) VALUES (1), (2), (3);
+	`)
+	require.NoError(t, err)
+
+	conn, err := db.WithStatementTimeout(10 * time.Millisecond).Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	res, err := conn.Query("SELECT * FROM test")
+	require.NoError(t, err)
+	defer res.Close()
+
+	err = res.Iterate(func(r *chai.Row) error {
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+	require.ErrorIs(t, err, chai.ErrStatementTimeout)
+
+	// the timeout of the connection overrides the one of the database
+	conn.SetStatementTimeout(-1)
+
+	res, err = conn.Query("SELECT * FROM test")
+	require.NoError(t, err)
+	defer res.Close()
+
+	err = res.Iterate(func(r *chai.Row) error {
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestOpenLocked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db")
+
+	db, err := chai.Open(path)
+	require.NoError(t, err)
+
+	// the lock conflicts with the first open, even within the same process
+	_, err = chai.Open(path)
+	require.ErrorIs(t, err, chai.ErrDatabaseLocked)
+	require.ErrorContains(t, err, fmt.Sprintf("locked by process %d", os.Getpid()))
+
+	start := time.Now()
+	_, err = chai.OpenWith(path, &chai.Options{LockTimeout: 200 * time.Millisecond})
+	require.ErrorIs(t, err, chai.ErrDatabaseLocked)
+	require.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+
+	// waiting succeeds once the database is closed
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		_ = db.Close()
+	}()
+	db, err = chai.OpenWith(path, &chai.Options{LockTimeout: 10 * time.Second})
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	// in-memory databases are not locked
+	db1, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db1.Close()
+	db2, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db2.Close()
+}
+
 func TestIterateDeepCopy(t *testing.T) {
 	db, err := chai.Open(":memory:")
 	require.NoError(t, err)
@@ -253,4 +445,460 @@ func TestIterateDeepCopy(t *testing.T) {
 	require.Equal(t, len(items), 2)
 	require.Equal(t, &item{A: 2, B: "sample text 2"}, items[0])
 	require.Equal(t, &item{A: 1, B: "sample text 1"}, items[1])
+
+	res, err = conn.Query(`SELECT * FROM foo ORDER BY a DESC`)
+	require.NoError(t, err)
+	defer res.Close()
+
+	var rows []*chai.Row
+	err = res.Iterate(func(r *chai.Row) error {
+		rows = append(rows, r.Clone())
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.Len(t, rows, 2)
+	for i, r := range rows {
+		var it item
+		require.NoError(t, r.StructScan(&it))
+		require.Equal(t, items[i], &it)
+	}
+}
+
+func TestOutOfRange(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE test(a INT16)")
+	require.NoError(t, err)
+
+	err = db.Exec("INSERT INTO test (a) VALUES (40000)")
+	require.ErrorIs(t, err, chai.ErrOutOfRange)
+
+	_, err = db.QueryRow("SELECT 2147483647 + 1")
+	require.ErrorIs(t, err, chai.ErrOutOfRange)
+}
+
+func TestCBOR(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test(
+			id BIGINT PRIMARY KEY, name TEXT, data BLOB, price DECIMAL(10, 2),
+			ts TIMESTAMP, u UUID, ratio DOUBLE, ok BOOL, missing TEXT
+		);
+		CREATE TABLE copy(
+			id BIGINT PRIMARY KEY, name TEXT, data BLOB, price DECIMAL(10, 2),
+			ts TIMESTAMP, u UUID, ratio DOUBLE, ok BOOL, missing TEXT
+		);
+		INSERT INTO test VALUES (
+			9007199254740993, 'foo', '\xdeadbeef', 12.50,
+			'2024-05-01T10:30:00.123456Z', 'a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11', 0.5, true, NULL
+		);
+	`)
+	require.NoError(t, err)
+
+	r, err := db.QueryRow("SELECT * FROM test")
+	require.NoError(t, err)
+	data, err := r.MarshalCBOR()
+	require.NoError(t, err)
+
+	args, err := chai.CBORArgs(data)
+	require.NoError(t, err)
+	require.Len(t, args, 9)
+
+	err = db.Exec(`INSERT INTO copy VALUES ($id, $name, $data, $price, $ts, $u, $ratio, $ok, $missing)`, args...)
+	require.NoError(t, err)
+
+	r, err = db.QueryRow("SELECT * FROM copy")
+	require.NoError(t, err)
+	got, err := r.MarshalCBOR()
+	require.NoError(t, err)
+	require.Equal(t, data, got)
+
+	var n int
+	r, err = db.QueryRow("SELECT COUNT(*) FROM copy WHERE id = 9007199254740993 AND data = '\\xdeadbeef' AND price = 12.5")
+	require.NoError(t, err)
+	require.NoError(t, r.Scan(&n))
+	require.Equal(t, 1, n)
+}
+
+func TestMsgpack(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`CREATE TABLE test(id INT PRIMARY KEY, name TEXT, data BLOB, ts TIMESTAMP, price DECIMAL(10, 2))`)
+	require.NoError(t, err)
+
+	// {"id": 1, "name": "foo", "data": 0x00ff, "ts": 2020-01-01T00:00:00Z, "price": "12.50"}
+	payload := []byte("\x85\xa2id\x01\xa4name\xa3foo\xa4data\xc4\x02\x00\xff\xa2ts\xd6\xff\x5e\x0b\xe1\x00\xa5price\xa512.50")
+	args, err := chai.MsgpackArgs(payload)
+	require.NoError(t, err)
+
+	err = db.Exec("INSERT INTO test (id, name, data, ts, price) VALUES ($id, $name, $data, $ts, $price)", args...)
+	require.NoError(t, err)
+
+	r, err := db.QueryRow("SELECT * FROM test")
+	require.NoError(t, err)
+	j, err := r.MarshalJSON()
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id": 1, "name": "foo", "data": "AP8=", "ts": "2020-01-01T00:00:00Z", "price": 12.50}`, string(j))
+
+	data, err := r.MarshalMsgpack()
+	require.NoError(t, err)
+	require.Equal(t, "\x85\xa4data\xc4\x02\x00\xff\xa2id\x01\xa4name\xa3foo\xa5price\xa512.50\xa2ts\xd6\xff\x5e\x0b\xe1\x00", string(data))
+}
+
+// money is stored as a number of cents.
+type money struct {
+	cents int64
+}
+
+func (m money) MarshalChaiValue() (any, error) {
+	return m.cents, nil
+}
+
+func (m *money) UnmarshalChaiValue(v any) error {
+	switch x := v.(type) {
+	case nil:
+		m.cents = 0
+	case int64:
+		m.cents = x
+	default:
+		return fmt.Errorf("cannot scan %T into money", v)
+	}
+
+	return nil
+}
+
+type invoice struct {
+	id    int
+	total money
+}
+
+func (i *invoice) ScanRow(r *chai.Row) error {
+	return r.Scan(&i.id, &i.total)
+}
+
+func TestValueMarshaler(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE test(id INT PRIMARY KEY, total BIGINT)")
+	require.NoError(t, err)
+
+	err = db.Exec("INSERT INTO test (id, total) VALUES (1, ?), (2, NULL)", money{1250})
+	require.NoError(t, err)
+
+	r, err := db.QueryRow("SELECT total FROM test WHERE id = 1")
+	require.NoError(t, err)
+	var m money
+	require.NoError(t, r.Scan(&m))
+	require.Equal(t, money{1250}, m)
+
+	r, err = db.QueryRow("SELECT id, total FROM test WHERE id = 1")
+	require.NoError(t, err)
+	var inv invoice
+	require.NoError(t, r.StructScan(&inv))
+	require.Equal(t, invoice{id: 1, total: money{1250}}, inv)
+
+	var s struct {
+		ID    int
+		Total *money
+	}
+	r, err = db.QueryRow("SELECT id, total FROM test WHERE id = 2")
+	require.NoError(t, err)
+	require.NoError(t, r.StructScan(&s))
+	require.Equal(t, 2, s.ID)
+	require.Nil(t, s.Total)
+
+	r, err = db.QueryRow("SELECT 'foo'")
+	require.NoError(t, err)
+	require.EqualError(t, r.Scan(&m), "cannot scan string into money")
+}
+
+func TestStructScanStrict(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE test(id INT PRIMARY KEY, name TEXT, ttl TEXT)")
+	require.NoError(t, err)
+
+	err = db.Exec("INSERT INTO test (id, name, ttl) VALUES (1, 'foo', '1h')")
+	require.NoError(t, err)
+
+	type user struct {
+		ID   int
+		Name string
+	}
+
+	r, err := db.QueryRow("SELECT * FROM test")
+	require.NoError(t, err)
+
+	var u user
+	require.NoError(t, r.StructScan(&u))
+	require.Equal(t, user{ID: 1, Name: "foo"}, u)
+	require.EqualError(t, r.StructScanStrict(&u), `column "ttl" is not mapped to a field of chai_test.user`)
+
+	var s struct {
+		user
+		TTL time.Duration
+	}
+	require.NoError(t, r.StructScanStrict(&s))
+	require.Equal(t, user{ID: 1, Name: "foo"}, s.user)
+	require.Equal(t, time.Hour, s.TTL)
+}
+
+func TestQueryAs(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE users(id INT PRIMARY KEY, name TEXT, address TEXT, phones TEXT)")
+	require.NoError(t, err)
+
+	type address struct {
+		City string `json:"city"`
+	}
+	type phone struct {
+		Kind   string `json:"kind"`
+		Number string `json:"number"`
+	}
+	type user struct {
+		ID      int
+		Name    string
+		Address address
+		Phones  []phone
+	}
+
+	err = db.Exec(`INSERT INTO users VALUES (1, 'foo', '{"city": "Lyon"}', '[{"kind": "home", "number": "123"}]')`)
+	require.NoError(t, err)
+	err = db.Exec(`INSERT INTO users VALUES (2, 'bar', '{"city": "Paris"}', NULL)`)
+	require.NoError(t, err)
+
+	users, err := chai.QueryAs[user](db, "SELECT * FROM users ORDER BY id")
+	require.NoError(t, err)
+	require.Equal(t, []user{
+		{1, "foo", address{"Lyon"}, []phone{{"home", "123"}}},
+		{2, "bar", address{"Paris"}, nil},
+	}, users)
+
+	ptrs, err := chai.QueryAs[*user](db, "SELECT id, name FROM users WHERE id = ?", 2)
+	require.NoError(t, err)
+	require.Equal(t, []*user{{ID: 2, Name: "bar"}}, ptrs)
+
+	maps, err := chai.QueryAs[map[string]any](db, "SELECT id, name FROM users ORDER BY id DESC")
+	require.NoError(t, err)
+	require.Equal(t, []map[string]any{
+		{"id": int32(2), "name": "bar"},
+		{"id": int32(1), "name": "foo"},
+	}, maps)
+
+	names, err := chai.QueryAs[string](db, "SELECT name FROM users ORDER BY name")
+	require.NoError(t, err)
+	require.Equal(t, []string{"bar", "foo"}, names)
+
+	none, err := chai.QueryAs[user](db, "SELECT * FROM users WHERE id > 10")
+	require.NoError(t, err)
+	require.Empty(t, none)
+
+	_, err = chai.QueryAs[int](db, "SELECT name FROM users")
+	require.Error(t, err)
+
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	res, err := conn.Query("SELECT id, address FROM users ORDER BY id")
+	require.NoError(t, err)
+	users, err = chai.ScanAll[user](res)
+	require.NoError(t, err)
+	require.Equal(t, []user{{ID: 1, Address: address{"Lyon"}}, {ID: 2, Address: address{"Paris"}}}, users)
+}
+
+func TestContext(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db")
+	db, err := chai.Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE test(a INTEGER PRIMARY KEY)")
+	require.NoError(t, err)
+	for i := 1; i <= 1000; i++ {
+		err = db.Exec("INSERT INTO test (a) VALUES (?)", i)
+		require.NoError(t, err)
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	t.Run("Open", func(t *testing.T) {
+		// the wait for the lock stops when the context is done
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		_, err := chai.OpenContext(ctx, path, &chai.Options{LockTimeout: 10 * time.Second})
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("Exec", func(t *testing.T) {
+		err := db.ExecContext(canceled, "DELETE FROM test")
+		require.ErrorIs(t, err, context.Canceled)
+
+		_, err = db.QueryRowContext(canceled, "SELECT COUNT(*) FROM test")
+		require.ErrorIs(t, err, context.Canceled)
+
+		r, err := db.QueryRowContext(context.Background(), "SELECT COUNT(*) FROM test")
+		require.NoError(t, err)
+		var n int
+		require.NoError(t, r.Scan(&n))
+		require.Equal(t, 1000, n)
+	})
+
+	t.Run("Iterate", func(t *testing.T) {
+		conn, err := db.Connect()
+		require.NoError(t, err)
+		defer conn.Close()
+
+		res, err := conn.QueryContext(context.Background(), "SELECT * FROM test WHERE a % 2000 = 1999")
+		require.NoError(t, err)
+		defer res.Close()
+
+		// the scan stops even though no row is returned
+		err = res.IterateContext(canceled, func(r *chai.Row) error {
+			return nil
+		})
+		require.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("Begin", func(t *testing.T) {
+		conn1, err := db.Connect()
+		require.NoError(t, err)
+		defer conn1.Close()
+		conn2, err := db.Connect()
+		require.NoError(t, err)
+		defer conn2.Close()
+
+		tx1, err := conn1.Begin(true)
+		require.NoError(t, err)
+		defer tx1.Rollback()
+
+		// the wait for the write lock stops when the context is done
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err = conn2.BeginContext(ctx, true)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.NoError(t, tx1.Rollback())
+
+		// the queries of the transaction use its context
+		ctx, cancel = context.WithCancel(context.Background())
+		tx2, err := conn2.BeginContext(ctx, true)
+		require.NoError(t, err)
+		defer tx2.Rollback()
+
+		err = tx2.Exec("DELETE FROM test WHERE a = 1")
+		require.NoError(t, err)
+		cancel()
+		err = tx2.Exec("DELETE FROM test WHERE a = 2")
+		require.ErrorIs(t, err, context.Canceled)
+		err = tx2.ExecContext(context.Background(), "DELETE FROM test WHERE a = 2")
+		require.NoError(t, err)
+	})
+}
+
+func TestResultCursor(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE test(a INT PRIMARY KEY, b TEXT)")
+	require.NoError(t, err)
+	for i := range 10 {
+		err = db.Exec("INSERT INTO test (a, b) VALUES (?, ?)", i, fmt.Sprintf("foo%d", i))
+		require.NoError(t, err)
+	}
+
+	conn, err := db.Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	t.Run("Next", func(t *testing.T) {
+		res, err := conn.Query("SELECT a, b FROM test")
+		require.NoError(t, err)
+		defer res.Close()
+
+		var n int
+		for res.Next() {
+			var a int
+			var b string
+			require.NoError(t, res.Scan(&a, &b))
+			require.Equal(t, n, a)
+			require.Equal(t, fmt.Sprintf("foo%d", n), b)
+			n++
+		}
+		require.NoError(t, res.Err())
+		require.Equal(t, 10, n)
+
+		// the cursor remains at the end
+		require.False(t, res.Next())
+		require.Nil(t, res.Row())
+		require.Error(t, res.Scan())
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		res, err := conn.Query("SELECT a FROM test")
+		require.NoError(t, err)
+
+		require.True(t, res.Next())
+		require.NotNil(t, res.Row())
+		require.NoError(t, res.Close())
+		require.False(t, res.Next())
+	})
+
+	t.Run("Rows", func(t *testing.T) {
+		res, err := conn.Query("SELECT a FROM test")
+		require.NoError(t, err)
+		defer res.Close()
+
+		var all []int
+		for r, err := range res.Rows() {
+			require.NoError(t, err)
+			var a int
+			require.NoError(t, r.Scan(&a))
+			if a == 5 {
+				break
+			}
+			all = append(all, a)
+		}
+		require.Equal(t, []int{0, 1, 2, 3, 4}, all)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		res, err := conn.Query("SELECT CAST(b AS INT) FROM test")
+		require.NoError(t, err)
+		defer res.Close()
+
+		require.False(t, res.Next())
+		require.Error(t, res.Err())
+
+		res, err = conn.Query("SELECT CAST(b AS INT) FROM test")
+		require.NoError(t, err)
+		defer res.Close()
+
+		var errs []error
+		for r, err := range res.Rows() {
+			require.Nil(t, r)
+			errs = append(errs, err)
+		}
+		require.Len(t, errs, 1)
+		require.Error(t, errs[0])
+	})
 }
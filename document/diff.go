@@ -0,0 +1,148 @@
+package document
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// Operations of a change.
+const (
+	OpAdd     = "add"
+	OpRemove  = "remove"
+	OpReplace = "replace"
+)
+
+// A Change is a modification of a JSON document.
+// Its JSON encoding is an operation of a JSON patch (RFC 6902).
+type Change struct {
+	// Op is OpAdd, OpRemove or OpReplace, or
+	// OpMove, OpCopy or OpTest in a JSON patch.
+	Op string `json:"op"`
+	// Path is the JSON pointer (RFC 6901) of the modified value.
+	// The empty path is the whole document.
+	Path string `json:"path"`
+	// Value is the new value, for OpAdd and OpReplace,
+	// or the expected value for OpTest.
+	Value json.RawMessage `json:"value,omitempty"`
+	// From is the JSON pointer of the value moved or copied
+	// by OpMove and OpCopy.
+	From string `json:"from,omitempty"`
+	// OldValue is the previous value, for OpRemove and OpReplace.
+	// It is not part of RFC 6902 and is ignored by the implementations.
+	OldValue json.RawMessage `json:"old_value,omitempty"`
+}
+
+// Diff returns the list of changes that transform the JSON document a into b.
+// Applied in order, they produce b. Members of objects are compared by key,
+// elements of arrays by position: the removed elements are at the end of a,
+// the added elements at the end of b.
+func Diff(a, b []byte) ([]Change, error) {
+	na, err := parse(a)
+	if err != nil {
+		return nil, err
+	}
+	nb, err := parse(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var d differ
+	err = d.diff(nil, na, nb)
+	return d.changes, err
+}
+
+type differ struct {
+	changes []Change
+}
+
+func (d *differ) diff(path []string, a, b *node) error {
+	switch {
+	case a.kind == objectKind && b.kind == objectKind:
+		return d.diffObjects(path, a, b)
+	case a.kind == arrayKind && b.kind == arrayKind:
+		return d.diffArrays(path, a, b)
+	case a.equal(b):
+		return nil
+	}
+
+	return d.add(OpReplace, path, a, b)
+}
+
+func (d *differ) diffObjects(path []string, a, b *node) error {
+	for i, k := range a.keys {
+		if b.get(k) == nil {
+			if err := d.add(OpRemove, append(path[:len(path):len(path)], k), a.values[i], nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i, k := range b.keys {
+		p := append(path[:len(path):len(path)], k)
+
+		old := a.get(k)
+		if old == nil {
+			if err := d.add(OpAdd, p, nil, b.values[i]); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := d.diff(p, old, b.values[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *differ) diffArrays(path []string, a, b *node) error {
+	n := min(len(a.elems), len(b.elems))
+	for i := 0; i < n; i++ {
+		p := append(path[:len(path):len(path)], strconv.Itoa(i))
+		if err := d.diff(p, a.elems[i], b.elems[i]); err != nil {
+			return err
+		}
+	}
+
+	// remove the last elements first, to keep the positions valid
+	for i := len(a.elems) - 1; i >= n; i-- {
+		p := append(path[:len(path):len(path)], strconv.Itoa(i))
+		if err := d.add(OpRemove, p, a.elems[i], nil); err != nil {
+			return err
+		}
+	}
+
+	for i := n; i < len(b.elems); i++ {
+		p := append(path[:len(path):len(path)], strconv.Itoa(i))
+		if err := d.add(OpAdd, p, nil, b.elems[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *differ) add(op string, path []string, old, v *node) error {
+	c := Change{
+		Op:   op,
+		Path: pointer(path),
+	}
+
+	var err error
+	if old != nil {
+		c.OldValue, err = old.MarshalJSON()
+		if err != nil {
+			return err
+		}
+	}
+	if v != nil {
+		c.Value, err = v.MarshalJSON()
+		if err != nil {
+			return err
+		}
+	}
+
+	d.changes = append(d.changes, c)
+	return nil
+}
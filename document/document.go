@@ -0,0 +1,272 @@
+// Package document compares and modifies JSON documents,
+// such as the ones stored in TEXT columns.
+//
+// Object keys keep their order: the members added to an object
+// are appended to it and the other members stay in place.
+package document
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+)
+
+type kind uint8
+
+const (
+	scalarKind kind = iota
+	objectKind
+	arrayKind
+)
+
+// node is a decoded JSON value, whose objects keep the order of their keys.
+type node struct {
+	kind kind
+
+	// members of an object
+	keys   []string
+	values []*node
+
+	// elements of an array
+	elems []*node
+
+	// string, json.Number, bool or nil
+	scalar any
+}
+
+// parse decodes a JSON document.
+func parse(data []byte) (*node, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	n, err := decodeNode(dec)
+	if err != nil {
+		return nil, errors.Wrap(err, "malformed JSON")
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("malformed JSON: unexpected data after the document")
+	}
+
+	return n, nil
+}
+
+func decodeNode(dec *json.Decoder) (*node, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		n := node{kind: objectKind}
+		for dec.More() {
+			k, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			v, err := decodeNode(dec)
+			if err != nil {
+				return nil, err
+			}
+
+			n.set(k.(string), v)
+		}
+		_, err = dec.Token()
+		return &n, err
+	case json.Delim('['):
+		n := node{kind: arrayKind}
+		for dec.More() {
+			v, err := decodeNode(dec)
+			if err != nil {
+				return nil, err
+			}
+
+			n.elems = append(n.elems, v)
+		}
+		_, err = dec.Token()
+		return &n, err
+	}
+
+	return &node{scalar: tok}, nil
+}
+
+// get returns the value of the member of an object, or nil.
+func (n *node) get(key string) *node {
+	if i := n.index(key); i >= 0 {
+		return n.values[i]
+	}
+
+	return nil
+}
+
+func (n *node) index(key string) int {
+	for i, k := range n.keys {
+		if k == key {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// set replaces the value of the member of an object, or appends it.
+func (n *node) set(key string, v *node) {
+	if i := n.index(key); i >= 0 {
+		n.values[i] = v
+		return
+	}
+
+	n.keys = append(n.keys, key)
+	n.values = append(n.values, v)
+}
+
+// delete removes the member of an object, if any.
+func (n *node) delete(key string) {
+	if i := n.index(key); i >= 0 {
+		n.keys = append(n.keys[:i], n.keys[i+1:]...)
+		n.values = append(n.values[:i], n.values[i+1:]...)
+	}
+}
+
+func (n *node) isNull() bool {
+	return n.kind == scalarKind && n.scalar == nil
+}
+
+// equal returns true if both values are equal.
+// Numbers are compared by value.
+func (n *node) equal(other *node) bool {
+	if n.kind != other.kind {
+		return false
+	}
+
+	switch n.kind {
+	case objectKind:
+		if len(n.keys) != len(other.keys) {
+			return false
+		}
+		for i, k := range n.keys {
+			o := other.get(k)
+			if o == nil || !n.values[i].equal(o) {
+				return false
+			}
+		}
+		return true
+	case arrayKind:
+		if len(n.elems) != len(other.elems) {
+			return false
+		}
+		for i := range n.elems {
+			if !n.elems[i].equal(other.elems[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	a, aok := n.scalar.(json.Number)
+	b, bok := other.scalar.(json.Number)
+	if aok && bok {
+		x, errx := strconv.ParseFloat(string(a), 64)
+		y, erry := strconv.ParseFloat(string(b), 64)
+		if errx == nil && erry == nil {
+			return x == y
+		}
+		return a == b
+	}
+
+	return n.scalar == other.scalar
+}
+
+// clone returns a deep copy of the value.
+func (n *node) clone() *node {
+	cp := *n
+	if n.kind == objectKind {
+		cp.keys = append([]string(nil), n.keys...)
+		cp.values = make([]*node, len(n.values))
+		for i, v := range n.values {
+			cp.values[i] = v.clone()
+		}
+	}
+	if n.kind == arrayKind {
+		cp.elems = make([]*node, len(n.elems))
+		for i, v := range n.elems {
+			cp.elems[i] = v.clone()
+		}
+	}
+
+	return &cp
+}
+
+// MarshalJSON encodes the value in compact JSON.
+func (n *node) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	err := n.write(&buf)
+	return buf.Bytes(), err
+}
+
+func (n *node) write(buf *bytes.Buffer) error {
+	switch n.kind {
+	case objectKind:
+		buf.WriteByte('{')
+		for i, k := range n.keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeScalar(buf, k); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err := n.values[i].write(buf); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	case arrayKind:
+		buf.WriteByte('[')
+		for i, v := range n.elems {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := v.write(buf); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	}
+
+	return writeScalar(buf, n.scalar)
+}
+
+func writeScalar(buf *bytes.Buffer, v any) error {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+
+	// remove the newline added by the encoder
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
+
+// escapes the reference tokens of JSON pointers.
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// pointer returns the JSON pointer (RFC 6901) made of the given reference tokens.
+func pointer(tokens []string) string {
+	var s strings.Builder
+	for _, t := range tokens {
+		s.WriteByte('/')
+		s.WriteString(pointerEscaper.Replace(t))
+	}
+
+	return s.String()
+}
@@ -0,0 +1,143 @@
+package document_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/chaisql/chai/document"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergePatch(t *testing.T) {
+	// examples of the appendix A of RFC 7386
+	tests := []struct {
+		doc, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.doc+" "+test.patch, func(t *testing.T) {
+			got, err := document.MergePatch([]byte(test.doc), []byte(test.patch))
+			require.NoError(t, err)
+			require.Equal(t, test.want, string(got))
+		})
+	}
+
+	t.Run("keeps the order of the keys", func(t *testing.T) {
+		got, err := document.MergePatch([]byte(`{"z": 1, "a": 2, "m": 3}`), []byte(`{"a": 4, "b": 5}`))
+		require.NoError(t, err)
+		require.Equal(t, `{"z":1,"a":4,"m":3,"b":5}`, string(got))
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		_, err := document.MergePatch([]byte(`{"a": 1}`), []byte(`{"a": }`))
+		require.Error(t, err)
+
+		_, err = document.MergePatch([]byte(`{"a": 1} {}`), []byte(`{}`))
+		require.Error(t, err)
+	})
+}
+
+func TestDiff(t *testing.T) {
+	changes, err := document.Diff(
+		[]byte(`{"a": 1, "b": {"c": "x", "d": [1, 2, 3]}, "e": true}`),
+		[]byte(`{"a": 1, "b": {"c": "y", "d": [1, 2]}, "f": null}`),
+	)
+	require.NoError(t, err)
+
+	require.Equal(t, []document.Change{
+		{Op: document.OpRemove, Path: "/e", OldValue: json.RawMessage(`true`)},
+		{Op: document.OpReplace, Path: "/b/c", Value: json.RawMessage(`"y"`), OldValue: json.RawMessage(`"x"`)},
+		{Op: document.OpRemove, Path: "/b/d/2", OldValue: json.RawMessage(`3`)},
+		{Op: document.OpAdd, Path: "/f", Value: json.RawMessage(`null`)},
+	}, changes)
+
+	changes, err = document.Diff([]byte(`{"a": [1, {"b": 2}]}`), []byte(`{"a": [1.0, {"b": 2}]}`))
+	require.NoError(t, err)
+	require.Empty(t, changes)
+}
+
+func TestPatch(t *testing.T) {
+	// examples of the appendix A of RFC 6902
+	tests := []struct {
+		name, doc, patch, want string
+	}{
+		{"add member", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"foo":"bar","baz":"qux"}`},
+		{"add element", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{"remove member", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{"remove element", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{"replace", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{"move member", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{"move element", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{"test", `{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`, `{"baz":"qux","foo":["a",2,"c"]}`},
+		{"add nested", `{"foo":"bar"}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, `{"foo":"bar","child":{"grandchild":{}}}`},
+		{"escaped path", `{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10}]`, `{"/":9,"~1":10}`},
+		{"append", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`},
+		{"copy", `{"a":{"b":[1]}}`, `[{"op":"copy","from":"/a","path":"/c"},{"op":"add","path":"/c/b/-","value":2}]`, `{"a":{"b":[1]},"c":{"b":[1,2]}}`},
+		{"add null", `{}`, `[{"op":"add","path":"/a","value":null}]`, `{"a":null}`},
+		{"replace document", `{"a":1}`, `[{"op":"replace","path":"","value":[1]}]`, `[1]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := document.Patch([]byte(test.doc), []byte(test.patch))
+			require.NoError(t, err)
+			require.Equal(t, test.want, string(got))
+		})
+	}
+
+	errs := []struct {
+		name, doc, patch string
+	}{
+		{"failed test", `{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`},
+		{"missing parent", `{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`},
+		{"remove missing member", `{"foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`},
+		{"replace missing element", `{"foo":[1]}`, `[{"op":"replace","path":"/foo/1","value":2}]`},
+		{"invalid index", `{"foo":[1]}`, `[{"op":"add","path":"/foo/01","value":2}]`},
+		{"move into child", `{"a":{"b":1}}`, `[{"op":"move","from":"/a","path":"/a/c"}]`},
+		{"unknown operation", `{}`, `[{"op":"swap","path":"/a"}]`},
+		{"missing value", `{}`, `[{"op":"add","path":"/a"}]`},
+		{"invalid pointer", `{}`, `[{"op":"add","path":"a","value":1}]`},
+		{"malformed patch", `{}`, `{"op":"add","path":"/a","value":1}`},
+	}
+
+	for _, test := range errs {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := document.Patch([]byte(test.doc), []byte(test.patch))
+			require.Error(t, err)
+		})
+	}
+
+	t.Run("apply a diff", func(t *testing.T) {
+		a := []byte(`{"a":1,"b":{"c":"x","d":[1,2,3]},"e":true}`)
+		b := []byte(`{"a":1,"b":{"c":"y","d":[1,2]},"f":null}`)
+
+		changes, err := document.Diff(a, b)
+		require.NoError(t, err)
+
+		got, err := document.Apply(a, changes)
+		require.NoError(t, err)
+		require.Equal(t, string(b), string(got))
+	})
+
+	t.Run("errors name the operation", func(t *testing.T) {
+		doc := []byte(`{"a":1}`)
+		_, err := document.Patch(doc, []byte(`[{"op":"remove","path":"/a"},{"op":"test","path":"/a","value":1}]`))
+		require.EqualError(t, err, `operation 1 (test "/a"): path "/a" does not exist`)
+	})
+}
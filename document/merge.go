@@ -0,0 +1,40 @@
+package document
+
+// MergePatch applies the JSON merge patch (RFC 7386) to the JSON document
+// and returns the result. The members of the patch replace those of the document,
+// recursively for objects, and its null members remove them.
+// A patch that is not an object replaces the whole document.
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	d, err := parse(doc)
+	if err != nil {
+		return nil, err
+	}
+	p, err := parse(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	return mergePatch(d, p).MarshalJSON()
+}
+
+func mergePatch(target, patch *node) *node {
+	if patch.kind != objectKind {
+		return patch
+	}
+
+	if target == nil || target.kind != objectKind {
+		target = &node{kind: objectKind}
+	}
+
+	for i, k := range patch.keys {
+		v := patch.values[i]
+		if v.isNull() {
+			target.delete(k)
+			continue
+		}
+
+		target.set(k, mergePatch(target.get(k), v))
+	}
+
+	return target
+}
@@ -0,0 +1,287 @@
+package document
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Operations of a JSON patch, in addition to OpAdd, OpRemove and OpReplace.
+const (
+	OpMove = "move"
+	OpCopy = "copy"
+	OpTest = "test"
+)
+
+// Patch applies the JSON patch (RFC 6902) to the JSON document and returns the result.
+// The patch is an array of operations, such as
+// [{"op": "replace", "path": "/a/b", "value": 1}, {"op": "remove", "path": "/c/0"}].
+func Patch(doc, patch []byte) ([]byte, error) {
+	var changes []Change
+	if err := json.Unmarshal(patch, &changes); err != nil {
+		return nil, errors.Wrap(err, "malformed JSON patch")
+	}
+
+	return Apply(doc, changes)
+}
+
+// Apply applies the changes to the JSON document, in order, and returns the result.
+// It fails if any of them can't be applied, or if a test operation fails.
+func Apply(doc []byte, changes []Change) ([]byte, error) {
+	root, err := parse(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range changes {
+		root, err = apply(root, &changes[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, "operation %d (%s %q)", i, changes[i].Op, changes[i].Path)
+		}
+	}
+
+	return root.MarshalJSON()
+}
+
+func apply(root *node, c *Change) (*node, error) {
+	path, err := parsePointer(c.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch c.Op {
+	case OpAdd, OpReplace, OpTest:
+		if c.Value == nil {
+			return nil, errors.New("missing value")
+		}
+
+		v, err := parse(c.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		switch c.Op {
+		case OpAdd:
+			return add(root, path, v)
+		case OpReplace:
+			return replace(root, path, v)
+		}
+
+		cur, err := get(root, path)
+		if err != nil {
+			return nil, err
+		}
+		if !cur.equal(v) {
+			return nil, errors.New("test failed")
+		}
+		return root, nil
+	case OpRemove:
+		return remove(root, path)
+	case OpMove, OpCopy:
+		from, err := parsePointer(c.From)
+		if err != nil {
+			return nil, err
+		}
+
+		v, err := get(root, from)
+		if err != nil {
+			return nil, err
+		}
+
+		if c.Op == OpCopy {
+			return add(root, path, v.clone())
+		}
+
+		if isPrefix(from, path) {
+			if len(from) == len(path) {
+				return root, nil
+			}
+			return nil, errors.New("cannot move a value into one of its children")
+		}
+
+		root, err = remove(root, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(root, path, v)
+	}
+
+	return nil, errors.Errorf("unknown operation %q", c.Op)
+}
+
+// add inserts v at the given path. The members of an object are replaced,
+// the elements of an array are shifted.
+func add(root *node, path []string, v *node) (*node, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+
+	parent, err := get(root, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	last := path[len(path)-1]
+	switch parent.kind {
+	case objectKind:
+		parent.set(last, v)
+	case arrayKind:
+		i := len(parent.elems)
+		if last != "-" {
+			i, err = arrayIndex(last, len(parent.elems)+1)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		parent.elems = append(parent.elems, nil)
+		copy(parent.elems[i+1:], parent.elems[i:])
+		parent.elems[i] = v
+	default:
+		return nil, errors.Errorf("path %q does not exist", pointer(path))
+	}
+
+	return root, nil
+}
+
+// remove removes the value at the given path, which must exist.
+func remove(root *node, path []string) (*node, error) {
+	if len(path) == 0 {
+		return nil, errors.New("cannot remove the whole document")
+	}
+
+	parent, last, i, err := lookupParent(root, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if parent.kind == objectKind {
+		parent.delete(last)
+	} else {
+		parent.elems = append(parent.elems[:i], parent.elems[i+1:]...)
+	}
+
+	return root, nil
+}
+
+// replace replaces the value at the given path, which must exist.
+func replace(root *node, path []string, v *node) (*node, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+
+	parent, last, i, err := lookupParent(root, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if parent.kind == objectKind {
+		parent.set(last, v)
+	} else {
+		parent.elems[i] = v
+	}
+
+	return root, nil
+}
+
+// lookupParent returns the object or the array containing the value at the given path,
+// the key of the value, and its index in the case of an array.
+// It fails if the value doesn't exist.
+func lookupParent(root *node, path []string) (*node, string, int, error) {
+	parent, err := get(root, path[:len(path)-1])
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	last := path[len(path)-1]
+	switch parent.kind {
+	case objectKind:
+		if parent.get(last) == nil {
+			return nil, "", 0, errors.Errorf("path %q does not exist", pointer(path))
+		}
+		return parent, last, 0, nil
+	case arrayKind:
+		i, err := arrayIndex(last, len(parent.elems))
+		if err != nil {
+			return nil, "", 0, err
+		}
+		return parent, last, i, nil
+	}
+
+	return nil, "", 0, errors.Errorf("path %q does not exist", pointer(path))
+}
+
+// get returns the value at the given path.
+func get(root *node, path []string) (*node, error) {
+	n := root
+	for i, t := range path {
+		var next *node
+		switch n.kind {
+		case objectKind:
+			next = n.get(t)
+		case arrayKind:
+			idx, err := arrayIndex(t, len(n.elems))
+			if err != nil {
+				return nil, err
+			}
+			next = n.elems[idx]
+		}
+		if next == nil {
+			return nil, errors.Errorf("path %q does not exist", pointer(path[:i+1]))
+		}
+
+		n = next
+	}
+
+	return n, nil
+}
+
+// arrayIndex parses the index of an element of an array, which must be lower than max.
+func arrayIndex(t string, max int) (int, error) {
+	i, err := strconv.Atoi(t)
+	if err != nil || i < 0 || (len(t) > 1 && t[0] == '0') || strings.HasPrefix(t, "+") {
+		return 0, errors.Errorf("invalid array index %q", t)
+	}
+	if i >= max {
+		return 0, errors.Errorf("array index %d out of bounds", i)
+	}
+
+	return i, nil
+}
+
+// unescapes the reference tokens of JSON pointers.
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// parsePointer returns the reference tokens of a JSON pointer (RFC 6901).
+func parsePointer(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if s[0] != '/' {
+		return nil, errors.Errorf("invalid JSON pointer %q", s)
+	}
+
+	tokens := strings.Split(s[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = pointerUnescaper.Replace(t)
+	}
+
+	return tokens, nil
+}
+
+// isPrefix returns true if path starts with prefix.
+func isPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"io"
+	"reflect"
 	"sync"
 	"time"
 
@@ -44,6 +45,17 @@ func (d sqlDriver) OpenConnector(name string) (driver.Connector, error) {
 	}, nil
 }
 
+// NewConnector returns a connector using an open database,
+// to be passed to sql.OpenDB. Each connection of the pool of the returned
+// sql.DB is a connection to db. Closing the sql.DB doesn't close db.
+func NewConnector(db *chai.DB) driver.Connector {
+	return &connector{
+		db:     db,
+		driver: sqlDriver{},
+		shared: true,
+	}
+}
+
 var (
 	_ driver.Connector = (*connector)(nil)
 	_ io.Closer        = (*connector)(nil)
@@ -53,9 +65,15 @@ type connector struct {
 	driver    driver.Driver
 	db        *chai.DB
 	closeOnce sync.Once
+	// if true, the database is not closed with the connector.
+	shared bool
 }
 
 func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	cc, err := c.db.Connect()
 	if err != nil {
 		return nil, err
@@ -72,6 +90,10 @@ func (c *connector) Driver() driver.Driver {
 }
 
 func (c *connector) Close() error {
+	if c.shared {
+		return nil
+	}
+
 	var err error
 	c.closeOnce.Do(func() {
 		err = c.db.Close()
@@ -79,6 +101,17 @@ func (c *connector) Close() error {
 	return err
 }
 
+var (
+	_ driver.Conn               = (*conn)(nil)
+	_ driver.ConnBeginTx        = (*conn)(nil)
+	_ driver.ConnPrepareContext = (*conn)(nil)
+	_ driver.QueryerContext     = (*conn)(nil)
+	_ driver.ExecerContext      = (*conn)(nil)
+	_ driver.NamedValueChecker  = (*conn)(nil)
+	_ driver.SessionResetter    = (*conn)(nil)
+	_ driver.Validator          = (*conn)(nil)
+)
+
 // conn represents a connection to the Chai database.
 // It implements the database/sql/driver.Conn interface.
 type conn struct {
@@ -103,6 +136,87 @@ func (c *conn) PrepareContext(ctx context.Context, q string) (driver.Stmt, error
 	}, nil
 }
 
+// QueryContext runs a query that may return rows, such as a SELECT,
+// without preparing it first.
+func (c *conn) QueryContext(ctx context.Context, q string, args []driver.NamedValue) (driver.Rows, error) {
+	res, err := c.conn.QueryContext(ctx, q, namedValueToParams(args)...)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := newRows(res)
+	if err != nil {
+		_ = res.Close()
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// ExecContext runs a query that doesn't return rows, such as an INSERT or UPDATE,
+// without preparing it first.
+func (c *conn) ExecContext(ctx context.Context, q string, args []driver.NamedValue) (driver.Result, error) {
+	err := c.conn.ExecContext(ctx, q, namedValueToParams(args)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return execResult{}, nil
+}
+
+// CheckNamedValue converts the arguments of the queries.
+// The values of the types implementing driver.Valuer are replaced by the result
+// of their Value method, unless they implement chai.ValueMarshaler.
+// Maps, slices other than []byte and structs other than time.Time are stored as JSON text,
+// like the fields of structs inserted with chai. Other types are passed as is to chai.
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	v, err := convertArg(nv.Value)
+	if err != nil {
+		return err
+	}
+
+	nv.Value = v
+	return nil
+}
+
+var valuerType = reflect.TypeFor[driver.Valuer]()
+
+func convertArg(x any) (any, error) {
+	switch t := x.(type) {
+	case chai.ValueMarshaler:
+		return x, nil
+	case driver.Valuer:
+		// like database/sql, nil pointers whose Value method has
+		// a value receiver are converted to NULL
+		if rv := reflect.ValueOf(t); rv.Kind() == reflect.Pointer && rv.IsNil() && rv.Type().Elem().Implements(valuerType) {
+			return nil, nil
+		}
+
+		v, err := t.Value()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := v.(driver.Valuer); ok {
+			return nil, errors.Errorf("%T.Value returned a driver.Valuer", x)
+		}
+
+		return convertArg(v)
+	}
+
+	if t := reflect.TypeOf(x); t != nil && row.IsJSONType(t) {
+		return row.NewJSONValue(reflect.ValueOf(x))
+	}
+
+	return x, nil
+}
+
+// IsValid reports whether the connection can be reused by the pool of database/sql.
+// Connections left with a transaction started by a BEGIN statement are discarded,
+// which rolls the transaction back.
+func (c *conn) IsValid() bool {
+	return c.conn.Conn.GetTx() == nil
+}
+
 // Close closes any ongoing transaction.
 func (c *conn) Close() error {
 	return c.conn.Close()
@@ -124,15 +238,17 @@ func (c *conn) ResetSession(ctx context.Context) error {
 
 // BeginTx starts and returns a new transaction.
 // It uses the ReadOnly option to determine whether to start a read-only or read/write transaction.
-// If the Isolation option is non zero, an error is returned.
+// Transactions read a snapshot of the database and write transactions run one at a time,
+// so they are serializable: every isolation level up to sql.LevelSerializable is accepted.
+// sql.LevelLinearizable returns an error.
 func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
-	if opts.Isolation != 0 {
-		return nil, errors.New("isolation levels are not supported")
+	if level := sql.IsolationLevel(opts.Isolation); level > sql.LevelSerializable {
+		return nil, errors.Errorf("isolation level %s is not supported", level)
 	}
 
 	// if the ReadOnly flag is explicitly specified, create a read-only transaction,
 	// otherwise create a read/write transaction.
-	return c.conn.Begin(!opts.ReadOnly)
+	return c.conn.BeginContext(ctx, !opts.ReadOnly)
 }
 
 // Stmt is a prepared statement. It is bound to a Conn and not
@@ -159,7 +275,7 @@ func (s stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver
 	default:
 	}
 
-	return execResult{}, s.stmt.Exec(namedValueToParams(args)...)
+	return execResult{}, s.stmt.WithContext(ctx).Exec(namedValueToParams(args)...)
 }
 
 type execResult struct{}
@@ -187,7 +303,7 @@ func (s stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (drive
 	default:
 	}
 
-	res, err := s.stmt.Query(namedValueToParams(args)...)
+	res, err := s.stmt.WithContext(ctx).Query(namedValueToParams(args)...)
 	if err != nil {
 		return nil, err
 	}
@@ -207,9 +323,9 @@ func namedValueToParams(args []driver.NamedValue) []any {
 	return params
 }
 
-// Close does nothing.
+// Close closes the prepared statement.
 func (s stmt) Close() error {
-	return nil
+	return s.stmt.Close()
 }
 
 var errStop = errors.New("stop")
@@ -259,7 +375,9 @@ func (rs *Rows) iterate(ctx context.Context) {
 	case <-rs.c:
 	}
 
-	err := rs.res.Iterate(func(r *chai.Row) error {
+	// closing the rows stops the iteration, including while
+	// the rows are read from the storage
+	err := rs.res.IterateContext(ctx, func(r *chai.Row) error {
 		select {
 		case <-ctx.Done():
 			return errStop
@@ -276,7 +394,7 @@ func (rs *Rows) iterate(ctx context.Context) {
 		}
 	})
 
-	if errors.Is(err, errStop) || err == nil {
+	if errors.Is(err, errStop) || err == nil || ctx.Err() != nil {
 		return
 	}
 	if err != nil {
@@ -353,7 +471,9 @@ func (rs *Rows) Next(dest []driver.Value) error {
 				return err
 			}
 			dest[i] = t
-		case types.TypeText:
+		case types.TypeText, types.TypeDecimal, types.TypeUUID:
+			// decimals are returned as texts to avoid losing precision,
+			// UUIDs in their canonical form
 			var s string
 			err = row.ScanValue(v, &s)
 			if err != nil {
@@ -3,10 +3,12 @@ package driver
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"testing"
 	"time"
 
+	"github.com/chaisql/chai"
 	"github.com/stretchr/testify/require"
 )
 
@@ -261,6 +263,49 @@ func TestDriver(t *testing.T) {
 		`)
 		require.EqualError(t, err, "cannot increment sequence on read-only transaction")
 	})
+
+	t.Run("Transaction control statements", func(t *testing.T) {
+		ctx := context.Background()
+
+		conn, err := db.Conn(ctx)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		count := func() int {
+			t.Helper()
+
+			var n int
+			err := conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM test").Scan(&n)
+			require.NoError(t, err)
+			return n
+		}
+
+		// the transaction spans multiple calls on the same connection
+		_, err = conn.ExecContext(ctx, "BEGIN")
+		require.NoError(t, err)
+		_, err = conn.ExecContext(ctx, "INSERT INTO test (a, b, c) VALUES (11, 'foo11', false)")
+		require.NoError(t, err)
+		require.Equal(t, 12, count())
+		_, err = conn.ExecContext(ctx, "ROLLBACK")
+		require.NoError(t, err)
+		require.Equal(t, 11, count())
+
+		_, err = conn.ExecContext(ctx, "BEGIN TRANSACTION")
+		require.NoError(t, err)
+		_, err = conn.ExecContext(ctx, "INSERT INTO test (a, b, c) VALUES (11, 'foo11', false)")
+		require.NoError(t, err)
+		_, err = conn.ExecContext(ctx, "COMMIT")
+		require.NoError(t, err)
+		require.Equal(t, 12, count())
+
+		_, err = conn.ExecContext(ctx, "BEGIN READ ONLY")
+		require.NoError(t, err)
+		_, err = conn.ExecContext(ctx, "INSERT INTO test (a, b, c) VALUES (12, 'foo12', true)")
+		require.Error(t, err)
+		_, err = conn.ExecContext(ctx, "ROLLBACK")
+		require.NoError(t, err)
+		require.Equal(t, 12, count())
+	})
 }
 
 func TestDriverWithTimeValues(t *testing.T) {
@@ -281,3 +326,98 @@ func TestDriverWithTimeValues(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, now, tt)
 }
+
+type point struct {
+	X, Y int
+}
+
+// celsius implements driver.Valuer with a value receiver.
+type celsius float64
+
+func (c celsius) Value() (driver.Value, error) {
+	return float64(c) + 273.15, nil
+}
+
+func TestDriverConversions(t *testing.T) {
+	db, err := sql.Open("chai", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE test(id INT PRIMARY KEY, doc TEXT, arr TEXT, k DOUBLE)")
+	require.NoError(t, err)
+
+	// maps, slices and structs are stored as JSON
+	_, err = db.Exec("INSERT INTO test (id, doc, arr, k) VALUES (?, ?, ?, ?)",
+		1, map[string]any{"a": 1}, []point{{1, 2}, {3, 4}}, celsius(10))
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO test (id, doc, arr, k) VALUES (?, ?, ?, ?)",
+		2, JSON(point{5, 6}), []int(nil), (*celsius)(nil))
+	require.NoError(t, err)
+
+	var doc map[string]int
+	var arr []point
+	var k float64
+	err = db.QueryRow("SELECT doc, arr, k FROM test WHERE id = ?", 1).Scan(JSON(&doc), JSON(&arr), &k)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"a": 1}, doc)
+	require.Equal(t, []point{{1, 2}, {3, 4}}, arr)
+	require.InDelta(t, 283.15, k, 0.001)
+
+	var p point
+	var nk sql.NullFloat64
+	err = db.QueryRow("SELECT doc, arr, k FROM test WHERE id = $id", sql.Named("id", 2)).Scan(JSON(&p), JSON(&arr), &nk)
+	require.NoError(t, err)
+	require.Equal(t, point{5, 6}, p)
+	require.Nil(t, arr)
+	require.False(t, nk.Valid)
+}
+
+func TestDriverTx(t *testing.T) {
+	db, err := sql.Open("chai", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE test(a INT)")
+	require.NoError(t, err)
+
+	for _, level := range []sql.IsolationLevel{sql.LevelDefault, sql.LevelReadCommitted, sql.LevelSnapshot, sql.LevelSerializable} {
+		tx, err := db.BeginTx(context.Background(), &sql.TxOptions{Isolation: level})
+		require.NoError(t, err)
+		require.NoError(t, tx.Rollback())
+	}
+
+	_, err = db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelLinearizable})
+	require.Error(t, err)
+
+	// a connection left with a transaction is discarded,
+	// which rolls the transaction back
+	_, err = db.Exec("BEGIN; INSERT INTO test (a) VALUES (1)")
+	require.NoError(t, err)
+	require.Zero(t, db.Stats().OpenConnections)
+
+	var n int
+	err = db.QueryRow("SELECT COUNT(*) FROM test").Scan(&n)
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+}
+
+func TestNewConnector(t *testing.T) {
+	cdb, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer cdb.Close()
+
+	err = cdb.Exec("CREATE TABLE test(a INT); INSERT INTO test (a) VALUES (1)")
+	require.NoError(t, err)
+
+	db := sql.OpenDB(NewConnector(cdb))
+	var a int
+	err = db.QueryRow("SELECT a FROM test").Scan(&a)
+	require.NoError(t, err)
+	require.Equal(t, 1, a)
+	require.NoError(t, db.Close())
+
+	// the database remains open
+	r, err := cdb.QueryRow("SELECT a FROM test")
+	require.NoError(t, err)
+	require.NoError(t, r.Scan(&a))
+}
@@ -0,0 +1,59 @@
+package driver
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"reflect"
+
+	"github.com/cockroachdb/errors"
+)
+
+// JSON returns a JSONValue wrapping v, to store a document or an array
+// as JSON text, or to decode one when scanning a column:
+//
+//	var tags []string
+//	err := db.QueryRow("SELECT tags FROM post").Scan(driver.JSON(&tags))
+func JSON(v any) JSONValue {
+	return JSONValue{V: v}
+}
+
+// JSONValue is a value stored as JSON text.
+// It implements driver.Valuer, encoding V as JSON, and sql.Scanner,
+// decoding the scanned text into V, which must be a pointer.
+type JSONValue struct {
+	V any
+}
+
+// Value encodes V as JSON. A nil V is stored as NULL.
+func (j JSONValue) Value() (driver.Value, error) {
+	if j.V == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(j.V)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+// Scan decodes the JSON text src into V. NULL sets V to its zero value.
+func (j JSONValue) Scan(src any) error {
+	rv := reflect.ValueOf(j.V)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return errors.Errorf("cannot scan JSON into %T: not a non-nil pointer", j.V)
+	}
+
+	switch t := src.(type) {
+	case nil:
+		rv.Elem().SetZero()
+		return nil
+	case string:
+		return json.Unmarshal([]byte(t), j.V)
+	case []byte:
+		return json.Unmarshal(t, j.V)
+	}
+
+	return errors.Errorf("cannot scan value of type %T as JSON", src)
+}
@@ -0,0 +1,131 @@
+package chai
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/database/catalogstore"
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/chaisql/chai/internal/expr/functions"
+	"github.com/cockroachdb/errors"
+)
+
+// Engine is the interface implemented by storage engines.
+// Keys are opaque byte slices that engines must order using CompareKeys.
+//
+// Engines that don't need to recover from partially written batches,
+// or to share snapshots between sessions, can implement Rollback, Recover,
+// LockSharedSnapshot and UnlockSharedSnapshot as no-ops.
+// Transient sessions are used to store temporary data, e.g. to sort rows,
+// which CleanupTransientNamespaces must delete when the database is opened.
+type Engine = engine.Engine
+
+// Session is a set of reads and writes performed on an engine.
+// Snapshot sessions only read, batch sessions write atomically when committed.
+type Session = engine.Session
+
+// Iterator iterates over the keys of a session, in the order of CompareKeys.
+type Iterator = engine.Iterator
+
+// IterOptions bounds the keys returned by an Iterator.
+type IterOptions = engine.IterOptions
+
+var (
+	// ErrKeyNotFound must be returned by sessions when the requested key doesn't exist.
+	ErrKeyNotFound = engine.ErrKeyNotFound
+
+	// ErrKeyAlreadyExists must be returned by Session.Insert when the key already exists.
+	ErrKeyAlreadyExists = engine.ErrKeyAlreadyExists
+)
+
+// CompareKeys returns the order of two keys written by Chai:
+// -1 if a < b, 0 if a == b and 1 if a > b.
+// Engines must iterate over keys in this order.
+func CompareKeys(a, b []byte) int {
+	return encoding.Compare(a, b)
+}
+
+// An EngineOpener opens the storage engine of a database at the given path.
+type EngineOpener func(path string) (Engine, error)
+
+var engines struct {
+	sync.RWMutex
+
+	openers map[string]EngineOpener
+}
+
+// RegisterEngine makes a storage engine available under the given name.
+// Databases are stored using the engine when their path is prefixed with
+// the name of the engine followed by "://", e.g. "myengine://path/to/db".
+// The rest of the path is passed to the opener.
+func RegisterEngine(name string, opener EngineOpener) error {
+	if name == "" || strings.Contains(name, "://") {
+		return errors.Errorf("cannot register engine %q: invalid name", name)
+	}
+	if opener == nil {
+		return errors.Errorf("cannot register engine %q: opener is nil", name)
+	}
+
+	engines.Lock()
+	defer engines.Unlock()
+
+	if _, ok := engines.openers[name]; ok {
+		return errors.Errorf("cannot register engine %q: engine already registered", name)
+	}
+
+	if engines.openers == nil {
+		engines.openers = make(map[string]EngineOpener)
+	}
+	engines.openers[name] = opener
+	return nil
+}
+
+// openOptions returns the path of the database and the options used to open it,
+// selecting the engine from the prefix of the path, if any.
+// The functions used by the schema are resolved with fns.
+func openOptions(path string, o *Options, fns *functions.Registry) (string, *database.Options, error) {
+	opts := database.Options{
+		CatalogLoader: catalogstore.NewLoader(fns.Deferred()),
+	}
+	if o != nil {
+		opts.Compression = string(o.Compression)
+		opts.WALArchiveDir = o.WALArchiveDir
+		opts.LockTimeout = o.LockTimeout
+		opts.BlobThreshold = o.BlobThreshold
+		opts.Durability = string(o.Durability)
+		opts.SyncInterval = o.SyncInterval
+		opts.CacheSize = o.CacheSize
+		opts.MemTableSize = o.MemTableSize
+		opts.MaxOpenFiles = o.MaxOpenFiles
+		opts.L0CompactionThreshold = o.L0CompactionThreshold
+		opts.L0StopWritesThreshold = o.L0StopWritesThreshold
+		opts.BusyTimeout = o.BusyTimeout
+		opts.BusyHandler = o.BusyHandler
+		opts.TimeZone = o.TimeZone
+		opts.RejectNonFiniteDoubles = o.RejectNonFiniteDoubles
+		opts.PreserveColumnOrder = o.PreserveColumnOrder
+		opts.MaxRowSize = o.MaxRowSize
+		opts.MaxDocumentDepth = o.MaxDocumentDepth
+		opts.MaxDocumentFields = o.MaxDocumentFields
+	}
+
+	name, rest, ok := strings.Cut(path, "://")
+	if !ok {
+		return path, &opts, nil
+	}
+
+	engines.RLock()
+	opener, ok := engines.openers[name]
+	engines.RUnlock()
+	if !ok {
+		return "", nil, errors.Errorf("unknown engine %q", name)
+	}
+
+	opts.OpenEngine = func(path string) (engine.Engine, error) {
+		return opener(path)
+	}
+
+	return rest, &opts, nil
+}
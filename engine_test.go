@@ -0,0 +1,46 @@
+package chai_test
+
+import (
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/kv"
+	"github.com/chaisql/chai/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterEngine(t *testing.T) {
+	var opened []string
+	err := chai.RegisterEngine("testengine", func(path string) (chai.Engine, error) {
+		opened = append(opened, path)
+
+		return kv.NewEngine(":memory:", kv.Options{
+			RollbackSegmentNamespace: int64(database.RollbackSegmentNamespace),
+			MinTransientNamespace:    uint64(database.MinTransientNamespace),
+			MaxTransientNamespace:    uint64(database.MaxTransientNamespace),
+		})
+	})
+	require.NoError(t, err)
+
+	err = chai.RegisterEngine("testengine", func(path string) (chai.Engine, error) { return nil, nil })
+	require.Error(t, err)
+
+	db, err := chai.Open("testengine://foo")
+	require.NoError(t, err)
+	defer db.Close()
+	require.Equal(t, []string{"foo"}, opened)
+
+	err = db.Exec(`
+		CREATE TABLE test(a INTEGER PRIMARY KEY);
+		INSERT INTO test (a) VALUES (2), (1);
+	`)
+	require.NoError(t, err)
+
+	r, err := db.QueryRow("SELECT * FROM test ORDER BY a")
+	require.NoError(t, err)
+	testutil.RequireJSONEq(t, r, `{"a": 1}`)
+
+	_, err = chai.Open("unknown://foo")
+	require.Error(t, err)
+}
@@ -3,6 +3,7 @@ package chai
 import (
 	"github.com/chaisql/chai/internal/database"
 	errs "github.com/chaisql/chai/internal/errors"
+	"github.com/chaisql/chai/internal/pkg/filelock"
 	"github.com/cockroachdb/errors"
 )
 
@@ -11,6 +12,24 @@ import (
 // doesn't exist.
 var IsNotFoundError = errs.IsNotFoundError
 
+// IsIncompatibleFormatError determines if the database couldn't be opened
+// because its format is not supported by this version of Chai.
+var IsIncompatibleFormatError = database.IsIncompatibleFormatError
+
+// ErrDatabaseLocked is wrapped by the error returned when opening
+// an on-disk database already opened by another process,
+// or by another DB of the same process.
+var ErrDatabaseLocked = filelock.ErrLocked
+
+// ErrBusy is wrapped by the error returned when starting a write transaction
+// while another one is running, if Options.BusyTimeout or Options.BusyHandler
+// give up waiting for it.
+var ErrBusy = database.ErrBusy
+
+// ErrLimitExceeded is wrapped by the error returned when writing a row
+// exceeding Options.MaxRowSize, Options.MaxDocumentDepth or Options.MaxDocumentFields.
+var ErrLimitExceeded = database.ErrLimitExceeded
+
 // IsAlreadyExistsError determines if the error is returned as a result of
 // a conflict when attempting to create a table, an index, an row or a sequence
 // with a name that is already used by another resource.
@@ -0,0 +1,229 @@
+package chai
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/chaisql/chai/internal/expr/functions"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+var (
+	errorType = reflect.TypeOf((*error)(nil)).Elem()
+	timeType  = reflect.TypeOf(time.Time{})
+)
+
+// RegisterFunction makes the Go function fn callable from SQL expressions under the given name.
+// fn must be a function taking arity parameters, or a variadic function accepting them,
+// and returning a single value, optionally followed by an error.
+//
+// Parameters can be of type bool, string, []byte, time.Time, any integer or float type,
+// or any to accept values of any type. The arguments are checked against these types
+// when the function is called: integer parameters only accept integers, float parameters accept
+// any number, etc. If an argument is NULL, the function is not called and returns NULL, unless
+// the parameter is a pointer or an interface, in which case it receives nil.
+//
+// Functions are only visible to the queries run on db. They can be used in the schema,
+// e.g. in CHECK constraints, in which case they must be registered again every time
+// the database is opened, before running queries that use them.
+// Builtin functions cannot be replaced.
+func (db *DB) RegisterFunction(name string, arity int, fn any) error {
+	fv := reflect.ValueOf(fn)
+	if fv.Kind() != reflect.Func {
+		return errors.Errorf("cannot register function %q: expected a function, got %T", name, fn)
+	}
+
+	ft := fv.Type()
+	switch {
+	case ft.IsVariadic() && arity < ft.NumIn()-1, !ft.IsVariadic() && arity != ft.NumIn():
+		return errors.Errorf("cannot register function %q: arity %d doesn't match the signature %s", name, arity, ft)
+	case ft.NumOut() == 0 || ft.NumOut() > 2 || ft.NumOut() == 2 && ft.Out(1) != errorType:
+		return errors.Errorf("cannot register function %q: expected a function returning a value and an optional error, got %s", name, ft)
+	}
+
+	if _, ok := paramSQLType(ft.Out(0)); !ok {
+		return errors.Errorf("cannot register function %q: unsupported return type %s", name, ft.Out(0))
+	}
+
+	params := make([]reflect.Type, arity)
+	for i := range params {
+		if ft.IsVariadic() && i >= ft.NumIn()-1 {
+			params[i] = ft.In(ft.NumIn() - 1).Elem()
+		} else {
+			params[i] = ft.In(i)
+		}
+
+		if _, ok := paramSQLType(params[i]); !ok {
+			return errors.Errorf("cannot register function %q: unsupported parameter type %s", name, params[i])
+		}
+	}
+
+	def := functions.NewScalarDefinition(name, arity, func(args ...types.Value) (types.Value, error) {
+		in := make([]reflect.Value, len(args))
+		for i, arg := range args {
+			if arg.Type() == types.TypeNull {
+				switch params[i].Kind() {
+				case reflect.Ptr, reflect.Interface:
+					in[i] = reflect.Zero(params[i])
+					continue
+				}
+
+				return types.NewNullValue(), nil
+			}
+
+			if !acceptsValue(params[i], arg) {
+				tp, _ := paramSQLType(params[i])
+				return nil, errors.Errorf("%s expects arg%d to be of type %s, got %s", functionSignature(name, arity), i+1, tp, arg.Type())
+			}
+
+			ref := reflect.New(params[i])
+			err := row.ScanValue(arg, ref.Interface())
+			if err != nil {
+				return nil, err
+			}
+			in[i] = ref.Elem()
+		}
+
+		out := fv.Call(in)
+		if len(out) == 2 && !out[1].IsNil() {
+			return nil, out[1].Interface().(error)
+		}
+
+		return row.NewValue(out[0].Interface())
+	})
+
+	err := db.functions.Register(def)
+	if err != nil {
+		return err
+	}
+
+	// cached queries may use a function previously registered with the same name.
+	db.cache.Clear()
+	return nil
+}
+
+// paramSQLType returns the name of the SQL type corresponding to the Go type t.
+func paramSQLType(t reflect.Type) (string, bool) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean", true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer", true
+	case reflect.Float32, reflect.Float64:
+		return "number", true
+	case reflect.String:
+		return "text", true
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "blob", true
+		}
+	case reflect.Interface:
+		if t.NumMethod() == 0 {
+			return "any", true
+		}
+	case reflect.Struct:
+		if t == timeType {
+			return "timestamp", true
+		}
+	}
+
+	return "", false
+}
+
+// acceptsValue reports whether v can be passed to a parameter of type t.
+func acceptsValue(t reflect.Type, v types.Value) bool {
+	tp, _ := paramSQLType(t)
+
+	switch tp {
+	case "boolean":
+		return v.Type() == types.TypeBoolean
+	case "integer":
+		return v.Type().IsInteger()
+	case "number":
+		return v.Type().IsNumber()
+	case "text":
+		return v.Type() == types.TypeText
+	case "blob":
+		return v.Type() == types.TypeBlob
+	case "timestamp":
+		return v.Type() == types.TypeTimestamp
+	}
+
+	return true
+}
+
+func functionSignature(name string, arity int) string {
+	return functions.NewScalarDefinition(name, arity, nil).String()
+}
+
+// An Aggregate computes a value from the rows of a group.
+// A new Aggregate is created for every group.
+type Aggregate interface {
+	// Step is called for each row of the group with the arguments of the function,
+	// converted to Go values: NULL is passed as nil, INTEGER as int32, BIGINT as int64,
+	// DOUBLE as float64, TEXT as string, BLOB as []byte, BOOLEAN as bool
+	// and TIMESTAMP as time.Time.
+	Step(args ...any) error
+	// Finalize returns the result of the aggregation.
+	// It is called once all the rows of the group have been passed to Step,
+	// or without calling Step if the group is empty.
+	Finalize() (any, error)
+}
+
+// RegisterAggregate makes a Go aggregate function callable from SQL queries under the given name,
+// e.g. in queries using GROUP BY. The function takes arity arguments.
+// init is called to create the Aggregate of each group.
+// Like functions registered with RegisterFunction, aggregates are only visible to the queries run on db.
+func (db *DB) RegisterAggregate(name string, arity int, init func() Aggregate) error {
+	if init == nil {
+		return errors.Errorf("cannot register aggregate %q: init function is nil", name)
+	}
+	if arity < 0 {
+		return errors.Errorf("cannot register aggregate %q: invalid arity %d", name, arity)
+	}
+
+	def := functions.NewAggregateDefinition(name, arity, func() functions.AggregateFunc {
+		return &goAggregate{a: init()}
+	})
+
+	err := db.functions.Register(def)
+	if err != nil {
+		return err
+	}
+
+	db.cache.Clear()
+	return nil
+}
+
+// goAggregate converts the values passed to an Aggregate.
+type goAggregate struct {
+	a Aggregate
+}
+
+func (g *goAggregate) Step(args ...types.Value) error {
+	in := make([]any, len(args))
+	for i, arg := range args {
+		err := row.ScanValue(arg, &in[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return g.a.Step(in...)
+}
+
+func (g *goAggregate) Finalize() (types.Value, error) {
+	v, err := g.a.Finalize()
+	if err != nil {
+		return nil, err
+	}
+
+	return row.NewValue(v)
+}
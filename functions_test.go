@@ -0,0 +1,257 @@
+package chai_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterFunction(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.RegisterFunction("test_reverse", 1, func(s string) string {
+		r := []rune(s)
+		for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+			r[i], r[j] = r[j], r[i]
+		}
+		return string(r)
+	})
+	require.NoError(t, err)
+
+	err = db.RegisterFunction("test_sum", 3, func(xs ...int64) (int64, error) {
+		var sum int64
+		for _, x := range xs {
+			if x < 0 {
+				return 0, fmt.Errorf("negative value %d", x)
+			}
+			sum += x
+		}
+		return sum, nil
+	})
+	require.NoError(t, err)
+
+	err = db.RegisterFunction("test_coalesce", 2, func(a *string, b string) string {
+		if a == nil {
+			return b
+		}
+		return *a
+	})
+	require.NoError(t, err)
+
+	t.Run("OK", func(t *testing.T) {
+		var s string
+		var n int64
+		r, err := db.QueryRow("SELECT test_reverse('hello'), TEST_SUM(1, 2, 3)")
+		require.NoError(t, err)
+		require.NoError(t, r.Scan(&s, &n))
+		require.Equal(t, "olleh", s)
+		require.EqualValues(t, 6, n)
+	})
+
+	t.Run("NULL", func(t *testing.T) {
+		var s, c *string
+		r, err := db.QueryRow("SELECT test_reverse(NULL), test_coalesce(NULL, 'b')")
+		require.NoError(t, err)
+		require.NoError(t, r.Scan(&s, &c))
+		require.Nil(t, s)
+		require.Equal(t, "b", *c)
+	})
+
+	t.Run("Type checking", func(t *testing.T) {
+		_, err := db.QueryRow("SELECT test_reverse(1)")
+		require.ErrorContains(t, err, "test_reverse(arg1) expects arg1 to be of type text, got integer")
+
+		_, err = db.QueryRow("SELECT test_sum(1, 2.5, 3)")
+		require.Error(t, err)
+	})
+
+	t.Run("Function error", func(t *testing.T) {
+		_, err := db.QueryRow("SELECT test_sum(1, -2, 3)")
+		require.ErrorContains(t, err, "negative value -2")
+	})
+
+	t.Run("Arity", func(t *testing.T) {
+		_, err := db.QueryRow("SELECT test_sum(1, 2)")
+		require.Error(t, err)
+	})
+
+	t.Run("Invalid registrations", func(t *testing.T) {
+		require.Error(t, db.RegisterFunction("lower", 1, func(s string) string { return s }))
+		require.Error(t, db.RegisterFunction("test_bad", 2, func(s string) string { return s }))
+		require.Error(t, db.RegisterFunction("test_bad", 1, func(s struct{}) string { return "" }))
+		require.Error(t, db.RegisterFunction("test_bad", 1, func(s string) {}))
+		require.Error(t, db.RegisterFunction("test_bad", 1, "not a function"))
+	})
+
+	t.Run("Scoped to the database", func(t *testing.T) {
+		other, err := chai.Open(":memory:")
+		require.NoError(t, err)
+		defer other.Close()
+
+		_, err = other.QueryRow("SELECT test_reverse('hello')")
+		require.ErrorContains(t, err, `no such function: "test_reverse"`)
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+		err := db.RegisterFunction("test_answer", 0, func() int64 { return 1 })
+		require.NoError(t, err)
+
+		var n int64
+		r, err := db.QueryRow("SELECT test_answer()")
+		require.NoError(t, err)
+		require.NoError(t, r.Scan(&n))
+		require.EqualValues(t, 1, n)
+
+		err = db.RegisterFunction("test_answer", 0, func() int64 { return 42 })
+		require.NoError(t, err)
+
+		r, err = db.QueryRow("SELECT test_answer()")
+		require.NoError(t, err)
+		require.NoError(t, r.Scan(&n))
+		require.EqualValues(t, 42, n)
+	})
+}
+
+func TestRegisterFunctionSchema(t *testing.T) {
+	dir := t.TempDir()
+	isEven := func(n int64) bool { return n%2 == 0 }
+
+	db, err := chai.Open(dir)
+	require.NoError(t, err)
+
+	err = db.RegisterFunction("test_is_even", 1, isEven)
+	require.NoError(t, err)
+
+	err = db.Exec("CREATE TABLE test(a INT PRIMARY KEY CHECK (test_is_even(a)))")
+	require.NoError(t, err)
+	err = db.Exec("INSERT INTO test (a) VALUES (2)")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	// the schema is loaded before the function is registered again
+	db, err = chai.Open(dir)
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("INSERT INTO test (a) VALUES (4)")
+	require.ErrorContains(t, err, `no such function: "test_is_even"`)
+
+	err = db.RegisterFunction("test_is_even", 1, isEven)
+	require.NoError(t, err)
+
+	err = db.Exec("INSERT INTO test (a) VALUES (4)")
+	require.NoError(t, err)
+	err = db.Exec("INSERT INTO test (a) VALUES (5)")
+	require.Error(t, err)
+}
+
+type distinctCount struct {
+	seen map[any]struct{}
+}
+
+func (d *distinctCount) Step(args ...any) error {
+	if args[0] != nil {
+		d.seen[args[0]] = struct{}{}
+	}
+	return nil
+}
+
+func (d *distinctCount) Finalize() (any, error) {
+	return len(d.seen), nil
+}
+
+type joinAggregate struct {
+	parts []string
+}
+
+func (j *joinAggregate) Step(args ...any) error {
+	s, ok := args[0].(string)
+	if !ok {
+		return fmt.Errorf("expected a text, got %T", args[0])
+	}
+	j.parts = append(j.parts, s+fmt.Sprint(args[1]))
+	return nil
+}
+
+func (j *joinAggregate) Finalize() (any, error) {
+	if len(j.parts) == 0 {
+		return nil, nil
+	}
+	return fmt.Sprint(j.parts), nil
+}
+
+func TestRegisterAggregate(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.RegisterAggregate("test_distinct_count", 1, func() chai.Aggregate {
+		return &distinctCount{seen: make(map[any]struct{})}
+	})
+	require.NoError(t, err)
+
+	err = db.RegisterAggregate("test_join", 2, func() chai.Aggregate {
+		return &joinAggregate{}
+	})
+	require.NoError(t, err)
+
+	err = db.Exec(`
+		CREATE TABLE test(a INT, b TEXT);
+		INSERT INTO test (a, b) VALUES (1, 'x'), (2, 'x'), (2, 'y'), (3, 'y'), (NULL, 'y');
+	`)
+	require.NoError(t, err)
+
+	t.Run("OK", func(t *testing.T) {
+		var n int
+		r, err := db.QueryRow("SELECT test_distinct_count(a) FROM test")
+		require.NoError(t, err)
+		require.NoError(t, r.Scan(&n))
+		require.Equal(t, 3, n)
+	})
+
+	t.Run("GROUP BY", func(t *testing.T) {
+		conn, err := db.Connect()
+		require.NoError(t, err)
+		defer conn.Close()
+
+		res, err := conn.Query("SELECT b, test_distinct_count(a) AS n, test_join(b, a) AS j FROM test GROUP BY b")
+		require.NoError(t, err)
+		defer res.Close()
+
+		var got []string
+		err = res.Iterate(func(r *chai.Row) error {
+			var b, j string
+			var n int
+			err := r.Scan(&b, &n, &j)
+			if err != nil {
+				return err
+			}
+			got = append(got, fmt.Sprintf("%s %d %s", b, n, j))
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"x 2 [x1 x2]", "y 2 [y2 y3 y<nil>]"}, got)
+	})
+
+	t.Run("Empty group", func(t *testing.T) {
+		var j *string
+		r, err := db.QueryRow("SELECT test_join(b, a) FROM test WHERE a > 10")
+		require.NoError(t, err)
+		require.NoError(t, r.Scan(&j))
+		require.Nil(t, j)
+	})
+
+	t.Run("Step error", func(t *testing.T) {
+		_, err := db.QueryRow("SELECT test_join(a, b) FROM test")
+		require.ErrorContains(t, err, "expected a text, got int32")
+	})
+
+	t.Run("Arity", func(t *testing.T) {
+		_, err := db.QueryRow("SELECT test_join(a) FROM test")
+		require.Error(t, err)
+	})
+}
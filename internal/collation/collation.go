@@ -0,0 +1,128 @@
+// Package collation defines how TEXT values are compared and sorted.
+//
+// A collation converts a text into a collation key: comparing the keys bytewise
+// yields the order defined by the collation. Keys are texts themselves, so that
+// they can be compared with other texts and stored in indexes like any TEXT value.
+//
+// The collations and the case mappings of locales are implemented with
+// golang.org/x/text, or with ICU through cgo when built with the chai_icu tag.
+// The icu tag is not used because it is reserved by golang.org/x/text.
+package collation
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+const (
+	// Binary compares texts bytewise. It is the default collation.
+	Binary = "binary"
+	// NoCase compares texts ignoring differences of case.
+	NoCase = "nocase"
+)
+
+// A Collation converts texts to collation keys.
+type Collation struct {
+	// Name of the collation, as used in SQL.
+	Name string
+
+	key func(s string) string
+}
+
+// Key returns the collation key of s.
+func (c *Collation) Key(s string) string {
+	return c.key(s)
+}
+
+// IsBinary reports whether the collation compares texts bytewise,
+// in which case keys are equal to the texts.
+func (c *Collation) IsBinary() bool {
+	return c.Name == Binary
+}
+
+var (
+	binary = &Collation{Name: Binary, key: func(s string) string { return s }}
+	nocase = &Collation{Name: NoCase, key: cases.Fold().String}
+)
+
+var locales = struct {
+	sync.Mutex
+	m map[language.Tag]*Collation
+}{
+	m: make(map[language.Tag]*Collation),
+}
+
+// Lookup returns the collation with the given name.
+// The names binary and nocase are case insensitive,
+// any other name must be a BCP 47 language tag (i.e. "de_DE", "tr").
+func Lookup(name string) (*Collation, error) {
+	switch {
+	case strings.EqualFold(name, Binary):
+		return binary, nil
+	case strings.EqualFold(name, NoCase):
+		return nocase, nil
+	}
+
+	tag, err := language.Parse(name)
+	if err != nil {
+		return nil, errors.Errorf("unknown collation %q", name)
+	}
+
+	locales.Lock()
+	defer locales.Unlock()
+
+	if c, ok := locales.m[tag]; ok {
+		return c, nil
+	}
+
+	key, err := localeKey(tag)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unknown collation %q", name)
+	}
+
+	c := &Collation{
+		Name: name,
+		key:  key,
+	}
+	locales.m[tag] = c
+	return c, nil
+}
+
+// Equal reports whether a and b designate the same collation.
+// An empty name designates the binary collation.
+func Equal(a, b string) bool {
+	if a == "" {
+		a = Binary
+	}
+	if b == "" {
+		b = Binary
+	}
+	if strings.EqualFold(a, b) {
+		return true
+	}
+
+	ca, err := Lookup(a)
+	if err != nil {
+		return false
+	}
+	cb, err := Lookup(b)
+	if err != nil {
+		return false
+	}
+
+	return ca == cb
+}
+
+// Lower maps s to lower case with the rules of the locale.
+func Lower(tag language.Tag, s string) string {
+	return localeCase(tag, s, false)
+}
+
+// Upper maps s to upper case with the rules of the locale.
+func Upper(tag language.Tag, s string) string {
+	return localeCase(tag, s, true)
+}
@@ -0,0 +1,58 @@
+package collation_test
+
+import (
+	"testing"
+
+	"github.com/chaisql/chai/internal/collation"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    string
+		less    bool
+		equal   bool
+		invalid bool
+	}{
+		{"binary", "B", "a", true, false, false},
+		{"BINARY", "a", "a", false, true, false},
+		{"nocase", "a", "B", true, false, false},
+		{"NoCase", "Straße", "STRASSE", false, true, false},
+		{"de_DE", "Äpfel", "Birne", true, false, false},
+		{"sv", "Äpfel", "Zebra", false, false, false},
+		{"not a locale", "", "", false, false, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := collation.Lookup(test.name)
+			if test.invalid {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			ka, kb := c.Key(test.a), c.Key(test.b)
+			require.Equal(t, test.less, ka < kb)
+			require.Equal(t, test.equal, ka == kb)
+		})
+	}
+}
+
+func TestEqual(t *testing.T) {
+	require.True(t, collation.Equal("", "binary"))
+	require.True(t, collation.Equal("NOCASE", "nocase"))
+	require.True(t, collation.Equal("de_DE", "de-DE"))
+	require.False(t, collation.Equal("", "nocase"))
+	require.False(t, collation.Equal("de", "sv"))
+}
+
+func TestCase(t *testing.T) {
+	require.Equal(t, "ılık", collation.Lower(language.Turkish, "ILIK"))
+	require.Equal(t, "İSTANBUL", collation.Upper(language.Turkish, "istanbul"))
+	require.Equal(t, "ilik", collation.Lower(language.English, "ILIK"))
+	require.Equal(t, "STRASSE", collation.Upper(language.German, "Straße"))
+	require.Equal(t, "", collation.Upper(language.German, ""))
+}
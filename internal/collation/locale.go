@@ -0,0 +1,38 @@
+//go:build !chai_icu
+
+package collation
+
+import (
+	"encoding/hex"
+	"sync"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// localeKey returns the function computing the collation keys of the locale.
+func localeKey(tag language.Tag) (func(s string) string, error) {
+	// collators are not safe for concurrent use
+	var mu sync.Mutex
+	var buf collate.Buffer
+	collator := collate.New(tag)
+
+	// the key is hex encoded to produce a valid text
+	// that sorts like the binary key
+	return func(s string) string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		buf.Reset()
+		return hex.EncodeToString(collator.KeyFromString(&buf, s))
+	}, nil
+}
+
+func localeCase(tag language.Tag, s string, upper bool) string {
+	if upper {
+		return cases.Upper(tag).String(s)
+	}
+
+	return cases.Lower(tag).String(s)
+}
@@ -0,0 +1,183 @@
+//go:build chai_icu
+
+package collation
+
+/*
+#cgo pkg-config: icu-uc icu-i18n
+
+#include <stdlib.h>
+#include <unicode/ucol.h>
+#include <unicode/uloc.h>
+#include <unicode/ustring.h>
+
+// chai_to_utf16 converts s to UTF-16, replacing invalid sequences with U+FFFD.
+// The returned buffer must be freed.
+static UChar *chai_to_utf16(const char *s, int32_t n, int32_t *len, UErrorCode *err) {
+	u_strFromUTF8WithSub(NULL, 0, len, s, n, 0xFFFD, NULL, err);
+	if (*err == U_BUFFER_OVERFLOW_ERROR || *err == U_STRING_NOT_TERMINATED_WARNING) {
+		*err = U_ZERO_ERROR;
+	}
+	if (U_FAILURE(*err)) {
+		return NULL;
+	}
+
+	UChar *buf = malloc(sizeof(UChar) * (*len + 1));
+	u_strFromUTF8WithSub(buf, *len + 1, len, s, n, 0xFFFD, NULL, err);
+	if (U_FAILURE(*err)) {
+		free(buf);
+		return NULL;
+	}
+	return buf;
+}
+
+// chai_sort_key returns the sort key of s, without its terminating zero.
+// The returned buffer must be freed.
+static uint8_t *chai_sort_key(const UCollator *coll, const char *s, int32_t n, int32_t *size, UErrorCode *err) {
+	int32_t len;
+	UChar *u = chai_to_utf16(s, n, &len, err);
+	if (u == NULL) {
+		return NULL;
+	}
+
+	*size = ucol_getSortKey(coll, u, len, NULL, 0);
+	uint8_t *key = malloc(*size);
+	ucol_getSortKey(coll, u, len, key, *size);
+	free(u);
+
+	(*size)--;
+	return key;
+}
+
+// chai_case maps s to lower or upper case with the rules of the locale.
+// The returned buffer must be freed.
+static char *chai_case(int upper, const char *locale, const char *s, int32_t n, int32_t *size, UErrorCode *err) {
+	int32_t len;
+	UChar *u = chai_to_utf16(s, n, &len, err);
+	if (u == NULL) {
+		return NULL;
+	}
+
+	int32_t (*fn)(UChar *, int32_t, const UChar *, int32_t, const char *, UErrorCode *) = upper ? u_strToUpper : u_strToLower;
+	int32_t mlen = fn(NULL, 0, u, len, locale, err);
+	if (*err == U_BUFFER_OVERFLOW_ERROR || *err == U_STRING_NOT_TERMINATED_WARNING) {
+		*err = U_ZERO_ERROR;
+	}
+	if (U_FAILURE(*err)) {
+		free(u);
+		return NULL;
+	}
+	UChar *m = malloc(sizeof(UChar) * (mlen + 1));
+	fn(m, mlen + 1, u, len, locale, err);
+	free(u);
+	if (U_FAILURE(*err)) {
+		free(m);
+		return NULL;
+	}
+
+	u_strToUTF8(NULL, 0, size, m, mlen, err);
+	if (*err == U_BUFFER_OVERFLOW_ERROR || *err == U_STRING_NOT_TERMINATED_WARNING) {
+		*err = U_ZERO_ERROR;
+	}
+	if (U_FAILURE(*err)) {
+		free(m);
+		return NULL;
+	}
+	char *out = malloc(*size + 1);
+	u_strToUTF8(out, *size + 1, size, m, mlen, err);
+	free(m);
+	if (U_FAILURE(*err)) {
+		free(out);
+		return NULL;
+	}
+	return out;
+}
+*/
+import "C"
+
+import (
+	"encoding/hex"
+	"unsafe"
+
+	"github.com/cockroachdb/errors"
+	"golang.org/x/text/language"
+)
+
+// icuLocale returns the ICU locale ID of the tag.
+func icuLocale(tag language.Tag) (string, error) {
+	ctag := C.CString(tag.String())
+	defer C.free(unsafe.Pointer(ctag))
+
+	var buf [C.ULOC_FULLNAME_CAPACITY]C.char
+	var err C.UErrorCode
+	n := C.uloc_forLanguageTag(ctag, &buf[0], C.int32_t(len(buf)), nil, &err)
+	if err > C.U_ZERO_ERROR {
+		return "", errors.Errorf("invalid locale %q: %s", tag, C.GoString(C.u_errorName(err)))
+	}
+
+	return C.GoStringN(&buf[0], n), nil
+}
+
+// localeKey returns the function computing the collation keys of the locale.
+// ICU collators are safe for concurrent use and are never closed.
+func localeKey(tag language.Tag) (func(s string) string, error) {
+	locale, err := icuLocale(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	cloc := C.CString(locale)
+	defer C.free(unsafe.Pointer(cloc))
+
+	var uerr C.UErrorCode
+	coll := C.ucol_open(cloc, &uerr)
+	if uerr > C.U_ZERO_ERROR {
+		return nil, errors.Errorf("cannot open collator: %s", C.GoString(C.u_errorName(uerr)))
+	}
+
+	// the key is hex encoded to produce a valid text
+	// that sorts like the binary key
+	return func(s string) string {
+		cs := C.CString(s)
+		defer C.free(unsafe.Pointer(cs))
+
+		var size C.int32_t
+		var uerr C.UErrorCode
+		key := C.chai_sort_key(coll, cs, C.int32_t(len(s)), &size, &uerr)
+		if key == nil {
+			// ICU only fails when out of memory
+			panic("cannot compute the sort key: " + C.GoString(C.u_errorName(uerr)))
+		}
+		defer C.free(unsafe.Pointer(key))
+
+		return hex.EncodeToString(C.GoBytes(unsafe.Pointer(key), C.int(size)))
+	}, nil
+}
+
+func localeCase(tag language.Tag, s string, upper bool) string {
+	locale, err := icuLocale(tag)
+	if err != nil {
+		// tags parsed by x/text are valid BCP 47 tags
+		return s
+	}
+
+	cloc := C.CString(locale)
+	defer C.free(unsafe.Pointer(cloc))
+	cs := C.CString(s)
+	defer C.free(unsafe.Pointer(cs))
+
+	var u C.int
+	if upper {
+		u = 1
+	}
+
+	var size C.int32_t
+	var uerr C.UErrorCode
+	out := C.chai_case(u, cloc, cs, C.int32_t(len(s)), &size, &uerr)
+	if out == nil {
+		// ICU only fails when out of memory
+		panic("cannot map the case: " + C.GoString(C.u_errorName(uerr)))
+	}
+	defer C.free(unsafe.Pointer(out))
+
+	return C.GoStringN(out, C.int(size))
+}
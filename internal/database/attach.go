@@ -0,0 +1,323 @@
+package database
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// MainDatabase is the name of the database opened with Open.
+// It is used to refer to its tables explicitly, i.e. main.users.
+const MainDatabase = "main"
+
+// attachedTxPrefix prefixes the id of the transactions of the attached
+// databases prepared while committing the transaction using them.
+// The id is followed by the name of the attached database
+// and the id of the commit: attached:<name>:<commit id>.
+const attachedTxPrefix = "attached:"
+
+// Attach opens the database file at path and makes its tables available
+// to the transactions of db under the given name.
+// The attached database is loaded with the options used to open db
+// and is closed when db is closed or when it is detached.
+// A transaction left prepared by a commit of db that didn't complete
+// is committed or rolled back, see resolveAttached.
+func (db *Database) Attach(name, path string) error {
+	if name == "" || name == MainDatabase {
+		return errors.Errorf("cannot attach a database as %q", name)
+	}
+
+	db.attachedMu.Lock()
+	defer db.attachedMu.Unlock()
+
+	if db.closeContext.Err() != nil {
+		return errors.New("database is closed")
+	}
+
+	if _, ok := db.attached[name]; ok {
+		return errors.Errorf("database %q is already attached", name)
+	}
+
+	opts := db.opts
+	if opts == nil {
+		opts = new(Options)
+	}
+
+	adb, err := Open(path, opts)
+	if err != nil {
+		return errors.Wrapf(err, "failed to attach database %q", name)
+	}
+
+	err = db.resolveAttached(adb)
+	if err != nil {
+		return errors.CombineErrors(errors.Wrapf(err, "failed to attach database %q", name), adb.Close())
+	}
+
+	if db.attached == nil {
+		db.attached = make(map[string]*Database)
+	}
+	db.attached[name] = adb
+	return nil
+}
+
+// Detach closes the database attached under the given name.
+// It waits for the transactions using it to be closed.
+func (db *Database) Detach(name string) error {
+	db.attachedMu.Lock()
+	adb, ok := db.attached[name]
+	delete(db.attached, name)
+	db.attachedMu.Unlock()
+
+	if !ok {
+		return errors.Errorf("database %q is not attached", name)
+	}
+
+	return adb.Close()
+}
+
+// Attached returns the database attached under the given name.
+func (db *Database) Attached(name string) (*Database, error) {
+	db.attachedMu.RLock()
+	defer db.attachedMu.RUnlock()
+
+	adb, ok := db.attached[name]
+	if !ok {
+		return nil, errors.Errorf("database %q is not attached", name)
+	}
+
+	return adb, nil
+}
+
+// closeAttached closes all the attached databases.
+func (db *Database) closeAttached() error {
+	db.attachedMu.Lock()
+	attached := db.attached
+	db.attached = nil
+	db.attachedMu.Unlock()
+
+	var err error
+	for _, adb := range attached {
+		err = errors.CombineErrors(err, adb.Close())
+	}
+
+	return err
+}
+
+// Attached returns the transaction used to access the database
+// attached under the given name, beginning it on first use.
+// It has the same mode as tx and is committed or rolled back with it.
+// Savepoints of tx don't apply to it.
+func (tx *Transaction) Attached(name string) (*Transaction, error) {
+	if atx, ok := tx.attached[name]; ok {
+		return atx, nil
+	}
+
+	adb, err := tx.db.Attached(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// prevent the attached database from being closed
+	// while the transaction is running
+	adb.connectionWg.Add(1)
+
+	atx, err := adb.beginTx(&TxOptions{ReadOnly: !tx.Writable})
+	if err != nil {
+		adb.connectionWg.Done()
+		return nil, err
+	}
+
+	if tx.attached == nil {
+		tx.attached = make(map[string]*Transaction)
+	}
+	tx.attached[name] = atx
+	return atx, nil
+}
+
+// UsesAttached reports whether the transaction accessed the database
+// attached under the given name.
+func (tx *Transaction) UsesAttached(name string) bool {
+	_, ok := tx.attached[name]
+	return ok
+}
+
+// prepareAttached durably prepares the changes of the transactions of the
+// attached databases, the first phase of their commit, so that they can
+// no longer fail to be committed once the main database is committed.
+// The decision to commit each of them is recorded in the main database,
+// in the AttachedCommitNamespace, and committed with its changes.
+// Databases whose engine doesn't support two-phase commit, such as
+// in-memory databases, are committed once the others are prepared.
+// If one of them fails, the transactions of the attached databases
+// are rolled back, except those already committed: this only happens
+// if several attached databases don't support two-phase commit.
+// If the process crashes before commitAttached is called, the transactions
+// stay prepared until the databases are attached again, see resolveAttached.
+func (tx *Transaction) prepareAttached() error {
+	if len(tx.attached) == 0 {
+		return nil
+	}
+
+	commitID, err := newAttachedCommitID()
+	if err != nil {
+		return err
+	}
+
+	decisions := tree.New(tx.Session, AttachedCommitNamespace, 0)
+	var others []*Transaction
+	for name, atx := range tx.attached {
+		if _, ok := atx.Session.(engine.TwoPhaseSession); !ok {
+			others = append(others, atx)
+			continue
+		}
+
+		err = atx.PrepareCommit(attachedTxPrefix + name + ":" + commitID)
+		if err != nil {
+			break
+		}
+
+		err = decisions.Put(attachedCommitKey(atx.preparedID), nil)
+		if err != nil {
+			break
+		}
+	}
+
+	if err == nil {
+		for _, atx := range others {
+			err = atx.Commit()
+			if err != nil {
+				break
+			}
+		}
+	}
+
+	if err != nil {
+		return errors.CombineErrors(err, tx.rollbackAttached())
+	}
+
+	return nil
+}
+
+// commitAttached commits the transactions of the attached databases
+// prepared by prepareAttached.
+// Prepared transactions that fail to be committed stay prepared.
+// The decisions of those committed are deleted by the next
+// write transaction of the main database.
+func (tx *Transaction) commitAttached() error {
+	var err error
+	for _, atx := range tx.attached {
+		if id := atx.preparedID; id != "" {
+			cerr := atx.Commit()
+			if cerr == nil {
+				tx.db.attachedCommitDone(id)
+			}
+			err = errors.CombineErrors(err, cerr)
+		}
+		atx.db.connectionWg.Done()
+	}
+	tx.attached = nil
+
+	return err
+}
+
+// rollbackAttached rolls back the transactions of the attached databases.
+func (tx *Transaction) rollbackAttached() error {
+	var err error
+	for _, atx := range tx.attached {
+		err = errors.CombineErrors(err, atx.Rollback())
+		atx.db.connectionWg.Done()
+	}
+	tx.attached = nil
+
+	return err
+}
+
+func newAttachedCommitID() (string, error) {
+	var b [16]byte
+	_, err := rand.Read(b[:])
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b[:]), nil
+}
+
+func attachedCommitKey(preparedID string) *tree.Key {
+	return tree.NewKey(types.NewTextValue(preparedID))
+}
+
+// attachedCommitDone records that the prepared transaction of an attached
+// database was committed: its decision is no longer needed.
+func (db *Database) attachedCommitDone(preparedID string) {
+	db.attachedCommitsMu.Lock()
+	db.attachedCommits = append(db.attachedCommits, preparedID)
+	db.attachedCommitsMu.Unlock()
+}
+
+// deleteAttachedCommits deletes the decisions of the prepared transactions
+// of the attached databases that are committed.
+func (tx *Transaction) deleteAttachedCommits() error {
+	tx.db.attachedCommitsMu.Lock()
+	ids := tx.db.attachedCommits
+	tx.db.attachedCommitsMu.Unlock()
+	if len(ids) == 0 {
+		return nil
+	}
+
+	decisions := tree.New(tx.Session, AttachedCommitNamespace, 0)
+	for _, id := range ids {
+		err := decisions.Delete(attachedCommitKey(id))
+		if err != nil && !errors.Is(err, engine.ErrKeyNotFound) {
+			return err
+		}
+	}
+
+	// write transactions are serialized: other ids can only
+	// be appended to the list until the transaction is committed.
+	tx.OnCommitHooks = append(tx.OnCommitHooks, func() {
+		tx.db.attachedCommitsMu.Lock()
+		tx.db.attachedCommits = tx.db.attachedCommits[len(ids):]
+		tx.db.attachedCommitsMu.Unlock()
+	})
+	return nil
+}
+
+// resolveAttached resolves the transaction left prepared in adb by a commit
+// of db that didn't complete because the process stopped: it is committed
+// if its decision was committed with the changes of the main database,
+// and rolled back otherwise.
+// A transaction prepared by another main database can't be told apart:
+// the database must be attached to the one that prepared it.
+func (db *Database) resolveAttached(adb *Database) error {
+	id, ok := adb.PreparedTransaction()
+	if !ok || !strings.HasPrefix(id, attachedTxPrefix) {
+		return nil
+	}
+
+	tx, err := db.beginTx(&TxOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	committed, err := tree.New(tx.Session, AttachedCommitNamespace, 0).Exists(attachedCommitKey(id))
+	_ = tx.Rollback()
+	if err != nil {
+		return err
+	}
+
+	if !committed {
+		return adb.RollbackPrepared(id)
+	}
+
+	err = adb.CommitPrepared(id)
+	if err != nil {
+		return err
+	}
+
+	db.attachedCommitDone(id)
+	return nil
+}
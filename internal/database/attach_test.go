@@ -0,0 +1,262 @@
+package database_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/testutil"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttach(t *testing.T) {
+	dir, err := os.MkdirTemp("", "chai")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "other")
+	other, err := chai.Open(path)
+	require.NoError(t, err)
+	err = other.Exec(`
+		CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);
+		INSERT INTO users (id, name) VALUES (1, 'foo');
+	`)
+	require.NoError(t, err)
+	require.NoError(t, other.Close())
+
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);
+		INSERT INTO users (id, name) VALUES (10, 'bar');
+	`)
+	require.NoError(t, err)
+
+	err = db.Exec(fmt.Sprintf("ATTACH '%s' AS other", path))
+	require.NoError(t, err)
+
+	t.Run("Read", func(t *testing.T) {
+		r, err := db.QueryRow("SELECT name FROM other.users")
+		require.NoError(t, err)
+		testutil.RequireJSONEq(t, r, `{"name": "foo"}`)
+
+		r, err = db.QueryRow("SELECT name FROM main.users")
+		require.NoError(t, err)
+		testutil.RequireJSONEq(t, r, `{"name": "bar"}`)
+	})
+
+	t.Run("Write", func(t *testing.T) {
+		conn, err := db.Connect()
+		require.NoError(t, err)
+		defer conn.Close()
+
+		tx, err := conn.Begin(true)
+		require.NoError(t, err)
+		require.NoError(t, tx.Exec("INSERT INTO other.users (id, name) VALUES (2, 'baz')"))
+		require.NoError(t, tx.Exec("UPDATE users SET name = 'qux'"))
+		require.NoError(t, tx.Rollback())
+
+		r, err := db.QueryRow("SELECT COUNT(*) AS n FROM other.users")
+		require.NoError(t, err)
+		testutil.RequireJSONEq(t, r, `{"n": 1}`)
+
+		tx, err = conn.Begin(true)
+		require.NoError(t, err)
+		require.NoError(t, tx.Exec("INSERT INTO other.users (id, name) VALUES (2, 'baz')"))
+		require.NoError(t, tx.Exec("DELETE FROM users"))
+		require.NoError(t, tx.Commit())
+
+		r, err = db.QueryRow("SELECT COUNT(*) AS n FROM other.users")
+		require.NoError(t, err)
+		testutil.RequireJSONEq(t, r, `{"n": 2}`)
+
+		r, err = db.QueryRow("SELECT COUNT(*) AS n FROM users")
+		require.NoError(t, err)
+		testutil.RequireJSONEq(t, r, `{"n": 0}`)
+	})
+
+	t.Run("Cross database statements", func(t *testing.T) {
+		err := db.Exec("INSERT INTO users SELECT * FROM other.users")
+		require.Error(t, err)
+
+		_, err = db.QueryRow("SELECT * FROM users UNION ALL SELECT * FROM other.users")
+		require.Error(t, err)
+	})
+
+	t.Run("Commit several databases", func(t *testing.T) {
+		thirdPath := filepath.Join(dir, "third")
+		third, err := chai.Open(thirdPath)
+		require.NoError(t, err)
+		require.NoError(t, third.Exec("CREATE TABLE logs (id INTEGER PRIMARY KEY)"))
+		require.NoError(t, third.Close())
+
+		err = db.Exec(fmt.Sprintf("ATTACH '%s' AS third", thirdPath))
+		require.NoError(t, err)
+
+		conn, err := db.Connect()
+		require.NoError(t, err)
+		defer conn.Close()
+
+		tx, err := conn.Begin(true)
+		require.NoError(t, err)
+		require.NoError(t, tx.Exec("INSERT INTO other.users (id, name) VALUES (3, 'quux')"))
+		require.NoError(t, tx.Exec("INSERT INTO third.logs (id) VALUES (1)"))
+		require.NoError(t, tx.Exec("INSERT INTO users (id, name) VALUES (20, 'corge')"))
+		require.NoError(t, tx.Commit())
+
+		r, err := db.QueryRow("SELECT COUNT(*) AS n FROM other.users")
+		require.NoError(t, err)
+		testutil.RequireJSONEq(t, r, `{"n": 3}`)
+
+		r, err = db.QueryRow("SELECT COUNT(*) AS n FROM users")
+		require.NoError(t, err)
+		testutil.RequireJSONEq(t, r, `{"n": 1}`)
+
+		require.NoError(t, db.Exec("DETACH third"))
+
+		// the transactions prepared during the commit
+		// must not outlive it
+		third, err = chai.Open(thirdPath)
+		require.NoError(t, err)
+		defer third.Close()
+
+		_, ok := third.PreparedTransaction()
+		require.False(t, ok)
+
+		r, err = third.QueryRow("SELECT COUNT(*) AS n FROM logs")
+		require.NoError(t, err)
+		testutil.RequireJSONEq(t, r, `{"n": 1}`)
+	})
+
+	t.Run("Detach", func(t *testing.T) {
+		conn, err := db.Connect()
+		require.NoError(t, err)
+		defer conn.Close()
+
+		tx, err := conn.Begin(false)
+		require.NoError(t, err)
+		_, err = tx.QueryRow("SELECT * FROM other.users")
+		require.NoError(t, err)
+		require.Error(t, tx.Exec("DETACH other"))
+		require.NoError(t, tx.Rollback())
+
+		require.NoError(t, db.Exec("DETACH DATABASE other"))
+		require.Error(t, db.Exec("DETACH other"))
+
+		_, err = db.QueryRow("SELECT * FROM other.users")
+		require.Error(t, err)
+	})
+}
+
+func TestAttachRecovery(t *testing.T) {
+	dir := t.TempDir()
+	mainPath := filepath.Join(dir, "main")
+	otherPath := filepath.Join(dir, "other")
+
+	other, err := chai.Open(otherPath)
+	require.NoError(t, err)
+	require.NoError(t, other.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY)"))
+	require.NoError(t, other.Close())
+
+	db, err := chai.Open(mainPath)
+	require.NoError(t, err)
+	defer func() { db.Close() }()
+	require.NoError(t, db.Exec("CREATE TABLE logs (id INTEGER PRIMARY KEY)"))
+
+	attach := func(t *testing.T) {
+		t.Helper()
+		require.NoError(t, db.Exec(fmt.Sprintf("ATTACH '%s' AS other", otherPath)))
+	}
+
+	requireCount := func(t *testing.T, table, want string) {
+		t.Helper()
+
+		r, err := db.QueryRow("SELECT COUNT(*) AS n FROM " + table)
+		require.NoError(t, err)
+		testutil.RequireJSONEq(t, r, want)
+	}
+
+	// prepared returns the transaction left prepared in the other database.
+	prepared := func(t *testing.T) string {
+		t.Helper()
+
+		other, err := chai.Open(otherPath)
+		require.NoError(t, err)
+		defer other.Close()
+
+		id, _ := other.PreparedTransaction()
+		return id
+	}
+
+	// write inserts a row in both databases and ends the transaction with end.
+	write := func(t *testing.T, id int, end func(tx *database.Transaction) error) {
+		t.Helper()
+
+		conn, err := db.DB.Connect()
+		require.NoError(t, err)
+		defer conn.Close()
+
+		tx, err := conn.BeginTx(nil)
+		require.NoError(t, err)
+		testutil.MustExec(t, db.DB, tx, fmt.Sprintf("INSERT INTO other.users (id) VALUES (%d)", id))
+		testutil.MustExec(t, db.DB, tx, fmt.Sprintf("INSERT INTO logs (id) VALUES (%d)", id))
+		require.NoError(t, end(tx))
+	}
+
+	t.Run("Main database committed", func(t *testing.T) {
+		attach(t)
+		write(t, 1, (*database.Transaction).CommitMainOnly)
+		require.NoError(t, db.Exec("DETACH other"))
+		require.True(t, strings.HasPrefix(prepared(t), "attached:other:"))
+
+		// the process restarts
+		require.NoError(t, db.Close())
+		db, err = chai.Open(mainPath)
+		require.NoError(t, err)
+
+		attach(t)
+		requireCount(t, "other.users", `{"n": 1}`)
+		requireCount(t, "logs", `{"n": 1}`)
+		require.NoError(t, db.Exec("DETACH other"))
+		require.Empty(t, prepared(t))
+	})
+
+	t.Run("Main database not committed", func(t *testing.T) {
+		attach(t)
+		write(t, 2, (*database.Transaction).PrepareAttachedOnly)
+		require.NoError(t, db.Exec("DETACH other"))
+		require.True(t, strings.HasPrefix(prepared(t), "attached:other:"))
+
+		attach(t)
+		requireCount(t, "other.users", `{"n": 1}`)
+		requireCount(t, "logs", `{"n": 1}`)
+
+		// the write lock of the attached database is released
+		require.NoError(t, db.Exec("INSERT INTO other.users (id) VALUES (3)"))
+		require.NoError(t, db.Exec("DETACH other"))
+		require.Empty(t, prepared(t))
+	})
+
+	t.Run("Decisions are deleted", func(t *testing.T) {
+		require.NoError(t, db.Exec("INSERT INTO logs (id) VALUES (4)"))
+
+		tx, err := db.DB.Begin(false)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		var n int
+		err = tree.New(tx.Session, database.AttachedCommitNamespace, 0).IterateOnRange(nil, false, func(*tree.Key, []byte) error {
+			n++
+			return nil
+		})
+		require.NoError(t, err)
+		require.Zero(t, n)
+	})
+}
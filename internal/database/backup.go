@@ -0,0 +1,471 @@
+package database
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/chaisql/chai/internal/kv"
+	"github.com/cockroachdb/errors"
+)
+
+// A checkpointer is an engine able to write a consistent copy
+// of its data to a directory, while reads and writes continue.
+type checkpointer interface {
+	Checkpoint(dir string) error
+}
+
+// name of the manifest in backup archives.
+const backupManifestName = "chai-backup.json"
+
+// A BackupManifest lists the files of the database when a backup was made.
+// It is the first entry of the archives written by Backup and BackupIncremental.
+type BackupManifest struct {
+	// Files of the database, including those that were not copied
+	// by an incremental backup because they were part of a previous backup.
+	Files []BackupFile `json:"files"`
+}
+
+// A BackupFile is a file of the database listed in a BackupManifest.
+type BackupFile struct {
+	// Path of the file, relative to the directory of the database.
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+// immutable returns whether the file never changes once written.
+// Such files are not copied again by incremental backups.
+func (f *BackupFile) immutable() bool {
+	return strings.HasSuffix(f.Name, ".sst")
+}
+
+// BackupTo writes a consistent copy of the database to the directory at the given path,
+// which must not exist. The copy can be opened like any database.
+// Reads and writes can continue while the backup is created.
+// Changes of transactions not yet committed are not part of the backup.
+func (db *Database) BackupTo(ctx context.Context, path string) error {
+	cp, ok := db.Engine.(checkpointer)
+	if !ok {
+		return errors.New("the storage engine of the database doesn't support backups")
+	}
+
+	if ctx != nil && ctx.Err() != nil {
+		return context.Cause(ctx)
+	}
+
+	_, err := os.Stat(path)
+	if err == nil {
+		return errors.Errorf("cannot backup to %q: file already exists", path)
+	}
+	if !os.IsNotExist(err) {
+		return err
+	}
+
+	return cp.Checkpoint(path)
+}
+
+// Backup writes a consistent copy of the database to w, as a tar archive.
+// Extracting the archive in a directory creates a database
+// that can be opened like any database.
+// Reads and writes can continue while the backup is created.
+func (db *Database) Backup(ctx context.Context, w io.Writer) error {
+	_, err := db.BackupIncremental(ctx, w, nil)
+	return err
+}
+
+// BackupIncremental writes a backup of the database to w, as a tar archive,
+// skipping the files that were already part of the backup described by since.
+// If since is nil, all the files are written, like Backup.
+// It returns the manifest of the backup, to pass to the next incremental backup.
+// Incremental backups are restored with RestoreBackup, after the backups they depend on.
+func (db *Database) BackupIncremental(ctx context.Context, w io.Writer, since *BackupManifest) (*BackupManifest, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	tmp, err := os.MkdirTemp("", "chai-backup-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	dir := filepath.Join(tmp, "db")
+	err = db.BackupTo(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest BackupManifest
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		name, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+
+		manifest.Files = append(manifest.Files, BackupFile{
+			Name: filepath.ToSlash(name),
+			Size: info.Size(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	skip := make(map[BackupFile]bool)
+	if since != nil {
+		for _, f := range since.Files {
+			if f.immutable() {
+				skip[f] = true
+			}
+		}
+	}
+
+	tw := tar.NewWriter(w)
+
+	data, err := json.Marshal(&manifest)
+	if err != nil {
+		return nil, err
+	}
+	err = tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     backupManifestName,
+		Mode:     0600,
+		Size:     int64(len(data)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	_, err = tw.Write(data)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range manifest.Files {
+		if ctx.Err() != nil {
+			return nil, context.Cause(ctx)
+		}
+		if skip[f] {
+			continue
+		}
+
+		err = writeBackupFile(tw, dir, f)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tw.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return &manifest, nil
+}
+
+func writeBackupFile(tw *tar.Writer, dir string, f BackupFile) error {
+	err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     f.Name,
+		Mode:     0600,
+		Size:     f.Size,
+	})
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(filepath.Join(dir, filepath.FromSlash(f.Name)))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.CopyN(tw, file, f.Size)
+	return err
+}
+
+// ReadBackupManifest reads the manifest of a backup archive.
+// Only the beginning of the archive is read.
+func ReadBackupManifest(r io.Reader) (*BackupManifest, error) {
+	return readBackupManifest(tar.NewReader(r))
+}
+
+func readBackupManifest(tr *tar.Reader) (*BackupManifest, error) {
+	hdr, err := tr.Next()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid backup")
+	}
+	if hdr.Name != backupManifestName {
+		return nil, errors.New("invalid backup: missing manifest")
+	}
+
+	var manifest BackupManifest
+	err = json.NewDecoder(tr).Decode(&manifest)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid backup")
+	}
+
+	return &manifest, nil
+}
+
+// RestoreBackup creates a database in the directory at the given path,
+// which must not exist, from a full backup followed by the incremental backups
+// made after it, in the order they were made.
+func RestoreBackup(dst string, backups ...io.Reader) error {
+	if len(backups) == 0 {
+		return errors.New("no backup to restore")
+	}
+
+	_, err := os.Stat(dst)
+	if err == nil {
+		return errors.Errorf("cannot restore to %q: file already exists", dst)
+	}
+	if !os.IsNotExist(err) {
+		return err
+	}
+
+	err = os.MkdirAll(dst, 0700)
+	if err != nil {
+		return err
+	}
+
+	for i, r := range backups {
+		err = restoreBackup(dst, r)
+		if err != nil {
+			_ = os.RemoveAll(dst)
+			return errors.Wrapf(err, "cannot restore backup %d", i+1)
+		}
+	}
+
+	return nil
+}
+
+// restoreBackup extracts the archive in dir and removes the files
+// of the previous backups that are not part of the database anymore.
+func restoreBackup(dir string, r io.Reader) error {
+	tr := tar.NewReader(r)
+
+	manifest, err := readBackupManifest(tr)
+	if err != nil {
+		return err
+	}
+
+	files := make(map[string]int64, len(manifest.Files))
+	for _, f := range manifest.Files {
+		if !filepath.IsLocal(filepath.FromSlash(f.Name)) {
+			return errors.Errorf("invalid backup: invalid file name %q", f.Name)
+		}
+		files[f.Name] = f.Size
+	}
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return errors.Wrap(err, "invalid backup")
+		}
+
+		if _, ok := files[hdr.Name]; !ok {
+			return errors.Errorf("invalid backup: unexpected file %q", hdr.Name)
+		}
+
+		err = extractBackupFile(dir, hdr.Name, tr)
+		if err != nil {
+			return err
+		}
+	}
+
+	// ensure the files skipped by an incremental backup
+	// were restored from the previous ones.
+	for name, size := range files {
+		fi, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil || fi.Size() != size {
+			return errors.Errorf("file %q is missing, restore the previous backups first", name)
+		}
+	}
+
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		name, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		if _, ok := files[filepath.ToSlash(name)]; ok {
+			return nil
+		}
+
+		return os.Remove(p)
+	})
+}
+
+func extractBackupFile(dir, name string, r io.Reader) error {
+	p := filepath.Join(dir, filepath.FromSlash(name))
+
+	err := os.MkdirAll(filepath.Dir(p), 0700)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(f, r)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// OpenBackup opens the database stored in a full backup followed by the incremental
+// backups made after it, in the order they were made, without restoring them:
+// the files of the database are read in place from the archives.
+// Write transactions fail with ErrReadOnly. The changes made by the storage engine
+// when the database is opened are kept in memory and lost when it is closed.
+// The backups implementing io.Closer are closed with the database.
+func OpenBackup(ctx context.Context, opts *Options, backups ...io.ReaderAt) (*Database, error) {
+	if len(backups) == 0 {
+		return nil, errors.New("no backup to open")
+	}
+
+	files := make(map[string]kv.ArchiveFile)
+	for i, r := range backups {
+		err := indexBackup(files, r)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot open backup %d", i+1)
+		}
+	}
+
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	o.ReadOnly = true
+	o.OpenEngine = func(string) (engine.Engine, error) {
+		return kv.NewArchiveEngine(files, engineOptions(ctx, &o))
+	}
+
+	return OpenContext(ctx, "", &o)
+}
+
+// indexBackup adds the files of the archive to the files of the previous backups
+// and removes those that are not part of the database anymore, like restoreBackup.
+// The content of the files is not read.
+func indexBackup(files map[string]kv.ArchiveFile, r io.ReaderAt) error {
+	sr := io.NewSectionReader(r, 0, math.MaxInt64)
+	tr := tar.NewReader(sr)
+
+	manifest, err := readBackupManifest(tr)
+	if err != nil {
+		return err
+	}
+
+	sizes := make(map[string]int64, len(manifest.Files))
+	for _, f := range manifest.Files {
+		if !filepath.IsLocal(filepath.FromSlash(f.Name)) {
+			return errors.Errorf("invalid backup: invalid file name %q", f.Name)
+		}
+		sizes[f.Name] = f.Size
+	}
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return errors.Wrap(err, "invalid backup")
+		}
+
+		if _, ok := sizes[hdr.Name]; !ok {
+			return errors.Errorf("invalid backup: unexpected file %q", hdr.Name)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			return errors.Errorf("invalid backup: %q is not a regular file", hdr.Name)
+		}
+
+		// the tar reader doesn't buffer: the content of the file
+		// starts at the current offset.
+		off, err := sr.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+
+		files[hdr.Name] = kv.ArchiveFile{
+			Archive: r,
+			Offset:  off,
+			Size:    hdr.Size,
+		}
+	}
+
+	for name := range files {
+		if _, ok := sizes[name]; !ok {
+			delete(files, name)
+		}
+	}
+
+	// ensure the files skipped by an incremental backup
+	// are part of the previous ones.
+	for name, size := range sizes {
+		if f, ok := files[name]; !ok || f.Size != size {
+			return errors.Errorf("file %q is missing, open the previous backups first", name)
+		}
+	}
+
+	return nil
+}
+
+// RestoreToTime creates a database in the directory at the given path, like RestoreBackup,
+// then replays the batches archived in walArchiveDir that were committed
+// after the backups and until the given time.
+// The backups must have been made while the WAL archive was enabled.
+// It returns the time of the last replayed commit, or the zero time if none was replayed.
+func RestoreToTime(dst, walArchiveDir string, until time.Time, backups ...io.Reader) (time.Time, error) {
+	err := RestoreBackup(dst, backups...)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	ng, err := kv.NewEngine(dst, kv.Options{
+		RollbackSegmentNamespace: int64(RollbackSegmentNamespace),
+		MinTransientNamespace:    uint64(MinTransientNamespace),
+		MaxTransientNamespace:    uint64(MaxTransientNamespace),
+		CommitTimestampNamespace: int64(CommitTimestampNamespace),
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	last, err := ng.ReplayWALArchive(walArchiveDir, until)
+	if err != nil {
+		_ = ng.Close()
+		return time.Time{}, errors.Wrap(err, "cannot replay the WAL archive")
+	}
+
+	return last, ng.Close()
+}
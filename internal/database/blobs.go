@@ -0,0 +1,288 @@
+package database
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/engine"
+	errs "github.com/chaisql/chai/internal/errors"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// A BlobReader reads the values stored outside of the rows
+// by engines supporting external blobs.
+type BlobReader interface {
+	ReadBlob(ptr []byte) ([]byte, error)
+}
+
+// A blobStore is an engine able to store large values
+// outside of its keys and values.
+type blobStore interface {
+	BlobReader
+	AppendBlob(v []byte) ([]byte, error)
+}
+
+// checkBlobOptions ensures the engine can store the values
+// larger than the threshold of the options.
+func checkBlobOptions(ng any, opts *Options) error {
+	if opts.BlobThreshold <= 0 {
+		return nil
+	}
+
+	if opts.WALArchiveDir != "" {
+		return errors.New("external blobs cannot be used with a WAL archive")
+	}
+	if _, ok := ng.(blobStore); !ok {
+		return errors.New("the storage engine of the database doesn't support external blobs")
+	}
+
+	return nil
+}
+
+// BlobReader returns the reader of the values stored outside of the rows,
+// or nil if the engine doesn't support external blobs.
+func (tx *Transaction) BlobReader() BlobReader {
+	r, _ := tx.Engine.(BlobReader)
+	return r
+}
+
+// externalize moves the text and blob values of the encoded row
+// larger than the blob threshold of the database to the blob store
+// of the engine, and replaces them with pointers.
+// It returns enc if no value was moved.
+func (t *Table) externalize(enc []byte) ([]byte, error) {
+	db := t.Tx.db
+	if db == nil || db.opts == nil || db.opts.BlobThreshold <= 0 {
+		return enc, nil
+	}
+	// internal tables are read without a blob reader
+	if strings.HasPrefix(t.Info.TableName, InternalPrefix) {
+		return enc, nil
+	}
+
+	store := db.Engine.(blobStore)
+
+	var out []byte
+	b := enc
+	for len(b) > 0 {
+		n := encoding.Skip(b)
+		if b[0] == encoding.TextValue || b[0] == encoding.BlobValue {
+			data, _ := encoding.DecodeBlob(b[:n])
+			if len(data) > db.opts.BlobThreshold {
+				ptr, err := store.AppendBlob(data)
+				if err != nil {
+					return nil, errors.Wrap(err, "cannot store blob")
+				}
+
+				if out == nil {
+					out = append(make([]byte, 0, len(enc)), enc[:len(enc)-len(b)]...)
+				}
+				out = encoding.EncodeExternal(out, b[0], ptr)
+				b = b[n:]
+				continue
+			}
+		}
+
+		if out != nil {
+			out = append(out, b[:n]...)
+		}
+		b = b[n:]
+	}
+
+	if out == nil {
+		return enc, nil
+	}
+
+	err := t.Tx.requireFormat(formatV2)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// A blobStreamer is an engine able to write and read external blobs
+// without holding them in memory.
+type blobStreamer interface {
+	AppendBlobFrom(r io.Reader) ([]byte, int64, error)
+	OpenBlob(ptr []byte) (io.Reader, error)
+}
+
+// streamer returns the engine used to stream the values of the table,
+// or nil if they must be stored in the rows.
+func (t *Table) streamer() blobStreamer {
+	db := t.Tx.db
+	if db == nil || db.opts == nil || db.opts.BlobThreshold <= 0 {
+		return nil
+	}
+	if strings.HasPrefix(t.Info.TableName, InternalPrefix) {
+		return nil
+	}
+
+	s, _ := db.Engine.(blobStreamer)
+	return s
+}
+
+// WriteBlob sets the BLOB column of the row identified by key to the content of r.
+// If the database stores large values outside of the rows, the content is
+// streamed to the blob store. Otherwise, it is read entirely and stored in the row.
+// Indexes and CHECK constraints are not updated, so the column must not be
+// part of any of them.
+func (t *Table) WriteBlob(key *tree.Key, column string, r io.Reader) error {
+	if t.Info.ReadOnly {
+		return errors.New("cannot write to read-only table")
+	}
+
+	cc, err := t.blobColumn(column)
+	if err != nil {
+		return err
+	}
+	if cc.Type != types.TypeBlob {
+		return errors.Errorf("column %s is not a BLOB column", column)
+	}
+	if err := t.checkUnconstrained(column); err != nil {
+		return err
+	}
+
+	enc, err := t.Tree.Get(key)
+	if err != nil {
+		if errors.Is(err, engine.ErrKeyNotFound) {
+			return errs.NewNotFoundError(key.String())
+		}
+		return err
+	}
+
+	s := t.streamer()
+	if s == nil {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+
+		var cb row.ColumnBuffer
+		e := NewEncodedRow(&t.Info.ColumnConstraints, enc)
+		e.blobs = t.Tx.BlobReader()
+		if err := cb.Copy(e); err != nil {
+			return err
+		}
+		if err := cb.Replace(column, types.NewBlobValue(data)); err != nil {
+			return err
+		}
+
+		_, err = t.Put(key, &cb)
+		return err
+	}
+
+	t.Tx.markModified(t.Info.TableName)
+
+	err = t.Tx.requireFormat(formatV2)
+	if err != nil {
+		return err
+	}
+
+	ptr, size, err := s.AppendBlobFrom(r)
+	if err != nil {
+		return errors.Wrap(err, "cannot store blob")
+	}
+
+	// replace the value of the column with the pointer
+	e := NewEncodedRow(&t.Info.ColumnConstraints, enc)
+	b := e.seek(cc.Position)
+	start, n := len(enc)-len(b), encoding.Skip(b)
+
+	if err := t.Tx.checkRowSize(len(enc) - n + int(size)); err != nil {
+		return err
+	}
+
+	out := append(make([]byte, 0, len(enc)-n+len(ptr)+8), enc[:start]...)
+	out = encoding.EncodeExternal(out, encoding.BlobValue, ptr)
+	out = append(out, enc[start+n:]...)
+
+	return t.Tree.Put(key, out)
+}
+
+// OpenBlob returns a reader of the BLOB or TEXT column of the row identified by key.
+// Values stored outside of the row are read in chunks, when the engine supports it.
+// The reader remains valid until the database is closed.
+func (t *Table) OpenBlob(key *tree.Key, column string) (io.Reader, error) {
+	cc, err := t.blobColumn(column)
+	if err != nil {
+		return nil, err
+	}
+	if cc.Type != types.TypeBlob && cc.Type != types.TypeText {
+		return nil, errors.Errorf("column %s is not a BLOB or TEXT column", column)
+	}
+
+	enc, err := t.Tree.Get(key)
+	if err != nil {
+		if errors.Is(err, engine.ErrKeyNotFound) {
+			return nil, errs.NewNotFoundError(key.String())
+		}
+		return nil, err
+	}
+
+	e := NewEncodedRow(&t.Info.ColumnConstraints, enc)
+	e.blobs = t.Tx.BlobReader()
+	b := e.seek(cc.Position)
+
+	switch b[0] {
+	case encoding.NullValue:
+		return nil, errors.Errorf("column %s is NULL", column)
+	case encoding.ExternalValue:
+		if s, ok := t.Tx.Engine.(blobStreamer); ok {
+			_, ptr, _ := encoding.DecodeExternal(b)
+			return s.OpenBlob(ptr)
+		}
+	}
+
+	v, _, err := e.decodeValue(cc, b)
+	if err != nil {
+		return nil, err
+	}
+
+	if v.Type() == types.TypeText {
+		return strings.NewReader(strings.Clone(types.AsString(v))), nil
+	}
+
+	return bytes.NewReader(bytes.Clone(types.AsByteSlice(v))), nil
+}
+
+func (t *Table) blobColumn(column string) (*ColumnConstraint, error) {
+	cc, ok := t.Info.ColumnConstraints.ByColumn[column]
+	if !ok {
+		return nil, errors.Wrapf(types.ErrColumnNotFound, "%s not found", column)
+	}
+
+	return cc, nil
+}
+
+// checkUnconstrained returns an error if the column is part of the primary key,
+// of an index or of a CHECK constraint of the table.
+// Expressions are matched by name, which can report columns they don't use.
+func (t *Table) checkUnconstrained(column string) error {
+	if pk := t.Info.PrimaryKey; pk != nil && slices.Contains(pk.Columns, column) {
+		return errors.Errorf("column %s is part of the primary key", column)
+	}
+
+	for _, tc := range t.Info.TableConstraints {
+		if tc.Check != nil && strings.Contains(tc.Check.String(), column) {
+			return errors.Errorf("column %s is used by the CHECK constraint %s", column, tc.Name)
+		}
+	}
+
+	for _, idx := range t.Tx.Catalog.Cache.GetTableIndexes(t.Info.TableName) {
+		for _, c := range idx.Columns {
+			if strings.Contains(c, column) {
+				return errors.Errorf("column %s is indexed by %s", column, idx.IndexName)
+			}
+		}
+	}
+
+	return nil
+}
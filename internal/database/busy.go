@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+// ErrBusy is returned when a write transaction can't be started
+// because another one is running, according to Options.BusyTimeout
+// and Options.BusyHandler.
+var ErrBusy = errors.New("database is busy")
+
+// maximum time between two attempts to acquire the write lock
+// when waiting with a timeout.
+const maxBusyBackoff = 50 * time.Millisecond
+
+// lockWriter acquires the write lock, waiting for the current
+// write transaction according to the busy options of the database.
+// The wait stops when ctx is done, if not nil.
+func (db *Database) lockWriter(ctx context.Context) error {
+	if db.writetxmu.TryLock() {
+		return nil
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if db.opts.BusyHandler != nil {
+		for n := 0; db.opts.BusyHandler(n); n++ {
+			if db.writetxmu.TryLock() {
+				return nil
+			}
+			if ctx.Err() != nil {
+				return context.Cause(ctx)
+			}
+		}
+
+		return ErrBusy
+	}
+
+	switch {
+	case db.opts.BusyTimeout < 0:
+		return ErrBusy
+	case db.opts.BusyTimeout == 0 && ctx.Done() == nil:
+		db.writetxmu.Lock()
+		return nil
+	}
+
+	// without a timeout, the wait only stops when ctx is done
+	var deadline time.Time
+	if db.opts.BusyTimeout > 0 {
+		deadline = time.Now().Add(db.opts.BusyTimeout)
+	}
+	backoff := time.Millisecond
+	for {
+		wait := backoff
+		if !deadline.IsZero() {
+			wait = min(backoff, time.Until(deadline))
+			if wait <= 0 {
+				return errors.Wrapf(ErrBusy, "timed out after %s", db.opts.BusyTimeout)
+			}
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return context.Cause(ctx)
+		case <-timer.C:
+		}
+
+		if db.writetxmu.TryLock() {
+			return nil
+		}
+
+		backoff = min(2*backoff, maxBusyBackoff)
+	}
+}
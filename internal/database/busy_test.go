@@ -0,0 +1,149 @@
+package database_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBusy(t *testing.T) {
+	// begin starts a write transaction on a new connection of db
+	begin := func(t *testing.T, db *chai.DB) (*chai.Tx, error) {
+		t.Helper()
+
+		conn, err := db.Connect()
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+
+		return conn.Begin(true)
+	}
+
+	t.Run("fail fast", func(t *testing.T) {
+		db, err := chai.OpenWith(":memory:", &chai.Options{BusyTimeout: -1})
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		tx, err := begin(t, db)
+		require.NoError(t, err)
+
+		_, err = begin(t, db)
+		require.ErrorIs(t, err, chai.ErrBusy)
+
+		// readers are not affected
+		conn, err := db.Connect()
+		require.NoError(t, err)
+		defer conn.Close()
+		rtx, err := conn.Begin(false)
+		require.NoError(t, err)
+		require.NoError(t, rtx.Rollback())
+
+		require.NoError(t, tx.Rollback())
+		tx, err = begin(t, db)
+		require.NoError(t, err)
+		require.NoError(t, tx.Rollback())
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		db, err := chai.OpenWith(":memory:", &chai.Options{BusyTimeout: 100 * time.Millisecond})
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		tx, err := begin(t, db)
+		require.NoError(t, err)
+
+		start := time.Now()
+		_, err = begin(t, db)
+		require.ErrorIs(t, err, chai.ErrBusy)
+		require.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+
+		// waiting succeeds once the writer is done
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			_ = tx.Rollback()
+		}()
+		tx, err = begin(t, db)
+		require.NoError(t, err)
+		require.NoError(t, tx.Rollback())
+	})
+
+	t.Run("handler", func(t *testing.T) {
+		var calls []int
+		var tx *chai.Tx
+		db, err := chai.OpenWith(":memory:", &chai.Options{
+			BusyHandler: func(n int) bool {
+				calls = append(calls, n)
+				if n == 2 && tx != nil {
+					require.NoError(t, tx.Rollback())
+					tx = nil
+				}
+				return n < 5
+			},
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		tx, err = begin(t, db)
+		require.NoError(t, err)
+
+		// the third call releases the lock
+		tx2, err := begin(t, db)
+		require.NoError(t, err)
+		require.Equal(t, []int{0, 1, 2}, calls)
+
+		// giving up returns ErrBusy
+		calls = nil
+		_, err = begin(t, db)
+		require.ErrorIs(t, err, chai.ErrBusy)
+		require.Equal(t, []int{0, 1, 2, 3, 4, 5}, calls)
+		require.NoError(t, tx2.Rollback())
+	})
+}
+
+func TestDeferredTransactions(t *testing.T) {
+	db, err := chai.OpenWith(":memory:", &chai.Options{BusyTimeout: -1})
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	err = db.Exec("CREATE TABLE test(a INTEGER PRIMARY KEY)")
+	require.NoError(t, err)
+
+	connect := func() *chai.Connection {
+		conn, err := db.Connect()
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+		return conn
+	}
+
+	deferred := connect()
+	other := connect()
+
+	// a deferred transaction doesn't prevent other writers until it writes
+	require.NoError(t, deferred.Exec("BEGIN DEFERRED"))
+	require.NoError(t, other.Exec("INSERT INTO test (a) VALUES (1)"))
+
+	// it can't write once another transaction committed
+	err = deferred.Exec("INSERT INTO test (a) VALUES (2)")
+	require.ErrorIs(t, err, chai.ErrBusy)
+	require.NoError(t, deferred.Exec("ROLLBACK"))
+
+	// after its first write, it holds the write lock
+	require.NoError(t, deferred.Exec("BEGIN DEFERRED"))
+	require.NoError(t, deferred.Exec("INSERT INTO test (a) VALUES (2)"))
+	err = other.Exec("INSERT INTO test (a) VALUES (3)")
+	require.ErrorIs(t, err, chai.ErrBusy)
+	require.NoError(t, deferred.Exec("COMMIT"))
+
+	// immediate transactions acquire it when they begin
+	require.NoError(t, deferred.Exec("BEGIN IMMEDIATE"))
+	err = other.Exec("BEGIN DEFERRED; INSERT INTO test (a) VALUES (3)")
+	require.ErrorIs(t, err, chai.ErrBusy)
+	require.NoError(t, other.Exec("ROLLBACK"))
+	require.NoError(t, deferred.Exec("ROLLBACK"))
+
+	r, err := db.QueryRow("SELECT COUNT(*) AS n FROM test")
+	require.NoError(t, err)
+	testutil.RequireJSONEq(t, r, `{"n": 2}`)
+}
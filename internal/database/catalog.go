@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	errs "github.com/chaisql/chai/internal/errors"
+	"github.com/chaisql/chai/internal/fulltext"
 	"github.com/chaisql/chai/internal/pkg/atomic"
 	"github.com/chaisql/chai/internal/row"
 	"github.com/chaisql/chai/internal/tree"
@@ -16,8 +17,9 @@ import (
 
 // System tables
 const (
-	CatalogTableName  = InternalPrefix + "catalog"
-	SequenceTableName = InternalPrefix + "sequence"
+	CatalogTableName    = InternalPrefix + "catalog"
+	SequenceTableName   = InternalPrefix + "sequence"
+	StatisticsTableName = InternalPrefix + "statistics"
 )
 
 // Relation types
@@ -37,6 +39,12 @@ const (
 	CatalogTableNamespace    tree.Namespace = 1
 	SequenceTableNamespace   tree.Namespace = 2
 	RollbackSegmentNamespace tree.Namespace = 3
+	ManifestNamespace        tree.Namespace = 4
+	StatisticsTableNamespace tree.Namespace = 5
+	CommitTimestampNamespace tree.Namespace = 6
+	PreparedTxNamespace      tree.Namespace = 7
+	PendingIndexNamespace    tree.Namespace = 8
+	AttachedCommitNamespace  tree.Namespace = 9
 	MinTransientNamespace    tree.Namespace = math.MaxInt64 - 1<<24
 	MaxTransientNamespace    tree.Namespace = math.MaxInt64
 )
@@ -49,6 +57,11 @@ type Catalog struct {
 	CatalogTable *CatalogStore
 
 	TransientNamespaces *atomic.Counter
+
+	// Version identifies the state of the schema described by
+	// a committed catalog. It is zero for catalogs cloned by
+	// a transaction, as their schema may be modified.
+	Version uint64
 }
 
 func NewCatalog() *Catalog {
@@ -64,6 +77,7 @@ func (c *Catalog) Clone() *Catalog {
 		Cache:               c.Cache.Clone(),
 		CatalogTable:        c.CatalogTable,
 		TransientNamespaces: c.TransientNamespaces,
+		Version:             c.Version,
 	}
 }
 
@@ -99,7 +113,18 @@ func (c *Catalog) GetIndex(tx *Transaction, indexName string) (*Index, error) {
 		return nil, err
 	}
 
-	return NewIndex(tree.New(tx.Session, info.StoreNamespace, info.KeySortOrder), *info), nil
+	ti, err := c.GetTableInfo(info.Owner.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	idx := NewIndex(tree.New(tx.Session, info.StoreNamespace, info.KeySortOrder), *info)
+	idx.Collations, err = ti.Collations(info.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	return idx, nil
 }
 
 // GetIndexInfo returns an index info by name.
@@ -111,6 +136,18 @@ func (c *Catalog) GetIndexInfo(indexName string) (*IndexInfo, error) {
 	return r.(*IndexInfoRelation).Info, nil
 }
 
+// GetFulltextIndexInfo returns the full-text index of the given column,
+// or nil if there is none.
+func (c *Catalog) GetFulltextIndexInfo(tableName, column string) *IndexInfo {
+	for _, info := range c.Cache.GetTableIndexes(tableName) {
+		if info.Fulltext && info.Columns[0] == column {
+			return info
+		}
+	}
+
+	return nil
+}
+
 // ListIndexes returns all indexes for a given table name. If tableName is empty
 // if returns a list of all indexes.
 // The returned list of indexes is sorted lexicographically.
@@ -240,6 +277,25 @@ func (c *CatalogWriter) CreateTable(tx *Transaction, tableName string, info *Tab
 		return errors.New("table name required")
 	}
 
+	// expired rows are detected by comparing the TTL column with the current time
+	if info.TTLColumn != "" {
+		cc := info.GetColumnConstraint(info.TTLColumn)
+		if cc == nil {
+			return errors.Errorf("TTL column %q does not exist", info.TTLColumn)
+		}
+		if cc.Type != types.TypeTimestamp {
+			return errors.Errorf("TTL column %q must be of type timestamp, got %s", info.TTLColumn, cc.Type)
+		}
+	}
+	if info.ArchiveTable != "" {
+		if info.TTLColumn == "" {
+			return errors.New("archive table requires a TTL column")
+		}
+		if info.ArchiveTable == info.TableName {
+			return errors.New("a table cannot be its own archive table")
+		}
+	}
+
 	_, err := c.Catalog.GetTable(tx, tableName)
 	if err != nil && !errs.IsNotFoundError(err) {
 		return err
@@ -261,6 +317,8 @@ func (c *CatalogWriter) CreateTable(tx *Transaction, tableName string, info *Tab
 		return err
 	}
 
+	tx.markModified(tableName)
+
 	return c.Catalog.Cache.Add(tx, &rel)
 }
 
@@ -275,6 +333,8 @@ func (c *CatalogWriter) DropTable(tx *Transaction, tableName string) error {
 		return errors.New("cannot write to read-only table")
 	}
 
+	tx.markModified(tableName)
+
 	for _, idx := range c.Cache.GetTableIndexes(tableName) {
 		_, err = c.Cache.Delete(tx, RelationIndexType, idx.IndexName)
 		if err != nil {
@@ -310,11 +370,61 @@ func (c *CatalogWriter) CreateIndex(tx *Transaction, info *IndexInfo) (*IndexInf
 	}
 
 	// check if the indexed columns exist
-	for _, p := range info.Columns {
-		fc := ti.GetColumnConstraint(p)
-		if fc == nil {
-			return nil, errors.Errorf("field %q does not exist for table %q", p, ti.TableName)
+	err = info.validateKey(ti)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, p := range info.Columns {
+		if !info.Fulltext {
+			break
+		}
+
+		if info.IsExpr(i) {
+			return nil, errors.New("full-text indexes cannot be created on expressions")
 		}
+
+		if fc := ti.GetColumnConstraint(p); fc.Type != types.TypeText {
+			return nil, errors.Errorf("cannot create a full-text index on column %q of type %s", p, fc.Type)
+		}
+	}
+
+	if info.Fulltext && (len(info.Columns) != 1 || info.Unique) {
+		return nil, errors.New("full-text indexes must be created on exactly one column and cannot be unique")
+	}
+
+	for i, p := range info.Columns {
+		if !info.Spatial {
+			break
+		}
+
+		if info.IsExpr(i) {
+			return nil, errors.New("spatial indexes cannot be created on expressions")
+		}
+
+		if fc := ti.GetColumnConstraint(p); fc.Type != types.TypeText {
+			return nil, errors.Errorf("cannot create a spatial index on column %q of type %s", p, fc.Type)
+		}
+	}
+
+	if info.Spatial && (len(info.Columns) != 1 || info.Unique || info.Fulltext) {
+		return nil, errors.New("spatial indexes must be created on exactly one column and cannot be unique")
+	}
+
+	if info.NullsNotDistinct && !info.Unique {
+		return nil, errors.New("only unique indexes accept NULLS NOT DISTINCT")
+	}
+
+	if info.Hash && (info.Unique || info.Fulltext || info.Spatial) {
+		return nil, errors.New("hash indexes cannot be unique, full-text or spatial")
+	}
+
+	if info.Fulltext {
+		if _, err := fulltext.Lookup(info.Analyzer); err != nil {
+			return nil, err
+		}
+	} else if info.Analyzer != "" {
+		return nil, errors.New("only full-text indexes accept an analyzer")
 	}
 
 	info.StoreNamespace, err = c.generateStoreNamespace(tx)
@@ -363,9 +473,44 @@ func (c *CatalogWriter) dropIndex(tx *Transaction, info *IndexInfo) error {
 		return err
 	}
 
+	err = clearPendingIndex(tx, info.IndexName)
+	if err != nil {
+		return err
+	}
+
 	return c.CatalogTable.Delete(tx, info.IndexName)
 }
 
+// replaceIndexStore makes the index use the tree of the given namespace
+// and deletes the content of its previous tree.
+func (c *CatalogWriter) replaceIndexStore(tx *Transaction, name string, ns tree.Namespace) error {
+	info, err := c.GetIndexInfo(name)
+	if err != nil {
+		return err
+	}
+
+	err = tree.New(tx.Session, info.StoreNamespace, info.KeySortOrder).Truncate()
+	if err != nil {
+		return err
+	}
+
+	err = clearPendingIndex(tx, name)
+	if err != nil {
+		return err
+	}
+
+	clone := info.Clone()
+	clone.StoreNamespace = ns
+
+	rel := &IndexInfoRelation{Info: clone}
+	err = c.Cache.Replace(tx, rel)
+	if err != nil {
+		return err
+	}
+
+	return c.CatalogTable.Replace(tx, name, rel)
+}
+
 // AddColumnConstraint adds a field constraint to a table.
 func (c *CatalogWriter) AddColumnConstraint(tx *Transaction, tableName string, cc *ColumnConstraint, tcs TableConstraints) error {
 	r, err := c.Cache.Get(RelationTableType, tableName)
@@ -374,6 +519,8 @@ func (c *CatalogWriter) AddColumnConstraint(tx *Transaction, tableName string, c
 	}
 	ti := r.(*TableInfoRelation).Info
 
+	tx.markModified(tableName)
+
 	clone := ti.Clone()
 	if cc != nil {
 		err = clone.AddColumnConstraint(cc)
@@ -410,6 +557,9 @@ func (c *CatalogWriter) RenameTable(tx *Transaction, oldName, newName string) er
 		return err
 	}
 
+	tx.markModified(oldName)
+	tx.markModified(newName)
+
 	o, err := c.Cache.Delete(tx, RelationTableType, oldName)
 	if err != nil {
 		return err
@@ -486,6 +636,33 @@ func (c *CatalogWriter) RenameTable(tx *Transaction, oldName, newName string) er
 	return nil
 }
 
+// SwapTables exchanges the names of two tables.
+// Indexes and sequences stay attached to the data they belong to.
+// Catalog changes only become visible when the transaction is committed,
+// so other transactions see either both tables before the swap or both after.
+func (c *CatalogWriter) SwapTables(tx *Transaction, a, b string) error {
+	for _, name := range []string{a, b} {
+		_, err := c.Cache.Get(RelationTableType, name)
+		if err != nil {
+			return errors.Wrapf(err, "table %s does not exist", name)
+		}
+	}
+
+	tmp := InternalPrefix + "swap_" + a
+
+	err := c.RenameTable(tx, a, tmp)
+	if err != nil {
+		return err
+	}
+
+	err = c.RenameTable(tx, b, a)
+	if err != nil {
+		return err
+	}
+
+	return c.RenameTable(tx, tmp, b)
+}
+
 // CreateSequence creates a sequence with the given name.
 func (c *CatalogWriter) CreateSequence(tx *Transaction, info *SequenceInfo) error {
 	if info == nil {
@@ -580,7 +757,7 @@ func (r *IndexInfoRelation) SetName(name string) {
 }
 
 func (r *IndexInfoRelation) GenerateBaseName() string {
-	return fmt.Sprintf("%s_%s_idx", r.Info.Owner.TableName, columnsToIndexName(r.Info.Columns))
+	return fmt.Sprintf("%s_%s_idx", r.Info.Owner.TableName, r.Info.keyName())
 }
 
 func (r *IndexInfoRelation) Clone() Relation {
@@ -589,10 +766,6 @@ func (r *IndexInfoRelation) Clone() Relation {
 	return &clone
 }
 
-func columnsToIndexName(columns []string) string {
-	return strings.Join(columns, "_")
-}
-
 type catalogCache struct {
 	tables    map[string]Relation
 	indexes   map[string]Relation
@@ -856,9 +1029,14 @@ func (s *CatalogStore) Table(tx *Transaction) *Table {
 
 // Insert a catalog object to the table.
 func (s *CatalogStore) Insert(tx *Transaction, r Relation) error {
+	err := requireRelationFormat(tx, r)
+	if err != nil {
+		return err
+	}
+
 	tb := s.Table(tx)
 
-	_, _, err := tb.Insert(relationToRow(r))
+	_, _, err = tb.Insert(relationToRow(r))
 	if cerr, ok := err.(*ConstraintViolationError); ok && cerr.Constraint == "PRIMARY KEY" {
 		return errors.WithStack(errs.AlreadyExistsError{Name: r.Name()})
 	}
@@ -868,13 +1046,29 @@ func (s *CatalogStore) Insert(tx *Transaction, r Relation) error {
 
 // Replace a catalog object with another.
 func (s *CatalogStore) Replace(tx *Transaction, name string, r Relation) error {
+	err := requireRelationFormat(tx, r)
+	if err != nil {
+		return err
+	}
+
 	tb := s.Table(tx)
 
 	key := tree.NewKey(types.NewTextValue(name))
-	_, err := tb.Replace(key, relationToRow(r))
+	_, err = tb.Replace(key, relationToRow(r))
 	return err
 }
 
+// requireRelationFormat raises the format version of the database
+// to the version able to read the definition of the table r, if r is a table.
+func requireRelationFormat(tx *Transaction, r Relation) error {
+	t, ok := r.(*TableInfoRelation)
+	if !ok {
+		return nil
+	}
+
+	return tx.requireFormat(t.Info.formatVersion())
+}
+
 func (s *CatalogStore) Delete(tx *Transaction, name string) error {
 	tb := s.Table(tx)
 
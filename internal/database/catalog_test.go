@@ -39,6 +39,7 @@ func updateCatalog(t testing.TB, db *database.Database, fn func(tx *database.Tra
 // - GetTable
 // - DropTable
 // - RenameTable
+// - SwapTables
 // - AddColumnConstraint
 func TestCatalogTable(t *testing.T) {
 	t.Run("Get", func(t *testing.T) {
@@ -176,6 +177,57 @@ func TestCatalogTable(t *testing.T) {
 		require.Equal(t, clone, db.Catalog())
 	})
 
+	t.Run("Swap", func(t *testing.T) {
+		db := testutil.NewTestDB(t)
+
+		newTableInfo := func(column string) *database.TableInfo {
+			return &database.TableInfo{
+				ColumnConstraints: database.MustNewColumnConstraints(
+					&database.ColumnConstraint{Column: column, Type: types.TypeText},
+				)}
+		}
+
+		updateCatalog(t, db, func(tx *database.Transaction, catalog *database.CatalogWriter) error {
+			err := catalog.CreateTable(tx, "foo", newTableInfo("a"))
+			require.NoError(t, err)
+			err = catalog.CreateTable(tx, "bar", newTableInfo("b"))
+			require.NoError(t, err)
+
+			_, err = catalog.CreateIndex(tx, &database.IndexInfo{Columns: []string{"a"}, IndexName: "idx_a", Owner: database.Owner{TableName: "foo"}})
+			require.NoError(t, err)
+
+			return nil
+		})
+
+		clone := db.Catalog().Clone()
+
+		updateCatalog(t, db, func(tx *database.Transaction, catalog *database.CatalogWriter) error {
+			err := catalog.SwapTables(tx, "foo", "bar")
+			require.NoError(t, err)
+
+			foo, err := catalog.GetTableInfo("foo")
+			require.NoError(t, err)
+			require.Equal(t, "b", foo.ColumnConstraints.Ordered[0].Column)
+
+			bar, err := catalog.GetTableInfo("bar")
+			require.NoError(t, err)
+			require.Equal(t, "a", bar.ColumnConstraints.Ordered[0].Column)
+
+			// The index follows its table.
+			info, err := catalog.GetIndexInfo("idx_a")
+			require.NoError(t, err)
+			require.Equal(t, "bar", info.Owner.TableName)
+
+			// Swapping with a non existing table should return an error
+			err = catalog.SwapTables(tx, "foo", "baz")
+			require.True(t, errs.IsNotFoundError(err))
+
+			return errDontCommit
+		})
+
+		require.Equal(t, clone, db.Catalog())
+	})
+
 	t.Run("Add column constraint", func(t *testing.T) {
 		db := testutil.NewTestDB(t)
 
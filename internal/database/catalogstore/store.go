@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/expr/functions"
 	"github.com/chaisql/chai/internal/query/statement"
 	"github.com/chaisql/chai/internal/sql/parser"
 	"github.com/chaisql/chai/internal/tree"
@@ -11,7 +12,21 @@ import (
 	"github.com/cockroachdb/errors"
 )
 
+// LoadCatalog loads the catalog of the database,
+// resolving the functions used by the schema among the builtin functions.
 func LoadCatalog(tx *database.Transaction) error {
+	return loadCatalog(tx, nil)
+}
+
+// NewLoader returns a catalog loader resolving the functions
+// used by the schema with fns.
+func NewLoader(fns functions.Lookup) func(tx *database.Transaction) error {
+	return func(tx *database.Transaction) error {
+		return loadCatalog(tx, fns)
+	}
+}
+
+func loadCatalog(tx *database.Transaction, fns functions.Lookup) error {
 	cw := tx.CatalogWriter()
 
 	err := cw.Init(tx)
@@ -19,7 +34,7 @@ func LoadCatalog(tx *database.Transaction) error {
 		return err
 	}
 
-	tables, indexes, sequences, err := loadCatalogStore(tx, tx.Catalog.CatalogTable)
+	tables, indexes, sequences, err := loadCatalogStore(tx, tx.Catalog.CatalogTable, fns)
 	if err != nil {
 		return errors.Wrap(err, "failed to load catalog store")
 	}
@@ -78,7 +93,7 @@ func loadSequences(tx *database.Transaction, info []database.SequenceInfo) ([]da
 	return sequences, nil
 }
 
-func loadCatalogStore(tx *database.Transaction, s *database.CatalogStore) (tables []database.TableInfo, indexes []database.IndexInfo, sequences []database.SequenceInfo, err error) {
+func loadCatalogStore(tx *database.Transaction, s *database.CatalogStore, fns functions.Lookup) (tables []database.TableInfo, indexes []database.IndexInfo, sequences []database.SequenceInfo, err error) {
 	tb := s.Table(tx)
 
 	err = tb.IterateOnRange(nil, false, func(key *tree.Key, r database.Row) error {
@@ -89,20 +104,20 @@ func loadCatalogStore(tx *database.Transaction, s *database.CatalogStore) (table
 
 		switch types.AsString(tp) {
 		case database.RelationTableType:
-			ti, err := tableInfoFromRow(r)
+			ti, err := tableInfoFromRow(r, fns)
 			if err != nil {
 				return errors.Wrap(err, "failed to decode table info")
 			}
 			tables = append(tables, *ti)
 		case database.RelationIndexType:
-			i, err := indexInfoFromRow(r)
+			i, err := indexInfoFromRow(r, fns)
 			if err != nil {
 				return errors.Wrap(err, "failed to decode index info")
 			}
 
 			indexes = append(indexes, *i)
 		case database.RelationSequenceType:
-			i, err := sequenceInfoFromRow(r)
+			i, err := sequenceInfoFromRow(r, fns)
 			if err != nil {
 				return errors.Wrap(err, "failed to decode sequence info")
 			}
@@ -114,13 +129,13 @@ func loadCatalogStore(tx *database.Transaction, s *database.CatalogStore) (table
 	return
 }
 
-func tableInfoFromRow(r database.Row) (*database.TableInfo, error) {
+func tableInfoFromRow(r database.Row, fns functions.Lookup) (*database.TableInfo, error) {
 	s, err := r.Get("sql")
 	if err != nil {
 		return nil, err
 	}
 
-	stmt, err := parser.NewParser(strings.NewReader(types.AsString(s))).ParseStatement()
+	stmt, err := parseStatement(types.AsString(s), fns)
 	if err != nil {
 		return nil, err
 	}
@@ -151,13 +166,13 @@ func tableInfoFromRow(r database.Row) (*database.TableInfo, error) {
 	return &ti, nil
 }
 
-func indexInfoFromRow(r database.Row) (*database.IndexInfo, error) {
+func indexInfoFromRow(r database.Row, fns functions.Lookup) (*database.IndexInfo, error) {
 	s, err := r.Get("sql")
 	if err != nil {
 		return nil, err
 	}
 
-	stmt, err := parser.NewParser(strings.NewReader(types.AsString(s))).ParseStatement()
+	stmt, err := parseStatement(types.AsString(s), fns)
 	if err != nil {
 		return nil, err
 	}
@@ -187,13 +202,13 @@ func indexInfoFromRow(r database.Row) (*database.IndexInfo, error) {
 	return &i, nil
 }
 
-func sequenceInfoFromRow(r database.Row) (*database.SequenceInfo, error) {
+func sequenceInfoFromRow(r database.Row, fns functions.Lookup) (*database.SequenceInfo, error) {
 	s, err := r.Get("sql")
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get sql field")
 	}
 
-	stmt, err := parser.NewParser(strings.NewReader(types.AsString(s))).ParseStatement()
+	stmt, err := parseStatement(types.AsString(s), fns)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to parse sql")
 	}
@@ -235,3 +250,7 @@ func ownerFromRow(r database.Row) (*database.Owner, error) {
 
 	return &owner, nil
 }
+
+func parseStatement(sql string, fns functions.Lookup) (statement.Statement, error) {
+	return parser.NewParser(strings.NewReader(sql)).WithFunctions(fns).ParseStatement()
+}
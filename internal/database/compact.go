@@ -0,0 +1,268 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/kv"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/cockroachdb/errors"
+)
+
+// A compactor is an engine able to rewrite its data
+// to reclaim the space used by deleted keys.
+type compactor interface {
+	Compact() (kv.CompactionStats, error)
+}
+
+// A blobCollector is a blob store able to delete
+// the segments holding unreferenced values.
+type blobCollector interface {
+	blobStore
+	BlobSegments() ([]kv.BlobSegment, error)
+	SealBlobs() ([]kv.BlobSegment, error)
+	RemoveBlobSegments(segs []uint64) error
+}
+
+// Compact rewrites the data of the database to reclaim the space
+// used by deleted rows and dropped tables and indexes,
+// and reports the size of the database before and after.
+// The values stored outside of the rows that are no longer referenced
+// are deleted as well, see collectBlobs.
+// Reads and writes can continue during the compaction.
+func (db *Database) Compact(ctx context.Context) (kv.CompactionStats, error) {
+	c, ok := db.Engine.(compactor)
+	if !ok {
+		return kv.CompactionStats{}, errors.New("the storage engine of the database doesn't support compaction")
+	}
+
+	if ctx != nil && ctx.Err() != nil {
+		return kv.CompactionStats{}, context.Cause(ctx)
+	}
+
+	bc, ok := db.Engine.(blobCollector)
+	if !ok {
+		return c.Compact()
+	}
+
+	before, err := blobsSize(bc)
+	if err != nil {
+		return kv.CompactionStats{}, err
+	}
+
+	err = db.collectBlobs(ctx, bc)
+	if err != nil {
+		return kv.CompactionStats{}, errors.Wrap(err, "cannot collect blobs")
+	}
+
+	stats, err := c.Compact()
+	if err != nil {
+		return stats, err
+	}
+
+	after, err := blobsSize(bc)
+	if err != nil {
+		return stats, err
+	}
+
+	stats.SizeBefore += uint64(before)
+	stats.SizeAfter += uint64(after)
+	return stats, nil
+}
+
+func blobsSize(bc blobCollector) (int64, error) {
+	segs, err := bc.BlobSegments()
+	if err != nil {
+		return 0, err
+	}
+
+	var size int64
+	for _, s := range segs {
+		size += s.Size
+	}
+
+	return size, nil
+}
+
+// collectBlobs deletes the segments of the blob log holding no value
+// referenced by the rows of the tables. The values of the segments that are
+// mostly unreferenced are copied to the end of the log first, so that these
+// segments can be deleted as well.
+// Nothing is deleted while a prepared transaction, which may reference values
+// of the log, is pending, nor by read-only databases.
+func (db *Database) collectBlobs(ctx context.Context, bc blobCollector) error {
+	if _, ok := db.PreparedTransaction(); ok || db.readOnly.Load() || db.replica.Load() {
+		return nil
+	}
+
+	tx, err := db.beginTx(&TxOptions{Context: ctx})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// the transaction prevents other transactions from writing:
+	// the values they append from now on are stored in new segments.
+	segs, err := bc.SealBlobs()
+	if err != nil || len(segs) == 0 {
+		return err
+	}
+
+	live := make(map[uint64]int64, len(segs))
+	for _, s := range segs {
+		live[s.ID] = 0
+	}
+
+	err = forEachBlob(tx, func(_ *Table, _ *tree.Key, ptr []byte) error {
+		seg, size, err := kv.BlobLocation(ptr)
+		if err != nil {
+			return err
+		}
+		if _, ok := live[seg]; ok {
+			live[seg] += size
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var removed []uint64
+	moved := make(map[uint64]bool)
+	for _, s := range segs {
+		switch {
+		case live[s.ID] == 0:
+			removed = append(removed, s.ID)
+		case live[s.ID] < s.Size/2:
+			moved[s.ID] = true
+			removed = append(removed, s.ID)
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+
+	if len(moved) > 0 {
+		err = moveBlobs(tx, bc, moved)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	return bc.RemoveBlobSegments(removed)
+}
+
+// forEachBlob calls fn with the pointers to the values stored
+// outside of the rows of every table.
+func forEachBlob(tx *Transaction, fn func(t *Table, key *tree.Key, ptr []byte) error) error {
+	for _, name := range tx.Catalog.Cache.ListObjects(RelationTableType) {
+		if strings.HasPrefix(name, InternalPrefix) {
+			continue
+		}
+
+		t, err := tx.Catalog.GetTable(tx, name)
+		if err != nil {
+			return err
+		}
+
+		err = t.Tree.IterateOnRange(nil, false, func(k *tree.Key, enc []byte) error {
+			for b := enc; len(b) > 0; b = b[encoding.Skip(b):] {
+				if b[0] != encoding.ExternalValue {
+					continue
+				}
+
+				_, ptr, _ := encoding.DecodeExternal(b)
+				err := fn(t, k, ptr)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// moveBlobs copies the values stored in the given segments
+// to the end of the blob log and updates the rows referencing them.
+func moveBlobs(tx *Transaction, bc blobCollector, segs map[uint64]bool) error {
+	type rowKey struct {
+		t   *Table
+		key *tree.Key
+	}
+
+	// rows are updated once the tables have been read
+	var rows []rowKey
+	err := forEachBlob(tx, func(t *Table, key *tree.Key, ptr []byte) error {
+		seg, _, err := kv.BlobLocation(ptr)
+		if err != nil {
+			return err
+		}
+		if !segs[seg] {
+			return nil
+		}
+
+		if n := len(rows); n == 0 || rows[n-1].t != t || !bytes.Equal(rows[n-1].key.Encoded, key.Encoded) {
+			rows = append(rows, rowKey{t: t, key: key.Clone()})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, r := range rows {
+		enc, err := r.t.Tree.Get(r.key)
+		if err != nil {
+			return err
+		}
+
+		out := make([]byte, 0, len(enc))
+		for b := enc; len(b) > 0; {
+			n := encoding.Skip(b)
+			if b[0] != encoding.ExternalValue {
+				out = append(out, b[:n]...)
+				b = b[n:]
+				continue
+			}
+
+			typ, ptr, _ := encoding.DecodeExternal(b)
+			seg, _, err := kv.BlobLocation(ptr)
+			if err != nil {
+				return err
+			}
+			if segs[seg] {
+				data, err := bc.ReadBlob(ptr)
+				if err != nil {
+					return err
+				}
+				ptr, err = bc.AppendBlob(data)
+				if err != nil {
+					return err
+				}
+			}
+
+			out = encoding.EncodeExternal(out, typ, ptr)
+			b = b[n:]
+		}
+
+		err = r.t.Tree.Put(r.key, out)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package database_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompact(t *testing.T) {
+	db, err := chai.OpenWith(filepath.Join(t.TempDir(), "db"), &chai.Options{Compression: chai.CompressionNone})
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE test(a INTEGER PRIMARY KEY, b TEXT)")
+	require.NoError(t, err)
+	for i := 0; i < 5000; i++ {
+		err = db.Exec("INSERT INTO test (a, b) VALUES (?, ?)", i, fmt.Sprintf("%0200d", i))
+		require.NoError(t, err)
+	}
+	err = db.DB.Engine.(*kv.PebbleEngine).DB().Flush()
+	require.NoError(t, err)
+
+	err = db.Exec("DELETE FROM test")
+	require.NoError(t, err)
+
+	stats, err := db.Compact(context.Background())
+	require.NoError(t, err)
+	require.Less(t, stats.SizeAfter, stats.SizeBefore)
+	require.Equal(t, stats.SizeBefore-stats.SizeAfter, stats.Reclaimed())
+
+	t.Run("VACUUM", func(t *testing.T) {
+		r, err := db.QueryRow("VACUUM")
+		require.NoError(t, err)
+
+		var before, after int64
+		err = r.Scan(&before, &after)
+		require.NoError(t, err)
+		require.NotZero(t, before)
+		require.NotZero(t, after)
+	})
+
+	mem, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer mem.Close()
+	err = mem.Exec("VACUUM")
+	require.Error(t, err)
+}
+
+func TestCompactBlobs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db")
+	db, err := chai.OpenWith(path, &chai.Options{BlobThreshold: 1024})
+	require.NoError(t, err)
+	defer func() { db.Close() }()
+
+	blobsSize := func() int64 {
+		t.Helper()
+
+		segments, err := filepath.Glob(filepath.Join(path, "blobs", "*.blob"))
+		require.NoError(t, err)
+
+		var size int64
+		for _, s := range segments {
+			fi, err := os.Stat(s)
+			require.NoError(t, err)
+			size += fi.Size()
+		}
+		return size
+	}
+
+	value := func(i int) string {
+		return strings.Repeat(string(rune('a'+i%26)), 10000)
+	}
+
+	err = db.Exec("CREATE TABLE test(a INTEGER PRIMARY KEY, b TEXT)")
+	require.NoError(t, err)
+	for i := 0; i < 100; i++ {
+		err = db.Exec("INSERT INTO test (a, b) VALUES (?, ?)", i, value(i))
+		require.NoError(t, err)
+	}
+	before := blobsSize()
+	require.EqualValues(t, 100*10000, before)
+
+	// snapshots taken before the compaction can still read the deleted values
+	snap, err := db.Snapshot("before")
+	require.NoError(t, err)
+
+	err = db.Exec("DELETE FROM test WHERE a >= 10")
+	require.NoError(t, err)
+
+	stats, err := db.Compact(context.Background())
+	require.NoError(t, err)
+	require.Less(t, stats.SizeAfter, stats.SizeBefore)
+
+	// the values still referenced were moved to a new segment
+	require.EqualValues(t, 10*10000, blobsSize())
+
+	err = snap.View(func(tx *chai.Tx) error {
+		var b string
+		r, err := tx.QueryRow("SELECT b FROM test WHERE a = 50")
+		require.NoError(t, err)
+		require.NoError(t, r.Scan(&b))
+		require.Equal(t, value(50), b)
+		return nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, snap.Release())
+
+	check := func(t *testing.T, db *chai.DB) {
+		t.Helper()
+
+		for i := 0; i < 10; i++ {
+			var b string
+			r, err := db.QueryRow("SELECT b FROM test WHERE a = ?", i)
+			require.NoError(t, err)
+			require.NoError(t, r.Scan(&b))
+			require.Equal(t, value(i), b)
+		}
+	}
+	check(t, db)
+
+	// the log is still appended to after the compaction
+	err = db.Exec("INSERT INTO test (a, b) VALUES (100, ?)", value(100))
+	require.NoError(t, err)
+	require.EqualValues(t, 11*10000, blobsSize())
+
+	require.NoError(t, db.Close())
+	db, err = chai.OpenWith(path, &chai.Options{BlobThreshold: 1024})
+	require.NoError(t, err)
+	check(t, db)
+
+	err = db.Exec("DELETE FROM test")
+	require.NoError(t, err)
+	_, err = db.Compact(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, blobsSize())
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"time"
 
 	"github.com/cockroachdb/errors"
 )
@@ -10,6 +11,15 @@ type Connection struct {
 	db  *Database
 	ctx context.Context
 	tx  *Transaction
+
+	// temporary indexes created with CREATE TEMP INDEX.
+	tempIndexes map[string]*tempIndex
+
+	// maximum duration of the queries, overriding the database option.
+	statementTimeout time.Duration
+
+	// work memory of the transactions, overriding the database option.
+	workMemory int
 }
 
 // BeginTx starts a new transaction with the given options.
@@ -24,6 +34,15 @@ func (c *Connection) BeginTx(opts *TxOptions) (*Transaction, error) {
 		return nil, errors.New("cannot open a transaction within a transaction")
 	}
 
+	if c.workMemory > 0 && (opts == nil || opts.WorkMemory == 0) {
+		o := TxOptions{}
+		if opts != nil {
+			o = *opts
+		}
+		o.WorkMemory = c.workMemory
+		opts = &o
+	}
+
 	tx, err := c.db.beginTx(opts)
 	if err != nil {
 		return nil, err
@@ -37,6 +56,14 @@ func (c *Connection) BeginTx(opts *TxOptions) (*Transaction, error) {
 	return tx, nil
 }
 
+// SetWorkMemory sets the number of bytes an operator of the transactions
+// started by the connection can hold in memory before spilling to temporary storage,
+// overriding the work memory of the database.
+// If zero, the work memory of the database is used.
+func (c *Connection) SetWorkMemory(n int) {
+	c.workMemory = n
+}
+
 func (c *Connection) Reset() error {
 	if c.tx != nil {
 		return errors.New("cannot reset a connection with an attached transaction")
@@ -60,9 +87,19 @@ func (c *Connection) GetTx() *Transaction {
 func (c *Connection) Close() error {
 	defer c.db.connectionWg.Done()
 
+	err := c.closeTempIndexes()
+
 	if c.tx != nil {
+		// a prepared transaction outlives the connection,
+		// it must be resolved with Database.CommitPrepared
+		// or Database.RollbackPrepared.
+		if c.tx.Prepared() {
+			c.db.recoveredTx.Store(&c.tx.preparedID)
+			return err
+		}
+
 		return c.tx.Rollback()
 	}
 
-	return nil
+	return err
 }
@@ -18,10 +18,50 @@ type ColumnConstraint struct {
 	Type         types.Type
 	IsNotNull    bool
 	DefaultValue TableExpression
+	// Collation used to compare and index the values of a TEXT column.
+	// If empty, values are compared bytewise.
+	Collation string
+	// Precision and Scale of a DECIMAL column: values are rounded
+	// to Scale decimal places and can't have more than Precision digits.
+	// If Precision is zero, values are stored as is.
+	Precision int
+	Scale     int
+	// Bits is the width of an integer column declared as TINYINT, INT16, INT32 or INT64.
+	// Values that don't fit are rejected. If zero, the range of Type is used.
+	Bits int
+	// Enum lists the values allowed in a TEXT column, if any.
+	// Values are stored as their position in the list, which
+	// therefore can't be reordered.
+	Enum []string
 }
 
 func (f *ColumnConstraint) IsEmpty() bool {
-	return f.Column == "" && f.Type.IsAny() && !f.IsNotNull && f.DefaultValue == nil
+	return f.Column == "" && f.Type.IsAny() && !f.IsNotNull && f.DefaultValue == nil && f.Collation == "" && len(f.Enum) == 0
+}
+
+// EnumCode returns the position of the value in the list of values
+// allowed by the ENUM constraint, which is the code stored in its place.
+func (f *ColumnConstraint) EnumCode(v string) (int, bool) {
+	for i, e := range f.Enum {
+		if e == v {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+// TypeString returns the SQL type of the column, with its modifiers.
+func (f *ColumnConstraint) TypeString() string {
+	t := strings.ToUpper(f.Type.String())
+	if f.Bits > 0 {
+		t = types.IntegerTypeName(f.Bits)
+	}
+	if f.Precision > 0 {
+		t += fmt.Sprintf("(%d, %d)", f.Precision, f.Scale)
+	}
+
+	return t
 }
 
 func (f *ColumnConstraint) String() string {
@@ -29,7 +69,18 @@ func (f *ColumnConstraint) String() string {
 
 	s.WriteString(f.Column)
 	s.WriteString(" ")
-	s.WriteString(strings.ToUpper(f.Type.String()))
+	s.WriteString(f.TypeString())
+
+	if len(f.Enum) > 0 {
+		s.WriteString(" ENUM(")
+		for i, e := range f.Enum {
+			if i > 0 {
+				s.WriteString(", ")
+			}
+			s.WriteString(types.NewTextValue(e).String())
+		}
+		s.WriteString(")")
+	}
 
 	if f.IsNotNull {
 		s.WriteString(" NOT NULL")
@@ -40,6 +91,11 @@ func (f *ColumnConstraint) String() string {
 		s.WriteString(f.DefaultValue.String())
 	}
 
+	if f.Collation != "" {
+		s.WriteString(" COLLATE ")
+		s.WriteString(stringutil.NormalizeIdentifier(f.Collation, '"'))
+	}
+
 	return s.String()
 }
 
@@ -94,7 +150,7 @@ func (f *ColumnConstraints) Add(newCc *ColumnConstraint) error {
 			// Integers can be converted to other integers, doubles, texts and bools.
 			// TODO: rework
 			switch newCc.Type {
-			case types.TypeInteger, types.TypeBigint, types.TypeDouble, types.TypeText:
+			case types.TypeInteger, types.TypeBigint, types.TypeDouble, types.TypeDecimal, types.TypeText:
 			default:
 				return fmt.Errorf("default value %q cannot be converted to type %q", newCc.DefaultValue, newCc.Type)
 			}
@@ -192,6 +248,8 @@ func (t *TableConstraints) ValidateRow(tx *Transaction, r row.Row) error {
 			ok = types.AsInt64(v) != 0
 		case types.TypeDouble:
 			ok = types.AsFloat64(v) != 0
+		case types.TypeDecimal:
+			ok = v.(types.DecimalValue).Sign() != 0
 		case types.TypeNull:
 			ok = true
 		}
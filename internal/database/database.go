@@ -16,6 +16,10 @@ const (
 	InternalPrefix = "__chai_"
 )
 
+// ErrReadOnly is returned when starting a write transaction
+// on a database opened with Options.ReadOnly.
+var ErrReadOnly = errors.New("database is read-only")
+
 type Database struct {
 	catalogMu sync.RWMutex
 	catalog   *Catalog
@@ -27,6 +31,9 @@ type Database struct {
 	// waitgroup to wait for all connections to be closed.
 	connectionWg sync.WaitGroup
 
+	// waitgroup to wait for the TTL reaper to stop.
+	ttlWg sync.WaitGroup
+
 	// This is used to prevent creating a new transaction
 	// during certain operations (commit, close, etc.)
 	txmu sync.RWMutex
@@ -42,6 +49,60 @@ type Database struct {
 
 	closeOnce sync.Once
 
+	// commitSeq is incremented every time a transaction
+	// modifying tables is committed.
+	commitSeq atomic.Uint64
+	// commits is incremented every time a transaction is committed.
+	// It is used to detect whether deferred transactions can be upgraded.
+	commits atomic.Uint64
+	// tableVersions stores the commitSeq of the last commit
+	// that modified each table.
+	// It is used to detect stale temporary indexes.
+	tableVersionsMu sync.Mutex
+	tableVersions   map[string]uint64
+
+	// set while the database follows a leader,
+	// which prevents starting write transactions.
+	replica atomic.Bool
+
+	// set once a database opened with Options.ReadOnly is open,
+	// which prevents starting write transactions.
+	readOnly atomic.Bool
+
+	// id of the transaction prepared before the database was opened,
+	// or whose connection was closed, which holds the write lock
+	// until it is committed or rolled back.
+	recoveredTx atomic.Pointer[string]
+
+	// progress of the TTL reaper, reported by Stats.
+	ttlRuns     atomic.Uint64
+	ttlDeleted  atomic.Uint64
+	ttlArchived atomic.Uint64
+
+	// MinReaderVersion of the manifest, as last committed.
+	minReaderVersion atomic.Uint64
+
+	// schemaVersion is incremented every time a catalog is published.
+	// It is used to version the catalog.
+	schemaVersion atomic.Uint64
+
+	// queries run by the connections, by id.
+	queriesMu sync.Mutex
+	queries   map[uint64]*RunningQuery
+	queryIDs  atomic.Uint64
+
+	// options used to open the database,
+	// reused to open attached databases.
+	opts *Options
+
+	// databases attached with Attach, by name.
+	attachedMu sync.RWMutex
+	attached   map[string]*Database
+	// ids of the commits whose attached databases are all committed,
+	// whose decision is deleted by the next write transaction.
+	attachedCommitsMu sync.Mutex
+	attachedCommits   []string
+
 	// Underlying kv store.
 	Engine engine.Engine
 }
@@ -50,8 +111,119 @@ type Database struct {
 // how the database is loaded.
 type Options struct {
 	CatalogLoader func(tx *Transaction) error
+
+	// If set, databases written with an older format version
+	// are migrated to FormatVersion instead of being rejected.
+	Upgrade bool
+
+	// Time between two deletions of the expired rows of the tables
+	// with a TTL column. If zero, DefaultTTLInterval is used.
+	// If negative, expired rows are only deleted by DeleteExpiredRows.
+	TTLInterval time.Duration
+
+	// Maximum number of bytes an operator, such as a sort, holds in memory
+	// before spilling its values to temporary storage.
+	// It also bounds the size of the batches written to temporary trees.
+	// If zero, DefaultWorkMemory is used.
+	// It can be overridden by Connection.SetWorkMemory and TxOptions.WorkMemory.
+	WorkMemory int
+
+	// Maximum duration of the queries run by the connections
+	// of the database. If zero, queries have no timeout.
+	// It can be overridden by Connection.SetStatementTimeout.
+	StatementTimeout time.Duration
+
+	// Time zone of the database. The texts without zone offset
+	// converted to timestamps are interpreted in it, and the timestamps
+	// returned by the queries are formatted in it.
+	// Timestamps are always stored in UTC. If nil, UTC is used.
+	TimeZone *time.Location
+
+	// If set, NaN and infinite doubles can't be written to the tables.
+	// Otherwise they are stored, and sorted and compared in this order:
+	// -Infinity < finite values < +Infinity < NaN, with NaN equal to itself.
+	RejectNonFiniteDoubles bool
+
+	// Limits on the rows written to the tables, to protect the database from
+	// hostile payloads. Zero values disable them.
+	// MaxRowSize is the maximum size of an encoded row, in bytes.
+	// MaxDocumentDepth and MaxDocumentFields limit the nesting depth and the total
+	// number of object members of the JSON documents stored in TEXT columns.
+	MaxRowSize        int
+	MaxDocumentDepth  int
+	MaxDocumentFields int
+
+	// If set, the rows returned by the queries keep the order of their columns
+	// when encoded in JSON or MessagePack. Otherwise, they are sorted by name.
+	PreserveColumnOrder bool
+
+	// Compression of the data written on disk by the default Pebble engine:
+	// kv.CompressionNone, kv.CompressionSnappy or kv.CompressionZstd.
+	// If empty, Snappy is used.
+	Compression string
+
+	// If set, the batches written by the default Pebble engine are archived
+	// in this directory, so that RestoreToTime can replay them on top of a backup.
+	WALArchiveDir string
+
+	// Maximum duration to wait for another process to close
+	// an on-disk database before failing to open it.
+	// If zero, opening a database used by another process fails immediately.
+	LockTimeout time.Duration
+
+	// If positive, the text and blob values larger than this number of bytes
+	// are stored in a separate blob log of the engine and the rows only
+	// reference them, which keeps the main store small.
+	// Such values are only read when their column is accessed.
+	// Databases opened without it can still read the existing blobs.
+	BlobThreshold int
+
+	// Durability of the commits written by the default Pebble engine:
+	// kv.DurabilityAlways, kv.DurabilityPeriodic or kv.DurabilityNever.
+	// If empty, commits are synced to disk before returning.
+	// It can be overridden for a transaction by TxOptions.Durability.
+	Durability string
+
+	// Interval between two syncs to disk with kv.DurabilityPeriodic.
+	// If zero, the commits are synced every 100ms.
+	SyncInterval time.Duration
+
+	// Tuning of the default Pebble engine, see kv.Options.
+	// Zero values select the defaults of Pebble.
+	CacheSize             int64
+	MemTableSize          int64
+	MaxOpenFiles          int
+	L0CompactionThreshold int
+	L0StopWritesThreshold int
+
+	// Maximum duration Begin waits for the current write transaction
+	// to finish before starting a write transaction.
+	// If zero, Begin waits as long as needed.
+	// If negative, Begin fails immediately with ErrBusy.
+	// It is ignored if BusyHandler is set.
+	BusyTimeout time.Duration
+
+	// BusyHandler, if set, is called when a write transaction can't be started
+	// because another one is running. n is the number of times it was already
+	// called for this Begin. If it returns true, Begin tries again, otherwise
+	// it fails with ErrBusy. The handler is responsible for waiting between
+	// two attempts.
+	BusyHandler func(n int) bool
+
+	// OpenEngine, if set, opens the storage engine of the database
+	// at the given path instead of the default Pebble engine.
+	OpenEngine func(path string) (engine.Engine, error)
+
+	// If set, write transactions fail with ErrReadOnly once the database is open.
+	// The storage engine is still written while the database is opened,
+	// e.g. to recover from a crash.
+	ReadOnly bool
 }
 
+// DefaultWorkMemory is the memory budget of the operators
+// when Options.WorkMemory is not set.
+const DefaultWorkMemory = 4 << 20 // 4MB
+
 // CatalogLoader loads the catalog from the disk.
 // It may parse a SQL representation of the catalog
 // and return a Catalog that represents all entities stored on disk.
@@ -63,20 +235,77 @@ type CatalogLoader interface {
 type TxOptions struct {
 	// Open a read-only transaction.
 	ReadOnly bool
+
+	// Open a read-write transaction that starts as a read transaction
+	// and only acquires the write lock when it is upgraded, by Transaction.Upgrade.
+	// It is ignored if ReadOnly is set.
+	Deferred bool
+
+	// Durability of the commit of the transaction.
+	// If DefaultDurability, the durability of the database is used.
+	Durability Durability
+
+	// If set, the transaction reads the snapshot instead of
+	// the current state of the database. It requires ReadOnly.
+	Snapshot *Snapshot
+
+	// Maximum number of bytes an operator of the transaction holds in memory
+	// before spilling to temporary storage, overriding Options.WorkMemory.
+	// If zero, the work memory of the connection or of the database is used.
+	WorkMemory int
+
+	// If set, waiting for the write lock, when the transaction begins
+	// or is upgraded, stops when the context is done.
+	Context context.Context
+}
+
+// Durability describes whether the commit of a transaction
+// waits for its changes to be synced to disk.
+type Durability int
+
+const (
+	// DefaultDurability uses the durability the database was opened with.
+	DefaultDurability Durability = iota
+	// FullDurability waits for the changes to be synced to disk.
+	FullDurability
+	// RelaxedDurability returns once the changes are written
+	// to the write-ahead log, without waiting for them to be synced.
+	// A crash of the machine can lose them, but never partially.
+	RelaxedDurability
+)
+
+// A syncSetter is a session whose commit can
+// be made to wait, or not, for the changes to be synced.
+type syncSetter interface {
+	SetSync(sync bool)
 }
 
 func Open(path string, opts *Options) (*Database, error) {
-	store, err := kv.NewEngine(path, kv.Options{
-		RollbackSegmentNamespace: int64(RollbackSegmentNamespace),
-		MinTransientNamespace:    uint64(MinTransientNamespace),
-		MaxTransientNamespace:    uint64(MaxTransientNamespace),
-	})
+	return OpenContext(context.Background(), path, opts)
+}
+
+// OpenContext opens the database like Open. Waiting for the lock
+// of the database directory, according to Options.LockTimeout,
+// stops when ctx is done.
+func OpenContext(ctx context.Context, path string, opts *Options) (*Database, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, context.Cause(ctx)
+	}
+
+	store, err := openEngine(ctx, path, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkBlobOptions(store, opts)
 	if err != nil {
+		_ = store.Close()
 		return nil, err
 	}
 
 	db := Database{
 		Engine: store,
+		opts:   opts,
 	}
 
 	// create a context that will be cancelled when the database is closed.
@@ -101,7 +330,16 @@ func Open(path string, opts *Options) (*Database, error) {
 	}
 	defer tx.Rollback()
 
+	// ensure this version of Chai can read the database
+	err = loadManifest(tx, opts.Upgrade)
+	if err != nil {
+		_ = tx.Rollback()
+		_ = db.Engine.Close()
+		return nil, err
+	}
+
 	db.catalog = NewCatalog()
+	db.catalog.Version = db.schemaVersion.Add(1)
 	tx.Catalog = db.catalog
 
 	if opts.CatalogLoader != nil {
@@ -121,9 +359,106 @@ func Open(path string, opts *Options) (*Database, error) {
 		return nil, err
 	}
 
+	err = db.recoverPrepared()
+	if err != nil {
+		_ = db.Engine.Close()
+		return nil, err
+	}
+
+	if opts.ReadOnly {
+		db.readOnly.Store(true)
+		return &db, nil
+	}
+
+	// a prepared transaction holds the write lock, the pending indexes
+	// are rebuilt the next time the database is opened.
+	if db.recoveredTx.Load() == nil {
+		err = db.rebuildPendingIndexes(ctx)
+		if err != nil {
+			_ = db.Engine.Close()
+			return nil, err
+		}
+	}
+
+	interval := opts.TTLInterval
+	if interval == 0 {
+		interval = DefaultTTLInterval
+	}
+	if interval > 0 {
+		db.ttlWg.Add(1)
+		go db.runTTLReaper(interval)
+	}
+
 	return &db, nil
 }
 
+// openEngine opens the storage engine described by the options.
+func openEngine(ctx context.Context, path string, opts *Options) (engine.Engine, error) {
+	if opts.OpenEngine != nil {
+		return opts.OpenEngine(path)
+	}
+
+	// in-memory databases don't need the rollback segment
+	// nor the write-ahead log of Pebble.
+	if path == ":memory:" {
+		return kv.NewMemoryEngine(), nil
+	}
+
+	return kv.NewEngine(path, engineOptions(ctx, opts))
+}
+
+// engineOptions returns the options of the Pebble engine described by the options.
+func engineOptions(ctx context.Context, opts *Options) kv.Options {
+	return kv.Options{
+		RollbackSegmentNamespace: int64(RollbackSegmentNamespace),
+		MaxTransientBatchSize:    workMemory(opts),
+		MinTransientNamespace:    uint64(MinTransientNamespace),
+		MaxTransientNamespace:    uint64(MaxTransientNamespace),
+		Compression:              opts.Compression,
+		WALArchiveDir:            opts.WALArchiveDir,
+		CommitTimestampNamespace: int64(CommitTimestampNamespace),
+		PreparedTxNamespace:      int64(PreparedTxNamespace),
+		LockTimeout:              opts.LockTimeout,
+		LockContext:              ctx,
+		Durability:               opts.Durability,
+		SyncInterval:             opts.SyncInterval,
+		CacheSize:                opts.CacheSize,
+		MemTableSize:             opts.MemTableSize,
+		MaxOpenFiles:             opts.MaxOpenFiles,
+		L0CompactionThreshold:    opts.L0CompactionThreshold,
+		L0StopWritesThreshold:    opts.L0StopWritesThreshold,
+	}
+}
+
+// WorkMemory returns the number of bytes an operator
+// can hold in memory before spilling to temporary storage.
+func (db *Database) WorkMemory() int {
+	return workMemory(db.opts)
+}
+
+// PreserveColumnOrder returns true if the rows returned by the queries
+// keep the order of their columns when encoded.
+func (db *Database) PreserveColumnOrder() bool {
+	return db.opts.PreserveColumnOrder
+}
+
+// Location returns the time zone of the database.
+func (db *Database) Location() *time.Location {
+	if db.opts.TimeZone == nil {
+		return time.UTC
+	}
+
+	return db.opts.TimeZone
+}
+
+func workMemory(opts *Options) int {
+	if opts.WorkMemory <= 0 {
+		return DefaultWorkMemory
+	}
+
+	return opts.WorkMemory
+}
+
 // Close the database.
 func (db *Database) Close() error {
 	var err error
@@ -132,7 +467,8 @@ func (db *Database) Close() error {
 		db.closeCancel()
 
 		db.connectionWg.Wait()
-		err = db.closeDatabase()
+		db.ttlWg.Wait()
+		err = errors.CombineErrors(db.closeAttached(), db.closeDatabase())
 	})
 
 	return err
@@ -201,17 +537,39 @@ func (db *Database) beginTx(opts *TxOptions) (*Transaction, error) {
 		return nil, errors.New("database is closed")
 	}
 
-	db.txmu.RLock()
-	defer db.txmu.RUnlock()
-
 	if opts == nil {
 		opts = new(TxOptions)
 	}
 
-	if !opts.ReadOnly {
-		db.writetxmu.Lock()
+	if opts.Snapshot != nil {
+		if !opts.ReadOnly {
+			return nil, errors.New("cannot write to a snapshot")
+		}
+		if opts.Snapshot.db != db {
+			return nil, errors.New("snapshot belongs to another database")
+		}
 	}
 
+	// the write lock is acquired before the transaction mutex:
+	// waiting for the current writer must neither block the readers
+	// nor the commit of that writer.
+	if !opts.ReadOnly && !opts.Deferred {
+		if db.replica.Load() {
+			return nil, ErrReadOnlyReplica
+		}
+		if db.readOnly.Load() {
+			return nil, ErrReadOnly
+		}
+
+		err := db.lockWriter(opts.Context)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	db.txmu.RLock()
+	defer db.txmu.RUnlock()
+
 	return db.beginTxUnlocked(opts)
 }
 
@@ -221,30 +579,86 @@ func (db *Database) beginTxUnlocked(opts *TxOptions) (*Transaction, error) {
 		opts = &TxOptions{}
 	}
 
+	// deferred transactions read a snapshot until they are upgraded
+	readOnly := opts.ReadOnly || opts.Deferred
+
 	var sess engine.Session
-	if opts.ReadOnly {
+	switch {
+	case opts.Snapshot != nil:
+		var err error
+		sess, err = opts.Snapshot.newSession()
+		if err != nil {
+			return nil, err
+		}
+	case readOnly:
 		sess = db.Engine.NewSnapshotSession()
-	} else {
-		sess = db.Engine.NewBatchSession()
+	default:
+		sess = db.newBatchSession(opts.Durability)
 	}
 
 	tx := Transaction{
-		db:       db,
-		Engine:   db.Engine,
-		Session:  sess,
-		Writable: !opts.ReadOnly,
-		ID:       db.transactionIDs.Add(1),
-		Catalog:  db.Catalog(),
-		TxStart:  time.Now(),
+		db:          db,
+		Engine:      db.Engine,
+		Session:     sess,
+		Writable:    !readOnly,
+		Deferred:    opts.Deferred && !opts.ReadOnly,
+		ID:          db.transactionIDs.Add(1),
+		Catalog:     db.Catalog(),
+		TxStart:     time.Now(),
+		snapshotSeq: db.commitSeq.Load(),
+		commits:     db.commits.Load(),
+		durability:  opts.Durability,
+		workMemory:  opts.WorkMemory,
+		ctx:         opts.Context,
 	}
 
-	if !opts.ReadOnly {
+	if !readOnly {
 		tx.WriteTxMu = &db.writetxmu
 	}
 
+	if s := opts.Snapshot; s != nil {
+		tx.Catalog = s.catalog
+		tx.snapshotSeq = s.snapshotSeq
+		tx.commits = s.commits
+	}
+
 	return &tx, nil
 }
 
+// newBatchSession creates the session of a write transaction.
+func (db *Database) newBatchSession(d Durability) engine.Session {
+	sess := db.Engine.NewBatchSession()
+
+	// engines that don't sync their commits ignore the durability
+	if s, ok := sess.(syncSetter); ok && d != DefaultDurability {
+		s.SetSync(d == FullDurability)
+	}
+
+	return sess
+}
+
+// tableVersion returns the commitSeq of the last commit that modified the table.
+func (db *Database) tableVersion(tableName string) uint64 {
+	db.tableVersionsMu.Lock()
+	defer db.tableVersionsMu.Unlock()
+
+	return db.tableVersions[tableName]
+}
+
+// setTableVersions records that the tables were modified by the commit seq.
+func (db *Database) setTableVersions(tables map[string]struct{}, seq uint64) {
+	db.tableVersionsMu.Lock()
+	defer db.tableVersionsMu.Unlock()
+
+	if db.tableVersions == nil {
+		db.tableVersions = make(map[string]uint64)
+	}
+
+	for name := range tables {
+		db.tableVersions[name] = seq
+	}
+}
+
 func (db *Database) Catalog() *Catalog {
 	db.catalogMu.RLock()
 	c := db.catalog
@@ -252,7 +666,10 @@ func (db *Database) Catalog() *Catalog {
 	return c
 }
 
+// SetCatalog publishes the catalog and assigns it a new version.
 func (db *Database) SetCatalog(c *Catalog) {
+	c.Version = db.schemaVersion.Add(1)
+
 	db.catalogMu.Lock()
 	db.catalog = c
 	db.catalogMu.Unlock()
@@ -1,10 +1,21 @@
 package database_test
 
 import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/database/catalogstore"
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/kv"
 	"github.com/stretchr/testify/require"
 )
 
@@ -75,3 +86,449 @@ func TestConcurrentTransactionManagement(t *testing.T) {
 		t.Fatal("deadlock")
 	}
 }
+
+func TestManifest(t *testing.T) {
+	path := t.TempDir()
+	opts := database.Options{
+		CatalogLoader: catalogstore.LoadCatalog,
+	}
+
+	db, err := database.Open(path, &opts)
+	require.NoError(t, err)
+
+	// simulate a database written by a future version of Chai
+	update(t, db, func(tx *database.Transaction) error {
+		v := binary.AppendUvarint(nil, database.FormatVersion+1)
+		v = binary.AppendUvarint(v, database.FormatVersion+1)
+		return tx.Session.Put(encoding.EncodeInt(nil, int64(database.ManifestNamespace)), v)
+	})
+	require.NoError(t, db.Close())
+
+	_, err = database.Open(path, &opts)
+	require.Error(t, err)
+	require.True(t, database.IsIncompatibleFormatError(err))
+
+	opts.Upgrade = true
+	_, err = database.Open(path, &opts)
+	require.True(t, database.IsIncompatibleFormatError(err))
+}
+
+func TestFormatVersion(t *testing.T) {
+	manifest := func(t *testing.T, db *chai.DB) (writer, minReader uint64) {
+		t.Helper()
+
+		tx, err := db.DB.Begin(false)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		v, err := tx.Session.Get(encoding.EncodeInt(nil, int64(database.ManifestNamespace)))
+		require.NoError(t, err)
+		writer, n := binary.Uvarint(v)
+		minReader, _ = binary.Uvarint(v[n:])
+		return writer, minReader
+	}
+
+	tests := []struct {
+		name   string
+		column string
+	}{
+		{"DECIMAL", "DECIMAL(10, 2)"},
+		{"UUID", "UUID"},
+		{"TINYINT", "TINYINT"},
+		{"INT32", "INT32"},
+		{"ENUM", "TEXT ENUM('a', 'b')"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := chai.Open(filepath.Join(t.TempDir(), "db"))
+			require.NoError(t, err)
+			defer db.Close()
+
+			// new databases can be read by the first format version
+			writer, minReader := manifest(t, db)
+			require.Equal(t, database.FormatVersion, writer)
+			require.EqualValues(t, 1, minReader)
+
+			err = db.Exec("CREATE TABLE a(x INTEGER, y TEXT, z DOUBLE)")
+			require.NoError(t, err)
+			_, minReader = manifest(t, db)
+			require.EqualValues(t, 1, minReader)
+
+			// the version is not raised by rolled back transactions
+			err = db.Exec(fmt.Sprintf("BEGIN; CREATE TABLE b(x %s); ROLLBACK", test.column))
+			require.NoError(t, err)
+			_, minReader = manifest(t, db)
+			require.EqualValues(t, 1, minReader)
+
+			err = db.Exec(fmt.Sprintf("CREATE TABLE b(x %s)", test.column))
+			require.NoError(t, err)
+			_, minReader = manifest(t, db)
+			require.EqualValues(t, 2, minReader)
+		})
+	}
+
+	t.Run("ALTER TABLE", func(t *testing.T) {
+		db, err := chai.Open(filepath.Join(t.TempDir(), "db"))
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec("CREATE TABLE a(x INTEGER)")
+		require.NoError(t, err)
+		err = db.Exec("ALTER TABLE a ADD COLUMN y UUID")
+		require.NoError(t, err)
+		_, minReader := manifest(t, db)
+		require.EqualValues(t, 2, minReader)
+	})
+
+	t.Run("External values", func(t *testing.T) {
+		db, err := chai.OpenWith(filepath.Join(t.TempDir(), "db"), &chai.Options{BlobThreshold: 1024})
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec("CREATE TABLE a(x INTEGER PRIMARY KEY, y TEXT)")
+		require.NoError(t, err)
+		err = db.Exec("INSERT INTO a (x, y) VALUES (1, 'small')")
+		require.NoError(t, err)
+		_, minReader := manifest(t, db)
+		require.EqualValues(t, 1, minReader)
+
+		err = db.Exec("INSERT INTO a (x, y) VALUES (2, ?)", strings.Repeat("a", 2000))
+		require.NoError(t, err)
+		_, minReader = manifest(t, db)
+		require.EqualValues(t, 2, minReader)
+	})
+
+	t.Run("Upgrade", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "db")
+		db, err := chai.Open(path)
+		require.NoError(t, err)
+
+		// simulate a database written with the first format version
+		err = db.DB.Engine.(*kv.PebbleEngine).DB().Set(
+			encoding.EncodeInt(nil, int64(database.ManifestNamespace)),
+			binary.AppendUvarint(binary.AppendUvarint(nil, 1), 1),
+			nil,
+		)
+		require.NoError(t, err)
+		require.NoError(t, db.Close())
+
+		_, err = chai.Open(path)
+		var ferr *database.IncompatibleFormatError
+		require.ErrorAs(t, err, &ferr)
+		require.True(t, ferr.NeedsUpgrade())
+
+		require.NoError(t, chai.Upgrade(path))
+
+		db, err = chai.Open(path)
+		require.NoError(t, err)
+		defer db.Close()
+
+		writer, minReader := manifest(t, db)
+		require.Equal(t, database.FormatVersion, writer)
+		require.EqualValues(t, 1, minReader)
+	})
+}
+
+func TestConcurrentReaders(t *testing.T) {
+	for _, path := range []string{":memory:", filepath.Join(t.TempDir(), "db")} {
+		t.Run(path, func(t *testing.T) {
+			db, err := chai.Open(path)
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec("CREATE TABLE test(a INTEGER PRIMARY KEY)")
+			require.NoError(t, err)
+			for i := 0; i < 10; i++ {
+				err = db.Exec("INSERT INTO test (a) VALUES (?)", i)
+				require.NoError(t, err)
+			}
+
+			count := func(tx *chai.Tx) int {
+				var n int
+				r, err := tx.QueryRow("SELECT COUNT(*) FROM test")
+				require.NoError(t, err)
+				require.NoError(t, r.Scan(&n))
+				return n
+			}
+
+			// runs fn in a read transaction, which must not wait for the writers
+			read := func(fn func(tx *chai.Tx)) {
+				done := make(chan struct{})
+				go func() {
+					defer close(done)
+
+					conn, err := db.Connect()
+					require.NoError(t, err)
+					defer conn.Close()
+
+					tx, err := conn.Begin(false)
+					require.NoError(t, err)
+					defer tx.Rollback()
+
+					fn(tx)
+				}()
+
+				select {
+				case <-done:
+				case <-time.After(5 * time.Second):
+					t.Fatal("read transaction blocked by a writer")
+				}
+			}
+
+			conn, err := db.Connect()
+			require.NoError(t, err)
+			defer conn.Close()
+
+			writer, err := conn.Begin(true)
+			require.NoError(t, err)
+			for i := 10; i < 20; i++ {
+				err = writer.Exec("INSERT INTO test (a) VALUES (?)", i)
+				require.NoError(t, err)
+			}
+
+			// a reader started before the commit keeps its snapshot
+			before, err := db.Connect()
+			require.NoError(t, err)
+			defer before.Close()
+			beforeTx, err := before.Begin(false)
+			require.NoError(t, err)
+			defer beforeTx.Rollback()
+
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					read(func(tx *chai.Tx) {
+						require.Equal(t, 10, count(tx))
+					})
+				}()
+			}
+			wg.Wait()
+
+			// a second writer waits for the first one
+			// without blocking the readers nor the commit
+			second := make(chan error, 1)
+			go func() {
+				conn, err := db.Connect()
+				if err != nil {
+					second <- err
+					return
+				}
+				defer conn.Close()
+
+				second <- conn.Update(func(tx *chai.Tx) error {
+					return tx.Exec("INSERT INTO test (a) VALUES (20)")
+				})
+			}()
+			time.Sleep(10 * time.Millisecond)
+
+			read(func(tx *chai.Tx) {
+				require.Equal(t, 10, count(tx))
+			})
+
+			require.NoError(t, writer.Commit())
+			select {
+			case err := <-second:
+				require.NoError(t, err)
+			case <-time.After(5 * time.Second):
+				t.Fatal("second writer blocked")
+			}
+
+			require.Equal(t, 10, count(beforeTx))
+			read(func(tx *chai.Tx) {
+				require.Equal(t, 21, count(tx))
+			})
+		})
+	}
+}
+
+func TestWorkMemory(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE test(a INTEGER PRIMARY KEY, b INTEGER, c TEXT)")
+	require.NoError(t, err)
+	for i := 0; i < 200; i++ {
+		err = db.Exec("INSERT INTO test (a, b, c) VALUES (?, ?, ?)", i, (i*37)%100, strings.Repeat("x", 50))
+		require.NoError(t, err)
+	}
+
+	// a small work memory makes the sort and the aggregation spill
+	conn, err := db.WithWorkMemory(256).Connect()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	res, err := conn.Query("SELECT b, COUNT(*) FROM test GROUP BY b ORDER BY COUNT(*) DESC, b")
+	require.NoError(t, err)
+	defer res.Close()
+
+	var i int
+	err = res.Iterate(func(r *chai.Row) error {
+		var b, n int
+		require.NoError(t, r.Scan(&b, &n))
+		require.Equal(t, i, b)
+		require.Equal(t, 2, n)
+		i++
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 100, i)
+}
+
+func TestTimeZone(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+
+	db, err := chai.OpenWith(":memory:", &chai.Options{TimeZone: paris})
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test(a INTEGER PRIMARY KEY, b TIMESTAMP);
+		CREATE INDEX on test(b);
+		INSERT INTO test (a, b) VALUES (1, '2023-04-05 08:07:08'), (2, '2023-04-05T08:07:08Z')
+	`)
+	require.NoError(t, err)
+
+	// texts without offset are interpreted in the time zone of the database
+	// and timestamps are returned in it
+	var b time.Time
+	r, err := db.QueryRow("SELECT b FROM test WHERE a = 1")
+	require.NoError(t, err)
+	require.NoError(t, r.Scan(&b))
+	require.True(t, b.Equal(time.Date(2023, 4, 5, 6, 7, 8, 0, time.UTC)))
+	require.Equal(t, "2023-04-05T08:07:08+02:00", b.Format(time.RFC3339))
+
+	var s string
+	r, err = db.QueryRow("SELECT CAST(b AS TEXT) FROM test WHERE a = 2")
+	require.NoError(t, err)
+	require.NoError(t, r.Scan(&s))
+	require.Equal(t, "2023-04-05T10:07:08+02:00", s)
+
+	// comparisons with texts use the time zone of the database
+	var a int
+	r, err = db.QueryRow("SELECT a FROM test WHERE b = '2023-04-05 10:07:08'")
+	require.NoError(t, err)
+	require.NoError(t, r.Scan(&a))
+	require.Equal(t, 2, a)
+
+	// AT TIME ZONE overrides the time zone of the database
+	r, err = db.QueryRow("SELECT b AT TIME ZONE 'UTC' FROM test WHERE a = 1")
+	require.NoError(t, err)
+	require.NoError(t, r.Scan(&b))
+	require.Equal(t, "2023-04-05T06:07:08Z", b.Format(time.RFC3339))
+}
+
+func TestNonFiniteDoubles(t *testing.T) {
+	t.Run("store", func(t *testing.T) {
+		db, err := chai.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE test(a INTEGER PRIMARY KEY, b DOUBLE);
+			CREATE INDEX on test(b);
+			INSERT INTO test (a, b) VALUES (1, 'NaN'), (2, 1.5), (3, '-Infinity'), (4, 'Infinity'), (5, ?)
+		`, math.NaN())
+		require.NoError(t, err)
+
+		conn, err := db.Connect()
+		require.NoError(t, err)
+		defer conn.Close()
+
+		res, err := conn.Query("SELECT a FROM test ORDER BY b, a")
+		require.NoError(t, err)
+		defer res.Close()
+
+		var got []int
+		err = res.Iterate(func(r *chai.Row) error {
+			var a int
+			require.NoError(t, r.Scan(&a))
+			got = append(got, a)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []int{3, 2, 4, 1, 5}, got)
+
+		var n int
+		r, err := db.QueryRow("SELECT COUNT(*) FROM test WHERE b = CAST('NaN' AS DOUBLE)")
+		require.NoError(t, err)
+		require.NoError(t, r.Scan(&n))
+		require.Equal(t, 2, n)
+
+		r, err = db.QueryRow("SELECT b FROM test WHERE a = 4")
+		require.NoError(t, err)
+		j, err := r.MarshalJSON()
+		require.NoError(t, err)
+		require.JSONEq(t, `{"b": "Infinity"}`, string(j))
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		db, err := chai.OpenWith(":memory:", &chai.Options{RejectNonFiniteDoubles: true})
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec("CREATE TABLE test(a INTEGER PRIMARY KEY, b DOUBLE)")
+		require.NoError(t, err)
+
+		err = db.Exec("INSERT INTO test (a, b) VALUES (1, ?)", math.Inf(1))
+		require.EqualError(t, err, "column b: Infinity is not allowed")
+
+		err = db.Exec("INSERT INTO test (a, b) VALUES (1, 'NaN')")
+		require.EqualError(t, err, "column b: NaN is not allowed")
+
+		err = db.Exec("INSERT INTO test (a, b) VALUES (1, 1e308)")
+		require.NoError(t, err)
+		err = db.Exec("UPDATE test SET b = b * 10")
+		require.EqualError(t, err, "column b: Infinity is not allowed")
+	})
+}
+
+func TestPreserveColumnOrder(t *testing.T) {
+	for _, preserve := range []bool{false, true} {
+		t.Run(fmt.Sprint(preserve), func(t *testing.T) {
+			db, err := chai.OpenWith(":memory:", &chai.Options{PreserveColumnOrder: preserve})
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec(`CREATE TABLE audit(id INT PRIMARY KEY, z TEXT, a TEXT, payload TEXT);
+				INSERT INTO audit VALUES (1, 'z', 'a', '{"b": 1, "a": 2}')`)
+			require.NoError(t, err)
+
+			want := `{"a": "a", "id": 1, "payload": "{\"b\": 1, \"a\": 2}", "z": "z"}`
+			if preserve {
+				want = `{"id": 1, "z": "z", "a": "a", "payload": "{\"b\": 1, \"a\": 2}"}`
+			}
+
+			r, err := db.QueryRow("SELECT * FROM audit")
+			require.NoError(t, err)
+			data, err := r.MarshalJSON()
+			require.NoError(t, err)
+			require.Equal(t, want, string(data))
+
+			conn, err := db.Connect()
+			require.NoError(t, err)
+			defer conn.Close()
+
+			res, err := conn.Query("SELECT * FROM audit")
+			require.NoError(t, err)
+			defer res.Close()
+			data, err = res.MarshalJSON()
+			require.NoError(t, err)
+			require.Equal(t, "["+want+"]", string(data))
+
+			r, err = db.QueryRow("SELECT id, a FROM audit")
+			require.NoError(t, err)
+			data, err = r.MarshalMsgpack()
+			require.NoError(t, err)
+			first := "a161" // fixstr "a"
+			if preserve {
+				first = "a26964" // fixstr "id"
+			}
+			require.Equal(t, first, hex.EncodeToString(data[1:1+len(first)/2]))
+		})
+	}
+}
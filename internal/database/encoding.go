@@ -1,6 +1,8 @@
 package database
 
 import (
+	"strings"
+
 	"github.com/chaisql/chai/internal/encoding"
 	"github.com/chaisql/chai/internal/row"
 	"github.com/chaisql/chai/internal/types"
@@ -14,10 +16,15 @@ func (t *TableInfo) EncodeRow(tx *Transaction, dst []byte, r row.Row) ([]byte, e
 		return ed.encoded, nil
 	}
 
-	return encodeRow(tx, dst, &t.ColumnConstraints, r)
+	// the limits don't apply to internal tables
+	limits := !strings.HasPrefix(t.TableName, InternalPrefix)
+
+	return encodeRow(tx, dst, &t.ColumnConstraints, r, limits)
 }
 
-func encodeRow(tx *Transaction, dst []byte, ccs *ColumnConstraints, r row.Row) ([]byte, error) {
+func encodeRow(tx *Transaction, dst []byte, ccs *ColumnConstraints, r row.Row, limits bool) ([]byte, error) {
+	start := len(dst)
+
 	// loop over all the defined column contraints in order.
 	for _, cc := range ccs.Ordered {
 
@@ -47,24 +54,69 @@ func encodeRow(tx *Transaction, dst []byte, ccs *ColumnConstraints, r row.Row) (
 			return nil, &ConstraintViolationError{Constraint: "NOT NULL", Columns: []string{cc.Column}}
 		}
 
-		// ensure the value is of the correct type
-		v, err = v.CastAs(cc.Type)
+		// ensure the value is of the correct type.
+		// texts converted to timestamps are interpreted in the time zone of the database
+		v, err = types.CastIn(v, cc.Type, tx.Location())
 		if err != nil {
 			return nil, err
 		}
 
+		if d, ok := v.(types.DecimalValue); ok && cc.Precision > 0 {
+			v, err = d.Constrain(cc.Precision, cc.Scale)
+			if err != nil {
+				return nil, errors.Wrapf(err, "column %s", cc.Column)
+			}
+		}
+
+		if !types.IsFinite(v) && tx.rejectsNonFiniteDoubles() {
+			return nil, errors.Errorf("column %s: %s is not allowed", cc.Column, v)
+		}
+
+		if limits {
+			if err := tx.checkDocument(cc.Column, v); err != nil {
+				return nil, err
+			}
+		}
+
+		if cc.Bits > 0 && v.Type() != types.TypeNull {
+			if err := types.ConstrainInteger(v, cc.Bits); err != nil {
+				return nil, errors.Wrapf(err, "column %s", cc.Column)
+			}
+		}
+
+		// values of ENUM columns are stored as their position in the list
+		if len(cc.Enum) > 0 && v.Type() == types.TypeText {
+			code, ok := cc.EnumCode(types.AsString(v))
+			if !ok {
+				return nil, &ConstraintViolationError{Constraint: "ENUM", Columns: []string{cc.Column}}
+			}
+
+			dst = encoding.EncodeInt(dst, int64(code))
+			continue
+		}
+
 		dst, err = v.Encode(dst)
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	if limits {
+		if err := tx.checkRowSize(len(dst) - start); err != nil {
+			return nil, err
+		}
+	}
+
 	return dst, nil
 }
 
 type EncodedRow struct {
 	encoded           []byte
 	columnConstraints *ColumnConstraints
+	// reads the values stored outside of the row.
+	blobs BlobReader
+	// offsets of the columns skipped so far, in the order of the table.
+	offsets []int
 }
 
 func NewEncodedRow(ccs *ColumnConstraints, data []byte) *EncodedRow {
@@ -78,7 +130,34 @@ func NewEncodedRow(ccs *ColumnConstraints, data []byte) *EncodedRow {
 
 func (e *EncodedRow) ResetWith(ccs *ColumnConstraints, data []byte) {
 	e.columnConstraints = ccs
+	e.setEncoded(data)
+}
+
+func (e *EncodedRow) setEncoded(data []byte) {
 	e.encoded = data
+	e.offsets = e.offsets[:0]
+}
+
+// seek returns the encoded row, starting at the column at the given position.
+// The offsets of the columns are computed on demand and kept until the row is reset,
+// so that getting several columns of the same row skips each value only once.
+func (e *EncodedRow) seek(pos int) []byte {
+	if len(e.offsets) == 0 {
+		e.offsets = append(e.offsets, 0)
+	}
+
+	for len(e.offsets) <= pos {
+		last := e.offsets[len(e.offsets)-1]
+		e.offsets = append(e.offsets, last+encoding.Skip(e.encoded[last:]))
+	}
+
+	return e.encoded[e.offsets[pos]:]
+}
+
+// SetBlobReader sets the reader used to load the values
+// stored outside of the row.
+func (e *EncodedRow) SetBlobReader(r BlobReader) {
+	e.blobs = r
 }
 
 func (e *EncodedRow) decodeValue(fc *ColumnConstraint, b []byte) (types.Value, int, error) {
@@ -86,29 +165,79 @@ func (e *EncodedRow) decodeValue(fc *ColumnConstraint, b []byte) (types.Value, i
 		return types.NewNullValue(), 1, nil
 	}
 
+	if b[0] == encoding.ExternalValue {
+		return e.decodeExternalValue(fc, b)
+	}
+
+	if len(fc.Enum) > 0 {
+		code, n := encoding.DecodeInt(b)
+		if code < 0 || code >= int64(len(fc.Enum)) {
+			return nil, 0, errors.Errorf("invalid enum value of column %s", fc.Column)
+		}
+
+		return types.NewTextValue(fc.Enum[code]), n, nil
+	}
+
 	v, n := fc.Type.Def().Decode(b)
 
 	return v, n, nil
 }
 
+// decodeExternalValue loads a value stored outside of the row.
+func (e *EncodedRow) decodeExternalValue(fc *ColumnConstraint, b []byte) (types.Value, int, error) {
+	typ, ptr, n := encoding.DecodeExternal(b)
+	if e.blobs == nil {
+		return nil, 0, errors.Errorf("cannot read external value of column %s", fc.Column)
+	}
+
+	data, err := e.blobs.ReadBlob(ptr)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "cannot read external value of column %s", fc.Column)
+	}
+
+	switch typ {
+	case encoding.TextValue:
+		return types.NewTextValue(string(data)), n, nil
+	case encoding.BlobValue:
+		return types.NewBlobValue(data), n, nil
+	}
+
+	return nil, 0, errors.Errorf("invalid external value of column %s", fc.Column)
+}
+
 // Get decodes the selected column from the buffer.
+// The other columns are skipped without being decoded.
 func (e *EncodedRow) Get(column string) (v types.Value, err error) {
-	b := e.encoded
-
 	// get the column from the list of column constraints
 	cc, ok := e.columnConstraints.ByColumn[column]
 	if !ok {
 		return nil, errors.Wrapf(types.ErrColumnNotFound, "%s not found", column)
 	}
 
-	// skip all columns before the selected column
-	for i := 0; i < cc.Position; i++ {
-		n := encoding.Skip(b)
-		b = b[n:]
+	v, _, err = e.decodeValue(cc, e.seek(cc.Position))
+	return
+}
+
+// RawText returns the bytes of the TEXT value of the selected column without decoding
+// nor copying them. It returns false if the value is NULL or not stored in the row,
+// in which case Get must be used instead.
+// The bytes must not be modified and are only valid until the row is reset.
+func (e *EncodedRow) RawText(column string) ([]byte, bool, error) {
+	cc, ok := e.columnConstraints.ByColumn[column]
+	if !ok {
+		return nil, false, errors.Wrapf(types.ErrColumnNotFound, "%s not found", column)
+	}
+	if cc.Type != types.TypeText || len(cc.Enum) > 0 {
+		return nil, false, nil
 	}
 
-	v, _, err = e.decodeValue(cc, b)
-	return
+	b := e.seek(cc.Position)
+	if b[0] != encoding.TextValue {
+		return nil, false, nil
+	}
+
+	data, _ := encoding.DecodeBlob(b)
+	return data, true, nil
 }
 
 // Iterate decodes each columns one by one and passes them to fn
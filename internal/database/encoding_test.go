@@ -75,3 +75,86 @@ func TestEncoding(t *testing.T) {
 
 	testutil.RequireRowEqual(t, want, er)
 }
+
+func TestEncodingEnum(t *testing.T) {
+	var ti database.TableInfo
+
+	err := ti.AddColumnConstraint(&database.ColumnConstraint{
+		Position: 0,
+		Column:   "status",
+		Type:     types.TypeText,
+		Enum:     []string{"open", "closed"},
+	})
+	require.NoError(t, err)
+
+	buf, err := ti.EncodeRow(nil, nil, row.NewFromMap(map[string]any{"status": "closed"}))
+	require.NoError(t, err)
+	// values are stored as their position
+	require.Len(t, buf, 1)
+
+	er := database.NewEncodedRow(&ti.ColumnConstraints, buf)
+	testutil.RequireRowEqual(t, row.NewFromMap(map[string]any{"status": "closed"}), er)
+
+	buf, err = ti.EncodeRow(nil, nil, row.NewFromMap(map[string]any{"status": nil}))
+	require.NoError(t, err)
+	er = database.NewEncodedRow(&ti.ColumnConstraints, buf)
+	v, err := er.Get("status")
+	require.NoError(t, err)
+	require.Equal(t, types.TypeNull, v.Type())
+
+	_, err = ti.EncodeRow(nil, nil, row.NewFromMap(map[string]any{"status": "pending"}))
+	var cerr *database.ConstraintViolationError
+	require.ErrorAs(t, err, &cerr)
+	require.Equal(t, "ENUM", cerr.Constraint)
+}
+
+func TestEncodedRowRawText(t *testing.T) {
+	var ti database.TableInfo
+
+	for i, c := range []struct {
+		name string
+		typ  types.Type
+		enum []string
+	}{
+		{"a", types.TypeInteger, nil},
+		{"doc", types.TypeText, nil},
+		{"b", types.TypeText, nil},
+		{"status", types.TypeText, []string{"open"}},
+		{"c", types.TypeDouble, nil},
+	} {
+		err := ti.AddColumnConstraint(&database.ColumnConstraint{Position: i, Column: c.name, Type: c.typ, Enum: c.enum})
+		require.NoError(t, err)
+	}
+
+	buf, err := ti.EncodeRow(nil, nil, row.NewFromMap(map[string]any{"a": 1, "doc": `{"a": 1}`, "status": "open", "c": 1.5}))
+	require.NoError(t, err)
+
+	er := database.NewEncodedRow(&ti.ColumnConstraints, buf)
+
+	data, ok, err := er.RawText("doc")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, `{"a": 1}`, string(data))
+
+	// NULL, enum and non text values are not available
+	for _, c := range []string{"a", "b", "status", "c"} {
+		_, ok, err = er.RawText(c)
+		require.NoError(t, err)
+		require.False(t, ok, c)
+	}
+
+	_, _, err = er.RawText("d")
+	require.ErrorIs(t, err, types.ErrColumnNotFound)
+
+	// the columns can be read in any order, after a reset
+	er.ResetWith(&ti.ColumnConstraints, buf)
+	v, err := er.Get("c")
+	require.NoError(t, err)
+	require.Equal(t, 1.5, types.AsFloat64(v))
+	v, err = er.Get("a")
+	require.NoError(t, err)
+	require.EqualValues(t, 1, types.AsInt64(v))
+	v, err = er.Get("status")
+	require.NoError(t, err)
+	require.Equal(t, "open", types.AsString(v))
+}
@@ -0,0 +1,35 @@
+package database
+
+// CommitMainOnly commits the changes of the transaction to the main database
+// only, as if the process stopped before committing the attached databases.
+func (tx *Transaction) CommitMainOnly() error {
+	err := tx.prepareAttached()
+	if err != nil {
+		return err
+	}
+
+	tx.stopAttached()
+	return tx.commitMain()
+}
+
+// PrepareAttachedOnly prepares the changes of the attached databases and
+// rolls back the main database, as if the process stopped before committing it.
+func (tx *Transaction) PrepareAttachedOnly() error {
+	err := tx.prepareAttached()
+	if err != nil {
+		return err
+	}
+
+	tx.stopAttached()
+	return tx.Rollback()
+}
+
+// stopAttached leaves the prepared transactions of the attached
+// databases unresolved, as when their connection is closed.
+func (tx *Transaction) stopAttached() {
+	for _, atx := range tx.attached {
+		atx.db.recoveredTx.Store(&atx.preparedID)
+		atx.db.connectionWg.Done()
+	}
+	tx.attached = nil
+}
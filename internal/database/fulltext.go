@@ -0,0 +1,276 @@
+package database
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/chaisql/chai/internal/fulltext"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// A full-text index stores, in the same tree:
+//
+//	k: 0, <term>, <primary key>  v: frequency of the term in the row
+//	k: 1, <primary key>          v: number of terms of the row
+//	k: 2, <term>                 v: number of rows containing the term
+//	k: 3                         v: number of rows, total number of terms
+//
+// The last three are used to compute the BM25 score of a row.
+const (
+	fulltextPostingPrefix int32 = iota
+	fulltextDocLenPrefix
+	fulltextDocFreqPrefix
+	fulltextStatsPrefix
+)
+
+func fulltextPostingKey(term string, key []byte) *tree.Key {
+	return tree.NewKey(types.NewIntegerValue(fulltextPostingPrefix), types.NewTextValue(term), types.NewBlobValue(key))
+}
+
+func fulltextDocLenKey(key []byte) *tree.Key {
+	return tree.NewKey(types.NewIntegerValue(fulltextDocLenPrefix), types.NewBlobValue(key))
+}
+
+func fulltextDocFreqKey(term string) *tree.Key {
+	return tree.NewKey(types.NewIntegerValue(fulltextDocFreqPrefix), types.NewTextValue(term))
+}
+
+func fulltextStatsKey() *tree.Key {
+	return tree.NewKey(types.NewIntegerValue(fulltextStatsPrefix))
+}
+
+// Analyze returns the terms of a text, using the analyzer of the index.
+func (idx *Index) Analyze(s string) ([]string, error) {
+	a, err := fulltext.Lookup(idx.Analyzer)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.Analyze(s), nil
+}
+
+// setFulltext indexes the terms of a TEXT value.
+// Other types are not indexed.
+func (idx *Index) setFulltext(v types.Value, key []byte) error {
+	if v.Type() != types.TypeText {
+		return nil
+	}
+
+	terms, err := idx.Analyze(types.AsString(v))
+	if err != nil || len(terms) == 0 {
+		return err
+	}
+
+	freqs := make(map[string]uint64)
+	for _, t := range terms {
+		freqs[t]++
+	}
+
+	// each key is read before being written to the batch,
+	// which is applied once all the keys are written.
+	b := idx.Tree.NewBatch()
+	for t, tf := range freqs {
+		err := b.Put(fulltextPostingKey(t, key), binary.AppendUvarint(nil, tf))
+		if err != nil {
+			return err
+		}
+
+		err = idx.addUint(b, fulltextDocFreqKey(t), 1)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = b.Put(fulltextDocLenKey(key), binary.AppendUvarint(nil, uint64(len(terms))))
+	if err != nil {
+		return err
+	}
+
+	docCount, total, err := idx.fulltextStats()
+	if err != nil {
+		return err
+	}
+
+	err = idx.putFulltextStats(b, docCount+1, total+uint64(len(terms)))
+	if err != nil {
+		return err
+	}
+
+	return b.Apply()
+}
+
+// deleteFulltext removes the terms of a TEXT value from the index.
+func (idx *Index) deleteFulltext(v types.Value, key []byte) error {
+	if v.Type() != types.TypeText {
+		return nil
+	}
+
+	terms, err := idx.Analyze(types.AsString(v))
+	if err != nil || len(terms) == 0 {
+		return err
+	}
+
+	b := idx.Tree.NewBatch()
+	for _, t := range fulltext.Unique(terms) {
+		err := b.Delete(fulltextPostingKey(t, key))
+		if err != nil {
+			return err
+		}
+
+		err = idx.addUint(b, fulltextDocFreqKey(t), -1)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = b.Delete(fulltextDocLenKey(key))
+	if err != nil {
+		return err
+	}
+
+	docCount, total, err := idx.fulltextStats()
+	if err != nil {
+		return err
+	}
+
+	err = idx.putFulltextStats(b, docCount-1, total-uint64(len(terms)))
+	if err != nil {
+		return err
+	}
+
+	return b.Apply()
+}
+
+// Search returns the keys of the rows containing all the terms,
+// ordered by key.
+func (idx *Index) Search(terms []string) ([][]byte, error) {
+	if !idx.Fulltext {
+		return nil, errors.New("not a full-text index")
+	}
+
+	terms = fulltext.Unique(terms)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	var keys [][]byte
+
+	prefix := tree.NewKey(types.NewIntegerValue(fulltextPostingPrefix), types.NewTextValue(terms[0]))
+	err := idx.Tree.IterateOnRange(&tree.Range{Min: prefix, Max: prefix}, false, func(k *tree.Key, _ []byte) error {
+		values, err := k.Decode()
+		if err != nil {
+			return err
+		}
+
+		key := bytes.Clone(types.AsByteSlice(values[len(values)-1]))
+
+		// ensure the row contains the other terms
+		for _, t := range terms[1:] {
+			ok, err := idx.Tree.Exists(fulltextPostingKey(t, key))
+			if err != nil || !ok {
+				return err
+			}
+		}
+
+		keys = append(keys, key)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// Score returns the BM25 score of the row identified by key for the given terms.
+func (idx *Index) Score(terms []string, key []byte) (float64, error) {
+	if !idx.Fulltext {
+		return 0, errors.New("not a full-text index")
+	}
+
+	docCount, total, err := idx.fulltextStats()
+	if err != nil || docCount == 0 {
+		return 0, err
+	}
+	avgDocLen := float64(total) / float64(docCount)
+
+	docLen, err := idx.getUint(fulltextDocLenKey(key))
+	if err != nil {
+		return 0, err
+	}
+
+	var score float64
+	for _, t := range fulltext.Unique(terms) {
+		tf, err := idx.getUint(fulltextPostingKey(t, key))
+		if err != nil {
+			return 0, err
+		}
+		if tf == 0 {
+			continue
+		}
+
+		df, err := idx.getUint(fulltextDocFreqKey(t))
+		if err != nil {
+			return 0, err
+		}
+
+		score += fulltext.BM25(tf, df, docLen, docCount, avgDocLen)
+	}
+
+	return score, nil
+}
+
+// getUint returns the uvarint stored under k, or 0 if the key doesn't exist.
+func (idx *Index) getUint(k *tree.Key) (uint64, error) {
+	v, err := idx.Tree.Get(k)
+	if err != nil {
+		if errors.Is(err, engine.ErrKeyNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	n, _ := binary.Uvarint(v)
+	return n, nil
+}
+
+// addUint adds delta to the uvarint stored under k, and writes
+// the result to the batch. The key is removed when the value reaches 0.
+func (idx *Index) addUint(b *tree.Batch, k *tree.Key, delta int64) error {
+	n, err := idx.getUint(k)
+	if err != nil {
+		return err
+	}
+
+	n = uint64(int64(n) + delta)
+	if int64(n) <= 0 {
+		return b.Delete(k)
+	}
+
+	return b.Put(k, binary.AppendUvarint(nil, n))
+}
+
+func (idx *Index) fulltextStats() (docCount, total uint64, err error) {
+	v, err := idx.Tree.Get(fulltextStatsKey())
+	if err != nil {
+		if errors.Is(err, engine.ErrKeyNotFound) {
+			return 0, 0, nil
+		}
+		return 0, 0, err
+	}
+
+	docCount, n := binary.Uvarint(v)
+	if n <= 0 {
+		return 0, 0, nil
+	}
+	total, _ = binary.Uvarint(v[n:])
+	return docCount, total, nil
+}
+
+func (idx *Index) putFulltextStats(b *tree.Batch, docCount, total uint64) error {
+	buf := binary.AppendUvarint(nil, docCount)
+	return b.Put(fulltextStatsKey(), binary.AppendUvarint(buf, total))
+}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"hash/fnv"
+
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// A hash index stores a 64-bit hash of the indexed values
+// instead of the values themselves:
+//
+//	k: <hash>, <primary key>  v: nil
+//
+// Keys are shorter than those of a regular index, but entries
+// are not ordered by value and different values may share the same hash:
+// the index can only be used to look up rows with an equality condition,
+// which must still be evaluated against each row.
+func hashValues(vs []types.Value) (int64, error) {
+	b, err := types.EncodeValuesAsKey(nil, vs...)
+	if err != nil {
+		return 0, err
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write(b)
+	return int64(h.Sum64()), nil
+}
+
+// setHash associates the hash of the values with the key.
+func (idx *Index) setHash(vs []types.Value, key []byte) error {
+	h, err := hashValues(idx.collate(vs))
+	if err != nil {
+		return err
+	}
+
+	return idx.Tree.Put(tree.NewKey(types.NewBigintValue(h), types.NewBlobValue(key)), nil)
+}
+
+// deleteHash removes the association between the hash of the values and the key.
+func (idx *Index) deleteHash(vs []types.Value, key []byte) error {
+	h, err := hashValues(idx.collate(vs))
+	if err != nil {
+		return err
+	}
+
+	k := tree.NewKey(types.NewBigintValue(h), types.NewBlobValue(key))
+	ok, err := idx.Tree.Exists(k)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.WithStack(engine.ErrKeyNotFound)
+	}
+
+	return idx.Tree.Delete(k)
+}
+
+// HashRange returns the range of the entries of a hash index
+// associated with the given values.
+// The TEXT values of collated columns must be collation keys.
+func (idx *Index) HashRange(vs []types.Value) (*tree.Range, error) {
+	if !idx.Hash {
+		return nil, errors.New("not a hash index")
+	}
+	if len(vs) != idx.Arity {
+		return nil, errors.Errorf("hash indexes can only be looked up with %d values, got %d", idx.Arity, len(vs))
+	}
+
+	h, err := hashValues(vs)
+	if err != nil {
+		return nil, err
+	}
+
+	k := tree.NewKey(types.NewBigintValue(h))
+	return &tree.Range{Min: k, Max: k}, nil
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 
+	"github.com/chaisql/chai/internal/collation"
 	"github.com/chaisql/chai/internal/engine"
 	"github.com/chaisql/chai/internal/tree"
 	"github.com/chaisql/chai/internal/types"
@@ -25,13 +26,31 @@ type Index struct {
 	// For example, an index created with `CREATE INDEX idx_a_b ON foo (a, b)` has an arity of 2.
 	Arity int
 	Tree  *tree.Tree
+	// If set, the index associates the terms of a TEXT value with keys.
+	// See Search and Score.
+	Fulltext bool
+	// Name of the analyzer used to extract the terms of a full-text index.
+	Analyzer string
+	// If set, the index associates the grid cells of a point with keys.
+	// See SearchBox.
+	Spatial bool
+	// If set, the index associates a hash of the values with keys.
+	// See hashKey.
+	Hash bool
+	// Collation of each indexed column, nil for columns compared bytewise.
+	// TEXT values are stored as collation keys. See collate.
+	Collations []*collation.Collation
 }
 
 // NewIndex creates an index that associates values with a list of keys.
 func NewIndex(tr *tree.Tree, opts IndexInfo) *Index {
 	return &Index{
-		Tree:  tr,
-		Arity: len(opts.Columns),
+		Tree:     tr,
+		Arity:    len(opts.Columns),
+		Fulltext: opts.Fulltext,
+		Analyzer: opts.Analyzer,
+		Spatial:  opts.Spatial,
+		Hash:     opts.Hash,
 	}
 }
 
@@ -59,8 +78,19 @@ func (idx *Index) Set(vs []types.Value, key []byte) error {
 		return fmt.Errorf("cannot index %d values on an index of arity %d", len(vs), idx.Arity)
 	}
 
+	if idx.Fulltext {
+		return idx.setFulltext(vs[0], key)
+	}
+	if idx.Spatial {
+		return idx.setSpatial(vs[0], key)
+	}
+
+	if idx.Hash {
+		return idx.setHash(vs, key)
+	}
+
 	// append the key to the values
-	values := append(vs, types.NewBlobValue(key))
+	values := append(idx.collate(vs), types.NewBlobValue(key))
 
 	// create the key for the tree
 	treeKey := tree.NewKey(values...)
@@ -74,7 +104,7 @@ func (idx *Index) Exists(vs []types.Value) (bool, *tree.Key, error) {
 		return false, nil, fmt.Errorf("required arity of %d", idx.Arity)
 	}
 
-	seek := tree.NewKey(vs...)
+	seek := tree.NewKey(idx.collate(vs)...)
 
 	var found bool
 	var dKey *tree.Key
@@ -97,7 +127,17 @@ func (idx *Index) Exists(vs []types.Value) (bool, *tree.Key, error) {
 
 // Delete all the references to the key from the index.
 func (idx *Index) Delete(vs []types.Value, key []byte) error {
-	vk := tree.NewKey(vs...)
+	if idx.Fulltext {
+		return idx.deleteFulltext(vs[0], key)
+	}
+	if idx.Spatial {
+		return idx.deleteSpatial(vs[0], key)
+	}
+	if idx.Hash {
+		return idx.deleteHash(vs, key)
+	}
+
+	vk := tree.NewKey(idx.collate(vs)...)
 	rng := tree.Range{
 		Min: vk,
 		Max: vk,
@@ -125,12 +165,46 @@ func (idx *Index) Delete(vs []types.Value, key []byte) error {
 	return errors.WithStack(engine.ErrKeyNotFound)
 }
 
+// collate replaces the TEXT values of collated columns
+// by their collation key. Ranges used to read the index must
+// be built from collation keys as well.
+func (idx *Index) collate(vs []types.Value) []types.Value {
+	if idx.Collations == nil {
+		return vs
+	}
+
+	cvs := make([]types.Value, len(vs))
+	for i, v := range vs {
+		if i < len(idx.Collations) && idx.Collations[i] != nil && v.Type() == types.TypeText {
+			v = types.NewTextValue(idx.Collations[i].Key(types.AsString(v)))
+		}
+		cvs[i] = v
+	}
+
+	return cvs
+}
+
 func (idx *Index) IterateOnRange(rng *tree.Range, reverse bool, fn func(key *tree.Key) error) error {
 	return idx.iterateOnRange(rng, reverse, func(itmKey, key *tree.Key) error {
 		return fn(key)
 	})
 }
 
+// IterateValuesOnRange is like IterateOnRange but also passes the values stored
+// in the index for each key. The TEXT values of collated columns are collation keys.
+func (idx *Index) IterateValuesOnRange(rng *tree.Range, reverse bool, fn func(values []types.Value, key *tree.Key) error) error {
+	return idx.Tree.IterateOnRange(rng, reverse, func(k *tree.Key, _ []byte) error {
+		values, err := k.Decode()
+		if err != nil {
+			return err
+		}
+
+		pk := tree.NewEncodedKey(types.AsByteSlice(values[len(values)-1]))
+
+		return fn(values[:len(values)-1], pk)
+	})
+}
+
 func (idx *Index) iterateOnRange(rng *tree.Range, reverse bool, fn func(itmKey *tree.Key, key *tree.Key) error) error {
 	return idx.Tree.IterateOnRange(rng, reverse, idx.iterator(fn))
 }
@@ -155,6 +155,64 @@ func TestIndexExists(t *testing.T) {
 }
 
 // BenchmarkIndexSet benchmarks the Set method with 1, 10, 1000 and 10000 successive insertions.
+func TestFulltextIndex(t *testing.T) {
+	idx := getIndex(t, 1)
+	idx.Fulltext = true
+
+	text := types.NewTextValue
+	require.NoError(t, idx.Set(values(text("the quick brown fox")), []byte("a")))
+	require.NoError(t, idx.Set(values(text("the lazy dog")), []byte("b")))
+	require.NoError(t, idx.Set(values(text("fox fox fox")), []byte("c")))
+	require.NoError(t, idx.Set(values(types.NewNullValue()), []byte("d")))
+
+	t.Run("Search", func(t *testing.T) {
+		keys, err := idx.Search([]string{"fox"})
+		require.NoError(t, err)
+		require.Equal(t, [][]byte{[]byte("a"), []byte("c")}, keys)
+
+		keys, err = idx.Search([]string{"the", "dog"})
+		require.NoError(t, err)
+		require.Equal(t, [][]byte{[]byte("b")}, keys)
+
+		keys, err = idx.Search([]string{"cat"})
+		require.NoError(t, err)
+		require.Empty(t, keys)
+	})
+
+	t.Run("Score", func(t *testing.T) {
+		a, err := idx.Score([]string{"fox"}, []byte("a"))
+		require.NoError(t, err)
+		c, err := idx.Score([]string{"fox"}, []byte("c"))
+		require.NoError(t, err)
+		b, err := idx.Score([]string{"fox"}, []byte("b"))
+		require.NoError(t, err)
+
+		require.Greater(t, c, a)
+		require.Zero(t, b)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		require.NoError(t, idx.Delete(values(text("fox fox fox")), []byte("c")))
+		require.NoError(t, idx.Delete(values(types.NewNullValue()), []byte("d")))
+
+		keys, err := idx.Search([]string{"fox"})
+		require.NoError(t, err)
+		require.Equal(t, [][]byte{[]byte("a")}, keys)
+
+		// only the statistics of the index remain
+		require.NoError(t, idx.Delete(values(text("the quick brown fox")), []byte("a")))
+		require.NoError(t, idx.Delete(values(text("the lazy dog")), []byte("b")))
+
+		var n int
+		err = idx.Tree.IterateOnRange(nil, false, func(*tree.Key, []byte) error {
+			n++
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+	})
+}
+
 func BenchmarkIndexSet(b *testing.B) {
 	for size := 10; size <= 10000; size *= 10 {
 		b.Run(fmt.Sprintf("%.05d", size), func(b *testing.B) {
@@ -237,3 +295,39 @@ func BenchmarkCompositeIndexIteration(b *testing.B) {
 		})
 	}
 }
+
+func TestHashIndex(t *testing.T) {
+	idx := getIndex(t, 2)
+	idx.Hash = true
+
+	text := types.NewTextValue
+	require.NoError(t, idx.Set(values(text("a"), types.NewIntegerValue(1)), []byte("a")))
+	require.NoError(t, idx.Set(values(text("a"), types.NewIntegerValue(1)), []byte("b")))
+	require.NoError(t, idx.Set(values(text("a"), types.NewIntegerValue(2)), []byte("c")))
+
+	lookup := func(vs ...types.Value) []string {
+		t.Helper()
+
+		rng, err := idx.HashRange(vs)
+		require.NoError(t, err)
+
+		var keys []string
+		err = idx.IterateOnRange(rng, false, func(key *tree.Key) error {
+			keys = append(keys, string(key.Encoded))
+			return nil
+		})
+		require.NoError(t, err)
+		return keys
+	}
+
+	require.Equal(t, []string{"a", "b"}, lookup(text("a"), types.NewIntegerValue(1)))
+	require.Equal(t, []string{"c"}, lookup(text("a"), types.NewIntegerValue(2)))
+	require.Empty(t, lookup(text("b"), types.NewIntegerValue(1)))
+
+	_, err := idx.HashRange(values(text("a")))
+	require.Error(t, err)
+
+	require.NoError(t, idx.Delete(values(text("a"), types.NewIntegerValue(1)), []byte("a")))
+	require.Equal(t, []string{"b"}, lookup(text("a"), types.NewIntegerValue(1)))
+	require.Error(t, idx.Delete(values(text("a"), types.NewIntegerValue(1)), []byte("a")))
+}
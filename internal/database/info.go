@@ -7,6 +7,8 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/chaisql/chai/internal/collation"
+	"github.com/chaisql/chai/internal/row"
 	"github.com/chaisql/chai/internal/stringutil"
 	"github.com/chaisql/chai/internal/tree"
 	"github.com/chaisql/chai/internal/types"
@@ -28,6 +30,13 @@ type TableInfo struct {
 	TableConstraints  TableConstraints
 
 	PrimaryKey *PrimaryKey
+
+	// Name of the TIMESTAMP column holding the expiration time of each row, if any.
+	// Expired rows are deleted in the background. See Database.DeleteExpiredRows.
+	TTLColumn string
+	// Name of the table the expired rows are moved to, if any.
+	// If empty, expired rows are deleted.
+	ArchiveTable string
 }
 
 func (ti *TableInfo) AddColumnConstraint(newCc *ColumnConstraint) error {
@@ -101,7 +110,7 @@ func (ti *TableInfo) AddTableConstraint(newTc *TableConstraint) error {
 
 		// generate name if not provided
 		if newTc.Name == "" {
-			newTc.Name = fmt.Sprintf("%s_%s_unique", ti.TableName, columnsToIndexName(newTc.Columns))
+			newTc.Name = fmt.Sprintf("%s_%s_unique", ti.TableName, strings.Join(newTc.Columns, "_"))
 		}
 	default:
 		return errors.New("invalid table constraint")
@@ -177,6 +186,33 @@ func (ti *TableInfo) GetColumnConstraint(column string) *ColumnConstraint {
 	return ti.ColumnConstraints.GetColumnConstraint(column)
 }
 
+// Collations returns the collation of each of the given columns,
+// or nil if they are all compared bytewise.
+func (ti *TableInfo) Collations(columns []string) ([]*collation.Collation, error) {
+	var list []*collation.Collation
+	for i, column := range columns {
+		cc := ti.GetColumnConstraint(column)
+		if cc == nil || cc.Collation == "" {
+			continue
+		}
+
+		c, err := collation.Lookup(cc.Collation)
+		if err != nil {
+			return nil, err
+		}
+		if c.IsBinary() {
+			continue
+		}
+
+		if list == nil {
+			list = make([]*collation.Collation, len(columns))
+		}
+		list[i] = c
+	}
+
+	return list, nil
+}
+
 func (ti *TableInfo) EncodeKey(key *tree.Key) ([]byte, error) {
 	var order tree.SortOrder
 	if ti.PrimaryKey != nil {
@@ -187,6 +223,18 @@ func (ti *TableInfo) EncodeKey(key *tree.Key) ([]byte, error) {
 }
 
 // String returns a SQL representation.
+// formatVersion returns the oldest format version able to read the table.
+func (ti *TableInfo) formatVersion() uint64 {
+	for _, cc := range ti.ColumnConstraints.Ordered {
+		switch {
+		case cc.Type == types.TypeDecimal, cc.Type == types.TypeUUID, cc.Bits > 0, len(cc.Enum) > 0:
+			return formatV2
+		}
+	}
+
+	return formatV1
+}
+
 func (ti *TableInfo) String() string {
 	var s strings.Builder
 
@@ -213,6 +261,14 @@ func (ti *TableInfo) String() string {
 
 	s.WriteString(")")
 
+	if ti.TTLColumn != "" {
+		fmt.Fprintf(&s, " WITH (ttl_column = '%s'", ti.TTLColumn)
+		if ti.ArchiveTable != "" {
+			fmt.Fprintf(&s, ", archive_table = '%s'", ti.ArchiveTable)
+		}
+		s.WriteString(")")
+	}
+
 	return s.String()
 }
 
@@ -243,12 +299,38 @@ type IndexInfo struct {
 	IndexName      string
 	Columns        []string
 
+	// Expressions indexed in place of columns.
+	// If Exprs[i] is not nil, the i-th part of the key is the result
+	// of the expression and Columns[i] holds its SQL representation.
+	Exprs []TableExpression
+
 	// Sort order of each indexed field.
 	KeySortOrder tree.SortOrder
 
 	// If set to true, values will be associated with at most one key. False by default.
 	Unique bool
 
+	// If set to true, NULL values are considered equal by the unique constraint
+	// of the index: only one row can have NULL values for the indexed columns.
+	// By default, several rows can have NULL values.
+	NullsNotDistinct bool
+
+	// If set to true, the index stores the terms of a TEXT column
+	// and is used to evaluate MATCH conditions.
+	Fulltext bool
+
+	// Name of the analyzer of a full-text index.
+	// If empty, the default analyzer is used.
+	Analyzer string
+
+	// If set to true, the index stores the points of a TEXT column
+	// and is used to evaluate spatial functions such as st_dwithin.
+	Spatial bool
+
+	// If set to true, the index stores a hash of the values instead of the values
+	// themselves and can only be used to evaluate equality conditions.
+	Hash bool
+
 	// If set, this index has been created from a table constraint
 	// i.e CREATE TABLE tbl(a INT UNIQUE)
 	// The path refers to the path this index is related to.
@@ -263,6 +345,12 @@ func (idx *IndexInfo) String() string {
 	if idx.Unique {
 		s.WriteString("UNIQUE ")
 	}
+	if idx.Fulltext {
+		s.WriteString("FULLTEXT ")
+	}
+	if idx.Spatial {
+		s.WriteString("SPATIAL ")
+	}
 
 	fmt.Fprintf(&s, "INDEX %s ON %s (", stringutil.NormalizeIdentifier(idx.IndexName, '`'), stringutil.NormalizeIdentifier(idx.Owner.TableName, '`'))
 
@@ -281,6 +369,18 @@ func (idx *IndexInfo) String() string {
 
 	s.WriteString(")")
 
+	if idx.NullsNotDistinct {
+		s.WriteString(" NULLS NOT DISTINCT")
+	}
+
+	if idx.Hash {
+		s.WriteString(" USING HASH")
+	}
+
+	if idx.Analyzer != "" {
+		fmt.Fprintf(&s, " WITH (analyzer = '%s')", idx.Analyzer)
+	}
+
 	return s.String()
 }
 
@@ -291,9 +391,78 @@ func (i IndexInfo) Clone() *IndexInfo {
 	c.Columns = make([]string, len(i.Columns))
 	copy(c.Columns, i.Columns)
 
+	if i.Exprs != nil {
+		c.Exprs = make([]TableExpression, len(i.Exprs))
+		copy(c.Exprs, i.Exprs)
+	}
+
 	return &c
 }
 
+// IsExpr reports whether the i-th part of the key is an expression.
+func (idx *IndexInfo) IsExpr(i int) bool {
+	return i < len(idx.Exprs) && idx.Exprs[i] != nil
+}
+
+// KeyValues returns the values of the row stored in the index,
+// in the order of the key. Missing columns are indexed as NULL.
+func (idx *IndexInfo) KeyValues(tx *Transaction, r row.Row) ([]types.Value, error) {
+	vs := make([]types.Value, 0, len(idx.Columns))
+	for i, column := range idx.Columns {
+		if idx.IsExpr(i) {
+			v, err := idx.Exprs[i].Eval(tx, r)
+			if err != nil {
+				return nil, err
+			}
+			vs = append(vs, v)
+			continue
+		}
+
+		v, err := r.Get(column)
+		if err != nil {
+			v = types.NewNullValue()
+		}
+		vs = append(vs, v)
+	}
+
+	return vs, nil
+}
+
+// validateKey ensures the indexed columns exist and that
+// the indexed expressions only refer to columns of the table.
+func (idx *IndexInfo) validateKey(ti *TableInfo) error {
+	for i, column := range idx.Columns {
+		if idx.IsExpr(i) {
+			err := idx.Exprs[i].Validate(ti)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if ti.GetColumnConstraint(column) == nil {
+			return errors.Errorf("field %q does not exist for table %q", column, ti.TableName)
+		}
+	}
+
+	return nil
+}
+
+// keyName returns a name describing the key of the index,
+// used to generate the name of the index.
+func (idx *IndexInfo) keyName() string {
+	parts := make([]string, len(idx.Columns))
+	for i, column := range idx.Columns {
+		if idx.IsExpr(i) {
+			parts[i] = "expr"
+		} else {
+			parts[i] = column
+		}
+	}
+
+	return strings.Join(parts, "_")
+}
+
 // SequenceInfo holds the configuration of a sequence.
 type SequenceInfo struct {
 	Name        string
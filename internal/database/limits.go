@@ -0,0 +1,117 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// ErrLimitExceeded is wrapped by the errors returned when writing a row
+// that exceeds one of the limits set by Options.MaxRowSize,
+// Options.MaxDocumentDepth or Options.MaxDocumentFields.
+var ErrLimitExceeded = errors.New("limit exceeded")
+
+// limitError describes the limit exceeded by a row.
+type limitError struct {
+	msg string
+}
+
+func newLimitError(format string, args ...any) error {
+	return errors.WithStack(&limitError{msg: fmt.Sprintf(format, args...)})
+}
+
+func (e *limitError) Error() string {
+	return e.msg
+}
+
+func (e *limitError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+// checkRowSize returns an error if the encoded row is larger than Options.MaxRowSize.
+func (tx *Transaction) checkRowSize(size int) error {
+	if tx == nil || tx.db == nil || tx.db.opts == nil || tx.db.opts.MaxRowSize <= 0 {
+		return nil
+	}
+
+	if size > tx.db.opts.MaxRowSize {
+		return newLimitError("row size of %d bytes exceeds the limit of %d bytes", size, tx.db.opts.MaxRowSize)
+	}
+
+	return nil
+}
+
+// checkDocument returns an error if v is a JSON object or array nested deeper than
+// Options.MaxDocumentDepth or with more members than Options.MaxDocumentFields.
+func (tx *Transaction) checkDocument(column string, v types.Value) error {
+	if tx == nil || tx.db == nil || tx.db.opts == nil || v.Type() != types.TypeText {
+		return nil
+	}
+	maxDepth, maxFields := tx.db.opts.MaxDocumentDepth, tx.db.opts.MaxDocumentFields
+	if maxDepth <= 0 && maxFields <= 0 {
+		return nil
+	}
+
+	s := types.AsString(v)
+	depth, fields := measureDocument(s)
+	if (maxDepth <= 0 || depth <= maxDepth) && (maxFields <= 0 || fields <= maxFields) {
+		return nil
+	}
+
+	// texts that are not JSON documents are not limited
+	if !json.Valid([]byte(s)) {
+		return nil
+	}
+
+	if maxDepth > 0 && depth > maxDepth {
+		return newLimitError("column %s: document nesting depth of %d exceeds the limit of %d", column, depth, maxDepth)
+	}
+
+	return newLimitError("column %s: document with %d fields exceeds the limit of %d fields", column, fields, maxFields)
+}
+
+// measureDocument returns the maximum nesting depth of the objects and arrays
+// of a JSON document, and the total number of members of its objects.
+// It returns zeros if s doesn't start with an object or an array.
+func measureDocument(s string) (maxDepth, fields int) {
+	var depth int
+	var inString, escaped bool
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case ' ', '\t', '\n', '\r':
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+			maxDepth = max(maxDepth, depth)
+		case '}', ']':
+			depth--
+		case ':':
+			// outside of strings, colons separate the keys of the members from their values
+			fields++
+		default:
+			if maxDepth == 0 {
+				// scalar document
+				return 0, 0
+			}
+		}
+	}
+
+	return maxDepth, fields
+}
@@ -0,0 +1,53 @@
+package database_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimits(t *testing.T) {
+	db, err := chai.OpenWith(":memory:", &chai.Options{
+		MaxRowSize:        100,
+		MaxDocumentDepth:  2,
+		MaxDocumentFields: 3,
+	})
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE test(id INT PRIMARY KEY, doc TEXT)")
+	require.NoError(t, err)
+
+	// within the limits
+	for i, doc := range []string{
+		`{"a": {"b": 1}, "c": [1, 2]}`,
+		`[[1], [2]]`,
+		`{"a": "{[{[:::"}`,
+		`[[[[ not JSON`,
+		"::::",
+	} {
+		err = db.Exec("INSERT INTO test VALUES (?, ?)", i, doc)
+		require.NoError(t, err, doc)
+	}
+
+	tests := []struct {
+		doc string
+		err string
+	}{
+		{`{"a": {"b": [1]}}`, "column doc: document nesting depth of 3 exceeds the limit of 2"},
+		{`[[[]]]`, "column doc: document nesting depth of 3 exceeds the limit of 2"},
+		{`{"a": 1, "b": 2, "c": {"d": 3}}`, "column doc: document with 4 fields exceeds the limit of 3 fields"},
+		{strings.Repeat("a", 100), "row size of 103 bytes exceeds the limit of 100 bytes"},
+	}
+
+	for _, test := range tests {
+		err = db.Exec("INSERT INTO test VALUES (10, ?)", test.doc)
+		require.ErrorIs(t, err, chai.ErrLimitExceeded)
+		require.EqualError(t, err, test.err)
+
+		err = db.Exec("UPDATE test SET doc = ? WHERE id = 0", test.doc)
+		require.ErrorIs(t, err, chai.ErrLimitExceeded)
+	}
+}
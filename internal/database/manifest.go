@@ -0,0 +1,185 @@
+package database
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/cockroachdb/errors"
+)
+
+// Versions of the on-disk format.
+const (
+	// formatV1 is the first format version.
+	formatV1 uint64 = 1 + iota
+	// formatV2 adds the values stored outside of the rows (see Options.BlobThreshold),
+	// the DECIMAL and UUID types, the ENUM constraints and the integer types
+	// of explicit width. Databases are only required to be read by version 2
+	// readers once one of them is used.
+	formatV2
+)
+
+// FormatVersion is the version of the on-disk format
+// written by this version of Chai.
+// It must be incremented every time the encoding of the catalog
+// or of the stored data changes in a way older versions can't read,
+// and a migration must be registered in formatMigrations.
+// The MinReaderVersion of the manifest of a database is raised to the version
+// adding a feature when the feature is first used, see Transaction.requireFormat.
+const FormatVersion = formatV2
+
+// formatMigrations upgrades a database from the format
+// version used as key to the next one.
+var formatMigrations = map[uint64]func(tx *Transaction) error{
+	// version 2 only adds new encodings: the existing data is unchanged.
+	formatV1: func(tx *Transaction) error { return nil },
+}
+
+// Manifest describes the format of a database.
+// It is stored in the ManifestNamespace and checked every time
+// the database is opened.
+type Manifest struct {
+	// Format version used to write the database.
+	WriterVersion uint64
+	// Minimum format version a reader must support to open the database.
+	MinReaderVersion uint64
+}
+
+func (m *Manifest) encode() []byte {
+	buf := binary.AppendUvarint(nil, m.WriterVersion)
+	return binary.AppendUvarint(buf, m.MinReaderVersion)
+}
+
+func (m *Manifest) decode(b []byte) error {
+	var n int
+
+	m.WriterVersion, n = binary.Uvarint(b)
+	if n <= 0 {
+		return errors.New("invalid manifest")
+	}
+	m.MinReaderVersion, n = binary.Uvarint(b[n:])
+	if n <= 0 {
+		return errors.New("invalid manifest")
+	}
+
+	return nil
+}
+
+// IncompatibleFormatError is returned when opening a database
+// whose format is not supported by this version of Chai.
+type IncompatibleFormatError struct {
+	Manifest Manifest
+}
+
+func (e *IncompatibleFormatError) Error() string {
+	if e.NeedsUpgrade() {
+		return fmt.Sprintf("database format version %d is older than the supported version %d, run \"chai upgrade\" to migrate it", e.Manifest.WriterVersion, FormatVersion)
+	}
+
+	return fmt.Sprintf("database requires format version %d, this version of Chai supports up to version %d", e.Manifest.MinReaderVersion, FormatVersion)
+}
+
+// NeedsUpgrade returns true if the database was written by an older version
+// of Chai and can be migrated.
+func (e *IncompatibleFormatError) NeedsUpgrade() bool {
+	return e.Manifest.MinReaderVersion <= FormatVersion && e.Manifest.WriterVersion < FormatVersion
+}
+
+// IsIncompatibleFormatError determines if the error is an IncompatibleFormatError.
+func IsIncompatibleFormatError(err error) bool {
+	var e *IncompatibleFormatError
+	return errors.As(err, &e)
+}
+
+func manifestKey() []byte {
+	return encoding.EncodeInt(nil, int64(ManifestNamespace))
+}
+
+// loadManifest ensures the database format is compatible
+// with this version of Chai. Databases created before the manifest
+// existed use the first format version.
+// New databases can be read by the readers of the first format version,
+// until they use a feature added since.
+// If upgrade is true, older databases are migrated to FormatVersion.
+func loadManifest(tx *Transaction, upgrade bool) error {
+	m := Manifest{
+		WriterVersion:    FormatVersion,
+		MinReaderVersion: formatV1,
+	}
+
+	v, err := tx.Session.Get(manifestKey())
+	if err != nil && !errors.Is(err, engine.ErrKeyNotFound) {
+		return err
+	}
+	if err == nil {
+		err = m.decode(v)
+		if err != nil {
+			return err
+		}
+	}
+	tx.db.minReaderVersion.Store(m.MinReaderVersion)
+
+	if m.MinReaderVersion > FormatVersion {
+		return errors.WithStack(&IncompatibleFormatError{Manifest: m})
+	}
+
+	if m.WriterVersion < FormatVersion {
+		if !upgrade {
+			return errors.WithStack(&IncompatibleFormatError{Manifest: m})
+		}
+
+		for ver := m.WriterVersion; ver < FormatVersion; ver++ {
+			fn, ok := formatMigrations[ver]
+			if !ok {
+				return errors.Errorf("no migration from format version %d", ver)
+			}
+
+			err = fn(tx)
+			if err != nil {
+				return errors.Wrapf(err, "failed to migrate from format version %d", ver)
+			}
+		}
+
+		// the migrations raise MinReaderVersion with requireFormat
+		// if they use features of their version.
+		m.WriterVersion = FormatVersion
+	} else if v != nil {
+		return nil
+	}
+
+	return tx.Session.Put(manifestKey(), m.encode())
+}
+
+// requireFormat raises the MinReaderVersion of the manifest to version,
+// if lower, so that the versions of Chai that can't read a feature
+// used by the transaction refuse to open the database.
+func (tx *Transaction) requireFormat(version uint64) error {
+	if version <= formatV1 || tx.db == nil || tx.db.minReaderVersion.Load() >= version {
+		return nil
+	}
+
+	var m Manifest
+	v, err := tx.Session.Get(manifestKey())
+	if err != nil {
+		return err
+	}
+	err = m.decode(v)
+	if err != nil {
+		return err
+	}
+	if m.MinReaderVersion >= version {
+		return nil
+	}
+
+	m.MinReaderVersion = version
+	err = tx.Session.Put(manifestKey(), m.encode())
+	if err != nil {
+		return err
+	}
+
+	tx.OnCommitHooks = append(tx.OnCommitHooks, func() {
+		tx.db.minReaderVersion.Store(version)
+	})
+	return nil
+}
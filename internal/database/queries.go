@@ -0,0 +1,130 @@
+package database
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	errs "github.com/chaisql/chai/internal/errors"
+	"github.com/cockroachdb/errors"
+)
+
+var (
+	// ErrQueryCanceled is returned by queries canceled with CancelQuery.
+	ErrQueryCanceled = errors.New("query canceled")
+
+	// ErrStatementTimeout is returned by queries running
+	// for longer than the statement timeout.
+	ErrStatementTimeout = errors.New("statement timeout")
+)
+
+// A RunningQuery describes a query run by a connection of the database.
+type RunningQuery struct {
+	ID      uint64
+	SQL     string
+	Started time.Time
+
+	cancel context.CancelCauseFunc
+}
+
+// SetStatementTimeout sets the maximum duration of the queries run by the connection,
+// overriding the statement timeout of the database.
+// If zero, the statement timeout of the database is used.
+// If negative, queries have no timeout.
+func (c *Connection) SetStatementTimeout(d time.Duration) {
+	c.statementTimeout = d
+}
+
+// StatementTimeout returns the maximum duration of the queries run by the connection,
+// or zero if they have no timeout.
+func (c *Connection) StatementTimeout() time.Duration {
+	d := c.statementTimeout
+	if d == 0 {
+		d = c.db.opts.StatementTimeout
+	}
+	if d < 0 {
+		return 0
+	}
+
+	return d
+}
+
+// StartQuery registers the query in the list of running queries of the database.
+// It returns a context that is done when the query is canceled or times out,
+// and a function that must be called once the query is done.
+func (c *Connection) StartQuery(ctx context.Context, sql string) (context.Context, func()) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	stop := func() {}
+	if d := c.StatementTimeout(); d > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeoutCause(ctx, d, ErrStatementTimeout)
+		stop = cancelTimeout
+	}
+
+	db := c.db
+	q := RunningQuery{
+		ID:      db.queryIDs.Add(1),
+		SQL:     sql,
+		Started: time.Now(),
+		cancel:  cancel,
+	}
+
+	db.queriesMu.Lock()
+	if db.queries == nil {
+		db.queries = make(map[uint64]*RunningQuery)
+	}
+	db.queries[q.ID] = &q
+	db.queriesMu.Unlock()
+
+	return ctx, func() {
+		db.queriesMu.Lock()
+		delete(db.queries, q.ID)
+		db.queriesMu.Unlock()
+
+		stop()
+		cancel(nil)
+	}
+}
+
+// RunningQueries returns the queries currently run by the connections
+// of the database, ordered by id.
+func (db *Database) RunningQueries() []RunningQuery {
+	db.queriesMu.Lock()
+	defer db.queriesMu.Unlock()
+
+	queries := make([]RunningQuery, 0, len(db.queries))
+	for _, q := range db.queries {
+		queries = append(queries, RunningQuery{
+			ID:      q.ID,
+			SQL:     q.SQL,
+			Started: q.Started,
+		})
+	}
+
+	slices.SortFunc(queries, func(a, b RunningQuery) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return queries
+}
+
+// CancelQuery cancels the running query with the given id.
+// The query stops with ErrQueryCanceled the next time it reads a row.
+func (db *Database) CancelQuery(id uint64) error {
+	db.queriesMu.Lock()
+	defer db.queriesMu.Unlock()
+
+	q, ok := db.queries[id]
+	if !ok {
+		return errs.NewNotFoundError(fmt.Sprintf("query %d", id))
+	}
+
+	q.cancel(ErrQueryCanceled)
+	return nil
+}
@@ -0,0 +1,276 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"sync"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// DefaultReIndexBatchSize is the number of rows indexed by each transaction
+// of ReIndex, if ReIndexOptions.BatchSize is not set.
+const DefaultReIndexBatchSize = 10_000
+
+// ReIndexOptions configures how indexes are rebuilt by ReIndex and ReIndexAll.
+type ReIndexOptions struct {
+	// Maximum number of rows indexed by each transaction.
+	// If zero, DefaultReIndexBatchSize is used.
+	BatchSize int
+
+	// If set, Progress is called after each batch with the number
+	// of rows indexed so far and the number of rows of the table.
+	Progress func(indexName string, indexed, total int)
+}
+
+// ReIndex rebuilds an index in several transactions, each of them indexing
+// at most opts.BatchSize rows, unlike the REINDEX statement which rebuilds
+// it in a single transaction.
+// The rows are indexed in a new tree which replaces the previous one
+// once all the rows have been indexed: until then, queries keep using
+// the previous tree. Other write transactions are blocked until the
+// index is rebuilt.
+// If ctx is done, ReIndex stops after the current batch and the index
+// keeps its previous tree.
+func (db *Database) ReIndex(ctx context.Context, indexName string, opts *ReIndexOptions) error {
+	if opts == nil {
+		opts = new(ReIndexOptions)
+	}
+
+	db.writetxmu.Lock()
+	defer db.writetxmu.Unlock()
+
+	return db.reIndex(ctx, indexName, opts)
+}
+
+// ReIndexAll rebuilds all the indexes of the database, one after the other.
+// See ReIndex.
+func (db *Database) ReIndexAll(ctx context.Context, opts *ReIndexOptions) error {
+	if opts == nil {
+		opts = new(ReIndexOptions)
+	}
+
+	db.writetxmu.Lock()
+	defer db.writetxmu.Unlock()
+
+	for _, name := range db.Catalog().Cache.ListObjects(RelationIndexType) {
+		err := db.reIndex(ctx, name, opts)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rebuildPendingIndexes rebuilds the indexes deferred by transactions
+// committed before the database was last closed, whose rebuild didn't complete.
+func (db *Database) rebuildPendingIndexes(ctx context.Context) error {
+	db.writetxmu.Lock()
+	defer db.writetxmu.Unlock()
+
+	var names []string
+	err := db.lockedUpdate(func(tx *Transaction) error {
+		return tree.New(tx.Session, PendingIndexNamespace, 0).IterateOnRange(nil, false, func(k *tree.Key, _ []byte) error {
+			vs, err := k.Decode()
+			if err != nil {
+				return err
+			}
+
+			names = append(names, types.AsString(vs[0]))
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		err = db.reIndex(ctx, name, &ReIndexOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "failed to rebuild index %s", name)
+		}
+	}
+
+	return nil
+}
+
+// markPendingIndex records that the index must be rebuilt,
+// until replaceIndexStore or dropIndex clears it.
+func markPendingIndex(tx *Transaction, name string) error {
+	return tree.New(tx.Session, PendingIndexNamespace, 0).Put(tree.NewKey(types.NewTextValue(name)), nil)
+}
+
+func clearPendingIndex(tx *Transaction, name string) error {
+	err := tree.New(tx.Session, PendingIndexNamespace, 0).Delete(tree.NewKey(types.NewTextValue(name)))
+	if errors.Is(err, engine.ErrKeyNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (db *Database) reIndex(ctx context.Context, indexName string, opts *ReIndexOptions) error {
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultReIndexBatchSize
+	}
+
+	// allocate the namespace of the new tree
+	// and count the rows to index
+	var ns tree.Namespace
+	var total int
+	err := db.lockedUpdate(func(tx *Transaction) error {
+		info, err := tx.Catalog.GetIndexInfo(indexName)
+		if err != nil {
+			return err
+		}
+
+		table, err := tx.Catalog.GetTable(tx, info.Owner.TableName)
+		if err != nil {
+			return err
+		}
+
+		ns, err = tx.CatalogWriter().generateStoreNamespace(tx)
+		if err != nil {
+			return err
+		}
+
+		return table.Tree.IterateOnRange(nil, false, func(*tree.Key, []byte) error {
+			total++
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	var last []byte
+	var indexed int
+	for {
+		var n int
+		err = context.Cause(ctx)
+		if err == nil {
+			err = db.lockedUpdate(func(tx *Transaction) error {
+				n, last, err = indexBatch(tx, indexName, ns, last, batchSize)
+				return err
+			})
+		}
+		if err != nil {
+			// the new tree isn't referenced by the catalog
+			_ = db.lockedUpdate(func(tx *Transaction) error {
+				return tree.New(tx.Session, ns, 0).Truncate()
+			})
+			return err
+		}
+
+		indexed += n
+		if opts.Progress != nil {
+			opts.Progress(indexName, indexed, total)
+		}
+
+		if n < batchSize {
+			break
+		}
+	}
+
+	return db.lockedUpdate(func(tx *Transaction) error {
+		return tx.CatalogWriter().replaceIndexStore(tx, indexName, ns)
+	})
+}
+
+// indexBatch indexes up to batchSize rows of the table, following the row
+// whose key is last, in the tree of the given namespace.
+// It returns the number of indexed rows and the key of the last of them.
+func indexBatch(tx *Transaction, indexName string, ns tree.Namespace, last []byte, batchSize int) (int, []byte, error) {
+	info, err := tx.Catalog.GetIndexInfo(indexName)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	table, err := tx.Catalog.GetTable(tx, info.Owner.TableName)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	idx := NewIndex(tree.New(tx.Session, ns, info.KeySortOrder), *info)
+	idx.Collations, err = table.Info.Collations(info.Columns)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var rng *tree.Range
+	if last != nil {
+		// keys are compared bytewise, the iteration resumes
+		// right after the last indexed row until the end of the table
+		end := append(encoding.EncodeInt(nil, int64(table.Info.StoreNamespace)), 0xFF)
+		rng = &tree.Range{Min: tree.NewEncodedKey(last), Max: tree.NewEncodedKey(end), Exclusive: true}
+	}
+
+	var n int
+	e := NewEncodedRow(&table.Info.ColumnConstraints, nil)
+	e.blobs = tx.BlobReader()
+	err = table.Tree.IterateOnRange(rng, false, func(k *tree.Key, enc []byte) error {
+		e.setEncoded(enc)
+		vs, err := info.KeyValues(tx, e)
+		if err != nil {
+			return err
+		}
+
+		encKey, err := table.Info.EncodeKey(k)
+		if err != nil {
+			return err
+		}
+
+		// the key is only valid during the iteration
+		encKey = bytes.Clone(encKey)
+		err = idx.Set(vs, encKey)
+		if err != nil {
+			return err
+		}
+
+		n++
+		last = encKey
+		if n == batchSize {
+			return errStop
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStop) {
+		return 0, nil, err
+	}
+
+	return n, last, nil
+}
+
+// lockedUpdate runs fn in a write transaction and commits it.
+// The caller must hold the write lock of the database for the duration
+// of all the transactions, which is why the transaction doesn't acquire it.
+func (db *Database) lockedUpdate(fn func(tx *Transaction) error) error {
+	if db.closeContext.Err() != nil {
+		return errors.New("database is closed")
+	}
+
+	db.txmu.RLock()
+	tx, err := db.beginTxUnlocked(&TxOptions{})
+	db.txmu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	// the transaction releases this mutex when it ends
+	var mu sync.Mutex
+	mu.Lock()
+	tx.WriteTxMu = &mu
+	defer tx.Rollback()
+
+	err = fn(tx)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,135 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/database/catalogstore"
+	"github.com/chaisql/chai/internal/testutil"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReIndexBatches(t *testing.T) {
+	db, tx, cleanup := testutil.NewTestTx(t)
+	defer cleanup()
+
+	testutil.MustExec(t, db, tx, `
+		CREATE TABLE test(a INT PRIMARY KEY, b INT);
+		CREATE INDEX test_b_idx ON test(b);
+		CREATE INDEX test_expr_idx ON test((b + 1));
+		INSERT INTO test(a, b) VALUES (1, 10), (2, 20), (3, 30), (4, 40), (5, 50);
+	`)
+	require.NoError(t, tx.Commit())
+
+	before, err := db.Catalog().GetIndexInfo("test_b_idx")
+	require.NoError(t, err)
+
+	type progress struct{ indexed, total int }
+	var got []progress
+	err = db.ReIndex(context.Background(), "test_b_idx", &database.ReIndexOptions{
+		BatchSize: 2,
+		Progress: func(indexName string, indexed, total int) {
+			require.Equal(t, "test_b_idx", indexName)
+			got = append(got, progress{indexed, total})
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []progress{{2, 5}, {4, 5}, {5, 5}}, got)
+
+	require.NoError(t, db.ReIndexAll(context.Background(), &database.ReIndexOptions{BatchSize: 3}))
+
+	tx, err = db.Begin(false)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	// the index must be stored in a new tree
+	after, err := tx.Catalog.GetIndexInfo("test_b_idx")
+	require.NoError(t, err)
+	require.NotEqual(t, before.StoreNamespace, after.StoreNamespace)
+
+	for _, name := range []string{"test_b_idx", "test_expr_idx"} {
+		idx, err := tx.Catalog.GetIndex(tx, name)
+		require.NoError(t, err)
+
+		var i int
+		err = idx.Tree.IterateOnRange(nil, false, func(*tree.Key, []byte) error {
+			i++
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 5, i)
+	}
+
+	// the previous tree must be empty
+	var i int
+	err = tree.New(tx.Session, before.StoreNamespace, 0).IterateOnRange(nil, false, func(*tree.Key, []byte) error {
+		i++
+		return nil
+	})
+	require.NoError(t, err)
+	require.Zero(t, i)
+}
+
+func TestReIndexPendingOnOpen(t *testing.T) {
+	path := t.TempDir()
+	opts := database.Options{
+		CatalogLoader: catalogstore.LoadCatalog,
+	}
+
+	db, err := database.Open(path, &opts)
+	require.NoError(t, err)
+	conn, err := db.Connect()
+	require.NoError(t, err)
+
+	tx, err := conn.BeginTx(&database.TxOptions{})
+	require.NoError(t, err)
+	testutil.MustExec(t, db, tx, `
+		CREATE TABLE test(a INT PRIMARY KEY, b INT);
+		CREATE INDEX test_b_idx ON test(b);
+	`)
+	require.NoError(t, tx.Commit())
+
+	tx, err = conn.BeginTx(&database.TxOptions{})
+	require.NoError(t, err)
+	require.NoError(t, tx.DeferIndexes("test_b_idx"))
+	testutil.MustExec(t, db, tx, `INSERT INTO test(a, b) VALUES (1, 10), (2, 20), (3, 30)`)
+	require.NoError(t, tx.Commit())
+
+	// the rebuild is interrupted
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = db.ReIndex(ctx, "test_b_idx", nil)
+	require.ErrorIs(t, err, context.Canceled)
+	require.NoError(t, conn.Close())
+	require.NoError(t, db.Close())
+
+	db, err = database.Open(path, &opts)
+	require.NoError(t, err)
+	defer db.Close()
+
+	tx, err = db.Begin(false)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	idx, err := tx.Catalog.GetIndex(tx, "test_b_idx")
+	require.NoError(t, err)
+
+	var i int
+	err = idx.Tree.IterateOnRange(nil, false, func(*tree.Key, []byte) error {
+		i++
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, i)
+
+	// the index is no longer pending
+	i = 0
+	err = tree.New(tx.Session, database.PendingIndexNamespace, 0).IterateOnRange(nil, false, func(*tree.Key, []byte) error {
+		i++
+		return nil
+	})
+	require.NoError(t, err)
+	require.Zero(t, i)
+}
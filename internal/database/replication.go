@@ -0,0 +1,199 @@
+package database
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+
+	"github.com/cockroachdb/errors"
+)
+
+// ErrReadOnlyReplica is returned when starting a write transaction
+// on a database following another one.
+var ErrReadOnlyReplica = errors.New("database is a read-only replica")
+
+// A replicationSource is an engine streaming the transactions
+// committed to its WAL archive.
+type replicationSource interface {
+	TailWALArchive(ctx context.Context, since int64, fn func(ts int64, batches [][]byte) error) error
+}
+
+// A replicationTarget is an engine applying the transactions
+// streamed by a replicationSource.
+type replicationTarget interface {
+	LastCommitTimestamp() (int64, error)
+	ApplyBatches(batches [][]byte) error
+}
+
+// The replication protocol is the following:
+// the follower sends the timestamp of the last commit it applied,
+// as a big endian uint64, then the leader sends every transaction
+// committed after it, as they are committed.
+// Each transaction is sent as its commit timestamp, as a big endian uint64,
+// followed by the number of batches and each batch prefixed by its size,
+// as big endian uint32.
+
+// ServeReplica streams the transactions committed to the database to a follower
+// connected to conn, starting after the last commit of the follower.
+// The database must be opened with Options.WALArchiveDir set.
+// It returns once the context is done or the connection is closed.
+func (db *Database) ServeReplica(ctx context.Context, conn io.ReadWriter) error {
+	src, ok := db.Engine.(replicationSource)
+	if !ok {
+		return errors.New("the storage engine of the database doesn't support replication")
+	}
+
+	var buf [8]byte
+	_, err := io.ReadFull(conn, buf[:])
+	if err != nil {
+		return errors.Wrap(err, "cannot read the last commit of the follower")
+	}
+	since := int64(binary.BigEndian.Uint64(buf[:]))
+
+	w := bufio.NewWriter(conn)
+	return src.TailWALArchive(ctx, since, func(ts int64, batches [][]byte) error {
+		var hdr [8 + 4]byte
+		binary.BigEndian.PutUint64(hdr[:8], uint64(ts))
+		binary.BigEndian.PutUint32(hdr[8:], uint32(len(batches)))
+		_, err := w.Write(hdr[:])
+		if err != nil {
+			return err
+		}
+
+		for _, b := range batches {
+			binary.BigEndian.PutUint32(hdr[:4], uint32(len(b)))
+			_, err = w.Write(hdr[:4])
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(b)
+			if err != nil {
+				return err
+			}
+		}
+
+		return w.Flush()
+	})
+}
+
+// Follow turns the database into a read-only replica of the leader connected to conn,
+// applying the transactions committed by the leader as they are received.
+// The database must start as a backup of the leader made while its WAL archive
+// was enabled, or as a replica that followed it previously.
+// Write transactions fail with ErrReadOnlyReplica until Follow returns,
+// which happens once the context is done or the connection is closed.
+func (db *Database) Follow(ctx context.Context, conn io.ReadWriter) error {
+	target, ok := db.Engine.(replicationTarget)
+	if !ok {
+		return errors.New("the storage engine of the database doesn't support replication")
+	}
+	if db.opts.WALArchiveDir != "" {
+		return errors.New("a replica cannot archive its WAL")
+	}
+
+	if !db.replica.CompareAndSwap(false, true) {
+		return errors.New("the database is already following a leader")
+	}
+	defer db.replica.Store(false)
+
+	since, err := target.LastCommitTimestamp()
+	if err != nil {
+		return err
+	}
+
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(since))
+	_, err = conn.Write(buf[:])
+	if err != nil {
+		return err
+	}
+
+	// stop reading when the context is done
+	if c, ok := conn.(io.Closer); ok {
+		stop := context.AfterFunc(ctx, func() {
+			_ = c.Close()
+		})
+		defer stop()
+	}
+
+	r := bufio.NewReader(conn)
+	for {
+		batches, err := readReplicatedTx(r)
+		if err != nil {
+			if ctx.Err() != nil {
+				return context.Cause(ctx)
+			}
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		err = db.applyReplicatedTx(target, batches)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func readReplicatedTx(r io.Reader) ([][]byte, error) {
+	var hdr [8 + 4]byte
+	_, err := io.ReadFull(r, hdr[:])
+	if err != nil {
+		return nil, err
+	}
+
+	batches := make([][]byte, binary.BigEndian.Uint32(hdr[8:]))
+	for i := range batches {
+		_, err = io.ReadFull(r, hdr[:4])
+		if err != nil {
+			return nil, noEOF(err)
+		}
+
+		batches[i] = make([]byte, binary.BigEndian.Uint32(hdr[:4]))
+		_, err = io.ReadFull(r, batches[i])
+		if err != nil {
+			return nil, noEOF(err)
+		}
+	}
+
+	return batches, nil
+}
+
+// noEOF converts io.EOF to io.ErrUnexpectedEOF.
+func noEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
+
+// applyReplicatedTx applies the batches within a write transaction,
+// so that read transactions keep reading the previous state
+// until the catalog is reloaded.
+func (db *Database) applyReplicatedTx(target replicationTarget, batches [][]byte) error {
+	db.writetxmu.Lock()
+	db.txmu.RLock()
+	tx, err := db.beginTxUnlocked(nil)
+	db.txmu.RUnlock()
+	if err != nil {
+		db.writetxmu.Unlock()
+		return err
+	}
+	defer tx.Rollback()
+
+	err = target.ApplyBatches(batches)
+	if err != nil {
+		return err
+	}
+
+	// the transaction may have modified the schema
+	err = tx.reloadCatalog()
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -28,55 +28,109 @@ type Row interface {
 var _ Row = (*LazyRow)(nil)
 
 // LazyRow holds an LazyRow key and lazily loads the LazyRow on demand when the Iterate or Get method is called.
-// It implements the Row and the row.Keyer interfaces.
+// It can also be initialized with the values of some of its columns, i.e. read from an index,
+// in which case the row is only loaded when other columns are accessed.
 type LazyRow struct {
 	key   *tree.Key
 	table *Table
 	row   Row
+
+	// known columns, in the order of the table
+	columns []string
+	values  []types.Value
 }
 
 func (r *LazyRow) ResetWith(table *Table, key *tree.Key) {
+	r.ResetWithValues(table, key, nil, nil)
+}
+
+// ResetWithValues resets the row with the values of some of its columns.
+// The columns must be listed in the order of the table definition.
+func (r *LazyRow) ResetWithValues(table *Table, key *tree.Key, columns []string, values []types.Value) {
 	r.key = key
 	r.table = table
 	r.row = nil
+	r.columns = columns
+	r.values = values
 }
 
-func (r *LazyRow) Iterate(fn func(name string, value types.Value) error) error {
-	var err error
-	if r.row == nil {
-		r.row, err = r.table.GetRow(r.key)
-		if err != nil {
-			return err
+// complete returns whether all the columns of the row are known.
+func (r *LazyRow) complete() bool {
+	return len(r.columns) > 0 && len(r.columns) == len(r.table.Info.ColumnConstraints.Ordered)
+}
+
+func (r *LazyRow) load() error {
+	if r.row != nil {
+		return nil
+	}
+
+	if r.complete() {
+		cb := row.NewColumnBuffer()
+		for i, c := range r.columns {
+			cb.Add(c, r.values[i])
 		}
+		r.row = &BasicRow{tableName: r.table.Info.TableName, key: r.key, Row: cb}
+		return nil
+	}
+
+	var err error
+	r.row, err = r.table.GetRow(r.key)
+	return err
+}
+
+func (r *LazyRow) Iterate(fn func(name string, value types.Value) error) error {
+	err := r.load()
+	if err != nil {
+		return err
 	}
 
 	return r.row.Iterate(fn)
 }
 
 func (r *LazyRow) Get(name string) (types.Value, error) {
-	var err error
 	if r.row == nil {
-		r.row, err = r.table.GetRow(r.key)
-		if err != nil {
-			return nil, err
+		for i, c := range r.columns {
+			if c == name {
+				return r.values[i], nil
+			}
 		}
 	}
 
+	err := r.load()
+	if err != nil {
+		return nil, err
+	}
+
 	return r.row.Get(name)
 }
 
 func (r *LazyRow) MarshalJSON() ([]byte, error) {
-	if r.row == nil {
-		var err error
-		r.row, err = r.table.GetRow(r.key)
-		if err != nil {
-			return nil, err
-		}
+	err := r.load()
+	if err != nil {
+		return nil, err
 	}
 
 	return r.row.(interface{ MarshalJSON() ([]byte, error) }).MarshalJSON()
 }
 
+// RawText returns the bytes of a TEXT value of the row, if it is stored.
+func (r *LazyRow) RawText(column string) ([]byte, bool, error) {
+	if r.row == nil && len(r.columns) > 0 {
+		return nil, false, nil
+	}
+
+	err := r.load()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if g, ok := r.row.(row.RawTextGetter); ok {
+		return g.RawText(column)
+	}
+
+	return nil, false, nil
+}
+
 func (r *LazyRow) Key() *tree.Key {
 	return r.key
 }
@@ -91,6 +145,9 @@ type BasicRow struct {
 	row.Row
 	tableName string
 	key       *tree.Key
+
+	// if true, the columns keep their order when encoded
+	ordered bool
 }
 
 func NewBasicRow(r row.Row) *BasicRow {
@@ -105,6 +162,15 @@ func (r *BasicRow) ResetWith(tableName string, key *tree.Key, rr row.Row) {
 	r.Row = rr
 }
 
+// RawText returns the bytes of a TEXT value of the underlying row, if it supports it.
+func (r *BasicRow) RawText(column string) ([]byte, bool, error) {
+	if g, ok := r.Row.(row.RawTextGetter); ok {
+		return g.RawText(column)
+	}
+
+	return nil, false, nil
+}
+
 func (r *BasicRow) Key() *tree.Key {
 	return r.key
 }
@@ -113,6 +179,24 @@ func (r *BasicRow) TableName() string {
 	return r.tableName
 }
 
+// SetOrderedColumns sets whether the columns keep their order when encoded
+// in JSON or MessagePack. See row.OrderedRow.
+func (r *BasicRow) SetOrderedColumns(ordered bool) {
+	r.ordered = ordered
+}
+
+func (r *BasicRow) OrderedColumns() bool {
+	return r.ordered
+}
+
+func (r *BasicRow) MarshalJSON() ([]byte, error) {
+	if r.ordered {
+		return row.MarshalJSON(r)
+	}
+
+	return r.Row.MarshalJSON()
+}
+
 type RowIterator interface {
 	// Iterate goes through all the rows of the table and calls the given function by passing each one of them.
 	// If the given function returns an error, the iteration stops.
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"sync"
+
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/cockroachdb/errors"
+)
+
+// A Snapshot pins the state of the database at the time it was created,
+// independently of the lifetime of transactions. Read-only transactions
+// started with TxOptions.Snapshot read the data and the catalog of the snapshot,
+// whatever was committed since.
+// A snapshot prevents the storage engine from discarding the data it reads
+// and the database from being closed: it must be released once unused.
+type Snapshot struct {
+	db *Database
+
+	// Name identifies the snapshot, for instance in logs.
+	// It is not used by the database.
+	Name string
+
+	mu       sync.Mutex
+	session  engine.Session
+	released bool
+
+	catalog     *Catalog
+	snapshotSeq uint64
+	commits     uint64
+}
+
+// Snapshot pins the current state of the database until the returned snapshot is released.
+func (db *Database) Snapshot(name string) (*Snapshot, error) {
+	if db.closeContext.Err() != nil {
+		return nil, errors.New("database is closed")
+	}
+
+	db.txmu.RLock()
+	defer db.txmu.RUnlock()
+
+	// prevent the database from being closed
+	// while the snapshot is used
+	db.connectionWg.Add(1)
+
+	return &Snapshot{
+		db:          db,
+		Name:        name,
+		session:     db.Engine.NewSnapshotSession(),
+		catalog:     db.Catalog(),
+		snapshotSeq: db.commitSeq.Load(),
+		commits:     db.commits.Load(),
+	}, nil
+}
+
+// Release the snapshot. Transactions reading it must be closed first.
+// Calling Release more than once does nothing.
+func (s *Snapshot) Release() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.released {
+		return nil
+	}
+	s.released = true
+
+	defer s.db.connectionWg.Done()
+	return s.session.Close()
+}
+
+// newSession returns a session reading the snapshot,
+// which doesn't release it when closed.
+func (s *Snapshot) newSession() (engine.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.released {
+		return nil, errors.Errorf("snapshot %q is released", s.Name)
+	}
+
+	return snapshotSession{s.session}, nil
+}
+
+// snapshotSession is used by the transactions reading a Snapshot.
+// The session of the snapshot is shared by all its transactions.
+type snapshotSession struct {
+	engine.Session
+}
+
+// Close does nothing: the session is closed by Snapshot.Release.
+func (s snapshotSession) Close() error {
+	return nil
+}
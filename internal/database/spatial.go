@@ -0,0 +1,115 @@
+package database
+
+import (
+	"bytes"
+	"slices"
+
+	"github.com/chaisql/chai/internal/geo"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// A spatial index stores, for each level of the grid,
+// the cell containing the point of a row:
+//
+//	k: <level>, <x>, <y>, <primary key>  v: nil
+//
+// See geo.PointCells.
+func spatialKey(c geo.Cell, key []byte) *tree.Key {
+	return tree.NewKey(types.NewIntegerValue(int32(c.Level)), types.NewBigintValue(c.X), types.NewBigintValue(c.Y), types.NewBlobValue(key))
+}
+
+// spatialPoint returns the point stored in a value.
+// NULL values are not indexed, other values must be points.
+func spatialPoint(v types.Value) (*geo.Point, error) {
+	if v.Type() == types.TypeNull {
+		return nil, nil
+	}
+	if v.Type() != types.TypeText {
+		return nil, errors.Errorf("spatial indexes only accept POINT values, got %s", v.Type())
+	}
+
+	g, err := geo.Parse(types.AsString(v))
+	if err != nil {
+		return nil, err
+	}
+
+	p, ok := g.(geo.Point)
+	if !ok {
+		return nil, errors.Errorf("spatial indexes only accept POINT values, got %s", g)
+	}
+
+	return &p, nil
+}
+
+// setSpatial indexes the point stored in v.
+func (idx *Index) setSpatial(v types.Value, key []byte) error {
+	p, err := spatialPoint(v)
+	if err != nil || p == nil {
+		return err
+	}
+
+	b := idx.Tree.NewBatch()
+	for _, c := range geo.PointCells(*p) {
+		err := b.Put(spatialKey(c, key), nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	return b.Apply()
+}
+
+// deleteSpatial removes the point stored in v from the index.
+func (idx *Index) deleteSpatial(v types.Value, key []byte) error {
+	p, err := spatialPoint(v)
+	if err != nil || p == nil {
+		return err
+	}
+
+	b := idx.Tree.NewBatch()
+	for _, c := range geo.PointCells(*p) {
+		err := b.Delete(spatialKey(c, key))
+		if err != nil {
+			return err
+		}
+	}
+
+	return b.Apply()
+}
+
+// SearchBox returns the keys of the rows whose point may be inside the box,
+// ordered by key. Points close to the box may be returned as well.
+// If the box is too large to be looked up in the index, it returns false.
+func (idx *Index) SearchBox(b geo.Box) ([][]byte, bool, error) {
+	if !idx.Spatial {
+		return nil, false, errors.New("not a spatial index")
+	}
+
+	cells, ok := geo.Covering(b)
+	if !ok {
+		return nil, false, nil
+	}
+
+	var keys [][]byte
+	for _, c := range cells {
+		prefix := tree.NewKey(types.NewIntegerValue(int32(c.Level)), types.NewBigintValue(c.X), types.NewBigintValue(c.Y))
+		err := idx.Tree.IterateOnRange(&tree.Range{Min: prefix, Max: prefix}, false, func(k *tree.Key, _ []byte) error {
+			values, err := k.Decode()
+			if err != nil {
+				return err
+			}
+
+			keys = append(keys, bytes.Clone(types.AsByteSlice(values[len(values)-1])))
+			return nil
+		})
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	// a point is stored in one cell per level, the keys are unique
+	slices.SortFunc(keys, bytes.Compare)
+	return keys, true, nil
+}
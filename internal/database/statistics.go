@@ -0,0 +1,398 @@
+package database
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"slices"
+
+	errs "github.com/chaisql/chai/internal/errors"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+const (
+	// maximum number of rows sampled to compute the statistics of a table.
+	statisticsSampleSize = 10_000
+
+	// maximum number of buckets of the histogram of a column.
+	histogramBuckets = 32
+)
+
+// The statistics of the columns of the analyzed tables are stored
+// in the statistics table, one row per column:
+//
+//	table_name, column_name, row_count, null_count, ndv, histogram
+//
+// The histogram is stored as the concatenation of the upper bound
+// of each bucket, encoded as keys.
+var statisticsTableInfo = func() *TableInfo {
+	info := &TableInfo{
+		TableName:      StatisticsTableName,
+		StoreNamespace: StatisticsTableNamespace,
+		ColumnConstraints: MustNewColumnConstraints(
+			&ColumnConstraint{
+				Position:  0,
+				Column:    "table_name",
+				Type:      types.TypeText,
+				IsNotNull: true,
+			},
+			&ColumnConstraint{
+				Position:  1,
+				Column:    "column_name",
+				Type:      types.TypeText,
+				IsNotNull: true,
+			},
+			&ColumnConstraint{
+				Position: 2,
+				Column:   "row_count",
+				Type:     types.TypeBigint,
+			},
+			&ColumnConstraint{
+				Position: 3,
+				Column:   "null_count",
+				Type:     types.TypeBigint,
+			},
+			&ColumnConstraint{
+				Position: 4,
+				Column:   "ndv",
+				Type:     types.TypeBigint,
+			},
+			&ColumnConstraint{
+				Position: 5,
+				Column:   "histogram",
+				Type:     types.TypeBlob,
+			},
+		),
+		TableConstraints: []*TableConstraint{
+			{
+				Name: StatisticsTableName + "_pk",
+				Columns: []string{
+					"table_name",
+					"column_name",
+				},
+				PrimaryKey: true,
+			},
+		},
+	}
+	info.BuildPrimaryKey()
+
+	return info
+}()
+
+// ColumnStatistics describes the distribution of the values of a column,
+// as computed by AnalyzeTable.
+type ColumnStatistics struct {
+	// Number of rows of the table when it was analyzed.
+	RowCount int64
+	// Estimated number of NULL values.
+	NullCount int64
+	// Estimated number of distinct non-NULL values.
+	NDV int64
+	// Upper bound of each bucket of an equi-depth histogram
+	// of the non-NULL values, in ascending order.
+	Histogram []types.Value
+}
+
+// EqualSelectivity returns the estimated fraction of the rows of the table
+// whose value is equal to a given value.
+func (s *ColumnStatistics) EqualSelectivity() float64 {
+	if s.RowCount == 0 || s.NDV == 0 {
+		return 0
+	}
+
+	return s.nonNullFraction() / float64(s.NDV)
+}
+
+// RangeSelectivity returns the estimated fraction of the rows of the table
+// whose value is between min and max. If min or max is nil, the range
+// is unbounded on that side.
+func (s *ColumnStatistics) RangeSelectivity(min, max types.Value) float64 {
+	if s.RowCount == 0 || len(s.Histogram) == 0 {
+		return 0
+	}
+
+	lo, hi := 0.0, 1.0
+	if min != nil {
+		lo = s.fractionBelow(min)
+	}
+	if max != nil {
+		hi = s.fractionBelow(max)
+	}
+
+	// values are only known at the granularity of a bucket
+	f := hi - lo + 1/float64(len(s.Histogram))
+	f = math.Max(0, math.Min(1, f))
+
+	return f * s.nonNullFraction()
+}
+
+func (s *ColumnStatistics) nonNullFraction() float64 {
+	return float64(s.RowCount-s.NullCount) / float64(s.RowCount)
+}
+
+// fractionBelow returns the fraction of the buckets of the histogram
+// whose upper bound is lower than v.
+func (s *ColumnStatistics) fractionBelow(v types.Value) float64 {
+	enc, err := types.EncodeValueAsKey(nil, v, false)
+	if err != nil {
+		return 0
+	}
+
+	var n int
+	for _, b := range s.Histogram {
+		benc, err := types.EncodeValueAsKey(nil, b, false)
+		if err != nil {
+			return 0
+		}
+		if bytes.Compare(benc, enc) >= 0 {
+			break
+		}
+		n++
+	}
+
+	return float64(n) / float64(len(s.Histogram))
+}
+
+// AnalyzeTable computes the statistics of each column of the table
+// from a sample of its rows and stores them in the statistics table,
+// replacing the previous statistics of the table.
+func AnalyzeTable(tx *Transaction, tableName string) error {
+	table, err := tx.Catalog.GetTable(tx, tableName)
+	if err != nil {
+		return err
+	}
+
+	columns := table.Info.ColumnConstraints.Ordered
+
+	// reservoir sampling of the rows of the table.
+	// Values are stored encoded as keys, which preserves their order,
+	// or nil for NULL values.
+	rnd := rand.New(rand.NewSource(1))
+	var sample [][][]byte
+	var rowCount int64
+	err = table.IterateOnRange(nil, false, func(_ *tree.Key, r Row) error {
+		rowCount++
+
+		pos := len(sample)
+		if pos == statisticsSampleSize {
+			pos = int(rnd.Int63n(rowCount))
+			if pos >= statisticsSampleSize {
+				return nil
+			}
+		}
+
+		values := make([][]byte, len(columns))
+		for i, cc := range columns {
+			v, err := r.Get(cc.Column)
+			if err != nil && !errors.Is(err, types.ErrColumnNotFound) {
+				return err
+			}
+			if err != nil || v.Type() == types.TypeNull {
+				continue
+			}
+
+			values[i], err = types.EncodeValueAsKey(nil, v, false)
+			if err != nil {
+				return err
+			}
+		}
+
+		if pos == len(sample) {
+			sample = append(sample, values)
+		} else {
+			sample[pos] = values
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = DeleteTableStatistics(tx, tableName)
+	if err != nil {
+		return err
+	}
+
+	tb, err := getOrCreateStatisticsTable(tx)
+	if err != nil {
+		return err
+	}
+
+	for i, cc := range columns {
+		values := make([][]byte, len(sample))
+		for j := range sample {
+			values[j] = sample[j][i]
+		}
+
+		s := columnStatistics(rowCount, values)
+		hist, err := types.EncodeValuesAsKey(nil, s.Histogram...)
+		if err != nil {
+			return err
+		}
+
+		_, err = tb.Put(tree.NewKey(types.NewTextValue(tableName), types.NewTextValue(cc.Column)),
+			row.NewColumnBuffer().
+				Add("table_name", types.NewTextValue(tableName)).
+				Add("column_name", types.NewTextValue(cc.Column)).
+				Add("row_count", types.NewBigintValue(s.RowCount)).
+				Add("null_count", types.NewBigintValue(s.NullCount)).
+				Add("ndv", types.NewBigintValue(s.NDV)).
+				Add("histogram", types.NewBlobValue(hist)),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// columnStatistics computes the statistics of a column from a sample
+// of its values, taken from a table of rowCount rows.
+func columnStatistics(rowCount int64, sample [][]byte) *ColumnStatistics {
+	s := ColumnStatistics{RowCount: rowCount}
+	if len(sample) == 0 {
+		return &s
+	}
+
+	var values [][]byte
+	for _, v := range sample {
+		if v != nil {
+			values = append(values, v)
+		}
+	}
+	slices.SortFunc(values, bytes.Compare)
+
+	// scale the number of NULL values found in the sample
+	ratio := float64(rowCount) / float64(len(sample))
+	s.NullCount = int64(math.Round(float64(len(sample)-len(values)) * ratio))
+	if len(values) == 0 {
+		return &s
+	}
+
+	// count the distinct values and the values that appear only once
+	var distinct, once int64
+	for i := 0; i < len(values); {
+		j := i + 1
+		for j < len(values) && bytes.Equal(values[i], values[j]) {
+			j++
+		}
+
+		distinct++
+		if j-i == 1 {
+			once++
+		}
+		i = j
+	}
+
+	if int64(len(sample)) == rowCount {
+		s.NDV = distinct
+	} else {
+		// Guaranteed-Error Estimator: values that appear only once
+		// in the sample are likely to have other distinct values
+		// in the rest of the table.
+		nonNull := rowCount - s.NullCount
+		ndv := math.Sqrt(float64(nonNull)/float64(len(values)))*float64(once) + float64(distinct-once)
+		s.NDV = min(int64(math.Round(ndv)), nonNull)
+	}
+
+	buckets := min(histogramBuckets, len(values))
+	for i := 1; i <= buckets; i++ {
+		v, _ := types.DecodeValue(values[i*len(values)/buckets-1])
+		s.Histogram = append(s.Histogram, v)
+	}
+
+	return &s
+}
+
+// GetColumnStatistics returns the statistics of a column,
+// or nil if the table hasn't been analyzed.
+func GetColumnStatistics(tx *Transaction, tableName, column string) (*ColumnStatistics, error) {
+	tb, err := tx.Catalog.GetTable(tx, StatisticsTableName)
+	if errs.IsNotFoundError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := tb.GetRow(tree.NewKey(types.NewTextValue(tableName), types.NewTextValue(column)))
+	if errs.IsNotFoundError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var s ColumnStatistics
+	for _, f := range []struct {
+		column string
+		dst    *int64
+	}{
+		{"row_count", &s.RowCount},
+		{"null_count", &s.NullCount},
+		{"ndv", &s.NDV},
+	} {
+		v, err := r.Get(f.column)
+		if err != nil {
+			return nil, err
+		}
+		*f.dst = types.AsInt64(v)
+	}
+
+	v, err := r.Get("histogram")
+	if err != nil {
+		return nil, err
+	}
+	s.Histogram = types.DecodeValues(types.AsByteSlice(v))
+
+	return &s, nil
+}
+
+// DeleteTableStatistics deletes the statistics of the columns of a table.
+func DeleteTableStatistics(tx *Transaction, tableName string) error {
+	tb, err := tx.Catalog.GetTable(tx, StatisticsTableName)
+	if errs.IsNotFoundError(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var keys []*tree.Key
+	rng := Range{Min: []types.Value{types.NewTextValue(tableName)}, Exact: true}
+	err = tb.IterateOnRange(&rng, false, func(key *tree.Key, _ Row) error {
+		// the key is only valid during the iteration
+		keys = append(keys, tree.NewEncodedKey(bytes.Clone(key.Encoded)))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		err = tb.Delete(k)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func getOrCreateStatisticsTable(tx *Transaction) (*Table, error) {
+	tb, err := tx.Catalog.GetTable(tx, StatisticsTableName)
+	if err == nil || !errs.IsNotFoundError(err) {
+		return tb, err
+	}
+
+	err = tx.CatalogWriter().CreateTable(tx, StatisticsTableName, statisticsTableInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	return tx.Catalog.GetTable(tx, StatisticsTableName)
+}
@@ -0,0 +1,64 @@
+package database_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/testutil"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzeTable(t *testing.T) {
+	db, tx, cleanup := testutil.NewTestTx(t)
+	defer cleanup()
+
+	var values []string
+	for i := 1; i <= 100; i++ {
+		c := "NULL"
+		if i%2 == 1 {
+			c = fmt.Sprint(i)
+		}
+		values = append(values, fmt.Sprintf("(%d, %d, %s)", i, i%4, c))
+	}
+
+	testutil.MustExec(t, db, tx, `
+		CREATE TABLE test(a INT PRIMARY KEY, b INT, c INT);
+		INSERT INTO test(a, b, c) VALUES `+strings.Join(values, ", "))
+
+	s, err := database.GetColumnStatistics(tx, "test", "a")
+	require.NoError(t, err)
+	require.Nil(t, s)
+
+	err = database.AnalyzeTable(tx, "test")
+	require.NoError(t, err)
+
+	s, err = database.GetColumnStatistics(tx, "test", "a")
+	require.NoError(t, err)
+	require.EqualValues(t, 100, s.RowCount)
+	require.EqualValues(t, 0, s.NullCount)
+	require.EqualValues(t, 100, s.NDV)
+	require.Len(t, s.Histogram, 32)
+	require.InDelta(t, 0.5, s.RangeSelectivity(nil, types.NewIntegerValue(51)), 0.1)
+	require.InDelta(t, 0.1, s.RangeSelectivity(types.NewIntegerValue(45), types.NewIntegerValue(55)), 0.1)
+
+	s, err = database.GetColumnStatistics(tx, "test", "b")
+	require.NoError(t, err)
+	require.EqualValues(t, 4, s.NDV)
+	require.InDelta(t, 0.25, s.EqualSelectivity(), 0.001)
+
+	s, err = database.GetColumnStatistics(tx, "test", "c")
+	require.NoError(t, err)
+	require.EqualValues(t, 50, s.NullCount)
+	require.EqualValues(t, 50, s.NDV)
+	require.InDelta(t, 0.01, s.EqualSelectivity(), 0.001)
+
+	err = database.DeleteTableStatistics(tx, "test")
+	require.NoError(t, err)
+
+	s, err = database.GetColumnStatistics(tx, "test", "a")
+	require.NoError(t, err)
+	require.Nil(t, s)
+}
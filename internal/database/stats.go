@@ -0,0 +1,142 @@
+package database
+
+import (
+	"context"
+
+	"github.com/chaisql/chai/internal/kv"
+	"github.com/chaisql/chai/internal/tree"
+)
+
+// A statsEngine is an engine reporting its metrics
+// and the disk space used by each namespace.
+type statsEngine interface {
+	Stats() kv.EngineStats
+	NamespaceDiskUsage(ns int64) (uint64, error)
+}
+
+// Stats describes the content of the database and the activity of its storage.
+type Stats struct {
+	Tables  []TableStats
+	Indexes []IndexStats
+	// Metrics of the storage engine, zero if the engine doesn't report them.
+	Engine kv.EngineStats
+	// Progress of the deletion of expired rows since the database was opened.
+	TTL TTLStats
+}
+
+// TTLStats reports the progress of the deletion of expired rows.
+// See Database.DeleteExpiredRows.
+type TTLStats struct {
+	// Number of runs of DeleteExpiredRows.
+	Runs uint64
+	// Number of expired rows deleted.
+	Deleted uint64
+	// Number of expired rows moved to an archive table.
+	Archived uint64
+}
+
+// TableStats describes the rows of a table.
+type TableStats struct {
+	Name string
+	Rows int64
+	// Estimated size of the rows on disk, in bytes.
+	// Rows that were not flushed to disk yet are not included.
+	DiskSize uint64
+}
+
+// IndexStats describes the entries of an index.
+type IndexStats struct {
+	Name    string
+	Table   string
+	Entries int64
+	// Estimated size of the entries on disk, in bytes.
+	DiskSize uint64
+}
+
+// Stats counts the rows and index entries of the database in a single read
+// transaction, and reports the disk space they use along with the metrics
+// of the storage engine.
+func (db *Database) Stats(ctx context.Context) (*Stats, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	tx, err := db.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var stats Stats
+	stats.TTL = TTLStats{
+		Runs:     db.ttlRuns.Load(),
+		Deleted:  db.ttlDeleted.Load(),
+		Archived: db.ttlArchived.Load(),
+	}
+	se, hasStats := db.Engine.(statsEngine)
+	if hasStats {
+		stats.Engine = se.Stats()
+	}
+
+	diskUsage := func(ns tree.Namespace) (uint64, error) {
+		if !hasStats {
+			return 0, nil
+		}
+		return se.NamespaceDiskUsage(int64(ns))
+	}
+
+	for _, name := range tx.Catalog.Cache.ListObjects(RelationTableType) {
+		table, err := tx.Catalog.GetTable(tx, name)
+		if err != nil {
+			return nil, err
+		}
+
+		ts := TableStats{Name: name}
+		ts.Rows, err = countKeys(ctx, table.Tree)
+		if err != nil {
+			return nil, err
+		}
+		ts.DiskSize, err = diskUsage(table.Info.StoreNamespace)
+		if err != nil {
+			return nil, err
+		}
+		stats.Tables = append(stats.Tables, ts)
+
+		for _, indexName := range tx.Catalog.ListIndexes(name) {
+			info, err := tx.Catalog.GetIndexInfo(indexName)
+			if err != nil {
+				return nil, err
+			}
+			idx, err := tx.Catalog.GetIndex(tx, indexName)
+			if err != nil {
+				return nil, err
+			}
+
+			is := IndexStats{Name: indexName, Table: name}
+			is.Entries, err = countKeys(ctx, idx.Tree)
+			if err != nil {
+				return nil, err
+			}
+			is.DiskSize, err = diskUsage(info.StoreNamespace)
+			if err != nil {
+				return nil, err
+			}
+			stats.Indexes = append(stats.Indexes, is)
+		}
+	}
+
+	return &stats, nil
+}
+
+func countKeys(ctx context.Context, t *tree.Tree) (int64, error) {
+	var n int64
+	err := t.IterateOnRange(nil, false, func(*tree.Key, []byte) error {
+		if n%1000 == 0 && ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+		n++
+		return nil
+	})
+
+	return n, err
+}
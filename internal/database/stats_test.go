@@ -0,0 +1,70 @@
+package database_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats(t *testing.T) {
+	db, err := chai.Open(filepath.Join(t.TempDir(), "db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE test(a INTEGER PRIMARY KEY, b TEXT); CREATE INDEX test_b_idx ON test(b)")
+	require.NoError(t, err)
+	for i := 0; i < 100; i++ {
+		err = db.Exec("INSERT INTO test (a, b) VALUES (?, ?)", i, fmt.Sprintf("%0100d", i))
+		require.NoError(t, err)
+	}
+	err = db.Exec("DELETE FROM test WHERE a >= 90")
+	require.NoError(t, err)
+	err = db.DB.Engine.(*kv.PebbleEngine).DB().Flush()
+	require.NoError(t, err)
+
+	stats, err := db.Stats(context.Background())
+	require.NoError(t, err)
+	require.NotZero(t, stats.Engine.DiskSize)
+
+	var table *chai.TableStats
+	for i := range stats.Tables {
+		if stats.Tables[i].Name == "test" {
+			table = &stats.Tables[i]
+		}
+	}
+	require.NotNil(t, table)
+	require.EqualValues(t, 90, table.Rows)
+	require.NotZero(t, table.DiskSize)
+
+	var indexes []chai.IndexStats
+	for _, is := range stats.Indexes {
+		if is.Table == "test" {
+			indexes = append(indexes, is)
+		}
+	}
+	require.Len(t, indexes, 1)
+	require.Equal(t, "test_b_idx", indexes[0].Name)
+	require.EqualValues(t, 90, indexes[0].Entries)
+	require.NotZero(t, indexes[0].DiskSize)
+
+	// in-memory databases only report the counts
+	mem, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer mem.Close()
+	err = mem.Exec("CREATE TABLE test(a INTEGER PRIMARY KEY); INSERT INTO test (a) VALUES (1), (2)")
+	require.NoError(t, err)
+	stats, err = mem.Stats(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, stats.Engine)
+	for _, ts := range stats.Tables {
+		if ts.Name == "test" {
+			require.EqualValues(t, 2, ts.Rows)
+			require.Zero(t, ts.DiskSize)
+		}
+	}
+}
@@ -23,6 +23,7 @@ type Table struct {
 
 // Truncate deletes all the objects from the table.
 func (t *Table) Truncate() error {
+	t.Tx.markModified(t.Info.TableName)
 	return t.Tree.Truncate()
 }
 
@@ -36,6 +37,8 @@ func (t *Table) Insert(r row.Row) (*tree.Key, Row, error) {
 		return nil, nil, errors.New("cannot write to read-only table")
 	}
 
+	t.Tx.markModified(t.Info.TableName)
+
 	key, isRowid, err := t.generateKey(t.Info, r)
 	if err != nil {
 		return nil, nil, err
@@ -46,6 +49,11 @@ func (t *Table) Insert(r row.Row) (*tree.Key, Row, error) {
 		return nil, nil, err
 	}
 
+	enc, err = t.externalize(enc)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// insert into the table
 	if !isRowid {
 		// if the key is not a rowid, make sure it doesn't exist
@@ -85,7 +93,9 @@ func (t *Table) encodeRow(r row.Row) (row.Row, []byte, error) {
 		return nil, nil, err
 	}
 
-	return NewEncodedRow(&t.Info.ColumnConstraints, dst), dst, nil
+	e := NewEncodedRow(&t.Info.ColumnConstraints, dst)
+	e.blobs = t.Tx.BlobReader()
+	return e, dst, nil
 }
 
 // Delete a object by key.
@@ -94,6 +104,8 @@ func (t *Table) Delete(key *tree.Key) error {
 		return errors.New("cannot write to read-only table")
 	}
 
+	t.Tx.markModified(t.Info.TableName)
+
 	err := t.Tree.Delete(key)
 	if errors.Is(err, engine.ErrKeyNotFound) {
 		return errs.NewNotFoundError(key.String())
@@ -127,11 +139,18 @@ func (t *Table) Put(key *tree.Key, r row.Row) (Row, error) {
 		return nil, errors.New("cannot write to read-only table")
 	}
 
+	t.Tx.markModified(t.Info.TableName)
+
 	r, enc, err := t.encodeRow(r)
 	if err != nil {
 		return nil, err
 	}
 
+	enc, err = t.externalize(enc)
+	if err != nil {
+		return nil, err
+	}
+
 	// replace old row with new row
 	err = t.Tree.Put(key, enc)
 	return &BasicRow{
@@ -161,6 +180,7 @@ func (t *Table) IterateOnRange(rng *Range, reverse bool, fn func(key *tree.Key,
 
 	e := EncodedRow{
 		columnConstraints: &t.Info.ColumnConstraints,
+		blobs:             t.Tx.BlobReader(),
 	}
 	row := BasicRow{
 		tableName: t.Info.TableName,
@@ -169,7 +189,7 @@ func (t *Table) IterateOnRange(rng *Range, reverse bool, fn func(key *tree.Key,
 
 	return t.Tree.IterateOnRange(r, reverse, func(k *tree.Key, enc []byte) error {
 		row.key = k
-		e.encoded = enc
+		e.setEncoded(enc)
 		return fn(k, &row)
 	})
 }
@@ -184,9 +204,12 @@ func (t *Table) GetRow(key *tree.Key) (Row, error) {
 		return nil, fmt.Errorf("failed to fetch row %q: %w", key, err)
 	}
 
+	e := NewEncodedRow(&t.Info.ColumnConstraints, enc)
+	e.blobs = t.Tx.BlobReader()
+
 	return &BasicRow{
 		tableName: t.Info.TableName,
-		Row:       NewEncodedRow(&t.Info.ColumnConstraints, enc),
+		Row:       e,
 		key:       key,
 	}, nil
 }
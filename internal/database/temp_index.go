@@ -0,0 +1,234 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+
+	errs "github.com/chaisql/chai/internal/errors"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/cockroachdb/errors"
+)
+
+// A tempIndex is an index that only exists for the lifetime of a connection.
+// It is never written to the catalog nor to the disk: its content is stored
+// in a transient tree, built from the table the first time the index is used
+// and rebuilt when the table has been modified since.
+type tempIndex struct {
+	info *IndexInfo
+
+	// built index, nil until the first use.
+	index   *Index
+	cleanup func() error
+	// commit sequence of the snapshot the index was built from.
+	builtAt uint64
+}
+
+func (t *tempIndex) release() error {
+	if t.cleanup == nil {
+		return nil
+	}
+
+	err := t.cleanup()
+	t.index, t.cleanup = nil, nil
+	return err
+}
+
+// CreateTempIndex declares a temporary index on the connection.
+// The index is built when it is first used by a query.
+func (c *Connection) CreateTempIndex(tx *Transaction, info *IndexInfo) error {
+	if info.Unique || info.Fulltext || info.Spatial {
+		return errors.New("temporary indexes cannot be unique, full-text or spatial")
+	}
+
+	ti, err := tx.Catalog.GetTableInfo(info.Owner.TableName)
+	if err != nil {
+		return err
+	}
+
+	err = info.validateKey(ti)
+	if err != nil {
+		return err
+	}
+
+	if info.IndexName == "" {
+		info.IndexName = fmt.Sprintf("%s_%s_temp_idx", ti.TableName, info.keyName())
+	}
+
+	_, err = tx.Catalog.GetIndexInfo(info.IndexName)
+	if err == nil || c.tempIndexes[info.IndexName] != nil {
+		return errs.AlreadyExistsError{Name: info.IndexName}
+	}
+
+	if c.tempIndexes == nil {
+		c.tempIndexes = make(map[string]*tempIndex)
+	}
+	c.tempIndexes[info.IndexName] = &tempIndex{info: info.Clone()}
+	return nil
+}
+
+// DropTempIndex removes a temporary index from the connection.
+func (c *Connection) DropTempIndex(name string) error {
+	t, ok := c.tempIndexes[name]
+	if !ok {
+		return errs.NewNotFoundError(name)
+	}
+
+	delete(c.tempIndexes, name)
+	return t.release()
+}
+
+// TempIndexes returns the temporary indexes of the table that can be used
+// by the given transaction, sorted by name.
+// Indexes of tables modified by the transaction are not returned
+// since they don't reflect its changes.
+func (c *Connection) TempIndexes(tx *Transaction, tableName string) []*IndexInfo {
+	if c == nil || tx.isModified(tableName) {
+		return nil
+	}
+
+	var list []*IndexInfo
+	for _, t := range c.tempIndexes {
+		if t.info.Owner.TableName == tableName {
+			list = append(list, t.info)
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].IndexName < list[j].IndexName
+	})
+	return list
+}
+
+// HasTempIndexes returns whether temporary indexes were declared on the connection.
+func (c *Connection) HasTempIndexes() bool {
+	return c != nil && len(c.tempIndexes) > 0
+}
+
+// getTempIndex returns the temporary index with the given name, building it
+// if it was never built or if the table was modified since.
+func (c *Connection) getTempIndex(tx *Transaction, name string) (*Index, *IndexInfo, error) {
+	t, ok := c.tempIndexes[name]
+	if !ok {
+		return nil, nil, errs.NewNotFoundError(name)
+	}
+
+	if tx.isModified(t.info.Owner.TableName) {
+		return nil, nil, errors.Errorf("temporary index %s cannot be used after modifying table %s in the same transaction", name, t.info.Owner.TableName)
+	}
+
+	// the index is up to date if the table wasn't modified
+	// between the snapshot it was built from and the snapshot of tx.
+	if t.index != nil && c.db.tableVersion(t.info.Owner.TableName) <= min(t.builtAt, tx.snapshotSeq) {
+		return t.index, t.info, nil
+	}
+
+	err := t.release()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = c.buildTempIndex(tx, t)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return t.index, t.info, nil
+}
+
+// buildTempIndex fills a transient tree with the values of the table.
+func (c *Connection) buildTempIndex(tx *Transaction, t *tempIndex) error {
+	table, err := tx.Catalog.GetTable(tx, t.info.Owner.TableName)
+	if err != nil {
+		return err
+	}
+
+	session := c.db.Engine.NewTransientSession()
+	tr, cleanup, err := tree.NewTransient(session, tx.Catalog.GetFreeTransientNamespace(), t.info.KeySortOrder)
+	if err != nil {
+		_ = session.Close()
+		return err
+	}
+
+	idx := NewIndex(tr, *t.info)
+	idx.Collations, err = table.Info.Collations(t.info.Columns)
+	if err != nil {
+		_ = cleanup()
+		_ = session.Close()
+		return err
+	}
+
+	err = table.IterateOnRange(nil, false, func(key *tree.Key, r Row) error {
+		vs, err := t.info.KeyValues(tx, r)
+		if err != nil {
+			return err
+		}
+
+		encKey, err := table.Info.EncodeKey(key)
+		if err != nil {
+			return err
+		}
+
+		return idx.Set(vs, encKey)
+	})
+
+	t.index = idx
+	t.builtAt = tx.snapshotSeq
+	t.cleanup = func() error {
+		err := cleanup()
+		if err != nil {
+			return err
+		}
+		return session.Close()
+	}
+
+	if err != nil {
+		_ = t.release()
+		return err
+	}
+
+	return nil
+}
+
+// closeTempIndexes releases the memory used by the temporary indexes.
+func (c *Connection) closeTempIndexes() error {
+	var err error
+	for name, t := range c.tempIndexes {
+		if e := t.release(); e != nil && err == nil {
+			err = e
+		}
+		delete(c.tempIndexes, name)
+	}
+
+	return err
+}
+
+// GetIndex returns the index with the given name, looking up
+// the temporary indexes of the connection if the catalog doesn't contain it.
+func (tx *Transaction) GetIndex(name string) (*Index, *IndexInfo, error) {
+	info, err := tx.Catalog.GetIndexInfo(name)
+	if err == nil {
+		idx, err := tx.Catalog.GetIndex(tx, name)
+		return idx, info, err
+	}
+	if !errs.IsNotFoundError(err) || tx.conn == nil {
+		return nil, nil, err
+	}
+
+	return tx.conn.getTempIndex(tx, name)
+}
+
+// GetIndexInfo returns the information of the index with the given name,
+// looking up the temporary indexes of the connection if the catalog doesn't contain it.
+func (tx *Transaction) GetIndexInfo(name string) (*IndexInfo, error) {
+	info, err := tx.Catalog.GetIndexInfo(name)
+	if err == nil || !errs.IsNotFoundError(err) || tx.conn == nil {
+		return info, err
+	}
+
+	t, ok := tx.conn.tempIndexes[name]
+	if !ok {
+		return nil, err
+	}
+
+	return t.info, nil
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"sync"
 	"time"
 
@@ -20,10 +21,13 @@ type Transaction struct {
 	// The timestamp must use the local timezone.
 	TxStart time.Time
 
-	Session   engine.Session
-	Engine    engine.Engine
-	ID        uint64
-	Writable  bool
+	Session  engine.Session
+	Engine   engine.Engine
+	ID       uint64
+	Writable bool
+	// set if the transaction was started with TxOptions.Deferred.
+	// It stays read-only until it is upgraded.
+	Deferred  bool
 	WriteTxMu *sync.Mutex
 	// these functions are run after a successful rollback.
 	OnRollbackHooks []func()
@@ -32,6 +36,39 @@ type Transaction struct {
 
 	Catalog       *Catalog
 	catalogWriter *CatalogWriter
+
+	// active savepoints, from the oldest to the most recent.
+	savepoints []savepoint
+
+	// commit sequence of the database when the transaction started.
+	snapshotSeq uint64
+	// number of commits of the database when the transaction started.
+	commits uint64
+	// durability used when a deferred transaction is upgraded.
+	durability Durability
+	// id of the transaction, once prepared by Prepare.
+	preparedID string
+	// work memory of the operators, if set by TxOptions.WorkMemory.
+	workMemory int
+	// context of the wait for the write lock, if set by TxOptions.Context.
+	ctx context.Context
+	// tables written by the transaction.
+	modifiedTables map[string]struct{}
+	// indexes not maintained by the inserts of the transaction.
+	deferredIndexes map[string]struct{}
+
+	// transactions of the attached databases used by the transaction.
+	attached map[string]*Transaction
+}
+
+// savepoint records the state of the transaction
+// at the time the savepoint was created.
+type savepoint struct {
+	name string
+	// identifier of the savepoint in the session.
+	id            int
+	rollbackHooks int
+	commitHooks   int
 }
 
 func (tx *Transaction) Connection() *Connection {
@@ -40,6 +77,12 @@ func (tx *Transaction) Connection() *Connection {
 
 // Rollback the transaction. Can be used safely after commit.
 func (tx *Transaction) Rollback() error {
+	if tx.preparedID != "" {
+		return tx.rollbackPrepared()
+	}
+
+	aerr := tx.rollbackAttached()
+
 	err := tx.Session.Close()
 	if err != nil {
 		return err
@@ -60,32 +103,93 @@ func (tx *Transaction) Rollback() error {
 		tx.OnRollbackHooks[i]()
 	}
 
-	return nil
+	return aerr
 }
 
 // Commit the transaction. Calling this method on read-only transactions
 // will return an error.
 func (tx *Transaction) Commit() error {
 	if !tx.Writable {
-		return errors.New("cannot commit read-only transaction")
+		if !tx.Deferred {
+			return errors.New("cannot commit read-only transaction")
+		}
+
+		// a deferred transaction that didn't write has nothing to commit
+		return tx.commitDeferred()
+	}
+
+	if tx.preparedID != "" {
+		return tx.commitPrepared()
+	}
+
+	// the changes of the attached databases are prepared first,
+	// so that a failure leaves the main database untouched,
+	// and committed once the main database is committed.
+	err := tx.prepareAttached()
+	if err != nil {
+		return err
+	}
+
+	err = tx.deleteAttachedCommits()
+	if err != nil {
+		return errors.CombineErrors(err, tx.rollbackAttached())
+	}
+
+	err = tx.commitMain()
+	if err != nil {
+		return errors.CombineErrors(err, tx.rollbackAttached())
+	}
+
+	return tx.commitAttached()
+}
+
+// commitMain commits the changes of the transaction to its database.
+func (tx *Transaction) commitMain() error {
+	// write the changes before locking the transaction mutex when possible:
+	// read transactions started meanwhile keep reading the data
+	// as it was before this transaction, with the current catalog.
+	var err error
+	staged, ok := tx.Session.(engine.StagedSession)
+	if ok {
+		err = staged.Prepare()
+		if err != nil {
+			return err
+		}
 	}
 
 	// lock the transaction mutex to prevent any other transaction
-	// from being created while the commit is in progress.
+	// from being created while the commit is published.
 	tx.db.txmu.Lock()
 	defer tx.db.txmu.Unlock()
 
-	err := tx.Session.Commit()
+	if ok {
+		err = staged.Publish()
+	} else {
+		err = tx.Session.Commit()
+	}
 	if err != nil {
 		return err
 	}
 
 	_ = tx.Session.Close()
 
+	tx.published()
+	return nil
+}
+
+// published updates the database once the changes of the transaction
+// are visible and releases the write lock.
+// It must be called with the transaction mutex locked.
+func (tx *Transaction) published() {
 	defer func() {
 		tx.WriteTxMu.Unlock()
 	}()
 
+	tx.db.commits.Add(1)
+	if len(tx.modifiedTables) > 0 {
+		tx.db.setTableVersions(tx.modifiedTables, tx.db.commitSeq.Add(1))
+	}
+
 	for i := len(tx.OnCommitHooks) - 1; i >= 0; i-- {
 		tx.OnCommitHooks[i]()
 	}
@@ -94,10 +198,176 @@ func (tx *Transaction) Commit() error {
 	if tx.catalogWriter != nil {
 		tx.db.SetCatalog(tx.Catalog)
 	}
+}
+
+// commitDeferred ends a deferred transaction that was never upgraded.
+func (tx *Transaction) commitDeferred() error {
+	aerr := tx.rollbackAttached()
+
+	err := tx.Session.Close()
+	if err != nil {
+		return err
+	}
+
+	for i := len(tx.OnCommitHooks) - 1; i >= 0; i-- {
+		tx.OnCommitHooks[i]()
+	}
+
+	return aerr
+}
+
+// Upgrade turns a deferred transaction into a write transaction,
+// waiting for the write lock according to the busy options of the database.
+// Since the transaction may already have read data, it fails with an error
+// wrapping ErrBusy if another transaction committed since it started.
+// It does nothing if the transaction is already writable.
+func (tx *Transaction) Upgrade() error {
+	if tx.Writable {
+		return nil
+	}
+	if !tx.Deferred {
+		return errors.New("cannot write in a read-only transaction")
+	}
+	if len(tx.attached) > 0 {
+		return errors.New("cannot upgrade a transaction using attached databases")
+	}
+	if tx.db.replica.Load() {
+		return ErrReadOnlyReplica
+	}
+	if tx.db.readOnly.Load() {
+		return ErrReadOnly
+	}
+
+	err := tx.db.lockWriter(tx.ctx)
+	if err != nil {
+		return err
+	}
+
+	if tx.db.commits.Load() != tx.commits {
+		tx.db.writetxmu.Unlock()
+		return errors.Wrap(ErrBusy, "the database was modified since the transaction started")
+	}
 
+	tx.db.txmu.RLock()
+	sess := tx.db.newBatchSession(tx.durability)
+	tx.db.txmu.RUnlock()
+
+	// nothing was written yet: all the savepoints
+	// point to the start of the new session.
+	if len(tx.savepoints) > 0 {
+		ss, ok := sess.(engine.SavepointSession)
+		if !ok {
+			_ = sess.Close()
+			tx.db.writetxmu.Unlock()
+			return errors.New("savepoints are not supported by this session")
+		}
+
+		for i := range tx.savepoints {
+			tx.savepoints[i].id, err = ss.Savepoint()
+			if err != nil {
+				_ = sess.Close()
+				tx.db.writetxmu.Unlock()
+				return err
+			}
+		}
+	}
+
+	_ = tx.Session.Close()
+	tx.Session = sess
+	tx.Writable = true
+	tx.WriteTxMu = &tx.db.writetxmu
 	return nil
 }
 
+// WorkMemory returns the number of bytes an operator of the transaction
+// can hold in memory before spilling to temporary storage.
+func (tx *Transaction) WorkMemory() int {
+	if tx.workMemory > 0 {
+		return tx.workMemory
+	}
+
+	return tx.db.WorkMemory()
+}
+
+// Location returns the time zone of the database of the transaction,
+// or UTC if the transaction is not associated with a database.
+func (tx *Transaction) Location() *time.Location {
+	if tx == nil || tx.db == nil {
+		return time.UTC
+	}
+
+	return tx.db.Location()
+}
+
+// rejectsNonFiniteDoubles returns true if NaN and infinite doubles
+// can't be written by the transaction.
+func (tx *Transaction) rejectsNonFiniteDoubles() bool {
+	return tx != nil && tx.db != nil && tx.db.opts != nil && tx.db.opts.RejectNonFiniteDoubles
+}
+
+// markModified records that the transaction wrote to the table.
+func (tx *Transaction) markModified(tableName string) {
+	if tx.modifiedTables == nil {
+		tx.modifiedTables = make(map[string]struct{})
+	}
+
+	tx.modifiedTables[tableName] = struct{}{}
+}
+
+func (tx *Transaction) isModified(tableName string) bool {
+	_, ok := tx.modifiedTables[tableName]
+	return ok
+}
+
+// DeferIndexes disables the maintenance of the given indexes by the rows
+// inserted by the transaction. The indexes must be rebuilt with ReIndex
+// once the transaction is committed. Until then, they are marked as pending
+// and are rebuilt the next time the database is opened.
+func (tx *Transaction) DeferIndexes(names ...string) error {
+	if tx.deferredIndexes == nil {
+		tx.deferredIndexes = make(map[string]struct{})
+	}
+
+	for _, name := range names {
+		err := markPendingIndex(tx, name)
+		if err != nil {
+			return err
+		}
+
+		tx.deferredIndexes[name] = struct{}{}
+	}
+
+	return nil
+}
+
+// IsIndexDeferred reports whether the inserts of the transaction skip the index.
+func (tx *Transaction) IsIndexDeferred(name string) bool {
+	_, ok := tx.deferredIndexes[name]
+	return ok
+}
+
+// ForkSession returns a new session reading the same snapshot as the
+// read-only transaction, to be used by another goroutine and closed by
+// the caller. It returns false if the transaction is writable or if its
+// engine cannot fork sessions.
+func (tx *Transaction) ForkSession() (engine.Session, bool) {
+	if tx.Writable {
+		return nil, false
+	}
+
+	sess := tx.Session
+	if s, ok := sess.(snapshotSession); ok {
+		sess = s.Session
+	}
+
+	f, ok := sess.(engine.ForkableSession)
+	if !ok {
+		return nil, false
+	}
+
+	return f.Fork(), true
+}
+
 func (tx *Transaction) CatalogWriter() *CatalogWriter {
 	if !tx.Writable {
 		panic("cannot get catalog writer from read-only transaction")
@@ -111,3 +381,117 @@ func (tx *Transaction) CatalogWriter() *CatalogWriter {
 
 	return tx.catalogWriter
 }
+
+// reloadCatalog loads the catalog again from the session,
+// like when opening the database, after the transaction modified
+// the schema without going through the catalog writer.
+// All the tables are considered modified, invalidating the temporary
+// indexes built on them.
+func (tx *Transaction) reloadCatalog() error {
+	tx.Catalog = NewCatalog()
+	tx.catalogWriter = NewCatalogWriter(tx.Catalog)
+
+	var err error
+	if tx.db.opts.CatalogLoader != nil {
+		err = tx.db.opts.CatalogLoader(tx)
+	} else {
+		err = tx.catalogWriter.Init(tx)
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to reload catalog")
+	}
+
+	for _, name := range tx.Catalog.Cache.ListObjects(RelationTableType) {
+		tx.markModified(name)
+	}
+
+	return nil
+}
+
+// Savepoint creates a savepoint with the given name.
+// Writes made after it can be undone using RollbackToSavepoint
+// without aborting the whole transaction.
+// If a savepoint with the same name already exists, the new one hides it
+// until it is released.
+func (tx *Transaction) Savepoint(name string) error {
+	sp := savepoint{
+		name:          name,
+		id:            -1,
+		rollbackHooks: len(tx.OnRollbackHooks),
+		commitHooks:   len(tx.OnCommitHooks),
+	}
+
+	// read-only transactions have nothing to undo
+	if tx.Writable {
+		sess, ok := tx.Session.(engine.SavepointSession)
+		if !ok {
+			return errors.New("savepoints are not supported by this session")
+		}
+
+		var err error
+		sp.id, err = sess.Savepoint()
+		if err != nil {
+			return err
+		}
+	}
+
+	tx.savepoints = append(tx.savepoints, sp)
+	return nil
+}
+
+// RollbackToSavepoint undoes all the changes made since the savepoint was created.
+// Savepoints created after it are destroyed. The savepoint itself remains active
+// and can be rolled back to again.
+func (tx *Transaction) RollbackToSavepoint(name string) error {
+	i, err := tx.getSavepoint(name)
+	if err != nil {
+		return err
+	}
+
+	sp := tx.savepoints[i]
+	if tx.Writable {
+		err = tx.Session.(engine.SavepointSession).RollbackToSavepoint(sp.id)
+		if err != nil {
+			return err
+		}
+	}
+
+	for j := len(tx.OnRollbackHooks) - 1; j >= sp.rollbackHooks; j-- {
+		tx.OnRollbackHooks[j]()
+	}
+	tx.OnRollbackHooks = tx.OnRollbackHooks[:sp.rollbackHooks]
+	tx.OnCommitHooks = tx.OnCommitHooks[:sp.commitHooks]
+
+	tx.savepoints = tx.savepoints[:i+1]
+	return nil
+}
+
+// ReleaseSavepoint destroys the savepoint and all the savepoints created after it.
+// The changes made since the savepoint was created are kept.
+func (tx *Transaction) ReleaseSavepoint(name string) error {
+	i, err := tx.getSavepoint(name)
+	if err != nil {
+		return err
+	}
+
+	if tx.Writable {
+		err = tx.Session.(engine.SavepointSession).ReleaseSavepoint(tx.savepoints[i].id)
+		if err != nil {
+			return err
+		}
+	}
+
+	tx.savepoints = tx.savepoints[:i]
+	return nil
+}
+
+// getSavepoint returns the position of the most recent savepoint with the given name.
+func (tx *Transaction) getSavepoint(name string) (int, error) {
+	for i := len(tx.savepoints) - 1; i >= 0; i-- {
+		if tx.savepoints[i].name == name {
+			return i, nil
+		}
+	}
+
+	return 0, errors.Errorf("savepoint %q does not exist", name)
+}
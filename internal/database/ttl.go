@@ -0,0 +1,274 @@
+package database
+
+import (
+	"bytes"
+	"slices"
+	"time"
+
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+const (
+	// DefaultTTLInterval is the time between two runs of the TTL reaper,
+	// if Options.TTLInterval is not set.
+	DefaultTTLInterval = time.Minute
+
+	// number of rows deleted by each transaction of the TTL reaper,
+	// to avoid blocking other writers for too long.
+	ttlBatchSize = 100
+)
+
+// runTTLReaper deletes the expired rows of the tables with a TTL column
+// every interval, until the database is closed.
+func (db *Database) runTTLReaper(interval time.Duration) {
+	defer db.ttlWg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-db.closeContext.Done():
+			return
+		case <-ticker.C:
+			// the write lock is held until the prepared
+			// transaction is resolved
+			if _, ok := db.PreparedTransaction(); ok {
+				continue
+			}
+
+			// errors are ignored, the rows will be deleted
+			// during the next run
+			_, _ = db.DeleteExpiredRows(time.Now())
+		}
+	}
+}
+
+// DeleteExpiredRows deletes the rows of the tables with a TTL column
+// whose expiration time is before now. The expired rows of tables with
+// an archive table are moved to it instead of being deleted.
+// Rows are deleted in small transactions, each of them deleting
+// at most a few hundred rows, so that a row is always visible in exactly
+// one of the table and its archive table.
+// It returns the number of deleted rows, including the archived ones.
+func (db *Database) DeleteExpiredRows(now time.Time) (int, error) {
+	var total int
+	db.ttlRuns.Add(1)
+
+	catalog := db.Catalog()
+	for _, name := range catalog.Cache.ListObjects(RelationTableType) {
+		info, err := catalog.GetTableInfo(name)
+		if err != nil {
+			return total, err
+		}
+		if info.TTLColumn == "" || info.ReadOnly {
+			continue
+		}
+
+		for {
+			n, err := db.deleteExpiredBatch(name, now)
+			total += n
+			if err != nil {
+				return total, err
+			}
+			if n < ttlBatchSize {
+				break
+			}
+		}
+	}
+
+	return total, nil
+}
+
+// deleteExpiredBatch deletes up to ttlBatchSize expired rows of the table
+// in a single transaction.
+func (db *Database) deleteExpiredBatch(tableName string, now time.Time) (int, error) {
+	tx, err := db.Begin(true)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// the table may have been dropped or altered
+	// since the list of tables was read
+	table, err := tx.Catalog.GetTable(tx, tableName)
+	if err != nil || table.Info.TTLColumn == "" {
+		return 0, nil
+	}
+
+	var keys []*tree.Key
+	err = table.IterateOnRange(nil, false, func(key *tree.Key, r Row) error {
+		v, err := r.Get(table.Info.TTLColumn)
+		if err != nil {
+			return err
+		}
+		if v.Type() != types.TypeTimestamp || !types.AsTime(v).Before(now) {
+			return nil
+		}
+
+		// the key is only valid during the iteration
+		keys = append(keys, tree.NewEncodedKey(bytes.Clone(key.Encoded)))
+		if len(keys) == ttlBatchSize {
+			return errStop
+		}
+		return nil
+	})
+	if err != nil && err != errStop {
+		return 0, err
+	}
+
+	indexes, err := tx.Catalog.listIndexInfos(tableName)
+	if err != nil {
+		return 0, err
+	}
+
+	var archive *Table
+	var archiveIndexes []*IndexInfo
+	if table.Info.ArchiveTable != "" {
+		archive, err = tx.Catalog.GetTable(tx, table.Info.ArchiveTable)
+		if err != nil {
+			return 0, errors.Wrapf(err, "cannot archive expired rows of %s", tableName)
+		}
+		archiveIndexes, err = tx.Catalog.listIndexInfos(archive.Info.TableName)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	for _, key := range keys {
+		if archive != nil {
+			r, err := table.GetRow(key)
+			if err != nil {
+				return 0, err
+			}
+
+			err = archive.insertWithIndexes(r, archiveIndexes)
+			if err != nil {
+				return 0, errors.Wrapf(err, "cannot archive expired rows of %s", tableName)
+			}
+		}
+
+		err = table.deleteWithIndexes(key, indexes)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	if archive != nil {
+		db.ttlArchived.Add(uint64(len(keys)))
+	} else {
+		db.ttlDeleted.Add(uint64(len(keys)))
+	}
+	return len(keys), nil
+}
+
+// listIndexInfos returns the configuration of the indexes of the table.
+func (c *Catalog) listIndexInfos(tableName string) ([]*IndexInfo, error) {
+	var indexes []*IndexInfo
+	for _, name := range c.ListIndexes(tableName) {
+		info, err := c.GetIndexInfo(name)
+		if err != nil {
+			return nil, err
+		}
+		indexes = append(indexes, info)
+	}
+
+	return indexes, nil
+}
+
+// insertWithIndexes inserts a row, after checking the constraints
+// of the table, and adds it to the given indexes.
+func (t *Table) insertWithIndexes(r row.Row, indexes []*IndexInfo) error {
+	key, dr, err := t.Insert(r)
+	if err != nil {
+		return err
+	}
+
+	err = t.Info.TableConstraints.ValidateRow(t.Tx, dr)
+	if err != nil {
+		return err
+	}
+
+	encKey, err := t.Info.EncodeKey(key)
+	if err != nil {
+		return err
+	}
+
+	for _, info := range indexes {
+		idx, err := t.Tx.Catalog.GetIndex(t.Tx, info.IndexName)
+		if err != nil {
+			return err
+		}
+
+		vs, err := info.KeyValues(t.Tx, dr)
+		if err != nil {
+			return err
+		}
+
+		// like the insertion of rows by SQL statements, rows with NULL values
+		// are not checked, unless the index was created with NULLS NOT DISTINCT.
+		hasNull := slices.ContainsFunc(vs, func(v types.Value) bool {
+			return v.Type() == types.TypeNull
+		})
+		if info.Unique && (!hasNull || info.NullsNotDistinct) {
+			duplicate, dkey, err := idx.Exists(vs)
+			if err != nil {
+				return err
+			}
+			if duplicate {
+				return &ConstraintViolationError{
+					Constraint: "UNIQUE",
+					Columns:    info.Columns,
+					Key:        dkey,
+				}
+			}
+		}
+
+		err = idx.Set(vs, encKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteWithIndexes deletes a row and removes it from the given indexes.
+func (t *Table) deleteWithIndexes(key *tree.Key, indexes []*IndexInfo) error {
+	r, err := t.GetRow(key)
+	if err != nil {
+		return err
+	}
+
+	encKey, err := t.Info.EncodeKey(key)
+	if err != nil {
+		return err
+	}
+
+	for _, info := range indexes {
+		idx, err := t.Tx.Catalog.GetIndex(t.Tx, info.IndexName)
+		if err != nil {
+			return err
+		}
+
+		vs, err := info.KeyValues(t.Tx, r)
+		if err != nil {
+			return err
+		}
+
+		err = idx.Delete(vs, encKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	return t.Delete(key)
+}
@@ -0,0 +1,123 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/testutil"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteExpiredRows(t *testing.T) {
+	db, tx, cleanup := testutil.NewTestTx(t)
+	defer cleanup()
+
+	testutil.MustExec(t, db, tx, `
+		CREATE TABLE test(a INT PRIMARY KEY, b INT, expires_at TIMESTAMP) WITH (ttl_column = 'expires_at');
+		CREATE INDEX test_b_idx ON test(b);
+		CREATE TABLE other(a INT, expires_at TIMESTAMP);
+
+		INSERT INTO test(a, b, expires_at) VALUES
+			(1, 10, '2020-01-01T00:00:00Z'),
+			(2, 20, '2030-01-01T00:00:00Z'),
+			(3, 30, '2021-01-01T00:00:00Z'),
+			(4, 40, NULL);
+		INSERT INTO other(a, expires_at) VALUES (1, '2020-01-01T00:00:00Z');
+	`)
+	require.NoError(t, tx.Commit())
+
+	n, err := db.DeleteExpiredRows(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	tx, err = db.Begin(false)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	count := func(name string) int {
+		t.Helper()
+
+		tb, err := tx.Catalog.GetTable(tx, name)
+		require.NoError(t, err)
+
+		var i int
+		err = tb.IterateOnRange(nil, false, func(*tree.Key, database.Row) error {
+			i++
+			return nil
+		})
+		require.NoError(t, err)
+		return i
+	}
+
+	require.Equal(t, 2, count("test"))
+	// tables without a TTL column are left untouched
+	require.Equal(t, 1, count("other"))
+
+	// expired rows must be removed from the indexes as well
+	idx, err := tx.Catalog.GetIndex(tx, "test_b_idx")
+	require.NoError(t, err)
+	var i int
+	err = idx.Tree.IterateOnRange(nil, false, func(*tree.Key, []byte) error {
+		i++
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, i)
+}
+
+func TestArchiveExpiredRows(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test_archive(a INT PRIMARY KEY, b INT UNIQUE, expires_at TIMESTAMP);
+		CREATE TABLE test(a INT PRIMARY KEY, b INT, expires_at TIMESTAMP)
+			WITH (ttl_column = 'expires_at', archive_table = 'test_archive');
+
+		INSERT INTO test(a, b, expires_at) VALUES
+			(1, 10, '2020-01-01T00:00:00Z'),
+			(2, 20, '2030-01-01T00:00:00Z'),
+			(3, 30, '2021-01-01T00:00:00Z');
+	`)
+	require.NoError(t, err)
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	n, err := db.DB.DeleteExpiredRows(now)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	count := func(q string) int {
+		t.Helper()
+
+		var n int
+		r, err := db.QueryRow(q)
+		require.NoError(t, err)
+		require.NoError(t, r.Scan(&n))
+		return n
+	}
+
+	require.Equal(t, 1, count("SELECT COUNT(*) FROM test"))
+	require.Equal(t, 2, count("SELECT COUNT(*) FROM test_archive"))
+	// the indexes of the archive table are updated
+	require.Equal(t, 1, count("SELECT COUNT(*) FROM test_archive WHERE b = 30"))
+
+	stats, err := db.Stats(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, chai.TTLStats{Runs: 1, Archived: 2}, stats.TTL)
+
+	// rows that can't be archived are kept in the table
+	err = db.Exec(`
+		INSERT INTO test(a, b, expires_at) VALUES (4, 10, '2020-01-01T00:00:00Z');
+		UPDATE test SET expires_at = '2020-01-01T00:00:00Z' WHERE a = 2;
+	`)
+	require.NoError(t, err)
+	_, err = db.DB.DeleteExpiredRows(now)
+	require.Error(t, err)
+	require.Equal(t, 2, count("SELECT COUNT(*) FROM test"))
+	require.Equal(t, 2, count("SELECT COUNT(*) FROM test_archive"))
+}
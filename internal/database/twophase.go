@@ -0,0 +1,173 @@
+package database
+
+import (
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/cockroachdb/errors"
+)
+
+// PrepareCommit durably records the changes of the transaction under the given id,
+// the first phase of a two-phase commit. Once prepared, the transaction can
+// only be committed or rolled back, and its changes are not visible until it
+// is committed. It keeps the write lock until then.
+// If its connection or the database is closed, or the process crashes,
+// before the transaction is committed or rolled back, the transaction
+// stays prepared: see Database.PreparedTransaction.
+func (tx *Transaction) PrepareCommit(id string) error {
+	if !tx.Writable {
+		return errors.New("cannot prepare read-only transaction")
+	}
+	if tx.preparedID != "" {
+		return errors.Errorf("transaction already prepared as %q", tx.preparedID)
+	}
+	if id == "" {
+		return errors.New("the id of a prepared transaction cannot be empty")
+	}
+	if len(tx.attached) > 0 {
+		return errors.New("cannot prepare a transaction using attached databases")
+	}
+
+	sess, ok := tx.Session.(engine.TwoPhaseSession)
+	if !ok {
+		return errors.New("the storage engine of the database doesn't support two-phase commit")
+	}
+
+	err := sess.PrepareTwoPhase(id)
+	if err != nil {
+		return err
+	}
+
+	tx.preparedID = id
+	return nil
+}
+
+// Prepared reports whether the transaction was prepared with PrepareCommit.
+func (tx *Transaction) Prepared() bool {
+	return tx.preparedID != ""
+}
+
+// commitPrepared applies the changes of the prepared transaction.
+// If it fails, the transaction stays prepared.
+func (tx *Transaction) commitPrepared() error {
+	tx.db.txmu.Lock()
+	defer tx.db.txmu.Unlock()
+
+	err := tx.Engine.(engine.TwoPhaseEngine).CommitPrepared(tx.preparedID)
+	if err != nil {
+		return err
+	}
+
+	tx.preparedID = ""
+	tx.published()
+	return nil
+}
+
+// rollbackPrepared discards the changes of the prepared transaction.
+func (tx *Transaction) rollbackPrepared() error {
+	err := tx.Engine.(engine.TwoPhaseEngine).RollbackPrepared(tx.preparedID)
+	if err != nil {
+		return err
+	}
+
+	tx.preparedID = ""
+	tx.WriteTxMu.Unlock()
+
+	for i := len(tx.OnRollbackHooks) - 1; i >= 0; i-- {
+		tx.OnRollbackHooks[i]()
+	}
+
+	return nil
+}
+
+// recoverPrepared looks for a transaction prepared before the database
+// was opened. If there is one, the write lock is held until it is
+// committed or rolled back.
+func (db *Database) recoverPrepared() error {
+	ng, ok := db.Engine.(engine.TwoPhaseEngine)
+	if !ok {
+		return nil
+	}
+
+	id, ok, err := ng.PreparedTransaction()
+	if err != nil || !ok {
+		return err
+	}
+
+	db.writetxmu.Lock()
+	db.recoveredTx.Store(&id)
+	return nil
+}
+
+// PreparedTransaction returns the id of the transaction prepared
+// before the database was opened, or whose connection was closed, if any. Until it is committed
+// with CommitPrepared or rolled back with RollbackPrepared,
+// write transactions can't start.
+func (db *Database) PreparedTransaction() (string, bool) {
+	id := db.recoveredTx.Load()
+	if id == nil {
+		return "", false
+	}
+
+	return *id, true
+}
+
+// checkRecovered returns an error if id is not the id
+// of the transaction returned by PreparedTransaction.
+func (db *Database) checkRecovered(id string) error {
+	if rid := db.recoveredTx.Load(); rid == nil || *rid != id {
+		return errors.Errorf("no prepared transaction %q", id)
+	}
+
+	return nil
+}
+
+// CommitPrepared commits the transaction returned by PreparedTransaction.
+func (db *Database) CommitPrepared(id string) error {
+	err := db.checkRecovered(id)
+	if err != nil {
+		return err
+	}
+
+	// the changes are applied within a write transaction, so that
+	// read transactions keep reading the previous state
+	// until the catalog is reloaded.
+	db.txmu.RLock()
+	tx, err := db.beginTxUnlocked(nil)
+	db.txmu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	err = db.Engine.(engine.TwoPhaseEngine).CommitPrepared(id)
+	if err != nil {
+		// keep the write lock: the transaction is still prepared
+		_ = tx.Session.Close()
+		return errors.CombineErrors(err, db.Engine.Rollback())
+	}
+	db.recoveredTx.Store(nil)
+	defer tx.Rollback()
+
+	// the transaction may have modified the schema
+	err = tx.reloadCatalog()
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RollbackPrepared rolls back the transaction returned by PreparedTransaction.
+func (db *Database) RollbackPrepared(id string) error {
+	err := db.checkRecovered(id)
+	if err != nil {
+		return err
+	}
+
+	err = db.Engine.(engine.TwoPhaseEngine).RollbackPrepared(id)
+	if err != nil {
+		return err
+	}
+
+	db.recoveredTx.Store(nil)
+	db.writetxmu.Unlock()
+	return nil
+}
@@ -0,0 +1,138 @@
+package database_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTwoPhaseCommit(t *testing.T) {
+	dir, err := os.MkdirTemp("", "chai")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "testdb")
+	db, err := chai.Open(path)
+	require.NoError(t, err)
+	defer func() { db.Close() }()
+
+	err = db.Exec("CREATE TABLE test(a INT PRIMARY KEY)")
+	require.NoError(t, err)
+
+	conn, err := db.Connect()
+	require.NoError(t, err)
+
+	requireCount := func(t *testing.T, want string) {
+		t.Helper()
+
+		r, err := db.QueryRow("SELECT COUNT(*) AS n FROM test")
+		require.NoError(t, err)
+		testutil.RequireJSONEq(t, r, want)
+	}
+
+	t.Run("commit", func(t *testing.T) {
+		tx, err := conn.Begin(true)
+		require.NoError(t, err)
+
+		err = tx.Exec("INSERT INTO test (a) VALUES (1)")
+		require.NoError(t, err)
+
+		err = tx.PrepareCommit("a")
+		require.NoError(t, err)
+
+		// prepared changes are not visible
+		requireCount(t, `{"n": 0}`)
+
+		err = tx.Exec("INSERT INTO test (a) VALUES (2)")
+		require.Error(t, err)
+
+		err = tx.Commit()
+		require.NoError(t, err)
+
+		requireCount(t, `{"n": 1}`)
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		tx, err := conn.Begin(true)
+		require.NoError(t, err)
+
+		err = tx.Exec("INSERT INTO test (a) VALUES (2)")
+		require.NoError(t, err)
+
+		err = tx.PrepareCommit("b")
+		require.NoError(t, err)
+
+		err = tx.Rollback()
+		require.NoError(t, err)
+
+		requireCount(t, `{"n": 1}`)
+	})
+
+	t.Run("recover", func(t *testing.T) {
+		tx, err := conn.Begin(true)
+		require.NoError(t, err)
+
+		err = tx.Exec("INSERT INTO test (a) VALUES (3); CREATE TABLE other(a INT)")
+		require.NoError(t, err)
+
+		err = tx.PrepareCommit("c")
+		require.NoError(t, err)
+
+		// the process stops before resolving the transaction
+		err = conn.Close()
+		require.NoError(t, err)
+
+		id, ok := db.PreparedTransaction()
+		require.True(t, ok)
+		require.Equal(t, "c", id)
+
+		err = db.Close()
+		require.NoError(t, err)
+
+		db, err = chai.Open(path)
+		require.NoError(t, err)
+
+		id, ok = db.PreparedTransaction()
+		require.True(t, ok)
+		require.Equal(t, "c", id)
+
+		requireCount(t, `{"n": 1}`)
+
+		err = db.CommitPrepared("unknown")
+		require.Error(t, err)
+
+		err = db.CommitPrepared("c")
+		require.NoError(t, err)
+
+		_, ok = db.PreparedTransaction()
+		require.False(t, ok)
+
+		requireCount(t, `{"n": 2}`)
+		err = db.Exec("INSERT INTO other (a) VALUES (1)")
+		require.NoError(t, err)
+
+		conn, err = db.Connect()
+		require.NoError(t, err)
+
+		tx, err = conn.Begin(true)
+		require.NoError(t, err)
+
+		err = tx.Exec("INSERT INTO test (a) VALUES (4)")
+		require.NoError(t, err)
+
+		err = tx.PrepareCommit("d")
+		require.NoError(t, err)
+
+		err = conn.Close()
+		require.NoError(t, err)
+
+		err = db.RollbackPrepared("d")
+		require.NoError(t, err)
+
+		requireCount(t, `{"n": 2}`)
+	})
+}
@@ -0,0 +1,357 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// maxReportedRows is the maximum number of corrupted rows
+// listed for each table by a VerifyReport.
+const maxReportedRows = 100
+
+// VerifyOptions configures how Verify checks the database.
+type VerifyOptions struct {
+	// If set, the indexes whose entries don't match the rows
+	// of their table are rebuilt with ReIndex once the check is done.
+	Repair bool
+}
+
+// A VerifyReport lists the problems found by Verify.
+type VerifyReport struct {
+	Tables  []TableReport `json:"tables"`
+	Indexes []IndexReport `json:"indexes"`
+}
+
+// OK returns whether no problem remains in the database.
+func (r *VerifyReport) OK() bool {
+	for _, t := range r.Tables {
+		if t.Corrupted > 0 {
+			return false
+		}
+	}
+	for _, idx := range r.Indexes {
+		if !idx.OK() && !idx.Rebuilt {
+			return false
+		}
+	}
+
+	return true
+}
+
+// A TableReport describes the rows of a table checked by Verify.
+type TableReport struct {
+	Name string `json:"name"`
+	Rows int    `json:"rows"`
+	// Number of rows that cannot be decoded
+	// or whose key doesn't match their primary key.
+	Corrupted int `json:"corrupted"`
+	// The first corrupted rows.
+	CorruptedRows []CorruptedRow `json:"corruptedRows,omitempty"`
+}
+
+// A CorruptedRow is a row of a table that failed the check.
+type CorruptedRow struct {
+	Key   string `json:"key"`
+	Error string `json:"error"`
+}
+
+// An IndexReport describes the entries of an index checked by Verify.
+type IndexReport struct {
+	Name    string `json:"name"`
+	Table   string `json:"table"`
+	Entries int    `json:"entries"`
+	// Number of entries expected from the rows of the table
+	// but absent from the index.
+	Missing int `json:"missing"`
+	// Number of entries of the index matching no row of the table.
+	Dangling int `json:"dangling"`
+	// Set if the index was rebuilt by the repair.
+	Rebuilt bool `json:"rebuilt,omitempty"`
+	// Set if the index couldn't be checked nor repaired
+	// because its table has corrupted rows.
+	Skipped bool `json:"skipped,omitempty"`
+}
+
+// OK returns whether the entries of the index match the rows of its table.
+func (r *IndexReport) OK() bool {
+	return r.Missing == 0 && r.Dangling == 0 && !r.Skipped
+}
+
+// Verify checks the integrity of the database in a single read transaction:
+// every row of every table must be decodable and stored under the key
+// of its primary key, and every index must contain exactly the entries
+// built from the rows of its table.
+// If opts.Repair is set, the indexes failing the check are rebuilt.
+// Corrupted rows are reported but never modified.
+func (db *Database) Verify(ctx context.Context, opts *VerifyOptions) (*VerifyReport, error) {
+	if opts == nil {
+		opts = new(VerifyOptions)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	tx, err := db.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := verify(ctx, tx)
+	_ = tx.Rollback()
+	if err != nil {
+		return nil, err
+	}
+
+	if !opts.Repair {
+		return report, nil
+	}
+
+	for i := range report.Indexes {
+		idx := &report.Indexes[i]
+		if idx.OK() || idx.Skipped {
+			continue
+		}
+
+		err = db.ReIndex(ctx, idx.Name, nil)
+		if err != nil {
+			return report, errors.Wrapf(err, "cannot rebuild index %s", idx.Name)
+		}
+		idx.Rebuilt = true
+	}
+
+	return report, nil
+}
+
+func verify(ctx context.Context, tx *Transaction) (*VerifyReport, error) {
+	var report VerifyReport
+
+	for _, name := range tx.Catalog.Cache.ListObjects(RelationTableType) {
+		if ctx.Err() != nil {
+			return nil, context.Cause(ctx)
+		}
+
+		table, err := tx.Catalog.GetTable(tx, name)
+		if err != nil {
+			return nil, err
+		}
+
+		tr, err := verifyTable(ctx, table)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot check table %s", name)
+		}
+		report.Tables = append(report.Tables, *tr)
+
+		for _, indexName := range tx.Catalog.ListIndexes(name) {
+			ir := IndexReport{
+				Name:  indexName,
+				Table: name,
+			}
+
+			// the entries of the corrupted rows cannot be computed
+			if tr.Corrupted > 0 {
+				ir.Skipped = true
+			} else {
+				err = verifyIndex(ctx, tx, table, &ir)
+				if err != nil {
+					return nil, errors.Wrapf(err, "cannot check index %s", indexName)
+				}
+			}
+
+			report.Indexes = append(report.Indexes, ir)
+		}
+	}
+
+	return &report, nil
+}
+
+func verifyTable(ctx context.Context, table *Table) (*TableReport, error) {
+	report := TableReport{
+		Name: table.Info.TableName,
+	}
+
+	err := table.Tree.IterateOnRange(nil, false, func(k *tree.Key, enc []byte) error {
+		if report.Rows%1000 == 0 && ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+		report.Rows++
+
+		err := verifyRow(table.Info, table.Tx.BlobReader(), k, enc)
+		if err != nil {
+			report.Corrupted++
+			if len(report.CorruptedRows) < maxReportedRows {
+				report.CorruptedRows = append(report.CorruptedRows, CorruptedRow{
+					Key:   fmt.Sprintf("%x", k.Encoded),
+					Error: err.Error(),
+				})
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+// verifyRow ensures every column of the row can be decoded
+// and that the row is stored under the key of its primary key.
+// Values stored outside of the row are read with blobs.
+func verifyRow(info *TableInfo, blobs BlobReader, k *tree.Key, enc []byte) (err error) {
+	// decoding corrupted values may panic
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Errorf("invalid encoding: %v", r)
+		}
+	}()
+
+	e := EncodedRow{blobs: blobs}
+	b := enc
+	values := make(map[string]types.Value, len(info.ColumnConstraints.Ordered))
+	for _, cc := range info.ColumnConstraints.Ordered {
+		if len(b) == 0 {
+			return errors.Errorf("column %s is missing", cc.Column)
+		}
+
+		n := encoding.Skip(b)
+		if n == 0 || n > len(b) {
+			return errors.Errorf("invalid encoding of column %s", cc.Column)
+		}
+
+		v, _, err := e.decodeValue(cc, b[:n])
+		if err != nil {
+			return errors.Wrapf(err, "invalid encoding of column %s", cc.Column)
+		}
+		if cc.IsNotNull && v.Type() == types.TypeNull {
+			return errors.Errorf("column %s is NULL", cc.Column)
+		}
+
+		values[cc.Column] = v
+		b = b[n:]
+	}
+	if len(b) > 0 {
+		return errors.Errorf("%d unexpected bytes after the last column", len(b))
+	}
+
+	pk := info.PrimaryKey
+	if pk == nil {
+		return nil
+	}
+
+	vs := make([]types.Value, len(pk.Columns))
+	for i, c := range pk.Columns {
+		vs[i] = values[c]
+	}
+	key, err := tree.NewKey(vs...).Encode(info.StoreNamespace, pk.SortOrder)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(key, k.Encoded) {
+		return errors.New("the key doesn't match the primary key of the row")
+	}
+
+	return nil
+}
+
+// verifyIndex builds the entries of the index from the rows of the table
+// in a transient tree and compares them with the entries of the index.
+func verifyIndex(ctx context.Context, tx *Transaction, table *Table, report *IndexReport) error {
+	info, err := tx.Catalog.GetIndexInfo(report.Name)
+	if err != nil {
+		return err
+	}
+
+	idx, err := tx.Catalog.GetIndex(tx, report.Name)
+	if err != nil {
+		return err
+	}
+
+	session := tx.db.Engine.NewTransientSession()
+	defer session.Close()
+
+	tr, cleanup, err := tree.NewTransient(session, tx.Catalog.GetFreeTransientNamespace(), info.KeySortOrder)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	expected := NewIndex(tr, *info)
+	expected.Collations, err = table.Info.Collations(info.Columns)
+	if err != nil {
+		return err
+	}
+
+	err = table.IterateOnRange(nil, false, func(key *tree.Key, r Row) error {
+		vs, err := info.KeyValues(tx, r)
+		if err != nil {
+			return err
+		}
+
+		encKey, err := table.Info.EncodeKey(key)
+		if err != nil {
+			return err
+		}
+
+		return expected.Set(vs, bytes.Clone(encKey))
+	})
+	if err != nil {
+		return err
+	}
+
+	// entries of the index absent from the expected tree
+	err = idx.Tree.IterateOnRange(nil, false, func(k *tree.Key, v []byte) error {
+		if report.Entries%1000 == 0 && ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+		report.Entries++
+
+		ok, err := sameEntry(tr, k, v)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			report.Dangling++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// and expected entries absent from the index
+	return tr.IterateOnRange(nil, false, func(k *tree.Key, v []byte) error {
+		ok, err := sameEntry(idx.Tree, k, v)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			report.Missing++
+		}
+		return nil
+	})
+}
+
+// sameEntry returns whether the tree contains the entry
+// of another tree, with the same value.
+func sameEntry(t *tree.Tree, k *tree.Key, v []byte) (bool, error) {
+	n := encoding.Skip(k.Encoded)
+	key := encoding.EncodeUint(nil, uint64(t.Namespace))
+	key = append(key, k.Encoded[n:]...)
+
+	got, err := t.Get(tree.NewEncodedKey(key))
+	if err != nil {
+		if errors.Is(err, engine.ErrKeyNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return bytes.Equal(got, v), nil
+}
@@ -0,0 +1,94 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/testutil"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify(t *testing.T) {
+	db, tx, cleanup := testutil.NewTestTx(t)
+	defer cleanup()
+
+	testutil.MustExec(t, db, tx, `
+		CREATE TABLE test(a INT PRIMARY KEY, b INT);
+		CREATE INDEX test_b_idx ON test(b);
+		CREATE UNIQUE INDEX test_expr_idx ON test((b + 1));
+		INSERT INTO test(a, b) VALUES (1, 10), (2, 20), (3, 30), (4, 40), (5, 50);
+	`)
+	require.NoError(t, tx.Commit())
+
+	indexReport := func(r *database.VerifyReport, name string) database.IndexReport {
+		t.Helper()
+
+		for _, idx := range r.Indexes {
+			if idx.Name == name {
+				return idx
+			}
+		}
+		t.Fatalf("index %s not found in the report", name)
+		return database.IndexReport{}
+	}
+
+	report, err := db.Verify(context.Background(), nil)
+	require.NoError(t, err)
+	require.True(t, report.OK())
+	require.Equal(t, 5, indexReport(report, "test_b_idx").Entries)
+	require.Equal(t, 5, indexReport(report, "test_expr_idx").Entries)
+
+	// remove the entries of an index and add a dangling one to the other
+	tx, err = db.Begin(true)
+	require.NoError(t, err)
+	idx, err := tx.Catalog.GetIndex(tx, "test_b_idx")
+	require.NoError(t, err)
+	require.NoError(t, idx.Tree.Truncate())
+	idx, err = tx.Catalog.GetIndex(tx, "test_expr_idx")
+	require.NoError(t, err)
+	table, err := tx.Catalog.GetTable(tx, "test")
+	require.NoError(t, err)
+	key, err := table.Info.EncodeKey(tree.NewKey(types.NewIntegerValue(10)))
+	require.NoError(t, err)
+	require.NoError(t, idx.Set([]types.Value{types.NewIntegerValue(100)}, key))
+	require.NoError(t, tx.Commit())
+
+	report, err = db.Verify(context.Background(), nil)
+	require.NoError(t, err)
+	require.False(t, report.OK())
+	require.Equal(t, database.IndexReport{Name: "test_b_idx", Table: "test", Missing: 5}, indexReport(report, "test_b_idx"))
+	require.Equal(t, database.IndexReport{Name: "test_expr_idx", Table: "test", Entries: 6, Dangling: 1}, indexReport(report, "test_expr_idx"))
+
+	report, err = db.Verify(context.Background(), &database.VerifyOptions{Repair: true})
+	require.NoError(t, err)
+	require.True(t, report.OK())
+	require.True(t, indexReport(report, "test_b_idx").Rebuilt)
+	require.True(t, indexReport(report, "test_expr_idx").Rebuilt)
+
+	report, err = db.Verify(context.Background(), nil)
+	require.NoError(t, err)
+	require.True(t, report.OK())
+
+	// corrupt a row: its indexes cannot be checked anymore
+	tx, err = db.Begin(true)
+	require.NoError(t, err)
+	table, err = tx.Catalog.GetTable(tx, "test")
+	require.NoError(t, err)
+	require.NoError(t, table.Tree.Put(tree.NewKey(types.NewIntegerValue(3)), []byte{0xFF}))
+	require.NoError(t, tx.Commit())
+
+	report, err = db.Verify(context.Background(), &database.VerifyOptions{Repair: true})
+	require.NoError(t, err)
+	require.False(t, report.OK())
+	for _, tr := range report.Tables {
+		if tr.Name == "test" {
+			require.Equal(t, 1, tr.Corrupted)
+			require.Len(t, tr.CorruptedRows, 1)
+		}
+	}
+	require.True(t, indexReport(report, "test_b_idx").Skipped)
+	require.False(t, indexReport(report, "test_b_idx").Rebuilt)
+}
@@ -40,3 +40,34 @@ func DecodeText(b []byte) (string, int) {
 	b = b[n : n+int(l)]
 	return string(b), 1 + n + int(l)
 }
+
+// EncodeExternal encodes a pointer to a value stored outside of the row.
+// The pointer is prefixed by the type of the value it references.
+func EncodeExternal(dst []byte, typ byte, ptr []byte) []byte {
+	buf := make([]byte, binary.MaxVarintLen64+1)
+	buf[0] = ExternalValue
+	n := binary.PutUvarint(buf[1:], uint64(len(ptr)+1))
+
+	dst = append(dst, buf[:n+1]...)
+	dst = append(dst, typ)
+	return append(dst, ptr...)
+}
+
+// DecodeExternal returns the type of the referenced value and the pointer.
+func DecodeExternal(b []byte) (byte, []byte, int) {
+	ptr, n := DecodeBlob(b)
+	return ptr[0], ptr[1:], n
+}
+
+// EncodeUUID encodes a UUID on 17 bytes: its type
+// followed by the 16 bytes of the UUID, which sort like their
+// canonical text representation.
+func EncodeUUID(dst []byte, x [16]byte) []byte {
+	dst = append(dst, UUIDValue)
+	return append(dst, x[:]...)
+}
+
+func DecodeUUID(b []byte) ([16]byte, int) {
+	// skip type
+	return [16]byte(b[1:17]), 17
+}
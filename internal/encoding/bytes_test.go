@@ -57,3 +57,15 @@ func TestEncodeDecodeBlob(t *testing.T) {
 		})
 	}
 }
+
+func TestEncodeDecodeUUID(t *testing.T) {
+	u := [16]byte{0x01, 0x8f, 0x3e, 0x2a, 0x7c, 0x11, 0x7a, 0xbc, 0x8d, 0xef, 0x01, 0x23, 0x45, 0x67, 0x89, 0xab}
+
+	got := encoding.EncodeUUID([]byte{0xFF}, u)
+	require.Equal(t, append([]byte{0xFF, encoding.UUIDValue}, u[:]...), got)
+
+	x, n := encoding.DecodeUUID(got[1:])
+	require.Equal(t, u, x)
+	require.Equal(t, 17, n)
+	require.Equal(t, 17, encoding.Skip(got[1:]))
+}
@@ -0,0 +1,153 @@
+package encoding
+
+import (
+	"encoding/binary"
+	"math/big"
+	"strings"
+)
+
+// Decimals are encoded as a length-prefixed payload,
+// whose bytes sort like the numbers they represent.
+// The payload starts with the sign of the number.
+// Non-zero numbers are written as 0.d1d2d3... * 10^exp:
+// the exponent is followed by the digits, two per byte.
+// Trailing zeros are removed, so that equal numbers have the same key
+// whatever their scale. Negative numbers are written with inverted bytes,
+// followed by a terminator.
+const (
+	decimalNegative byte = 1
+	decimalZero     byte = 2
+	decimalPositive byte = 3
+
+	decimalNegativeEnd byte = 0xFF
+
+	// marks the scale, stored after the key by EncodeDecimal.
+	decimalScale byte = 0
+)
+
+// EncodeDecimal encodes the decimal number coef * 10^-scale,
+// keeping its scale.
+func EncodeDecimal(dst []byte, coef *big.Int, scale int32) []byte {
+	payload := appendDecimal(nil, coef, scale)
+	payload = append(payload, decimalScale)
+	payload = binary.AppendUvarint(payload, uint64(scale))
+
+	return appendDecimalPayload(dst, payload)
+}
+
+// EncodeDecimalKey encodes the decimal number coef * 10^-scale
+// without its scale: equal numbers have the same encoding.
+func EncodeDecimalKey(dst []byte, coef *big.Int, scale int32) []byte {
+	return appendDecimalPayload(dst, appendDecimal(nil, coef, scale))
+}
+
+func appendDecimalPayload(dst, payload []byte) []byte {
+	dst = append(dst, DecimalValue)
+	dst = binary.AppendUvarint(dst, uint64(len(payload)))
+	return append(dst, payload...)
+}
+
+func appendDecimal(dst []byte, coef *big.Int, scale int32) []byte {
+	sign := coef.Sign()
+	if sign == 0 {
+		return append(dst, decimalZero)
+	}
+
+	digits := coef.Text(10)
+	if sign < 0 {
+		digits = digits[1:]
+	}
+	exp := uint32(int32(len(digits))-scale) ^ (1 << 31)
+	digits = strings.TrimRight(digits, "0")
+
+	if sign > 0 {
+		dst = append(dst, decimalPositive)
+		dst = binary.BigEndian.AppendUint32(dst, exp)
+	} else {
+		dst = append(dst, decimalNegative)
+		dst = binary.BigEndian.AppendUint32(dst, ^exp)
+	}
+
+	for i := 0; i < len(digits); i += 2 {
+		pair := int(digits[i]-'0') * 10
+		if i+1 < len(digits) {
+			pair += int(digits[i+1] - '0')
+		}
+
+		if sign > 0 {
+			dst = append(dst, byte(pair+1))
+		} else {
+			dst = append(dst, byte(254-pair))
+		}
+	}
+
+	if sign < 0 {
+		dst = append(dst, decimalNegativeEnd)
+	}
+
+	return dst
+}
+
+// DecodeDecimal decodes a decimal encoded by EncodeDecimal or EncodeDecimalKey.
+// The scale of numbers encoded by EncodeDecimalKey is the smallest
+// scale representing them exactly.
+func DecodeDecimal(b []byte) (coef *big.Int, scale int32, n int) {
+	// skip type
+	l, nn := binary.Uvarint(b[1:])
+	n = 1 + nn + int(l)
+	payload := b[1+nn : n]
+
+	coef = new(big.Int)
+	sign := payload[0]
+	payload = payload[1:]
+
+	var digits []byte
+	var exp int32
+	if sign != decimalZero {
+		e := binary.BigEndian.Uint32(payload)
+		if sign == decimalNegative {
+			e = ^e
+		}
+		exp = int32(e ^ (1 << 31))
+		payload = payload[4:]
+
+		for len(payload) > 0 && payload[0] != decimalScale && payload[0] != decimalNegativeEnd {
+			pair := int(payload[0]) - 1
+			if sign == decimalNegative {
+				pair = 254 - int(payload[0])
+			}
+			digits = append(digits, byte('0'+pair/10), byte('0'+pair%10))
+			payload = payload[1:]
+		}
+
+		if sign == decimalNegative {
+			// skip the terminator
+			payload = payload[1:]
+		}
+
+		// remove the padding of the last pair
+		for len(digits) > 0 && digits[len(digits)-1] == '0' {
+			digits = digits[:len(digits)-1]
+		}
+		coef.SetString(string(digits), 10)
+	}
+
+	// smallest scale representing the number
+	scale = max(int32(len(digits))-exp, 0)
+	if len(payload) > 0 && payload[0] == decimalScale {
+		s, _ := binary.Uvarint(payload[1:])
+		scale = int32(s)
+	}
+
+	// coef is 0.digits * 10^exp * 10^scale
+	shift := scale - (int32(len(digits)) - exp)
+	if shift > 0 {
+		coef.Mul(coef, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(shift)), nil))
+	}
+
+	if sign == decimalNegative {
+		coef.Neg(coef)
+	}
+
+	return coef, scale, n
+}
@@ -165,9 +165,19 @@ func EncodeFloat(dst []byte, x float64) []byte {
 	return EncodeFloat64(dst, x)
 }
 
+// EncodeFloat64 encodes x so that the encoded values sort like the numbers.
+// All NaN values are encoded as the same NaN, which sorts after +Inf,
+// and -0 is encoded as 0.
 func EncodeFloat64(dst []byte, x float64) []byte {
+	switch {
+	case math.IsNaN(x):
+		x = math.NaN()
+	case x == 0:
+		x = 0
+	}
+
 	fb := math.Float64bits(x)
-	if x >= 0 {
+	if fb>>63 == 0 {
 		fb ^= 1 << 63
 	} else {
 		fb ^= 1<<64 - 1
@@ -11,7 +11,8 @@ package encoding
 const (
 	TombstoneValue byte = 0
 
-	// 1: 1 type is free
+	// Pointer to a value stored outside of the row
+	ExternalValue byte = 1
 
 	// Null
 	NullValue byte = 2
@@ -46,7 +47,12 @@ const (
 	// Floating point numbers
 	Float64Value byte = 90
 
-	// 92 to 97: 6 types are free
+	// 91: 1 type is free
+
+	// Exact decimal numbers
+	DecimalValue byte = 92
+
+	// 93 to 97: 5 types are free
 
 	// Text
 	TextValue byte = 98
@@ -56,7 +62,12 @@ const (
 	// Binary
 	BlobValue byte = 103
 
-	// 104 to 109: 6 types are free
+	// 104: 1 type is free
+
+	// UUIDs
+	UUIDValue byte = 105
+
+	// 106 to 109: 4 types are free
 
 	// Arrays
 	ArrayValue byte = 110
@@ -74,8 +85,10 @@ const (
 	// DESC_ prefix means that the value is encoded in reverse order.
 	DESC_ObjectValue   byte = 255 - ObjectValue
 	DESC_ArrayValue    byte = 255 - ArrayValue
+	DESC_UUIDValue     byte = 255 - UUIDValue
 	DESC_BlobValue     byte = 255 - BlobValue
 	DESC_TextValue     byte = 255 - TextValue
+	DESC_DecimalValue  byte = 255 - DecimalValue
 	DESC_Float64Value  byte = 255 - Float64Value
 	DESC_Uint64Value   byte = 255 - Uint64Value
 	DESC_Uint32Value   byte = 255 - Uint32Value
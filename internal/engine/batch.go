@@ -0,0 +1,119 @@
+package engine
+
+import "github.com/cockroachdb/errors"
+
+type batchOpKind uint8
+
+const (
+	batchPut batchOpKind = iota + 1
+	batchDelete
+	batchDeleteRange
+)
+
+type batchOp struct {
+	kind batchOpKind
+	// key, or start of the range
+	k []byte
+	// value, or end of the range
+	v []byte
+}
+
+// A Batch accumulates writes that are applied to a session at once
+// by ApplyBatch, in the order they were added.
+// Unlike the writes of a Session, the writes of a Batch are not checked:
+// deleting a key that doesn't exist is not an error.
+type Batch struct {
+	ops []batchOp
+}
+
+// Put stores a key-value pair, overriding any existing value.
+// The key and value must not be modified until the batch is applied.
+func (b *Batch) Put(k, v []byte) error {
+	if len(k) == 0 {
+		return errors.New("cannot store empty key")
+	}
+	if len(v) == 0 {
+		return errors.New("cannot store empty value")
+	}
+
+	b.ops = append(b.ops, batchOp{kind: batchPut, k: k, v: v})
+	return nil
+}
+
+// Delete removes a key.
+func (b *Batch) Delete(k []byte) {
+	b.ops = append(b.ops, batchOp{kind: batchDelete, k: k})
+}
+
+// DeleteRange removes all the keys between start (inclusive) and end (exclusive).
+func (b *Batch) DeleteRange(start, end []byte) {
+	b.ops = append(b.ops, batchOp{kind: batchDeleteRange, k: start, v: end})
+}
+
+// Len returns the number of writes of the batch.
+func (b *Batch) Len() int {
+	return len(b.ops)
+}
+
+// Reset removes all the writes of the batch, so that it can be reused.
+func (b *Batch) Reset() {
+	clear(b.ops)
+	b.ops = b.ops[:0]
+}
+
+// Iterate calls the matching function for each write of the batch, in order.
+// It is meant to be used by the implementations of BatchApplier.
+func (b *Batch) Iterate(put func(k, v []byte) error, del func(k []byte) error, delRange func(start, end []byte) error) error {
+	for _, op := range b.ops {
+		var err error
+		switch op.kind {
+		case batchPut:
+			err = put(op.k, op.v)
+		case batchDelete:
+			err = del(op.k)
+		case batchDeleteRange:
+			err = delRange(op.k, op.v)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// HasDeleteRange returns whether the batch deletes ranges of keys.
+func (b *Batch) HasDeleteRange() bool {
+	for _, op := range b.ops {
+		if op.kind == batchDeleteRange {
+			return true
+		}
+	}
+
+	return false
+}
+
+// A BatchApplier is a Session able to apply the writes of a Batch
+// more efficiently than one by one.
+type BatchApplier interface {
+	// ApplyBatch applies all the writes of the batch at once:
+	// they are never split between two writes of the session
+	// to the underlying store.
+	ApplyBatch(b *Batch) error
+}
+
+// ApplyBatch applies the writes of the batch to the session.
+// If the session doesn't implement BatchApplier, the writes are applied one by one.
+func ApplyBatch(s Session, b *Batch) error {
+	if ba, ok := s.(BatchApplier); ok {
+		return ba.ApplyBatch(b)
+	}
+
+	return b.Iterate(s.Put, func(k []byte) error {
+		err := s.Delete(k)
+		if errors.Is(err, ErrKeyNotFound) {
+			return nil
+		}
+		return err
+	}, s.DeleteRange)
+}
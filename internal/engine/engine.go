@@ -23,6 +23,13 @@ type Engine interface {
 	NewTransientSession() Session
 }
 
+// A Session reads and writes the keys of an engine.
+// A session is used by one goroutine at a time, except the sessions returned
+// by NewSnapshotSession, which are shared by the transactions reading a
+// pinned snapshot: their Get, Exists and Iterator methods must be safe for
+// concurrent use. Iterators are never shared. Goroutines of a single
+// transaction reading its snapshot concurrently, such as the workers of a
+// parallel scan, each use a session returned by ForkableSession.Fork.
 type Session interface {
 	Commit() error
 	Close() error
@@ -40,6 +47,73 @@ type Session interface {
 	Iterator(opts *IterOptions) (Iterator, error)
 }
 
+// A ForkableSession is a read-only Session able to create
+// other sessions reading the same snapshot.
+type ForkableSession interface {
+	Session
+
+	// Fork returns a new session reading the same snapshot as the session,
+	// which can be used by another goroutine. It must be closed
+	// independently of the session.
+	Fork() Session
+}
+
+// A SavepointSession is a Session that can undo part of its writes
+// without being closed.
+type SavepointSession interface {
+	Session
+
+	// Savepoint marks the current state of the session and returns an identifier
+	// for it. Savepoints are nested: the identifiers increase with each call.
+	Savepoint() (int, error)
+	// RollbackToSavepoint undoes all the writes made since the given savepoint was created.
+	// Savepoints created after it are destroyed, the given savepoint remains active.
+	RollbackToSavepoint(id int) error
+	// ReleaseSavepoint destroys the given savepoint and all the savepoints created after it,
+	// keeping the writes made since.
+	ReleaseSavepoint(id int) error
+}
+
+// A StagedSession is a Session whose commit can be split in two steps:
+// writing the changes, which may wait for the disk, then publishing them.
+// Read sessions created in between keep reading the data as it was
+// before the session started.
+type StagedSession interface {
+	Session
+
+	// Prepare writes the changes of the session without making them
+	// visible to new read sessions. Once prepared, the changes can't be
+	// rolled back and the session must be published.
+	Prepare() error
+	// Publish makes the prepared changes visible to new read sessions
+	// and closes the session.
+	Publish() error
+}
+
+// A TwoPhaseSession is a Session whose changes can be durably prepared
+// before being committed or rolled back by its TwoPhaseEngine,
+// possibly after the engine was reopened.
+type TwoPhaseSession interface {
+	Session
+
+	// PrepareTwoPhase durably records the changes of the session under the given id,
+	// without making them visible, and closes the session.
+	// Only one transaction can be prepared at a time.
+	PrepareTwoPhase(id string) error
+}
+
+// A TwoPhaseEngine is an Engine whose batch sessions are TwoPhaseSessions.
+type TwoPhaseEngine interface {
+	Engine
+
+	// PreparedTransaction returns the id of the prepared transaction, if any.
+	PreparedTransaction() (id string, ok bool, err error)
+	// CommitPrepared applies the changes of the prepared transaction.
+	CommitPrepared(id string) error
+	// RollbackPrepared discards the changes of the prepared transaction.
+	RollbackPrepared(id string) error
+}
+
 type Iterator interface {
 	Close() error
 	First() bool
@@ -47,6 +121,12 @@ type Iterator interface {
 	Valid() bool
 	Next() bool
 	Prev() bool
+	// SeekGE moves the iterator to the first key greater than or equal to the given key,
+	// without going below the lower bound of the iterator.
+	SeekGE(key []byte) bool
+	// SeekLT moves the iterator to the last key less than the given key,
+	// without going above the upper bound of the iterator.
+	SeekLT(key []byte) bool
 	Error() error
 	Key() []byte
 	Value() ([]byte, error)
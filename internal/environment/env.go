@@ -1,6 +1,7 @@
 package environment
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/chaisql/chai/internal/database"
@@ -24,6 +25,8 @@ type Environment struct {
 	Row    row.Row
 	DB     *database.Database
 	Tx     *database.Transaction
+	// Ctx is the context of the query, used to cancel it.
+	Ctx context.Context
 
 	Outer *Environment
 }
@@ -132,3 +135,30 @@ func (e *Environment) GetDB() *database.Database {
 
 	return nil
 }
+
+// GetContext returns the context of the query,
+// or context.Background if none is set.
+func (e *Environment) GetContext() context.Context {
+	if e.Ctx != nil {
+		return e.Ctx
+	}
+
+	if outer := e.GetOuter(); outer != nil {
+		return outer.GetContext()
+	}
+
+	return context.Background()
+}
+
+// Err returns the cause of the cancellation of the query,
+// or nil if it is still running.
+// Operators reading rows call it for each row to stop cancelled queries.
+func (e *Environment) Err() error {
+	ctx := e.GetContext()
+	select {
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	default:
+		return nil
+	}
+}
@@ -25,6 +25,8 @@ func (op *arithmeticOperator) Clone() Expr {
 
 func (op *arithmeticOperator) Eval(env *environment.Environment) (types.Value, error) {
 	return op.simpleOperator.eval(env, func(va, vb types.Value) (types.Value, error) {
+		va, vb = exactOperand(op.a, va, vb), exactOperand(op.b, vb, va)
+
 		a, ok := va.(types.Numeric)
 		if !ok {
 			return NullLiteral, nil
@@ -71,6 +73,20 @@ func (op *arithmeticOperator) Eval(env *environment.Environment) (types.Value, e
 	})
 }
 
+// exactOperand returns the decimal value of the number literal e
+// if the other operand is a decimal, so that the result is exact.
+func exactOperand(e Expr, v, other types.Value) types.Value {
+	if other.Type() != types.TypeDecimal {
+		return v
+	}
+
+	if l, ok := e.(LiteralValue); ok && l.Exact != nil {
+		return *l.Exact
+	}
+
+	return v
+}
+
 // Add creates an expression thats evaluates to the result of a + b.
 func Add(a, b Expr) Expr {
 	return &arithmeticOperator{&simpleOperator{a, b, scanner.ADD}}
@@ -0,0 +1,88 @@
+package expr
+
+import (
+	"fmt"
+
+	"github.com/chaisql/chai/internal/collation"
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/stringutil"
+	"github.com/chaisql/chai/internal/types"
+)
+
+// Collate is an expression that evaluates to the value of its expression.
+// Comparisons and sorts use the collation key of TEXT values instead,
+// returned by Key, which yields the order defined by the collation.
+type Collate struct {
+	Expr      Expr
+	Collation string
+
+	collation *collation.Collation
+}
+
+// NewCollate returns a Collate expression for the given collation,
+// which must be binary, nocase or a BCP 47 language tag (i.e. "de_DE", "tr").
+func NewCollate(e Expr, name string) (*Collate, error) {
+	c, err := collation.Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Collate{
+		Expr:      e,
+		Collation: name,
+		collation: c,
+	}, nil
+}
+
+func (c *Collate) Eval(env *environment.Environment) (types.Value, error) {
+	return c.Expr.Eval(env)
+}
+
+// Key evaluates the expression and returns the collation key of TEXT values.
+// Other types are returned as is.
+func (c *Collate) Key(env *environment.Environment) (types.Value, error) {
+	v, err := c.Expr.Eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	if v.Type() != types.TypeText || c.collation.IsBinary() {
+		return v, nil
+	}
+
+	return types.NewTextValue(c.collation.Key(types.AsString(v))), nil
+}
+
+// SortKey evaluates e and returns the value used to compare or sort it:
+// the collation key of TEXT values if e is a Collate expression,
+// the value of e otherwise.
+func SortKey(e Expr, env *environment.Environment) (types.Value, error) {
+	if c, ok := e.(*Collate); ok {
+		return c.Key(env)
+	}
+
+	return e.Eval(env)
+}
+
+func (c *Collate) Clone() Expr {
+	return &Collate{
+		Expr:      Clone(c.Expr),
+		Collation: c.Collation,
+		collation: c.collation,
+	}
+}
+
+func (c *Collate) IsEqual(other Expr) bool {
+	o, ok := other.(*Collate)
+	if !ok {
+		return false
+	}
+
+	return collation.Equal(c.Collation, o.Collation) && Equal(c.Expr, o.Expr)
+}
+
+func (c *Collate) Params() []Expr { return []Expr{c.Expr} }
+
+func (c *Collate) String() string {
+	return fmt.Sprintf("%v COLLATE %s", c.Expr, stringutil.NormalizeIdentifier(c.Collation, '"'))
+}
@@ -14,15 +14,27 @@ type cmpOp struct {
 }
 
 // newCmpOp creates a comparison operator.
+// If only one of the operands has an explicit collation,
+// it is applied to the other one as well.
 func newCmpOp(a, b Expr, t scanner.Token) *cmpOp {
+	ca, aOk := a.(*Collate)
+	cb, bOk := b.(*Collate)
+	switch {
+	case aOk && !bOk:
+		b = &Collate{Expr: b, Collation: ca.Collation, collation: ca.collation}
+	case bOk && !aOk:
+		a = &Collate{Expr: a, Collation: cb.Collation, collation: cb.collation}
+	}
+
 	return &cmpOp{&simpleOperator{a, b, t}}
 }
 
 // Eval compares a and b together using the operator specified when constructing the CmpOp
 // and returns the result of the comparison.
 // Comparing with NULL always evaluates to NULL.
+// Collated operands are compared by their collation key.
 func (op *cmpOp) Eval(env *environment.Environment) (types.Value, error) {
-	return op.simpleOperator.eval(env, func(a, b types.Value) (types.Value, error) {
+	return op.simpleOperator.evalWith(env, SortKey, func(a, b types.Value) (types.Value, error) {
 		if a.Type() == types.TypeNull || b.Type() == types.TypeNull {
 			return NullLiteral, nil
 		}
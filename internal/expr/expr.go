@@ -142,6 +142,8 @@ func Walk(e Expr, fn func(Expr) bool) bool {
 		}
 	case *NamedExpr:
 		return Walk(t.Expr, fn)
+	case *Cast:
+		return Walk(t.Expr, fn)
 	case Function:
 		for _, p := range t.Params() {
 			if !Walk(p, fn) {
@@ -254,6 +256,9 @@ func Clone(e Expr) Expr {
 		return &Cast{
 			Expr:   Clone(e.Expr),
 			CastAs: e.CastAs,
+			Format: e.Format,
+			Try:    e.Try,
+			Bits:   e.Bits,
 		}
 	case LiteralValue,
 		*Column,
@@ -0,0 +1,296 @@
+package functions
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/buger/jsonparser"
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// Array functions operate on TEXT values containing a JSON array.
+// Elements are indexed from 0, like in JSON paths.
+
+var arrayLength = &ScalarDefinition{
+	name:  "array_length",
+	arity: 1,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		elems, err := jsonArrayArg("array_length", args[0])
+		if err != nil || elems == nil {
+			return types.NewNullValue(), err
+		}
+
+		return types.NewIntegerValue(int32(len(elems))), nil
+	},
+}
+
+var arrayContains = &ScalarDefinition{
+	name:  "array_contains",
+	arity: 2,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		i, err := arrayIndexOf("array_contains", args[0], args[1])
+		if err != nil || i == -2 {
+			return types.NewNullValue(), err
+		}
+
+		return types.NewBooleanValue(i >= 0), nil
+	},
+}
+
+var arrayPosition = &ScalarDefinition{
+	name:  "array_position",
+	arity: 2,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		i, err := arrayIndexOf("array_position", args[0], args[1])
+		if err != nil || i < 0 {
+			return types.NewNullValue(), err
+		}
+
+		return types.NewIntegerValue(int32(i)), nil
+	},
+}
+
+var arrayAppend = &ScalarDefinition{
+	name:  "array_append",
+	arity: 2,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		elems, err := jsonArrayArg("array_append", args[0])
+		if err != nil || elems == nil {
+			return types.NewNullValue(), err
+		}
+
+		v, err := args[1].MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+
+		return types.NewTextValue(marshalJSONArray(append(elems, jsonElement{raw: v}))), nil
+	},
+}
+
+var arraySlice = &ScalarDefinition{
+	name:  "array_slice",
+	arity: 3,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		elems, err := jsonArrayArg("array_slice", args[0])
+		if err != nil || elems == nil {
+			return types.NewNullValue(), err
+		}
+		if args[1].Type() == types.TypeNull || args[2].Type() == types.TypeNull {
+			return types.NewNullValue(), nil
+		}
+		if !args[1].Type().IsInteger() || !args[2].Type().IsInteger() {
+			return nil, errors.New("array_slice(arg1, arg2, arg3) expects arg2 and arg3 to be integers")
+		}
+
+		start := clampIndex(types.AsInt64(args[1]), len(elems))
+		end := clampIndex(types.AsInt64(args[2]), len(elems))
+		if end < start {
+			end = start
+		}
+
+		return types.NewTextValue(marshalJSONArray(elems[start:end])), nil
+	},
+}
+
+// jsonElement is an element of a JSON array.
+type jsonElement struct {
+	// raw is the JSON representation of the element.
+	raw []byte
+	tp  jsonparser.ValueType
+}
+
+// value converts the element to a value.
+// Objects and arrays are returned as JSON text.
+func (e jsonElement) value() (types.Value, error) {
+	switch e.tp {
+	case jsonparser.Object, jsonparser.Array:
+		return types.NewTextValue(string(e.raw)), nil
+	case jsonparser.String:
+		// strip the quotes
+		return row.ParseJSONValue(e.tp, e.raw[1:len(e.raw)-1])
+	}
+
+	return row.ParseJSONValue(e.tp, e.raw)
+}
+
+// jsonArrayArg returns the elements of the JSON array passed to an array function.
+// It returns nil if the value is NULL.
+func jsonArrayArg(name string, v types.Value) ([]jsonElement, error) {
+	if v.Type() == types.TypeNull {
+		return nil, nil
+	}
+
+	if v.Type() != types.TypeText {
+		return nil, errors.Errorf("%s(arg1) expects arg1 to be a JSON array", name)
+	}
+
+	data := []byte(types.AsString(v))
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		return nil, errors.Errorf("%s(arg1) expects arg1 to be a JSON array", name)
+	}
+
+	elems := []jsonElement{}
+	_, err := jsonparser.ArrayEach(data, func(value []byte, tp jsonparser.ValueType, _ int, _ error) {
+		if tp == jsonparser.String {
+			// jsonparser returns strings without their quotes
+			value = append(append([]byte{'"'}, value...), '"')
+		}
+		elems = append(elems, jsonElement{raw: value, tp: tp})
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "malformed JSON")
+	}
+
+	return elems, nil
+}
+
+// arrayIndexOf returns the position of the first element equal to v,
+// -1 if there is none, or -2 if the array or v are NULL.
+func arrayIndexOf(name string, arr, v types.Value) (int, error) {
+	elems, err := jsonArrayArg(name, arr)
+	if err != nil {
+		return 0, err
+	}
+	if elems == nil || v.Type() == types.TypeNull {
+		return -2, nil
+	}
+
+	for i, e := range elems {
+		ev, err := e.value()
+		if err != nil {
+			return 0, err
+		}
+
+		ok, err := ev.EQ(v)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			return i, nil
+		}
+	}
+
+	return -1, nil
+}
+
+func marshalJSONArray(elems []jsonElement) string {
+	var buf bytes.Buffer
+
+	buf.WriteByte('[')
+	for i, e := range elems {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.Write(e.raw)
+	}
+	buf.WriteByte(']')
+
+	return buf.String()
+}
+
+// clampIndex bounds i to [0, n].
+func clampIndex(i int64, n int) int {
+	if i < 0 {
+		return 0
+	}
+	if i > int64(n) {
+		return n
+	}
+	return int(i)
+}
+
+var _ expr.AggregatorBuilder = (*ArrayAgg)(nil)
+
+// ArrayAgg is the ARRAY_AGG aggregator function.
+// It returns the values of the group as a JSON array.
+type ArrayAgg struct {
+	Expr expr.Expr
+}
+
+func (a *ArrayAgg) Clone() expr.Expr {
+	return &ArrayAgg{
+		Expr: expr.Clone(a.Expr),
+	}
+}
+
+// Eval extracts the aggregated array from the given row and returns it.
+func (a *ArrayAgg) Eval(env *environment.Environment) (types.Value, error) {
+	r, ok := env.GetRow()
+	if !ok {
+		return nil, errors.New("misuse of aggregation function ARRAY_AGG()")
+	}
+
+	return r.Get(a.String())
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (a *ArrayAgg) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*ArrayAgg)
+	if !ok {
+		return false
+	}
+
+	return expr.Equal(a.Expr, o.Expr)
+}
+
+func (a *ArrayAgg) Params() []expr.Expr { return []expr.Expr{a.Expr} }
+
+func (a *ArrayAgg) String() string {
+	return fmt.Sprintf("ARRAY_AGG(%v)", a.Expr)
+}
+
+// Aggregator returns an ArrayAggAggregator. It implements the AggregatorBuilder interface.
+func (a *ArrayAgg) Aggregator() expr.Aggregator {
+	return &ArrayAggAggregator{
+		Fn: a,
+	}
+}
+
+// ArrayAggAggregator is an aggregator that collects all the values of a group,
+// including NULLs.
+type ArrayAggAggregator struct {
+	Fn    *ArrayAgg
+	Elems []jsonElement
+}
+
+// Aggregate appends the JSON representation of the value to the array.
+func (a *ArrayAggAggregator) Aggregate(env *environment.Environment) error {
+	v, err := a.Fn.Expr.Eval(env)
+	if err != nil && !errors.Is(err, types.ErrColumnNotFound) {
+		return err
+	}
+	if v == nil {
+		v = types.NewNullValue()
+	}
+
+	raw, err := v.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	a.Elems = append(a.Elems, jsonElement{raw: raw})
+	return nil
+}
+
+// Eval returns the aggregated values as a JSON array, or NULL if the group is empty.
+func (a *ArrayAggAggregator) Eval(_ *environment.Environment) (types.Value, error) {
+	if len(a.Elems) == 0 {
+		return types.NewNullValue(), nil
+	}
+
+	return types.NewTextValue(marshalJSONArray(a.Elems)), nil
+}
+
+func (a *ArrayAggAggregator) String() string {
+	return a.Fn.String()
+}
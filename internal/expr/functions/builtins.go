@@ -53,6 +53,55 @@ var builtinFunctions = Definitions{
 			return &Avg{Expr: args[0]}, nil
 		},
 	},
+	"stddev_pop": &definition{
+		name:  "stddev_pop",
+		arity: 1,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &Variance{Expr: args[0], Name: "STDDEV_POP", Stddev: true}, nil
+		},
+	},
+	"stddev_samp": &definition{
+		name:  "stddev_samp",
+		arity: 1,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &Variance{Expr: args[0], Name: "STDDEV_SAMP", Sample: true, Stddev: true}, nil
+		},
+	},
+	"var_pop": &definition{
+		name:  "var_pop",
+		arity: 1,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &Variance{Expr: args[0], Name: "VAR_POP"}, nil
+		},
+	},
+	"var_samp": &definition{
+		name:  "var_samp",
+		arity: 1,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &Variance{Expr: args[0], Name: "VAR_SAMP", Sample: true}, nil
+		},
+	},
+	"median": &definition{
+		name:  "median",
+		arity: 1,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &Percentile{Expr: args[0], Name: "MEDIAN", Continuous: true}, nil
+		},
+	},
+	"percentile_cont": &definition{
+		name:  "percentile_cont",
+		arity: 2,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &Percentile{Expr: args[0], Fraction: args[1], Name: "PERCENTILE_CONT", Continuous: true}, nil
+		},
+	},
+	"percentile_disc": &definition{
+		name:  "percentile_disc",
+		arity: 2,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &Percentile{Expr: args[0], Fraction: args[1], Name: "PERCENTILE_DISC"}, nil
+		},
+	},
 	"len": &definition{
 		name:  "len",
 		arity: 1,
@@ -77,16 +126,30 @@ var builtinFunctions = Definitions{
 
 	"lower": &definition{
 		name:  "lower",
-		arity: 1,
+		arity: variadicArity,
 		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
-			return &Lower{Expr: args[0]}, nil
+			switch len(args) {
+			case 1:
+				return &Lower{Expr: args[0]}, nil
+			case 2:
+				return &Lower{Expr: args[0], Locale: args[1]}, nil
+			}
+
+			return nil, fmt.Errorf("lower() takes 1 or 2 arguments, not %d", len(args))
 		},
 	},
 	"upper": &definition{
 		name:  "upper",
-		arity: 1,
+		arity: variadicArity,
 		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
-			return &Upper{Expr: args[0]}, nil
+			switch len(args) {
+			case 1:
+				return &Upper{Expr: args[0]}, nil
+			case 2:
+				return &Upper{Expr: args[0], Locale: args[1]}, nil
+			}
+
+			return nil, fmt.Errorf("upper() takes 1 or 2 arguments, not %d", len(args))
 		},
 	},
 	"trim": &definition{
@@ -111,16 +174,65 @@ var builtinFunctions = Definitions{
 		},
 	},
 
-	"floor":  floor,
-	"abs":    abs,
-	"acos":   acos,
-	"acosh":  acosh,
-	"asin":   asin,
-	"asinh":  asinh,
-	"atan":   atan,
-	"atan2":  atan2,
-	"random": random,
-	"sqrt":   sqrt,
+	"substr":       substr,
+	"replace":      replace,
+	"concat":       concat,
+	"split_part":   splitPart,
+	"lpad":         lpad,
+	"rpad":         rpad,
+	"length":       length,
+	"octet_length": octetLength,
+
+	"floor":      floor,
+	"ceil":       ceil,
+	"round":      round,
+	"abs":        abs,
+	"acos":       acos,
+	"acosh":      acosh,
+	"asin":       asin,
+	"asinh":      asinh,
+	"atan":       atan,
+	"atan2":      atan2,
+	"pow":        pow,
+	"ln":         ln,
+	"log":        log,
+	"sign":       sign,
+	"random":     random,
+	"randomblob": randomblob,
+	"sqrt":       sqrt,
+
+	"uuid":   uuid,
+	"uuidv7": uuidv7,
+
+	"json_extract":     jsonExtract,
+	"json_type":        jsonType,
+	"json_set":         jsonSet,
+	"json_merge_patch": jsonMergePatch,
+	"json_patch":       jsonPatch,
+	"json_diff":        jsonDiff,
+
+	"array_length":   arrayLength,
+	"array_contains": arrayContains,
+	"array_append":   arrayAppend,
+	"array_slice":    arraySlice,
+	"array_position": arrayPosition,
+	"array_agg": &definition{
+		name:  "array_agg",
+		arity: 1,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &ArrayAgg{Expr: args[0]}, nil
+		},
+	},
+
+	"st_point":        stPoint,
+	"st_makeenvelope": stMakeEnvelope,
+	"st_x":            stX,
+	"st_y":            stY,
+	"st_distance":     stDistance,
+	"st_dwithin":      stDWithin,
+	"st_contains":     stContains,
+
+	"rank": rank,
 }
 
 type TypeOf struct {
@@ -530,11 +642,14 @@ type SumAggregator struct {
 	Fn   *Sum
 	SumI *int64
 	SumF *float64
+	SumD *types.DecimalValue
 }
 
 // Aggregate stores the sum of all non-NULL numeric values in the group.
 // The result is an integer value if all summed values are integers.
 // If any of the value is a double, the returned result will be a double.
+// Otherwise, if any of the value is a decimal, the returned result
+// will be an exact decimal.
 func (s *SumAggregator) Aggregate(env *environment.Environment) error {
 	v, err := s.Fn.Expr.Eval(env)
 	if err != nil && !errors.Is(err, types.ErrColumnNotFound) {
@@ -548,6 +663,8 @@ func (s *SumAggregator) Aggregate(env *environment.Environment) error {
 		switch v.Type() {
 		case types.TypeInteger, types.TypeBigint:
 			*s.SumF += float64(types.AsInt64(v))
+		case types.TypeDecimal:
+			*s.SumF += v.(types.DecimalValue).Float64()
 		default:
 			*s.SumF += float64(types.AsFloat64(v))
 		}
@@ -560,12 +677,34 @@ func (s *SumAggregator) Aggregate(env *environment.Environment) error {
 		if s.SumI != nil {
 			sumF = float64(*s.SumI)
 		}
+		if s.SumD != nil {
+			sumF = s.SumD.Float64()
+		}
 		s.SumF = &sumF
 		*s.SumF += float64(types.AsFloat64(v))
 
 		return nil
 	}
 
+	if v.Type() == types.TypeDecimal && s.SumD == nil {
+		var sumI int64
+		if s.SumI != nil {
+			sumI = *s.SumI
+		}
+		sumD := types.NewDecimalValueFromInt(sumI)
+		s.SumD = &sumD
+	}
+
+	if s.SumD != nil {
+		sum, err := s.SumD.Add(v.(types.Numeric))
+		if err != nil {
+			return err
+		}
+		*s.SumD = sum.(types.DecimalValue)
+
+		return nil
+	}
+
 	if s.SumI == nil {
 		var sumI int64
 		s.SumI = &sumI
@@ -580,6 +719,9 @@ func (s *SumAggregator) Eval(_ *environment.Environment) (types.Value, error) {
 	if s.SumF != nil {
 		return types.NewDoubleValue(*s.SumF), nil
 	}
+	if s.SumD != nil {
+		return *s.SumD, nil
+	}
 	if s.SumI != nil {
 		return types.NewBigintValue(*s.SumI), nil
 	}
@@ -661,6 +803,8 @@ func (s *AvgAggregator) Aggregate(env *environment.Environment) error {
 		s.Avg += float64(types.AsInt64(v))
 	case types.TypeDouble:
 		s.Avg += types.AsFloat64(v)
+	case types.TypeDecimal:
+		s.Avg += v.(types.DecimalValue).Float64()
 	default:
 		return nil
 	}
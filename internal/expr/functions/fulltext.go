@@ -0,0 +1,106 @@
+package functions
+
+import (
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+var rank = &definition{
+	name:  "rank",
+	arity: 0,
+	constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+		return &Rank{}, nil
+	},
+}
+
+// Rank is the rank() function.
+// It returns the BM25 score of the current row for the MATCH condition
+// of the query. The higher the score, the more relevant the row.
+// The matched column must have a full-text index.
+type Rank struct {
+	// Match is the condition the row is ranked against.
+	// It is set when the statement is bound.
+	Match *expr.MatchOperator
+}
+
+func (r *Rank) Clone() expr.Expr {
+	return &Rank{Match: r.Match}
+}
+
+func (r *Rank) Eval(env *environment.Environment) (types.Value, error) {
+	if r.Match == nil {
+		return nil, errors.New("rank() requires a MATCH condition in the WHERE clause")
+	}
+
+	col, ok := r.Match.LeftHand().(*expr.Column)
+	if !ok {
+		return nil, errors.New("rank() requires a MATCH condition on a column")
+	}
+
+	q, err := r.Match.RightHand().Eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if q.Type() != types.TypeText {
+		return types.NewNullValue(), nil
+	}
+
+	dr, ok := env.GetDatabaseRow()
+	if !ok || dr.Key() == nil {
+		return nil, errors.New("misuse of rank()")
+	}
+
+	tx := env.GetTx()
+	if tx == nil {
+		return nil, errors.New("misuse of rank()")
+	}
+
+	info := tx.Catalog.GetFulltextIndexInfo(dr.TableName(), col.Name)
+	if info == nil {
+		return nil, errors.Errorf("rank() requires a full-text index on column %s", col.Name)
+	}
+
+	idx, err := tx.Catalog.GetIndex(tx, info.IndexName)
+	if err != nil {
+		return nil, err
+	}
+
+	ti, err := tx.Catalog.GetTableInfo(dr.TableName())
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := ti.EncodeKey(dr.Key())
+	if err != nil {
+		return nil, err
+	}
+
+	terms, err := idx.Analyze(types.AsString(q))
+	if err != nil {
+		return nil, err
+	}
+
+	score, err := idx.Score(terms, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return types.NewDoubleValue(score), nil
+}
+
+func (r *Rank) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	_, ok := other.(*Rank)
+	return ok
+}
+
+func (r *Rank) Params() []expr.Expr { return nil }
+
+func (r *Rank) String() string {
+	return "RANK()"
+}
@@ -0,0 +1,207 @@
+package functions
+
+import (
+	"math"
+
+	"github.com/chaisql/chai/internal/geo"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// Spatial functions operate on TEXT values containing the well-known text
+// representation of a geometry, i.e. 'POINT(1 2)'. See the geo package.
+// Conditions using st_dwithin and st_contains on a column with a spatial
+// index are served by the index.
+
+var stPoint = &ScalarDefinition{
+	name:  "st_point",
+	arity: 2,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		x, y, err := coordArgs("st_point", args[0], args[1])
+		if err != nil || x == nil {
+			return types.NewNullValue(), err
+		}
+
+		return types.NewTextValue(geo.Point{X: *x, Y: *y}.String()), nil
+	},
+}
+
+var stMakeEnvelope = &ScalarDefinition{
+	name:  "st_makeenvelope",
+	arity: 4,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		minX, minY, err := coordArgs("st_makeenvelope", args[0], args[1])
+		if err != nil || minX == nil {
+			return types.NewNullValue(), err
+		}
+		maxX, maxY, err := coordArgs("st_makeenvelope", args[2], args[3])
+		if err != nil || maxX == nil {
+			return types.NewNullValue(), err
+		}
+
+		return types.NewTextValue(geo.Polygon{
+			{X: *minX, Y: *minY},
+			{X: *maxX, Y: *minY},
+			{X: *maxX, Y: *maxY},
+			{X: *minX, Y: *maxY},
+			{X: *minX, Y: *minY},
+		}.String()), nil
+	},
+}
+
+var stX = &ScalarDefinition{
+	name:  "st_x",
+	arity: 1,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		p, err := pointArg("st_x", args[0])
+		if err != nil || p == nil {
+			return types.NewNullValue(), err
+		}
+
+		return types.NewDoubleValue(p.X), nil
+	},
+}
+
+var stY = &ScalarDefinition{
+	name:  "st_y",
+	arity: 1,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		p, err := pointArg("st_y", args[0])
+		if err != nil || p == nil {
+			return types.NewNullValue(), err
+		}
+
+		return types.NewDoubleValue(p.Y), nil
+	},
+}
+
+var stDistance = &ScalarDefinition{
+	name:  "st_distance",
+	arity: 2,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		a, err := pointArg("st_distance", args[0])
+		if err != nil || a == nil {
+			return types.NewNullValue(), err
+		}
+		b, err := pointArg("st_distance", args[1])
+		if err != nil || b == nil {
+			return types.NewNullValue(), err
+		}
+
+		return types.NewDoubleValue(geo.Distance(*a, *b)), nil
+	},
+}
+
+// st_dwithin returns whether two points are within the given distance of each other.
+var stDWithin = &ScalarDefinition{
+	name:  "st_dwithin",
+	arity: 3,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		a, err := pointArg("st_dwithin", args[0])
+		if err != nil || a == nil {
+			return types.NewNullValue(), err
+		}
+		b, err := pointArg("st_dwithin", args[1])
+		if err != nil || b == nil {
+			return types.NewNullValue(), err
+		}
+
+		if args[2].Type() == types.TypeNull {
+			return types.NewNullValue(), nil
+		}
+		if !args[2].Type().IsNumber() {
+			return nil, errors.New("st_dwithin(arg1, arg2, arg3) expects arg3 to be a number")
+		}
+		d, err := args[2].CastAs(types.TypeDouble)
+		if err != nil {
+			return nil, err
+		}
+
+		return types.NewBooleanValue(geo.Distance(*a, *b) <= types.AsFloat64(d)), nil
+	},
+}
+
+// st_contains returns whether the point is inside the geometry or on its boundary.
+var stContains = &ScalarDefinition{
+	name:  "st_contains",
+	arity: 2,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		g, err := geometryArg("st_contains", args[0])
+		if err != nil || g == nil {
+			return types.NewNullValue(), err
+		}
+		p, err := pointArg("st_contains", args[1])
+		if err != nil || p == nil {
+			return types.NewNullValue(), err
+		}
+
+		switch g := g.(type) {
+		case geo.Point:
+			return types.NewBooleanValue(g == *p), nil
+		case geo.Polygon:
+			return types.NewBooleanValue(g.Contains(*p)), nil
+		}
+
+		return types.NewBooleanValue(false), nil
+	},
+}
+
+// geometryArg parses the geometry passed to a function.
+// It returns nil for NULL values.
+func geometryArg(name string, v types.Value) (geo.Geometry, error) {
+	if v.Type() == types.TypeNull {
+		return nil, nil
+	}
+	if v.Type() != types.TypeText {
+		return nil, errors.Errorf("%s() expects a geometry, got %s", name, v.Type())
+	}
+
+	g, err := geo.Parse(types.AsString(v))
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s()", name)
+	}
+
+	return g, nil
+}
+
+// pointArg parses the point passed to a function.
+// It returns nil for NULL values.
+func pointArg(name string, v types.Value) (*geo.Point, error) {
+	g, err := geometryArg(name, v)
+	if err != nil || g == nil {
+		return nil, err
+	}
+
+	p, ok := g.(geo.Point)
+	if !ok {
+		return nil, errors.Errorf("%s() expects a point, got %s", name, g)
+	}
+
+	return &p, nil
+}
+
+// coordArgs returns the coordinates passed to a function,
+// or nil if one of them is NULL.
+func coordArgs(name string, x, y types.Value) (*float64, *float64, error) {
+	if x.Type() == types.TypeNull || y.Type() == types.TypeNull {
+		return nil, nil, nil
+	}
+
+	var coords [2]float64
+	for i, v := range []types.Value{x, y} {
+		if !v.Type().IsNumber() {
+			return nil, nil, errors.Errorf("%s() expects coordinates to be numbers", name)
+		}
+
+		d, err := v.CastAs(types.TypeDouble)
+		if err != nil {
+			return nil, nil, err
+		}
+		coords[i] = types.AsFloat64(d)
+		if math.IsInf(coords[i], 0) || math.IsNaN(coords[i]) {
+			return nil, nil, errors.Errorf("%s() expects coordinates to be finite", name)
+		}
+	}
+
+	return &coords[0], &coords[1], nil
+}
@@ -0,0 +1,293 @@
+package functions
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/buger/jsonparser"
+	"github.com/chaisql/chai/document"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// JSON functions operate on TEXT values containing JSON.
+// Paths follow the $.a.b[0] syntax, where $ is the root of the document.
+
+var jsonExtract = jsonPathDefinition("json_extract", 2, func(data []byte, keys []string) (types.Value, error) {
+	v, tp, err := jsonGet(data, keys)
+	if err != nil || tp == jsonparser.NotExist {
+		return types.NewNullValue(), err
+	}
+
+	// objects and arrays are returned as JSON
+	if tp == jsonparser.Object || tp == jsonparser.Array {
+		return types.NewTextValue(string(v)), nil
+	}
+
+	return row.ParseJSONValue(tp, v)
+})
+
+// json_type(doc) returns the type of the root of the document.
+var jsonType = jsonPathDefinition("json_type", 1, func(data []byte, keys []string) (types.Value, error) {
+	_, tp, err := jsonGet(data, keys)
+	if err != nil {
+		return types.NewNullValue(), err
+	}
+
+	switch tp {
+	case jsonparser.Object:
+		return types.NewTextValue("object"), nil
+	case jsonparser.Array:
+		return types.NewTextValue("array"), nil
+	case jsonparser.String:
+		return types.NewTextValue("string"), nil
+	case jsonparser.Number:
+		return types.NewTextValue("number"), nil
+	case jsonparser.Boolean:
+		return types.NewTextValue("boolean"), nil
+	case jsonparser.Null:
+		return types.NewTextValue("null"), nil
+	}
+
+	return types.NewNullValue(), nil
+})
+
+// jsonPathDefinition returns the definition of a function reading the value
+// found at a path of a document. If minArity is 1, the path can be omitted
+// to read the root of the document. When the document is a column of a stored row,
+// the path is looked up directly in the encoded row, without copying the document.
+func jsonPathDefinition(name string, minArity int, fn func(data []byte, keys []string) (types.Value, error)) *ScalarDefinition {
+	path := func(args []types.Value) types.Value {
+		if len(args) == 0 {
+			return types.NewTextValue("$")
+		}
+		return args[0]
+	}
+
+	return &ScalarDefinition{
+		name:     name,
+		arity:    2,
+		minArity: minArity,
+		callFn: func(args ...types.Value) (types.Value, error) {
+			data, keys, err := jsonArgs(name, args[0], path(args[1:]))
+			if err != nil || data == nil {
+				return types.NewNullValue(), err
+			}
+
+			return fn(data, keys)
+		},
+		docFn: func(doc []byte, args ...types.Value) (types.Value, error) {
+			keys, err := jsonPathArg(name, path(args))
+			if err != nil || keys == nil {
+				return types.NewNullValue(), err
+			}
+
+			return fn(doc, keys)
+		},
+	}
+}
+
+var jsonSet = &ScalarDefinition{
+	name:  "json_set",
+	arity: 3,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		data, keys, err := jsonArgs("json_set", args[0], args[1])
+		if err != nil || data == nil {
+			return types.NewNullValue(), err
+		}
+
+		v, err := args[2].MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(keys) == 0 {
+			return types.NewTextValue(string(v)), nil
+		}
+
+		data, err = jsonparser.Set(data, v, keys...)
+		if err != nil {
+			return nil, errors.Wrap(err, "json_set")
+		}
+
+		return types.NewTextValue(string(data)), nil
+	},
+}
+
+// jsonMergePatch applies a JSON merge patch (RFC 7386) to a document.
+var jsonMergePatch = &ScalarDefinition{
+	name:  "json_merge_patch",
+	arity: 2,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		doc, patch, err := jsonDocs("json_merge_patch", args[0], args[1])
+		if err != nil || doc == nil {
+			return types.NewNullValue(), err
+		}
+
+		data, err := document.MergePatch(doc, patch)
+		if err != nil {
+			return nil, errors.Wrap(err, "json_merge_patch")
+		}
+
+		return types.NewTextValue(string(data)), nil
+	},
+}
+
+// jsonPatch applies a JSON patch (RFC 6902) to a document.
+var jsonPatch = &ScalarDefinition{
+	name:  "json_patch",
+	arity: 2,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		doc, patch, err := jsonDocs("json_patch", args[0], args[1])
+		if err != nil || doc == nil {
+			return types.NewNullValue(), err
+		}
+
+		data, err := document.Patch(doc, patch)
+		if err != nil {
+			return nil, errors.Wrap(err, "json_patch")
+		}
+
+		return types.NewTextValue(string(data)), nil
+	},
+}
+
+// jsonDiff returns the changes between two documents, as a JSON patch (RFC 6902).
+var jsonDiff = &ScalarDefinition{
+	name:  "json_diff",
+	arity: 2,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		a, b, err := jsonDocs("json_diff", args[0], args[1])
+		if err != nil || a == nil {
+			return types.NewNullValue(), err
+		}
+
+		changes, err := document.Diff(a, b)
+		if err != nil {
+			return nil, errors.Wrap(err, "json_diff")
+		}
+		if changes == nil {
+			changes = []document.Change{}
+		}
+
+		data, err := json.Marshal(changes)
+		if err != nil {
+			return nil, err
+		}
+
+		return types.NewTextValue(string(data)), nil
+	},
+}
+
+// jsonDocs validates the two documents passed to a JSON function.
+// It returns nil documents if any of them is NULL.
+func jsonDocs(name string, a, b types.Value) ([]byte, []byte, error) {
+	if a.Type() == types.TypeNull || b.Type() == types.TypeNull {
+		return nil, nil, nil
+	}
+
+	if a.Type() != types.TypeText {
+		return nil, nil, errors.Errorf("%s(arg1, arg2) expects arg1 to be a JSON text", name)
+	}
+	if b.Type() != types.TypeText {
+		return nil, nil, errors.Errorf("%s(arg1, arg2) expects arg2 to be a JSON text", name)
+	}
+
+	return []byte(types.AsString(a)), []byte(types.AsString(b)), nil
+}
+
+// jsonArgs validates the document and the path passed to a JSON function.
+// It returns a nil document if any of them is NULL.
+func jsonArgs(name string, doc, path types.Value) ([]byte, []string, error) {
+	if doc.Type() == types.TypeNull || path.Type() == types.TypeNull {
+		return nil, nil, nil
+	}
+
+	if doc.Type() != types.TypeText {
+		return nil, nil, errors.Errorf("%s(arg1, arg2) expects arg1 to be a JSON text", name)
+	}
+
+	keys, err := jsonPathArg(name, path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return []byte(types.AsString(doc)), keys, nil
+}
+
+// jsonPathArg validates and parses the path passed to a JSON function.
+// It returns nil keys if the path is NULL, and empty keys for the root of the document.
+func jsonPathArg(name string, path types.Value) ([]string, error) {
+	if path.Type() == types.TypeNull {
+		return nil, nil
+	}
+	if path.Type() != types.TypeText {
+		return nil, errors.Errorf("%s(arg1, arg2) expects arg2 to be a JSON path", name)
+	}
+
+	keys, err := parseJSONPath(types.AsString(path))
+	if err != nil || keys == nil {
+		return []string{}, err
+	}
+
+	return keys, nil
+}
+
+// jsonGet returns the value found at the given keys.
+// If the path doesn't exist, it returns jsonparser.NotExist.
+func jsonGet(data []byte, keys []string) ([]byte, jsonparser.ValueType, error) {
+	v, tp, _, err := jsonparser.Get(data, keys...)
+	if errors.Is(err, jsonparser.KeyPathNotFoundError) {
+		return nil, jsonparser.NotExist, nil
+	}
+	if err != nil {
+		return nil, tp, errors.Wrap(err, "malformed JSON")
+	}
+
+	return v, tp, nil
+}
+
+// parseJSONPath converts a path such as $.a.b[0] to the list of keys
+// expected by jsonparser, i.e. ["a", "b", "[0]"].
+func parseJSONPath(path string) ([]string, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, errors.Errorf("invalid JSON path %q", path)
+	}
+
+	var keys []string
+
+	p := path[1:]
+	for len(p) > 0 {
+		switch p[0] {
+		case '.':
+			p = p[1:]
+			end := strings.IndexAny(p, ".[")
+			if end == -1 {
+				end = len(p)
+			}
+			if end == 0 {
+				return nil, errors.Errorf("invalid JSON path %q", path)
+			}
+
+			keys = append(keys, p[:end])
+			p = p[end:]
+		case '[':
+			end := strings.IndexByte(p, ']')
+			if end == -1 {
+				return nil, errors.Errorf("invalid JSON path %q", path)
+			}
+			if n, err := strconv.Atoi(p[1:end]); err != nil || n < 0 {
+				return nil, errors.Errorf("invalid JSON path %q", path)
+			}
+
+			keys = append(keys, p[:end+1])
+			p = p[end+1:]
+		default:
+			return nil, errors.Errorf("invalid JSON path %q", path)
+		}
+	}
+
+	return keys, nil
+}
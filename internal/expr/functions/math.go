@@ -1,6 +1,7 @@
 package functions
 
 import (
+	crand "crypto/rand"
 	"fmt"
 	"math"
 	"math/rand"
@@ -13,8 +14,12 @@ var floor = &ScalarDefinition{
 	arity: 1,
 	callFn: func(args ...types.Value) (types.Value, error) {
 		switch args[0].Type() {
+		case types.TypeNull:
+			return args[0], nil
 		case types.TypeDouble:
 			return types.NewDoubleValue(math.Floor(types.AsFloat64(args[0]))), nil
+		case types.TypeDecimal:
+			return args[0].(types.DecimalValue).Floor(), nil
 		case types.TypeInteger, types.TypeBigint:
 			return args[0], nil
 		default:
@@ -23,6 +28,85 @@ var floor = &ScalarDefinition{
 	},
 }
 
+var ceil = &ScalarDefinition{
+	name:  "ceil",
+	arity: 1,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		switch args[0].Type() {
+		case types.TypeNull:
+			return args[0], nil
+		case types.TypeDouble:
+			return types.NewDoubleValue(math.Ceil(types.AsFloat64(args[0]))), nil
+		case types.TypeDecimal:
+			return args[0].(types.DecimalValue).Ceil(), nil
+		case types.TypeInteger, types.TypeBigint:
+			return args[0], nil
+		default:
+			return nil, fmt.Errorf("ceil(arg1) expects arg1 to be a number")
+		}
+	},
+}
+
+// round rounds a number to the given number of decimal places, 0 by default,
+// rounding half away from zero. Integers are returned as integers.
+// A negative number of decimal places rounds to the left of the decimal point.
+var round = &ScalarDefinition{
+	name:     "round",
+	arity:    2,
+	minArity: 1,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		var n int64
+		if len(args) == 2 {
+			switch {
+			case args[1].Type() == types.TypeNull:
+				return args[1], nil
+			case !args[1].Type().IsInteger():
+				return nil, fmt.Errorf("round(arg1, arg2) expects arg2 to be an integer")
+			}
+			n = types.AsInt64(args[1])
+		}
+
+		switch args[0].Type() {
+		case types.TypeNull:
+			return args[0], nil
+		case types.TypeDouble:
+			x, p := types.AsFloat64(args[0]), math.Pow(10, float64(n))
+			if math.IsInf(x*p, 0) {
+				// the number doesn't have that many decimal places
+				return args[0], nil
+			}
+			return types.NewDoubleValue(math.Round(x*p) / p), nil
+		case types.TypeDecimal:
+			if n > math.MaxInt32 || n < math.MinInt32 {
+				return nil, fmt.Errorf("round(arg1, arg2): arg2 out of range")
+			}
+			return args[0].(types.DecimalValue).Round(int32(n)), nil
+		case types.TypeInteger, types.TypeBigint:
+			if n >= 0 {
+				return args[0], nil
+			}
+			if n < -18 {
+				return types.NewIntegerValue(0).CastAs(args[0].Type())
+			}
+
+			p := int64(math.Pow10(int(-n)))
+			x := types.AsInt64(args[0])
+			q, r := x/p, x%p
+			if r >= p-r {
+				q++
+			} else if -r >= p+r {
+				q--
+			}
+			if q > math.MaxInt64/p || q < math.MinInt64/p {
+				return nil, fmt.Errorf("integer out of range")
+			}
+			return types.NewBigintValue(q * p).CastAs(args[0].Type())
+		default:
+			return nil, fmt.Errorf("round(arg1) expects arg1 to be a number")
+		}
+	},
+}
+
 var abs = &ScalarDefinition{
 	name:  "abs",
 	arity: 1,
@@ -30,6 +114,9 @@ var abs = &ScalarDefinition{
 		if args[0].Type() == types.TypeNull {
 			return types.NewNullValue(), nil
 		}
+		if args[0].Type() == types.TypeDecimal {
+			return args[0].(types.DecimalValue).Abs(), nil
+		}
 		v, err := args[0].CastAs(types.TypeDouble)
 		if err != nil {
 			return nil, err
@@ -152,6 +239,106 @@ var atan2 = &ScalarDefinition{
 	},
 }
 
+var pow = &ScalarDefinition{
+	name:  "pow",
+	arity: 2,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		vA, err := args[0].CastAs(types.TypeDouble)
+		if err != nil || vA.Type() == types.TypeNull {
+			return vA, err
+		}
+		vB, err := args[1].CastAs(types.TypeDouble)
+		if err != nil || vB.Type() == types.TypeNull {
+			return vB, err
+		}
+		res := math.Pow(types.AsFloat64(vA), types.AsFloat64(vB))
+		if math.IsNaN(res) {
+			return nil, fmt.Errorf("out of range, pow(arg1, arg2) expects arg2 to be an integer when arg1 is negative")
+		}
+		return types.NewDoubleValue(res), nil
+	},
+}
+
+var ln = &ScalarDefinition{
+	name:  "ln",
+	arity: 1,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		v, err := args[0].CastAs(types.TypeDouble)
+		if err != nil || v.Type() == types.TypeNull {
+			return v, err
+		}
+		vv := types.AsFloat64(v)
+		if vv <= 0 {
+			return nil, fmt.Errorf("out of range, ln(arg1) expects arg1 > 0")
+		}
+		return types.NewDoubleValue(math.Log(vv)), nil
+	},
+}
+
+// log returns the base 10 logarithm of a number,
+// or its logarithm in the given base if called with two arguments: log(base, x).
+var log = &ScalarDefinition{
+	name:     "log",
+	arity:    2,
+	minArity: 1,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		v, err := args[len(args)-1].CastAs(types.TypeDouble)
+		if err != nil || v.Type() == types.TypeNull {
+			return v, err
+		}
+		vv := types.AsFloat64(v)
+		if vv <= 0 {
+			return nil, fmt.Errorf("out of range, log(arg1) expects arg1 > 0")
+		}
+		if len(args) == 1 {
+			return types.NewDoubleValue(math.Log10(vv)), nil
+		}
+
+		b, err := args[0].CastAs(types.TypeDouble)
+		if err != nil || b.Type() == types.TypeNull {
+			return b, err
+		}
+		bb := types.AsFloat64(b)
+		if bb <= 0 || bb == 1 {
+			return nil, fmt.Errorf("out of range, log(arg1, arg2) expects arg1 > 0 and arg1 != 1")
+		}
+		return types.NewDoubleValue(math.Log(vv) / math.Log(bb)), nil
+	},
+}
+
+// sign returns -1, 0 or 1 depending on the sign of the number.
+var sign = &ScalarDefinition{
+	name:  "sign",
+	arity: 1,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		var res int32
+		switch args[0].Type() {
+		case types.TypeNull:
+			return args[0], nil
+		case types.TypeDouble:
+			switch x := types.AsFloat64(args[0]); {
+			case x > 0:
+				res = 1
+			case x < 0:
+				res = -1
+			}
+		case types.TypeInteger, types.TypeBigint:
+			switch x := types.AsInt64(args[0]); {
+			case x > 0:
+				res = 1
+			case x < 0:
+				res = -1
+			}
+		case types.TypeDecimal:
+			res = int32(args[0].(types.DecimalValue).Sign())
+		default:
+			return nil, fmt.Errorf("sign(arg1) expects arg1 to be a number")
+		}
+
+		return types.NewIntegerValue(res), nil
+	},
+}
+
 var random = &ScalarDefinition{
 	name:  "random",
 	arity: 0,
@@ -161,11 +348,39 @@ var random = &ScalarDefinition{
 	},
 }
 
+// randomblob returns a blob of n random bytes.
+var randomblob = &ScalarDefinition{
+	name:  "randomblob",
+	arity: 1,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		switch {
+		case args[0].Type() == types.TypeNull:
+			return args[0], nil
+		case !args[0].Type().IsInteger():
+			return nil, fmt.Errorf("randomblob(arg1) expects arg1 to be an integer")
+		}
+
+		n := types.AsInt64(args[0])
+		if n < 1 || n > maxRandomBlobSize {
+			return nil, fmt.Errorf("out of range, randomblob(arg1) expects arg1 to be within [1, %d]", maxRandomBlobSize)
+		}
+
+		b := make([]byte, n)
+		_, err := crand.Read(b)
+		if err != nil {
+			return nil, err
+		}
+		return types.NewBlobValue(b), nil
+	},
+}
+
+const maxRandomBlobSize = 1 << 20
+
 var sqrt = &ScalarDefinition{
 	name:  "sqrt",
 	arity: 1,
 	callFn: func(args ...types.Value) (types.Value, error) {
-		if args[0].Type() != types.TypeDouble && args[0].Type() != types.TypeInteger {
+		if !args[0].Type().IsNumber() {
 			return types.NewNullValue(), nil
 		}
 		v, err := args[0].CastAs(types.TypeDouble)
@@ -0,0 +1,162 @@
+package functions
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// A Lookup returns the definition of a function from its name.
+type Lookup interface {
+	GetFunc(name string) (Definition, error)
+}
+
+// A Registry holds the functions registered by the application for a database,
+// in addition to the builtin functions.
+// It is safe for concurrent use.
+type Registry struct {
+	mu   sync.RWMutex
+	defs Definitions
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{defs: make(Definitions)}
+}
+
+// Register makes a user-defined function available to the queries using the registry.
+// Builtin functions cannot be replaced, but a user-defined function
+// registered with the same name is.
+func (r *Registry) Register(def Definition) error {
+	name := strings.ToLower(def.Name())
+	if _, ok := builtinFunctions[name]; ok {
+		return errors.Errorf("cannot register function %q: a builtin function with the same name already exists", def.Name())
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.defs[name] = def
+	return nil
+}
+
+// GetFunc returns the definition of a builtin function,
+// or of a function of the registry.
+func (r *Registry) GetFunc(fname string) (Definition, error) {
+	def, err := GetFunc(fname)
+	if err == nil {
+		return def, nil
+	}
+
+	r.mu.RLock()
+	def, ok := r.defs[strings.ToLower(fname)]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, err
+	}
+	return def, nil
+}
+
+// Deferred returns a Lookup resolving the functions of the registry when
+// they are called rather than when they are parsed. It is used to load the
+// schema of a database, which can use functions that are registered once
+// the database is open.
+func (r *Registry) Deferred() Lookup {
+	return deferredLookup{r: r}
+}
+
+type deferredLookup struct {
+	r *Registry
+}
+
+func (l deferredLookup) GetFunc(fname string) (Definition, error) {
+	def, err := l.r.GetFunc(fname)
+	if err == nil {
+		return def, nil
+	}
+
+	return &deferredDefinition{r: l.r, name: fname}, nil
+}
+
+// deferredDefinition is the definition of a function
+// that is not registered yet.
+type deferredDefinition struct {
+	r    *Registry
+	name string
+}
+
+func (d *deferredDefinition) Name() string {
+	return d.name
+}
+
+func (d *deferredDefinition) String() string {
+	return d.name + "(...)"
+}
+
+func (d *deferredDefinition) Function(args ...expr.Expr) (expr.Function, error) {
+	return &deferredFunction{r: d.r, name: d.name, params: args}, nil
+}
+
+// deferredFunction is a call to a function that was not registered
+// when the expression was parsed. It is looked up every time it is evaluated.
+type deferredFunction struct {
+	r      *Registry
+	name   string
+	params []expr.Expr
+}
+
+func (f *deferredFunction) Eval(env *environment.Environment) (types.Value, error) {
+	def, err := f.r.GetFunc(f.name)
+	if err != nil {
+		return nil, err
+	}
+
+	fn, err := def.Function(f.params...)
+	if err != nil {
+		return nil, err
+	}
+
+	return fn.Eval(env)
+}
+
+func (f *deferredFunction) IsEqual(other expr.Expr) bool {
+	o, ok := other.(*deferredFunction)
+	if !ok || o.r != f.r || o.name != f.name || len(o.params) != len(f.params) {
+		return false
+	}
+
+	for i := range f.params {
+		if !expr.Equal(f.params[i], o.params[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (f *deferredFunction) Params() []expr.Expr {
+	return f.params
+}
+
+func (f *deferredFunction) Clone() expr.Expr {
+	params := make([]expr.Expr, 0, len(f.params))
+	for _, e := range f.params {
+		params = append(params, expr.Clone(e))
+	}
+
+	return &deferredFunction{r: f.r, name: f.name, params: params}
+}
+
+func (f *deferredFunction) String() string {
+	params := make([]string, len(f.params))
+	for i, p := range f.params {
+		params[i] = p.String()
+	}
+
+	return fmt.Sprintf("%s(%s)", f.name, strings.Join(params, ", "))
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/chaisql/chai/internal/environment"
 	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/row"
 	"github.com/chaisql/chai/internal/types"
 )
 
@@ -14,10 +15,18 @@ import (
 //
 // This difference allows to simply define them with a CallFn function that takes multiple row.Value and
 // return another types.Value, rather than having to manually evaluate expressions (see Definition).
+//
+// Functions accepting a variable number of arguments set minArity to the number of required arguments
+// and arity to the maximum number of arguments, or to variadicArity if there is no limit.
 type ScalarDefinition struct {
-	name   string
-	arity  int
-	callFn func(...types.Value) (types.Value, error)
+	name     string
+	arity    int
+	minArity int
+	callFn   func(...types.Value) (types.Value, error)
+	// docFn, if set, is called instead of callFn when the first argument
+	// is a TEXT column whose bytes can be read from the row without decoding them.
+	// It receives these bytes and the other arguments.
+	docFn func(doc []byte, args ...types.Value) (types.Value, error)
 }
 
 func NewScalarDefinition(name string, arity int, callFn func(...types.Value) (types.Value, error)) *ScalarDefinition {
@@ -31,17 +40,31 @@ func (fd *ScalarDefinition) Name() string {
 
 // String returns the defined function name and its arguments.
 func (fd *ScalarDefinition) String() string {
-	args := make([]string, 0, fd.arity)
-	for i := 0; i < fd.arity; i++ {
+	n := fd.arity
+	if n == variadicArity {
+		n = fd.minArity
+	}
+
+	args := make([]string, 0, n+1)
+	for i := 0; i < n; i++ {
 		args = append(args, fmt.Sprintf("arg%d", i+1))
 	}
+	if fd.arity == variadicArity {
+		args = append(args, "...")
+	}
 	return fmt.Sprintf("%s(%s)", fd.name, strings.Join(args, ", "))
 }
 
 // Function returns a Function expr node.
 func (fd *ScalarDefinition) Function(args ...expr.Expr) (expr.Function, error) {
-	if len(args) != fd.arity {
-		return nil, fmt.Errorf("%s takes %d argument(s), not %d", fd.String(), fd.arity, len(args))
+	if fd.minArity == 0 || fd.minArity == fd.arity {
+		if len(args) != fd.arity {
+			return nil, fmt.Errorf("%s takes %d argument(s), not %d", fd.String(), fd.arity, len(args))
+		}
+	} else if len(args) < fd.minArity {
+		return nil, fmt.Errorf("%s takes at least %d argument(s), not %d", fd.String(), fd.minArity, len(args))
+	} else if fd.arity != variadicArity && len(args) > fd.arity {
+		return nil, fmt.Errorf("%s takes at most %d argument(s), not %d", fd.String(), fd.arity, len(args))
 	}
 	return &ScalarFunction{
 		params: args,
@@ -71,6 +94,13 @@ func (sf *ScalarFunction) Clone() expr.Expr {
 // Eval returns a row.Value based on the given environment and the underlying function
 // definition.
 func (sf *ScalarFunction) Eval(env *environment.Environment) (types.Value, error) {
+	if sf.def.docFn != nil {
+		v, ok, err := sf.evalRawDoc(env)
+		if ok || err != nil {
+			return v, err
+		}
+	}
+
 	args, err := sf.evalParams(env)
 	if err != nil {
 		return nil, err
@@ -78,6 +108,41 @@ func (sf *ScalarFunction) Eval(env *environment.Environment) (types.Value, error
 	return sf.def.callFn(args...)
 }
 
+// evalRawDoc calls docFn with the bytes of the column passed as first argument,
+// if the row of the environment gives access to them.
+func (sf *ScalarFunction) evalRawDoc(env *environment.Environment) (types.Value, bool, error) {
+	c, ok := sf.params[0].(*expr.Column)
+	if !ok {
+		return nil, false, nil
+	}
+
+	r, ok := env.GetRow()
+	if !ok {
+		return nil, false, nil
+	}
+	g, ok := r.(row.RawTextGetter)
+	if !ok {
+		return nil, false, nil
+	}
+
+	doc, ok, err := g.RawText(c.Name)
+	if !ok || err != nil {
+		return nil, false, err
+	}
+
+	args := make([]types.Value, 0, len(sf.params)-1)
+	for _, param := range sf.params[1:] {
+		v, err := param.Eval(env)
+		if err != nil {
+			return nil, false, err
+		}
+		args = append(args, v)
+	}
+
+	v, err := sf.def.docFn(doc, args...)
+	return v, true, err
+}
+
 // evalParams evaluate all arguments given to the function in the context of the given environmment.
 func (sf *ScalarFunction) evalParams(env *environment.Environment) ([]types.Value, error) {
 	values := make([]types.Value, 0, len(sf.params))
@@ -93,10 +158,20 @@ func (sf *ScalarFunction) evalParams(env *environment.Environment) ([]types.Valu
 
 // String returns a string represention of the function expression and its arguments.
 func (sf *ScalarFunction) String() string {
-	return fmt.Sprintf("%s(%v)", sf.def.name, sf.params)
+	params := make([]string, len(sf.params))
+	for i, p := range sf.params {
+		params[i] = p.String()
+	}
+
+	return fmt.Sprintf("%s(%s)", sf.def.name, strings.Join(params, ", "))
 }
 
 // Params return the function arguments.
 func (sf *ScalarFunction) Params() []expr.Expr {
 	return sf.params
 }
+
+// Name returns the name of the function.
+func (sf *ScalarFunction) Name() string {
+	return sf.def.name
+}
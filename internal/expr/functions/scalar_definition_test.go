@@ -56,3 +56,30 @@ func TestScalarFunctionDef(t *testing.T) {
 		})
 	})
 }
+
+func TestScalarFunctionDefArity(t *testing.T) {
+	substr, err := functions.GetFunc("substr")
+	require.NoError(t, err)
+	require.Equal(t, "substr(arg1, arg2, arg3)", substr.String())
+
+	concat, err := functions.GetFunc("concat")
+	require.NoError(t, err)
+	require.Equal(t, "concat(arg1, ...)", concat.String())
+
+	a := expr.LiteralValue{Value: types.NewTextValue("a")}
+	one := expr.LiteralValue{Value: types.NewIntegerValue(1)}
+
+	_, err = substr.Function(a)
+	require.Error(t, err)
+	_, err = substr.Function(a, one)
+	require.NoError(t, err)
+	_, err = substr.Function(a, one, one)
+	require.NoError(t, err)
+	_, err = substr.Function(a, one, one, one)
+	require.Error(t, err)
+
+	_, err = concat.Function()
+	require.Error(t, err)
+	_, err = concat.Function(a, a, a, a)
+	require.NoError(t, err)
+}
@@ -0,0 +1,284 @@
+package functions
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+var _ expr.AggregatorBuilder = (*Variance)(nil)
+
+// Variance implements the VAR_POP, VAR_SAMP, STDDEV_POP and STDDEV_SAMP aggregator functions.
+// They ignore NULL and non numeric values and return a double.
+type Variance struct {
+	Expr expr.Expr
+	// Name of the function, in upper case.
+	Name string
+	// If true, compute the sample variance rather than the population variance.
+	Sample bool
+	// If true, return the standard deviation rather than the variance.
+	Stddev bool
+}
+
+func (v *Variance) Clone() expr.Expr {
+	return &Variance{
+		Expr:   expr.Clone(v.Expr),
+		Name:   v.Name,
+		Sample: v.Sample,
+		Stddev: v.Stddev,
+	}
+}
+
+// Eval extracts the aggregated value from the given row and returns it.
+func (v *Variance) Eval(env *environment.Environment) (types.Value, error) {
+	r, ok := env.GetRow()
+	if !ok {
+		return nil, fmt.Errorf("misuse of aggregation function %s()", v.Name)
+	}
+
+	return r.Get(v.String())
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (v *Variance) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*Variance)
+	if !ok {
+		return false
+	}
+
+	return v.Name == o.Name && expr.Equal(v.Expr, o.Expr)
+}
+
+func (v *Variance) Params() []expr.Expr { return []expr.Expr{v.Expr} }
+
+func (v *Variance) String() string {
+	return fmt.Sprintf("%s(%v)", v.Name, v.Expr)
+}
+
+// Aggregator returns a VarianceAggregator. It implements the AggregatorBuilder interface.
+func (v *Variance) Aggregator() expr.Aggregator {
+	return &VarianceAggregator{
+		Fn: v,
+	}
+}
+
+// VarianceAggregator computes the variance of a group in a single pass,
+// using Welford's algorithm.
+type VarianceAggregator struct {
+	Fn      *Variance
+	Counter int64
+	Mean    float64
+	// sum of the squared differences from the mean.
+	M2 float64
+}
+
+// Aggregate updates the mean and the sum of squares with the value of the row.
+func (s *VarianceAggregator) Aggregate(env *environment.Environment) error {
+	v, err := s.Fn.Expr.Eval(env)
+	if err != nil && !errors.Is(err, types.ErrColumnNotFound) {
+		return err
+	}
+	if v == nil || !v.Type().IsNumber() {
+		return nil
+	}
+
+	x := asFloat64(v)
+
+	s.Counter++
+	delta := x - s.Mean
+	s.Mean += delta / float64(s.Counter)
+	s.M2 += delta * (x - s.Mean)
+
+	return nil
+}
+
+// Eval returns the variance or the standard deviation of the group.
+// It returns NULL if the group is empty, or if it contains a single value
+// for the sample variants.
+func (s *VarianceAggregator) Eval(_ *environment.Environment) (types.Value, error) {
+	n := s.Counter
+	if s.Fn.Sample {
+		n--
+	}
+	if n <= 0 {
+		return types.NewNullValue(), nil
+	}
+
+	res := s.M2 / float64(n)
+	if s.Fn.Stddev {
+		res = math.Sqrt(res)
+	}
+
+	return types.NewDoubleValue(res), nil
+}
+
+func (s *VarianceAggregator) String() string {
+	return s.Fn.String()
+}
+
+var _ expr.AggregatorBuilder = (*Percentile)(nil)
+
+// Percentile implements the PERCENTILE_CONT, PERCENTILE_DISC and MEDIAN aggregator functions.
+// They ignore NULL and non numeric values.
+// PERCENTILE_CONT interpolates between the values of the group and returns a double,
+// while PERCENTILE_DISC returns the first value whose position in the group
+// is greater than or equal to the fraction. MEDIAN is PERCENTILE_CONT with a fraction of 0.5.
+type Percentile struct {
+	Expr expr.Expr
+	// Fraction is a number between 0 and 1. It is nil for MEDIAN.
+	Fraction expr.Expr
+	// Name of the function, in upper case.
+	Name string
+	// If true, interpolate between values.
+	Continuous bool
+}
+
+func (p *Percentile) Clone() expr.Expr {
+	return &Percentile{
+		Expr:       expr.Clone(p.Expr),
+		Fraction:   expr.Clone(p.Fraction),
+		Name:       p.Name,
+		Continuous: p.Continuous,
+	}
+}
+
+// Eval extracts the aggregated value from the given row and returns it.
+func (p *Percentile) Eval(env *environment.Environment) (types.Value, error) {
+	r, ok := env.GetRow()
+	if !ok {
+		return nil, fmt.Errorf("misuse of aggregation function %s()", p.Name)
+	}
+
+	return r.Get(p.String())
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (p *Percentile) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*Percentile)
+	if !ok {
+		return false
+	}
+
+	return p.Name == o.Name && expr.Equal(p.Expr, o.Expr) && expr.Equal(p.Fraction, o.Fraction)
+}
+
+func (p *Percentile) Params() []expr.Expr {
+	if p.Fraction == nil {
+		return []expr.Expr{p.Expr}
+	}
+
+	return []expr.Expr{p.Expr, p.Fraction}
+}
+
+func (p *Percentile) String() string {
+	if p.Fraction == nil {
+		return fmt.Sprintf("%s(%v)", p.Name, p.Expr)
+	}
+
+	return fmt.Sprintf("%s(%v, %v)", p.Name, p.Expr, p.Fraction)
+}
+
+// Aggregator returns a PercentileAggregator. It implements the AggregatorBuilder interface.
+func (p *Percentile) Aggregator() expr.Aggregator {
+	return &PercentileAggregator{
+		Fn: p,
+	}
+}
+
+// PercentileAggregator keeps all the numeric values of the group in memory
+// and sorts them when the group is evaluated.
+type PercentileAggregator struct {
+	Fn     *Percentile
+	Values []types.Value
+}
+
+// Aggregate stores the value of the row if it is a number.
+func (s *PercentileAggregator) Aggregate(env *environment.Environment) error {
+	v, err := s.Fn.Expr.Eval(env)
+	if err != nil && !errors.Is(err, types.ErrColumnNotFound) {
+		return err
+	}
+	if v == nil || !v.Type().IsNumber() {
+		return nil
+	}
+
+	s.Values = append(s.Values, v)
+	return nil
+}
+
+// Eval returns the percentile of the group, or NULL if the group is empty.
+func (s *PercentileAggregator) Eval(env *environment.Environment) (types.Value, error) {
+	f, err := s.fraction(env)
+	if err != nil {
+		return nil, err
+	}
+	if len(s.Values) == 0 {
+		return types.NewNullValue(), nil
+	}
+
+	sort.Slice(s.Values, func(i, j int) bool {
+		return asFloat64(s.Values[i]) < asFloat64(s.Values[j])
+	})
+
+	if !s.Fn.Continuous {
+		i := int(math.Ceil(f*float64(len(s.Values)))) - 1
+		return s.Values[max(i, 0)], nil
+	}
+
+	pos := f * float64(len(s.Values)-1)
+	lo, hi := int(math.Floor(pos)), int(math.Ceil(pos))
+	x, y := asFloat64(s.Values[lo]), asFloat64(s.Values[hi])
+
+	return types.NewDoubleValue(x + (y-x)*(pos-float64(lo))), nil
+}
+
+// fraction evaluates the fraction of the percentile.
+func (s *PercentileAggregator) fraction(env *environment.Environment) (float64, error) {
+	if s.Fn.Fraction == nil {
+		return 0.5, nil
+	}
+
+	v, err := s.Fn.Fraction.Eval(env)
+	if err != nil {
+		return 0, err
+	}
+	if v.Type().IsNumber() {
+		f := asFloat64(v)
+		if f >= 0 && f <= 1 {
+			return f, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%s(arg1, arg2) expects arg2 to be a number between 0 and 1", s.Fn.Name)
+}
+
+func (s *PercentileAggregator) String() string {
+	return s.Fn.String()
+}
+
+// asFloat64 converts a numeric value to a float64.
+func asFloat64(v types.Value) float64 {
+	switch v.Type() {
+	case types.TypeDouble:
+		return types.AsFloat64(v)
+	case types.TypeDecimal:
+		return v.(types.DecimalValue).Float64()
+	}
+
+	return float64(types.AsInt64(v))
+}
@@ -3,21 +3,28 @@ package functions
 import (
 	"fmt"
 	"strings"
+	"unicode/utf8"
 
+	"github.com/chaisql/chai/internal/collation"
 	"github.com/chaisql/chai/internal/environment"
 	"github.com/chaisql/chai/internal/expr"
 	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+	"golang.org/x/text/language"
 )
 
 // Lower is the LOWER function
-// It returns the lower-case version of a string
+// It returns the lower-case version of a string.
+// If a locale is provided, language specific casing rules are applied.
 type Lower struct {
-	Expr expr.Expr
+	Expr   expr.Expr
+	Locale expr.Expr
 }
 
 func (s *Lower) Clone() expr.Expr {
 	return &Lower{
-		Expr: expr.Clone(s.Expr),
+		Expr:   expr.Clone(s.Expr),
+		Locale: expr.Clone(s.Locale),
 	}
 }
 
@@ -31,9 +38,16 @@ func (s *Lower) Eval(env *environment.Environment) (types.Value, error) {
 		return types.NewNullValue(), nil
 	}
 
-	lowerCaseString := strings.ToLower(types.AsString(val))
+	if s.Locale == nil {
+		return types.NewTextValue(strings.ToLower(types.AsString(val))), nil
+	}
+
+	tag, ok, err := evalLocale(env, s.Locale)
+	if err != nil || !ok {
+		return types.NewNullValue(), err
+	}
 
-	return types.NewTextValue(lowerCaseString), nil
+	return types.NewTextValue(collation.Lower(tag, types.AsString(val))), nil
 }
 
 func (s *Lower) IsEqual(other expr.Expr) bool {
@@ -46,24 +60,37 @@ func (s *Lower) IsEqual(other expr.Expr) bool {
 		return false
 	}
 
-	return expr.Equal(s.Expr, o.Expr)
+	return expr.Equal(s.Expr, o.Expr) && expr.Equal(s.Locale, o.Locale)
 }
 
-func (s *Lower) Params() []expr.Expr { return []expr.Expr{s.Expr} }
+func (s *Lower) Params() []expr.Expr {
+	if s.Locale != nil {
+		return []expr.Expr{s.Expr, s.Locale}
+	}
+
+	return []expr.Expr{s.Expr}
+}
 
 func (s *Lower) String() string {
+	if s.Locale != nil {
+		return fmt.Sprintf("LOWER(%v, %v)", s.Expr, s.Locale)
+	}
+
 	return fmt.Sprintf("LOWER(%v)", s.Expr)
 }
 
 // Upper is the UPPER function
-// It returns the upper-case version of a string
+// It returns the upper-case version of a string.
+// If a locale is provided, language specific casing rules are applied.
 type Upper struct {
-	Expr expr.Expr
+	Expr   expr.Expr
+	Locale expr.Expr
 }
 
 func (s *Upper) Clone() expr.Expr {
 	return &Upper{
-		Expr: expr.Clone(s.Expr),
+		Expr:   expr.Clone(s.Expr),
+		Locale: expr.Clone(s.Locale),
 	}
 }
 
@@ -77,9 +104,16 @@ func (s *Upper) Eval(env *environment.Environment) (types.Value, error) {
 		return types.NewNullValue(), nil
 	}
 
-	upperCaseString := strings.ToUpper(types.AsString(val))
+	if s.Locale == nil {
+		return types.NewTextValue(strings.ToUpper(types.AsString(val))), nil
+	}
+
+	tag, ok, err := evalLocale(env, s.Locale)
+	if err != nil || !ok {
+		return types.NewNullValue(), err
+	}
 
-	return types.NewTextValue(upperCaseString), nil
+	return types.NewTextValue(collation.Upper(tag, types.AsString(val))), nil
 }
 
 func (s *Upper) IsEqual(other expr.Expr) bool {
@@ -92,15 +126,45 @@ func (s *Upper) IsEqual(other expr.Expr) bool {
 		return false
 	}
 
-	return expr.Equal(s.Expr, o.Expr)
+	return expr.Equal(s.Expr, o.Expr) && expr.Equal(s.Locale, o.Locale)
 }
 
-func (s *Upper) Params() []expr.Expr { return []expr.Expr{s.Expr} }
+func (s *Upper) Params() []expr.Expr {
+	if s.Locale != nil {
+		return []expr.Expr{s.Expr, s.Locale}
+	}
+
+	return []expr.Expr{s.Expr}
+}
 
 func (s *Upper) String() string {
+	if s.Locale != nil {
+		return fmt.Sprintf("UPPER(%v, %v)", s.Expr, s.Locale)
+	}
+
 	return fmt.Sprintf("UPPER(%v)", s.Expr)
 }
 
+// evalLocale evaluates e and parses the result as a BCP 47 language tag.
+// It returns false if the value is not a TEXT value.
+func evalLocale(env *environment.Environment, e expr.Expr) (language.Tag, bool, error) {
+	v, err := e.Eval(env)
+	if err != nil {
+		return language.Tag{}, false, err
+	}
+
+	if v.Type() != types.TypeText {
+		return language.Tag{}, false, nil
+	}
+
+	tag, err := language.Parse(types.AsString(v))
+	if err != nil {
+		return language.Tag{}, false, fmt.Errorf("invalid locale %q", types.AsString(v))
+	}
+
+	return tag, true, nil
+}
+
 // TRIM removes leading and trailing characters from a string based on the given input.
 // LTRIM removes leading characters
 // RTRIM removes trailing characters
@@ -188,3 +252,234 @@ func (s *Trim) String() string {
 	}
 	return fmt.Sprintf("%v(%v, %v)", s.Name, s.Expr[0], s.Expr[1])
 }
+
+// The following functions return NULL if any of their arguments is NULL.
+// Positions and lengths are expressed in characters, not bytes.
+
+var substr = &ScalarDefinition{
+	name:     "substr",
+	arity:    3,
+	minArity: 2,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		if hasNullArg(args) {
+			return types.NewNullValue(), nil
+		}
+		if args[0].Type() != types.TypeText {
+			return nil, errors.New("substr(arg1, arg2) expects arg1 to be a text")
+		}
+		if !args[1].Type().IsInteger() {
+			return nil, errors.New("substr(arg1, arg2) expects arg2 to be an integer")
+		}
+
+		r := []rune(types.AsString(args[0]))
+
+		// positions start at 1, negative positions start from the end
+		start := types.AsInt64(args[1])
+		if start < 0 {
+			start += int64(len(r)) + 1
+		}
+		end := int64(len(r)) + 1
+		if len(args) == 3 {
+			if !args[2].Type().IsInteger() {
+				return nil, errors.New("substr(arg1, arg2, arg3) expects arg3 to be an integer")
+			}
+			n := types.AsInt64(args[2])
+			if n < 0 {
+				return nil, errors.New("substr(arg1, arg2, arg3) expects arg3 to be positive")
+			}
+			end = min(end, start+n)
+		}
+		start = max(start, 1)
+		if start >= end {
+			return types.NewTextValue(""), nil
+		}
+
+		return types.NewTextValue(string(r[start-1 : end-1])), nil
+	},
+}
+
+var replace = &ScalarDefinition{
+	name:  "replace",
+	arity: 3,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		if hasNullArg(args) {
+			return types.NewNullValue(), nil
+		}
+		for _, a := range args {
+			if a.Type() != types.TypeText {
+				return nil, errors.New("replace(arg1, arg2, arg3) expects all arguments to be texts")
+			}
+		}
+
+		old := types.AsString(args[1])
+		if old == "" {
+			return args[0], nil
+		}
+
+		return types.NewTextValue(strings.ReplaceAll(types.AsString(args[0]), old, types.AsString(args[2]))), nil
+	},
+}
+
+// concat converts its arguments to text and concatenates them.
+var concat = &ScalarDefinition{
+	name:     "concat",
+	arity:    variadicArity,
+	minArity: 1,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		if hasNullArg(args) {
+			return types.NewNullValue(), nil
+		}
+
+		var sb strings.Builder
+		for _, a := range args {
+			v, err := a.CastAs(types.TypeText)
+			if err != nil {
+				return nil, err
+			}
+			sb.WriteString(types.AsString(v))
+		}
+
+		return types.NewTextValue(sb.String()), nil
+	},
+}
+
+// splitPart splits a text on a delimiter and returns the field at the given position.
+// Positions start at 1, negative positions start from the end.
+// If the position is out of range, it returns an empty text.
+var splitPart = &ScalarDefinition{
+	name:  "split_part",
+	arity: 3,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		if hasNullArg(args) {
+			return types.NewNullValue(), nil
+		}
+		if args[0].Type() != types.TypeText || args[1].Type() != types.TypeText {
+			return nil, errors.New("split_part(arg1, arg2, arg3) expects arg1 and arg2 to be texts")
+		}
+		if !args[2].Type().IsInteger() {
+			return nil, errors.New("split_part(arg1, arg2, arg3) expects arg3 to be an integer")
+		}
+
+		n := types.AsInt64(args[2])
+		if n == 0 {
+			return nil, errors.New("split_part(arg1, arg2, arg3) expects arg3 to be different from 0")
+		}
+
+		s, sep := types.AsString(args[0]), types.AsString(args[1])
+		fields := []string{s}
+		if sep != "" {
+			fields = strings.Split(s, sep)
+		}
+
+		if n < 0 {
+			n += int64(len(fields)) + 1
+		}
+		if n < 1 || n > int64(len(fields)) {
+			return types.NewTextValue(""), nil
+		}
+
+		return types.NewTextValue(fields[n-1]), nil
+	},
+}
+
+var lpad = &ScalarDefinition{
+	name:     "lpad",
+	arity:    3,
+	minArity: 2,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		return pad("lpad", true, args)
+	},
+}
+
+var rpad = &ScalarDefinition{
+	name:     "rpad",
+	arity:    3,
+	minArity: 2,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		return pad("rpad", false, args)
+	},
+}
+
+// pad fills a text up to the given length with a fill text, which defaults to a space.
+// If the text is longer, it is truncated.
+func pad(name string, left bool, args []types.Value) (types.Value, error) {
+	if hasNullArg(args) {
+		return types.NewNullValue(), nil
+	}
+	if args[0].Type() != types.TypeText {
+		return nil, errors.Errorf("%s(arg1, arg2) expects arg1 to be a text", name)
+	}
+	if !args[1].Type().IsInteger() {
+		return nil, errors.Errorf("%s(arg1, arg2) expects arg2 to be an integer", name)
+	}
+
+	fill := []rune(" ")
+	if len(args) == 3 {
+		if args[2].Type() != types.TypeText {
+			return nil, errors.Errorf("%s(arg1, arg2, arg3) expects arg3 to be a text", name)
+		}
+		fill = []rune(types.AsString(args[2]))
+	}
+
+	r := []rune(types.AsString(args[0]))
+	n := int(max(types.AsInt64(args[1]), 0))
+	if n <= len(r) || len(fill) == 0 {
+		return types.NewTextValue(string(r[:min(n, len(r))])), nil
+	}
+
+	padding := make([]rune, 0, n-len(r))
+	for i := 0; len(padding) < cap(padding); i++ {
+		padding = append(padding, fill[i%len(fill)])
+	}
+
+	if left {
+		return types.NewTextValue(string(padding) + string(r)), nil
+	}
+	return types.NewTextValue(string(r) + string(padding)), nil
+}
+
+// length returns the number of characters of a text or the number of bytes of a blob.
+var length = &ScalarDefinition{
+	name:  "length",
+	arity: 1,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		switch args[0].Type() {
+		case types.TypeNull:
+			return types.NewNullValue(), nil
+		case types.TypeText:
+			return types.NewBigintValue(int64(utf8.RuneCountInString(types.AsString(args[0])))), nil
+		case types.TypeBlob:
+			return types.NewBigintValue(int64(len(types.AsByteSlice(args[0])))), nil
+		}
+
+		return nil, errors.New("length(arg1) expects arg1 to be a text or a blob")
+	},
+}
+
+// octetLength returns the number of bytes of a text or a blob.
+var octetLength = &ScalarDefinition{
+	name:  "octet_length",
+	arity: 1,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		switch args[0].Type() {
+		case types.TypeNull:
+			return types.NewNullValue(), nil
+		case types.TypeText:
+			return types.NewBigintValue(int64(len(types.AsString(args[0])))), nil
+		case types.TypeBlob:
+			return types.NewBigintValue(int64(len(types.AsByteSlice(args[0])))), nil
+		}
+
+		return nil, errors.New("octet_length(arg1) expects arg1 to be a text or a blob")
+	},
+}
+
+func hasNullArg(args []types.Value) bool {
+	for _, a := range args {
+		if a.Type() == types.TypeNull {
+			return true
+		}
+	}
+
+	return false
+}
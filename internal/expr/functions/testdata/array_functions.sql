@@ -0,0 +1,67 @@
+-- test: array_length
+> array_length('[1, 2, "a", [3, 4], {"b": 5}]')
+5
+> array_length('[]')
+0
+> array_length(NULL)
+NULL
+! array_length('{"a": 1}')
+'array_length(arg1) expects arg1 to be a JSON array'
+! array_length(1)
+'array_length(arg1) expects arg1 to be a JSON array'
+
+-- test: array_contains
+> array_contains('[1, 2, "a"]', 2)
+true
+> array_contains('[1, 2, "a"]', 2.0)
+true
+> array_contains('[1, 2, "a"]', 'a')
+true
+> array_contains('[1, 2, "a"]', 3)
+false
+> array_contains('[1, 2, "a"]', '2')
+false
+> array_contains('[[1, 2]]', '[1, 2]')
+true
+> array_contains('[1, 2, "a"]', NULL)
+NULL
+> array_contains(NULL, 1)
+NULL
+
+-- test: array_position
+> array_position('[1, 2, "a"]', 1)
+0
+> array_position('[1, 2, "a"]', 'a')
+2
+> array_position('[1, 2, 1]', 1)
+0
+> array_position('[1, 2, "a"]', 3)
+NULL
+> array_position('[1, 2, "a"]', NULL)
+NULL
+
+-- test: array_append
+> array_append('[1, 2]', 3)
+'[1, 2, 3]'
+> array_append('[]', 'a')
+'["a"]'
+> array_append('["a"]', true)
+'["a", true]'
+> array_append('[1]', NULL)
+'[1, null]'
+> array_append(NULL, 1)
+NULL
+
+-- test: array_slice
+> array_slice('[1, 2, "a", [3, 4]]', 1, 3)
+'[2, "a"]'
+> array_slice('[1, 2, "a", [3, 4]]', 2, 10)
+'["a", [3, 4]]'
+> array_slice('[1, 2, "a", [3, 4]]', 3, 1)
+'[]'
+> array_slice('[1, 2, "a", [3, 4]]', -1, 1)
+'[1]'
+> array_slice('[1, 2]', NULL, 1)
+NULL
+! array_slice('[1, 2]', 'a', 1)
+'array_slice(arg1, arg2, arg3) expects arg2 and arg3 to be integers'
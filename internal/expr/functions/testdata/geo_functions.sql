@@ -0,0 +1,61 @@
+-- test: st_point
+> st_point(1, 2)
+'POINT(1 2)'
+> st_point(-1.5, 2.25)
+'POINT(-1.5 2.25)'
+> st_point(NULL, 2)
+NULL
+! st_point('a', 2)
+'st_point() expects coordinates to be numbers'
+
+-- test: st_makeenvelope
+> st_makeenvelope(0, 0, 2, 1)
+'POLYGON((0 0, 2 0, 2 1, 0 1, 0 0))'
+> st_makeenvelope(0, 0, NULL, 1)
+NULL
+
+-- test: st_x, st_y
+> st_x('POINT(1.5 2)')
+1.5
+> st_y('point(1.5 2)')
+2.0
+> st_x(NULL)
+NULL
+! st_x('POINT(1)')
+'invalid geometry'
+! st_x('POLYGON((0 0, 1 0, 1 1, 0 0))')
+'st_x() expects a point'
+! st_x(1)
+'st_x() expects a geometry, got integer'
+
+-- test: st_distance
+> st_distance('POINT(0 0)', 'POINT(3 4)')
+5.0
+> st_distance('POINT(0 0)', NULL)
+NULL
+
+-- test: st_dwithin
+> st_dwithin('POINT(0 0)', 'POINT(3 4)', 5)
+true
+> st_dwithin('POINT(0 0)', 'POINT(3 4)', 4.9)
+false
+> st_dwithin('POINT(0 0)', 'POINT(3 4)', NULL)
+NULL
+! st_dwithin('POINT(0 0)', 'POINT(3 4)', 'a')
+'st_dwithin(arg1, arg2, arg3) expects arg3 to be a number'
+
+-- test: st_contains
+> st_contains('POLYGON((0 0, 10 0, 10 10, 0 10, 0 0))', 'POINT(5 5)')
+true
+> st_contains('POLYGON((0 0, 10 0, 10 10, 0 10, 0 0))', 'POINT(10 5)')
+true
+> st_contains('POLYGON((0 0, 10 0, 10 10, 0 10, 0 0))', 'POINT(11 5)')
+false
+> st_contains(st_makeenvelope(0, 0, 10, 10), st_point(1, 1))
+true
+> st_contains('POINT(1 1)', 'POINT(1 1)')
+true
+> st_contains(NULL, 'POINT(1 1)')
+NULL
+! st_contains('POINT(1 1)', 'POLYGON((0 0, 10 0, 10 10, 0 10, 0 0))')
+'st_contains() expects a point'
@@ -0,0 +1,105 @@
+-- test: json_extract
+> json_extract('{"a": {"b": [1, 2.5, "c"]}}', '$.a.b[0]')
+1
+> json_extract('{"a": {"b": [1, 2.5, "c"]}}', '$.a.b[1]')
+2.5
+> json_extract('{"a": {"b": [1, 2.5, "c"]}}', '$.a.b[2]')
+'c'
+> json_extract('{"a": {"b": [1, 2.5, "c"]}}', '$.a.b')
+'[1, 2.5, "c"]'
+> json_extract('{"a": true, "b": null}', '$.a')
+true
+> json_extract('{"a": true, "b": null}', '$.b')
+NULL
+> json_extract('{"a": 1}', '$.z')
+NULL
+> json_extract('{"a": 1}', '$')
+'{"a": 1}'
+> json_extract(NULL, '$.a')
+NULL
+! json_extract(1, '$.a')
+'json_extract(arg1, arg2) expects arg1 to be a JSON text'
+! json_extract('{"a": 1}', 'a')
+'invalid JSON path "a"'
+! json_extract('{"a": 1}', '$.a[x]')
+'invalid JSON path "$.a[x]"'
+
+-- test: json_type
+> json_type('{"a": {"b": [1, "c", null]}}', '$')
+'object'
+> json_type('{"a": {"b": [1, "c", null]}}', '$.a.b')
+'array'
+> json_type('{"a": {"b": [1, "c", null]}}', '$.a.b[0]')
+'number'
+> json_type('{"a": {"b": [1, "c", null]}}', '$.a.b[1]')
+'string'
+> json_type('{"a": {"b": [1, "c", null]}}', '$.a.b[2]')
+'null'
+> json_type('{"a": false}', '$.a')
+'boolean'
+> json_type('{"a": false}', '$.b')
+NULL
+> json_type('[1, 2]')
+'array'
+> json_type('"foo"')
+'string'
+> json_type(NULL)
+NULL
+! json_type('{"a": 1}', '$.a', '$.b')
+'json_type(arg1, arg2) takes at most 2 argument(s), not 3'
+
+-- test: json_set
+> json_set('{"a": 1}', '$.a', 2)
+'{"a": 2}'
+> json_set('{"a": 1}', '$.b', 'foo')
+'{"a": 1,"b":"foo"}'
+> json_set('{"a": {"b": [1, 2]}}', '$.a.b[1]', true)
+'{"a": {"b": [1, true]}}'
+> json_set('{"a": 1}', '$', NULL)
+'null'
+> json_set(NULL, '$.a', 1)
+NULL
+
+-- test: json_merge_patch
+> json_merge_patch('{"a": 1, "b": {"c": 2, "d": 3}}', '{"b": {"c": null, "e": 4}, "f": [1]}')
+'{"a":1,"b":{"d":3,"e":4},"f":[1]}'
+> json_merge_patch('{"a": 1}', '{"a": {"b": null}}')
+'{"a":{}}'
+> json_merge_patch('[1, 2]', '{"a": 1}')
+'{"a":1}'
+> json_merge_patch('{"a": [1, 2]}', '{"a": [3]}')
+'{"a":[3]}'
+> json_merge_patch('{"a": 1}', '"foo"')
+'"foo"'
+> json_merge_patch(NULL, '{}')
+NULL
+! json_merge_patch('{"a": 1', '{}')
+'malformed JSON'
+! json_merge_patch('{}', 1)
+'json_merge_patch(arg1, arg2) expects arg2 to be a JSON text'
+
+-- test: json_patch
+> json_patch('{"a": 1, "b": [1, 2]}', '[{"op": "replace", "path": "/a", "value": 2}, {"op": "add", "path": "/b/-", "value": 3}]')
+'{"a":2,"b":[1,2,3]}'
+> json_patch('{"a": {"b": 1}}', '[{"op": "move", "from": "/a/b", "path": "/c"}, {"op": "remove", "path": "/a"}]')
+'{"c":1}'
+> json_patch('{"a": 1}', '[]')
+'{"a":1}'
+> json_patch('{"a": 1}', NULL)
+NULL
+! json_patch('{"a": 1}', '[{"op": "test", "path": "/a", "value": 2}]')
+'test failed'
+! json_patch('{"a": 1}', '[{"op": "remove", "path": "/b"}]')
+'path "/b" does not exist'
+
+-- test: json_diff
+> json_diff('{"a": 1, "b": [1, 2]}', '{"a": 1.0, "b": [1, 2]}')
+'[]'
+> json_diff('{"a": 1, "b": 2}', '{"a": 3, "c": 4}')
+'[{"op":"remove","path":"/b","old_value":2},{"op":"replace","path":"/a","value":3,"old_value":1},{"op":"add","path":"/c","value":4}]'
+> json_diff('{"a/b": [1, 2, 3]}', '{"a/b": [1, 4]}')
+'[{"op":"replace","path":"/a~1b/1","value":4,"old_value":2},{"op":"remove","path":"/a~1b/2","old_value":3}]'
+> json_diff('1', '[1]')
+'[{"op":"replace","path":"","value":[1],"old_value":1}]'
+> json_diff('{}', NULL)
+NULL
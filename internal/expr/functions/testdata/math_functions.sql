@@ -134,4 +134,130 @@ NULL
 > sqrt(1.1)
 1.0488088481701516
 > sqrt('foo')
-NULL
\ No newline at end of file
+NULL
+
+-- test: floor NULL
+> floor(NULL)
+NULL
+
+-- test: ceil
+> ceil(2.3)
+3.0
+> ceil(-2.3)
+-2.0
+> ceil(2)
+2
+> ceil(NULL)
+NULL
+! ceil('a')
+'ceil(arg1) expects arg1 to be a number'
+
+-- test: round
+> round(2.5)
+3.0
+> round(-2.5)
+-3.0
+> round(2.4)
+2.0
+> round(3.14159, 3)
+3.142
+> round(1234.5, -2)
+1200.0
+> round(1.5, 400)
+1.5
+> round(12)
+12
+> round(1234, -2)
+1200
+> round(1250, -2)
+1300
+> round(-1250, -2)
+-1300
+> typeof(round(1250, -2))
+'integer'
+> round(1250, -20)
+0
+> round(NULL)
+NULL
+> round(1.5, NULL)
+NULL
+! round('a')
+'round(arg1) expects arg1 to be a number'
+! round(1.5, 1.5)
+'round(arg1, arg2) expects arg2 to be an integer'
+
+-- test: pow
+> pow(2, 10)
+1024.0
+> pow(2, -1)
+0.5
+> pow(2.0, 0.5)
+1.4142135623730951
+> pow(-2, 3)
+-8.0
+> pow(NULL, 2)
+NULL
+> pow(2, NULL)
+NULL
+! pow(-8, 0.5)
+'out of range, pow(arg1, arg2) expects arg2 to be an integer when arg1 is negative'
+! pow('foo', 1)
+'cannot cast "foo" as double'
+
+-- test: ln
+> ln(1)
+0.0
+> ln(10)
+2.302585092994046
+> ln(NULL)
+NULL
+! ln(0)
+'out of range, ln(arg1) expects arg1 > 0'
+
+-- test: log
+> log(100)
+2.0
+> log(1000.0)
+3.0
+> log(2, 8)
+3.0
+> log(NULL)
+NULL
+> log(2, NULL)
+NULL
+> log(NULL, 8)
+NULL
+! log(-1)
+'out of range, log(arg1) expects arg1 > 0'
+! log(1, 8)
+'out of range, log(arg1, arg2) expects arg1 > 0 and arg1 != 1'
+
+-- test: sign
+> sign(-5)
+-1
+> sign(0)
+0
+> sign(3.2)
+1
+> sign(-0.1)
+-1
+> sign(NULL)
+NULL
+! sign('a')
+'sign(arg1) expects arg1 to be a number'
+
+-- test: randomblob
+> typeof(randomblob(16))
+'blob'
+> octet_length(randomblob(16))
+16
+> randomblob(NULL)
+NULL
+! randomblob(0)
+'out of range, randomblob(arg1) expects arg1 to be within [1, 1048576]'
+! randomblob('a')
+'randomblob(arg1) expects arg1 to be an integer'
+
+-- test: sqrt bigint
+> sqrt(CAST(16 AS BIGINT))
+4.0
@@ -0,0 +1,131 @@
+-- test: substr
+> substr('hello', 2)
+'ello'
+> substr('hello', 2, 3)
+'ell'
+> substr('hello', 0, 2)
+'h'
+> substr('hello', -3)
+'llo'
+> substr('hello', -3, 2)
+'ll'
+> substr('hello', 10)
+''
+> substr('héllo', 2, 2)
+'él'
+> substr(NULL, 2)
+NULL
+> substr('hello', NULL)
+NULL
+> substr('hello', 2, NULL)
+NULL
+! substr(1, 2)
+'substr(arg1, arg2) expects arg1 to be a text'
+! substr('hello', 'a')
+'substr(arg1, arg2) expects arg2 to be an integer'
+! substr('hello', 1, -1)
+'substr(arg1, arg2, arg3) expects arg3 to be positive'
+! substr('hello')
+
+-- test: replace
+> replace('hello world', 'o', '0')
+'hell0 w0rld'
+> replace('hello', '', 'a')
+'hello'
+> replace('hello', 'l', '')
+'heo'
+> replace(NULL, 'l', '')
+NULL
+> replace('hello', NULL, '')
+NULL
+! replace('hello', 1, '')
+'replace(arg1, arg2, arg3) expects all arguments to be texts'
+
+-- test: concat
+> concat('a')
+'a'
+> concat('a', 'b', 'c')
+'abc'
+> concat('a', 1, 2.5, true)
+'a12.5true'
+> concat('a', NULL)
+NULL
+! concat()
+
+-- test: split_part
+> split_part('a,b,c', ',', 2)
+'b'
+> split_part('a,b,c', ',', -1)
+'c'
+> split_part('a,b,c', ',', 4)
+''
+> split_part('a,,c', ',', 2)
+''
+> split_part('a::b', '::', 2)
+'b'
+> split_part('abc', '', 1)
+'abc'
+> split_part(NULL, ',', 1)
+NULL
+! split_part('a,b', ',', 0)
+'split_part(arg1, arg2, arg3) expects arg3 to be different from 0'
+! split_part('a,b', ',', 'a')
+'split_part(arg1, arg2, arg3) expects arg3 to be an integer'
+
+-- test: lpad
+> lpad('hi', 5)
+'   hi'
+> lpad('hi', 5, 'xy')
+'xyxhi'
+> lpad('hello', 3)
+'hel'
+> lpad('hi', 5, '')
+'hi'
+> lpad('é', 3, 'à')
+'ààé'
+> lpad(NULL, 5)
+NULL
+> lpad('hi', NULL)
+NULL
+! lpad('hi', 'a')
+'lpad(arg1, arg2) expects arg2 to be an integer'
+
+-- test: rpad
+> rpad('hi', 5)
+'hi   '
+> rpad('hi', 5, 'xy')
+'hixyx'
+> rpad('hello', 3)
+'hel'
+> rpad('hi', -1)
+''
+> rpad('hi', 5, NULL)
+NULL
+! rpad(1, 5)
+'rpad(arg1, arg2) expects arg1 to be a text'
+
+-- test: length
+> length('hello')
+5
+> length('héllo')
+5
+> length('')
+0
+> length('\xAABB')
+2
+> length(NULL)
+NULL
+! length(1)
+'length(arg1) expects arg1 to be a text or a blob'
+
+-- test: octet_length
+> octet_length('hello')
+5
+> octet_length('héllo')
+6
+> octet_length('\xAABB')
+2
+> octet_length(NULL)
+NULL
+! octet_length(1)
+'octet_length(arg1) expects arg1 to be a text or a blob'
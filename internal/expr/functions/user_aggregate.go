@@ -0,0 +1,158 @@
+package functions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// An AggregateFunc computes a value from the rows of a group.
+// A new AggregateFunc is created for every group.
+type AggregateFunc interface {
+	// Step is called for each row of the group with the evaluated arguments of the function.
+	Step(args ...types.Value) error
+	// Finalize returns the result of the aggregation.
+	Finalize() (types.Value, error)
+}
+
+// An AggregateDefinition is the definition of a user-defined aggregate function.
+type AggregateDefinition struct {
+	name  string
+	arity int
+	newFn func() AggregateFunc
+}
+
+// NewAggregateDefinition returns the definition of an aggregate function.
+// newFn is called to create the state of each group.
+func NewAggregateDefinition(name string, arity int, newFn func() AggregateFunc) *AggregateDefinition {
+	return &AggregateDefinition{name: name, arity: arity, newFn: newFn}
+}
+
+// Name returns the defined function named (as an ident, so no parentheses).
+func (fd *AggregateDefinition) Name() string {
+	return fd.name
+}
+
+// String returns the defined function name and its arguments.
+func (fd *AggregateDefinition) String() string {
+	args := make([]string, 0, fd.arity)
+	for i := 0; i < fd.arity; i++ {
+		args = append(args, fmt.Sprintf("arg%d", i+1))
+	}
+	return fmt.Sprintf("%s(%s)", fd.name, strings.Join(args, ", "))
+}
+
+// Function returns a UserAggregate expr node.
+func (fd *AggregateDefinition) Function(args ...expr.Expr) (expr.Function, error) {
+	if len(args) != fd.arity {
+		return nil, fmt.Errorf("%s takes %d argument(s), not %d", fd.String(), fd.arity, len(args))
+	}
+
+	return &UserAggregate{def: fd, Exprs: args}, nil
+}
+
+var _ expr.AggregatorBuilder = (*UserAggregate)(nil)
+
+// UserAggregate is a call to a user-defined aggregate function.
+type UserAggregate struct {
+	def   *AggregateDefinition
+	Exprs []expr.Expr
+}
+
+func (u *UserAggregate) Clone() expr.Expr {
+	exprs := make([]expr.Expr, len(u.Exprs))
+	for i := range u.Exprs {
+		exprs[i] = expr.Clone(u.Exprs[i])
+	}
+
+	return &UserAggregate{
+		def:   u.def,
+		Exprs: exprs,
+	}
+}
+
+// Eval extracts the aggregated value from the given row and returns it.
+func (u *UserAggregate) Eval(env *environment.Environment) (types.Value, error) {
+	r, ok := env.GetRow()
+	if !ok {
+		return nil, fmt.Errorf("misuse of aggregation function %s()", u.def.name)
+	}
+
+	return r.Get(u.String())
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (u *UserAggregate) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*UserAggregate)
+	if !ok || u.def != o.def || len(u.Exprs) != len(o.Exprs) {
+		return false
+	}
+
+	for i := range u.Exprs {
+		if !expr.Equal(u.Exprs[i], o.Exprs[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (u *UserAggregate) Params() []expr.Expr { return u.Exprs }
+
+func (u *UserAggregate) String() string {
+	args := make([]string, len(u.Exprs))
+	for i, e := range u.Exprs {
+		args[i] = e.String()
+	}
+
+	return fmt.Sprintf("%s(%s)", u.def.name, strings.Join(args, ", "))
+}
+
+// Aggregator returns a UserAggregator. It implements the AggregatorBuilder interface.
+func (u *UserAggregate) Aggregator() expr.Aggregator {
+	return &UserAggregator{
+		Fn:    u,
+		State: u.def.newFn(),
+	}
+}
+
+// UserAggregator passes the rows of a group to a user-defined aggregate function.
+type UserAggregator struct {
+	Fn    *UserAggregate
+	State AggregateFunc
+}
+
+// Aggregate evaluates the arguments of the function and passes them to the Step method.
+func (u *UserAggregator) Aggregate(env *environment.Environment) error {
+	args := make([]types.Value, len(u.Fn.Exprs))
+	for i, e := range u.Fn.Exprs {
+		v, err := e.Eval(env)
+		if err != nil && !errors.Is(err, types.ErrColumnNotFound) {
+			return err
+		}
+		if v == nil {
+			v = types.NewNullValue()
+		}
+		args[i] = v
+	}
+
+	return u.State.Step(args...)
+}
+
+// Eval returns the result of the Finalize method.
+func (u *UserAggregator) Eval(_ *environment.Environment) (types.Value, error) {
+	return u.State.Finalize()
+}
+
+func (u *UserAggregator) String() string {
+	return u.Fn.String()
+}
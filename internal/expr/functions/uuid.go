@@ -0,0 +1,95 @@
+package functions
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/chaisql/chai/internal/types"
+)
+
+// uuid returns a random (version 4) UUID.
+var uuid = &ScalarDefinition{
+	name:  "uuid",
+	arity: 0,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		var u [16]byte
+		_, err := crand.Read(u[:])
+		if err != nil {
+			return nil, err
+		}
+
+		u[6] = u[6]&0x0F | 0x40 // version 4
+		u[8] = u[8]&0x3F | 0x80 // RFC 9562 variant
+		return types.NewUUIDValue(u), nil
+	},
+}
+
+// uuidv7 returns a time-ordered (version 7) UUID.
+// Its first 48 bits are the Unix timestamp in milliseconds,
+// which keeps the UUIDs generated around the same time close to each other in indexes.
+var uuidv7 = &ScalarDefinition{
+	name:  "uuidv7",
+	arity: 0,
+	callFn: func(args ...types.Value) (types.Value, error) {
+		u, err := v7gen.next(time.Now())
+		if err != nil {
+			return nil, err
+		}
+
+		return types.NewUUIDValue(u), nil
+	},
+}
+
+var v7gen v7Generator
+
+// v7Generator generates increasing version 7 UUIDs.
+// The 12 bits following the version are used as a counter
+// for the UUIDs generated during the same millisecond, as described
+// by the method 1 of section 6.2 of RFC 9562.
+type v7Generator struct {
+	mu     sync.Mutex
+	lastMs int64
+	seq    uint16
+}
+
+func (g *v7Generator) next(now time.Time) ([16]byte, error) {
+	var u [16]byte
+	_, err := crand.Read(u[:])
+	if err != nil {
+		return u, err
+	}
+
+	g.mu.Lock()
+	ms := now.UnixMilli()
+	if ms > g.lastMs {
+		// start the counter with a random value, leaving room
+		// for the UUIDs generated in the same millisecond
+		g.seq = binary.BigEndian.Uint16(u[6:8]) & 0x7FF
+	} else {
+		// the clock didn't move or went backwards:
+		// keep the last timestamp and increment the counter
+		ms = g.lastMs
+		g.seq++
+		if g.seq > 0xFFF {
+			ms++
+			g.seq = 0
+		}
+	}
+	g.lastMs = ms
+	seq := g.seq
+	g.mu.Unlock()
+
+	u[0] = byte(ms >> 40)
+	u[1] = byte(ms >> 32)
+	u[2] = byte(ms >> 24)
+	u[3] = byte(ms >> 16)
+	u[4] = byte(ms >> 8)
+	u[5] = byte(ms)
+	u[6] = 0x70 | byte(seq>>8) // version 7
+	u[7] = byte(seq)
+	u[8] = u[8]&0x3F | 0x80 // RFC 9562 variant
+
+	return u, nil
+}
@@ -185,3 +185,39 @@ func MatchLike(pattern, s string) bool {
 	}
 	return true
 }
+
+// LikeLiterals returns the parts of a LIKE pattern that must appear as is
+// in the strings matching it, i.e. the sequences of characters between the
+// wildcards, with escape characters removed.
+func LikeLiterals(pattern string) []string {
+	var list []string
+	var cur []rune
+	var prevEscape bool
+
+	for len(pattern) != 0 {
+		var p rune
+		p, pattern = readRune(pattern)
+
+		switch {
+		case prevEscape:
+			prevEscape = false
+		case p == matchEsc:
+			prevEscape = true
+			continue
+		case p == matchAll || p == matchOne:
+			if len(cur) > 0 {
+				list = append(list, string(cur))
+				cur = cur[:0]
+			}
+			continue
+		}
+
+		cur = append(cur, p)
+	}
+
+	if len(cur) > 0 {
+		list = append(list, string(cur))
+	}
+
+	return list
+}
@@ -200,3 +200,32 @@ func TestMatchLike(t *testing.T) {
 		}
 	}
 }
+
+func TestLikeLiterals(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"", nil},
+		{"%", nil},
+		{"abc", []string{"abc"}},
+		{"%abc%", []string{"abc"}},
+		{"a_c%def", []string{"a", "c", "def"}},
+		{"%50\\%%", []string{"50%"}},
+		{"a\\_b", []string{"a_b"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern, func(t *testing.T) {
+			got := LikeLiterals(test.pattern)
+			if len(got) != len(test.want) {
+				t.Fatalf("LikeLiterals(%q) = %q, want %q", test.pattern, got, test.want)
+			}
+			for i := range got {
+				if got[i] != test.want[i] {
+					t.Fatalf("LikeLiterals(%q) = %q, want %q", test.pattern, got, test.want)
+				}
+			}
+		})
+	}
+}
@@ -11,6 +11,10 @@ import (
 // A LiteralValue represents a literal value of any type defined by the value package.
 type LiteralValue struct {
 	Value types.Value
+
+	// Exact is the decimal value of a number literal, if any.
+	// It is used instead of Value in arithmetic with decimals.
+	Exact *types.DecimalValue
 }
 
 // IsEqual compares this expression with the other expression and returns
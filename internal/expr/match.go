@@ -0,0 +1,75 @@
+package expr
+
+import (
+	"fmt"
+
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/fulltext"
+	"github.com/chaisql/chai/internal/sql/scanner"
+	"github.com/chaisql/chai/internal/types"
+)
+
+// MatchOperator evaluates to true if the text on the left contains
+// all the terms of the query on the right.
+// If the column has a full-text index, the planner uses it
+// to read only the matching rows, and the terms are extracted with
+// the analyzer of the index. Otherwise the default analyzer is used.
+type MatchOperator struct {
+	*simpleOperator
+}
+
+// Match creates an expression that evaluates to the result of a MATCH b.
+func Match(a, b Expr) Expr {
+	return &MatchOperator{&simpleOperator{a, b, scanner.MATCH}}
+}
+
+func (op *MatchOperator) Clone() Expr {
+	return &MatchOperator{
+		simpleOperator: op.simpleOperator.Clone(),
+	}
+}
+
+func (op *MatchOperator) Eval(env *environment.Environment) (types.Value, error) {
+	return op.simpleOperator.eval(env, func(a, b types.Value) (types.Value, error) {
+		if a.Type() != types.TypeText || b.Type() != types.TypeText {
+			return NullLiteral, nil
+		}
+
+		an, err := op.analyzer(env)
+		if err != nil {
+			return nil, err
+		}
+
+		if fulltext.Contains(an.Analyze(types.AsString(a)), an.Analyze(types.AsString(b))) {
+			return TrueLiteral, nil
+		}
+
+		return FalseLiteral, nil
+	})
+}
+
+// analyzer returns the analyzer of the full-text index
+// of the column on the left, if any.
+func (op *MatchOperator) analyzer(env *environment.Environment) (fulltext.Analyzer, error) {
+	col, ok := op.a.(*Column)
+	if !ok {
+		return fulltext.Lookup("")
+	}
+
+	r, ok := env.GetDatabaseRow()
+	tx := env.GetTx()
+	if !ok || tx == nil {
+		return fulltext.Lookup("")
+	}
+
+	info := tx.Catalog.GetFulltextIndexInfo(r.TableName(), col.Name)
+	if info == nil {
+		return fulltext.Lookup("")
+	}
+
+	return fulltext.Lookup(info.Analyzer)
+}
+
+func (op *MatchOperator) String() string {
+	return fmt.Sprintf("%v MATCH %v", op.a, op.b)
+}
@@ -2,6 +2,7 @@ package expr
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/chaisql/chai/internal/environment"
 	"github.com/chaisql/chai/internal/sql/scanner"
@@ -47,16 +48,21 @@ func (op *simpleOperator) Clone() *simpleOperator {
 }
 
 func (op *simpleOperator) eval(env *environment.Environment, fn func(a, b types.Value) (types.Value, error)) (types.Value, error) {
+	return op.evalWith(env, Expr.Eval, fn)
+}
+
+// evalWith is like eval but evaluates the operands with evalFn.
+func (op *simpleOperator) evalWith(env *environment.Environment, evalFn func(Expr, *environment.Environment) (types.Value, error), fn func(a, b types.Value) (types.Value, error)) (types.Value, error) {
 	if op.a == nil || op.b == nil {
 		return NullLiteral, errors.New("missing operand")
 	}
 
-	va, err := op.a.Eval(env)
+	va, err := evalFn(op.a, env)
 	if err != nil {
 		return NullLiteral, err
 	}
 
-	vb, err := op.b.Eval(env)
+	vb, err := evalFn(op.b, env)
 	if err != nil {
 		return NullLiteral, err
 	}
@@ -108,6 +114,10 @@ func Concat(a, b Expr) Expr {
 	return &ConcatOperator{&simpleOperator{a, b, scanner.CONCAT}}
 }
 
+func (op *ConcatOperator) Clone() Expr {
+	return &ConcatOperator{op.simpleOperator.Clone()}
+}
+
 func (op *ConcatOperator) Eval(env *environment.Environment) (types.Value, error) {
 	return op.simpleOperator.eval(env, func(a, b types.Value) (types.Value, error) {
 		if a.Type() != types.TypeText || b.Type() != types.TypeText {
@@ -122,6 +132,15 @@ func (op *ConcatOperator) Eval(env *environment.Environment) (types.Value, error
 type Cast struct {
 	Expr   Expr
 	CastAs types.Type
+	// Format controls how values are converted from or to text.
+	// See types.CastWithFormat.
+	Format string
+	// If true, the cast returns NULL instead of failing
+	// when the value cannot be converted (TRY_CAST).
+	Try bool
+	// Bits is the width of the integer type the value is cast to,
+	// i.e. 16 for INT16. If zero, the range of CastAs is used.
+	Bits int
 }
 
 // Eval returns the primary key of the current row.
@@ -131,7 +150,21 @@ func (c *Cast) Eval(env *environment.Environment) (types.Value, error) {
 		return v, err
 	}
 
-	return v.CastAs(c.CastAs)
+	// timestamps are parsed and formatted in the time zone of the database
+	loc := env.GetTx().Location()
+	if c.Format != "" {
+		v, err = types.CastWithFormatIn(v, c.CastAs, c.Format, loc)
+	} else {
+		v, err = types.CastIn(v, c.CastAs, loc)
+	}
+	if err == nil && c.Bits > 0 && v.Type() != types.TypeNull {
+		err = types.ConstrainInteger(v, c.Bits)
+	}
+	if err != nil && c.Try {
+		return types.NewNullValue(), nil
+	}
+
+	return v, err
 }
 
 // IsEqual compares this expression with the other expression and returns
@@ -146,7 +179,7 @@ func (c *Cast) IsEqual(other Expr) bool {
 		return false
 	}
 
-	if c.CastAs != o.CastAs {
+	if c.CastAs != o.CastAs || c.Format != o.Format || c.Try != o.Try || c.Bits != o.Bits {
 		return false
 	}
 
@@ -160,5 +193,19 @@ func (c *Cast) IsEqual(other Expr) bool {
 func (c *Cast) Params() []Expr { return []Expr{c.Expr} }
 
 func (c *Cast) String() string {
-	return fmt.Sprintf("CAST(%v AS %v)", c.Expr, c.CastAs)
+	name := "CAST"
+	if c.Try {
+		name = "TRY_CAST"
+	}
+
+	typ := c.CastAs.String()
+	if c.Bits > 0 {
+		typ = strings.ToLower(types.IntegerTypeName(c.Bits))
+	}
+
+	if c.Format != "" {
+		return fmt.Sprintf("%s(%v AS %s FORMAT %s)", name, c.Expr, typ, types.NewTextValue(c.Format))
+	}
+
+	return fmt.Sprintf("%s(%v AS %s)", name, c.Expr, typ)
 }
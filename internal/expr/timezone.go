@@ -0,0 +1,139 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// AtTimeZone is an expression that associates a timestamp with a time zone.
+// The instant described by the timestamp is unchanged, but it is formatted
+// in the time zone when converted to text or returned by a query.
+// Texts are parsed as timestamps, interpreting those without zone offset
+// in the time zone.
+type AtTimeZone struct {
+	Expr Expr
+	Zone Expr
+}
+
+// NewAtTimeZone returns an AT TIME ZONE expression.
+func NewAtTimeZone(e, zone Expr) *AtTimeZone {
+	return &AtTimeZone{Expr: e, Zone: zone}
+}
+
+func (a *AtTimeZone) Eval(env *environment.Environment) (types.Value, error) {
+	v, err := a.Expr.Eval(env)
+	if err != nil || v.Type() == types.TypeNull {
+		return v, err
+	}
+
+	z, err := a.Zone.Eval(env)
+	if err != nil || z.Type() == types.TypeNull {
+		return z, err
+	}
+	if z.Type() != types.TypeText {
+		return nil, errors.Errorf("time zone must be a text, got %s", z.Type())
+	}
+
+	loc, err := LoadLocation(types.AsString(z))
+	if err != nil {
+		return nil, err
+	}
+
+	switch v.Type() {
+	case types.TypeTimestamp:
+		return types.NewTimestampValue(types.AsTime(v)).In(loc), nil
+	case types.TypeText:
+		t, err := types.ParseTimestampIn(types.AsString(v), loc)
+		if err != nil {
+			return nil, fmt.Errorf(`cannot cast %q as timestamp: %w`, types.AsString(v), err)
+		}
+		return types.NewTimestampValue(t).In(loc), nil
+	}
+
+	return nil, errors.Errorf("cannot use AT TIME ZONE with %s", v.Type())
+}
+
+// LoadLocation returns the time zone with the given name.
+// The name is either an IANA time zone name (i.e. "Europe/Paris"),
+// or a fixed offset from UTC (i.e. "+02:00", "-0530").
+func LoadLocation(name string) (*time.Location, error) {
+	if strings.EqualFold(name, "UTC") || strings.EqualFold(name, "Z") {
+		// time.UTC is used for the timestamps without location
+		return time.FixedZone("UTC", 0), nil
+	}
+
+	if name != "" && (name[0] == '+' || name[0] == '-') {
+		if offset, ok := parseOffset(name[1:]); ok {
+			if name[0] == '-' {
+				offset = -offset
+			}
+			return time.FixedZone(name, offset), nil
+		}
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "" || strings.EqualFold(name, "Local") {
+		return nil, errors.Errorf("unknown time zone %q", name)
+	}
+
+	return loc, nil
+}
+
+// parseOffset parses an offset of the form HH, HH:MM or HHMM
+// and returns it in seconds.
+func parseOffset(s string) (int, bool) {
+	hh, mm := s, ""
+	switch {
+	case len(s) == 5 && s[2] == ':':
+		hh, mm = s[:2], s[3:]
+	case len(s) == 4:
+		hh, mm = s[:2], s[2:]
+	case len(s) != 2:
+		return 0, false
+	}
+	if strings.Trim(hh+mm, "0123456789") != "" {
+		return 0, false
+	}
+
+	h, err := strconv.Atoi(hh)
+	if err != nil || h > 14 {
+		return 0, false
+	}
+	var m int
+	if mm != "" {
+		m, err = strconv.Atoi(mm)
+		if err != nil || m > 59 {
+			return 0, false
+		}
+	}
+
+	return h*3600 + m*60, true
+}
+
+func (a *AtTimeZone) Clone() Expr {
+	return &AtTimeZone{
+		Expr: Clone(a.Expr),
+		Zone: Clone(a.Zone),
+	}
+}
+
+func (a *AtTimeZone) IsEqual(other Expr) bool {
+	o, ok := other.(*AtTimeZone)
+	if !ok {
+		return false
+	}
+
+	return Equal(a.Expr, o.Expr) && Equal(a.Zone, o.Zone)
+}
+
+func (a *AtTimeZone) Params() []Expr { return []Expr{a.Expr, a.Zone} }
+
+func (a *AtTimeZone) String() string {
+	return fmt.Sprintf("%v AT TIME ZONE %v", a.Expr, a.Zone)
+}
@@ -0,0 +1,173 @@
+package fulltext
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/cockroachdb/errors"
+)
+
+// DefaultAnalyzer is the name of the analyzer used by full-text indexes
+// created without an analyzer option.
+const DefaultAnalyzer = "unicode"
+
+// TrigramAnalyzer is the name of the analyzer indexing the trigrams of each term.
+// Full-text indexes using it can be used to find the rows containing a substring.
+// See SubstringTrigrams.
+const TrigramAnalyzer = "trigram"
+
+// An Analyzer converts a text into the list of terms stored in a full-text index.
+// The same analyzer is used for the indexed text and for the queries,
+// so that both produce comparable terms.
+type Analyzer interface {
+	Analyze(s string) []string
+}
+
+// A Tokenizer splits a text into tokens.
+type Tokenizer func(s string) []string
+
+// A Filter transforms a list of tokens. It can modify, remove or add tokens.
+type Filter func(tokens []string) []string
+
+// Pipeline is an analyzer that splits a text with a tokenizer
+// then applies a list of filters, in order.
+type Pipeline struct {
+	Tokenizer Tokenizer
+	Filters   []Filter
+}
+
+// NewAnalyzer returns an analyzer that tokenizes a text then applies the filters.
+func NewAnalyzer(t Tokenizer, filters ...Filter) *Pipeline {
+	return &Pipeline{Tokenizer: t, Filters: filters}
+}
+
+// Analyze returns the terms of the text.
+func (p *Pipeline) Analyze(s string) []string {
+	tokens := p.Tokenizer(s)
+	for _, f := range p.Filters {
+		tokens = f(tokens)
+	}
+
+	return tokens
+}
+
+var registry = struct {
+	sync.RWMutex
+	analyzers map[string]Analyzer
+}{
+	analyzers: map[string]Analyzer{
+		// unicode splits the text on anything that is not a letter or a digit.
+		"unicode": NewAnalyzer(Tokenize),
+		// english removes english stop words and reduces the terms to their stem.
+		"english": NewAnalyzer(Tokenize, StopWords(EnglishStopWords...), EnglishStemmer),
+		// trigram indexes the trigrams of each term, which allows
+		// to search for parts of words.
+		TrigramAnalyzer: NewAnalyzer(Tokenize, NGrams(3, 3)),
+	},
+}
+
+// Register makes an analyzer available to full-text indexes under the given name.
+// An analyzer must be registered before opening a database containing
+// indexes that use it.
+// If an analyzer is already registered with the same name, it is replaced.
+func Register(name string, a Analyzer) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	registry.analyzers[name] = a
+}
+
+// Lookup returns the analyzer registered under the given name.
+// If the name is empty, it returns the default analyzer.
+func Lookup(name string) (Analyzer, error) {
+	if name == "" {
+		name = DefaultAnalyzer
+	}
+
+	registry.RLock()
+	defer registry.RUnlock()
+
+	a, ok := registry.analyzers[name]
+	if !ok {
+		return nil, errors.Errorf("unknown analyzer %q", name)
+	}
+
+	return a, nil
+}
+
+// StopWords returns a filter that removes the given words.
+func StopWords(words ...string) Filter {
+	set := make(map[string]struct{}, len(words))
+	for _, w := range words {
+		set[w] = struct{}{}
+	}
+
+	return func(tokens []string) []string {
+		list := tokens[:0]
+		for _, t := range tokens {
+			if _, ok := set[t]; !ok {
+				list = append(list, t)
+			}
+		}
+
+		return list
+	}
+}
+
+// NGrams returns a filter that replaces each token with its character
+// n-grams, from min to max characters.
+// Tokens shorter than min are kept as is.
+func NGrams(min, max int) Filter {
+	return func(tokens []string) []string {
+		var list []string
+
+		for _, t := range tokens {
+			r := []rune(t)
+			if len(r) < min {
+				list = append(list, t)
+				continue
+			}
+
+			for n := min; n <= max && n <= len(r); n++ {
+				for i := 0; i+n <= len(r); i++ {
+					list = append(list, string(r[i:i+n]))
+				}
+			}
+		}
+
+		return list
+	}
+}
+
+// SubstringTrigrams returns trigrams produced by the trigram analyzer for
+// any text containing s, ignoring case.
+// Terms of s shorter than three characters are ignored, as they can be
+// part of longer terms of the text, which are only indexed as trigrams.
+// Characters equal under case folding to characters with a different
+// lowercase form, i.e. "s" and "ſ", are ignored as well.
+func SubstringTrigrams(s string) []string {
+	var tokens []string
+	for _, t := range Tokenize(s) {
+		for _, part := range strings.FieldsFunc(t, isFoldVariant) {
+			if utf8.RuneCountInString(part) >= 3 {
+				tokens = append(tokens, part)
+			}
+		}
+	}
+
+	return Unique(NGrams(3, 3)(tokens))
+}
+
+// isFoldVariant returns whether some of the characters equal to the lowercase
+// character r under case folding have a different lowercase form.
+func isFoldVariant(r rune) bool {
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if unicode.ToLower(f) != r {
+			return true
+		}
+	}
+
+	return false
+}
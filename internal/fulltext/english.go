@@ -0,0 +1,170 @@
+package fulltext
+
+import "strings"
+
+// EnglishStopWords is a list of common english words that are not worth indexing.
+var EnglishStopWords = []string{
+	"a", "an", "and", "are", "as", "at", "be", "but", "by", "for", "if", "in",
+	"into", "is", "it", "no", "not", "of", "on", "or", "such", "that", "the",
+	"their", "then", "there", "these", "they", "this", "to", "was", "will", "with",
+}
+
+// EnglishStemmer is a filter that reduces english words to their stem,
+// so that "jumps", "jumped" and "jumping" all produce "jump".
+// It implements the steps 1 and 5 of the Porter stemming algorithm,
+// which remove plurals, -ed, -ing and trailing -e suffixes.
+// Tokens containing non ASCII letters are kept as is.
+func EnglishStemmer(tokens []string) []string {
+	for i, t := range tokens {
+		tokens[i] = stem(t)
+	}
+
+	return tokens
+}
+
+func stem(w string) string {
+	if len(w) <= 2 {
+		return w
+	}
+	for i := 0; i < len(w); i++ {
+		if w[i] < 'a' || w[i] > 'z' {
+			return w
+		}
+	}
+
+	w = step1a(w)
+	w = step1b(w)
+	w = step1c(w)
+	return step5(w)
+}
+
+// step1a removes plurals.
+func step1a(w string) string {
+	switch {
+	case strings.HasSuffix(w, "sses"):
+		return w[:len(w)-2]
+	case strings.HasSuffix(w, "ies"):
+		return w[:len(w)-2]
+	case strings.HasSuffix(w, "ss"):
+		return w
+	case strings.HasSuffix(w, "s"):
+		return w[:len(w)-1]
+	}
+
+	return w
+}
+
+// step1b removes -eed, -ed and -ing.
+func step1b(w string) string {
+	if strings.HasSuffix(w, "eed") {
+		if measure(w[:len(w)-3]) > 0 {
+			return w[:len(w)-1]
+		}
+		return w
+	}
+
+	var s string
+	switch {
+	case strings.HasSuffix(w, "ed") && hasVowel(w[:len(w)-2]):
+		s = w[:len(w)-2]
+	case strings.HasSuffix(w, "ing") && hasVowel(w[:len(w)-3]):
+		s = w[:len(w)-3]
+	default:
+		return w
+	}
+
+	switch {
+	case strings.HasSuffix(s, "at"), strings.HasSuffix(s, "bl"), strings.HasSuffix(s, "iz"):
+		return s + "e"
+	case endsWithDoubleConsonant(s):
+		if c := s[len(s)-1]; c != 'l' && c != 's' && c != 'z' {
+			return s[:len(s)-1]
+		}
+	case measure(s) == 1 && endsWithCVC(s):
+		return s + "e"
+	}
+
+	return s
+}
+
+// step1c replaces a trailing y with i if the stem contains a vowel.
+func step1c(w string) string {
+	if strings.HasSuffix(w, "y") && hasVowel(w[:len(w)-1]) {
+		return w[:len(w)-1] + "i"
+	}
+
+	return w
+}
+
+// step5 removes a trailing e and reduces a trailing ll.
+func step5(w string) string {
+	if strings.HasSuffix(w, "e") {
+		s := w[:len(w)-1]
+		if m := measure(s); m > 1 || m == 1 && !endsWithCVC(s) {
+			w = s
+		}
+	}
+
+	if strings.HasSuffix(w, "ll") && measure(w) > 1 {
+		w = w[:len(w)-1]
+	}
+
+	return w
+}
+
+// isConsonant reports whether the letter at index i is a consonant.
+// y is a consonant when it follows a vowel or starts the word.
+func isConsonant(w string, i int) bool {
+	switch w[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !isConsonant(w, i-1)
+	}
+
+	return true
+}
+
+// measure returns the number of vowel-consonant sequences of the word.
+func measure(w string) int {
+	var m int
+	var vowel bool
+
+	for i := range w {
+		if !isConsonant(w, i) {
+			vowel = true
+		} else if vowel {
+			m++
+			vowel = false
+		}
+	}
+
+	return m
+}
+
+func hasVowel(w string) bool {
+	for i := range w {
+		if !isConsonant(w, i) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func endsWithDoubleConsonant(w string) bool {
+	n := len(w)
+	return n >= 2 && w[n-1] == w[n-2] && isConsonant(w, n-1)
+}
+
+// endsWithCVC reports whether the word ends with a consonant-vowel-consonant
+// sequence where the last consonant is not w, x or y, e.g. "hop".
+func endsWithCVC(w string) bool {
+	n := len(w)
+	if n < 3 || !isConsonant(w, n-1) || isConsonant(w, n-2) || !isConsonant(w, n-3) {
+		return false
+	}
+
+	c := w[n-1]
+	return c != 'w' && c != 'x' && c != 'y'
+}
@@ -0,0 +1,96 @@
+// Package fulltext provides the text processing used by full-text indexes
+// and the MATCH operator.
+package fulltext
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// BM25 parameters.
+const (
+	// K1 controls how quickly the score saturates
+	// as the frequency of a term increases.
+	K1 = 1.2
+	// B controls how much the length of a document
+	// normalizes its score.
+	B = 0.75
+)
+
+// Tokenize splits a text into lowercase terms.
+// Terms are sequences of letters and digits, everything else
+// is considered a separator.
+func Tokenize(s string) []string {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for i := range fields {
+		fields[i] = strings.ToLower(fields[i])
+	}
+
+	return fields
+}
+
+// Unique returns the list of terms without duplicates,
+// in order of first appearance.
+func Unique(terms []string) []string {
+	seen := make(map[string]struct{}, len(terms))
+	list := make([]string, 0, len(terms))
+
+	for _, t := range terms {
+		if _, ok := seen[t]; ok {
+			continue
+		}
+		seen[t] = struct{}{}
+		list = append(list, t)
+	}
+
+	return list
+}
+
+// Contains returns true if all the terms of the query
+// appear in the document.
+// An empty query matches nothing.
+func Contains(doc, query []string) bool {
+	if len(query) == 0 {
+		return false
+	}
+
+	set := make(map[string]struct{}, len(doc))
+	for _, t := range doc {
+		set[t] = struct{}{}
+	}
+
+	for _, t := range query {
+		if _, ok := set[t]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// BM25 returns the score of a term for a document.
+//   - tf: number of occurrences of the term in the document
+//   - df: number of documents containing the term
+//   - docLen: number of terms of the document
+//   - docCount: number of documents
+//   - avgDocLen: average number of terms per document
+func BM25(tf, df, docLen, docCount uint64, avgDocLen float64) float64 {
+	if tf == 0 || docCount == 0 {
+		return 0
+	}
+
+	n := float64(docCount)
+	idf := math.Log(1 + (n-float64(df)+0.5)/(float64(df)+0.5))
+
+	norm := 1.0
+	if avgDocLen > 0 {
+		norm = 1 - B + B*float64(docLen)/avgDocLen
+	}
+
+	f := float64(tf)
+	return idf * (f * (K1 + 1)) / (f + K1*norm)
+}
@@ -0,0 +1,107 @@
+package fulltext_test
+
+import (
+	"testing"
+
+	"github.com/chaisql/chai/internal/fulltext"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", []string{}},
+		{"Hello", []string{"hello"}},
+		{"Hello, World!", []string{"hello", "world"}},
+		{"  foo--bar_baz 42 ", []string{"foo", "bar", "baz", "42"}},
+		{"Crème Brûlée", []string{"crème", "brûlée"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			require.Equal(t, test.want, fulltext.Tokenize(test.text))
+		})
+	}
+}
+
+func TestContains(t *testing.T) {
+	doc := fulltext.Tokenize("the quick brown fox")
+
+	require.True(t, fulltext.Contains(doc, []string{"fox"}))
+	require.True(t, fulltext.Contains(doc, []string{"fox", "quick"}))
+	require.False(t, fulltext.Contains(doc, []string{"fox", "dog"}))
+	require.False(t, fulltext.Contains(doc, nil))
+}
+
+func TestBM25(t *testing.T) {
+	// a term appearing more often scores higher
+	require.Greater(t, fulltext.BM25(2, 1, 10, 10, 10), fulltext.BM25(1, 1, 10, 10, 10))
+	// a rare term scores higher than a common one
+	require.Greater(t, fulltext.BM25(1, 1, 10, 10, 10), fulltext.BM25(1, 9, 10, 10, 10))
+	// a short document scores higher than a long one
+	require.Greater(t, fulltext.BM25(1, 1, 5, 10, 10), fulltext.BM25(1, 1, 20, 10, 10))
+	// absent terms don't score
+	require.Zero(t, fulltext.BM25(0, 1, 10, 10, 10))
+}
+
+func TestAnalyzers(t *testing.T) {
+	tests := []struct {
+		analyzer string
+		text     string
+		want     []string
+	}{
+		{"", "The Quick fox", []string{"the", "quick", "fox"}},
+		{"unicode", "The Quick fox", []string{"the", "quick", "fox"}},
+		{"english", "The foxes are jumping", []string{"fox", "jump"}},
+		{"english", "hoping hopped agreed happy", []string{"hope", "hop", "agre", "happi"}},
+		{"english", "Crème Brûlée", []string{"crème", "brûlée"}},
+		{"trigram", "Fox dogs", []string{"fox", "dog", "ogs"}},
+		{"trigram", "a", []string{"a"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.analyzer+"/"+test.text, func(t *testing.T) {
+			a, err := fulltext.Lookup(test.analyzer)
+			require.NoError(t, err)
+			require.Equal(t, test.want, a.Analyze(test.text))
+		})
+	}
+
+	_, err := fulltext.Lookup("klingon")
+	require.Error(t, err)
+}
+
+func TestSubstringTrigrams(t *testing.T) {
+	tests := []struct {
+		s    string
+		want []string
+	}{
+		{"", nil},
+		{"ab", nil},
+		{"Quick", []string{"qui", "uic", "ick"}},
+		{"k fox", []string{"fox"}},
+		{"fox-fox", []string{"fox"}},
+		{"foxes", []string{"fox", "oxe"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			got := fulltext.SubstringTrigrams(test.s)
+			if len(test.want) == 0 {
+				require.Empty(t, got)
+				return
+			}
+			require.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	fulltext.Register("bigrams", fulltext.NewAnalyzer(fulltext.Tokenize, fulltext.StopWords("b"), fulltext.NGrams(1, 2)))
+
+	a, err := fulltext.Lookup("bigrams")
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "c", "ac"}, a.Analyze("b AC"))
+}
@@ -0,0 +1,97 @@
+package geo
+
+import "math"
+
+// Spatial indexes divide the plane into grids of square cells.
+// Each level uses cells 8 times smaller than the previous one,
+// from 4096 units at level 0 to about 4e-6 units at the last level.
+// A point is stored in the cell containing it at every level, and
+// a box is looked up at the finest level where it spans a few cells only.
+const (
+	// NumLevels is the number of grids.
+	NumLevels = 11
+
+	levelRatio = 8
+	// size of the cells of level 0, as a power of levelRatio
+	topLevelExp = 4
+	// maximum number of cells looked up for a box
+	maxCoveringCells = 16
+)
+
+// A Cell is a square of the grid of a level, identified by its coordinates
+// on the grid.
+type Cell struct {
+	Level int
+	X, Y  int64
+}
+
+// CellSize returns the width of the cells of the given level.
+func CellSize(level int) float64 {
+	return math.Pow(levelRatio, float64(topLevelExp-level))
+}
+
+// PointCells returns the cells containing the point, one per level.
+func PointCells(p Point) []Cell {
+	cells := make([]Cell, NumLevels)
+	for l := range cells {
+		size := CellSize(l)
+		cells[l] = Cell{Level: l, X: gridCoord(p.X, size), Y: gridCoord(p.Y, size)}
+	}
+
+	return cells
+}
+
+// Covering returns the cells of the finest level covering the box,
+// so that any point inside the box is stored in one of them.
+// It returns false if the box is too large to be covered by a few cells.
+func Covering(b Box) ([]Cell, bool) {
+	if b.MinX > b.MaxX || b.MinY > b.MaxY {
+		return nil, true
+	}
+
+	for l := NumLevels - 1; l >= 0; l-- {
+		size := CellSize(l)
+
+		w := math.Floor(b.MaxX/size) - math.Floor(b.MinX/size) + 1
+		h := math.Floor(b.MaxY/size) - math.Floor(b.MinY/size) + 1
+		if w*h > maxCoveringCells {
+			continue
+		}
+
+		minX, maxX := gridCoord(b.MinX, size), gridCoord(b.MaxX, size)
+		minY, maxY := gridCoord(b.MinY, size), gridCoord(b.MaxY, size)
+
+		// the loops stop on equality to avoid overflowing
+		// on clamped coordinates
+		var cells []Cell
+		for x := minX; ; x++ {
+			for y := minY; ; y++ {
+				cells = append(cells, Cell{Level: l, X: x, Y: y})
+				if y == maxY {
+					break
+				}
+			}
+			if x == maxX {
+				break
+			}
+		}
+
+		return cells, true
+	}
+
+	return nil, false
+}
+
+// gridCoord returns the coordinate of the cell containing c,
+// clamped to the range of int64.
+func gridCoord(c, size float64) int64 {
+	f := math.Floor(c / size)
+	switch {
+	case f >= math.MaxInt64:
+		return math.MaxInt64
+	case f <= math.MinInt64:
+		return math.MinInt64
+	}
+
+	return int64(f)
+}
@@ -0,0 +1,195 @@
+// Package geo provides the geometries used by the spatial functions
+// and spatial indexes.
+//
+// Geometries are stored in TEXT values, using their well-known text
+// representation:
+//
+//	POINT(x y)
+//	POLYGON((x1 y1, x2 y2, x3 y3, x1 y1))
+//
+// Coordinates are planar: distances are expressed in the unit of the coordinates.
+package geo
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+)
+
+// A Geometry is either a Point or a Polygon.
+type Geometry interface {
+	// BBox returns the smallest box containing the geometry.
+	BBox() Box
+	// String returns the well-known text representation of the geometry.
+	String() string
+}
+
+// A Point is a location on the plane.
+type Point struct {
+	X, Y float64
+}
+
+func (p Point) BBox() Box {
+	return Box{MinX: p.X, MinY: p.Y, MaxX: p.X, MaxY: p.Y}
+}
+
+func (p Point) String() string {
+	return "POINT(" + formatCoords(p) + ")"
+}
+
+// A Polygon is a closed ring of points: its first and last points are equal.
+type Polygon []Point
+
+func (p Polygon) BBox() Box {
+	b := p[0].BBox()
+	for _, pt := range p[1:] {
+		b.MinX, b.MaxX = math.Min(b.MinX, pt.X), math.Max(b.MaxX, pt.X)
+		b.MinY, b.MaxY = math.Min(b.MinY, pt.Y), math.Max(b.MaxY, pt.Y)
+	}
+
+	return b
+}
+
+func (p Polygon) String() string {
+	var s strings.Builder
+
+	s.WriteString("POLYGON((")
+	for i, pt := range p {
+		if i > 0 {
+			s.WriteString(", ")
+		}
+		s.WriteString(formatCoords(pt))
+	}
+	s.WriteString("))")
+
+	return s.String()
+}
+
+// Contains returns whether the point is inside the polygon or on its boundary.
+func (p Polygon) Contains(pt Point) bool {
+	var inside bool
+
+	for i := 1; i < len(p); i++ {
+		a, b := p[i-1], p[i]
+
+		if onSegment(a, b, pt) {
+			return true
+		}
+
+		// count the edges crossed by a ray going from the point to the right
+		if (a.Y > pt.Y) != (b.Y > pt.Y) && pt.X < (b.X-a.X)*(pt.Y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
+
+func onSegment(a, b, pt Point) bool {
+	if (b.X-a.X)*(pt.Y-a.Y) != (pt.X-a.X)*(b.Y-a.Y) {
+		return false
+	}
+
+	return pt.X >= math.Min(a.X, b.X) && pt.X <= math.Max(a.X, b.X) &&
+		pt.Y >= math.Min(a.Y, b.Y) && pt.Y <= math.Max(a.Y, b.Y)
+}
+
+// Distance returns the euclidean distance between two points.
+func Distance(a, b Point) float64 {
+	return math.Hypot(a.X-b.X, a.Y-b.Y)
+}
+
+// A Box is an axis-aligned rectangle.
+type Box struct {
+	MinX, MinY, MaxX, MaxY float64
+}
+
+// Expand returns the box grown by d in every direction.
+func (b Box) Expand(d float64) Box {
+	return Box{MinX: b.MinX - d, MinY: b.MinY - d, MaxX: b.MaxX + d, MaxY: b.MaxY + d}
+}
+
+// Contains returns whether the point is inside the box or on its boundary.
+func (b Box) Contains(p Point) bool {
+	return p.X >= b.MinX && p.X <= b.MaxX && p.Y >= b.MinY && p.Y <= b.MaxY
+}
+
+// Parse parses the well-known text representation of a geometry.
+// Keywords are case-insensitive.
+func Parse(s string) (Geometry, error) {
+	kind, body, ok := strings.Cut(strings.TrimSpace(s), "(")
+	if !ok || !strings.HasSuffix(body, ")") {
+		return nil, errors.Errorf("invalid geometry %q", s)
+	}
+	body = strings.TrimSuffix(body, ")")
+
+	switch strings.ToUpper(strings.TrimSpace(kind)) {
+	case "POINT":
+		p, err := parseCoords(body)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid geometry %q", s)
+		}
+		return p, nil
+	case "POLYGON":
+		p, err := parseRing(body)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid geometry %q", s)
+		}
+		return p, nil
+	}
+
+	return nil, errors.Errorf("invalid geometry %q: only POINT and POLYGON are supported", s)
+}
+
+// parseRing parses the ring of a polygon, i.e. "(x1 y1, x2 y2, ...)".
+// Polygons with holes are not supported.
+func parseRing(s string) (Polygon, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "(") || !strings.HasSuffix(s, ")") {
+		return nil, errors.New("expected a single ring of coordinates")
+	}
+
+	parts := strings.Split(s[1:len(s)-1], ",")
+	if len(parts) < 4 {
+		return nil, errors.New("a polygon must have at least 4 points")
+	}
+
+	p := make(Polygon, 0, len(parts))
+	for _, part := range parts {
+		pt, err := parseCoords(part)
+		if err != nil {
+			return nil, err
+		}
+		p = append(p, pt)
+	}
+
+	if p[0] != p[len(p)-1] {
+		return nil, errors.New("the first and last points of a polygon must be equal")
+	}
+
+	return p, nil
+}
+
+func parseCoords(s string) (Point, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return Point{}, errors.Errorf("expected 2 coordinates, got %q", strings.TrimSpace(s))
+	}
+
+	var coords [2]float64
+	for i, f := range fields {
+		c, err := strconv.ParseFloat(f, 64)
+		if err != nil || math.IsInf(c, 0) || math.IsNaN(c) {
+			return Point{}, errors.Errorf("invalid coordinate %q", f)
+		}
+		coords[i] = c
+	}
+
+	return Point{X: coords[0], Y: coords[1]}, nil
+}
+
+func formatCoords(p Point) string {
+	return strconv.FormatFloat(p.X, 'g', -1, 64) + " " + strconv.FormatFloat(p.Y, 'g', -1, 64)
+}
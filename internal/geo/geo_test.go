@@ -0,0 +1,98 @@
+package geo_test
+
+import (
+	"testing"
+
+	"github.com/chaisql/chai/internal/geo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text  string
+		want  geo.Geometry
+		fails bool
+	}{
+		{"POINT(1 2)", pt(1, 2), false},
+		{" point ( -1.5  2e3 ) ", pt(-1.5, 2000), false},
+		{"POLYGON((0 0, 1 0, 1 1, 0 0))", geo.Polygon{pt(0, 0), pt(1, 0), pt(1, 1), pt(0, 0)}, false},
+		{"POINT(1)", nil, true},
+		{"POINT(1 2 3)", nil, true},
+		{"POINT(a b)", nil, true},
+		{"POINT(inf 0)", nil, true},
+		{"POINT 1 2", nil, true},
+		{"LINESTRING(0 0, 1 1)", nil, true},
+		{"POLYGON((0 0, 1 0, 0 0))", nil, true},
+		{"POLYGON((0 0, 1 0, 1 1, 0 1))", nil, true},
+		{"POLYGON(0 0, 1 0, 1 1, 0 0)", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			g, err := geo.Parse(test.text)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.want, g)
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	require.Equal(t, "POINT(1.5 -2)", geo.Point{X: 1.5, Y: -2}.String())
+	require.Equal(t, "POLYGON((0 0, 1 0, 1 1, 0 0))", geo.Polygon{pt(0, 0), pt(1, 0), pt(1, 1), pt(0, 0)}.String())
+}
+
+func TestPolygonContains(t *testing.T) {
+	square := geo.Polygon{pt(0, 0), pt(10, 0), pt(10, 10), pt(0, 10), pt(0, 0)}
+
+	require.True(t, square.Contains(geo.Point{X: 5, Y: 5}))
+	require.True(t, square.Contains(geo.Point{X: 0, Y: 5}))
+	require.True(t, square.Contains(geo.Point{X: 10, Y: 10}))
+	require.False(t, square.Contains(geo.Point{X: 11, Y: 5}))
+	require.False(t, square.Contains(geo.Point{X: 5, Y: -1}))
+
+	triangle := geo.Polygon{pt(0, 0), pt(10, 0), pt(0, 10), pt(0, 0)}
+	require.True(t, triangle.Contains(geo.Point{X: 2, Y: 2}))
+	require.False(t, triangle.Contains(geo.Point{X: 8, Y: 8}))
+}
+
+func TestCovering(t *testing.T) {
+	// every point of the box must be in one of the covering cells
+	b := geo.Point{X: 12.5, Y: -3}.BBox().Expand(0.2)
+	cells, ok := geo.Covering(b)
+	require.True(t, ok)
+	require.NotEmpty(t, cells)
+	require.LessOrEqual(t, len(cells), 16)
+
+	for _, p := range []geo.Point{pt(12.5, -3), pt(12.3, -3.2), pt(12.7, -2.8), pt(12.3, -2.8)} {
+		var found bool
+		for _, c := range geo.PointCells(p) {
+			for _, cc := range cells {
+				found = found || c == cc
+			}
+		}
+		require.True(t, found, "%v", p)
+	}
+
+	// boxes too large for the coarsest grid
+	_, ok = geo.Covering(geo.Box{MinX: -1e6, MinY: -1e6, MaxX: 1e6, MaxY: 1e6})
+	require.False(t, ok)
+
+	// empty boxes don't contain any cell
+	cells, ok = geo.Covering(geo.Box{MinX: 1, MinY: 1, MaxX: 0, MaxY: 0})
+	require.True(t, ok)
+	require.Empty(t, cells)
+
+	// huge coordinates are clamped
+	cells, ok = geo.Covering(geo.Point{X: 1e300, Y: -1e300}.BBox())
+	require.True(t, ok)
+	require.Len(t, cells, 1)
+}
+
+func pt(x, y float64) geo.Point {
+	return geo.Point{X: x, Y: y}
+}
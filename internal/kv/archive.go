@@ -0,0 +1,272 @@
+package kv
+
+import (
+	"io"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/vfs"
+)
+
+// An ArchiveFile is a file of the database stored in an archive,
+// such as a tar backup, at the given offset.
+type ArchiveFile struct {
+	Archive io.ReaderAt
+	Offset  int64
+	Size    int64
+}
+
+// NewArchiveEngine opens the database whose files are stored in archives,
+// without extracting them. Files are named relative to the directory of the database.
+// The archives are only read: the files written by Pebble, and the files
+// replacing those of the archives, are kept in memory and lost when the engine is closed.
+// Large values can't be appended to the blob log.
+// The archives implementing io.Closer are closed with the engine.
+func NewArchiveEngine(files map[string]ArchiveFile, opts Options) (*PebbleEngine, error) {
+	fs := newArchiveFS(files)
+
+	popts := pebble.Options{
+		FS: fs,
+	}
+
+	ng, err := NewEngineWith("pebble", opts, &popts)
+	if err != nil {
+		return nil, err
+	}
+	ng.blobs = newBlobLog(blobDirName)
+	ng.blobs.fs = fs
+
+	seen := make(map[io.ReaderAt]bool)
+	for _, f := range files {
+		if c, ok := f.Archive.(io.Closer); ok && !seen[f.Archive] {
+			seen[f.Archive] = true
+			ng.archives = append(ng.archives, c)
+		}
+	}
+
+	return ng, nil
+}
+
+// archiveFS is a copy-on-write file system: files are read from archives
+// until they are removed or replaced, and written to memory.
+type archiveFS struct {
+	// stores the directories and the files written by Pebble.
+	vfs.FS
+
+	files map[string]ArchiveFile
+
+	mu sync.Mutex
+	// files of the archives that were removed or replaced.
+	deleted map[string]bool
+}
+
+func newArchiveFS(files map[string]ArchiveFile) *archiveFS {
+	fs := archiveFS{
+		FS:      vfs.NewMem(),
+		files:   make(map[string]ArchiveFile, len(files)),
+		deleted: make(map[string]bool),
+	}
+	for name, f := range files {
+		fs.files[path.Clean(name)] = f
+	}
+
+	return &fs
+}
+
+// archived returns the file of the archives stored at the given path,
+// unless it was removed or replaced.
+func (fs *archiveFS) archived(name string) (ArchiveFile, bool) {
+	name = path.Clean(name)
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if fs.deleted[name] {
+		return ArchiveFile{}, false
+	}
+	f, ok := fs.files[name]
+	return f, ok
+}
+
+// replace hides the file of the archives stored at the given path, if any.
+func (fs *archiveFS) replace(name string) bool {
+	name = path.Clean(name)
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if _, ok := fs.files[name]; !ok || fs.deleted[name] {
+		return false
+	}
+	fs.deleted[name] = true
+	return true
+}
+
+func (fs *archiveFS) Create(name string) (vfs.File, error) {
+	fs.replace(name)
+	return fs.FS.Create(name)
+}
+
+func (fs *archiveFS) Link(oldname, newname string) error {
+	if _, ok := fs.archived(oldname); ok {
+		return errors.Errorf("cannot link archived file %q", oldname)
+	}
+
+	fs.replace(newname)
+	return fs.FS.Link(oldname, newname)
+}
+
+func (fs *archiveFS) Open(name string, opts ...vfs.OpenOption) (vfs.File, error) {
+	f, ok := fs.archived(name)
+	if !ok {
+		return fs.FS.Open(name, opts...)
+	}
+
+	return &archiveFile{
+		SectionReader: io.NewSectionReader(f.Archive, f.Offset, f.Size),
+		name:          path.Base(name),
+	}, nil
+}
+
+func (fs *archiveFS) OpenReadWrite(name string, opts ...vfs.OpenOption) (vfs.File, error) {
+	if _, ok := fs.archived(name); ok {
+		return nil, errors.Errorf("cannot write archived file %q", name)
+	}
+
+	return fs.FS.OpenReadWrite(name, opts...)
+}
+
+func (fs *archiveFS) Remove(name string) error {
+	if fs.replace(name) {
+		return nil
+	}
+
+	return fs.FS.Remove(name)
+}
+
+func (fs *archiveFS) RemoveAll(name string) error {
+	dir := path.Clean(name)
+
+	fs.mu.Lock()
+	for p := range fs.files {
+		if p == dir || path.Dir(p) == dir {
+			fs.deleted[p] = true
+		}
+	}
+	fs.mu.Unlock()
+
+	return fs.FS.RemoveAll(name)
+}
+
+func (fs *archiveFS) Rename(oldname, newname string) error {
+	if _, ok := fs.archived(oldname); ok {
+		return errors.Errorf("cannot rename archived file %q", oldname)
+	}
+
+	fs.replace(newname)
+	return fs.FS.Rename(oldname, newname)
+}
+
+func (fs *archiveFS) ReuseForWrite(oldname, newname string) (vfs.File, error) {
+	if fs.replace(oldname) {
+		return fs.Create(newname)
+	}
+
+	return fs.FS.ReuseForWrite(oldname, newname)
+}
+
+func (fs *archiveFS) List(dir string) ([]string, error) {
+	names, err := fs.FS.List(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	dir = path.Clean(dir)
+	fs.mu.Lock()
+	for p := range fs.files {
+		if path.Dir(p) == dir && !fs.deleted[p] {
+			names = append(names, path.Base(p))
+		}
+	}
+	fs.mu.Unlock()
+
+	if len(names) == 0 && err != nil {
+		return nil, err
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+func (fs *archiveFS) Stat(name string) (os.FileInfo, error) {
+	f, ok := fs.archived(name)
+	if !ok {
+		return fs.FS.Stat(name)
+	}
+
+	return archiveFileInfo{name: path.Base(name), size: f.Size}, nil
+}
+
+// archiveFile is a read-only file of an archive.
+type archiveFile struct {
+	*io.SectionReader
+
+	name string
+}
+
+func (f *archiveFile) Close() error {
+	return nil
+}
+
+func (f *archiveFile) Write(p []byte) (int, error) {
+	return 0, errors.Errorf("cannot write archived file %q", f.name)
+}
+
+func (f *archiveFile) WriteAt(p []byte, off int64) (int, error) {
+	return 0, errors.Errorf("cannot write archived file %q", f.name)
+}
+
+func (f *archiveFile) Preallocate(offset, length int64) error {
+	return nil
+}
+
+func (f *archiveFile) Stat() (os.FileInfo, error) {
+	return archiveFileInfo{name: f.name, size: f.Size()}, nil
+}
+
+func (f *archiveFile) Sync() error {
+	return nil
+}
+
+func (f *archiveFile) SyncTo(length int64) (bool, error) {
+	return true, nil
+}
+
+func (f *archiveFile) SyncData() error {
+	return nil
+}
+
+func (f *archiveFile) Prefetch(offset, length int64) error {
+	return nil
+}
+
+func (f *archiveFile) Fd() uintptr {
+	return vfs.InvalidFd
+}
+
+type archiveFileInfo struct {
+	name string
+	size int64
+}
+
+func (fi archiveFileInfo) Name() string       { return fi.name }
+func (fi archiveFileInfo) Size() int64        { return fi.size }
+func (fi archiveFileInfo) Mode() os.FileMode  { return 0400 }
+func (fi archiveFileInfo) ModTime() time.Time { return time.Time{} }
+func (fi archiveFileInfo) IsDir() bool        { return false }
+func (fi archiveFileInfo) Sys() any           { return nil }
@@ -1,12 +1,13 @@
 package kv
 
 import (
+	"github.com/chaisql/chai/internal/encoding"
 	"github.com/chaisql/chai/internal/engine"
 	"github.com/cockroachdb/errors"
 	"github.com/cockroachdb/pebble"
 )
 
-var _ engine.Session = (*BatchSession)(nil)
+var _ engine.StagedSession = (*BatchSession)(nil)
 
 var (
 	tombStone = []byte{0}
@@ -20,6 +21,11 @@ type BatchSession struct {
 	rollbackSegment *RollbackSegment
 	maxBatchSize    int
 	keys            map[string]struct{}
+	savepoints      []savepoint
+	// whether the commit waits for the WAL to be synced.
+	sync bool
+	// set once the batch is committed by Prepare.
+	prepared bool
 }
 
 func (s *PebbleEngine) NewBatchSession() engine.Session {
@@ -36,13 +42,29 @@ func (s *PebbleEngine) NewBatchSession() engine.Session {
 		rollbackSegment: s.rollbackSegment,
 		maxBatchSize:    s.opts.MaxBatchSize,
 		keys:            make(map[string]struct{}),
+		sync:            !s.relaxedCommits,
 	}
 }
 
 func (s *BatchSession) Commit() error {
+	err := s.Prepare()
+	if err != nil {
+		return err
+	}
+
+	return s.Publish()
+}
+
+// Prepare commits the batch. Until Publish is called, the new snapshot
+// sessions keep reading the shared snapshot created with the session,
+// which doesn't contain the changes.
+func (s *BatchSession) Prepare() error {
 	if s.closed {
 		return errors.New("already closed")
 	}
+	if s.prepared {
+		return errors.New("already prepared")
+	}
 
 	// We are about to commit the batch, we can empty
 	// the rollback segment.
@@ -51,11 +73,58 @@ func (s *BatchSession) Commit() error {
 		return err
 	}
 
-	err = s.Batch.Commit(nil)
+	// stamp the commit so that the WAL archive can be replayed
+	// from the last commit of a backup.
+	var ts int64
+	archive := s.Store.walArchive
+	if archive != nil {
+		ts = archive.nextCommitTimestamp()
+		err = s.Batch.Set(commitTimestampKey(s.Store.opts.CommitTimestampNamespace), encoding.EncodeInt(nil, ts), nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the blobs referenced by the batch must be durable before it is.
+	// Commits that are not synced rely on Sync to sync both.
+	if s.sync && s.Store.blobs != nil {
+		err = s.Store.blobs.sync()
+		if err != nil {
+			return err
+		}
+	}
+
+	wo := pebble.NoSync
+	if s.sync {
+		wo = pebble.Sync
+	}
+
+	err = s.Batch.Commit(wo)
 	if err != nil {
 		return err
 	}
 
+	// the batch is already committed: if it cannot be archived,
+	// the archive cannot be replayed beyond the previous commit.
+	if archive != nil {
+		err = archive.append(ts, s.Batch.Repr())
+		if err != nil {
+			_ = s.Close()
+			return err
+		}
+	}
+
+	s.prepared = true
+	return nil
+}
+
+// Publish releases the shared snapshot, so that the new snapshot sessions
+// read the changes of the prepared batch, and closes the session.
+func (s *BatchSession) Publish() error {
+	if !s.prepared {
+		return errors.New("cannot publish a batch that is not prepared")
+	}
+
 	return s.Close()
 }
 
@@ -98,7 +167,12 @@ func (s *BatchSession) applyBatch() error {
 		return nil
 	}
 
-	err := s.rollbackSegment.Apply(s.Batch)
+	err := s.captureSavepoints()
+	if err != nil {
+		return err
+	}
+
+	err = s.rollbackSegment.Apply(s.Batch)
 	if err != nil {
 		return err
 	}
@@ -110,6 +184,13 @@ func (s *BatchSession) applyBatch() error {
 		return err
 	}
 
+	if s.Store.walArchive != nil {
+		err = s.Store.walArchive.append(0, s.Batch.Repr())
+		if err != nil {
+			return err
+		}
+	}
+
 	// reset batch
 	s.Batch.Reset()
 	clear(s.keys)
@@ -233,3 +314,62 @@ func (s *BatchSession) Iterator(opts *engine.IterOptions) (engine.Iterator, erro
 		Iterator: it,
 	}, nil
 }
+
+// ApplyBatch writes all the operations of the batch to the pebble batch
+// of the session before checking its size, so that they are never split
+// between two intermediary commits.
+func (s *BatchSession) ApplyBatch(b *engine.Batch) error {
+	// deleting a range requires iterating over the keys
+	// written by the session so far.
+	if b.HasDeleteRange() {
+		err := s.applyBatch()
+		if err != nil {
+			return err
+		}
+	}
+
+	err := b.Iterate(func(k, v []byte) error {
+		s.keys[string(k)] = struct{}{}
+		return s.Batch.Set(k, v, nil)
+	}, func(k []byte) error {
+		delete(s.keys, string(k))
+		return s.Batch.Delete(k, nil)
+	}, s.deleteRangeInBatch)
+	if err != nil {
+		return err
+	}
+
+	return s.ensureBatchSize()
+}
+
+// deleteRangeInBatch deletes the keys of the range written to the store
+// and those written to the pebble batch, without applying the batch.
+func (s *BatchSession) deleteRangeInBatch(start, end []byte) error {
+	it, err := s.DB.NewIter(&pebble.IterOptions{
+		LowerBound: start,
+		UpperBound: end,
+	})
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+
+	for it.First(); it.Valid(); it.Next() {
+		err = s.Batch.Delete(it.Key(), nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	for k := range s.keys {
+		if encoding.Compare([]byte(k), start) >= 0 && encoding.Compare([]byte(k), end) < 0 {
+			delete(s.keys, k)
+			err = s.Batch.Delete([]byte(k), nil)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return it.Error()
+}
@@ -0,0 +1,640 @@
+package kv
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/pebble/vfs"
+)
+
+const (
+	blobSegmentSuffix  = ".blob"
+	blobSegmentMaxSize = 64 << 20 // 64MB
+
+	// name of the directory of the blob log, within the directory of the database.
+	blobDirName = "blobs"
+)
+
+// blobLog stores large values outside of the LSM, in append-only segment files.
+// Values are referenced by pointers, returned by append, which encode
+// the segment, offset, size and checksum of the value.
+// Segments are never rewritten: the segments whose values are no longer
+// referenced are deleted with remove, see Database.Compact.
+type blobLog struct {
+	mu sync.Mutex
+
+	dir string
+	// segment being appended to, zero until the first append,
+	// or the last removed segment.
+	seg  uint64
+	f    *os.File
+	size int64
+	// set if the current segment was written since the last sync.
+	dirty bool
+
+	// segments opened for reading.
+	readers map[uint64]blobSegment
+
+	// number of open snapshots of the engine.
+	snapshots int
+	// segments removed while snapshots were open. They are kept open,
+	// so that the snapshots can still read them, until the snapshots are closed.
+	removed []uint64
+
+	// file system storing the segments, nil if they are stored on disk.
+	// Values can't be appended to segments of other file systems.
+	fs vfs.FS
+}
+
+// blobSegment is a segment opened for reading.
+type blobSegment interface {
+	io.ReaderAt
+	io.Closer
+}
+
+func newBlobLog(dir string) *blobLog {
+	return &blobLog{
+		dir:     dir,
+		readers: make(map[uint64]blobSegment),
+	}
+}
+
+func blobSegmentPath(dir string, seg uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("%016d%s", seg, blobSegmentSuffix))
+}
+
+// lastSegment returns the number of the last segment of the directory, if any.
+func (l *blobLog) lastSegment() (uint64, error) {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	var last uint64
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), blobSegmentSuffix)
+		if !ok || e.IsDir() {
+			continue
+		}
+
+		var n uint64
+		_, err := fmt.Sscanf(name, "%d", &n)
+		if err == nil && n > last {
+			last = n
+		}
+	}
+
+	return last, nil
+}
+
+// rotate syncs and closes the current segment and creates the next one.
+// Existing segments are never appended to: their end may have been
+// partially written before a crash.
+func (l *blobLog) rotate() error {
+	if l.fs != nil {
+		return errors.New("cannot append to the blob log of an archived database")
+	}
+
+	if l.f != nil {
+		err := l.f.Sync()
+		if err != nil {
+			return err
+		}
+		err = l.f.Close()
+		if err != nil {
+			return err
+		}
+		l.f = nil
+	} else {
+		last, err := l.lastSegment()
+		if err != nil {
+			return err
+		}
+		// removed segments may still be read by snapshots:
+		// their numbers are not reused.
+		l.seg = max(l.seg, last)
+	}
+
+	err := os.MkdirAll(l.dir, 0700)
+	if err != nil {
+		return err
+	}
+
+	l.seg++
+	f, err := os.OpenFile(blobSegmentPath(l.dir, l.seg), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	l.f = f
+	l.size = 0
+	l.dirty = false
+	return nil
+}
+
+// append writes the value to the log and returns its pointer.
+// The value is not durable until sync is called.
+func (l *blobLog) append(v []byte) ([]byte, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.f == nil || l.size+int64(len(v)) > blobSegmentMaxSize {
+		err := l.rotate()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err := l.f.Write(v)
+	if err != nil {
+		return nil, err
+	}
+
+	ptr := blobPointer(l.seg, l.size, int64(len(v)), crc32.ChecksumIEEE(v))
+
+	l.size += int64(len(v))
+	l.dirty = true
+	return ptr, nil
+}
+
+// appendFrom writes the content of r to the log and returns its pointer and size,
+// without holding it in memory.
+// If r returns an error, the bytes written so far are left unreferenced.
+func (l *blobLog) appendFrom(r io.Reader) ([]byte, int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// the size is unknown: start a new segment if the current one is full
+	if l.f == nil || l.size >= blobSegmentMaxSize {
+		err := l.rotate()
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	h := crc32.NewIEEE()
+	n, err := io.Copy(io.MultiWriter(l.f, h), r)
+	if err != nil {
+		// the next values are appended after the partial value
+		l.size += n
+		l.dirty = true
+		return nil, 0, err
+	}
+
+	ptr := blobPointer(l.seg, l.size, n, h.Sum32())
+
+	l.size += n
+	l.dirty = true
+	return ptr, n, nil
+}
+
+func blobPointer(seg uint64, off, size int64, sum uint32) []byte {
+	ptr := binary.AppendUvarint(nil, seg)
+	ptr = binary.AppendUvarint(ptr, uint64(off))
+	ptr = binary.AppendUvarint(ptr, uint64(size))
+	return binary.BigEndian.AppendUint32(ptr, sum)
+}
+
+// sync makes the values appended so far durable.
+func (l *blobLog) sync() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.dirty {
+		return nil
+	}
+
+	err := l.f.Sync()
+	if err != nil {
+		return err
+	}
+
+	l.dirty = false
+	return nil
+}
+
+// parseBlobPointer returns the segment, offset, size and checksum of a value.
+func parseBlobPointer(ptr []byte) (seg, off, size uint64, sum uint32, err error) {
+	seg, n := binary.Uvarint(ptr)
+	off, m := binary.Uvarint(ptr[max(n, 0):])
+	size, o := binary.Uvarint(ptr[max(n+m, 0):])
+	if n <= 0 || m <= 0 || o <= 0 || len(ptr) != n+m+o+4 {
+		return 0, 0, 0, 0, errors.New("invalid blob pointer")
+	}
+
+	return seg, off, size, binary.BigEndian.Uint32(ptr[n+m+o:]), nil
+}
+
+// read returns the value referenced by the pointer.
+func (l *blobLog) read(ptr []byte) ([]byte, error) {
+	seg, off, size, sum, err := parseBlobPointer(ptr)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := l.reader(seg)
+	if err != nil {
+		return nil, err
+	}
+
+	v := make([]byte, size)
+	_, err = f.ReadAt(v, int64(off))
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.Errorf("blob at offset %d of segment %d is truncated", off, seg)
+		}
+		return nil, err
+	}
+	if crc32.ChecksumIEEE(v) != sum {
+		return nil, errors.Errorf("blob at offset %d of segment %d is corrupted: checksum mismatch", off, seg)
+	}
+
+	return v, nil
+}
+
+// open returns a reader of the value referenced by the pointer.
+// The checksum of the value is verified once it is read entirely.
+func (l *blobLog) open(ptr []byte) (io.Reader, error) {
+	seg, off, size, sum, err := parseBlobPointer(ptr)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := l.reader(seg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &blobReader{
+		r:   io.NewSectionReader(f, int64(off), int64(size)),
+		h:   crc32.NewIEEE(),
+		sum: sum,
+		seg: seg,
+		off: off,
+	}, nil
+}
+
+// blobReader reads a value of the log and verifies its checksum.
+type blobReader struct {
+	r   *io.SectionReader
+	h   hash.Hash32
+	sum uint32
+
+	// location of the value, for the errors
+	seg, off uint64
+	read     int64
+}
+
+func (b *blobReader) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	b.h.Write(p[:n])
+	b.read += int64(n)
+
+	if err == io.EOF {
+		if b.read != b.r.Size() {
+			return n, errors.Errorf("blob at offset %d of segment %d is truncated", b.off, b.seg)
+		}
+		if b.h.Sum32() != b.sum {
+			return n, errors.Errorf("blob at offset %d of segment %d is corrupted: checksum mismatch", b.off, b.seg)
+		}
+	}
+
+	return n, err
+}
+
+func (l *blobLog) reader(seg uint64) (blobSegment, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if f, ok := l.readers[seg]; ok {
+		return f, nil
+	}
+
+	var f blobSegment
+	var err error
+	if l.fs != nil {
+		f, err = l.fs.Open(blobSegmentPath(l.dir, seg))
+	} else {
+		f, err = os.Open(blobSegmentPath(l.dir, seg))
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot open blob segment %d", seg)
+	}
+
+	l.readers[seg] = f
+	return f, nil
+}
+
+// copyTo syncs the log and copies its segments to the given directory.
+// Values appended during the copy may or may not be part of it.
+func (l *blobLog) copyTo(dir string) error {
+	err := l.sync()
+	if err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), blobSegmentSuffix) {
+			continue
+		}
+
+		err = copyFile(filepath.Join(l.dir, e.Name()), filepath.Join(dir, e.Name()))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+	if err == nil {
+		err = out.Sync()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+// A BlobSegment is a file of the blob log.
+type BlobSegment struct {
+	ID   uint64
+	Size int64
+}
+
+// segments returns the segments of the log.
+func (l *blobLog) segments() ([]BlobSegment, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.segmentsLocked()
+}
+
+func (l *blobLog) segmentsLocked() ([]BlobSegment, error) {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var segs []BlobSegment
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), blobSegmentSuffix)
+		if !ok || e.IsDir() {
+			continue
+		}
+
+		var n uint64
+		_, err := fmt.Sscanf(name, "%d", &n)
+		if err != nil {
+			continue
+		}
+
+		fi, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		segs = append(segs, BlobSegment{ID: n, Size: fi.Size()})
+	}
+
+	return segs, nil
+}
+
+// seal closes the segment being appended to, if any, and returns
+// the segments of the log. The next values are appended to a new segment.
+func (l *blobLog) seal() ([]BlobSegment, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.f != nil {
+		err := l.f.Sync()
+		if err != nil {
+			return nil, err
+		}
+		err = l.f.Close()
+		if err != nil {
+			return nil, err
+		}
+		l.f = nil
+		l.dirty = false
+	}
+
+	return l.segmentsLocked()
+}
+
+// remove deletes sealed segments of the log.
+// The snapshots open when the segments are removed can still read them.
+func (l *blobLog) remove(segs []uint64) error {
+	if l.fs != nil {
+		return errors.New("cannot remove the blob segments of an archived database")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, seg := range segs {
+		if l.f != nil && seg >= l.seg {
+			return errors.Errorf("cannot remove blob segment %d: values are appended to it", seg)
+		}
+
+		path := blobSegmentPath(l.dir, seg)
+		f, opened := l.readers[seg]
+		switch {
+		case l.snapshots > 0 && !opened:
+			var err error
+			f, err = os.Open(path)
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return err
+			}
+			l.readers[seg] = f
+			fallthrough
+		case l.snapshots > 0:
+			l.removed = append(l.removed, seg)
+		case opened:
+			_ = f.Close()
+			delete(l.readers, seg)
+		}
+
+		err := os.Remove(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		l.seg = max(l.seg, seg)
+	}
+
+	return nil
+}
+
+// acquireSnapshot is called when a snapshot of the engine is created.
+func (l *blobLog) acquireSnapshot() {
+	l.mu.Lock()
+	l.snapshots++
+	l.mu.Unlock()
+}
+
+// releaseSnapshot is called when a snapshot of the engine is closed.
+// The removed segments are closed once no snapshot can read them.
+func (l *blobLog) releaseSnapshot() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.snapshots--
+	if l.snapshots > 0 {
+		return
+	}
+
+	for _, seg := range l.removed {
+		if f, ok := l.readers[seg]; ok {
+			_ = f.Close()
+			delete(l.readers, seg)
+		}
+	}
+	l.removed = nil
+}
+
+func (l *blobLog) close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var err error
+	if l.f != nil {
+		err = l.f.Sync()
+		if cerr := l.f.Close(); err == nil {
+			err = cerr
+		}
+		l.f = nil
+	}
+
+	for seg, f := range l.readers {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		delete(l.readers, seg)
+	}
+
+	return err
+}
+
+// AppendBlob writes a large value to the blob log of the engine
+// and returns a pointer to it, to be stored in place of the value.
+// The value is made durable by the commit of the next batch session.
+func (s *PebbleEngine) AppendBlob(v []byte) ([]byte, error) {
+	if s.blobs == nil {
+		return nil, errors.New("in-memory databases cannot store external blobs")
+	}
+
+	return s.blobs.append(v)
+}
+
+// AppendBlobFrom writes the content of r to the blob log of the engine,
+// like AppendBlob, without holding it in memory. It returns the pointer
+// and the size of the value.
+func (s *PebbleEngine) AppendBlobFrom(r io.Reader) ([]byte, int64, error) {
+	if s.blobs == nil {
+		return nil, 0, errors.New("in-memory databases cannot store external blobs")
+	}
+
+	return s.blobs.appendFrom(r)
+}
+
+// OpenBlob returns a reader of the value referenced by a pointer returned
+// by AppendBlob or AppendBlobFrom. The reader fails at the end of the value
+// if it is corrupted.
+func (s *PebbleEngine) OpenBlob(ptr []byte) (io.Reader, error) {
+	if s.blobs == nil {
+		return nil, errors.New("in-memory databases cannot store external blobs")
+	}
+
+	return s.blobs.open(ptr)
+}
+
+// ReadBlob returns the value referenced by a pointer returned by AppendBlob.
+func (s *PebbleEngine) ReadBlob(ptr []byte) ([]byte, error) {
+	if s.blobs == nil {
+		return nil, errors.New("in-memory databases cannot store external blobs")
+	}
+
+	return s.blobs.read(ptr)
+}
+
+// BlobSegments returns the segments of the blob log of the engine.
+func (s *PebbleEngine) BlobSegments() ([]BlobSegment, error) {
+	if s.blobs == nil {
+		return nil, nil
+	}
+
+	return s.blobs.segments()
+}
+
+// SealBlobs closes the segment of the blob log being appended to and
+// returns the segments of the log, which are no longer appended to.
+// The next values are appended to a new segment.
+func (s *PebbleEngine) SealBlobs() ([]BlobSegment, error) {
+	if s.blobs == nil {
+		return nil, nil
+	}
+
+	return s.blobs.seal()
+}
+
+// RemoveBlobSegments deletes segments of the blob log returned by SealBlobs.
+// The values they hold must no longer be referenced, except by the snapshots
+// open when they are removed, which can still read them.
+func (s *PebbleEngine) RemoveBlobSegments(segs []uint64) error {
+	if s.blobs == nil || len(segs) == 0 {
+		return nil
+	}
+
+	return s.blobs.remove(segs)
+}
+
+// BlobLocation returns the segment holding the value referenced
+// by a pointer returned by AppendBlob, and its size.
+func BlobLocation(ptr []byte) (seg uint64, size int64, err error) {
+	seg, _, n, _, err := parseBlobPointer(ptr)
+	return seg, int64(n), err
+}
@@ -0,0 +1,37 @@
+package kv
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/vfs"
+)
+
+// Checkpoint writes a consistent copy of the committed data of the engine
+// to the given directory, which can then be opened with NewEngine.
+// Files that don't change anymore, such as sstables, are hard linked when possible,
+// the segments of the blob log are copied.
+func (s *PebbleEngine) Checkpoint(dir string) error {
+	if s.fs != nil && s.fs != vfs.Default {
+		return errors.New("cannot checkpoint an in-memory database")
+	}
+
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Checkpoint(filepath.Join(dir, "pebble"), pebble.WithFlushedWAL())
+	if err != nil {
+		return err
+	}
+
+	// the blobs referenced by the checkpoint were appended before it
+	if s.blobs != nil {
+		return s.blobs.copyTo(filepath.Join(dir, blobDirName))
+	}
+
+	return nil
+}
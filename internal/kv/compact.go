@@ -0,0 +1,63 @@
+package kv
+
+import (
+	"math"
+
+	"github.com/cockroachdb/pebble"
+
+	"github.com/chaisql/chai/internal/encoding"
+)
+
+// CompactionStats describes the disk space used by the engine
+// before and after a compaction.
+type CompactionStats struct {
+	// Size of the files of the engine, in bytes.
+	SizeBefore uint64
+	SizeAfter  uint64
+}
+
+// Reclaimed returns the number of bytes freed by the compaction.
+func (s CompactionStats) Reclaimed() uint64 {
+	if s.SizeAfter >= s.SizeBefore {
+		return 0
+	}
+
+	return s.SizeBefore - s.SizeAfter
+}
+
+// Compact flushes the memtable and compacts the whole key space
+// down to the last level, dropping the deleted and overwritten keys
+// and the tombstones of the deleted ranges.
+// Reads and writes can continue during the compaction.
+func (s *PebbleEngine) Compact() (CompactionStats, error) {
+	var stats CompactionStats
+	stats.SizeBefore = liveSize(s.db.Metrics())
+
+	err := s.db.Flush()
+	if err != nil {
+		return stats, err
+	}
+
+	// every key starts with its namespace, which is positive
+	start := encoding.EncodeInt(nil, 0)
+	end := encoding.EncodeInt(nil, math.MaxInt64)
+	err = s.db.Compact(start, end, true)
+	if err != nil {
+		return stats, err
+	}
+
+	stats.SizeAfter = liveSize(s.db.Metrics())
+	return stats, nil
+}
+
+// liveSize returns the size of the WAL and of the tables currently in use.
+// The files made obsolete by a compaction are deleted in the background,
+// they are not included.
+func liveSize(m *pebble.Metrics) uint64 {
+	size := m.WAL.PhysicalSize
+	for _, l := range m.Levels {
+		size += uint64(l.Size)
+	}
+
+	return size
+}
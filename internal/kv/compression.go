@@ -0,0 +1,73 @@
+package kv
+
+import (
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/pebble"
+)
+
+// Compression algorithms of the blocks written to disk.
+const (
+	CompressionNone   = "none"
+	CompressionSnappy = "snappy"
+	CompressionZstd   = "zstd"
+)
+
+// pebbleCompression returns the Pebble compression matching the given name.
+// An empty name selects the default compression of Pebble.
+func pebbleCompression(name string) (pebble.Compression, error) {
+	switch name {
+	case "":
+		return pebble.DefaultCompression, nil
+	case CompressionNone:
+		return pebble.NoCompression, nil
+	case CompressionSnappy:
+		return pebble.SnappyCompression, nil
+	case CompressionZstd:
+		return pebble.ZstdCompression, nil
+	}
+
+	return 0, errors.Errorf("unknown compression %q", name)
+}
+
+// CompressionStats describes how much the data written to disk was compressed.
+type CompressionStats struct {
+	// Number of sstables on disk.
+	Tables int
+	// Size of the keys and values stored in the sstables, before compression.
+	RawSize uint64
+	// Size of the sstables on disk.
+	DiskSize uint64
+}
+
+// Ratio returns the raw size of the data divided by its size on disk,
+// or zero if nothing was written to disk yet.
+func (c CompressionStats) Ratio() float64 {
+	if c.DiskSize == 0 {
+		return 0
+	}
+
+	return float64(c.RawSize) / float64(c.DiskSize)
+}
+
+// CompressionStats returns the compression achieved by the sstables of the engine.
+// Data that hasn't been flushed from the memtables yet is not included.
+func (s *PebbleEngine) CompressionStats() (CompressionStats, error) {
+	var stats CompressionStats
+
+	levels, err := s.db.SSTables(pebble.WithProperties())
+	if err != nil {
+		return stats, err
+	}
+
+	for _, tables := range levels {
+		for _, t := range tables {
+			stats.Tables++
+			stats.DiskSize += t.Size
+			if t.Properties != nil {
+				stats.RawSize += t.Properties.RawKeySize + t.Properties.RawValueSize
+			}
+		}
+	}
+
+	return stats, nil
+}
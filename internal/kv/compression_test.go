@@ -0,0 +1,51 @@
+package kv_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompression(t *testing.T) {
+	ratio := func(t *testing.T, c chai.Compression) float64 {
+		t.Helper()
+
+		db, err := chai.OpenWith(filepath.Join(t.TempDir(), "db"), &chai.Options{Compression: c})
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec("CREATE TABLE test(a INTEGER PRIMARY KEY, b TEXT)")
+		require.NoError(t, err)
+		for i := 0; i < 1000; i++ {
+			err = db.Exec("INSERT INTO test (a, b) VALUES (?, 'aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa')", i)
+			require.NoError(t, err)
+		}
+
+		// nothing is reported until the data is flushed to disk
+		err = db.DB.Engine.(*kv.PebbleEngine).DB().Flush()
+		require.NoError(t, err)
+
+		stats, err := db.CompressionStats()
+		require.NoError(t, err)
+		require.NotZero(t, stats.Tables)
+		require.NotZero(t, stats.RawSize)
+		return stats.Ratio()
+	}
+
+	require.Less(t, ratio(t, chai.CompressionNone), 1.0)
+	require.Greater(t, ratio(t, chai.CompressionSnappy), 1.0)
+	require.Greater(t, ratio(t, chai.CompressionZstd), 1.0)
+	require.Greater(t, ratio(t, ""), 1.0)
+
+	_, err := chai.OpenWith(filepath.Join(t.TempDir(), "db"), &chai.Options{Compression: "lz4"})
+	require.Error(t, err)
+
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.CompressionStats()
+	require.Error(t, err)
+}
@@ -0,0 +1,95 @@
+package kv
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/pebble"
+)
+
+// Durability levels of the commits of the batch sessions.
+const (
+	// Commits return once the WAL is synced to disk.
+	DurabilityAlways = "always"
+	// Commits return once written to the WAL,
+	// which is synced to disk every SyncInterval.
+	DurabilityPeriodic = "periodic"
+	// Commits return once written to the WAL, which is only synced
+	// when the engine is closed: a crash of the machine can lose them.
+	DurabilityNever = "never"
+)
+
+const defaultSyncInterval = 100 * time.Millisecond
+
+// syncCommits returns whether the commits must be synced
+// with the given durability, and validates it.
+// An empty durability is DurabilityAlways.
+func syncCommits(durability string) (bool, error) {
+	switch durability {
+	case "", DurabilityAlways:
+		return true, nil
+	case DurabilityPeriodic, DurabilityNever:
+		return false, nil
+	}
+
+	return false, errors.Errorf("unknown durability %q", durability)
+}
+
+// syncer syncs the WAL of the engine and the blob log
+// at regular intervals, until stopped.
+type syncer struct {
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func (s *PebbleEngine) startSyncer(interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultSyncInterval
+	}
+
+	s.syncer = &syncer{stop: make(chan struct{})}
+	s.syncer.wg.Add(1)
+	go func() {
+		defer s.syncer.wg.Done()
+
+		t := time.NewTicker(interval)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-s.syncer.stop:
+				return
+			case <-t.C:
+				// errors are reported by the next synced commit, if any
+				_ = s.Sync()
+			}
+		}
+	}()
+}
+
+func (s *syncer) close() {
+	close(s.stop)
+	s.wg.Wait()
+}
+
+// Sync makes the commits written so far durable,
+// whatever the durability of the engine and of their session.
+func (s *PebbleEngine) Sync() error {
+	if s.blobs != nil {
+		err := s.blobs.sync()
+		if err != nil {
+			return err
+		}
+	}
+
+	// an empty batch is not written to the WAL,
+	// log an empty record to force the sync.
+	return s.db.LogData(nil, pebble.Sync)
+}
+
+// SetSync overrides the durability of the engine for the commit of the session.
+// If sync is false, the commit returns without waiting for the WAL to be synced.
+func (s *BatchSession) SetSync(sync bool) {
+	s.sync = sync
+}
@@ -0,0 +1,42 @@
+package kv_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chaisql/chai"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDurability(t *testing.T) {
+	for _, d := range []chai.Durability{chai.DurabilityAlways, chai.DurabilityPeriodic, chai.DurabilityNever} {
+		t.Run(string(d), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "db")
+			db, err := chai.OpenWith(path, &chai.Options{Durability: d, SyncInterval: 10 * time.Millisecond})
+			require.NoError(t, err)
+
+			err = db.Exec("CREATE TABLE test(a INTEGER PRIMARY KEY)")
+			require.NoError(t, err)
+			err = db.Exec("BEGIN WITH DURABILITY RELAXED; INSERT INTO test (a) VALUES (1); COMMIT")
+			require.NoError(t, err)
+			err = db.Exec("BEGIN WITH DURABILITY FULL; INSERT INTO test (a) VALUES (2); COMMIT")
+			require.NoError(t, err)
+			require.NoError(t, db.Close())
+
+			// closing the database syncs the relaxed commits
+			db, err = chai.Open(path)
+			require.NoError(t, err)
+			defer db.Close()
+
+			var n int
+			r, err := db.QueryRow("SELECT COUNT(*) FROM test")
+			require.NoError(t, err)
+			require.NoError(t, r.Scan(&n))
+			require.Equal(t, 2, n)
+		})
+	}
+
+	_, err := chai.OpenWith(filepath.Join(t.TempDir(), "db"), &chai.Options{Durability: "sometimes"})
+	require.Error(t, err)
+}
@@ -1,14 +1,18 @@
 package kv
 
 import (
+	"context"
+	"io"
 	"math"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/chaisql/chai/internal/encoding"
 	"github.com/chaisql/chai/internal/pkg/atomic"
+	"github.com/chaisql/chai/internal/pkg/filelock"
 	"github.com/chaisql/chai/internal/pkg/pebbleutil"
 	"github.com/cockroachdb/errors"
 	"github.com/cockroachdb/pebble"
@@ -20,6 +24,11 @@ const (
 	defaultMaxTransientBatchSize int = 1 << 19          // 512KB
 )
 
+// LockFileName is the name of the file locked by the process
+// that opened the database, within the directory of the database.
+// It contains the PID of that process.
+const LockFileName = "chai.lock"
+
 type PebbleEngine struct {
 	db              *pebble.DB
 	opts            Options
@@ -37,6 +46,29 @@ type PebbleEngine struct {
 
 	minTransientNamespace uint64
 	maxTransientNamespace uint64
+
+	// file system storing the files of the database,
+	// nil if they are stored on disk.
+	fs vfs.FS
+
+	// archive of the written batches, if Options.WALArchiveDir is set.
+	walArchive *walArchive
+
+	// lock of the database directory, held until the engine is closed.
+	lock *filelock.Lock
+
+	// log of the large values stored outside of the LSM,
+	// nil for in-memory engines.
+	blobs *blobLog
+
+	// archives storing the files of the database,
+	// closed with the engine. See NewArchiveEngine.
+	archives []io.Closer
+
+	// set if commits don't wait for the WAL to be synced by default.
+	relaxedCommits bool
+	// syncs the WAL periodically, if the durability is DurabilityPeriodic.
+	syncer *syncer
 }
 
 type Options struct {
@@ -45,6 +77,55 @@ type Options struct {
 	MaxTransientBatchSize    int
 	MinTransientNamespace    uint64
 	MaxTransientNamespace    uint64
+
+	// Compression of the blocks written to disk:
+	// CompressionNone, CompressionSnappy or CompressionZstd.
+	// If empty, the default compression of Pebble is used.
+	Compression string
+
+	// If set, every batch written to the engine is also appended
+	// to the segments of a WAL archive stored in this directory,
+	// which ReplayWALArchive applies to a backup of the database.
+	// Segments are never deleted by the engine.
+	WALArchiveDir string
+	// Namespace of the key storing the time of the last commit
+	// written to the WAL archive.
+	CommitTimestampNamespace int64
+
+	// Namespace of the key storing the changes of the transaction
+	// prepared by BatchSession.PrepareTwoPhase.
+	// If zero, transactions can't be prepared.
+	PreparedTxNamespace int64
+
+	// Maximum duration NewEngine waits for another process
+	// to release the lock of the database directory.
+	// If zero, NewEngine fails immediately if the directory is locked.
+	LockTimeout time.Duration
+	// If set, the wait for the lock stops when the context is done.
+	LockContext context.Context
+
+	// Durability of the commits of the batch sessions:
+	// DurabilityAlways, DurabilityPeriodic or DurabilityNever.
+	// If empty, DurabilityAlways is used.
+	Durability string
+	// Interval between two syncs of the WAL with DurabilityPeriodic.
+	// If zero, the WAL is synced every 100ms.
+	SyncInterval time.Duration
+
+	// Tuning of Pebble used by NewEngine.
+	// Zero values select the defaults of Pebble.
+
+	// Size of the block cache, in bytes.
+	CacheSize int64
+	// Size of a memtable, in bytes, between 1MB and 4GB.
+	MemTableSize int64
+	// Maximum number of files kept open.
+	MaxOpenFiles int
+	// Number of L0 files triggering a compaction.
+	L0CompactionThreshold int
+	// Number of L0 files at which writes are stopped until
+	// compactions catch up. It can't be lower than L0CompactionThreshold.
+	L0StopWritesThreshold int
 }
 
 func NewEngineWith(path string, opts Options, popts *pebble.Options) (*PebbleEngine, error) {
@@ -58,19 +139,61 @@ func NewEngineWith(path string, opts Options, popts *pebble.Options) (*PebbleEng
 		popts.Logger = pebbleutil.NoopLoggerAndTracer{}
 	}
 
+	// EnsureDefaults wraps the default file system,
+	// keep the one chosen by the caller.
+	fs := popts.FS
 	popts = popts.EnsureDefaults()
 
+	syncs, err := syncCommits(opts.Durability)
+	if err != nil {
+		return nil, err
+	}
+
 	db, err := pebble.Open(path, popts)
 	if err != nil {
 		return nil, err
 	}
 
-	return NewStore(db, opts), nil
+	ng := NewStore(db, opts)
+	ng.fs = fs
+	ng.relaxedCommits = !syncs
+
+	if opts.WALArchiveDir != "" {
+		last, err := lastCommitTimestamp(db, opts.CommitTimestampNamespace)
+		if err != nil {
+			_ = db.Close()
+			return nil, err
+		}
+
+		ng.walArchive, err = openWALArchive(opts.WALArchiveDir, last)
+		if err != nil {
+			_ = db.Close()
+			return nil, err
+		}
+		ng.rollbackSegment.walArchive = ng.walArchive
+	}
+
+	if opts.Durability == DurabilityPeriodic {
+		ng.startSyncer(opts.SyncInterval)
+	}
+
+	return ng, nil
 }
 
 func NewEngine(path string, opts Options) (*PebbleEngine, error) {
 	var popts pebble.Options
 	var pbpath string
+	var lock *filelock.Lock
+
+	compression, err := pebbleCompression(opts.Compression)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateTuning(&opts)
+	if err != nil {
+		return nil, err
+	}
 
 	if path == ":memory:" {
 		popts.FS = vfs.NewMem()
@@ -97,12 +220,46 @@ func NewEngine(path string, opts Options) (*PebbleEngine, error) {
 			}
 		}
 
+		lockCtx := opts.LockContext
+		if lockCtx == nil {
+			lockCtx = context.Background()
+		}
+		lock, err = filelock.LockWait(lockCtx, filepath.Join(path, LockFileName), opts.LockTimeout)
+		if err != nil {
+			if errors.Is(err, filelock.ErrLocked) {
+				return nil, errors.Wrapf(err, "database %q is already open", path)
+			}
+			return nil, err
+		}
+
 		pbpath = filepath.Join(path, "pebble")
 	}
 
 	popts.FormatMajorVersion = pebble.FormatVirtualSSTables
 
-	return NewEngineWith(pbpath, opts, &popts)
+	// the options of the first level are reused by the others,
+	// so that every level uses the same compression.
+	popts.Levels = []pebble.LevelOptions{{Compression: compression}}
+
+	applyTuning(&opts, &popts)
+	if popts.Cache != nil {
+		// the database holds its own reference
+		defer popts.Cache.Unref()
+	}
+
+	ng, err := NewEngineWith(pbpath, opts, &popts)
+	if err != nil {
+		if lock != nil {
+			_ = lock.Unlock()
+		}
+		return nil, err
+	}
+	ng.lock = lock
+	if pbpath != "" {
+		ng.blobs = newBlobLog(filepath.Join(path, blobDirName))
+	}
+
+	return ng, nil
 }
 
 // DefaultComparer is the default implementation of the Comparer interface for chai.
@@ -140,7 +297,47 @@ func NewStore(db *pebble.DB, opts Options) *PebbleEngine {
 }
 
 func (s *PebbleEngine) Close() error {
-	return s.db.Close()
+	if s.lock != nil {
+		defer s.lock.Unlock()
+	}
+
+	if s.syncer != nil {
+		s.syncer.close()
+	}
+
+	// make the commits that were not synced durable
+	if s.relaxedCommits {
+		err := s.Sync()
+		if err != nil {
+			_ = s.db.Close()
+			return err
+		}
+	}
+
+	if s.blobs != nil {
+		err := s.blobs.close()
+		if err != nil {
+			_ = s.db.Close()
+			return err
+		}
+	}
+
+	if s.walArchive != nil {
+		err := s.walArchive.Close()
+		if err != nil {
+			_ = s.db.Close()
+			return err
+		}
+	}
+
+	err := s.db.Close()
+	for _, a := range s.archives {
+		if cerr := a.Close(); err == nil {
+			err = cerr
+		}
+	}
+
+	return err
 }
 
 func (s *PebbleEngine) Rollback() error {
@@ -153,12 +350,22 @@ func (s *PebbleEngine) Recover() error {
 
 func (s *PebbleEngine) LockSharedSnapshot() {
 	s.sharedSnapshot.Lock()
-	s.sharedSnapshot.snapshot = &snapshot{
+	s.sharedSnapshot.snapshot = s.newSnapshot()
+	s.sharedSnapshot.snapshot.Incr()
+	s.sharedSnapshot.Unlock()
+}
+
+// newSnapshot creates a snapshot of the engine, with no reference.
+func (s *PebbleEngine) newSnapshot() *snapshot {
+	if s.blobs != nil {
+		s.blobs.acquireSnapshot()
+	}
+
+	return &snapshot{
 		snapshot: s.db.NewSnapshot(),
 		refCount: atomic.NewCounter(0, math.MaxInt64, false),
+		blobs:    s.blobs,
 	}
-	s.sharedSnapshot.snapshot.Incr()
-	s.sharedSnapshot.Unlock()
 }
 
 func (s *PebbleEngine) UnlockSharedSnapshot() {
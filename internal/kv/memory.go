@@ -0,0 +1,454 @@
+package kv
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"sync"
+
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/cockroachdb/errors"
+)
+
+var _ engine.Engine = (*MemoryEngine)(nil)
+
+// MemoryEngine is an engine storing all of its data in memory,
+// in an immutable tree. Read sessions keep reading the tree
+// as it was when they started, while the batch session writes
+// to its own copy of the tree, published when it is committed.
+// As writes are never visible before being committed, the engine
+// doesn't need a rollback segment.
+type MemoryEngine struct {
+	mu     sync.RWMutex
+	root   *memNode
+	closed bool
+}
+
+// NewMemoryEngine creates an empty in-memory engine.
+func NewMemoryEngine() *MemoryEngine {
+	return &MemoryEngine{}
+}
+
+func (m *MemoryEngine) load() *memNode {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.root
+}
+
+func (m *MemoryEngine) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return errors.New("already closed")
+	}
+	m.closed = true
+	m.root = nil
+	return nil
+}
+
+// Rollback does nothing, uncommitted writes are never visible.
+func (m *MemoryEngine) Rollback() error {
+	return nil
+}
+
+// Recover does nothing, the engine starts empty.
+func (m *MemoryEngine) Recover() error {
+	return nil
+}
+
+// LockSharedSnapshot does nothing, every read session
+// reads the last committed tree.
+func (m *MemoryEngine) LockSharedSnapshot() {}
+
+// UnlockSharedSnapshot does nothing.
+func (m *MemoryEngine) UnlockSharedSnapshot() {}
+
+// CleanupTransientNamespaces does nothing, transient sessions
+// are never committed.
+func (m *MemoryEngine) CleanupTransientNamespaces() error {
+	return nil
+}
+
+func (m *MemoryEngine) NewSnapshotSession() engine.Session {
+	return &memSnapshotSession{root: m.load()}
+}
+
+func (m *MemoryEngine) NewBatchSession() engine.Session {
+	return &memBatchSession{engine: m, root: m.load()}
+}
+
+func (m *MemoryEngine) NewTransientSession() engine.Session {
+	return &memTransientSession{}
+}
+
+// memorySnapshotMagic starts the files written by SaveSnapshot.
+var memorySnapshotMagic = []byte("chaimem1")
+
+// SaveSnapshot writes the committed data of the engine to w.
+// Writes committed while the snapshot is written are not included.
+func (m *MemoryEngine) SaveSnapshot(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	_, err := bw.Write(memorySnapshotMagic)
+	if err != nil {
+		return err
+	}
+
+	var buf [binary.MaxVarintLen64]byte
+	writeBytes := func(b []byte) error {
+		n := binary.PutUvarint(buf[:], uint64(len(b)))
+		_, err := bw.Write(buf[:n])
+		if err != nil {
+			return err
+		}
+
+		_, err = bw.Write(b)
+		return err
+	}
+
+	err = memWalk(m.load(), func(n *memNode) error {
+		err := writeBytes(n.key)
+		if err != nil {
+			return err
+		}
+
+		return writeBytes(n.value)
+	})
+	if err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+// LoadSnapshot replaces the data of the engine with the snapshot read from r.
+// It must not be called while sessions are open.
+func (m *MemoryEngine) LoadSnapshot(r io.Reader) error {
+	br := bufio.NewReader(r)
+
+	magic := make([]byte, len(memorySnapshotMagic))
+	_, err := io.ReadFull(br, magic)
+	if err != nil || !bytes.Equal(magic, memorySnapshotMagic) {
+		return errors.New("invalid snapshot")
+	}
+
+	readBytes := func() ([]byte, error) {
+		n, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, err
+		}
+
+		b := make([]byte, n)
+		_, err = io.ReadFull(br, b)
+		return b, err
+	}
+
+	var root *memNode
+	for {
+		k, err := readBytes()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return errors.Wrap(err, "invalid snapshot")
+		}
+
+		v, err := readBytes()
+		if err != nil {
+			return errors.Wrap(err, "invalid snapshot")
+		}
+
+		root = memPut(root, k, v)
+	}
+
+	m.mu.Lock()
+	m.root = root
+	m.mu.Unlock()
+
+	return nil
+}
+
+// memSnapshotSession reads the tree committed when it was created.
+type memSnapshotSession struct {
+	root   *memNode
+	closed bool
+}
+
+// Fork returns a new session reading the same tree.
+// The nodes of the tree are never modified once committed.
+func (s *memSnapshotSession) Fork() engine.Session {
+	return &memSnapshotSession{root: s.root}
+}
+
+func (s *memSnapshotSession) Commit() error {
+	return errors.New("cannot commit in read-only mode")
+}
+
+func (s *memSnapshotSession) Close() error {
+	if s.closed {
+		return errors.New("already closed")
+	}
+	s.closed = true
+	s.root = nil
+	return nil
+}
+
+func (s *memSnapshotSession) Insert(k, v []byte) error {
+	return errors.New("cannot insert in read-only mode")
+}
+
+func (s *memSnapshotSession) Put(k, v []byte) error {
+	return errors.New("cannot put in read-only mode")
+}
+
+// Get returns a value associated with the given key. If not found, returns ErrKeyNotFound.
+func (s *memSnapshotSession) Get(k []byte) ([]byte, error) {
+	return memSessionGet(s.root, k)
+}
+
+// Exists returns whether a key exists and is visible by the current session.
+func (s *memSnapshotSession) Exists(k []byte) (bool, error) {
+	return memGet(s.root, k) != nil, nil
+}
+
+func (s *memSnapshotSession) Delete(k []byte) error {
+	return errors.New("cannot delete in read-only mode")
+}
+
+func (s *memSnapshotSession) DeleteRange(start []byte, end []byte) error {
+	return errors.New("cannot delete range in read-only mode")
+}
+
+func (s *memSnapshotSession) Iterator(opts *engine.IterOptions) (engine.Iterator, error) {
+	return newMemIterator(s.root, opts), nil
+}
+
+var _ engine.SavepointSession = (*memBatchSession)(nil)
+
+// memBatchSession writes to its own copy of the tree,
+// which replaces the tree of the engine when committed.
+type memBatchSession struct {
+	engine     *MemoryEngine
+	root       *memNode
+	savepoints []*memNode
+	closed     bool
+}
+
+func (s *memBatchSession) Commit() error {
+	if s.closed {
+		return errors.New("already closed")
+	}
+
+	s.engine.mu.Lock()
+	s.engine.root = s.root
+	s.engine.mu.Unlock()
+
+	return s.Close()
+}
+
+func (s *memBatchSession) Close() error {
+	if s.closed {
+		return errors.New("already closed")
+	}
+	s.closed = true
+	s.root = nil
+	s.savepoints = nil
+	return nil
+}
+
+// Insert inserts a key-value pair. If it already exists, it returns ErrKeyAlreadyExists.
+func (s *memBatchSession) Insert(k, v []byte) error {
+	if memGet(s.root, k) != nil {
+		return engine.ErrKeyAlreadyExists
+	}
+
+	return s.Put(k, v)
+}
+
+// Put stores a key value pair. If it already exists, it overrides it.
+func (s *memBatchSession) Put(k, v []byte) error {
+	root, err := memSessionPut(s.root, k, v)
+	if err != nil {
+		return err
+	}
+
+	s.root = root
+	return nil
+}
+
+// Get returns a value associated with the given key. If not found, returns ErrKeyNotFound.
+func (s *memBatchSession) Get(k []byte) ([]byte, error) {
+	return memSessionGet(s.root, k)
+}
+
+// Exists returns whether a key exists and is visible by the current session.
+func (s *memBatchSession) Exists(k []byte) (bool, error) {
+	return memGet(s.root, k) != nil, nil
+}
+
+// Delete a record by key. If the key doesn't exist, it doesn't do anything.
+func (s *memBatchSession) Delete(k []byte) error {
+	s.root = memDelete(s.root, k)
+	return nil
+}
+
+// DeleteRange deletes all keys in the given range.
+func (s *memBatchSession) DeleteRange(start []byte, end []byte) error {
+	s.root = memDeleteRange(s.root, start, end)
+	return nil
+}
+
+func (s *memBatchSession) Iterator(opts *engine.IterOptions) (engine.Iterator, error) {
+	return newMemIterator(s.root, opts), nil
+}
+
+// Savepoint marks the current state of the session.
+// As trees are immutable, a savepoint is the root of the tree at that time.
+func (s *memBatchSession) Savepoint() (int, error) {
+	if s.closed {
+		return 0, errors.New("already closed")
+	}
+
+	s.savepoints = append(s.savepoints, s.root)
+	return len(s.savepoints) - 1, nil
+}
+
+// RollbackToSavepoint restores the tree as it was when the savepoint was created.
+func (s *memBatchSession) RollbackToSavepoint(id int) error {
+	if s.closed {
+		return errors.New("already closed")
+	}
+	if id < 0 || id >= len(s.savepoints) {
+		return errors.Errorf("unknown savepoint %d", id)
+	}
+
+	s.root = s.savepoints[id]
+	s.savepoints = s.savepoints[:id+1]
+	return nil
+}
+
+// ReleaseSavepoint destroys the savepoint and the ones created after it.
+func (s *memBatchSession) ReleaseSavepoint(id int) error {
+	if s.closed {
+		return errors.New("already closed")
+	}
+	if id < 0 || id >= len(s.savepoints) {
+		return errors.Errorf("unknown savepoint %d", id)
+	}
+
+	s.savepoints = s.savepoints[:id]
+	return nil
+}
+
+// memTransientSession stores temporary data in a tree
+// that is only visible to the session.
+type memTransientSession struct {
+	root   *memNode
+	closed bool
+}
+
+func (s *memTransientSession) Commit() error {
+	return errors.New("cannot commit in transient mode")
+}
+
+func (s *memTransientSession) Close() error {
+	if s.closed {
+		return errors.New("already closed")
+	}
+	s.closed = true
+	s.root = nil
+	return nil
+}
+
+func (s *memTransientSession) Insert(k, v []byte) error {
+	return errors.New("cannot insert in transient mode")
+}
+
+// Put stores a key value pair. If it already exists, it overrides it.
+func (s *memTransientSession) Put(k, v []byte) error {
+	root, err := memSessionPut(s.root, k, v)
+	if err != nil {
+		return err
+	}
+
+	s.root = root
+	return nil
+}
+
+// Get returns a value associated with the given key. If not found, returns ErrKeyNotFound.
+func (s *memTransientSession) Get(k []byte) ([]byte, error) {
+	return memSessionGet(s.root, k)
+}
+
+// Exists returns whether a key exists and is visible by the current session.
+func (s *memTransientSession) Exists(k []byte) (bool, error) {
+	return memGet(s.root, k) != nil, nil
+}
+
+// Delete a record by key. If not found, returns ErrKeyNotFound.
+func (s *memTransientSession) Delete(k []byte) error {
+	if memGet(s.root, k) == nil {
+		return errors.WithStack(engine.ErrKeyNotFound)
+	}
+
+	s.root = memDelete(s.root, k)
+	return nil
+}
+
+func (s *memTransientSession) DeleteRange(start []byte, end []byte) error {
+	s.root = memDeleteRange(s.root, start, end)
+	return nil
+}
+
+func (s *memTransientSession) Iterator(opts *engine.IterOptions) (engine.Iterator, error) {
+	return newMemIterator(s.root, opts), nil
+}
+
+func newMemIterator(root *memNode, opts *engine.IterOptions) *memIterator {
+	it := memIterator{root: root}
+	if opts != nil {
+		it.lower = opts.LowerBound
+		it.upper = opts.UpperBound
+	}
+
+	return &it
+}
+
+// memSessionGet returns a copy of the value of the key.
+func memSessionGet(root *memNode, k []byte) ([]byte, error) {
+	n := memGet(root, k)
+	if n == nil {
+		return nil, errors.WithStack(engine.ErrKeyNotFound)
+	}
+
+	return bytes.Clone(n.value), nil
+}
+
+// memSessionPut stores copies of the key and the value,
+// as the caller may reuse their buffers.
+func memSessionPut(root *memNode, k, v []byte) (*memNode, error) {
+	if len(k) == 0 {
+		return nil, errors.New("cannot store empty key")
+	}
+
+	if len(v) == 0 {
+		return nil, errors.New("cannot store empty value")
+	}
+
+	return memPut(root, bytes.Clone(k), bytes.Clone(v)), nil
+}
+
+// memDeleteRange returns a tree without the keys between start (inclusive) and end (exclusive).
+// A nil end deletes all the keys after start.
+func memDeleteRange(root *memNode, start, end []byte) *memNode {
+	l, r := memSplit(root, start)
+	if end == nil {
+		return l
+	}
+
+	_, r = memSplit(r, end)
+	return memMerge(l, r)
+}
@@ -0,0 +1,207 @@
+package kv_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/chaisql/chai/internal/kv"
+	"github.com/stretchr/testify/require"
+)
+
+func memKey(i int64) []byte {
+	return encoding.EncodeInt(encoding.EncodeInt(nil, 10), i)
+}
+
+func memKeys(t *testing.T, s engine.Session, opts *engine.IterOptions, reverse bool) []int64 {
+	t.Helper()
+
+	it, err := s.Iterator(opts)
+	require.NoError(t, err)
+	defer it.Close()
+
+	var keys []int64
+	valid := it.First
+	next := it.Next
+	if reverse {
+		valid, next = it.Last, it.Prev
+	}
+	for ok := valid(); ok; ok = next() {
+		v, err := it.Value()
+		require.NoError(t, err)
+		n, _ := encoding.DecodeInt(v)
+		keys = append(keys, n)
+	}
+	require.NoError(t, it.Error())
+
+	return keys
+}
+
+func TestMemoryEngine(t *testing.T) {
+	ng := kv.NewMemoryEngine()
+	defer ng.Close()
+
+	batch := ng.NewBatchSession()
+	for _, i := range []int64{5, -3, 8, 1, 0, 12, -10} {
+		err := batch.Put(memKey(i), encoding.EncodeInt(nil, i))
+		require.NoError(t, err)
+	}
+
+	err := batch.Insert(memKey(5), encoding.EncodeInt(nil, 5))
+	require.ErrorIs(t, err, engine.ErrKeyAlreadyExists)
+
+	// snapshots created during the write transaction should not see the changes
+	ss := ng.NewSnapshotSession()
+	_, err = ss.Get(memKey(5))
+	require.ErrorIs(t, err, engine.ErrKeyNotFound)
+
+	err = batch.Commit()
+	require.NoError(t, err)
+
+	// the snapshot still reads the tree as it was when it was created
+	require.Empty(t, memKeys(t, ss, nil, false))
+	require.NoError(t, ss.Close())
+
+	ss = ng.NewSnapshotSession()
+	defer ss.Close()
+
+	require.Equal(t, []int64{-10, -3, 0, 1, 5, 8, 12}, memKeys(t, ss, nil, false))
+	require.Equal(t, []int64{12, 8, 5, 1, 0, -3, -10}, memKeys(t, ss, nil, true))
+
+	opts := engine.IterOptions{LowerBound: memKey(0), UpperBound: memKey(8)}
+	require.Equal(t, []int64{0, 1, 5}, memKeys(t, ss, &opts, false))
+	require.Equal(t, []int64{5, 1, 0}, memKeys(t, ss, &opts, true))
+
+	v, err := ss.Get(memKey(8))
+	require.NoError(t, err)
+	require.Equal(t, encoding.EncodeInt(nil, 8), v)
+
+	t.Run("Delete", func(t *testing.T) {
+		batch := ng.NewBatchSession()
+		defer batch.Close()
+
+		require.NoError(t, batch.Delete(memKey(1)))
+		require.NoError(t, batch.DeleteRange(memKey(5), memKey(12)))
+		require.Equal(t, []int64{-10, -3, 0, 12}, memKeys(t, batch, nil, false))
+
+		require.NoError(t, batch.DeleteRange(memKey(0), nil))
+		require.Equal(t, []int64{-10, -3}, memKeys(t, batch, nil, false))
+
+		// closing the session without committing discards the changes
+		require.NoError(t, batch.Close())
+		s := ng.NewSnapshotSession()
+		defer s.Close()
+		require.Equal(t, []int64{-10, -3, 0, 1, 5, 8, 12}, memKeys(t, s, nil, false))
+	})
+
+	t.Run("Savepoints", func(t *testing.T) {
+		batch := ng.NewBatchSession()
+		defer batch.Close()
+
+		sp := batch.(engine.SavepointSession)
+
+		require.NoError(t, batch.Put(memKey(20), encoding.EncodeInt(nil, 20)))
+		id, err := sp.Savepoint()
+		require.NoError(t, err)
+
+		require.NoError(t, batch.Put(memKey(21), encoding.EncodeInt(nil, 21)))
+		require.NoError(t, batch.Delete(memKey(0)))
+
+		require.NoError(t, sp.RollbackToSavepoint(id))
+		require.Equal(t, []int64{-10, -3, 0, 1, 5, 8, 12, 20}, memKeys(t, batch, nil, false))
+
+		require.NoError(t, sp.ReleaseSavepoint(id))
+		require.Error(t, sp.RollbackToSavepoint(id))
+	})
+
+	t.Run("Transient", func(t *testing.T) {
+		s := ng.NewTransientSession()
+		defer s.Close()
+
+		require.NoError(t, s.Put(memKey(3), encoding.EncodeInt(nil, 3)))
+		require.Equal(t, []int64{3}, memKeys(t, s, nil, false))
+		require.ErrorIs(t, s.Delete(memKey(4)), engine.ErrKeyNotFound)
+		require.Error(t, s.Commit())
+
+		// transient data is never visible to other sessions
+		ss := ng.NewSnapshotSession()
+		defer ss.Close()
+		ok, err := ss.Exists(memKey(3))
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+}
+
+func TestMemoryEngineSnapshot(t *testing.T) {
+	ng := kv.NewMemoryEngine()
+	defer ng.Close()
+
+	batch := ng.NewBatchSession()
+	for i := int64(0); i < 100; i++ {
+		err := batch.Put(memKey(i), encoding.EncodeInt(nil, i))
+		require.NoError(t, err)
+	}
+	require.NoError(t, batch.Commit())
+
+	var buf bytes.Buffer
+	err := ng.SaveSnapshot(&buf)
+	require.NoError(t, err)
+
+	restored := kv.NewMemoryEngine()
+	defer restored.Close()
+	err = restored.LoadSnapshot(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+
+	ss := restored.NewSnapshotSession()
+	defer ss.Close()
+	keys := memKeys(t, ss, nil, false)
+	require.Len(t, keys, 100)
+	for i, k := range keys {
+		require.Equal(t, int64(i), k)
+	}
+
+	err = restored.LoadSnapshot(bytes.NewReader([]byte("not a snapshot")))
+	require.Error(t, err)
+
+	// truncated snapshots are rejected
+	err = restored.LoadSnapshot(bytes.NewReader(buf.Bytes()[:buf.Len()-1]))
+	require.Error(t, err)
+}
+
+func TestMemoryIteratorSeek(t *testing.T) {
+	ng := kv.NewMemoryEngine()
+	defer ng.Close()
+
+	s := ng.NewBatchSession()
+	defer s.Close()
+	for i := int64(0); i < 10; i++ {
+		require.NoError(t, s.Put(memKey(i*2), encoding.EncodeInt(nil, i*2)))
+	}
+
+	it, err := s.Iterator(&engine.IterOptions{LowerBound: memKey(4), UpperBound: memKey(14)})
+	require.NoError(t, err)
+	defer it.Close()
+
+	value := func() int64 {
+		t.Helper()
+
+		v, err := it.Value()
+		require.NoError(t, err)
+		n, _ := encoding.DecodeInt(v)
+		return n
+	}
+
+	require.True(t, it.SeekGE(memKey(7)))
+	require.Equal(t, int64(8), value())
+	require.True(t, it.SeekLT(memKey(7)))
+	require.Equal(t, int64(6), value())
+
+	// seeks are bounded
+	require.True(t, it.SeekGE(memKey(0)))
+	require.Equal(t, int64(4), value())
+	require.True(t, it.SeekLT(memKey(18)))
+	require.Equal(t, int64(12), value())
+	require.False(t, it.SeekGE(memKey(14)))
+	require.False(t, it.SeekLT(memKey(4)))
+}
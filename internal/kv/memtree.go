@@ -0,0 +1,294 @@
+package kv
+
+import (
+	"math/rand/v2"
+
+	"github.com/chaisql/chai/internal/encoding"
+)
+
+// memNode is a node of an immutable treap.
+// Nodes are never modified once they are part of a tree:
+// every write copies the path leading to the modified node,
+// so that any root keeps representing the same set of keys.
+type memNode struct {
+	key      []byte
+	value    []byte
+	priority uint32
+	left     *memNode
+	right    *memNode
+}
+
+func (n *memNode) clone() *memNode {
+	c := *n
+	return &c
+}
+
+// memGet returns the node holding the key, or nil.
+func memGet(n *memNode, k []byte) *memNode {
+	for n != nil {
+		c := encoding.Compare(k, n.key)
+		switch {
+		case c < 0:
+			n = n.left
+		case c > 0:
+			n = n.right
+		default:
+			return n
+		}
+	}
+
+	return nil
+}
+
+// memPut returns a tree where the key is associated with the value.
+// The key and the value are not copied.
+func memPut(n *memNode, k, v []byte) *memNode {
+	return memInsert(memDelete(n, k), &memNode{key: k, value: v, priority: rand.Uint32()})
+}
+
+func memInsert(n *memNode, nn *memNode) *memNode {
+	if n == nil {
+		return nn
+	}
+
+	if nn.priority > n.priority {
+		nn.left, nn.right = memSplit(n, nn.key)
+		return nn
+	}
+
+	c := n.clone()
+	if encoding.Compare(nn.key, n.key) < 0 {
+		c.left = memInsert(n.left, nn)
+	} else {
+		c.right = memInsert(n.right, nn)
+	}
+
+	return c
+}
+
+// memDelete returns a tree without the key.
+// If the key doesn't exist, the tree is returned as is.
+func memDelete(n *memNode, k []byte) *memNode {
+	if n == nil {
+		return nil
+	}
+
+	c := encoding.Compare(k, n.key)
+	if c == 0 {
+		return memMerge(n.left, n.right)
+	}
+
+	var child *memNode
+	if c < 0 {
+		child = memDelete(n.left, k)
+		if child == n.left {
+			return n
+		}
+	} else {
+		child = memDelete(n.right, k)
+		if child == n.right {
+			return n
+		}
+	}
+
+	cp := n.clone()
+	if c < 0 {
+		cp.left = child
+	} else {
+		cp.right = child
+	}
+
+	return cp
+}
+
+// memSplit splits the tree into the keys lower than k and the others.
+func memSplit(n *memNode, k []byte) (*memNode, *memNode) {
+	if n == nil {
+		return nil, nil
+	}
+
+	c := n.clone()
+	if encoding.Compare(n.key, k) < 0 {
+		var r *memNode
+		c.right, r = memSplit(n.right, k)
+		return c, r
+	}
+
+	var l *memNode
+	l, c.left = memSplit(n.left, k)
+	return l, c
+}
+
+// memMerge merges two trees, all the keys of a being lower than the keys of b.
+func memMerge(a, b *memNode) *memNode {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+
+	if a.priority > b.priority {
+		c := a.clone()
+		c.right = memMerge(a.right, b)
+		return c
+	}
+
+	c := b.clone()
+	c.left = memMerge(a, b.left)
+	return c
+}
+
+// memSeekGE returns the node with the smallest key greater than or equal to k.
+// If k is nil, it returns the first node.
+func memSeekGE(n *memNode, k []byte) *memNode {
+	var found *memNode
+	for n != nil {
+		if k == nil || encoding.Compare(n.key, k) >= 0 {
+			found = n
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+
+	return found
+}
+
+// memSeekGT returns the node with the smallest key greater than k.
+func memSeekGT(n *memNode, k []byte) *memNode {
+	var found *memNode
+	for n != nil {
+		if encoding.Compare(n.key, k) > 0 {
+			found = n
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+
+	return found
+}
+
+// memSeekLT returns the node with the largest key lower than k.
+// If k is nil, it returns the last node.
+func memSeekLT(n *memNode, k []byte) *memNode {
+	var found *memNode
+	for n != nil {
+		if k == nil || encoding.Compare(n.key, k) < 0 {
+			found = n
+			n = n.right
+		} else {
+			n = n.left
+		}
+	}
+
+	return found
+}
+
+// memWalk calls fn for each node of the tree, in order.
+func memWalk(n *memNode, fn func(n *memNode) error) error {
+	if n == nil {
+		return nil
+	}
+
+	err := memWalk(n.left, fn)
+	if err != nil {
+		return err
+	}
+
+	err = fn(n)
+	if err != nil {
+		return err
+	}
+
+	return memWalk(n.right, fn)
+}
+
+// memIterator iterates over the keys of a tree between optional bounds.
+type memIterator struct {
+	root  *memNode
+	lower []byte
+	upper []byte
+	cur   *memNode
+}
+
+func (it *memIterator) inBounds(n *memNode) bool {
+	if n == nil {
+		return false
+	}
+	if it.lower != nil && encoding.Compare(n.key, it.lower) < 0 {
+		return false
+	}
+	if it.upper != nil && encoding.Compare(n.key, it.upper) >= 0 {
+		return false
+	}
+
+	return true
+}
+
+func (it *memIterator) First() bool {
+	it.cur = memSeekGE(it.root, it.lower)
+	return it.Valid()
+}
+
+func (it *memIterator) Last() bool {
+	it.cur = memSeekLT(it.root, it.upper)
+	return it.Valid()
+}
+
+func (it *memIterator) SeekGE(key []byte) bool {
+	if it.lower != nil && encoding.Compare(key, it.lower) < 0 {
+		key = it.lower
+	}
+
+	it.cur = memSeekGE(it.root, key)
+	return it.Valid()
+}
+
+func (it *memIterator) SeekLT(key []byte) bool {
+	if it.upper != nil && encoding.Compare(key, it.upper) > 0 {
+		key = it.upper
+	}
+
+	it.cur = memSeekLT(it.root, key)
+	return it.Valid()
+}
+
+func (it *memIterator) Valid() bool {
+	return it.inBounds(it.cur)
+}
+
+func (it *memIterator) Next() bool {
+	if it.cur == nil {
+		return false
+	}
+
+	it.cur = memSeekGT(it.root, it.cur.key)
+	return it.Valid()
+}
+
+func (it *memIterator) Prev() bool {
+	if it.cur == nil {
+		return false
+	}
+
+	it.cur = memSeekLT(it.root, it.cur.key)
+	return it.Valid()
+}
+
+func (it *memIterator) Key() []byte {
+	return it.cur.key
+}
+
+func (it *memIterator) Value() ([]byte, error) {
+	return it.cur.value, nil
+}
+
+func (it *memIterator) Error() error {
+	return nil
+}
+
+func (it *memIterator) Close() error {
+	return nil
+}
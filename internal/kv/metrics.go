@@ -0,0 +1,58 @@
+package kv
+
+import (
+	"github.com/chaisql/chai/internal/encoding"
+)
+
+// EngineStats describes the storage used by the engine
+// and the activity of its caches and compactions.
+type EngineStats struct {
+	// Size of the files of the engine, in bytes.
+	DiskSize uint64
+
+	// Lookups of blocks and tables found or not in the caches.
+	BlockCacheHits   int64
+	BlockCacheMisses int64
+	TableCacheHits   int64
+	TableCacheMisses int64
+
+	// Number of compactions completed since the engine was opened.
+	Compactions int64
+	// Number of compactions running.
+	CompactionsInProgress int64
+	// Estimated number of bytes to compact for the levels to reach their target size.
+	CompactionDebt uint64
+}
+
+// BlockCacheHitRate returns the fraction of the lookups
+// of the block cache that were hits, or zero if there were none.
+func (s EngineStats) BlockCacheHitRate() float64 {
+	total := s.BlockCacheHits + s.BlockCacheMisses
+	if total == 0 {
+		return 0
+	}
+
+	return float64(s.BlockCacheHits) / float64(total)
+}
+
+// Stats returns the current metrics of the engine.
+func (s *PebbleEngine) Stats() EngineStats {
+	m := s.db.Metrics()
+
+	return EngineStats{
+		DiskSize:              m.DiskSpaceUsage(),
+		BlockCacheHits:        m.BlockCache.Hits,
+		BlockCacheMisses:      m.BlockCache.Misses,
+		TableCacheHits:        m.TableCache.Hits,
+		TableCacheMisses:      m.TableCache.Misses,
+		Compactions:           m.Compact.Count,
+		CompactionsInProgress: m.Compact.NumInProgress,
+		CompactionDebt:        m.Compact.EstimatedDebt,
+	}
+}
+
+// NamespaceDiskUsage returns an estimate of the disk space used
+// by the keys of the namespace. Keys still in the memtables are not included.
+func (s *PebbleEngine) NamespaceDiskUsage(ns int64) (uint64, error) {
+	return s.db.EstimateDiskUsage(encoding.EncodeInt(nil, ns), encoding.EncodeInt(nil, ns+1))
+}
@@ -0,0 +1,102 @@
+package kv
+
+import (
+	"context"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/pebble"
+)
+
+// LastCommitTimestamp returns the timestamp, in nanoseconds, of the last commit
+// written to the WAL archive and applied to the engine, or zero if there is none.
+func (s *PebbleEngine) LastCommitTimestamp() (int64, error) {
+	return lastCommitTimestamp(s.db, s.opts.CommitTimestampNamespace)
+}
+
+// TailWALArchive calls fn with the batches of every transaction committed after since,
+// read from the WAL archive of the engine, in the order they were committed.
+// Once the archive is read, it waits for new commits until the context is done.
+func (s *PebbleEngine) TailWALArchive(ctx context.Context, since int64, fn func(ts int64, batches [][]byte) error) error {
+	a := s.walArchive
+	if a == nil {
+		return errors.New("the WAL archive is not enabled")
+	}
+
+	// batches written before the next commit.
+	var pending [][]byte
+	handle := func(ts int64, repr []byte) error {
+		if ts == 0 {
+			pending = append(pending, repr)
+			return nil
+		}
+
+		batches := append(pending, repr)
+		pending = nil
+		if ts <= since {
+			return nil
+		}
+
+		return fn(ts, batches)
+	}
+
+	var seg int
+	var off int64
+	for {
+		changed := a.changed()
+
+		// list the segments before reading the current one:
+		// if a newer segment exists, the current one is complete.
+		segs, err := walArchiveSegments(a.dir)
+		if err != nil {
+			return err
+		}
+
+		if seg == 0 && len(segs) > 0 {
+			seg = segs[0]
+		}
+
+		if seg != 0 {
+			off, err = readWALArchiveSegment(walArchiveSegmentPath(a.dir, seg), off, handle)
+			if err != nil {
+				return err
+			}
+
+			if len(segs) > 0 && segs[len(segs)-1] > seg {
+				for _, n := range segs {
+					if n > seg {
+						seg, off = n, 0
+						break
+					}
+				}
+				continue
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-changed:
+		}
+	}
+}
+
+// ApplyBatches atomically applies the batches of a transaction
+// read from the WAL archive of another engine.
+func (s *PebbleEngine) ApplyBatches(batches [][]byte) error {
+	b := s.db.NewBatch()
+	defer b.Close()
+
+	for _, repr := range batches {
+		rb := s.db.NewBatch()
+		err := rb.SetRepr(repr)
+		if err == nil {
+			err = b.Apply(rb, nil)
+		}
+		_ = rb.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return b.Commit(pebble.Sync)
+}
@@ -15,6 +15,9 @@ type RollbackSegment struct {
 	buf              []byte
 	seen             map[string]struct{}
 	segmentCommitted bool
+
+	// if set, the batches rolling back the changes are archived.
+	walArchive *walArchive
 }
 
 func NewRollbackSegment(db *pebble.DB, namespace int64) *RollbackSegment {
@@ -94,6 +97,9 @@ func (s *RollbackSegment) Rollback() error {
 		return nil
 	}
 
+	// the keys will be recorded again by the next transaction
+	clear(s.seen)
+
 	// read the rollback segment and rollback the changes
 	b := s.db.NewBatch()
 	it, err := s.db.NewIter(&pebble.IterOptions{
@@ -136,7 +142,16 @@ func (s *RollbackSegment) Rollback() error {
 	// we don't need to sync here.
 	// in case of a crash, the rollback segment will be rolled back
 	// during the next recovery phase.
-	return b.Commit(pebble.NoSync)
+	err = b.Commit(pebble.NoSync)
+	if err != nil {
+		return err
+	}
+
+	if s.walArchive != nil {
+		return s.walArchive.append(0, b.Repr())
+	}
+
+	return nil
 }
 
 func (s *RollbackSegment) Reset() error {
@@ -160,4 +175,37 @@ func (s *RollbackSegment) Clear(b *pebble.Batch) error {
 func (s *RollbackSegment) reset() {
 	s.buf = s.buf[:len(s.nsStart)]
 	s.segmentCommitted = false
+	clear(s.seen)
+}
+
+// iterate calls fn with the keys whose previous value
+// is stored in the rollback segment.
+func (s *RollbackSegment) iterate(fn func(k []byte) error) error {
+	if !s.segmentCommitted {
+		return nil
+	}
+
+	it, err := s.db.NewIter(&pebble.IterOptions{
+		LowerBound: s.nsStart,
+		UpperBound: s.nsEnd,
+	})
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+
+	for it.First(); it.Valid(); it.Next() {
+		k := it.Key()
+
+		// skip the namespace prefix
+		n := encoding.Skip(k)
+		uk, _ := encoding.DecodeBlob(k[n:])
+
+		err = fn(uk)
+		if err != nil {
+			return err
+		}
+	}
+
+	return it.Error()
 }
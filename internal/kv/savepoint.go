@@ -0,0 +1,132 @@
+package kv
+
+import (
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/pebble"
+)
+
+var _ engine.SavepointSession = (*BatchSession)(nil)
+
+// savepoint holds the value of every key modified
+// since the savepoint was created, as it was at that time.
+// A nil value means the key didn't exist.
+type savepoint map[string][]byte
+
+// Savepoint marks the current state of the session.
+// The pending batch is applied so that the writes made after the savepoint
+// can be tracked separately.
+func (s *BatchSession) Savepoint() (int, error) {
+	if s.closed {
+		return 0, errors.New("already closed")
+	}
+
+	err := s.applyBatch()
+	if err != nil {
+		return 0, err
+	}
+
+	s.savepoints = append(s.savepoints, make(savepoint))
+	return len(s.savepoints) - 1, nil
+}
+
+// RollbackToSavepoint restores the value of all the keys modified
+// since the savepoint was created.
+func (s *BatchSession) RollbackToSavepoint(id int) error {
+	if s.closed {
+		return errors.New("already closed")
+	}
+	if id < 0 || id >= len(s.savepoints) {
+		return errors.Errorf("unknown savepoint %d", id)
+	}
+
+	// apply the pending writes to capture
+	// the previous value of the keys.
+	err := s.applyBatch()
+	if err != nil {
+		return err
+	}
+
+	for k, v := range s.savepoints[id] {
+		if v == nil {
+			err = s.Batch.Delete([]byte(k), nil)
+		} else {
+			err = s.Batch.Set([]byte(k), v, nil)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	s.savepoints = s.savepoints[:id+1]
+
+	// the restored writes go through the rollback segment
+	// like any other write, so that rolling back the whole
+	// transaction still restores the original values.
+	err = s.applyBatch()
+	if err != nil {
+		return err
+	}
+
+	// the state of the session now matches the savepoint
+	s.savepoints[id] = make(savepoint)
+
+	return nil
+}
+
+// ReleaseSavepoint destroys the savepoint and the ones created after it.
+func (s *BatchSession) ReleaseSavepoint(id int) error {
+	if s.closed {
+		return errors.New("already closed")
+	}
+	if id < 0 || id >= len(s.savepoints) {
+		return errors.Errorf("unknown savepoint %d", id)
+	}
+
+	s.savepoints = s.savepoints[:id]
+	return nil
+}
+
+// captureSavepoints records the current value of the keys of the batch
+// in every savepoint that doesn't know them yet.
+// It must be called before the batch is applied.
+func (s *BatchSession) captureSavepoints() error {
+	if len(s.savepoints) == 0 {
+		return nil
+	}
+
+	r, n := pebble.ReadBatch(s.Batch.Repr())
+	for i := uint32(0); i < n; i++ {
+		kind, key, _, ok, err := r.Next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+
+		if kind != pebble.InternalKeyKindSet && kind != pebble.InternalKeyKindDelete {
+			continue
+		}
+
+		var v []byte
+		var loaded bool
+		for _, sp := range s.savepoints {
+			if _, ok := sp[string(key)]; ok {
+				continue
+			}
+
+			if !loaded {
+				v, err = get(s.DB, key)
+				if err != nil && !errors.Is(err, engine.ErrKeyNotFound) {
+					return err
+				}
+				loaded = true
+			}
+
+			sp[string(key)] = v
+		}
+	}
+
+	return nil
+}
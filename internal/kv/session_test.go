@@ -8,6 +8,7 @@ import (
 	"github.com/chaisql/chai"
 	"github.com/chaisql/chai/internal/encoding"
 	"github.com/chaisql/chai/internal/engine"
+	"github.com/chaisql/chai/internal/kv"
 	"github.com/chaisql/chai/internal/testutil"
 	"github.com/stretchr/testify/require"
 )
@@ -472,3 +473,50 @@ func TestQueriesSameTransaction(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+func TestApplyBatch(t *testing.T) {
+	key := func(i int64) []byte {
+		return encoding.EncodeInt(encoding.EncodeInt(nil, 10), i)
+	}
+
+	engines := map[string]func(t *testing.T) engine.Engine{
+		"Pebble": func(t *testing.T) engine.Engine { return testutil.NewEngine(t) },
+		"Memory": func(t *testing.T) engine.Engine { return kv.NewMemoryEngine() },
+	}
+
+	for name, newEngine := range engines {
+		t.Run(name, func(t *testing.T) {
+			ng := newEngine(t)
+
+			s := ng.NewBatchSession()
+			defer s.Close()
+
+			for i := int64(1); i <= 3; i++ {
+				require.NoError(t, s.Put(key(i), encoding.EncodeInt(nil, i)))
+			}
+
+			var b engine.Batch
+			require.NoError(t, b.Put(key(4), encoding.EncodeInt(nil, 4)))
+			require.NoError(t, b.Put(key(5), encoding.EncodeInt(nil, 5)))
+			b.Delete(key(1))
+			b.Delete(key(10))
+			b.DeleteRange(key(2), key(4))
+			require.Equal(t, 5, b.Len())
+
+			require.NoError(t, engine.ApplyBatch(s, &b))
+			require.NoError(t, s.Commit())
+
+			ss := ng.NewSnapshotSession()
+			defer ss.Close()
+			for i := int64(1); i <= 5; i++ {
+				v, err := ss.Get(key(i))
+				if i < 4 {
+					require.ErrorIs(t, err, engine.ErrKeyNotFound)
+					continue
+				}
+				require.NoError(t, err)
+				require.Equal(t, encoding.EncodeInt(nil, i), v)
+			}
+		})
+	}
+}
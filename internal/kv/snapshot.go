@@ -1,8 +1,6 @@
 package kv
 
 import (
-	"math"
-
 	"github.com/chaisql/chai/internal/engine"
 	"github.com/chaisql/chai/internal/pkg/atomic"
 	"github.com/cockroachdb/errors"
@@ -12,6 +10,8 @@ import (
 type snapshot struct {
 	refCount *atomic.Counter
 	snapshot *pebble.Snapshot
+	// blob log of the engine, if any, notified when the snapshot is closed.
+	blobs *blobLog
 }
 
 func (s *snapshot) Incr() {
@@ -20,6 +20,9 @@ func (s *snapshot) Incr() {
 
 func (s *snapshot) Done() error {
 	if s.refCount.Decr() <= 0 {
+		if s.blobs != nil {
+			s.blobs.releaseSnapshot()
+		}
 		return s.snapshot.Close()
 	}
 	return nil
@@ -42,10 +45,7 @@ func (s *PebbleEngine) NewSnapshotSession() engine.Session {
 
 	// if there is no shared snapshot, create one.
 	if sn == nil {
-		sn = &snapshot{
-			snapshot: s.db.NewSnapshot(),
-			refCount: atomic.NewCounter(0, math.MaxInt64, false),
-		}
+		sn = s.newSnapshot()
 	}
 	sn.Incr()
 
@@ -57,6 +57,18 @@ func (s *PebbleEngine) NewSnapshotSession() engine.Session {
 	}
 }
 
+var _ engine.ForkableSession = (*SnapshotSession)(nil)
+
+// Fork returns a new session sharing the pebble snapshot of the session.
+func (s *SnapshotSession) Fork() engine.Session {
+	s.Snapshot.Incr()
+
+	return &SnapshotSession{
+		Store:    s.Store,
+		Snapshot: s.Snapshot,
+	}
+}
+
 func (s *SnapshotSession) Commit() error {
 	return errors.New("cannot commit in read-only mode")
 }
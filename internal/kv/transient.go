@@ -34,6 +34,10 @@ func (s *TransientSession) Close() error {
 	}
 	s.closed = true
 
+	if s.batch == nil {
+		return nil
+	}
+
 	return s.batch.Close()
 }
 
@@ -0,0 +1,74 @@
+package kv
+
+import (
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/pebble"
+)
+
+const (
+	// Pebble requires the memtables to be smaller than 4GB.
+	maxMemTableSize = 4<<30 - 1
+	minMemTableSize = 1 << 20 // 1MB
+
+	// defaults of Pebble
+	defaultL0CompactionThreshold = 4
+	defaultL0StopWritesThreshold = 12
+)
+
+// validateTuning ensures the tuning options are consistent.
+// Zero values select the defaults of Pebble.
+func validateTuning(opts *Options) error {
+	if opts.CacheSize < 0 {
+		return errors.Errorf("invalid cache size %d: must not be negative", opts.CacheSize)
+	}
+
+	if opts.MemTableSize != 0 && (opts.MemTableSize < minMemTableSize || opts.MemTableSize > maxMemTableSize) {
+		return errors.Errorf("invalid memtable size %d: must be between %d and %d", opts.MemTableSize, minMemTableSize, maxMemTableSize)
+	}
+
+	if opts.MaxOpenFiles < 0 {
+		return errors.Errorf("invalid max open files %d: must not be negative", opts.MaxOpenFiles)
+	}
+
+	if opts.L0CompactionThreshold < 0 {
+		return errors.Errorf("invalid L0 compaction threshold %d: must not be negative", opts.L0CompactionThreshold)
+	}
+	if opts.L0StopWritesThreshold < 0 {
+		return errors.Errorf("invalid L0 stop writes threshold %d: must not be negative", opts.L0StopWritesThreshold)
+	}
+
+	// compare the thresholds that will be used, including the defaults
+	compaction, stop := defaultL0CompactionThreshold, defaultL0StopWritesThreshold
+	if opts.L0CompactionThreshold > 0 {
+		compaction = opts.L0CompactionThreshold
+	}
+	if opts.L0StopWritesThreshold > 0 {
+		stop = opts.L0StopWritesThreshold
+	}
+	if stop < compaction {
+		return errors.Errorf("L0 stop writes threshold %d must not be lower than the L0 compaction threshold %d", stop, compaction)
+	}
+
+	return nil
+}
+
+// applyTuning sets the tuning options, validated by validateTuning,
+// on the Pebble options. The cache created, if any, must be released
+// with Unref once the database is opened.
+func applyTuning(opts *Options, popts *pebble.Options) {
+	if opts.CacheSize > 0 {
+		popts.Cache = pebble.NewCache(opts.CacheSize)
+	}
+	if opts.MemTableSize > 0 {
+		popts.MemTableSize = uint64(opts.MemTableSize)
+	}
+	if opts.MaxOpenFiles > 0 {
+		popts.MaxOpenFiles = opts.MaxOpenFiles
+	}
+	if opts.L0CompactionThreshold > 0 {
+		popts.L0CompactionThreshold = opts.L0CompactionThreshold
+	}
+	if opts.L0StopWritesThreshold > 0 {
+		popts.L0StopWritesThreshold = opts.L0StopWritesThreshold
+	}
+}
@@ -0,0 +1,44 @@
+package kv_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTuningOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db")
+	db, err := chai.OpenWith(path, &chai.Options{
+		CacheSize:             16 << 20,
+		MemTableSize:          8 << 20,
+		MaxOpenFiles:          100,
+		L0CompactionThreshold: 2,
+		L0StopWritesThreshold: 20,
+	})
+	require.NoError(t, err)
+
+	err = db.Exec("CREATE TABLE test(a INTEGER PRIMARY KEY)")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	tests := []struct {
+		name string
+		opts chai.Options
+	}{
+		{"negative cache size", chai.Options{CacheSize: -1}},
+		{"small memtable", chai.Options{MemTableSize: 1024}},
+		{"large memtable", chai.Options{MemTableSize: 8 << 30}},
+		{"negative max open files", chai.Options{MaxOpenFiles: -1}},
+		{"stop below compaction", chai.Options{L0CompactionThreshold: 10, L0StopWritesThreshold: 5}},
+		{"compaction above default stop", chai.Options{L0CompactionThreshold: 20}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := chai.OpenWith(path, &test.opts)
+			require.Error(t, err)
+		})
+	}
+}
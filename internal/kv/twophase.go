@@ -0,0 +1,223 @@
+package kv
+
+import (
+	"encoding/binary"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/pebble"
+)
+
+var (
+	_ engine.TwoPhaseSession = (*BatchSession)(nil)
+	_ engine.TwoPhaseEngine  = (*PebbleEngine)(nil)
+)
+
+func preparedTxKey(namespace int64) []byte {
+	return encoding.EncodeInt(nil, namespace)
+}
+
+// the prepared transaction is stored as the length of its id,
+// its id and the representation of the batch applying its changes.
+func encodePreparedTx(id string, repr []byte) []byte {
+	buf := binary.AppendUvarint(nil, uint64(len(id)))
+	buf = append(buf, id...)
+	return append(buf, repr...)
+}
+
+func decodePreparedTx(v []byte) (string, []byte, error) {
+	l, n := binary.Uvarint(v)
+	if n <= 0 || uint64(len(v)-n) < l {
+		return "", nil, errors.New("corrupted prepared transaction")
+	}
+
+	return string(v[n : n+int(l)]), v[n+int(l):], nil
+}
+
+// PrepareTwoPhase records the final value of every key written by the session
+// in a batch stored under the given id, then undoes the intermediary commits
+// of the session: until the batch is applied by CommitPrepared, the store
+// is left as it was before the session started.
+func (s *BatchSession) PrepareTwoPhase(id string) error {
+	if s.closed {
+		return errors.New("already closed")
+	}
+	if s.prepared {
+		return errors.New("already prepared")
+	}
+	ns := s.Store.opts.PreparedTxNamespace
+	if ns == 0 {
+		return errors.New("two-phase commit is not enabled")
+	}
+
+	_, ok, err := s.Store.PreparedTransaction()
+	if err != nil {
+		return err
+	}
+	if ok {
+		return errors.New("another transaction is already prepared")
+	}
+
+	redo := s.DB.NewBatch()
+	defer redo.Close()
+
+	// the keys written by the intermediary commits
+	err = s.rollbackSegment.iterate(func(k []byte) error {
+		v, err := get(s.DB, k)
+		if errors.Is(err, engine.ErrKeyNotFound) {
+			return redo.Delete(k, nil)
+		}
+		if err != nil {
+			return err
+		}
+
+		return redo.Set(k, v, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	// followed by the pending writes, which override them
+	err = redo.Apply(s.Batch, nil)
+	if err != nil {
+		return err
+	}
+
+	err = s.rollbackSegment.Rollback()
+	if err != nil {
+		return err
+	}
+
+	// the blobs referenced by the changes must be durable before them
+	if s.Store.blobs != nil {
+		err = s.Store.blobs.sync()
+		if err != nil {
+			return err
+		}
+	}
+
+	b := s.DB.NewBatch()
+	defer b.Close()
+
+	err = b.Set(preparedTxKey(ns), encodePreparedTx(id, redo.Repr()), nil)
+	if err != nil {
+		return err
+	}
+
+	err = b.Commit(pebble.Sync)
+	if err != nil {
+		return err
+	}
+
+	s.rollbackSegment.reset()
+	return s.Close()
+}
+
+// PreparedTransaction returns the id of the transaction prepared
+// by PrepareTwoPhase, if any.
+func (s *PebbleEngine) PreparedTransaction() (string, bool, error) {
+	if s.opts.PreparedTxNamespace == 0 {
+		return "", false, nil
+	}
+
+	v, err := get(s.db, preparedTxKey(s.opts.PreparedTxNamespace))
+	if errors.Is(err, engine.ErrKeyNotFound) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	id, _, err := decodePreparedTx(v)
+	if err != nil {
+		return "", false, err
+	}
+
+	return id, true, nil
+}
+
+// preparedTx returns the batch of the prepared transaction with the given id.
+func (s *PebbleEngine) preparedTx(id string) ([]byte, error) {
+	if s.opts.PreparedTxNamespace == 0 {
+		return nil, errors.New("two-phase commit is not enabled")
+	}
+
+	v, err := get(s.db, preparedTxKey(s.opts.PreparedTxNamespace))
+	if err != nil && !errors.Is(err, engine.ErrKeyNotFound) {
+		return nil, err
+	}
+
+	var pid string
+	var repr []byte
+	if err == nil {
+		pid, repr, err = decodePreparedTx(v)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if v == nil || pid != id {
+		return nil, errors.Errorf("no prepared transaction %q", id)
+	}
+
+	return repr, nil
+}
+
+// CommitPrepared atomically applies the changes of the prepared transaction
+// and deletes it.
+func (s *PebbleEngine) CommitPrepared(id string) error {
+	repr, err := s.preparedTx(id)
+	if err != nil {
+		return err
+	}
+
+	b := s.db.NewBatch()
+	defer b.Close()
+
+	rb := s.db.NewBatch()
+	err = rb.SetRepr(repr)
+	if err == nil {
+		err = b.Apply(rb, nil)
+	}
+	_ = rb.Close()
+	if err != nil {
+		return err
+	}
+
+	err = b.Delete(preparedTxKey(s.opts.PreparedTxNamespace), nil)
+	if err != nil {
+		return err
+	}
+
+	// stamp the commit, like BatchSession.Prepare
+	var ts int64
+	archive := s.walArchive
+	if archive != nil {
+		ts = archive.nextCommitTimestamp()
+		err = b.Set(commitTimestampKey(s.opts.CommitTimestampNamespace), encoding.EncodeInt(nil, ts), nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = b.Commit(pebble.Sync)
+	if err != nil {
+		return err
+	}
+
+	if archive != nil {
+		return archive.append(ts, b.Repr())
+	}
+
+	return nil
+}
+
+// RollbackPrepared deletes the prepared transaction.
+func (s *PebbleEngine) RollbackPrepared(id string) error {
+	_, err := s.preparedTx(id)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Delete(preparedTxKey(s.opts.PreparedTxNamespace), pebble.Sync)
+}
@@ -0,0 +1,371 @@
+package kv
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/pebble"
+)
+
+const (
+	walArchiveSegmentSuffix  = ".wal"
+	walArchiveSegmentMaxSize = 64 << 20 // 64MB
+
+	// size of the header of the records:
+	// commit timestamp, size of the batch and checksum of the batch.
+	walArchiveHeaderSize = 8 + 4 + 4
+)
+
+// walArchive appends the batches written to the engine to segment files.
+// Each record of a segment holds the representation of a Pebble batch.
+// Records of committed batches are stamped with the time of the commit,
+// records of the batches written before the commit of large transactions,
+// or to roll them back, have a zero timestamp.
+type walArchive struct {
+	mu sync.Mutex
+
+	dir  string
+	seg  int
+	f    *os.File
+	w    *bufio.Writer
+	size int
+
+	// timestamp of the last commit, in nanoseconds.
+	// commit timestamps are strictly increasing.
+	lastCommit int64
+
+	// closed and replaced every time a commit is written.
+	committed chan struct{}
+}
+
+func openWALArchive(dir string, lastCommit int64) (*walArchive, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	segs, err := walArchiveSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	a := walArchive{
+		dir:        dir,
+		lastCommit: lastCommit,
+		committed:  make(chan struct{}),
+	}
+
+	// never append to an existing segment, its last record
+	// may have been partially written.
+	if len(segs) > 0 {
+		a.seg = segs[len(segs)-1]
+	}
+	err = a.rotate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &a, nil
+}
+
+// walArchiveSegments returns the numbers of the segments of the archive, in order.
+func walArchiveSegments(dir string) ([]int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var segs []int
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), walArchiveSegmentSuffix)
+		if !ok || e.IsDir() {
+			continue
+		}
+
+		var n int
+		_, err := fmt.Sscanf(name, "%d", &n)
+		if err != nil {
+			continue
+		}
+		segs = append(segs, n)
+	}
+
+	slices.Sort(segs)
+	return segs, nil
+}
+
+func walArchiveSegmentPath(dir string, seg int) string {
+	return filepath.Join(dir, fmt.Sprintf("%016d%s", seg, walArchiveSegmentSuffix))
+}
+
+// rotate closes the current segment and creates the next one.
+func (a *walArchive) rotate() error {
+	err := a.closeSegment()
+	if err != nil {
+		return err
+	}
+
+	a.seg++
+	f, err := os.OpenFile(walArchiveSegmentPath(a.dir, a.seg), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	a.f = f
+	a.w = bufio.NewWriter(f)
+	a.size = 0
+	return nil
+}
+
+func (a *walArchive) closeSegment() error {
+	if a.f == nil {
+		return nil
+	}
+
+	err := a.w.Flush()
+	if err != nil {
+		return err
+	}
+
+	err = a.f.Sync()
+	if err != nil {
+		return err
+	}
+
+	err = a.f.Close()
+	a.f, a.w = nil, nil
+	return err
+}
+
+// nextCommitTimestamp returns the timestamp of the next commit.
+func (a *walArchive) nextCommitTimestamp() int64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	ts := max(time.Now().UnixNano(), a.lastCommit+1)
+	a.lastCommit = ts
+	return ts
+}
+
+// append writes the representation of a batch to the archive.
+// ts is the timestamp of the commit, or zero if the batch isn't a commit.
+func (a *walArchive) append(ts int64, repr []byte) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.f == nil {
+		return errors.New("the WAL archive is closed")
+	}
+
+	if a.size > 0 && a.size+walArchiveHeaderSize+len(repr) > walArchiveSegmentMaxSize {
+		err := a.rotate()
+		if err != nil {
+			return err
+		}
+	}
+
+	var hdr [walArchiveHeaderSize]byte
+	binary.BigEndian.PutUint64(hdr[:8], uint64(ts))
+	binary.BigEndian.PutUint32(hdr[8:12], uint32(len(repr)))
+	binary.BigEndian.PutUint32(hdr[12:], crc32.ChecksumIEEE(repr))
+
+	_, err := a.w.Write(hdr[:])
+	if err != nil {
+		return err
+	}
+	_, err = a.w.Write(repr)
+	if err != nil {
+		return err
+	}
+	a.size += walArchiveHeaderSize + len(repr)
+
+	// commits are written to the segment file immediately,
+	// so that they survive a crash of the process.
+	if ts != 0 {
+		err = a.w.Flush()
+		if err != nil {
+			return err
+		}
+
+		close(a.committed)
+		a.committed = make(chan struct{})
+	}
+
+	return nil
+}
+
+// changed returns a channel closed when the next commit is written.
+func (a *walArchive) changed() <-chan struct{} {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.committed
+}
+
+func (a *walArchive) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.closeSegment()
+}
+
+// commitTimestampKey returns the key storing the timestamp of the last commit
+// written to the WAL archive.
+func commitTimestampKey(namespace int64) []byte {
+	return encoding.EncodeInt(nil, namespace)
+}
+
+// lastCommitTimestamp returns the timestamp of the last commit
+// stored in the database, or zero if none was stored.
+func lastCommitTimestamp(db *pebble.DB, namespace int64) (int64, error) {
+	v, closer, err := db.Get(commitTimestampKey(namespace))
+	if errors.Is(err, pebble.ErrNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer closer.Close()
+
+	ts, _ := encoding.DecodeInt(v)
+	return ts, nil
+}
+
+// ReplayWALArchive applies the batches of the WAL archive stored in dir
+// that were committed after the last commit of the engine and until the given time.
+// Batches written by transactions that were not committed by then are not applied.
+// It returns the time of the last commit applied, or the zero time if none was applied.
+func (s *PebbleEngine) ReplayWALArchive(dir string, until time.Time) (time.Time, error) {
+	since, err := lastCommitTimestamp(s.db, s.opts.CommitTimestampNamespace)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	segs, err := walArchiveSegments(dir)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// batches written before the next commit.
+	var pending [][]byte
+	var last int64
+
+	apply := func(repr []byte) error {
+		b := s.db.NewBatch()
+		defer b.Close()
+
+		err := b.SetRepr(repr)
+		if err != nil {
+			return err
+		}
+
+		return s.db.Apply(b, pebble.NoSync)
+	}
+
+	stop := errors.New("stop")
+	for _, seg := range segs {
+		_, err = readWALArchiveSegment(walArchiveSegmentPath(dir, seg), 0, func(ts int64, repr []byte) error {
+			if ts == 0 {
+				pending = append(pending, repr)
+				return nil
+			}
+
+			// already part of the database
+			if ts <= since {
+				pending = pending[:0]
+				return nil
+			}
+
+			if ts > until.UnixNano() {
+				return stop
+			}
+
+			for _, p := range pending {
+				err := apply(p)
+				if err != nil {
+					return err
+				}
+			}
+			pending = pending[:0]
+
+			last = ts
+			return apply(repr)
+		})
+		if errors.Is(err, stop) {
+			break
+		}
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	err = s.db.Flush()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if last == 0 {
+		return time.Time{}, nil
+	}
+
+	return time.Unix(0, last), nil
+}
+
+// readWALArchiveSegment calls fn for each record of the segment, starting at the given offset.
+// A partially written record at the end of the segment is ignored.
+// It returns the offset following the last record read.
+func readWALArchiveSegment(path string, off int64, fn func(ts int64, repr []byte) error) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return off, err
+	}
+	defer f.Close()
+
+	_, err = f.Seek(off, io.SeekStart)
+	if err != nil {
+		return off, err
+	}
+
+	r := bufio.NewReader(f)
+	var hdr [walArchiveHeaderSize]byte
+	for {
+		_, err := io.ReadFull(r, hdr[:])
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return off, nil
+		}
+		if err != nil {
+			return off, err
+		}
+
+		ts := int64(binary.BigEndian.Uint64(hdr[:8]))
+		repr := make([]byte, binary.BigEndian.Uint32(hdr[8:12]))
+		_, err = io.ReadFull(r, repr)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return off, nil
+		}
+		if err != nil {
+			return off, err
+		}
+
+		if crc32.ChecksumIEEE(repr) != binary.BigEndian.Uint32(hdr[12:]) {
+			return off, errors.Errorf("corrupted WAL archive segment %q", path)
+		}
+
+		err = fn(ts, repr)
+		if err != nil {
+			return off, err
+		}
+		off += int64(walArchiveHeaderSize + len(repr))
+	}
+}
@@ -0,0 +1,158 @@
+// Package objstore uploads files to object stores, such as S3
+// or Azure Blob Storage, split in parts described by a manifest.
+package objstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/cockroachdb/errors"
+)
+
+// DefaultPartSize is the size of the parts uploaded by Upload.
+const DefaultPartSize = 16 << 20 // 16MB
+
+// ErrNotFound is returned by stores when an object doesn't exist.
+var ErrNotFound = errors.New("object not found")
+
+// A Store stores objects by key.
+type Store interface {
+	// Put stores the object read from r, whose size is known in advance.
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	// Get returns the content of the object.
+	// It returns ErrNotFound if the object doesn't exist.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+// A Manifest describes the parts of an uploaded file.
+// It is uploaded once all the parts are uploaded.
+type Manifest struct {
+	Parts []Part `json:"parts"`
+}
+
+// A Part is a part of an uploaded file.
+type Part struct {
+	Key  string `json:"key"`
+	Size int64  `json:"size"`
+	// SHA-256 of the part, hex encoded.
+	SHA256 string `json:"sha256"`
+}
+
+func manifestKey(prefix string) string {
+	return prefix + "/manifest.json"
+}
+
+func partKey(prefix string, i int) string {
+	return fmt.Sprintf("%s/part-%06d", prefix, i)
+}
+
+// Upload uploads the content of r under the given prefix, in parts of partSize bytes,
+// followed by the manifest listing them.
+// If partSize is zero or negative, DefaultPartSize is used.
+func Upload(ctx context.Context, store Store, prefix string, r io.Reader, partSize int) (*Manifest, error) {
+	if partSize <= 0 {
+		partSize = DefaultPartSize
+	}
+
+	var m Manifest
+	buf := make([]byte, partSize)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			sum := sha256.Sum256(buf[:n])
+			part := Part{
+				Key:    partKey(prefix, len(m.Parts)+1),
+				Size:   int64(n),
+				SHA256: hex.EncodeToString(sum[:]),
+			}
+
+			perr := store.Put(ctx, part.Key, bytes.NewReader(buf[:n]), int64(n))
+			if perr != nil {
+				return nil, errors.Wrapf(perr, "cannot upload %q", part.Key)
+			}
+			m.Parts = append(m.Parts, part)
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := json.Marshal(&m)
+	if err != nil {
+		return nil, err
+	}
+
+	err = store.Put(ctx, manifestKey(prefix), bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot upload manifest")
+	}
+
+	return &m, nil
+}
+
+// Download returns a reader of the file uploaded under the given prefix.
+// Every part is verified against the manifest, the reader returns an error
+// if a part is missing, truncated or corrupted.
+func Download(ctx context.Context, store Store, prefix string) (io.ReadCloser, error) {
+	rc, err := store.Get(ctx, manifestKey(prefix))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, errors.Errorf("no complete upload under %q: manifest not found", prefix)
+		}
+		return nil, err
+	}
+	defer rc.Close()
+
+	var m Manifest
+	err = json.NewDecoder(rc).Decode(&m)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid manifest")
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(downloadParts(ctx, store, &m, pw))
+	}()
+
+	return pr, nil
+}
+
+func downloadParts(ctx context.Context, store Store, m *Manifest, w io.Writer) error {
+	for _, p := range m.Parts {
+		rc, err := store.Get(ctx, p.Key)
+		if err != nil {
+			return errors.Wrapf(err, "cannot download %q", p.Key)
+		}
+
+		// verify the part before writing it
+		var buf bytes.Buffer
+		_, err = io.Copy(&buf, io.LimitReader(rc, p.Size+1))
+		_ = rc.Close()
+		if err != nil {
+			return errors.Wrapf(err, "cannot download %q", p.Key)
+		}
+
+		if int64(buf.Len()) != p.Size {
+			return errors.Errorf("part %q has %d bytes, expected %d", p.Key, buf.Len(), p.Size)
+		}
+		sum := sha256.Sum256(buf.Bytes())
+		if hex.EncodeToString(sum[:]) != p.SHA256 {
+			return errors.Errorf("part %q is corrupted: checksum mismatch", p.Key)
+		}
+
+		_, err = w.Write(buf.Bytes())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+// Package filelock implements advisory locks on files, recording
+// the process holding them.
+package filelock
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+// ErrLocked is returned when the file is locked by another process,
+// or by another open of the file in the same process.
+var ErrLocked = errors.New("file is locked")
+
+// retryInterval is the interval between two attempts of LockWait.
+const retryInterval = 50 * time.Millisecond
+
+// A Lock is an exclusive lock held on a file.
+// The lock is released when the process exits.
+type Lock struct {
+	f *os.File
+}
+
+// TryLock creates the file if it doesn't exist and locks it, without waiting.
+// Once locked, the file contains the PID of the current process.
+// If the file is already locked, the returned error wraps ErrLocked
+// and, if known, reports the PID of the process holding the lock.
+func TryLock(path string) (*Lock, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	locked, err := tryLock(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, errors.Wrapf(err, "cannot lock %q", path)
+	}
+	if !locked {
+		pid := readPID(f)
+		_ = f.Close()
+		if pid != 0 {
+			return nil, errors.Wrapf(ErrLocked, "%q is locked by process %d", path, pid)
+		}
+		return nil, errors.Wrapf(ErrLocked, "%q is locked by another process", path)
+	}
+
+	err = writePID(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	return &Lock{f: f}, nil
+}
+
+// LockWait is like TryLock, but waits for the lock to be released
+// for at most timeout, or until the context is done.
+func LockWait(ctx context.Context, path string, timeout time.Duration) (*Lock, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		l, err := TryLock(path)
+		if err == nil || !errors.Is(err, ErrLocked) || !time.Now().Before(deadline) {
+			return l, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, errors.WithSecondaryError(context.Cause(ctx), err)
+		case <-time.After(min(retryInterval, time.Until(deadline))):
+		}
+	}
+}
+
+// Unlock releases the lock. The file is kept, so that processes
+// waiting for the lock keep locking the same file.
+func (l *Lock) Unlock() error {
+	// clear the PID, the lock is released when the file is closed
+	_ = l.f.Truncate(0)
+	return l.f.Close()
+}
+
+func readPID(f *os.File) int {
+	var buf [32]byte
+	n, _ := f.ReadAt(buf[:], 0)
+	pid, err := strconv.Atoi(string(bytes.TrimSpace(buf[:n])))
+	if err != nil {
+		return 0
+	}
+
+	return pid
+}
+
+func writePID(f *os.File) error {
+	err := f.Truncate(0)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	if err != nil {
+		return err
+	}
+
+	return f.Sync()
+}
@@ -0,0 +1,12 @@
+//go:build !unix
+
+package filelock
+
+import "os"
+
+// tryLock always succeeds on platforms without flock:
+// only the PID is recorded, and the lock of the storage
+// engine still prevents concurrent opens.
+func tryLock(f *os.File) (bool, error) {
+	return true, nil
+}
@@ -0,0 +1,155 @@
+package planner
+
+import (
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/expr/glob"
+	"github.com/chaisql/chai/internal/fulltext"
+	"github.com/chaisql/chai/internal/stream"
+	"github.com/chaisql/chai/internal/stream/index"
+	"github.com/chaisql/chai/internal/stream/table"
+	"github.com/chaisql/chai/internal/types"
+)
+
+// selectFulltextIndex replaces the table scan by a full-text index scan
+// if one of the filter nodes is a MATCH condition on a column
+// with a full-text index.
+// The filter node is removed as the index only returns matching rows.
+//
+//	CREATE FULLTEXT INDEX foo_body_idx ON foo (body)
+//	SELECT * FROM foo WHERE body MATCH 'bar'
+//	table.Scan('foo') | rows.Filter(body MATCH 'bar') | rows.Project(*)
+//
+// becomes:
+//
+//	index.FulltextScan('foo_body_idx', 'bar') | rows.Project(*)
+func selectFulltextIndex(sctx *StreamContext, seq *table.ScanOperator) bool {
+	for _, f := range sctx.Filters {
+		m, ok := f.Expr.(*expr.MatchOperator)
+		if !ok {
+			continue
+		}
+
+		col, ok := m.LeftHand().(*expr.Column)
+		if !ok {
+			continue
+		}
+
+		// the query must not depend on the row
+		switch m.RightHand().(type) {
+		case expr.LiteralValue, expr.PositionalParam, expr.NamedParam:
+		default:
+			continue
+		}
+
+		info := sctx.Catalog.GetFulltextIndexInfo(seq.TableName, col.Name)
+		if info == nil || !seq.Hint.AllowsIndex(info.IndexName) {
+			continue
+		}
+
+		sctx.removeFilterNode(f)
+
+		s := sctx.Stream
+		s.Remove(s.First())
+		scan := index.FulltextScan(info.IndexName, m.RightHand())
+		if s.Op == nil {
+			s.Op = scan
+		} else {
+			stream.InsertBefore(s.First(), scan)
+		}
+		sctx.Stream = s
+
+		return true
+	}
+
+	return false
+}
+
+// selectTrigramIndex replaces the table scan by a trigram scan
+// if one of the filter nodes is a LIKE condition on a column with
+// a full-text index using the trigram analyzer.
+// Unlike MATCH conditions, the filter node is kept to verify
+// each row returned by the index.
+//
+//	CREATE FULLTEXT INDEX foo_body_idx ON foo (body) WITH (analyzer = 'trigram')
+//	SELECT * FROM foo WHERE body LIKE '%bar%'
+//	table.Scan('foo') | rows.Filter(body LIKE '%bar%') | rows.Project(*)
+//
+// becomes:
+//
+//	index.TrigramScan('foo_body_idx', '%bar%') | rows.Filter(body LIKE '%bar%') | rows.Project(*)
+func selectTrigramIndex(sctx *StreamContext, seq *table.ScanOperator) error {
+	for _, f := range sctx.Filters {
+		l, ok := f.Expr.(*expr.LikeOperator)
+		if !ok {
+			continue
+		}
+
+		col, ok := l.LeftHand().(*expr.Column)
+		if !ok {
+			continue
+		}
+
+		// patterns known in advance must contain at least one trigram
+		switch p := l.RightHand().(type) {
+		case expr.LiteralValue:
+			if p.Value.Type() != types.TypeText || !hasTrigrams(types.AsString(p.Value)) {
+				continue
+			}
+		case expr.PositionalParam, expr.NamedParam:
+		default:
+			continue
+		}
+
+		info, err := trigramIndexInfo(sctx, seq.TableName, col.Name)
+		if err != nil {
+			return err
+		}
+		if info == nil || !seq.Hint.AllowsIndex(info.IndexName) {
+			continue
+		}
+
+		s := sctx.Stream
+		s.Remove(s.First())
+		scan := index.TrigramScan(info.IndexName, l.RightHand())
+		if s.Op == nil {
+			s.Op = scan
+		} else {
+			stream.InsertBefore(s.First(), scan)
+		}
+		sctx.Stream = s
+
+		return nil
+	}
+
+	return nil
+}
+
+// trigramIndexInfo returns the full-text index of the column
+// using the trigram analyzer, if any.
+func trigramIndexInfo(sctx *StreamContext, tableName, column string) (*database.IndexInfo, error) {
+	for _, name := range sctx.Catalog.ListIndexes(tableName) {
+		info, err := sctx.Catalog.GetIndexInfo(name)
+		if err != nil {
+			return nil, err
+		}
+
+		if info.Fulltext && info.Analyzer == fulltext.TrigramAnalyzer && info.Columns[0] == column {
+			return info, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// hasTrigrams returns whether the LIKE pattern contains
+// at least one trigram that can be looked up in a trigram index.
+func hasTrigrams(pattern string) bool {
+	for _, l := range glob.LikeLiterals(pattern) {
+		if len(fulltext.SubstringTrigrams(l)) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,128 @@
+package planner
+
+import (
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/stream"
+	"github.com/chaisql/chai/internal/stream/rows"
+	"github.com/chaisql/chai/internal/stream/table"
+)
+
+// selectIndexMerge replaces the sequential scan by a union of scans
+// if a filter is a disjunction of conditions that can each be served
+// by an index or by the primary key:
+//
+//	SELECT * FROM foo WHERE a = 1 OR b = 2
+//	table.Scan('foo') | rows.Filter(a = 1 OR b = 2) | rows.Project(*)
+//
+// becomes:
+//
+//	table.Union('foo', index.Scan('foo_a_idx', [1]), index.Scan('foo_b_idx', [2])) | rows.Filter(a = 1 OR b = 2) | rows.Project(*)
+//
+// The union returns the rows matched by several scans only once.
+// The filter is kept, as some scans may return rows that don't match
+// their condition, i.e. scans of hash indexes.
+func (i *indexSelector) selectIndexMerge() error {
+	for _, f := range i.sctx.Filters {
+		ds := disjuncts(f.Expr)
+		if len(ds) < 2 {
+			continue
+		}
+
+		cands, err := i.disjunctionCandidates(ds)
+		if err != nil {
+			return err
+		}
+		if cands == nil {
+			continue
+		}
+
+		// if the table was analyzed, ensure the scans read
+		// a small enough fraction of the rows
+		stats, err := loadStatistics(i.sctx.Tx, i.info)
+		if err != nil {
+			return err
+		}
+		if stats != nil {
+			var n float64
+			for _, c := range cands {
+				n += stats.estimateRows(c)
+			}
+			if n > maxIndexSelectivity*float64(stats.rowCount) {
+				continue
+			}
+		}
+
+		streams := make([]*stream.Stream, len(cands))
+		for j, c := range cands {
+			streams[j] = stream.New(c.replaceRootBy[0])
+			for _, op := range c.replaceRootBy[1:] {
+				streams[j] = streams[j].Pipe(op)
+			}
+		}
+
+		s := i.sctx.Stream
+		s.Remove(s.First())
+		union := table.Union(i.tableScan.TableName, streams...)
+		if s.Op == nil {
+			s.Op = union
+		} else {
+			stream.InsertBefore(s.First(), union)
+		}
+		i.sctx.Stream = s
+
+		return nil
+	}
+
+	return nil
+}
+
+// disjunctionCandidates returns the cheapest candidate for each condition
+// of the disjunction, or nil if one of them cannot be served by an index
+// or by the primary key.
+func (i *indexSelector) disjunctionCandidates(ds []expr.Expr) ([]*candidate, error) {
+	indexes, err := i.listIndexes()
+	if err != nil {
+		return nil, err
+	}
+
+	var cands []*candidate
+	for _, d := range ds {
+		node, err := i.isFilterIndexable(rows.Filter(d))
+		if err != nil || node == nil {
+			return nil, err
+		}
+		nodes := indexableNodes{node}
+
+		var selected *candidate
+		if pk := i.info.PrimaryKey; pk != nil && i.tableScan.Hint.AllowsPrimaryKey() {
+			selected = i.associateIndexWithNodes(i.info.TableName, false, false, pk.Columns, nil, nil, pk.SortOrder, nodes)
+		}
+
+		for _, idxInfo := range indexes {
+			cand := i.associateIndex(idxInfo, nodes)
+			if cand != nil && (selected == nil || cand.Cost() < selected.Cost()) {
+				selected = cand
+			}
+		}
+
+		if selected == nil {
+			return nil, nil
+		}
+
+		cands = append(cands, selected)
+	}
+
+	return cands, nil
+}
+
+// disjuncts returns the operands of a chain of OR operators.
+func disjuncts(e expr.Expr) []expr.Expr {
+	switch t := e.(type) {
+	case expr.Parentheses:
+		return disjuncts(t.E)
+	case *expr.OrOp:
+		return append(disjuncts(t.LeftHand()), disjuncts(t.RightHand())...)
+	}
+
+	return []expr.Expr{e}
+}
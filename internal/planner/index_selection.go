@@ -1,6 +1,7 @@
 package planner
 
 import (
+	"github.com/chaisql/chai/internal/collation"
 	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/expr"
 	"github.com/chaisql/chai/internal/sql/scanner"
@@ -103,13 +104,42 @@ func SelectIndex(sctx *StreamContext) error {
 		return nil
 	}
 
+	// MATCH conditions are best served by full-text indexes
+	if selectFulltextIndex(sctx, seq) {
+		return nil
+	}
+
 	is := indexSelector{
 		tableScan: seq,
 		sctx:      sctx,
 		info:      info,
 	}
 
-	return is.selectIndex()
+	err = is.selectIndex()
+	if err != nil {
+		return err
+	}
+
+	// if no index can be used, disjunctions can be served by a union
+	// of index scans, spatial conditions by a spatial index
+	// and LIKE conditions by a trigram index
+	if sctx.Stream.First() == seq {
+		err = is.selectIndexMerge()
+		if err != nil {
+			return err
+		}
+	}
+	if sctx.Stream.First() == seq {
+		err = selectSpatialIndex(sctx, seq)
+		if err != nil {
+			return err
+		}
+	}
+	if sctx.Stream.First() == seq {
+		return selectTrigramIndex(sctx, seq)
+	}
+
+	return nil
 }
 
 // indexSelector analyses a stream and generates a plan for each of them that
@@ -154,6 +184,13 @@ func (i *indexSelector) selectIndex() error {
 		}
 	}
 
+	// statistics collected by ANALYZE, if any,
+	// are used to estimate the number of rows read by each candidate
+	stats, err := loadStatistics(i.sctx.Tx, i.info)
+	if err != nil {
+		return err
+	}
+
 	// select the cheapest plan
 	var selected *candidate
 	var cost int
@@ -164,38 +201,47 @@ func (i *indexSelector) selectIndex() error {
 		return err
 	}
 	pk := tb.PrimaryKey
-	if pk != nil {
-		selected = i.associateIndexWithNodes(tb.TableName, false, false, pk.Columns, pk.SortOrder, nodes)
+	if pk != nil && i.tableScan.Hint.AllowsPrimaryKey() {
+		// the primary key stores values as is
+		selected = i.associateIndexWithNodes(tb.TableName, false, false, pk.Columns, nil, nil, pk.SortOrder, nodes)
 		if selected != nil {
 			cost = selected.Cost()
 		}
 	}
 
-	// get all the indexes for this table and associate them
-	// with compatible candidates
-	for _, idxName := range i.sctx.Catalog.ListIndexes(i.tableScan.TableName) {
-		idxInfo, err := i.sctx.Catalog.GetIndexInfo(idxName)
-		if err != nil {
-			return err
-		}
-
-		candidate := i.associateIndexWithNodes(idxInfo.IndexName, true, idxInfo.Unique, idxInfo.Columns, idxInfo.KeySortOrder, nodes)
+	// get all the indexes for this table and associate them with compatible candidates
+	indexes, err := i.listIndexes()
+	if err != nil {
+		return err
+	}
 
-		if candidate == nil {
+	for _, idxInfo := range indexes {
+		cand := i.associateIndex(idxInfo, nodes)
+		if cand == nil {
 			continue
 		}
 
 		if selected == nil {
-			selected = candidate
+			selected = cand
 			cost = selected.Cost()
 			continue
 		}
 
-		c := candidate.Cost()
+		c := cand.Cost()
+
+		if stats != nil {
+			if ec, es := stats.estimateCost(cand), stats.estimateCost(selected); ec != es {
+				if ec < es {
+					cost = c
+					selected = cand
+				}
+				continue
+			}
+		}
 
-		if len(selected.nodes) < len(candidate.nodes) || (len(selected.nodes) == len(candidate.nodes) && c < cost) {
+		if len(selected.nodes) < len(cand.nodes) || (len(selected.nodes) == len(cand.nodes) && c < cost) {
 			cost = c
-			selected = candidate
+			selected = cand
 		}
 	}
 
@@ -203,11 +249,21 @@ func (i *indexSelector) selectIndex() error {
 		return nil
 	}
 
+	if stats != nil && stats.prefersTableScan(selected, i.tableScan.Hint) {
+		return nil
+	}
+
 	// remove the filter nodes from the tree
 	for _, f := range selected.nodes {
+		if selected.keepFilters {
+			break
+		}
+
 		switch tp := f.node.(type) {
 		case *rows.FilterOperator:
-			i.sctx.removeFilterNode(tp)
+			for _, served := range f.servedFilters() {
+				i.sctx.removeFilterNode(served)
+			}
 			if f.orderBy != nil {
 				i.sctx.removeTempTreeNodeNode(f.orderBy.node.(*rows.TempTreeSortOperator))
 			}
@@ -231,6 +287,52 @@ func (i *indexSelector) selectIndex() error {
 	return nil
 }
 
+// listIndexes returns the indexes of the table, including
+// the temporary indexes of the connection.
+func (i *indexSelector) listIndexes() ([]*database.IndexInfo, error) {
+	var indexes []*database.IndexInfo
+	for _, idxName := range i.sctx.Catalog.ListIndexes(i.tableScan.TableName) {
+		idxInfo, err := i.sctx.Catalog.GetIndexInfo(idxName)
+		if err != nil {
+			return nil, err
+		}
+
+		indexes = append(indexes, idxInfo)
+	}
+
+	return append(indexes, i.sctx.tempIndexes(i.tableScan.TableName)...), nil
+}
+
+// associateIndex returns the candidate reading from the index
+// the rows matching the nodes, or nil if the index cannot be used.
+func (i *indexSelector) associateIndex(idxInfo *database.IndexInfo, nodes indexableNodes) *candidate {
+	// full-text and spatial indexes don't store the values of the column
+	if idxInfo.Fulltext || idxInfo.Spatial {
+		return nil
+	}
+
+	if !i.tableScan.Hint.AllowsIndex(idxInfo.IndexName) {
+		return nil
+	}
+
+	// indexes store the values of collated columns as collation keys
+	collations := make([]string, len(idxInfo.Columns))
+	for j, col := range idxInfo.Columns {
+		if idxInfo.IsExpr(j) {
+			continue
+		}
+		if cc := i.info.GetColumnConstraint(col); cc != nil {
+			collations[j] = cc.Collation
+		}
+	}
+
+	if idxInfo.Hash {
+		return i.associateHashIndexWithNodes(idxInfo.IndexName, idxInfo.Columns, idxInfo.Exprs, collations, nodes)
+	}
+
+	return i.associateIndexWithNodes(idxInfo.IndexName, true, idxInfo.Unique, idxInfo.Columns, idxInfo.Exprs, collations, idxInfo.KeySortOrder, nodes)
+}
+
 func (i *indexSelector) isFilterIndexable(f *rows.FilterOperator) (*indexableNode, error) {
 	// only operators can associate this node to an index
 	op, ok := f.Expr.(expr.Operator)
@@ -245,33 +347,162 @@ func (i *indexSelector) isFilterIndexable(f *rows.FilterOperator) (*indexableNod
 
 	// determine if the operator could benefit from an index
 	ok, path, e, err := i.operatorCanUseIndex(op)
-	if !ok || err != nil {
+	if err != nil {
 		return nil, err
 	}
+	if ok {
+		return &indexableNode{
+			node:      f,
+			col:       path,
+			collation: operatorCollation(op),
+			operator:  op.Token(),
+			operand:   e,
+		}, nil
+	}
+
+	// otherwise, the operator could use an index on an expression
+	ok, path, e = exprOperatorCanUseIndex(op)
+	if !ok {
+		return nil, nil
+	}
 
-	node := indexableNode{
+	return &indexableNode{
 		node:     f,
 		col:      path,
+		isExpr:   true,
 		operator: op.Token(),
 		operand:  e,
+	}, nil
+}
+
+// exprOperatorCanUseIndex determines if the operator compares an expression
+// of the columns of the table with a literal value, in which case it can use
+// an index on that expression.
+// Since the type of the indexed values is not known in advance, only equality
+// with values that cannot be compared with other types is supported.
+// valid:   lower(a) = 'foo'
+// invalid: lower(a) > 'foo'
+// invalid: a + 1 = 10
+func exprOperatorCanUseIndex(op expr.Operator) (bool, string, expr.Expr) {
+	if op.Token() != scanner.EQ {
+		return false, "", nil
+	}
+
+	lh, rh := op.LeftHand(), op.RightHand()
+	if _, ok := lh.(expr.LiteralValue); ok {
+		lh, rh = rh, lh
+	}
+
+	v, ok := rh.(expr.LiteralValue)
+	if !ok || !isIndexableExpr(lh) {
+		return false, "", nil
+	}
+
+	switch v.Value.Type() {
+	case types.TypeText, types.TypeBlob, types.TypeBoolean:
+	default:
+		return false, "", nil
+	}
+
+	return true, lh.String(), v
+}
+
+// isIndexableExpr reports whether e is an expression
+// of the columns of the table, other than a column.
+func isIndexableExpr(e expr.Expr) bool {
+	switch e.(type) {
+	case *expr.Column, expr.LiteralValue, *expr.Collate:
+		return false
 	}
 
-	return &node, nil
+	var hasColumn bool
+	expr.Walk(e, func(e expr.Expr) bool {
+		if _, ok := e.(*expr.Column); ok {
+			hasColumn = true
+			return false
+		}
+
+		return true
+	})
+
+	return hasColumn
 }
 
 func (i *indexSelector) isTempTreeSortIndexable(n *rows.TempTreeSortOperator) *indexableNode {
-	// only columns can be associated with an index
-	col, ok := n.Expr.(*expr.Column)
+	node := sortKeyNode(n.Expr, n.Desc)
+	if node == nil {
+		return nil
+	}
+	node.node = n
+
+	// when sorting by several expressions, each of them must be indexable
+	for _, k := range n.Then {
+		then := sortKeyNode(k.Expr, k.Desc)
+		if then == nil {
+			return nil
+		}
+
+		node.then = append(node.then, then)
+	}
+
+	return node
+}
+
+// sortKeyNode returns the indexable node of a sort key,
+// or nil if it cannot be associated with an index.
+func sortKeyNode(e expr.Expr, desc bool) *indexableNode {
+	// expressions can be associated with an index on the same expression
+	if isIndexableExpr(e) {
+		return &indexableNode{
+			col:      e.String(),
+			isExpr:   true,
+			desc:     desc,
+			operator: scanner.ORDER,
+		}
+	}
+
+	// otherwise, only columns can be associated with an index
+	col, ok := unwrapCollate(e).(*expr.Column)
 	if !ok {
 		return nil
 	}
 
+	var collation string
+	if c, ok := e.(*expr.Collate); ok {
+		collation = c.Collation
+	}
+
 	return &indexableNode{
-		node:     n,
-		col:      col.Name,
-		desc:     n.Desc,
-		operator: scanner.ORDER,
+		col:       col.Name,
+		collation: collation,
+		desc:      desc,
+		operator:  scanner.ORDER,
+	}
+}
+
+// unwrapCollate returns the column a COLLATE clause applies to.
+// Other expressions are returned as is.
+func unwrapCollate(e expr.Expr) expr.Expr {
+	if c, ok := e.(*expr.Collate); ok {
+		if col, ok := c.Expr.(*expr.Column); ok {
+			return col
+		}
+	}
+
+	return e
+}
+
+// operatorCollation returns the collation used by a comparison,
+// or an empty string if values are compared bytewise.
+func operatorCollation(op expr.Operator) string {
+	if c, ok := op.LeftHand().(*expr.Collate); ok {
+		return c.Collation
+	}
+	if c, ok := op.RightHand().(*expr.Collate); ok {
+		return c.Collation
 	}
+
+	return ""
 }
 
 // for a given index, select all filter nodes that match according to the following rules:
@@ -289,14 +520,51 @@ func (i *indexSelector) isTempTreeSortIndexable(n *rows.TempTreeSortOperator) *i
 //	 -> range = {min: [3], exact: true}
 //	rows.Filter(a IN (1, 2))
 //	 -> ranges = [1], [2]
-func (i *indexSelector) associateIndexWithNodes(treeName string, isIndex bool, isUnique bool, columns []string, sortOrder tree.SortOrder, nodes indexableNodes) *candidate {
+func (i *indexSelector) associateIndexWithNodes(treeName string, isIndex bool, isUnique bool, columns []string, exprs []database.TableExpression, collations []string, sortOrder tree.SortOrder, nodes indexableNodes) *candidate {
 	found := make([]*indexableNode, 0, len(columns))
 	var desc bool
 
+	columnAt := func(j int) (coll string, isExpr bool) {
+		if collations != nil {
+			coll = collations[j]
+		}
+
+		return coll, j < len(exprs) && exprs[j] != nil
+	}
+
+	// a TempSort node sorting by several expressions can only be associated
+	// with the index if the following keys match the next indexed columns
+	// and if all of them can be read in the same direction.
+	// i.e. ORDER BY a ASC, b DESC can be served by an index on (a ASC, b DESC),
+	// or on (a DESC, b ASC) by reading it in reverse.
+	sortKeysMatch := func(n *indexableNode, j int) bool {
+		reverse := n.desc != sortOrder.IsDesc(j)
+		for k, t := range n.then {
+			c := j + k + 1
+			if c >= len(columns) {
+				return false
+			}
+
+			coll, isExpr := columnAt(c)
+			if t.col != columns[c] || t.isExpr != isExpr || !collation.Equal(t.collation, coll) {
+				return false
+			}
+
+			if (t.desc != sortOrder.IsDesc(c)) != reverse {
+				return false
+			}
+		}
+
+		return true
+	}
+
 	var hasIn bool
 	var sorter *indexableNode
-	for _, p := range columns {
-		ns := nodes.getByColumn(p)
+	var sorterCol int
+	for j, p := range columns {
+		coll, isExpr := columnAt(j)
+
+		ns := nodes.getByColumn(p, coll, isExpr)
 		if len(ns) == 0 {
 			break
 		}
@@ -304,9 +572,14 @@ func (i *indexSelector) associateIndexWithNodes(treeName string, isIndex bool, i
 		// get the filter node and the TempSort node if any
 		var filter *indexableNode
 		for i, n := range ns {
-			if n.operator == scanner.ORDER && sorter == nil {
-				sorter = ns[i]
-				desc = sorter.desc
+			if n.operator == scanner.ORDER {
+				// the ranges generated by an IN operator are read one after
+				// the other: the following columns are not sorted across them.
+				if sorter == nil && !hasIn && sortKeysMatch(n, j) {
+					sorter = ns[i]
+					sorterCol = j
+					desc = sorter.desc
+				}
 				continue
 			}
 			if filter == nil {
@@ -322,6 +595,19 @@ func (i *indexSelector) associateIndexWithNodes(treeName string, isIndex bool, i
 			break
 		}
 
+		// a lower and an upper bound of the same column
+		// can be served by a single bounded range
+		if bounded := mergeBounds(filter, ns); bounded != nil {
+			filter = bounded
+		}
+
+		// neither is the column of an IN operator, as its ranges
+		// are read in the order of the list
+		if sorter != nil && filter.operator == scanner.IN {
+			sorter = nil
+			desc = false
+		}
+
 		// if we have both a filter and a TempSort node, we can merge them
 		if filter != nil && sorter != nil {
 			filter.orderBy = sorter
@@ -359,7 +645,7 @@ func (i *indexSelector) associateIndexWithNodes(treeName string, isIndex bool, i
 		}
 
 		// in case the primary key or index is descending, we need to use a reverse the order
-		if sortOrder.IsDesc(0) {
+		if sortOrder.IsDesc(sorterCol) {
 			desc = !desc
 		}
 
@@ -390,6 +676,7 @@ func (i *indexSelector) associateIndexWithNodes(treeName string, isIndex bool, i
 
 	// in case we found an orphan sorter node and we need to assign it to the first filter node
 	// for deletion
+	orderBy := sorter != nil
 	if sorter != nil {
 		found[0].orderBy = sorter
 	}
@@ -412,8 +699,8 @@ func (i *indexSelector) associateIndexWithNodes(treeName string, isIndex bool, i
 	}
 
 	// in case the indexed path is descending, we need to reverse the order
-	if found[len(found)-1].orderBy != nil {
-		if sortOrder.IsDesc(len(found) - 1) {
+	if orderBy || found[len(found)-1].orderBy != nil {
+		if sortOrder.IsDesc(sorterCol) {
 			desc = !desc
 		}
 	}
@@ -443,6 +730,55 @@ func (i *indexSelector) associateIndexWithNodes(treeName string, isIndex bool, i
 	return &c
 }
 
+// associateHashIndexWithNodes associates a hash index with filter nodes.
+// Since hash indexes don't store values in order, they can only be used
+// if every indexed column is compared with the = or IN operator:
+//
+//	CREATE INDEX ON foo(a, b) USING HASH
+//	rows.Filter(a = 1) | rows.Filter(b IN (2, 3))
+//	 -> ranges = [1, 2], [1, 3]
+//
+// Different values can share the same hash: the filter nodes are kept.
+func (i *indexSelector) associateHashIndexWithNodes(treeName string, columns []string, exprs []database.TableExpression, collations []string, nodes indexableNodes) *candidate {
+	found := make([]*indexableNode, 0, len(columns))
+
+	var hasIn bool
+	for j, col := range columns {
+		var filter *indexableNode
+		for _, n := range nodes.getByColumn(col, collations[j], j < len(exprs) && exprs[j] != nil) {
+			if n.operator == scanner.EQ || n.operator == scanner.IN {
+				filter = n
+				break
+			}
+		}
+
+		if filter == nil {
+			return nil
+		}
+
+		if filter.operator == scanner.IN {
+			hasIn = true
+		}
+
+		found = append(found, filter)
+	}
+
+	var ranges stream.Ranges
+	if !hasIn {
+		ranges = stream.Ranges{i.buildRangeFromFilterNodes(found...)}
+	} else {
+		ranges = i.buildRangesFromFilterNodes(columns, found)
+	}
+
+	return &candidate{
+		nodes:         found,
+		replaceRootBy: []stream.Operator{index.Scan(treeName, ranges...)},
+		rangesCost:    ranges.Cost(),
+		isIndex:       true,
+		keepFilters:   true,
+	}
+}
+
 func (i *indexSelector) buildRangesFromFilterNodes(columns []string, filters []*indexableNode) stream.Ranges {
 	// build a 2 dimentional list of all expressions
 	// so that: rows.Filter(a IN (10, 11)) | rows.Filter(b = 20) | rows.Filter(c IN (30, 31))
@@ -514,7 +850,64 @@ func (i *indexSelector) buildRangeFromFilterNodes(filters ...*indexableNode) str
 	// use last filter node to determine the direction of the range
 	filter := filters[len(filters)-1]
 
-	return i.buildRangeFromOperator(filter.operator, colums, el...)
+	rng := i.buildRangeFromOperator(filter.operator, colums, el...)
+	rng.Exclusive = rng.Exclusive || filter.exclusive
+	return rng
+}
+
+// mergeBounds returns a node matching the values of the column between
+// the bound of the given node and the opposite bound found in the other nodes,
+// i.e. a > 1 AND a <= 10, or nil if there is no such bound.
+// Ranges exclude either both of their bounds or none: if only one of the
+// conditions excludes its bound, its filter must be kept.
+func mergeBounds(n *indexableNode, others []*indexableNode) *indexableNode {
+	isLower := func(tok scanner.Token) bool { return tok == scanner.GT || tok == scanner.GTE }
+	isUpper := func(tok scanner.Token) bool { return tok == scanner.LT || tok == scanner.LTE }
+
+	if !isLower(n.operator) && !isUpper(n.operator) {
+		return nil
+	}
+
+	for _, o := range others {
+		var lower, upper *indexableNode
+		switch {
+		case isLower(n.operator) && isUpper(o.operator):
+			lower, upper = n, o
+		case isUpper(n.operator) && isLower(o.operator):
+			lower, upper = o, n
+		default:
+			continue
+		}
+
+		return &indexableNode{
+			node:      lower.node,
+			col:       n.col,
+			collation: n.collation,
+			operator:  scanner.BETWEEN,
+			operand:   expr.LiteralExprList{lower.operand, upper.operand},
+			bounds:    []*indexableNode{lower, upper},
+			exclusive: lower.operator == scanner.GT && upper.operator == scanner.LT,
+		}
+	}
+
+	return nil
+}
+
+// servedFilters returns the filter operators whose condition
+// is entirely evaluated by the range built from the node.
+func (n *indexableNode) servedFilters() []*rows.FilterOperator {
+	if n.bounds == nil {
+		return []*rows.FilterOperator{n.node.(*rows.FilterOperator)}
+	}
+
+	var filters []*rows.FilterOperator
+	for _, b := range n.bounds {
+		if n.exclusive || b.operator == scanner.GTE || b.operator == scanner.LTE {
+			filters = append(filters, b.node.(*rows.FilterOperator))
+		}
+	}
+
+	return filters
 }
 
 func (i *indexSelector) buildRangeFromOperator(lastOp scanner.Token, columns []string, operands ...expr.Expr) stream.Range {
@@ -595,20 +988,40 @@ type indexableNode struct {
 	operand  expr.Expr
 	desc     bool
 
+	// if true, col is the SQL representation of an expression
+	// and the node can only be associated with an index on that expression.
+	isExpr bool
+
+	// collation used to compare or sort the column values.
+	// The node can only be associated with indexes that store
+	// values using the same collation.
+	collation string
+
 	// merged TempTreeSort node to remove
 	// from the stream
 	orderBy *indexableNode
+
+	// for TempTreeSort nodes sorting by several expressions,
+	// the keys following col, in order.
+	then []*indexableNode
+
+	// for BETWEEN nodes merging a lower and an upper bound
+	// of the column, the nodes of both bounds and whether
+	// the range excludes them.
+	bounds    []*indexableNode
+	exclusive bool
 }
 
 type indexableNodes []*indexableNode
 
-// getByColumn returns all indexable nodes for the given path.
+// getByColumn returns all indexable nodes for the given path
+// or expression using the given collation.
 // TODO(asdine): add a rule that merges nodes that point to the
 // same path.
-func (n indexableNodes) getByColumn(c string) []*indexableNode {
+func (n indexableNodes) getByColumn(c string, coll string, isExpr bool) []*indexableNode {
 	var nodes []*indexableNode
 	for _, fn := range n {
-		if fn.col == c {
+		if fn.col == c && fn.isExpr == isExpr && collation.Equal(fn.collation, coll) {
 			nodes = append(nodes, fn)
 		}
 	}
@@ -633,6 +1046,9 @@ type candidate struct {
 	isIndex bool
 	// if it's an index, does it have a unique constraint
 	isUnique bool
+	// if true, the index may return rows that don't match
+	// the filter nodes, which must be kept in the stream.
+	keepFilters bool
 }
 
 func (c *candidate) Cost() int {
@@ -669,8 +1085,8 @@ func (i *indexSelector) operatorCanUseIndex(op expr.Operator) (bool, string, exp
 		return i.betweenOperatorCanUseIndex(op)
 	}
 
-	lh := op.LeftHand()
-	rh := op.RightHand()
+	lh := unwrapCollate(op.LeftHand())
+	rh := unwrapCollate(op.RightHand())
 	lc, leftIsCol := lh.(*expr.Column)
 	rc, rightIsCol := rh.(*expr.Column)
 
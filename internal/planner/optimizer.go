@@ -1,6 +1,8 @@
 package planner
 
 import (
+	"context"
+
 	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/environment"
 	"github.com/chaisql/chai/internal/expr"
@@ -20,40 +22,55 @@ var optimizerRules = []func(sctx *StreamContext) error{
 	RemoveUnnecessaryFilterNodesRule,
 	RemoveUnnecessaryTempSortNodesRule,
 	SelectIndex,
+	HashAggregateRule,
+	LimitTempSortRule,
+	ParallelScanRule,
 }
 
 // Optimize takes a tree, applies a list of optimization rules
 // and returns an optimized tree.
 // Depending on the rule, the tree may be modified in place or
 // replaced by a new one.
-func Optimize(s *stream.Stream, catalog *database.Catalog, params []environment.Param) (*stream.Stream, error) {
+func Optimize(s *stream.Stream, tx *database.Transaction, params []environment.Param) (*stream.Stream, error) {
+	return OptimizeContext(context.Background(), s, tx, params)
+}
+
+// OptimizeContext is like Optimize but stops with the cause
+// of the cancellation of ctx if it is done before the tree is optimized.
+func OptimizeContext(ctx context.Context, s *stream.Stream, tx *database.Transaction, params []environment.Param) (*stream.Stream, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
 	if firstNode, ok := s.First().(*stream.ConcatOperator); ok {
 		// If the first operation is a concat, optimize all streams individually.
 		for i, st := range firstNode.Streams {
-			ss, err := Optimize(st, catalog, params)
+			ss, err := OptimizeContext(ctx, st, tx, params)
 			if err != nil {
 				return nil, err
 			}
 			firstNode.Streams[i] = ss
 		}
 
+		limitTempSort(s)
 		return s, nil
 	}
 
 	if firstNode, ok := s.First().(*stream.UnionOperator); ok {
 		// If the first operation is a union, optimize all streams individually.
 		for i, st := range firstNode.Streams {
-			ss, err := Optimize(st, catalog, params)
+			ss, err := OptimizeContext(ctx, st, tx, params)
 			if err != nil {
 				return nil, err
 			}
 			firstNode.Streams[i] = ss
 		}
 
+		limitTempSort(s)
 		return s, nil
 	}
 
-	return optimize(s, catalog, params)
+	return optimize(ctx, s, tx, params)
 }
 
 type StreamContext struct {
@@ -64,6 +81,10 @@ type StreamContext struct {
 	Filters       []*rows.FilterOperator
 	Projections   []*rows.ProjectOperator
 	TempTreeSorts []*rows.TempTreeSortOperator
+
+	// Transaction the stream is optimized for, if any.
+	// It gives access to the temporary indexes of the connection.
+	Tx *database.Transaction
 }
 
 func NewStreamContext(s *stream.Stream, catalog *database.Catalog) *StreamContext {
@@ -105,6 +126,16 @@ func NewStreamContext(s *stream.Stream, catalog *database.Catalog) *StreamContex
 	return &sctx
 }
 
+// tempIndexes returns the temporary indexes of the connection
+// that can be used to read the table.
+func (sctx *StreamContext) tempIndexes(tableName string) []*database.IndexInfo {
+	if sctx.Tx == nil {
+		return nil
+	}
+
+	return sctx.Tx.Connection().TempIndexes(sctx.Tx, tableName)
+}
+
 func (sctx *StreamContext) removeFilterNodeByIndex(index int) {
 	f := sctx.Filters[index]
 	sctx.Stream.Remove(f)
@@ -142,11 +173,16 @@ func (sctx *StreamContext) removeProjectionNode(index int) {
 	sctx.Projections = append(sctx.Projections[:index], sctx.Projections[index+1:]...)
 }
 
-func optimize(s *stream.Stream, catalog *database.Catalog, params []environment.Param) (*stream.Stream, error) {
-	sctx := NewStreamContext(s, catalog)
+func optimize(ctx context.Context, s *stream.Stream, tx *database.Transaction, params []environment.Param) (*stream.Stream, error) {
+	sctx := NewStreamContext(s, tx.Catalog)
+	sctx.Tx = tx
 	sctx.Params = params
 
 	for _, rule := range optimizerRules {
+		if ctx.Err() != nil {
+			return nil, context.Cause(ctx)
+		}
+
 		err := rule(sctx)
 		if err != nil {
 			return nil, err
@@ -243,6 +279,12 @@ func PrecalculateExprRule(sctx *StreamContext) error {
 			}
 		case *rows.TempTreeSortOperator:
 			t.Expr, err = precalculateExpr(sctx, t.Expr)
+			for i := range t.Then {
+				if err != nil {
+					return err
+				}
+				t.Then[i].Expr, err = precalculateExpr(sctx, t.Then[i].Expr)
+			}
 		case *path.SetOperator:
 			t.Expr, err = precalculateExpr(sctx, t.Expr)
 		case *rows.EmitOperator:
@@ -287,6 +329,13 @@ func precalculateExpr(sctx *StreamContext, e expr.Expr) (expr.Expr, error) {
 			return nil, err
 		}
 		return expr.LiteralValue{Value: v}, nil
+	case *expr.Collate:
+		inner, err := precalculateExpr(sctx, t.Expr)
+		if err != nil {
+			return nil, err
+		}
+		t.Expr = inner
+		return t, nil
 	case expr.Operator:
 		// since expr.Operator is an interface,
 		// this optimization must only be applied to
@@ -307,6 +356,16 @@ func precalculateExpr(sctx *StreamContext, e expr.Expr) (expr.Expr, error) {
 		if err != nil {
 			return nil, err
 		}
+		if isCollatedComparison(t) {
+			lh, err = precalculateCollationKey(lh)
+			if err != nil {
+				return nil, err
+			}
+			rh, err = precalculateCollationKey(rh)
+			if err != nil {
+				return nil, err
+			}
+		}
 		t.SetLeftHandExpr(lh)
 		t.SetRightHandExpr(rh)
 
@@ -344,8 +403,8 @@ func precalculateExpr(sctx *StreamContext, e expr.Expr) (expr.Expr, error) {
 				return nil, errors.Errorf("invalid input syntax for type %s: %s", tp, rh)
 			}
 
-			if tp.Def().IsIndexComparableWith(rv.Value.Type()) {
-				v, err := rv.Value.CastAs(tp)
+			if tp.Def().IsIndexComparableWith(rv.Value.Type()) || isTimestampText(tp, rv) {
+				v, err := types.CastIn(rv.Value, tp, sctx.Tx.Location())
 				if err != nil {
 					return nil, errors.Errorf("invalid input syntax for type %s: %s", tp, rh)
 				}
@@ -359,8 +418,8 @@ func precalculateExpr(sctx *StreamContext, e expr.Expr) (expr.Expr, error) {
 				return nil, errors.Errorf("invalid input syntax for type %s: %s", tp, lh)
 			}
 
-			if tp.Def().IsIndexComparableWith(lv.Value.Type()) {
-				v, err := lv.Value.CastAs(tp)
+			if tp.Def().IsIndexComparableWith(lv.Value.Type()) || isTimestampText(tp, lv) {
+				v, err := types.CastIn(lv.Value, tp, sctx.Tx.Location())
 				if err != nil {
 					return nil, errors.Errorf("invalid input syntax for type %s: %s", tp, lh)
 				}
@@ -374,6 +433,46 @@ func precalculateExpr(sctx *StreamContext, e expr.Expr) (expr.Expr, error) {
 	return e, nil
 }
 
+// isCollatedComparison reports whether op is a comparison operator
+// comparing the collation keys of its collated operands.
+func isCollatedComparison(op expr.Operator) bool {
+	if !expr.IsComparisonOperator(op) {
+		return false
+	}
+
+	switch op.Token() {
+	case scanner.EQ, scanner.NEQ, scanner.GT, scanner.GTE, scanner.LT, scanner.LTE:
+		return true
+	}
+
+	return false
+}
+
+// precalculateCollationKey replaces a literal followed by a COLLATE clause
+// by its collation key, which is the value compared.
+func precalculateCollationKey(e expr.Expr) (expr.Expr, error) {
+	c, ok := e.(*expr.Collate)
+	if !ok {
+		return e, nil
+	}
+	if _, ok := c.Expr.(expr.LiteralValue); !ok {
+		return e, nil
+	}
+
+	v, err := c.Key(&environment.Environment{})
+	if err != nil {
+		return nil, err
+	}
+
+	return expr.LiteralValue{Value: v}, nil
+}
+
+// isTimestampText returns true if the text literal is compared to a timestamp column.
+// It is converted before the comparison, to interpret it in the time zone of the database.
+func isTimestampText(tp types.Type, lit expr.LiteralValue) bool {
+	return tp == types.TypeTimestamp && lit.Value.Type() == types.TypeText
+}
+
 func CheckExprTypeRule(sctx *StreamContext) error {
 	n := sctx.Stream.Op
 	var err error
@@ -391,6 +490,12 @@ func CheckExprTypeRule(sctx *StreamContext) error {
 			}
 		case *rows.TempTreeSortOperator:
 			err = checkExprType(sctx, t.Expr)
+			for i := range t.Then {
+				if err != nil {
+					return err
+				}
+				err = checkExprType(sctx, t.Then[i].Expr)
+			}
 		case *path.SetOperator:
 			err = checkExprType(sctx, t.Expr)
 		case *rows.EmitOperator:
@@ -486,7 +591,9 @@ func RemoveUnnecessaryFilterNodesRule(sctx *StreamContext) error {
 // RemoveUnnecessaryProjection removes any project node whose
 // expression is a wildcard only.
 func RemoveUnnecessaryProjection(sctx *StreamContext) error {
-	for i, p := range sctx.Projections {
+	// iterate backwards, as removing a node shifts the following ones
+	for i := len(sctx.Projections) - 1; i >= 0; i-- {
+		p := sctx.Projections[i]
 		if len(p.Exprs) == 1 {
 			if _, ok := p.Exprs[0].(expr.Wildcard); ok {
 				sctx.removeProjectionNode(i)
@@ -497,6 +604,175 @@ func RemoveUnnecessaryProjection(sctx *StreamContext) error {
 	return nil
 }
 
+// HashAggregateRule replaces the TempSort node sorting the rows
+// of a GROUP BY by a hash aggregation, if the stream isn't already
+// sorted by an index:
+//
+//	SELECT a, COUNT(*) FROM foo GROUP BY a
+//	table.Scan('foo') | rows.TempTreeSort(a) | rows.GroupAggregate(a, COUNT(*)) | rows.Project(a, COUNT(*))
+//	becomes:
+//	table.Scan('foo') | rows.HashAggregate(a, COUNT(*)) | rows.Project(a, COUNT(*))
+//
+// If the table was analyzed and the grouped column has more distinct values
+// than groups that can be aggregated in memory, the rows are sorted instead.
+func HashAggregateRule(sctx *StreamContext) error {
+	for n := sctx.Stream.First(); n != nil; n = n.GetNext() {
+		sort, ok := n.(*rows.TempTreeSortOperator)
+		if !ok || len(sort.Then) > 0 || sort.Limit != nil {
+			continue
+		}
+
+		ga, ok := sort.GetNext().(*rows.GroupAggregateOperator)
+		if !ok || ga.E == nil || !expr.Equal(sort.Expr, ga.E) {
+			continue
+		}
+
+		groups, err := estimateGroups(sctx, ga.E)
+		if err != nil {
+			return err
+		}
+		if groups > rows.MaxHashGroups {
+			return nil
+		}
+
+		ha := rows.HashAggregate(ga.E, ga.Builders...)
+		ha.Desc = sort.Desc
+
+		prev, next := sort.GetPrev(), ga.GetNext()
+		sctx.removeTempTreeNodeNode(sort)
+		sctx.Stream.Remove(ga)
+
+		ha.SetPrev(prev)
+		if prev != nil {
+			prev.SetNext(ha)
+		}
+		ha.SetNext(next)
+		if next != nil {
+			next.SetPrev(ha)
+		} else {
+			sctx.Stream.Op = ha
+		}
+
+		return nil
+	}
+
+	return nil
+}
+
+// estimateGroups returns the number of distinct values of the grouped column
+// collected by ANALYZE, or zero if it is unknown.
+func estimateGroups(sctx *StreamContext, e expr.Expr) (int64, error) {
+	col, ok := e.(*expr.Column)
+	if !ok || sctx.TableInfo == nil {
+		return 0, nil
+	}
+
+	stats, err := loadStatistics(sctx.Tx, sctx.TableInfo)
+	if err != nil || stats == nil {
+		return 0, err
+	}
+
+	cs := stats.columns[col.Name]
+	if cs == nil {
+		return 0, nil
+	}
+
+	groups := cs.NDV
+	if cs.NullCount > 0 {
+		groups++
+	}
+
+	return groups, nil
+}
+
+// LimitTempSortRule bounds the TempSort node followed by a LIMIT clause,
+// so that it only keeps the rows that will be returned instead of
+// sorting the entire stream.
+//
+//	SELECT * FROM foo ORDER BY a LIMIT 10 OFFSET 5
+//	table.Scan('foo') | rows.TempTreeSort(a) | rows.Skip(5) | rows.Take(10)
+//	becomes:
+//	table.Scan('foo') | rows.TempTreeSort(a LIMIT 10 OFFSET 5) | rows.Skip(5) | rows.Take(10)
+//
+// Streams without TempSort nodes don't need to be modified: scans are
+// stopped as soon as the Take node has returned enough rows.
+func LimitTempSortRule(sctx *StreamContext) error {
+	limitTempSort(sctx.Stream)
+	return nil
+}
+
+func limitTempSort(s *stream.Stream) {
+	for n := s.First(); n != nil; n = n.GetNext() {
+		sort, ok := n.(*rows.TempTreeSortOperator)
+		if !ok {
+			continue
+		}
+
+		next := sort.GetNext()
+		var offset expr.Expr
+		if skip, ok := next.(*rows.SkipOperator); ok {
+			offset = skip.E
+			next = skip.GetNext()
+		}
+
+		if take, ok := next.(*rows.TakeOperator); ok {
+			sort.WithLimit(take.E, offset)
+		}
+	}
+}
+
+// ParallelScanRule replaces the sequential scan of a table read with
+// the PARALLEL clause by a parallel scan, running the filters that follow
+// it in each worker:
+//
+//	SELECT a FROM foo PARALLEL 4 WHERE b > 10
+//	table.Scan('foo') | rows.Filter(b > 10) | rows.Project(a)
+//	becomes:
+//	table.ParallelScan('foo', 4, rows.Filter(b > 10) | rows.Project(a))
+//
+// The projection is only run by the workers if it is the last operator,
+// as the next ones may read the columns of the original row.
+// Scans of read/write transactions are not modified, since their changes
+// cannot be read concurrently.
+func ParallelScanRule(sctx *StreamContext) error {
+	scan, ok := sctx.Stream.First().(*table.ScanOperator)
+	if !ok || scan.Parallelism < 2 || len(scan.Ranges) > 0 || scan.Reverse || scan.Table != nil {
+		return nil
+	}
+	if sctx.Tx == nil || sctx.Tx.Writable {
+		return nil
+	}
+
+	var ops []stream.Operator
+	for n := scan.GetNext(); n != nil; n = n.GetNext() {
+		if f, ok := n.(*rows.FilterOperator); ok {
+			ops = append(ops, f)
+			continue
+		}
+		if p, ok := n.(*rows.ProjectOperator); ok && p.GetNext() == nil {
+			ops = append(ops, p)
+		}
+		break
+	}
+	if len(ops) == 0 {
+		return nil
+	}
+
+	next := ops[len(ops)-1].GetNext()
+	ops[0].SetPrev(nil)
+	ops[len(ops)-1].SetNext(nil)
+
+	ps := table.ParallelScan(scan.TableName, scan.Parallelism, ops...)
+	if next == nil {
+		sctx.Stream.Op = ps
+	} else {
+		ps.SetNext(next)
+		next.SetPrev(ps)
+	}
+
+	return nil
+}
+
 // RemoveUnnecessaryTempSortNodesRule removes any duplicate TempSort node.
 // For each stream, there can be at most two TempSort nodes.
 // In the following case, we can remove the second TempSort node.
@@ -177,6 +177,51 @@ func TestRemoveUnnecessarySelectionNodesRule(t *testing.T) {
 	}
 }
 
+func TestRemoveUnnecessaryProjection(t *testing.T) {
+	tests := []struct {
+		name           string
+		root, expected *stream.Stream
+	}{
+		{
+			"non-wildcard projection",
+			stream.New(table.Scan("foo")).Pipe(rows.Project(parser.MustParseExpr("a"))),
+			stream.New(table.Scan("foo")).Pipe(rows.Project(parser.MustParseExpr("a"))),
+		},
+		{
+			"wildcard projection",
+			stream.New(table.Scan("foo")).Pipe(rows.Project(expr.Wildcard{})),
+			stream.New(table.Scan("foo")),
+		},
+		{
+			"several wildcard projections",
+			stream.New(table.Scan("foo")).
+				Pipe(rows.Project(expr.Wildcard{})).
+				Pipe(table.Insert("bar")).
+				Pipe(rows.Project(expr.Wildcard{})),
+			stream.New(table.Scan("foo")).Pipe(table.Insert("bar")),
+		},
+		{
+			"wildcard projection followed by another projection",
+			stream.New(table.Scan("foo")).
+				Pipe(rows.Project(expr.Wildcard{})).
+				Pipe(table.Insert("bar")).
+				Pipe(rows.Project(parser.MustParseExpr("a"))),
+			stream.New(table.Scan("foo")).
+				Pipe(table.Insert("bar")).
+				Pipe(rows.Project(parser.MustParseExpr("a"))),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sctx := planner.NewStreamContext(test.root, nil)
+			err := planner.RemoveUnnecessaryProjection(sctx)
+			require.NoError(t, err)
+			require.Equal(t, test.expected.String(), sctx.Stream.String())
+		})
+	}
+}
+
 func exprList(list ...expr.Expr) expr.LiteralExprList {
 	return expr.LiteralExprList(list)
 }
@@ -343,7 +388,7 @@ func TestSelectIndex_Simple(t *testing.T) {
 
 			sctx := planner.NewStreamContext(test.root, tx.Catalog)
 			sctx.Catalog = tx.Catalog
-			st, err := planner.Optimize(test.root, tx.Catalog, nil)
+			st, err := planner.Optimize(test.root, tx, nil)
 			// err := planner.SelectIndex(sctx)
 			require.NoError(t, err)
 			require.Equal(t, test.expected.String(), st.String())
@@ -591,7 +636,7 @@ func TestSelectIndex_Composite(t *testing.T) {
 
 			sctx := planner.NewStreamContext(test.root, tx.Catalog)
 			sctx.Catalog = tx.Catalog
-			st, err := planner.Optimize(test.root, tx.Catalog, []environment.Param{
+			st, err := planner.Optimize(test.root, tx, []environment.Param{
 				{Value: 1},
 				{Value: 2},
 			})
@@ -620,7 +665,7 @@ func TestOptimize(t *testing.T) {
 					stream.New(table.Scan("foo")).Pipe(rows.Filter(parser.MustParseExpr("c = 1 + 2"))),
 					stream.New(table.Scan("bar")).Pipe(rows.Filter(parser.MustParseExpr("d = 1 + $2"))),
 				)),
-				tx.Catalog, []environment.Param{
+				tx, []environment.Param{
 					{Name: "1", Value: 2},
 					{Name: "2", Value: 3},
 				})
@@ -655,7 +700,7 @@ func TestOptimize(t *testing.T) {
 					stream.New(table.Scan("foo")).Pipe(rows.Filter(parser.MustParseExpr("12"))),
 					stream.New(table.Scan("bar")).Pipe(rows.Filter(parser.MustParseExpr("13"))),
 				)),
-				tx.Catalog, nil)
+				tx, nil)
 
 			want := stream.New(stream.Union(
 				stream.New(stream.Concat(
@@ -690,7 +735,7 @@ func TestOptimize(t *testing.T) {
 					Pipe(rows.Filter(parser.MustParseExpr("a = 1"))).
 					Pipe(rows.Filter(parser.MustParseExpr("d = 2"))),
 			)),
-			tx.Catalog, nil)
+			tx, nil)
 
 		want := stream.New(stream.Concat(
 			stream.New(index.Scan("idx_foo_a_d", stream.Range{Min: testutil.ExprList(t, `(1, 2)`), Exact: true})),
@@ -0,0 +1,115 @@
+package planner
+
+import (
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/expr/functions"
+	"github.com/chaisql/chai/internal/stream"
+	"github.com/chaisql/chai/internal/stream/index"
+	"github.com/chaisql/chai/internal/stream/table"
+)
+
+// selectSpatialIndex replaces the table scan by a spatial index scan
+// if one of the filter nodes is a st_dwithin or st_contains condition
+// on a column with a spatial index.
+// The filter node is kept to verify each row returned by the index.
+//
+//	CREATE SPATIAL INDEX foo_loc_idx ON foo (loc)
+//	SELECT * FROM foo WHERE st_dwithin(loc, 'POINT(1 2)', 0.5)
+//	table.Scan('foo') | rows.Filter(st_dwithin(loc, 'POINT(1 2)', 0.5)) | rows.Project(*)
+//
+// becomes:
+//
+//	index.SpatialScan('foo_loc_idx', 'POINT(1 2)', 0.5) | rows.Filter(st_dwithin(loc, 'POINT(1 2)', 0.5)) | rows.Project(*)
+func selectSpatialIndex(sctx *StreamContext, seq *table.ScanOperator) error {
+	for _, f := range sctx.Filters {
+		fn, ok := f.Expr.(*functions.ScalarFunction)
+		if !ok {
+			continue
+		}
+
+		// the column and the geometry it is compared with
+		var col, geometry, distance expr.Expr
+		args := fn.Params()
+		switch fn.Name() {
+		case "st_dwithin":
+			col, geometry, distance = args[0], args[1], args[2]
+			if _, ok := col.(*expr.Column); !ok {
+				col, geometry = geometry, col
+			}
+			if !isConstantExpr(distance) {
+				continue
+			}
+		case "st_contains":
+			geometry, col = args[0], args[1]
+		default:
+			continue
+		}
+
+		c, ok := col.(*expr.Column)
+		if !ok || !isConstantExpr(geometry) {
+			continue
+		}
+
+		info, err := spatialIndexInfo(sctx, seq.TableName, c.Name)
+		if err != nil {
+			return err
+		}
+		if info == nil || !seq.Hint.AllowsIndex(info.IndexName) {
+			continue
+		}
+
+		s := sctx.Stream
+		s.Remove(s.First())
+		scan := index.SpatialScan(info.IndexName, geometry, distance)
+		if s.Op == nil {
+			s.Op = scan
+		} else {
+			stream.InsertBefore(s.First(), scan)
+		}
+		sctx.Stream = s
+
+		return nil
+	}
+
+	return nil
+}
+
+// spatialIndexInfo returns the spatial index of the column, if any.
+func spatialIndexInfo(sctx *StreamContext, tableName, column string) (*database.IndexInfo, error) {
+	for _, name := range sctx.Catalog.ListIndexes(tableName) {
+		info, err := sctx.Catalog.GetIndexInfo(name)
+		if err != nil {
+			return nil, err
+		}
+
+		if info.Spatial && info.Columns[0] == column {
+			return info, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// isConstantExpr returns whether the expression doesn't depend on the row:
+// a literal, a parameter, or a scalar function of such expressions.
+func isConstantExpr(e expr.Expr) bool {
+	switch t := e.(type) {
+	case expr.LiteralValue, expr.PositionalParam, expr.NamedParam:
+		return true
+	case *functions.ScalarFunction:
+		if len(t.Params()) == 0 {
+			return false
+		}
+
+		for _, p := range t.Params() {
+			if !isConstantExpr(p) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,132 @@
+package planner
+
+import (
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/sql/scanner"
+	"github.com/chaisql/chai/internal/stream/table"
+)
+
+// maximum fraction of the rows of a table that can be read
+// from a secondary index before a sequential scan becomes cheaper.
+const maxIndexSelectivity = 0.25
+
+// tableStatistics holds the statistics collected by ANALYZE
+// for the columns of a table.
+type tableStatistics struct {
+	rowCount int64
+	columns  map[string]*database.ColumnStatistics
+}
+
+// loadStatistics returns the statistics of the table,
+// or nil if the table hasn't been analyzed.
+func loadStatistics(tx *database.Transaction, info *database.TableInfo) (*tableStatistics, error) {
+	if tx == nil {
+		return nil, nil
+	}
+
+	var ts *tableStatistics
+	for _, cc := range info.ColumnConstraints.Ordered {
+		s, err := database.GetColumnStatistics(tx, info.TableName, cc.Column)
+		if err != nil {
+			return nil, err
+		}
+		if s == nil {
+			continue
+		}
+
+		if ts == nil {
+			ts = &tableStatistics{
+				rowCount: s.RowCount,
+				columns:  make(map[string]*database.ColumnStatistics),
+			}
+		}
+		ts.columns[cc.Column] = s
+	}
+
+	return ts, nil
+}
+
+// estimateRows returns the estimated number of rows read by a candidate,
+// assuming the values of the columns are independent.
+func (ts *tableStatistics) estimateRows(c *candidate) float64 {
+	rows := float64(ts.rowCount)
+	for _, n := range c.nodes {
+		rows *= ts.selectivity(n)
+	}
+
+	return rows
+}
+
+// selectivity returns the estimated fraction of the rows matching
+// the filter node. Nodes whose selectivity cannot be estimated
+// are assumed to match all the rows.
+func (ts *tableStatistics) selectivity(n *indexableNode) float64 {
+	s := ts.columns[n.col]
+	if s == nil || n.isExpr {
+		return 1
+	}
+
+	switch n.operator {
+	case scanner.EQ:
+		return s.EqualSelectivity()
+	case scanner.IN:
+		l, ok := n.operand.(expr.LiteralExprList)
+		if !ok {
+			return 1
+		}
+		return min(1, float64(len(l))*s.EqualSelectivity())
+	case scanner.GT, scanner.GTE:
+		if v, ok := n.operand.(expr.LiteralValue); ok {
+			return s.RangeSelectivity(v.Value, nil)
+		}
+	case scanner.LT, scanner.LTE:
+		if v, ok := n.operand.(expr.LiteralValue); ok {
+			return s.RangeSelectivity(nil, v.Value)
+		}
+	case scanner.BETWEEN:
+		l, ok := n.operand.(expr.LiteralExprList)
+		if !ok || len(l) != 2 {
+			return 1
+		}
+		lv, lok := l[0].(expr.LiteralValue)
+		rv, rok := l[1].(expr.LiteralValue)
+		if lok && rok {
+			return s.RangeSelectivity(lv.Value, rv.Value)
+		}
+	}
+
+	return 1
+}
+
+// estimateCost returns the estimated cost of a candidate: reading a row
+// from a secondary index requires a lookup in the table as well.
+func (ts *tableStatistics) estimateCost(c *candidate) float64 {
+	rows := ts.estimateRows(c)
+	if c.isIndex {
+		rows *= 2
+	}
+
+	return rows
+}
+
+// prefersTableScan returns true if the candidate reads too many rows
+// from a secondary index to be cheaper than a sequential scan.
+// Candidates used to sort the rows and indexes requested
+// with USE INDEX are always kept.
+func (ts *tableStatistics) prefersTableScan(c *candidate, hint *table.IndexHint) bool {
+	if !c.isIndex || ts.rowCount == 0 {
+		return false
+	}
+	if hint != nil && hint.Kind == table.UseIndex {
+		return false
+	}
+
+	for _, n := range c.nodes {
+		if n.operator == scanner.ORDER || n.orderBy != nil {
+			return false
+		}
+	}
+
+	return ts.estimateRows(c) > maxIndexSelectivity*float64(ts.rowCount)
+}
@@ -0,0 +1,230 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// BulkInsertBatchSize is the number of rows inserted by each transaction
+// of INSERT ... FROM READER, if InsertFromReaderStmt.BatchSize is not set.
+const BulkInsertBatchSize = 10_000
+
+var _ queryRunner = (*InsertFromReaderStmt)(nil)
+
+// InsertFromReaderStmt is a statement that inserts the rows read from
+// the io.Reader passed as parameter, as a sequence of JSON objects.
+// Outside of a transaction, the rows are inserted by several transactions,
+// each of them inserting at most BatchSize rows. If the insertion fails,
+// the rows inserted by the transactions committed before the failure are kept.
+// Within a transaction, the rows are all inserted by the transaction.
+type InsertFromReaderStmt struct {
+	// Database is the name of the attached database
+	// the table belongs to, if any.
+	Database  string
+	TableName string
+	// Reader is the parameter holding the io.Reader.
+	Reader expr.Expr
+	// Maximum number of rows inserted by each transaction.
+	// If zero, BulkInsertBatchSize is used.
+	BatchSize int
+}
+
+func (stmt *InsertFromReaderStmt) Bind(ctx *statement.Context) error {
+	return nil
+}
+
+// Prepare implements the Preparer interface.
+func (stmt *InsertFromReaderStmt) Prepare(*statement.Context) (statement.Statement, error) {
+	return stmt, nil
+}
+
+func (stmt *InsertFromReaderStmt) IsReadOnly() bool {
+	return false
+}
+
+func (stmt *InsertFromReaderStmt) Run(ctx *statement.Context) (statement.Result, error) {
+	return statement.Result{}, errors.New("cannot insert from a reader in this context")
+}
+
+func (stmt *InsertFromReaderStmt) runQuery(ctx context.Context, qctx *Context, q *Query) error {
+	r, err := stmt.reader(qctx.Params)
+	if err != nil {
+		return err
+	}
+
+	batchSize := stmt.BatchSize
+	if batchSize <= 0 {
+		batchSize = BulkInsertBatchSize
+	}
+
+	// outside of a transaction, the statement commits its own transactions
+	tx := q.tx
+	autoCommit := tx == nil
+	if !autoCommit {
+		if tx.Prepared() {
+			return errors.New("cannot run statements in a prepared transaction")
+		}
+
+		if tx.Deferred && !tx.Writable {
+			err = tx.Upgrade()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	defer func() {
+		if autoCommit && tx != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	// insert statements are prepared for each set of columns
+	stmts := make(map[string]statement.Statement)
+	var n int
+
+	dec := json.NewDecoder(r)
+	for {
+		if err := ctx.Err(); err != nil {
+			return context.Cause(ctx)
+		}
+
+		var data json.RawMessage
+		err := dec.Decode(&data)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return errors.Wrap(err, "failed to read row")
+		}
+
+		cb := row.NewColumnBuffer()
+		err = cb.UnmarshalJSON(data)
+		if err != nil {
+			return errors.Wrap(err, "failed to read row")
+		}
+
+		var columns []string
+		var params []environment.Param
+		err = cb.Iterate(func(column string, v types.Value) error {
+			columns = append(columns, column)
+			params = append(params, environment.Param{Value: v})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if tx == nil {
+			tx, err = qctx.Conn.BeginTx(&database.TxOptions{Context: ctx})
+			if err != nil {
+				return err
+			}
+		}
+
+		key := strings.Join(columns, "\x00")
+		s, ok := stmts[key]
+		if !ok {
+			s, err = stmt.prepareInsert(&statement.Context{DB: qctx.DB, Conn: qctx.Conn, Tx: tx}, columns)
+			if err != nil {
+				return err
+			}
+			stmts[key] = s
+		}
+
+		res, err := s.Run(&statement.Context{
+			Ctx:    ctx,
+			DB:     qctx.DB,
+			Conn:   qctx.Conn,
+			Tx:     tx,
+			Params: params,
+		})
+		if err != nil {
+			return err
+		}
+		err = res.Iterate(func(database.Row) error { return nil })
+		if err != nil {
+			return err
+		}
+
+		n++
+		if autoCommit && n == batchSize {
+			err = tx.Commit()
+			tx = nil
+			if err != nil {
+				return err
+			}
+			n = 0
+		}
+	}
+
+	if autoCommit && tx != nil {
+		err = tx.Commit()
+		tx = nil
+		return err
+	}
+
+	return nil
+}
+
+// reader returns the io.Reader passed as parameter.
+func (stmt *InsertFromReaderStmt) reader(params []environment.Param) (io.Reader, error) {
+	var v any
+	switch p := stmt.Reader.(type) {
+	case expr.PositionalParam:
+		if int(p) > len(params) {
+			return nil, errors.Errorf("cannot find param number %d", p)
+		}
+		v = params[p-1].Value
+	case expr.NamedParam:
+		i := -1
+		for j := range params {
+			if params[j].Name == string(p) {
+				i = j
+				break
+			}
+		}
+		if i == -1 {
+			return nil, errors.Errorf("param %s not found", string(p))
+		}
+		v = params[i].Value
+	}
+
+	r, ok := v.(io.Reader)
+	if !ok {
+		return nil, errors.Errorf("cannot insert rows from %T, expected an io.Reader", v)
+	}
+
+	return r, nil
+}
+
+// prepareInsert prepares the insertion of a row with the given columns,
+// whose values are passed as parameters.
+func (stmt *InsertFromReaderStmt) prepareInsert(ctx *statement.Context, columns []string) (statement.Statement, error) {
+	values := make(expr.LiteralExprList, len(columns))
+	for i := range values {
+		values[i] = expr.PositionalParam(i + 1)
+	}
+
+	ins := statement.NewInsertStatement()
+	ins.Database = stmt.Database
+	ins.TableName = stmt.TableName
+	ins.Columns = columns
+	ins.Values = []expr.Expr{values}
+
+	err := ins.Bind(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return ins.Prepare(ctx)
+}
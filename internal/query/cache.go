@@ -0,0 +1,171 @@
+package query
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/chaisql/chai/internal/query/statement"
+)
+
+// A Cache stores prepared queries by their SQL text and the version
+// of the catalog they were prepared against, so that repeated queries
+// are not parsed and prepared again.
+// Only the compiled streams are cached: they are still optimized
+// every time they are run, since the optimal plan depends on
+// the parameters, the statistics and the indexes of the connection.
+// It is safe for concurrent use.
+type Cache struct {
+	mu      sync.Mutex
+	size    int
+	version uint64
+	ll      *list.List
+	entries map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key   string
+	query Query
+}
+
+// NewCache creates a cache holding at most size queries.
+func NewCache(size int) *Cache {
+	return &Cache{
+		size:    size,
+		ll:      list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Get returns the query prepared against the given catalog version, if any.
+func (c *Cache) Get(sql string, version uint64) (Query, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if version != c.version {
+		return Query{}, false
+	}
+
+	e, ok := c.entries[normalizeQuery(sql)]
+	if !ok {
+		return Query{}, false
+	}
+
+	c.ll.MoveToFront(e)
+	return e.Value.(*cacheEntry).query, true
+}
+
+// Put stores a query prepared against the given catalog version.
+// Queries that cannot be shared between executions are ignored.
+// Storing a query prepared against a newer version invalidates
+// all the queries prepared against older versions.
+func (c *Cache) Put(sql string, version uint64, q Query) {
+	if !q.cacheable() {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if version < c.version {
+		return
+	}
+	if version > c.version {
+		c.ll.Init()
+		clear(c.entries)
+		c.version = version
+	}
+
+	key := normalizeQuery(sql)
+	if e, ok := c.entries[key]; ok {
+		e.Value.(*cacheEntry).query = q
+		c.ll.MoveToFront(e)
+		return
+	}
+
+	c.entries[key] = c.ll.PushFront(&cacheEntry{key: key, query: q})
+
+	if c.ll.Len() > c.size {
+		e := c.ll.Back()
+		c.ll.Remove(e)
+		delete(c.entries, e.Value.(*cacheEntry).key)
+	}
+}
+
+// Clear removes all the queries from the cache.
+func (c *Cache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ll.Init()
+	clear(c.entries)
+}
+
+// Len returns the number of queries in the cache.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.ll.Len()
+}
+
+// cacheable returns whether all the statements of the query
+// were compiled into streams that can be run several times.
+// Other statements, i.e. DDL or transaction control statements,
+// are parsed again every time.
+func (q Query) cacheable() bool {
+	if len(q.Statements) == 0 {
+		return false
+	}
+
+	for _, stmt := range q.Statements {
+		s, ok := stmt.(*statement.PreparedStreamStmt)
+		if !ok || s.Database != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// normalizeQuery collapses the whitespace of a query found outside
+// of quoted strings and identifiers, so that queries differing only
+// by their formatting share the same entry.
+// Queries containing comments are returned as is.
+func normalizeQuery(q string) string {
+	var sb strings.Builder
+	var quote, prev rune
+	var space, escaped bool
+
+	for _, r := range strings.TrimSpace(q) {
+		switch {
+		case quote != 0:
+			switch {
+			case escaped:
+				escaped = false
+			case r == '\\':
+				escaped = true
+			case r == quote:
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case (r == '-' && prev == '-') || (r == '*' && prev == '/'):
+			return q
+		case unicode.IsSpace(r):
+			space = true
+			prev = r
+			continue
+		}
+
+		if space {
+			sb.WriteByte(' ')
+			space = false
+		}
+		sb.WriteRune(r)
+		prev = r
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,89 @@
+package query_test
+
+import (
+	"testing"
+
+	"github.com/chaisql/chai/internal/query"
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/stream"
+	"github.com/chaisql/chai/internal/stream/table"
+	"github.com/stretchr/testify/require"
+)
+
+func newStreamQuery() query.Query {
+	return query.New(&statement.PreparedStreamStmt{
+		Stream:   stream.New(table.Scan("test")),
+		ReadOnly: true,
+	})
+}
+
+func TestCache(t *testing.T) {
+	t.Run("Get", func(t *testing.T) {
+		c := query.NewCache(10)
+		q := newStreamQuery()
+		c.Put("SELECT * FROM test", 1, q)
+
+		got, ok := c.Get("SELECT * FROM test", 1)
+		require.True(t, ok)
+		require.Equal(t, q, got)
+
+		// whitespace outside of quotes is ignored
+		_, ok = c.Get("  SELECT *\n\tFROM   test ", 1)
+		require.True(t, ok)
+
+		_, ok = c.Get("SELECT * FROM other", 1)
+		require.False(t, ok)
+
+		// other versions of the catalog
+		_, ok = c.Get("SELECT * FROM test", 2)
+		require.False(t, ok)
+	})
+
+	t.Run("Quotes and comments", func(t *testing.T) {
+		c := query.NewCache(10)
+		c.Put("SELECT * FROM test WHERE a = 'a  b'", 1, newStreamQuery())
+		c.Put("SELECT * FROM test -- comment\n, foo", 1, newStreamQuery())
+
+		_, ok := c.Get("SELECT * FROM test WHERE a = 'a b'", 1)
+		require.False(t, ok)
+		_, ok = c.Get(`SELECT * FROM test WHERE a = 'a  b'`, 1)
+		require.True(t, ok)
+
+		_, ok = c.Get("SELECT * FROM test -- comment , foo", 1)
+		require.False(t, ok)
+	})
+
+	t.Run("Invalidation", func(t *testing.T) {
+		c := query.NewCache(10)
+		c.Put("SELECT 1", 1, newStreamQuery())
+		c.Put("SELECT 2", 2, newStreamQuery())
+		require.Equal(t, 1, c.Len())
+
+		// queries prepared against older versions are ignored
+		c.Put("SELECT 3", 1, newStreamQuery())
+		require.Equal(t, 1, c.Len())
+		_, ok := c.Get("SELECT 1", 1)
+		require.False(t, ok)
+	})
+
+	t.Run("Eviction", func(t *testing.T) {
+		c := query.NewCache(2)
+		c.Put("SELECT 1", 1, newStreamQuery())
+		c.Put("SELECT 2", 1, newStreamQuery())
+		_, ok := c.Get("SELECT 1", 1)
+		require.True(t, ok)
+		c.Put("SELECT 3", 1, newStreamQuery())
+
+		require.Equal(t, 2, c.Len())
+		_, ok = c.Get("SELECT 2", 1)
+		require.False(t, ok)
+		_, ok = c.Get("SELECT 1", 1)
+		require.True(t, ok)
+	})
+
+	t.Run("Not cacheable", func(t *testing.T) {
+		c := query.NewCache(10)
+		c.Put("BEGIN", 1, query.New(&statement.AnalyzeStmt{}))
+		require.Equal(t, 0, c.Len())
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/environment"
 	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/cockroachdb/errors"
 )
 
 // A Query can execute statements against the database. It can read or write data
@@ -27,6 +28,8 @@ type Context struct {
 	DB     *database.Database
 	Conn   *database.Connection
 	Params []environment.Param
+	// SQL is the text of the query, listed with the running queries.
+	SQL string
 }
 
 func (c *Context) GetTx() *database.Transaction {
@@ -91,32 +94,43 @@ func (q *Query) Prepare(context *Context) error {
 }
 
 // Run executes all the statements in their own transaction and returns the last result.
+// The query is listed with the running queries of the database until the result is closed,
+// and is cancelled if it runs for longer than the statement timeout of the connection.
 func (q Query) Run(context *Context) (*statement.Result, error) {
+	ctx, done := context.Conn.StartQuery(context.Ctx, context.SQL)
+
+	res, err := q.run(context, ctx)
+	if err != nil {
+		done()
+		return nil, err
+	}
+
+	res.Done = done
+	return res, nil
+}
+
+func (q Query) run(qctx *Context, ctx context.Context) (*statement.Result, error) {
 	var res statement.Result
 	var err error
 
-	q.tx = context.GetTx()
+	q.tx = qctx.GetTx()
 	if q.tx == nil {
 		q.autoCommit = true
 	}
 
-	ctx := context.Ctx
-
 	for i, stmt := range q.Statements {
-		if ctx != nil {
-			select {
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			default:
-			}
+		select {
+		case <-ctx.Done():
+			return nil, context.Cause(ctx)
+		default:
 		}
 		// reinitialize the result
 		res = statement.Result{}
 
 		if qa, ok := stmt.(queryAlterer); ok {
-			err = qa.alterQuery(context.Conn, &q)
+			err = qa.alterQuery(qctx.Conn, &q)
 			if err != nil {
-				if tx := context.GetTx(); tx != nil {
+				if tx := qctx.GetTx(); tx != nil {
 					_ = tx.Rollback()
 				}
 				return nil, err
@@ -125,8 +139,17 @@ func (q Query) Run(context *Context) (*statement.Result, error) {
 			continue
 		}
 
+		if qr, ok := stmt.(queryRunner); ok {
+			err = qr.runQuery(ctx, qctx, &q)
+			if err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
 		if q.tx == nil {
-			q.tx, err = context.Conn.BeginTx(&database.TxOptions{
+			q.tx, err = qctx.Conn.BeginTx(&database.TxOptions{
 				ReadOnly: stmt.IsReadOnly(),
 			})
 			if err != nil {
@@ -134,11 +157,24 @@ func (q Query) Run(context *Context) (*statement.Result, error) {
 			}
 		}
 
+		if q.tx.Prepared() {
+			return nil, errors.New("cannot run statements in a prepared transaction")
+		}
+
+		// the first write of a deferred transaction acquires the write lock
+		if q.tx.Deferred && !q.tx.Writable && !stmt.IsReadOnly() {
+			err = q.tx.Upgrade()
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		res, err = stmt.Run(&statement.Context{
-			DB:     context.DB,
-			Conn:   context.Conn,
+			Ctx:    ctx,
+			DB:     qctx.DB,
+			Conn:   qctx.Conn,
 			Tx:     q.tx,
-			Params: context.Params,
+			Params: qctx.Params,
 		})
 		if err != nil {
 			if q.autoCommit {
@@ -192,3 +228,9 @@ func (q Query) Run(context *Context) (*statement.Result, error) {
 type queryAlterer interface {
 	alterQuery(conn *database.Connection, q *Query) error
 }
+
+// A queryRunner is a statement that runs in the transaction of the query,
+// if any, or in transactions of its own.
+type queryRunner interface {
+	runQuery(ctx context.Context, qctx *Context, q *Query) error
+}
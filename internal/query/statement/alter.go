@@ -10,6 +10,7 @@ import (
 )
 
 var _ Statement = (*AlterTableRenameStmt)(nil)
+var _ Statement = (*AlterTableSwapStmt)(nil)
 var _ Statement = (*AlterTableAddColumnStmt)(nil)
 
 // AlterTableRenameStmt is a DSL that allows creating a full ALTER TABLE query.
@@ -45,9 +46,59 @@ func (stmt *AlterTableRenameStmt) Run(ctx *Context) (Result, error) {
 	}
 
 	err := ctx.Tx.CatalogWriter().RenameTable(ctx.Tx, stmt.TableName, stmt.NewTableName)
+	if err != nil {
+		return res, err
+	}
+
+	// statistics are stored by table name, they will be
+	// collected again by the next ANALYZE
+	err = database.DeleteTableStatistics(ctx.Tx, stmt.TableName)
 	return res, err
 }
 
+// AlterTableSwapStmt exchanges the names of two tables.
+type AlterTableSwapStmt struct {
+	TableName      string
+	OtherTableName string
+}
+
+func (stmt *AlterTableSwapStmt) Bind(ctx *Context) error {
+	return nil
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt *AlterTableSwapStmt) IsReadOnly() bool {
+	return false
+}
+
+// Run runs the ALTER TABLE SWAP WITH statement in the given transaction.
+// It implements the Statement interface.
+func (stmt *AlterTableSwapStmt) Run(ctx *Context) (Result, error) {
+	var res Result
+
+	if stmt.TableName == "" || stmt.OtherTableName == "" {
+		return res, errors.New("missing table name")
+	}
+
+	if stmt.TableName == stmt.OtherTableName {
+		return res, errors.New("cannot swap a table with itself")
+	}
+
+	err := ctx.Tx.CatalogWriter().SwapTables(ctx.Tx, stmt.TableName, stmt.OtherTableName)
+	if err != nil {
+		return res, err
+	}
+
+	for _, name := range []string{stmt.TableName, stmt.OtherTableName} {
+		err = database.DeleteTableStatistics(ctx.Tx, name)
+		if err != nil {
+			return res, err
+		}
+	}
+
+	return res, nil
+}
+
 type AlterTableAddColumnStmt struct {
 	TableName        string
 	ColumnConstraint *database.ColumnConstraint
@@ -0,0 +1,54 @@
+package statement
+
+import (
+	"strings"
+
+	"github.com/chaisql/chai/internal/database"
+)
+
+var _ Statement = (*AnalyzeStmt)(nil)
+
+// AnalyzeStmt is a Statement that collects the statistics
+// of a table, or of all the tables if TableName is empty.
+// These statistics are used by the planner to choose between
+// the indexes of a table.
+type AnalyzeStmt struct {
+	TableName string
+}
+
+func (stmt *AnalyzeStmt) Bind(ctx *Context) error {
+	return nil
+}
+
+// Run samples the rows of the tables and replaces their statistics.
+func (stmt *AnalyzeStmt) Run(ctx *Context) (Result, error) {
+	if stmt.TableName != "" {
+		return Result{}, database.AnalyzeTable(ctx.Tx, stmt.TableName)
+	}
+
+	for _, name := range ctx.Tx.Catalog.Cache.ListObjects(database.RelationTableType) {
+		if strings.HasPrefix(name, database.InternalPrefix) {
+			continue
+		}
+
+		info, err := ctx.Tx.Catalog.GetTableInfo(name)
+		if err != nil {
+			return Result{}, err
+		}
+		if info.ReadOnly {
+			continue
+		}
+
+		err = database.AnalyzeTable(ctx.Tx, name)
+		if err != nil {
+			return Result{}, err
+		}
+	}
+
+	return Result{}, nil
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt *AnalyzeStmt) IsReadOnly() bool {
+	return false
+}
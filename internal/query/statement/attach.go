@@ -0,0 +1,59 @@
+package statement
+
+import (
+	"github.com/cockroachdb/errors"
+)
+
+var (
+	_ Statement = (*AttachStmt)(nil)
+	_ Statement = (*DetachStmt)(nil)
+)
+
+// AttachStmt is a Statement that attaches a database file
+// to the database under a name.
+type AttachStmt struct {
+	Path string
+	Name string
+}
+
+func (stmt *AttachStmt) Bind(ctx *Context) error {
+	return nil
+}
+
+// Run opens the database file and attaches it.
+func (stmt *AttachStmt) Run(ctx *Context) (Result, error) {
+	return Result{}, ctx.DB.Attach(stmt.Name, stmt.Path)
+}
+
+// IsReadOnly returns true. Attaching a database doesn't modify the
+// main database.
+func (stmt *AttachStmt) IsReadOnly() bool {
+	return true
+}
+
+// DetachStmt is a Statement that detaches a database
+// attached with ATTACH.
+type DetachStmt struct {
+	Name string
+}
+
+func (stmt *DetachStmt) Bind(ctx *Context) error {
+	return nil
+}
+
+// Run detaches the database. It waits for the transactions of other
+// connections using it to complete and returns an error
+// if the current transaction uses it.
+func (stmt *DetachStmt) Run(ctx *Context) (Result, error) {
+	if ctx.Tx.UsesAttached(stmt.Name) {
+		return Result{}, errors.Errorf("cannot detach database %q: it is used by the current transaction", stmt.Name)
+	}
+
+	return Result{}, ctx.DB.Detach(stmt.Name)
+}
+
+// IsReadOnly returns true. Detaching a database doesn't modify the
+// main database.
+func (stmt *DetachStmt) IsReadOnly() bool {
+	return true
+}
@@ -0,0 +1,24 @@
+package statement
+
+var _ Statement = (*BackupStmt)(nil)
+
+// BackupStmt is a Statement that writes a consistent copy
+// of the database to a directory.
+type BackupStmt struct {
+	Path string
+}
+
+func (stmt *BackupStmt) Bind(ctx *Context) error {
+	return nil
+}
+
+// Run writes the backup. Only the changes committed
+// before the statement is run are part of the backup.
+func (stmt *BackupStmt) Run(ctx *Context) (Result, error) {
+	return Result{}, ctx.DB.BackupTo(ctx.Ctx, stmt.Path)
+}
+
+// IsReadOnly returns true. Backing up a database doesn't modify it.
+func (stmt *BackupStmt) IsReadOnly() bool {
+	return true
+}
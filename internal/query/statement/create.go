@@ -8,6 +8,7 @@ import (
 	"github.com/chaisql/chai/internal/stream"
 	"github.com/chaisql/chai/internal/stream/index"
 	"github.com/chaisql/chai/internal/stream/table"
+	"github.com/cockroachdb/errors"
 )
 
 var _ Statement = (*CreateTableStmt)(nil)
@@ -85,11 +86,16 @@ func (stmt *CreateTableStmt) Run(ctx *Context) (Result, error) {
 type CreateIndexStmt struct {
 	IfNotExists bool
 	Info        database.IndexInfo
+
+	// If set, the index is only visible to the current connection
+	// and is kept in memory until the connection is closed.
+	Temporary bool
 }
 
-// IsReadOnly always returns false. It implements the Statement interface.
+// IsReadOnly returns true for temporary indexes, which are not stored in the database.
+// It implements the Statement interface.
 func (stmt *CreateIndexStmt) IsReadOnly() bool {
-	return false
+	return stmt.Temporary
 }
 
 func (stmt *CreateIndexStmt) Bind(ctx *Context) error {
@@ -101,6 +107,10 @@ func (stmt *CreateIndexStmt) Bind(ctx *Context) error {
 func (stmt *CreateIndexStmt) Run(ctx *Context) (Result, error) {
 	var res Result
 
+	if stmt.Temporary {
+		return res, stmt.createTempIndex(ctx)
+	}
+
 	_, err := ctx.Tx.CatalogWriter().CreateIndex(ctx.Tx, &stmt.Info)
 	if stmt.IfNotExists {
 		if errs.IsAlreadyExistsError(err) {
@@ -123,6 +133,22 @@ func (stmt *CreateIndexStmt) Run(ctx *Context) (Result, error) {
 	return ss.Run(ctx)
 }
 
+// createTempIndex declares the index on the connection.
+// It will be built the first time a query uses it.
+func (stmt *CreateIndexStmt) createTempIndex(ctx *Context) error {
+	if ctx.Conn == nil {
+		return errors.New("temporary indexes require a connection")
+	}
+
+	info := stmt.Info
+	err := ctx.Conn.CreateTempIndex(ctx.Tx, &info)
+	if stmt.IfNotExists && errs.IsAlreadyExistsError(err) {
+		return nil
+	}
+
+	return err
+}
+
 // CreateSequenceStmt represents a parsed CREATE SEQUENCE statement.
 type CreateSequenceStmt struct {
 	IfNotExists bool
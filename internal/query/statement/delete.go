@@ -15,12 +15,22 @@ var _ Statement = (*DeleteStmt)(nil)
 type DeleteStmt struct {
 	basePreparedStatement
 
+	// Database is the name of the attached database
+	// the table belongs to, if any.
+	Database         string
 	TableName        string
 	WhereExpr        expr.Expr
 	OffsetExpr       expr.Expr
-	OrderBy          *expr.Column
+	OrderBy          expr.Expr
 	LimitExpr        expr.Expr
 	OrderByDirection scanner.Token
+	// Collation used to sort TEXT values, if any.
+	OrderByCollation string
+	// Additional ORDER BY terms, used to sort rows
+	// that are equal on the previous ones.
+	OrderByThen []OrderByTerm
+	// IndexHint restricts the indexes used to read the table, if any.
+	IndexHint *table.IndexHint
 }
 
 func NewDeleteStatement() *DeleteStmt {
@@ -35,7 +45,12 @@ func NewDeleteStatement() *DeleteStmt {
 }
 
 func (stmt *DeleteStmt) Bind(ctx *Context) error {
-	err := BindExpr(ctx, stmt.TableName, stmt.WhereExpr)
+	ctx, err := ctx.forDatabase(stmt.Database)
+	if err != nil {
+		return err
+	}
+
+	err = BindExpr(ctx, stmt.TableName, stmt.WhereExpr)
 	if err != nil {
 		return err
 	}
@@ -50,6 +65,13 @@ func (stmt *DeleteStmt) Bind(ctx *Context) error {
 		return err
 	}
 
+	for _, t := range stmt.OrderByThen {
+		err = BindExpr(ctx, stmt.TableName, t.Expr)
+		if err != nil {
+			return err
+		}
+	}
+
 	err = BindExpr(ctx, stmt.TableName, stmt.LimitExpr)
 	if err != nil {
 		return err
@@ -58,19 +80,32 @@ func (stmt *DeleteStmt) Bind(ctx *Context) error {
 	return nil
 }
 
-func (stmt *DeleteStmt) Prepare(c *Context) (Statement, error) {
-	s := stream.New(table.Scan(stmt.TableName))
+func (stmt *DeleteStmt) Prepare(ctx *Context) (Statement, error) {
+	c, err := ctx.forDatabase(stmt.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	err = stmt.IndexHint.Validate(c.Tx, stmt.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	scan := table.Scan(stmt.TableName)
+	scan.Hint = stmt.IndexHint
+	s := stream.New(scan)
 
 	if stmt.WhereExpr != nil {
 		s = s.Pipe(rows.Filter(stmt.WhereExpr))
 	}
 
 	if stmt.OrderBy != nil {
-		if stmt.OrderByDirection == scanner.DESC {
-			s = s.Pipe(rows.TempTreeSortReverse(stmt.OrderBy))
-		} else {
-			s = s.Pipe(rows.TempTreeSort(stmt.OrderBy))
+		sort, err := orderBySort(c, OrderByTerm{Expr: stmt.OrderBy, Direction: stmt.OrderByDirection, Collation: stmt.OrderByCollation}, stmt.OrderByThen)
+		if err != nil {
+			return nil, err
 		}
+
+		s = s.Pipe(sort)
 	}
 
 	if stmt.OffsetExpr != nil {
@@ -93,7 +128,8 @@ func (stmt *DeleteStmt) Prepare(c *Context) (Statement, error) {
 	st := StreamStmt{
 		Stream:   s,
 		ReadOnly: false,
+		Database: stmt.Database,
 	}
 
-	return st.Prepare(c)
+	return st.Prepare(ctx)
 }
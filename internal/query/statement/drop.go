@@ -3,6 +3,7 @@ package statement
 import (
 	"fmt"
 
+	"github.com/chaisql/chai/internal/database"
 	errs "github.com/chaisql/chai/internal/errors"
 	"github.com/cockroachdb/errors"
 )
@@ -57,6 +58,7 @@ func (stmt *DropTableStmt) Run(ctx *Context) (Result, error) {
 		}
 	}
 
+	err = database.DeleteTableStatistics(ctx.Tx, stmt.TableName)
 	return res, err
 }
 
@@ -84,6 +86,14 @@ func (stmt *DropIndexStmt) Run(ctx *Context) (Result, error) {
 		return res, errors.New("missing index name")
 	}
 
+	// temporary indexes are not stored in the catalog
+	if ctx.Conn != nil {
+		err := ctx.Conn.DropTempIndex(stmt.IndexName)
+		if !errs.IsNotFoundError(err) {
+			return res, err
+		}
+	}
+
 	err := ctx.Tx.CatalogWriter().DropIndex(ctx.Tx, stmt.IndexName)
 	if errs.IsNotFoundError(err) && stmt.IfExists {
 		err = nil
@@ -41,8 +41,13 @@ func (stmt *ExplainStmt) Run(ctx *Context) (Result, error) {
 		return Result{}, errors.New("EXPLAIN only works on INSERT, SELECT, UPDATE AND DELETE statements")
 	}
 
+	dbCtx, err := ctx.forDatabase(s.Database)
+	if err != nil {
+		return Result{}, err
+	}
+
 	// Optimize the stream.
-	s.Stream, err = planner.Optimize(s.Stream, ctx.Tx.Catalog, ctx.Params)
+	s.Stream, err = planner.OptimizeContext(ctx.Ctx, s.Stream, dbCtx.Tx, ctx.Params)
 	if err != nil {
 		return Result{}, err
 	}
@@ -26,11 +26,11 @@ func TestExplainStmt(t *testing.T) {
 		{"EXPLAIN SELECT a + 1 FROM test WHERE a > 10", false, `"index.Scan(\"idx_a\", [{\"min\": (10), \"exclusive\": true}]) | rows.Project(a + 1)"`},
 		{"EXPLAIN SELECT a + 1 FROM test WHERE x = 10 AND y > 5", false, `"index.Scan(\"idx_x_y\", [{\"min\": (10, 5), \"exclusive\": true}]) | rows.Project(a + 1)"`},
 		{"EXPLAIN SELECT a + 1 FROM test WHERE a > 10 AND b > 20 AND c > 30", false, `"index.Scan(\"idx_b\", [{\"min\": (20), \"exclusive\": true}]) | rows.Filter(a > 10) | rows.Filter(c > 30) | rows.Project(a + 1)"`},
-		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 30 ORDER BY d LIMIT 10 OFFSET 20", false, `"table.Scan(\"test\") | rows.Filter(c > 30) | rows.Project(a + 1) | rows.TempTreeSort(d) | rows.Skip(20) | rows.Take(10)"`},
-		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 30 ORDER BY d DESC LIMIT 10 OFFSET 20", false, `"table.Scan(\"test\") | rows.Filter(c > 30) | rows.Project(a + 1) | rows.TempTreeSortReverse(d) | rows.Skip(20) | rows.Take(10)"`},
+		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 30 ORDER BY d LIMIT 10 OFFSET 20", false, `"table.Scan(\"test\") | rows.Filter(c > 30) | rows.Project(a + 1) | rows.TempTreeSort(d LIMIT 10 OFFSET 20) | rows.Skip(20) | rows.Take(10)"`},
+		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 30 ORDER BY d DESC LIMIT 10 OFFSET 20", false, `"table.Scan(\"test\") | rows.Filter(c > 30) | rows.Project(a + 1) | rows.TempTreeSortReverse(d LIMIT 10 OFFSET 20) | rows.Skip(20) | rows.Take(10)"`},
 		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 30 ORDER BY a DESC LIMIT 10 OFFSET 20", false, `"index.ScanReverse(\"idx_a\") | rows.Filter(c > 30) | rows.Project(a + 1) | rows.Skip(20) | rows.Take(10)"`},
 		{"EXPLAIN SELECT a FROM test WHERE c > 30 GROUP BY a ORDER BY a DESC LIMIT 10 OFFSET 20", false, `"index.ScanReverse(\"idx_a\") | rows.Filter(c > 30) | rows.GroupAggregate(a) | rows.Project(a) | rows.Skip(20) | rows.Take(10)"`},
-		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 30 GROUP BY a + 1 ORDER BY a DESC LIMIT 10 OFFSET 20", false, `"table.Scan(\"test\") | rows.Filter(c > 30) | rows.TempTreeSort(a + 1) | rows.GroupAggregate(a + 1) | rows.Project(a + 1) | rows.TempTreeSortReverse(a) | rows.Skip(20) | rows.Take(10)"`},
+		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 30 GROUP BY a + 1 ORDER BY a DESC LIMIT 10 OFFSET 20", false, `"table.Scan(\"test\") | rows.Filter(c > 30) | rows.HashAggregate(a + 1) | rows.Project(a + 1) | rows.TempTreeSortReverse(a LIMIT 10 OFFSET 20) | rows.Skip(20) | rows.Take(10)"`},
 		{"EXPLAIN UPDATE test SET a = 10", false, `"table.Scan(\"test\") | paths.Set(a, 10) | table.Validate(\"test\") | index.Delete(\"idx_a\") | index.Delete(\"idx_b\") | index.Delete(\"idx_x_y\") | table.Replace(\"test\") | index.Insert(\"idx_a\") | index.Validate(\"idx_b\") | index.Insert(\"idx_b\") | index.Insert(\"idx_x_y\") | discard()"`},
 		{"EXPLAIN UPDATE test SET a = 10 WHERE c > 10", false, `"table.Scan(\"test\") | rows.Filter(c > 10) | paths.Set(a, 10) | table.Validate(\"test\") | index.Delete(\"idx_a\") | index.Delete(\"idx_b\") | index.Delete(\"idx_x_y\") | table.Replace(\"test\") | index.Insert(\"idx_a\") | index.Validate(\"idx_b\") | index.Insert(\"idx_b\") | index.Insert(\"idx_x_y\") | discard()"`},
 		{"EXPLAIN UPDATE test SET a = 10 WHERE a > 10", false, `"index.Scan(\"idx_a\", [{\"min\": (10), \"exclusive\": true}]) | paths.Set(a, 10) | table.Validate(\"test\") | index.Delete(\"idx_a\") | index.Delete(\"idx_b\") | index.Delete(\"idx_x_y\") | table.Replace(\"test\") | index.Insert(\"idx_a\") | index.Validate(\"idx_b\") | index.Insert(\"idx_b\") | index.Insert(\"idx_x_y\") | discard()"`},
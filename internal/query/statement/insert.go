@@ -17,6 +17,9 @@ var _ Statement = (*InsertStmt)(nil)
 type InsertStmt struct {
 	basePreparedStatement
 
+	// Database is the name of the attached database
+	// the table belongs to, if any.
+	Database   string
 	TableName  string
 	Values     []expr.Expr
 	Columns    []string
@@ -37,13 +40,7 @@ func NewInsertStatement() *InsertStmt {
 }
 
 func (stmt *InsertStmt) Bind(ctx *Context) error {
-	for i := range stmt.Values {
-		err := BindExpr(ctx, stmt.TableName, stmt.Values[i])
-		if err != nil {
-			return err
-		}
-	}
-
+	// the select statement switches to its own database
 	if stmt.SelectStmt != nil {
 		if s, ok := stmt.SelectStmt.(Statement); ok {
 			err := s.Bind(ctx)
@@ -53,6 +50,18 @@ func (stmt *InsertStmt) Bind(ctx *Context) error {
 		}
 	}
 
+	ctx, err := ctx.forDatabase(stmt.Database)
+	if err != nil {
+		return err
+	}
+
+	for i := range stmt.Values {
+		err := BindExpr(ctx, stmt.TableName, stmt.Values[i])
+		if err != nil {
+			return err
+		}
+	}
+
 	for i := range stmt.Returning {
 		err := BindExpr(ctx, stmt.TableName, stmt.Returning[i])
 		if err != nil {
@@ -63,7 +72,12 @@ func (stmt *InsertStmt) Bind(ctx *Context) error {
 	return nil
 }
 
-func (stmt *InsertStmt) Prepare(c *Context) (Statement, error) {
+func (stmt *InsertStmt) Prepare(ctx *Context) (Statement, error) {
+	c, err := ctx.forDatabase(stmt.Database)
+	if err != nil {
+		return nil, err
+	}
+
 	var s *stream.Stream
 
 	var columns []string
@@ -125,11 +139,15 @@ func (stmt *InsertStmt) Prepare(c *Context) (Statement, error) {
 
 		s = stream.New(rows.Emit(columns, rowList...))
 	} else {
-		selectStream, err := stmt.SelectStmt.Prepare(c)
+		selectStream, err := stmt.SelectStmt.Prepare(ctx)
 		if err != nil {
 			return nil, err
 		}
 
+		if selectStream.(*PreparedStreamStmt).Database != stmt.Database {
+			return nil, errors.New("cannot read and write tables of different databases in the same statement")
+		}
+
 		s = selectStream.(*PreparedStreamStmt).Stream
 
 		// ensure we are not reading and writing to the same table.
@@ -185,7 +203,8 @@ func (stmt *InsertStmt) Prepare(c *Context) (Statement, error) {
 	st := StreamStmt{
 		Stream:   s,
 		ReadOnly: false,
+		Database: stmt.Database,
 	}
 
-	return st.Prepare(c)
+	return st.Prepare(ctx)
 }
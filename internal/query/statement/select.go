@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/expr/functions"
 	"github.com/chaisql/chai/internal/sql/scanner"
 	"github.com/chaisql/chai/internal/stream"
 	"github.com/chaisql/chai/internal/stream/rows"
@@ -14,11 +15,21 @@ import (
 var _ Statement = (*SelectStmt)(nil)
 
 type SelectCoreStmt struct {
+	// Database is the name of the attached database
+	// the table belongs to, if any.
+	Database        string
 	TableName       string
 	Distinct        bool
 	WhereExpr       expr.Expr
 	GroupByExpr     expr.Expr
 	ProjectionExprs []expr.Expr
+
+	// IndexHint restricts the indexes used to read the table, if any.
+	IndexHint *table.IndexHint
+
+	// Parallelism is the number of workers used to scan the table,
+	// if greater than 1.
+	Parallelism int
 }
 
 func (stmt *SelectCoreStmt) Bind(ctx *Context) error {
@@ -39,7 +50,7 @@ func (stmt *SelectCoreStmt) Bind(ctx *Context) error {
 		}
 	}
 
-	return nil
+	return bindRank(stmt.WhereExpr, stmt.ProjectionExprs...)
 }
 
 func (stmt *SelectCoreStmt) Prepare(ctx *Context) (*StreamStmt, error) {
@@ -53,7 +64,15 @@ func (stmt *SelectCoreStmt) Prepare(ctx *Context) (*StreamStmt, error) {
 			return nil, err
 		}
 
-		s = s.Pipe(table.Scan(stmt.TableName))
+		err = stmt.IndexHint.Validate(ctx.Tx, stmt.TableName)
+		if err != nil {
+			return nil, err
+		}
+
+		scan := table.Scan(stmt.TableName)
+		scan.Hint = stmt.IndexHint
+		scan.Parallelism = stmt.Parallelism
+		s = s.Pipe(scan)
 	}
 
 	if stmt.WhereExpr != nil {
@@ -177,10 +196,23 @@ type SelectStmt struct {
 
 	CompoundSelect    []*SelectCoreStmt
 	CompoundOperators []scanner.Token
-	OrderBy           *expr.Column
+	OrderBy           expr.Expr
 	OrderByDirection  scanner.Token
-	OffsetExpr        expr.Expr
-	LimitExpr         expr.Expr
+	// Collation used to sort TEXT values, if any.
+	OrderByCollation string
+	// Additional ORDER BY terms, used to sort rows
+	// that are equal on the previous ones.
+	OrderByThen []OrderByTerm
+	OffsetExpr  expr.Expr
+	LimitExpr   expr.Expr
+}
+
+// OrderByTerm is a term of an ORDER BY clause.
+type OrderByTerm struct {
+	Expr      expr.Expr
+	Direction scanner.Token
+	// Collation used to sort TEXT values, if any.
+	Collation string
 }
 
 func NewSelectStatement() *SelectStmt {
@@ -195,6 +227,16 @@ func NewSelectStatement() *SelectStmt {
 }
 
 func (stmt *SelectStmt) Bind(ctx *Context) error {
+	db, err := stmt.database()
+	if err != nil {
+		return err
+	}
+
+	ctx, err = ctx.forDatabase(db)
+	if err != nil {
+		return err
+	}
+
 	for i := range stmt.CompoundSelect {
 		err := stmt.CompoundSelect[i].Bind(ctx)
 		if err != nil {
@@ -202,7 +244,23 @@ func (stmt *SelectStmt) Bind(ctx *Context) error {
 		}
 	}
 
-	err := BindExpr(ctx, stmt.CompoundSelect[0].TableName, stmt.OrderBy)
+	orderBy := []expr.Expr{stmt.OrderBy}
+	for _, t := range stmt.OrderByThen {
+		orderBy = append(orderBy, t.Expr)
+	}
+
+	for _, e := range orderBy {
+		err = BindExpr(ctx, stmt.CompoundSelect[0].TableName, e)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(stmt.CompoundSelect) == 1 {
+		err = bindRank(stmt.CompoundSelect[0].WhereExpr, orderBy...)
+	} else {
+		err = bindRank(nil, orderBy...)
+	}
 	if err != nil {
 		return err
 	}
@@ -222,6 +280,16 @@ func (stmt *SelectStmt) Bind(ctx *Context) error {
 
 // Prepare implements the Preparer interface.
 func (stmt *SelectStmt) Prepare(ctx *Context) (Statement, error) {
+	db, err := stmt.database()
+	if err != nil {
+		return nil, err
+	}
+
+	dbCtx, err := ctx.forDatabase(db)
+	if err != nil {
+		return nil, err
+	}
+
 	var s *stream.Stream
 
 	var prev scanner.Token
@@ -230,7 +298,7 @@ func (stmt *SelectStmt) Prepare(ctx *Context) (Statement, error) {
 	var readOnly bool = true
 
 	for i, coreSelect := range stmt.CompoundSelect {
-		coreStmt, err := coreSelect.Prepare(ctx)
+		coreStmt, err := coreSelect.Prepare(dbCtx)
 		if err != nil {
 			return nil, err
 		}
@@ -267,11 +335,12 @@ func (stmt *SelectStmt) Prepare(ctx *Context) (Statement, error) {
 	}
 
 	if stmt.OrderBy != nil {
-		if stmt.OrderByDirection == scanner.DESC {
-			s = s.Pipe(rows.TempTreeSortReverse(stmt.OrderBy))
-		} else {
-			s = s.Pipe(rows.TempTreeSort(stmt.OrderBy))
+		sort, err := orderBySort(dbCtx, OrderByTerm{Expr: stmt.OrderBy, Direction: stmt.OrderByDirection, Collation: stmt.OrderByCollation}, stmt.OrderByThen)
+		if err != nil {
+			return nil, err
 		}
+
+		s = s.Pipe(sort)
 	}
 
 	if stmt.OffsetExpr != nil {
@@ -285,7 +354,111 @@ func (stmt *SelectStmt) Prepare(ctx *Context) (Statement, error) {
 	st := StreamStmt{
 		Stream:   s,
 		ReadOnly: readOnly,
+		Database: db,
 	}
 
 	return st.Prepare(ctx)
 }
+
+// database returns the name of the attached database the statement reads from.
+// All the tables of a compound select must belong to the same database.
+func (stmt *SelectStmt) database() (string, error) {
+	var db string
+	var found bool
+	for _, core := range stmt.CompoundSelect {
+		if core.TableName == "" {
+			continue
+		}
+
+		if found && core.Database != db {
+			return "", errors.New("cannot read tables of different databases in the same statement")
+		}
+
+		db, found = core.Database, true
+	}
+
+	return db, nil
+}
+
+// orderByExpr returns the expression used to sort the rows.
+// If a collation is provided, or if the rows are sorted by a column
+// declared with a collation, TEXT values are sorted by their collation key.
+func orderByExpr(ctx *Context, e expr.Expr, collation string) (expr.Expr, error) {
+	if col, ok := e.(*expr.Column); ok && collation == "" && col.Table != "" {
+		info, err := ctx.Tx.Catalog.GetTableInfo(col.Table)
+		if err != nil {
+			return nil, err
+		}
+
+		collation = columnCollation(info, col)
+	}
+
+	if collation == "" {
+		return e, nil
+	}
+
+	return expr.NewCollate(e, collation)
+}
+
+// orderBySort returns the operator sorting the stream by the terms of an ORDER BY clause.
+func orderBySort(ctx *Context, first OrderByTerm, then []OrderByTerm) (*rows.TempTreeSortOperator, error) {
+	sortExpr, err := orderByExpr(ctx, first.Expr, first.Collation)
+	if err != nil {
+		return nil, err
+	}
+
+	var sort *rows.TempTreeSortOperator
+	if first.Direction == scanner.DESC {
+		sort = rows.TempTreeSortReverse(sortExpr)
+	} else {
+		sort = rows.TempTreeSort(sortExpr)
+	}
+
+	for _, t := range then {
+		sortExpr, err := orderByExpr(ctx, t.Expr, t.Collation)
+		if err != nil {
+			return nil, err
+		}
+
+		sort = sort.ThenBy(sortExpr, t.Direction == scanner.DESC)
+	}
+
+	return sort, nil
+}
+
+// bindRank associates the rank() functions found in exprs
+// with the MATCH condition of the WHERE clause they rank rows against.
+func bindRank(where expr.Expr, exprs ...expr.Expr) error {
+	var ranks []*functions.Rank
+	for _, e := range exprs {
+		expr.Walk(e, func(e expr.Expr) bool {
+			if r, ok := e.(*functions.Rank); ok {
+				ranks = append(ranks, r)
+			}
+			return true
+		})
+	}
+	if len(ranks) == 0 {
+		return nil
+	}
+
+	var matches []*expr.MatchOperator
+	expr.Walk(where, func(e expr.Expr) bool {
+		if m, ok := e.(*expr.MatchOperator); ok {
+			matches = append(matches, m)
+		}
+		return true
+	})
+	if len(matches) != 1 {
+		return errors.New("rank() requires exactly one MATCH condition in the WHERE clause")
+	}
+	if _, ok := matches[0].LeftHand().(*expr.Column); !ok {
+		return errors.New("rank() requires a MATCH condition on a column")
+	}
+
+	for _, r := range ranks {
+		r.Match = matches[0]
+	}
+
+	return nil
+}
@@ -0,0 +1,191 @@
+package statement
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/stream"
+	"github.com/chaisql/chai/internal/stream/rows"
+	"github.com/chaisql/chai/internal/types"
+)
+
+var (
+	_ Statement = (*ShowTablesStmt)(nil)
+	_ Statement = (*ShowIndexesStmt)(nil)
+	_ Statement = (*DescribeStmt)(nil)
+)
+
+// ShowTablesStmt is a Statement that lists the tables of the database,
+// sorted by name. Internal tables are not listed.
+type ShowTablesStmt struct{}
+
+func (stmt *ShowTablesStmt) Bind(ctx *Context) error {
+	return nil
+}
+
+// Run returns one row per table, with a name column.
+func (stmt *ShowTablesStmt) Run(ctx *Context) (Result, error) {
+	columns := []string{"name"}
+
+	var list []expr.Row
+	for _, name := range ctx.Tx.Catalog.Cache.ListObjects(database.RelationTableType) {
+		if strings.HasPrefix(name, database.InternalPrefix) {
+			continue
+		}
+
+		list = append(list, catalogRow(columns, types.NewTextValue(name)))
+	}
+
+	return emitRows(ctx, columns, list)
+}
+
+func (stmt *ShowTablesStmt) IsReadOnly() bool {
+	return true
+}
+
+// ShowIndexesStmt is a Statement that lists the indexes of the database,
+// or of a single table, sorted by name. Temporary indexes of the connection are listed as well.
+type ShowIndexesStmt struct {
+	TableName string
+}
+
+func (stmt *ShowIndexesStmt) Bind(ctx *Context) error {
+	return nil
+}
+
+// Run returns one row per index, with the name of the index, the name of its table,
+// the list of indexed columns and whether it is unique, full-text or temporary.
+func (stmt *ShowIndexesStmt) Run(ctx *Context) (Result, error) {
+	tables := []string{stmt.TableName}
+	if stmt.TableName == "" {
+		tables = ctx.Tx.Catalog.Cache.ListObjects(database.RelationTableType)
+	} else if _, err := ctx.Tx.Catalog.GetTableInfo(stmt.TableName); err != nil {
+		return Result{}, err
+	}
+
+	type index struct {
+		info      *database.IndexInfo
+		temporary bool
+	}
+
+	var indexes []index
+	for _, table := range tables {
+		for _, name := range ctx.Tx.Catalog.ListIndexes(table) {
+			info, err := ctx.Tx.Catalog.GetIndexInfo(name)
+			if err != nil {
+				return Result{}, err
+			}
+
+			indexes = append(indexes, index{info: info})
+		}
+
+		for _, info := range ctx.Tx.Connection().TempIndexes(ctx.Tx, table) {
+			indexes = append(indexes, index{info: info, temporary: true})
+		}
+	}
+
+	slices.SortFunc(indexes, func(a, b index) int {
+		return strings.Compare(a.info.IndexName, b.info.IndexName)
+	})
+
+	columns := []string{"name", "table_name", "columns", "unique", "fulltext", "temporary"}
+	list := make([]expr.Row, 0, len(indexes))
+	for _, idx := range indexes {
+		var cols strings.Builder
+		for i, c := range idx.info.Columns {
+			if i > 0 {
+				cols.WriteString(", ")
+			}
+			cols.WriteString(c)
+			if idx.info.KeySortOrder.IsDesc(i) {
+				cols.WriteString(" DESC")
+			}
+		}
+
+		list = append(list, catalogRow(columns,
+			types.NewTextValue(idx.info.IndexName),
+			types.NewTextValue(idx.info.Owner.TableName),
+			types.NewTextValue(cols.String()),
+			types.NewBooleanValue(idx.info.Unique),
+			types.NewBooleanValue(idx.info.Fulltext),
+			types.NewBooleanValue(idx.temporary),
+		))
+	}
+
+	return emitRows(ctx, columns, list)
+}
+
+func (stmt *ShowIndexesStmt) IsReadOnly() bool {
+	return true
+}
+
+// DescribeStmt is a Statement that lists the columns of a table.
+type DescribeStmt struct {
+	TableName string
+}
+
+func (stmt *DescribeStmt) Bind(ctx *Context) error {
+	return nil
+}
+
+// Run returns one row per column, in the order of the table definition,
+// with the name and type of the column, whether it is nullable, its default value,
+// its collation and whether it is part of the primary key.
+func (stmt *DescribeStmt) Run(ctx *Context) (Result, error) {
+	info, err := ctx.Tx.Catalog.GetTableInfo(stmt.TableName)
+	if err != nil {
+		return Result{}, err
+	}
+
+	columns := []string{"name", "type", "nullable", "default", "collation", "primary_key"}
+	list := make([]expr.Row, 0, len(info.ColumnConstraints.Ordered))
+	for _, cc := range info.ColumnConstraints.Ordered {
+		var dflt types.Value = types.NewNullValue()
+		if cc.DefaultValue != nil {
+			dflt = types.NewTextValue(cc.DefaultValue.String())
+		}
+
+		var collation types.Value = types.NewNullValue()
+		if cc.Collation != "" {
+			collation = types.NewTextValue(cc.Collation)
+		}
+
+		pk := info.PrimaryKey != nil && slices.Contains(info.PrimaryKey.Columns, cc.Column)
+
+		list = append(list, catalogRow(columns,
+			types.NewTextValue(cc.Column),
+			types.NewTextValue(cc.TypeString()),
+			types.NewBooleanValue(!cc.IsNotNull),
+			dflt,
+			collation,
+			types.NewBooleanValue(pk),
+		))
+	}
+
+	return emitRows(ctx, columns, list)
+}
+
+func (stmt *DescribeStmt) IsReadOnly() bool {
+	return true
+}
+
+func catalogRow(columns []string, values ...types.Value) expr.Row {
+	exprs := make([]expr.Expr, len(values))
+	for i, v := range values {
+		exprs[i] = expr.LiteralValue{Value: v}
+	}
+
+	return expr.Row{Columns: columns, Exprs: exprs}
+}
+
+// emitRows returns a result that iterates over the given rows.
+func emitRows(ctx *Context, columns []string, list []expr.Row) (Result, error) {
+	st := PreparedStreamStmt{
+		Stream:   stream.New(rows.Emit(columns, list...)),
+		ReadOnly: true,
+	}
+
+	return st.Run(ctx)
+}
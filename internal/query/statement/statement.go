@@ -1,9 +1,12 @@
 package statement
 
 import (
+	"context"
+
 	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/environment"
 	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/sql/scanner"
 	"github.com/cockroachdb/errors"
 )
 
@@ -33,12 +36,39 @@ func (stmt *basePreparedStatement) Run(ctx *Context) (Result, error) {
 }
 
 type Context struct {
+	Ctx    context.Context
 	DB     *database.Database
 	Conn   *database.Connection
 	Tx     *database.Transaction
 	Params []environment.Param
 }
 
+// forDatabase returns a context whose transaction operates on the
+// database attached under the given name.
+// If the name is empty, it returns the context itself.
+func (c *Context) forDatabase(name string) (*Context, error) {
+	if name == "" {
+		return c, nil
+	}
+
+	tx, err := c.Tx.Attached(name)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := c.DB.Attached(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Context{
+		Ctx:    c.Ctx,
+		DB:     db,
+		Tx:     tx,
+		Params: c.Params,
+	}, nil
+}
+
 type Preparer interface {
 	Prepare(*Context) (Statement, error)
 }
@@ -47,8 +77,11 @@ type Preparer interface {
 type Result struct {
 	Iterator database.RowIterator
 	Tx       *database.Transaction
-	closed   bool
-	err      error
+	// Done, if set, is called when the result is closed
+	// to release the resources of the query.
+	Done   func()
+	closed bool
+	err    error
 }
 
 func (r *Result) Iterate(fn func(database.Row) error) error {
@@ -74,6 +107,10 @@ func (r *Result) Close() (err error) {
 
 	r.closed = true
 
+	if r.Done != nil {
+		defer r.Done()
+	}
+
 	if r.Tx != nil {
 		if r.Tx.Writable && r.err == nil {
 			err = r.Tx.Commit()
@@ -85,6 +122,63 @@ func (r *Result) Close() (err error) {
 	return err
 }
 
+// applyColumnCollation makes comparisons involving a column declared with
+// a collation use that collation, unless one of the operands has an explicit
+// COLLATE clause. If both operands are collated columns, the collation
+// of the left operand is used.
+func applyColumnCollation(info *database.TableInfo, op expr.Operator) error {
+	switch op.Token() {
+	case scanner.EQ, scanner.NEQ, scanner.GT, scanner.GTE, scanner.LT, scanner.LTE:
+	default:
+		return nil
+	}
+
+	lh, rh := op.LeftHand(), op.RightHand()
+	if _, ok := lh.(*expr.Collate); ok {
+		return nil
+	}
+	if _, ok := rh.(*expr.Collate); ok {
+		return nil
+	}
+
+	name := columnCollation(info, lh)
+	if name == "" {
+		name = columnCollation(info, rh)
+	}
+	if name == "" {
+		return nil
+	}
+
+	l, err := expr.NewCollate(lh, name)
+	if err != nil {
+		return err
+	}
+	r, err := expr.NewCollate(rh, name)
+	if err != nil {
+		return err
+	}
+
+	op.SetLeftHandExpr(l)
+	op.SetRightHandExpr(r)
+	return nil
+}
+
+// columnCollation returns the collation of e if it is a column
+// of the table declared with a collation.
+func columnCollation(info *database.TableInfo, e expr.Expr) string {
+	col, ok := e.(*expr.Column)
+	if !ok {
+		return ""
+	}
+
+	cc := info.GetColumnConstraint(col.Name)
+	if cc == nil {
+		return ""
+	}
+
+	return cc.Collation
+}
+
 func BindExpr(ctx *Context, tableName string, e expr.Expr) (err error) {
 	if e == nil {
 		return nil
@@ -100,6 +194,13 @@ func BindExpr(ctx *Context, tableName string, e expr.Expr) (err error) {
 
 	expr.Walk(e, func(e expr.Expr) bool {
 		switch t := e.(type) {
+		case expr.Operator:
+			if info != nil {
+				err = applyColumnCollation(info, t)
+				if err != nil {
+					return false
+				}
+			}
 		case *expr.Column:
 			if t == nil {
 				return true
@@ -1,10 +1,14 @@
 package statement
 
 import (
+	"time"
+
 	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/environment"
 	"github.com/chaisql/chai/internal/planner"
+	"github.com/chaisql/chai/internal/row"
 	"github.com/chaisql/chai/internal/stream"
+	"github.com/chaisql/chai/internal/types"
 	"github.com/cockroachdb/errors"
 )
 
@@ -14,6 +18,9 @@ var _ Statement = (*PreparedStreamStmt)(nil)
 type StreamStmt struct {
 	Stream   *stream.Stream
 	ReadOnly bool
+	// Database is the name of the attached database
+	// the stream operates on, if any.
+	Database string
 }
 
 // Prepare implements the Preparer interface.
@@ -21,6 +28,7 @@ func (s *StreamStmt) Prepare(ctx *Context) (Statement, error) {
 	return &PreparedStreamStmt{
 		Stream:   s.Stream,
 		ReadOnly: s.ReadOnly,
+		Database: s.Database,
 	}, nil
 }
 
@@ -28,6 +36,7 @@ func (s *StreamStmt) Prepare(ctx *Context) (Statement, error) {
 type PreparedStreamStmt struct {
 	Stream   *stream.Stream
 	ReadOnly bool
+	Database string
 }
 
 func (s *PreparedStreamStmt) Bind(ctx *Context) error {
@@ -37,7 +46,12 @@ func (s *PreparedStreamStmt) Bind(ctx *Context) error {
 // Run returns a result containing the stream. The stream will be executed by calling the Iterate method of
 // the result.
 func (s *PreparedStreamStmt) Run(ctx *Context) (Result, error) {
-	st, err := planner.Optimize(s.Stream.Clone(), ctx.Tx.Catalog, ctx.Params)
+	ctx, err := ctx.forDatabase(s.Database)
+	if err != nil {
+		return Result{}, err
+	}
+
+	st, err := planner.OptimizeContext(ctx.Ctx, s.Stream.Clone(), ctx.Tx, ctx.Params)
 	if err != nil {
 		return Result{}, err
 	}
@@ -69,8 +83,15 @@ func (s *StreamStmtIterator) Iterate(fn func(r database.Row) error) error {
 	var env environment.Environment
 	env.DB = s.Context.DB
 	env.Tx = s.Context.Tx
+	env.Ctx = s.Context.Ctx
 	env.SetParams(s.Context.Params)
 
+	// timestamps are returned in the time zone of the database
+	var lr localRow
+	lr.loc = s.Context.Tx.Location()
+	lr.ordered = s.Context.DB != nil && s.Context.DB.PreserveColumnOrder()
+
+	var br database.BasicRow
 	err := s.Stream.Iterate(&env, func(env *environment.Environment) error {
 		// if there is no row in this specific environment,
 		// the last operator is not outputting anything
@@ -79,10 +100,64 @@ func (s *StreamStmtIterator) Iterate(fn func(r database.Row) error) error {
 			return nil
 		}
 
-		return fn(env.Row.(database.Row))
+		// rows emitted from expressions are not associated with a table
+		r, ok := env.Row.(database.Row)
+		if !ok {
+			br.ResetWith("", nil, env.Row)
+			r = &br
+		}
+
+		if lr.loc != time.UTC || lr.ordered {
+			lr.Row = r
+			r = &lr
+		}
+
+		return fn(r)
 	})
 	if errors.Is(err, stream.ErrStreamClosed) {
 		err = nil
 	}
 	return err
 }
+
+// localRow is a row whose timestamps are formatted in the given location,
+// unless they were already associated with a location.
+type localRow struct {
+	database.Row
+
+	loc *time.Location
+
+	// if true, the columns keep their order when encoded
+	ordered bool
+}
+
+func (r *localRow) Iterate(fn func(column string, value types.Value) error) error {
+	return r.Row.Iterate(func(column string, v types.Value) error {
+		return fn(column, r.local(v))
+	})
+}
+
+func (r *localRow) Get(name string) (types.Value, error) {
+	v, err := r.Row.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.local(v), nil
+}
+
+func (r *localRow) MarshalJSON() ([]byte, error) {
+	return row.MarshalJSON(r)
+}
+
+func (r *localRow) OrderedColumns() bool {
+	return r.ordered
+}
+
+func (r *localRow) local(v types.Value) types.Value {
+	if r.loc == time.UTC || v.Type() != types.TypeTimestamp {
+		return v
+	}
+
+	return types.LocalTimestamp(v, r.loc)
+}
@@ -15,7 +15,12 @@ var _ Statement = (*UpdateStmt)(nil)
 type UpdateStmt struct {
 	basePreparedStatement
 
+	// Database is the name of the attached database
+	// the table belongs to, if any.
+	Database  string
 	TableName string
+	// IndexHint restricts the indexes used to read the table, if any.
+	IndexHint *table.IndexHint
 
 	// SetPairs is used along with the Set clause. It holds
 	// each column with its corresponding value that
@@ -42,7 +47,12 @@ type UpdateSetPair struct {
 }
 
 func (stmt *UpdateStmt) Bind(ctx *Context) error {
-	err := BindExpr(ctx, stmt.TableName, stmt.WhereExpr)
+	ctx, err := ctx.forDatabase(stmt.Database)
+	if err != nil {
+		return err
+	}
+
+	err = BindExpr(ctx, stmt.TableName, stmt.WhereExpr)
 	if err != nil {
 		return err
 	}
@@ -63,14 +73,26 @@ func (stmt *UpdateStmt) Bind(ctx *Context) error {
 }
 
 // Prepare implements the Preparer interface.
-func (stmt *UpdateStmt) Prepare(c *Context) (Statement, error) {
+func (stmt *UpdateStmt) Prepare(ctx *Context) (Statement, error) {
+	c, err := ctx.forDatabase(stmt.Database)
+	if err != nil {
+		return nil, err
+	}
+
 	ti, err := c.Tx.Catalog.GetTableInfo(stmt.TableName)
 	if err != nil {
 		return nil, err
 	}
 	pk := ti.PrimaryKey
 
-	s := stream.New(table.Scan(stmt.TableName))
+	err = stmt.IndexHint.Validate(c.Tx, stmt.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	scan := table.Scan(stmt.TableName)
+	scan.Hint = stmt.IndexHint
+	s := stream.New(scan)
 
 	if stmt.WhereExpr != nil {
 		s = s.Pipe(rows.Filter(stmt.WhereExpr))
@@ -128,7 +150,8 @@ func (stmt *UpdateStmt) Prepare(c *Context) (Statement, error) {
 	st := StreamStmt{
 		Stream:   s,
 		ReadOnly: false,
+		Database: stmt.Database,
 	}
 
-	return st.Prepare(c)
+	return st.Prepare(ctx)
 }
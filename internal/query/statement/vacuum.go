@@ -0,0 +1,36 @@
+package statement
+
+import (
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/types"
+)
+
+var _ Statement = (*VacuumStmt)(nil)
+
+// VacuumStmt is a Statement that compacts the storage of the database
+// to reclaim the space used by deleted data.
+type VacuumStmt struct{}
+
+func (stmt *VacuumStmt) Bind(ctx *Context) error {
+	return nil
+}
+
+// Run compacts the database and returns one row with the size_before
+// and size_after columns, reporting the size of the database in bytes.
+func (stmt *VacuumStmt) Run(ctx *Context) (Result, error) {
+	stats, err := ctx.DB.Compact(ctx.Ctx)
+	if err != nil {
+		return Result{}, err
+	}
+
+	columns := []string{"size_before", "size_after"}
+	return emitRows(ctx, columns, []expr.Row{catalogRow(columns,
+		types.NewBigintValue(int64(stats.SizeBefore)),
+		types.NewBigintValue(int64(stats.SizeAfter)),
+	)})
+}
+
+// IsReadOnly returns true. Compacting the database doesn't modify its content.
+func (stmt *VacuumStmt) IsReadOnly() bool {
+	return true
+}
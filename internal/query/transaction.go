@@ -13,6 +13,11 @@ var _ queryAlterer = CommitStmt{}
 // BeginStmt is a statement that creates a new transaction.
 type BeginStmt struct {
 	Writable bool
+	// Set by BEGIN DEFERRED: the transaction only acquires
+	// the write lock when it runs its first write statement.
+	Deferred bool
+	// Durability of the commit, set by WITH DURABILITY.
+	Durability database.Durability
 }
 
 func (stmt BeginStmt) Bind(ctx *statement.Context) error {
@@ -31,7 +36,9 @@ func (stmt BeginStmt) alterQuery(conn *database.Connection, q *Query) error {
 
 	var err error
 	q.tx, err = conn.BeginTx(&database.TxOptions{
-		ReadOnly: !stmt.Writable,
+		ReadOnly:   !stmt.Writable,
+		Deferred:   stmt.Deferred,
+		Durability: stmt.Durability,
 	})
 	q.autoCommit = false
 	return err
@@ -112,3 +119,99 @@ func (stmt CommitStmt) IsReadOnly() bool {
 func (stmt CommitStmt) Run(ctx *statement.Context) (statement.Result, error) {
 	return statement.Result{}, errors.New("cannot commit with no active transaction")
 }
+
+var _ queryAlterer = SavepointStmt{}
+var _ queryAlterer = RollbackToSavepointStmt{}
+var _ queryAlterer = ReleaseSavepointStmt{}
+
+// SavepointStmt is a statement that creates a savepoint in the current active transaction.
+type SavepointStmt struct {
+	Name string
+}
+
+func (stmt SavepointStmt) Bind(ctx *statement.Context) error {
+	return nil
+}
+
+// Prepare implements the Preparer interface.
+func (stmt SavepointStmt) Prepare(*statement.Context) (statement.Statement, error) {
+	return stmt, nil
+}
+
+func (stmt SavepointStmt) alterQuery(conn *database.Connection, q *Query) error {
+	if q.tx == nil || q.autoCommit {
+		return errors.New("cannot create a savepoint with no active transaction")
+	}
+
+	return q.tx.Savepoint(stmt.Name)
+}
+
+func (stmt SavepointStmt) IsReadOnly() bool {
+	return false
+}
+
+func (stmt SavepointStmt) Run(ctx *statement.Context) (statement.Result, error) {
+	return statement.Result{}, errors.New("cannot create a savepoint with no active transaction")
+}
+
+// RollbackToSavepointStmt is a statement that undoes the changes made
+// since a savepoint was created.
+type RollbackToSavepointStmt struct {
+	Name string
+}
+
+func (stmt RollbackToSavepointStmt) Bind(ctx *statement.Context) error {
+	return nil
+}
+
+// Prepare implements the Preparer interface.
+func (stmt RollbackToSavepointStmt) Prepare(*statement.Context) (statement.Statement, error) {
+	return stmt, nil
+}
+
+func (stmt RollbackToSavepointStmt) alterQuery(conn *database.Connection, q *Query) error {
+	if q.tx == nil || q.autoCommit {
+		return errors.New("cannot rollback to a savepoint with no active transaction")
+	}
+
+	return q.tx.RollbackToSavepoint(stmt.Name)
+}
+
+func (stmt RollbackToSavepointStmt) IsReadOnly() bool {
+	return false
+}
+
+func (stmt RollbackToSavepointStmt) Run(ctx *statement.Context) (statement.Result, error) {
+	return statement.Result{}, errors.New("cannot rollback to a savepoint with no active transaction")
+}
+
+// ReleaseSavepointStmt is a statement that destroys a savepoint,
+// keeping the changes made since it was created.
+type ReleaseSavepointStmt struct {
+	Name string
+}
+
+func (stmt ReleaseSavepointStmt) Bind(ctx *statement.Context) error {
+	return nil
+}
+
+// Prepare implements the Preparer interface.
+func (stmt ReleaseSavepointStmt) Prepare(*statement.Context) (statement.Statement, error) {
+	return stmt, nil
+}
+
+func (stmt ReleaseSavepointStmt) alterQuery(conn *database.Connection, q *Query) error {
+	if q.tx == nil || q.autoCommit {
+		return errors.New("cannot release a savepoint with no active transaction")
+	}
+
+	return q.tx.ReleaseSavepoint(stmt.Name)
+}
+
+func (stmt ReleaseSavepointStmt) IsReadOnly() bool {
+	return false
+}
+
+func (stmt ReleaseSavepointStmt) Run(ctx *statement.Context) (statement.Result, error) {
+	return statement.Result{}, errors.New("cannot release a savepoint with no active transaction")
+}
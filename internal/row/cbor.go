@@ -0,0 +1,500 @@
+package row
+
+import (
+	"encoding/binary"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// CBOR (RFC 8949) major types.
+const (
+	cborUint byte = iota << 5
+	cborNegInt
+	cborBytes
+	cborText
+	cborArray
+	cborMap
+	cborTag
+	cborSimple
+)
+
+// CBOR tags used to preserve the type of the values.
+const (
+	cborTagDateTime      = 0
+	cborTagEpoch         = 1
+	cborTagPosBignum     = 2
+	cborTagNegBignum     = 3
+	cborTagDecimal       = 4
+	cborTagUUID          = 37
+	cborIndefiniteLength = 31
+	cborBreak            = 0xff
+)
+
+// MarshalCBOR encodes a row to a CBOR map, whose keys are the columns of the row, in order.
+// Integers are encoded as CBOR integers, doubles as 64-bit floats, blobs as byte strings,
+// timestamps as RFC 3339 date/time strings (tag 0), decimals as decimal fractions (tag 4)
+// and UUIDs as tagged byte strings (tag 37).
+func MarshalCBOR(r Row) ([]byte, error) {
+	n, err := Length(r)
+	if err != nil {
+		return nil, err
+	}
+
+	dst := appendCBORHead(nil, cborMap, uint64(n))
+	err = r.Iterate(func(c string, v types.Value) error {
+		dst = appendCBORHead(dst, cborText, uint64(len(c)))
+		dst = append(dst, c...)
+
+		dst, err = AppendCBORValue(dst, v)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dst, nil
+}
+
+// AppendCBORValue appends the CBOR encoding of v to dst.
+func AppendCBORValue(dst []byte, v types.Value) ([]byte, error) {
+	if v.V() == nil {
+		return append(dst, cborSimple|22), nil
+	}
+
+	switch v.Type() {
+	case types.TypeNull:
+		return append(dst, cborSimple|22), nil
+	case types.TypeBoolean:
+		if types.AsBool(v) {
+			return append(dst, cborSimple|21), nil
+		}
+		return append(dst, cborSimple|20), nil
+	case types.TypeInteger, types.TypeBigint:
+		return appendCBORInt(dst, types.AsInt64(v)), nil
+	case types.TypeDouble:
+		dst = append(dst, cborSimple|27)
+		return binary.BigEndian.AppendUint64(dst, math.Float64bits(types.AsFloat64(v))), nil
+	case types.TypeDecimal:
+		d := v.(types.DecimalValue)
+		dst = appendCBORHead(dst, cborTag, cborTagDecimal)
+		dst = appendCBORHead(dst, cborArray, 2)
+		dst = appendCBORInt(dst, -int64(d.Scale()))
+		return appendCBORBigInt(dst, d.Coef()), nil
+	case types.TypeTimestamp:
+		s := types.AsTime(v).Format(time.RFC3339Nano)
+		dst = appendCBORHead(dst, cborTag, cborTagDateTime)
+		dst = appendCBORHead(dst, cborText, uint64(len(s)))
+		return append(dst, s...), nil
+	case types.TypeText:
+		s := types.AsString(v)
+		dst = appendCBORHead(dst, cborText, uint64(len(s)))
+		return append(dst, s...), nil
+	case types.TypeBlob:
+		b := types.AsByteSlice(v)
+		dst = appendCBORHead(dst, cborBytes, uint64(len(b)))
+		return append(dst, b...), nil
+	case types.TypeUUID:
+		u := v.(types.UUIDValue)
+		dst = appendCBORHead(dst, cborTag, cborTagUUID)
+		dst = appendCBORHead(dst, cborBytes, uint64(len(u)))
+		return append(dst, u[:]...), nil
+	}
+
+	return nil, errors.Errorf("unexpected type: %s", v.Type())
+}
+
+// appendCBORHead appends the head of a data item, made of its major type
+// and of its argument, encoded in the smallest possible form.
+func appendCBORHead(dst []byte, major byte, arg uint64) []byte {
+	switch {
+	case arg < 24:
+		return append(dst, major|byte(arg))
+	case arg <= math.MaxUint8:
+		return append(dst, major|24, byte(arg))
+	case arg <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(dst, major|25), uint16(arg))
+	case arg <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(dst, major|26), uint32(arg))
+	}
+
+	return binary.BigEndian.AppendUint64(append(dst, major|27), arg)
+}
+
+func appendCBORInt(dst []byte, x int64) []byte {
+	if x < 0 {
+		// -1 - x never overflows
+		return appendCBORHead(dst, cborNegInt, uint64(-1-x))
+	}
+
+	return appendCBORHead(dst, cborUint, uint64(x))
+}
+
+// appendCBORBigInt encodes x as an integer, or as a bignum if it doesn't fit in 64 bits.
+func appendCBORBigInt(dst []byte, x *big.Int) []byte {
+	if x.IsInt64() {
+		return appendCBORInt(dst, x.Int64())
+	}
+
+	tag := uint64(cborTagPosBignum)
+	if x.Sign() < 0 {
+		// a negative bignum encodes -1 - x
+		tag = cborTagNegBignum
+		x = new(big.Int).Sub(new(big.Int).Neg(x), big.NewInt(1))
+	}
+
+	b := x.Bytes()
+	dst = appendCBORHead(dst, cborTag, tag)
+	dst = appendCBORHead(dst, cborBytes, uint64(len(b)))
+	return append(dst, b...)
+}
+
+// UnmarshalCBOR decodes a CBOR map and adds its members to the buffer.
+// The keys of the map must be text strings and its values scalars.
+func (cb *ColumnBuffer) UnmarshalCBOR(data []byte) error {
+	if cb.frozen {
+		return ErrFrozen
+	}
+
+	d := cborDecoder{data: data}
+
+	major, n, indefinite, err := d.head()
+	if err != nil {
+		return err
+	}
+	if major != cborMap {
+		return errors.New("CBOR data item is not a map")
+	}
+
+	for i := uint64(0); indefinite || i < n; i++ {
+		if indefinite && d.atBreak() {
+			d.pos++
+			break
+		}
+
+		major, _, _, err := d.peekHead()
+		if err != nil {
+			return err
+		}
+		if major != cborText {
+			return errors.New("CBOR map keys must be text strings")
+		}
+		k, err := d.value()
+		if err != nil {
+			return err
+		}
+
+		v, err := d.value()
+		if err != nil {
+			return errors.Wrapf(err, "column %s", types.AsString(k))
+		}
+
+		cb.Add(types.AsString(k), v)
+	}
+
+	if d.pos != len(d.data) {
+		return errors.New("unexpected data after the CBOR map")
+	}
+
+	return nil
+}
+
+// ParseCBORValue decodes a scalar CBOR data item.
+func ParseCBORValue(data []byte) (types.Value, error) {
+	d := cborDecoder{data: data}
+	v, err := d.value()
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(d.data) {
+		return nil, errors.New("unexpected data after the CBOR data item")
+	}
+
+	return v, nil
+}
+
+var errCBORTruncated = errors.New("truncated CBOR data")
+
+type cborDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *cborDecoder) atBreak() bool {
+	return d.pos < len(d.data) && d.data[d.pos] == cborBreak
+}
+
+// peekHead decodes the head of the next data item without consuming it.
+func (d *cborDecoder) peekHead() (major byte, arg uint64, indefinite bool, err error) {
+	pos := d.pos
+	major, arg, indefinite, err = d.head()
+	d.pos = pos
+	return
+}
+
+// head decodes the major type and the argument of the next data item.
+func (d *cborDecoder) head() (major byte, arg uint64, indefinite bool, err error) {
+	if d.pos >= len(d.data) {
+		return 0, 0, false, errCBORTruncated
+	}
+
+	b := d.data[d.pos]
+	d.pos++
+	major, info := b&0xe0, b&0x1f
+
+	size := 0
+	switch {
+	case info < 24:
+		return major, uint64(info), false, nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	case info == cborIndefiniteLength && major >= cborBytes && major <= cborMap:
+		return major, 0, true, nil
+	default:
+		return 0, 0, false, errors.Errorf("malformed CBOR data item 0x%02x", b)
+	}
+
+	if d.pos+size > len(d.data) {
+		return 0, 0, false, errCBORTruncated
+	}
+	for _, c := range d.data[d.pos : d.pos+size] {
+		arg = arg<<8 | uint64(c)
+	}
+	d.pos += size
+
+	return major, arg, false, nil
+}
+
+// bytes decodes the content of a byte or text string, whose head was already decoded.
+func (d *cborDecoder) bytes(major byte, n uint64, indefinite bool) ([]byte, error) {
+	if !indefinite {
+		if n > uint64(len(d.data)-d.pos) {
+			return nil, errCBORTruncated
+		}
+		b := d.data[d.pos : d.pos+int(n)]
+		d.pos += int(n)
+		return b, nil
+	}
+
+	// an indefinite-length string is a sequence of definite-length chunks
+	var b []byte
+	for !d.atBreak() {
+		m, n, indefinite, err := d.head()
+		if err != nil {
+			return nil, err
+		}
+		if m != major || indefinite {
+			return nil, errors.New("malformed CBOR indefinite-length string")
+		}
+		chunk, err := d.bytes(major, n, false)
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, chunk...)
+	}
+	d.pos++
+
+	return b, nil
+}
+
+// value decodes the next data item as a scalar value.
+func (d *cborDecoder) value() (types.Value, error) {
+	start := d.pos
+	major, arg, indefinite, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case cborUint:
+		if arg > math.MaxInt64 {
+			return newBigValue(new(big.Int).SetUint64(arg))
+		}
+		return newIntValue(int64(arg)), nil
+	case cborNegInt:
+		if arg > math.MaxInt64 {
+			x := new(big.Int).SetUint64(arg)
+			return newBigValue(x.Sub(x.Neg(x), big.NewInt(1)))
+		}
+		return newIntValue(-1 - int64(arg)), nil
+	case cborBytes:
+		b, err := d.bytes(major, arg, indefinite)
+		if err != nil {
+			return nil, err
+		}
+		return types.NewBlobValue(append([]byte(nil), b...)), nil
+	case cborText:
+		b, err := d.bytes(major, arg, indefinite)
+		if err != nil {
+			return nil, err
+		}
+		return types.NewTextValue(string(b)), nil
+	case cborArray, cborMap:
+		return nil, errors.New("nested CBOR arrays and maps are not supported")
+	case cborTag:
+		return d.tagged(arg)
+	}
+
+	// major type 7: simple values and floats
+	switch d.data[start] & 0x1f {
+	case 20:
+		return types.NewBooleanValue(false), nil
+	case 21:
+		return types.NewBooleanValue(true), nil
+	case 22, 23:
+		// null and undefined
+		return types.NewNullValue(), nil
+	case 25:
+		return types.NewDoubleValue(float16(uint16(arg))), nil
+	case 26:
+		return types.NewDoubleValue(float64(math.Float32frombits(uint32(arg)))), nil
+	case 27:
+		return types.NewDoubleValue(math.Float64frombits(arg)), nil
+	}
+
+	return nil, errors.Errorf("unsupported CBOR simple value %d", arg)
+}
+
+// tagged decodes the content of a tagged data item.
+// Unknown tags are ignored.
+func (d *cborDecoder) tagged(tag uint64) (types.Value, error) {
+	switch tag {
+	case cborTagDateTime:
+		v, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		if v.Type() != types.TypeText {
+			return nil, errors.New("malformed CBOR date/time string")
+		}
+		t, err := time.Parse(time.RFC3339Nano, types.AsString(v))
+		if err != nil {
+			return nil, err
+		}
+		return types.NewTimestampValue(t), nil
+	case cborTagEpoch:
+		v, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		switch v.Type() {
+		case types.TypeInteger, types.TypeBigint:
+			return types.NewTimestampValue(time.Unix(types.AsInt64(v), 0).UTC()), nil
+		case types.TypeDouble:
+			f := types.AsFloat64(v)
+			if !types.IsFinite(v) {
+				return nil, errors.New("malformed CBOR epoch-based date/time")
+			}
+			sec, frac := math.Modf(f)
+			return types.NewTimestampValue(time.Unix(int64(sec), int64(frac*1e9)).UTC()), nil
+		}
+		return nil, errors.New("malformed CBOR epoch-based date/time")
+	case cborTagPosBignum, cborTagNegBignum:
+		major, n, indefinite, err := d.head()
+		if err != nil {
+			return nil, err
+		}
+		if major != cborBytes {
+			return nil, errors.New("malformed CBOR bignum")
+		}
+		b, err := d.bytes(major, n, indefinite)
+		if err != nil {
+			return nil, err
+		}
+		x := new(big.Int).SetBytes(b)
+		if tag == cborTagNegBignum {
+			x.Sub(x.Neg(x), big.NewInt(1))
+		}
+		return newBigValue(x)
+	case cborTagDecimal:
+		major, n, indefinite, err := d.head()
+		if err != nil {
+			return nil, err
+		}
+		if major != cborArray || indefinite || n != 2 {
+			return nil, errors.New("malformed CBOR decimal fraction")
+		}
+		exp, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		mant, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		if !exp.Type().IsInteger() || types.AsInt64(exp) <= math.MinInt32 || types.AsInt64(exp) > math.MaxInt32 {
+			return nil, errors.New("malformed CBOR decimal fraction")
+		}
+
+		var coef *big.Int
+		switch mant.Type() {
+		case types.TypeInteger, types.TypeBigint:
+			coef = big.NewInt(types.AsInt64(mant))
+		case types.TypeDecimal:
+			coef = mant.(types.DecimalValue).Coef()
+		default:
+			return nil, errors.New("malformed CBOR decimal fraction")
+		}
+		return types.NewDecimalValue(coef, int32(-types.AsInt64(exp))), nil
+	case cborTagUUID:
+		v, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		if v.Type() != types.TypeBlob || len(types.AsByteSlice(v)) != 16 {
+			return nil, errors.New("malformed CBOR UUID")
+		}
+		return types.NewUUIDValue([16]byte(types.AsByteSlice(v))), nil
+	}
+
+	return d.value()
+}
+
+// newBigValue returns a bigint if x fits in 64 bits, or a decimal otherwise.
+func newBigValue(x *big.Int) (types.Value, error) {
+	if x.IsInt64() {
+		return newIntValue(x.Int64()), nil
+	}
+
+	return types.NewDecimalValue(x, 0), nil
+}
+
+// newIntValue returns an integer if x fits in 32 bits, or a bigint otherwise.
+func newIntValue(x int64) types.Value {
+	if x < math.MinInt32 || x > math.MaxInt32 {
+		return types.NewBigintValue(x)
+	}
+
+	return types.NewIntegerValue(int32(x))
+}
+
+// float16 converts an IEEE 754 half-precision float.
+func float16(h uint16) float64 {
+	sign := 1.0
+	if h&0x8000 != 0 {
+		sign = -1
+	}
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+
+	switch exp {
+	case 0:
+		return sign * math.Ldexp(mant, -24)
+	case 0x1f:
+		if mant != 0 {
+			return math.NaN()
+		}
+		return math.Inf(int(sign))
+	}
+
+	return sign * math.Ldexp(mant+1024, exp-25)
+}
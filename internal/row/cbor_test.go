@@ -0,0 +1,140 @@
+package row_test
+
+import (
+	"encoding/hex"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCBORValue(t *testing.T) {
+	huge, _ := new(big.Int).SetString("18446744073709551616", 10)
+	ts := time.Date(2013, 3, 21, 20, 4, 0, 0, time.UTC)
+
+	// examples of the appendix A of RFC 8949
+	tests := []struct {
+		hex  string
+		want types.Value
+	}{
+		{"00", types.NewIntegerValue(0)},
+		{"17", types.NewIntegerValue(23)},
+		{"1818", types.NewIntegerValue(24)},
+		{"1903e8", types.NewIntegerValue(1000)},
+		{"1b000000e8d4a51000", types.NewBigintValue(1000000000000)},
+		{"1bffffffffffffffff", types.NewDecimalValue(new(big.Int).SetUint64(math.MaxUint64), 0)},
+		{"c249010000000000000000", types.NewDecimalValue(huge, 0)},
+		{"3bffffffffffffffff", types.NewDecimalValue(new(big.Int).Neg(huge), 0)},
+		{"c349010000000000000000", types.NewDecimalValue(new(big.Int).Sub(new(big.Int).Neg(huge), big.NewInt(1)), 0)},
+		{"20", types.NewIntegerValue(-1)},
+		{"3903e7", types.NewIntegerValue(-1000)},
+		{"f90000", types.NewDoubleValue(0)},
+		{"f93c00", types.NewDoubleValue(1)},
+		{"f93e00", types.NewDoubleValue(1.5)},
+		{"f97bff", types.NewDoubleValue(65504)},
+		{"f90001", types.NewDoubleValue(5.960464477539063e-8)},
+		{"f9c400", types.NewDoubleValue(-4)},
+		{"fa47c35000", types.NewDoubleValue(100000)},
+		{"fb3ff199999999999a", types.NewDoubleValue(1.1)},
+		{"f97c00", types.NewDoubleValue(math.Inf(1))},
+		{"f4", types.NewBooleanValue(false)},
+		{"f5", types.NewBooleanValue(true)},
+		{"f6", types.NewNullValue()},
+		{"f7", types.NewNullValue()},
+		{"c074323031332d30332d32315432303a30343a30305a", types.NewTimestampValue(ts)},
+		{"c11a514b67b0", types.NewTimestampValue(ts)},
+		{"c1fb41d452d9ec200000", types.NewTimestampValue(ts.Add(500 * time.Millisecond))},
+		{"c48221196ab3", types.NewDecimalValue(big.NewInt(27315), 2)},
+		{"d82076687474703a2f2f7777772e6578616d706c652e636f6d", types.NewTextValue("http://www.example.com")},
+		{"4401020304", types.NewBlobValue([]byte{1, 2, 3, 4})},
+		{"6449455446", types.NewTextValue("IETF")},
+		{"62c3bc", types.NewTextValue("ü")},
+		{"5f42010243030405ff", types.NewBlobValue([]byte{1, 2, 3, 4, 5})},
+		{"7f657374726561646d696e67ff", types.NewTextValue("streaming")},
+		{"d82550a0eebc999c0b4ef8bb6d6bb9bd380a11", types.NewUUIDValue([16]byte{0xa0, 0xee, 0xbc, 0x99, 0x9c, 0x0b, 0x4e, 0xf8, 0xbb, 0x6d, 0x6b, 0xb9, 0xbd, 0x38, 0x0a, 0x11})},
+	}
+
+	for _, test := range tests {
+		t.Run(test.hex, func(t *testing.T) {
+			data, err := hex.DecodeString(test.hex)
+			require.NoError(t, err)
+
+			v, err := row.ParseCBORValue(data)
+			require.NoError(t, err)
+			require.Equal(t, test.want.Type(), v.Type())
+			ok, err := v.EQ(test.want)
+			require.NoError(t, err)
+			require.True(t, ok, "got %v", v)
+		})
+	}
+
+	for _, h := range []string{"", "18", "62c3", "80", "a0", "c0f5", "c48201f5", "d8254101", "5f6161ff", "1c"} {
+		t.Run("invalid "+h, func(t *testing.T) {
+			data, err := hex.DecodeString(h)
+			require.NoError(t, err)
+
+			_, err = row.ParseCBORValue(data)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestMarshalCBOR(t *testing.T) {
+	coef, _ := new(big.Int).SetString("-123456789012345678901234567890", 10)
+
+	cb := row.NewColumnBuffer().
+		Add("a", types.NewIntegerValue(-500)).
+		Add("b", types.NewBigintValue(math.MaxInt64)).
+		Add("c", types.NewDoubleValue(1.1)).
+		Add("d", types.NewDoubleValue(math.NaN())).
+		Add("e", types.NewDecimalValue(big.NewInt(27315), 2)).
+		Add("f", types.NewDecimalValue(coef, 10)).
+		Add("g", types.NewTextValue("foo")).
+		Add("h", types.NewBlobValue([]byte{0, 255})).
+		Add("i", types.NewTimestampValue(time.Date(2024, 5, 1, 10, 30, 0, 123456000, time.UTC))).
+		Add("j", types.NewUUIDValue([16]byte{1, 2, 3})).
+		Add("k", types.NewBooleanValue(true)).
+		Add("l", types.NewNullValue())
+
+	data, err := row.MarshalCBOR(cb)
+	require.NoError(t, err)
+
+	// the encoding of the first members
+	require.Equal(t, "ac6161"+"3901f3"+"6162"+"1b7fffffffffffffff"+"6163"+"fb3ff199999999999a", hex.EncodeToString(data[:28]))
+
+	var got row.ColumnBuffer
+	require.NoError(t, got.UnmarshalCBOR(data))
+	require.Equal(t, cb.Len(), got.Len())
+
+	err = cb.Iterate(func(column string, want types.Value) error {
+		v, err := got.Get(column)
+		require.NoError(t, err)
+		require.Equal(t, want.Type(), v.Type(), column)
+		ok, err := v.EQ(want)
+		require.NoError(t, err)
+		require.True(t, ok || want.Type() == types.TypeNull, "%s: got %v, want %v", column, v, want)
+		return nil
+	})
+	require.NoError(t, err)
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, h := range []string{"80", "a1016161", "a161610f00", "a16161", "a1616181f5", "bf6161f5"} {
+			var cb row.ColumnBuffer
+			data, err := hex.DecodeString(h)
+			require.NoError(t, err)
+			require.Error(t, cb.UnmarshalCBOR(data), h)
+		}
+	})
+
+	t.Run("indefinite length", func(t *testing.T) {
+		var cb row.ColumnBuffer
+		data, err := hex.DecodeString("bf6161f56162f6ff")
+		require.NoError(t, err)
+		require.NoError(t, cb.UnmarshalCBOR(data))
+		require.Equal(t, `{"a": true, "b": null}`, cb.String())
+	})
+}
@@ -8,7 +8,8 @@ import (
 	"github.com/cockroachdb/errors"
 )
 
-func parseJSONValue(dataType jsonparser.ValueType, data []byte) (v types.Value, err error) {
+// ParseJSONValue converts a scalar JSON value to a Chai value.
+func ParseJSONValue(dataType jsonparser.ValueType, data []byte) (v types.Value, err error) {
 	switch dataType {
 	case jsonparser.Null:
 		return types.NewNullValue(), nil
@@ -0,0 +1,363 @@
+package row
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// msgpackTimestamp is the extension type of the MessagePack timestamps.
+const msgpackTimestamp = -1
+
+// MarshalMsgpack encodes a row to a MessagePack map.
+// The values are converted like in MarshalJSON: decimals and UUIDs are encoded as strings,
+// except for blobs, encoded as binary data, and timestamps, encoded with the timestamp
+// extension type.
+func MarshalMsgpack(r Row) ([]byte, error) {
+	n, err := Length(r)
+	if err != nil {
+		return nil, err
+	}
+
+	dst := appendMsgpackMap(nil, n)
+	err = SortColumns(r).Iterate(func(c string, v types.Value) error {
+		dst = appendMsgpackString(dst, c)
+		dst, err = AppendMsgpackValue(dst, v)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dst, nil
+}
+
+// AppendMsgpackValue appends the MessagePack encoding of v to dst.
+func AppendMsgpackValue(dst []byte, v types.Value) ([]byte, error) {
+	if v.V() == nil {
+		return append(dst, 0xc0), nil
+	}
+
+	switch v.Type() {
+	case types.TypeNull:
+		return append(dst, 0xc0), nil
+	case types.TypeBoolean:
+		if types.AsBool(v) {
+			return append(dst, 0xc3), nil
+		}
+		return append(dst, 0xc2), nil
+	case types.TypeInteger, types.TypeBigint:
+		return appendMsgpackInt(dst, types.AsInt64(v)), nil
+	case types.TypeDouble:
+		dst = append(dst, 0xcb)
+		return binary.BigEndian.AppendUint64(dst, math.Float64bits(types.AsFloat64(v))), nil
+	case types.TypeDecimal:
+		return appendMsgpackString(dst, v.String()), nil
+	case types.TypeUUID:
+		return appendMsgpackString(dst, v.(types.UUIDValue).Text()), nil
+	case types.TypeText:
+		return appendMsgpackString(dst, types.AsString(v)), nil
+	case types.TypeBlob:
+		b := types.AsByteSlice(v)
+		dst = appendMsgpackLen(dst, 0xc4, 0xc5, 0xc6, len(b))
+		return append(dst, b...), nil
+	case types.TypeTimestamp:
+		return appendMsgpackTime(dst, types.AsTime(v)), nil
+	}
+
+	return nil, errors.Errorf("unexpected type: %s", v.Type())
+}
+
+// appendMsgpackLen appends the header of a map, a string or binary data of length n,
+// given the headers of its 8, 16 and 32-bit forms.
+func appendMsgpackLen(dst []byte, h8, h16, h32 byte, n int) []byte {
+	switch {
+	case h8 != 0 && n <= math.MaxUint8:
+		return append(dst, h8, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(dst, h16), uint16(n))
+	}
+
+	return binary.BigEndian.AppendUint32(append(dst, h32), uint32(n))
+}
+
+func appendMsgpackMap(dst []byte, n int) []byte {
+	if n < 16 {
+		return append(dst, 0x80|byte(n))
+	}
+
+	return appendMsgpackLen(dst, 0, 0xde, 0xdf, n)
+}
+
+func appendMsgpackString(dst []byte, s string) []byte {
+	if len(s) < 32 {
+		dst = append(dst, 0xa0|byte(len(s)))
+	} else {
+		dst = appendMsgpackLen(dst, 0xd9, 0xda, 0xdb, len(s))
+	}
+
+	return append(dst, s...)
+}
+
+// appendMsgpackInt encodes x in the smallest possible form.
+func appendMsgpackInt(dst []byte, x int64) []byte {
+	switch {
+	case x >= 0 && x <= math.MaxInt8:
+		return append(dst, byte(x))
+	case x < 0 && x >= -32:
+		return append(dst, byte(x))
+	case x >= math.MinInt8 && x <= math.MaxInt8:
+		return append(dst, 0xd0, byte(x))
+	case x >= math.MinInt16 && x <= math.MaxInt16:
+		return binary.BigEndian.AppendUint16(append(dst, 0xd1), uint16(x))
+	case x >= math.MinInt32 && x <= math.MaxInt32:
+		return binary.BigEndian.AppendUint32(append(dst, 0xd2), uint32(x))
+	}
+
+	return binary.BigEndian.AppendUint64(append(dst, 0xd3), uint64(x))
+}
+
+// appendMsgpackTime encodes t with the timestamp extension type,
+// in its 32, 64 or 96-bit form.
+func appendMsgpackTime(dst []byte, t time.Time) []byte {
+	sec, nsec := t.Unix(), uint64(t.Nanosecond())
+	switch {
+	case sec>>34 == 0 && nsec == 0:
+		dst = append(dst, 0xd6, 0xff)
+		return binary.BigEndian.AppendUint32(dst, uint32(sec))
+	case sec>>34 == 0:
+		dst = append(dst, 0xd7, 0xff)
+		return binary.BigEndian.AppendUint64(dst, nsec<<34|uint64(sec))
+	}
+
+	dst = append(dst, 0xc7, 12, 0xff)
+	dst = binary.BigEndian.AppendUint32(dst, uint32(nsec))
+	return binary.BigEndian.AppendUint64(dst, uint64(sec))
+}
+
+// NewFromMsgpack decodes a MessagePack map into a row.
+// The keys of the map must be strings and its values scalars, converted
+// like in ParseJSONValue: integers that fit in 32 bits are integers, other integers
+// are bigints or, if they don't fit in 64 bits, doubles. Binary data is converted
+// to blobs and timestamps to timestamps.
+func NewFromMsgpack(data []byte) (Row, error) {
+	d := msgpackDecoder{data: data}
+
+	n, err := d.mapLen()
+	if err != nil {
+		return nil, err
+	}
+
+	cb := NewColumnBuffer()
+	for i := 0; i < n; i++ {
+		k, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		if k.Type() != types.TypeText {
+			return nil, errors.New("msgpack map keys must be strings")
+		}
+
+		v, err := d.value()
+		if err != nil {
+			return nil, errors.Wrapf(err, "column %s", types.AsString(k))
+		}
+
+		cb.Add(types.AsString(k), v)
+	}
+
+	if d.pos != len(d.data) {
+		return nil, errors.New("unexpected data after the msgpack map")
+	}
+
+	return cb, nil
+}
+
+var errMsgpackTruncated = errors.New("truncated msgpack data")
+
+type msgpackDecoder struct {
+	data []byte
+	pos  int
+}
+
+// next returns the next n bytes.
+func (d *msgpackDecoder) next(n int) ([]byte, error) {
+	if n < 0 || n > len(d.data)-d.pos {
+		return nil, errMsgpackTruncated
+	}
+
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+// uint decodes a big-endian unsigned integer of n bytes.
+func (d *msgpackDecoder) uint(n int) (uint64, error) {
+	b, err := d.next(n)
+	if err != nil {
+		return 0, err
+	}
+
+	var x uint64
+	for _, c := range b {
+		x = x<<8 | uint64(c)
+	}
+	return x, nil
+}
+
+func (d *msgpackDecoder) mapLen() (int, error) {
+	b, err := d.next(1)
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case b[0]&0xf0 == 0x80:
+		return int(b[0] & 0x0f), nil
+	case b[0] == 0xde:
+		n, err := d.uint(2)
+		return int(n), err
+	case b[0] == 0xdf:
+		n, err := d.uint(4)
+		return int(n), err
+	}
+
+	return 0, errors.New("msgpack data is not a map")
+}
+
+// value decodes the next scalar value.
+func (d *msgpackDecoder) value() (types.Value, error) {
+	b, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+	c := b[0]
+
+	switch {
+	case c <= 0x7f:
+		return newIntValue(int64(c)), nil
+	case c >= 0xe0:
+		return newIntValue(int64(int8(c))), nil
+	case c&0xe0 == 0xa0:
+		return d.str(int(c & 0x1f))
+	case c <= 0x9f:
+		// fixmap and fixarray
+		return nil, errors.New("nested msgpack maps and arrays are not supported")
+	}
+
+	switch c {
+	case 0xc0:
+		return types.NewNullValue(), nil
+	case 0xc2:
+		return types.NewBooleanValue(false), nil
+	case 0xc3:
+		return types.NewBooleanValue(true), nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.uint(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.next(int(n))
+		if err != nil {
+			return nil, err
+		}
+		return types.NewBlobValue(append([]byte(nil), b...)), nil
+	case 0xc7, 0xc8, 0xc9:
+		n, err := d.uint(1 << (c - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return d.ext(int(n))
+	case 0xca:
+		x, err := d.uint(4)
+		if err != nil {
+			return nil, err
+		}
+		return types.NewDoubleValue(float64(math.Float32frombits(uint32(x)))), nil
+	case 0xcb:
+		x, err := d.uint(8)
+		if err != nil {
+			return nil, err
+		}
+		return types.NewDoubleValue(math.Float64frombits(x)), nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		x, err := d.uint(1 << (c - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		if x > math.MaxInt64 {
+			// like in JSON, integers that don't fit in an int64 are converted to doubles
+			return types.NewDoubleValue(float64(x)), nil
+		}
+		return newIntValue(int64(x)), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (c - 0xd0)
+		x, err := d.uint(size)
+		if err != nil {
+			return nil, err
+		}
+		// sign-extend the integer
+		shift := 64 - 8*size
+		return newIntValue(int64(x<<shift) >> shift), nil
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.ext(1 << (c - 0xd4))
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.uint(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.str(int(n))
+	case 0xdc, 0xdd, 0xde, 0xdf:
+		return nil, errors.New("nested msgpack maps and arrays are not supported")
+	}
+
+	return nil, errors.Errorf("malformed msgpack data 0x%02x", c)
+}
+
+func (d *msgpackDecoder) str(n int) (types.Value, error) {
+	b, err := d.next(n)
+	if err != nil {
+		return nil, err
+	}
+
+	return types.NewTextValue(string(b)), nil
+}
+
+// ext decodes an extension of n bytes. Only timestamps are supported.
+func (d *msgpackDecoder) ext(n int) (types.Value, error) {
+	typ, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+	if int8(typ[0]) != msgpackTimestamp {
+		return nil, errors.Errorf("unsupported msgpack extension type %d", int8(typ[0]))
+	}
+
+	var sec, nsec uint64
+	switch n {
+	case 4:
+		sec, err = d.uint(4)
+	case 8:
+		var x uint64
+		x, err = d.uint(8)
+		sec, nsec = x&(1<<34-1), x>>34
+	case 12:
+		nsec, err = d.uint(4)
+		if err == nil {
+			sec, err = d.uint(8)
+		}
+	default:
+		return nil, errors.New("malformed msgpack timestamp")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if nsec >= 1e9 {
+		return nil, errors.New("malformed msgpack timestamp")
+	}
+
+	return types.NewTimestampValue(time.Unix(int64(sec), int64(nsec)).UTC()), nil
+}
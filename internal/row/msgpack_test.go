@@ -0,0 +1,117 @@
+package row_test
+
+import (
+	"encoding/hex"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMsgpack(t *testing.T) {
+	t.Run("decode", func(t *testing.T) {
+		tests := []struct {
+			hex  string
+			want types.Value
+		}{
+			{"05", types.NewIntegerValue(5)},
+			{"ff", types.NewIntegerValue(-1)},
+			{"cc80", types.NewIntegerValue(128)},
+			{"cdffff", types.NewIntegerValue(65535)},
+			{"ceffffffff", types.NewBigintValue(math.MaxUint32)},
+			{"cf7fffffffffffffff", types.NewBigintValue(math.MaxInt64)},
+			{"cfffffffffffffffff", types.NewDoubleValue(math.MaxUint64)},
+			{"d080", types.NewIntegerValue(-128)},
+			{"d18000", types.NewIntegerValue(-32768)},
+			{"d280000000", types.NewIntegerValue(math.MinInt32)},
+			{"d38000000000000000", types.NewBigintValue(math.MinInt64)},
+			{"ca3fc00000", types.NewDoubleValue(1.5)},
+			{"cb3ff199999999999a", types.NewDoubleValue(1.1)},
+			{"c0", types.NewNullValue()},
+			{"c2", types.NewBooleanValue(false)},
+			{"c3", types.NewBooleanValue(true)},
+			{"a3666f6f", types.NewTextValue("foo")},
+			{"d903666f6f", types.NewTextValue("foo")},
+			{"c40200ff", types.NewBlobValue([]byte{0, 255})},
+			{"d6ff5e0be100", types.NewTimestampValue(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))},
+			{"d7ff1d6f28005e0be100", types.NewTimestampValue(time.Date(2020, 1, 1, 0, 0, 0, 123456000, time.UTC))},
+			{"c70cff00000000ffffffffed300880", types.NewTimestampValue(time.Date(1960, 1, 1, 0, 0, 0, 0, time.UTC))},
+		}
+
+		for _, test := range tests {
+			t.Run(test.hex, func(t *testing.T) {
+				data, err := hex.DecodeString("81a161" + test.hex)
+				require.NoError(t, err)
+
+				r, err := row.NewFromMsgpack(data)
+				require.NoError(t, err)
+				v, err := r.Get("a")
+				require.NoError(t, err)
+				require.Equal(t, test.want.Type(), v.Type())
+				ok, err := v.EQ(test.want)
+				require.NoError(t, err)
+				require.True(t, ok || v.Type() == types.TypeNull, "got %v", v)
+			})
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, h := range []string{"", "91a161", "81", "8105a161", "81a16181a161c0", "81a16191c0", "81a161d4010000", "81a161c1", "81a161a3666f", "81a161c081"} {
+			data, err := hex.DecodeString(h)
+			require.NoError(t, err)
+
+			_, err = row.NewFromMsgpack(data)
+			require.Error(t, err, h)
+		}
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		cb := row.NewColumnBuffer().
+			Add("a", types.NewIntegerValue(-500)).
+			Add("b", types.NewBigintValue(math.MaxInt64)).
+			Add("c", types.NewDoubleValue(1.1)).
+			Add("d", types.NewTextValue("foo")).
+			Add("e", types.NewBlobValue([]byte{0, 255})).
+			Add("f", types.NewTimestampValue(time.Date(2024, 5, 1, 10, 30, 0, 123456000, time.UTC))).
+			Add("g", types.NewTimestampValue(time.Date(1900, 5, 1, 10, 30, 0, 0, time.UTC))).
+			Add("h", types.NewBooleanValue(true)).
+			Add("i", types.NewNullValue())
+
+		data, err := row.MarshalMsgpack(cb)
+		require.NoError(t, err)
+
+		got, err := row.NewFromMsgpack(data)
+		require.NoError(t, err)
+
+		err = cb.Iterate(func(column string, want types.Value) error {
+			v, err := got.Get(column)
+			require.NoError(t, err)
+			require.Equal(t, want.Type(), v.Type(), column)
+			ok, err := v.EQ(want)
+			require.NoError(t, err)
+			require.True(t, ok || want.Type() == types.TypeNull, "%s: got %v, want %v", column, v, want)
+			return nil
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("like JSON", func(t *testing.T) {
+		cb := row.NewColumnBuffer().
+			Add("b", types.NewDecimalValue(big.NewInt(1250), 2)).
+			Add("a", types.NewUUIDValue([16]byte{1}))
+
+		data, err := row.MarshalMsgpack(cb)
+		require.NoError(t, err)
+
+		// the columns are sorted, decimals and UUIDs are strings
+		got, err := row.NewFromMsgpack(data)
+		require.NoError(t, err)
+		j, err := row.MarshalJSON(got)
+		require.NoError(t, err)
+		require.Equal(t, `{"a": "01000000-0000-0000-0000-000000000000", "b": "12.50"}`, string(j))
+	})
+}
@@ -2,8 +2,10 @@ package row_test
 
 import (
 	"encoding/json"
+	"fmt"
 	"testing"
 	"time"
+	"unsafe"
 
 	"github.com/cockroachdb/errors"
 	"github.com/stretchr/testify/require"
@@ -178,6 +180,38 @@ func TestColumnBuffer(t *testing.T) {
 		require.NoError(t, err)
 		require.JSONEq(t, `{"a":1, "c":1, "e":1}`, string(got))
 	})
+
+	t.Run("Clone", func(t *testing.T) {
+		b := []byte("hello")
+		text := []byte("world")
+		buf := row.NewColumnBuffer().
+			Add("a", types.NewBlobValue(b)).
+			Add("b", types.NewTextValue(unsafe.String(&text[0], len(text))))
+
+		cp := buf.Clone()
+		b[0], text[0] = 'j', 'W'
+		require.Equal(t, `{"a": "aGVsbG8=", "b": "world"}`, cp.String())
+
+		require.NoError(t, cp.Delete("a"))
+		require.Equal(t, 2, buf.Len())
+	})
+
+	t.Run("Freeze", func(t *testing.T) {
+		buf := row.NewColumnBuffer().Add("a", types.NewIntegerValue(1)).Freeze()
+		require.True(t, buf.Frozen())
+
+		require.ErrorIs(t, buf.Set("a", types.NewIntegerValue(2)), row.ErrFrozen)
+		require.ErrorIs(t, buf.Replace("a", types.NewIntegerValue(2)), row.ErrFrozen)
+		require.ErrorIs(t, buf.Delete("a"), row.ErrFrozen)
+		require.ErrorIs(t, buf.Copy(buf), row.ErrFrozen)
+		require.Panics(t, func() { buf.Add("b", types.NewIntegerValue(2)) })
+		require.Panics(t, buf.Reset)
+		require.Equal(t, `{"a": 1}`, buf.String())
+
+		cp := buf.Clone()
+		require.False(t, cp.Frozen())
+		require.NoError(t, cp.Set("a", types.NewIntegerValue(2)))
+	})
 }
 
 func TestNewFromStruct(t *testing.T) {
@@ -378,6 +412,106 @@ func TestNewFromStruct(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, types.NewBigintValue(10), v)
 	})
+
+	t.Run("ValueMarshaler", func(t *testing.T) {
+		type s struct {
+			A point
+			B *point
+			C celsius
+		}
+
+		d, err := row.NewFromStruct(&s{A: point{1, 2}, B: &point{3, 4}, C: 21.5})
+		require.NoError(t, err)
+		testutil.RequireRowEqual(t, row.NewColumnBuffer().
+			Add("a", types.NewTextValue("1,2")).
+			Add("b", types.NewTextValue("3,4")).
+			Add("c", types.NewDoubleValue(294.65)), d)
+
+		// a struct passed by value is copied
+		d, err = row.NewFromStruct(s{A: point{5, 6}})
+		require.NoError(t, err)
+		v, err := d.Get("a")
+		require.NoError(t, err)
+		require.Equal(t, types.NewTextValue("5,6"), v)
+
+		_, err = row.NewValue(&point{-1, 0})
+		require.EqualError(t, err, "negative coordinates")
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		type base struct {
+			ID int `chai:"id"`
+		}
+		type audit struct {
+			Created time.Time
+			TTL     time.Duration
+		}
+		type s struct {
+			base
+			Audit   audit             `chai:",inline"`
+			Name    string            `chai:"full_name,omitempty"`
+			Age     int               `chai:",omitempty"`
+			Deleted time.Time         `chai:",omitzero"`
+			Extra   map[string]any    `chai:",inline"`
+			Labels  map[string]string `chai:"labels"`
+		}
+
+		now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		d, err := row.NewFromStruct(&s{
+			base:   base{ID: 1},
+			Audit:  audit{Created: now, TTL: time.Minute},
+			Age:    0,
+			Extra:  map[string]any{"z": true, "y": "foo"},
+			Labels: map[string]string{"env": "prod"},
+		})
+		require.NoError(t, err)
+		testutil.RequireRowEqual(t, row.NewColumnBuffer().
+			Add("id", types.NewBigintValue(1)).
+			Add("created", types.NewTimestampValue(now)).
+			Add("ttl", types.NewBigintValue(int64(time.Minute))).
+			Add("y", types.NewTextValue("foo")).
+			Add("z", types.NewBooleanValue(true)).
+			Add("labels", types.NewTextValue(`{"env":"prod"}`)), d)
+
+		d, err = row.NewFromStruct(&s{Name: "foo", Age: 10, Deleted: now})
+		require.NoError(t, err)
+		testutil.RequireRowEqual(t, row.NewColumnBuffer().
+			Add("id", types.NewBigintValue(0)).
+			Add("created", types.NewTimestampValue(time.Time{})).
+			Add("ttl", types.NewBigintValue(0)).
+			Add("full_name", types.NewTextValue("foo")).
+			Add("age", types.NewBigintValue(10)).
+			Add("deleted", types.NewTimestampValue(now)).
+			Add("labels", types.NewNullValue()), d)
+
+		_, err = row.NewFromStruct(&struct {
+			A int `chai:",inline"`
+		}{})
+		require.EqualError(t, err, "cannot inline field A of type int")
+
+		_, err = row.NewFromStruct(&struct {
+			A int `chai:",omitnull"`
+		}{})
+		require.EqualError(t, err, `unknown option "omitnull" in the tag of field A`)
+	})
+}
+
+// point is stored as a text.
+type point struct{ X, Y int }
+
+func (p *point) MarshalChaiValue() (any, error) {
+	if p.X < 0 || p.Y < 0 {
+		return nil, errors.New("negative coordinates")
+	}
+
+	return fmt.Sprintf("%d,%d", p.X, p.Y), nil
+}
+
+// celsius is stored in kelvins.
+type celsius float64
+
+func (c celsius) MarshalChaiValue() (any, error) {
+	return float64(c) + 273.15, nil
 }
 
 type foo struct {
@@ -1,6 +1,8 @@
 package row
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"math"
 	"reflect"
@@ -26,6 +28,15 @@ type Row interface {
 	MarshalJSON() ([]byte, error)
 }
 
+// A RawTextGetter is a row that gives access to the bytes of its TEXT values,
+// without decoding nor copying them, such as the rows read from a table.
+type RawTextGetter interface {
+	// RawText returns the bytes of the TEXT value of the column.
+	// It returns false if they are not available, in which case Get must be used.
+	// The bytes must not be modified nor retained.
+	RawText(column string) ([]byte, bool, error)
+}
+
 // Length returns the number of columns of a row.
 func Length(r Row) (int, error) {
 	if cb, ok := r.(*ColumnBuffer); ok {
@@ -132,11 +143,29 @@ func NewFromStruct(s any) (Row, error) {
 		return nil, errors.New("expected struct or pointer to struct")
 	}
 
+	// copy structs passed by value to call the ValueMarshalers
+	// implemented with a pointer receiver
+	if !ref.CanAddr() {
+		cp := reflect.New(ref.Type()).Elem()
+		cp.Set(ref)
+		ref = cp
+	}
+
 	return newFromStruct(ref)
 }
 
 func newFromStruct(ref reflect.Value) (Row, error) {
 	var cb ColumnBuffer
+	if err := addStructFields(&cb, ref); err != nil {
+		return nil, err
+	}
+
+	return &cb, nil
+}
+
+// addStructFields adds the fields of a struct to cb, following their tags.
+// See fieldTag.
+func addStructFields(cb *ColumnBuffer, ref reflect.Value) error {
 	l := ref.NumField()
 	tp := ref.Type()
 
@@ -146,61 +175,138 @@ func newFromStruct(ref reflect.Value) (Row, error) {
 			continue
 		}
 
+		sf := tp.Field(i)
+		ft, err := parseFieldTag(sf)
+		if err != nil {
+			return err
+		}
+		if ft.skip {
+			continue
+		}
+
+		isUnexported := sf.PkgPath != ""
+		if isUnexported && !(sf.Anonymous && ft.inline) {
+			continue
+		}
+
+		if ft.omit(f) {
+			continue
+		}
+
 		if f.Kind() == reflect.Ptr {
 			if f.IsNil() {
 				continue
 			}
 
-			f = f.Elem()
+			if ft.inline {
+				f = f.Elem()
+			}
 		}
 
-		sf := tp.Field(i)
-
-		isUnexported := sf.PkgPath != ""
-
-		if sf.Anonymous {
-			if isUnexported && f.Kind() != reflect.Struct {
-				continue
-			}
-			d, err := newFromStruct(f)
-			if err != nil {
-				return nil, err
+		if ft.inline {
+			if f.Kind() == reflect.Map {
+				err = addMapEntries(cb, f)
+			} else {
+				err = addStructFields(cb, f)
 			}
-			err = d.Iterate(func(column string, value types.Value) error {
-				cb.Add(column, value)
-				return nil
-			})
 			if err != nil {
-				return nil, err
+				return err
 			}
 			continue
-		} else if isUnexported {
-			continue
 		}
 
-		v, err := NewValue(f.Interface())
+		x := f.Interface()
+		// use the ValueMarshaler implemented with a pointer receiver
+		if f.CanAddr() {
+			if m, ok := f.Addr().Interface().(ValueMarshaler); ok {
+				x = m
+			}
+		}
+
+		var v types.Value
+		if _, ok := x.(ValueMarshaler); !ok && IsJSONType(f.Type()) {
+			v, err = NewJSONValue(f)
+		} else {
+			v, err = NewValue(x)
+		}
 		if err != nil {
-			return nil, err
+			return errors.Wrapf(err, "field %s", sf.Name)
 		}
 
-		column := strings.ToLower(sf.Name)
-		if gtag, ok := sf.Tag.Lookup("chai"); ok {
-			if gtag == "-" {
-				continue
-			}
-			column = gtag
+		cb.Add(ft.column, v)
+	}
+
+	return nil
+}
+
+// addMapEntries adds the entries of a map[string]T to cb, sorted by key.
+func addMapEntries(cb *ColumnBuffer, m reflect.Value) error {
+	keys := m.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+
+	for _, k := range keys {
+		v, err := NewValue(m.MapIndex(k).Interface())
+		if err != nil {
+			return errors.Wrapf(err, "map entry %s", k.String())
 		}
 
-		cb.Add(column, v)
+		cb.Add(k.String(), v)
 	}
 
-	return &cb, nil
+	return nil
+}
+
+// IsJSONType returns true if the values of type t are stored as JSON text:
+// maps, slices other than []byte and structs other than time.Time,
+// as well as pointers to these types.
+func IsJSONType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Map:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Uint8
+	case reflect.Struct:
+		return t != timeType
+	}
+
+	return false
+}
+
+// NewJSONValue encodes a map, a slice or a struct in JSON and returns it as text.
+// A nil map, slice or pointer is converted to NULL.
+func NewJSONValue(m reflect.Value) (types.Value, error) {
+	if m.Kind() != reflect.Struct && m.IsNil() {
+		return types.NewNullValue(), nil
+	}
+
+	data, err := json.Marshal(m.Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	return types.NewTextValue(string(data)), nil
+}
+
+// A ValueMarshaler is a type that converts itself to a value, like json.Marshaler.
+// MarshalChaiValue returns a Go value of any of the types supported by NewValue.
+type ValueMarshaler interface {
+	MarshalChaiValue() (any, error)
 }
 
 // NewValue creates a value whose type is infered from x.
 func NewValue(x any) (types.Value, error) {
 	// Attempt exact matches first:
 	switch v := x.(type) {
+	case types.Value:
+		return v, nil
+	case ValueMarshaler:
+		return marshalValue(v)
 	case time.Duration:
 		return types.NewBigintValue(v.Nanoseconds()), nil
 	case time.Time:
@@ -236,6 +342,14 @@ func NewValue(x any) (types.Value, error) {
 			return types.NewBlobValue(v.Bytes()), nil
 		}
 		return nil, errors.Errorf("unsupported slice type: %T", x)
+	case reflect.Array:
+		// 16-byte arrays, such as the UUID types of most Go packages, are stored as UUIDs
+		if v.Type().Elem().Kind() == reflect.Uint8 && v.Len() == 16 {
+			var u [16]byte
+			reflect.Copy(reflect.ValueOf(&u).Elem(), v)
+			return types.NewUUIDValue(u), nil
+		}
+		return nil, errors.Errorf("unsupported array type: %T", x)
 	case reflect.Interface:
 		if v.IsNil() {
 			return types.NewNullValue(), nil
@@ -246,6 +360,18 @@ func NewValue(x any) (types.Value, error) {
 	return nil, NewErrUnsupportedType(x, "")
 }
 
+func marshalValue(m ValueMarshaler) (types.Value, error) {
+	x, err := m.MarshalChaiValue()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := x.(ValueMarshaler); ok {
+		return nil, errors.Errorf("%T.MarshalChaiValue returned a ValueMarshaler", m)
+	}
+
+	return NewValue(x)
+}
+
 // NewFromCSV takes a list of headers and columns and returns an row.
 // Each header will be assigned as the key and each corresponding column as a text value.
 // The length of headers and columns must be the same.
@@ -256,9 +382,13 @@ func NewFromCSV(headers, columns []string) Row {
 	return fb
 }
 
+// ErrFrozen is returned when modifying a frozen ColumnBuffer.
+var ErrFrozen = errors.New("column buffer is frozen")
+
 // ColumnBuffer stores a group of columns in memory. It implements the Row interface.
 type ColumnBuffer struct {
 	columns []Column
+	frozen  bool
 }
 
 // NewColumnBuffer creates a ColumnBuffer.
@@ -272,8 +402,12 @@ func (cb *ColumnBuffer) MarshalJSON() ([]byte, error) {
 }
 
 func (cb *ColumnBuffer) UnmarshalJSON(data []byte) error {
+	if cb.frozen {
+		return ErrFrozen
+	}
+
 	return jsonparser.ObjectEach(data, func(key []byte, value []byte, dataType jsonparser.ValueType, offset int) error {
-		v, err := parseJSONValue(dataType, value)
+		v, err := ParseJSONValue(dataType, value)
 		if err != nil {
 			return err
 		}
@@ -294,13 +428,19 @@ type Column struct {
 }
 
 // Add a field to the buffer.
+// It panics if the buffer is frozen.
 func (cb *ColumnBuffer) Add(column string, v types.Value) *ColumnBuffer {
+	cb.mustNotBeFrozen()
 	cb.columns = append(cb.columns, Column{column, v})
 	return cb
 }
 
 // ScanRow copies all the columns of d to the buffer.
 func (cb *ColumnBuffer) ScanRow(r Row) error {
+	if cb.frozen {
+		return ErrFrozen
+	}
+
 	return r.Iterate(func(f string, v types.Value) error {
 		cb.Add(f, v)
 		return nil
@@ -320,6 +460,10 @@ func (cb ColumnBuffer) Get(column string) (types.Value, error) {
 
 // Set replaces a column if it already exists or creates one if not.
 func (cb *ColumnBuffer) Set(column string, v types.Value) error {
+	if cb.frozen {
+		return ErrFrozen
+	}
+
 	_, err := cb.Get(column)
 	if errors.Is(err, types.ErrColumnNotFound) {
 		cb.Add(column, v)
@@ -348,6 +492,10 @@ func (cb ColumnBuffer) Iterate(fn func(column string, value types.Value) error)
 
 // Delete a column from the buffer.
 func (cb *ColumnBuffer) Delete(column string) error {
+	if cb.frozen {
+		return ErrFrozen
+	}
+
 	for i := range cb.columns {
 		if cb.columns[i].Name == column {
 			cb.columns = append(cb.columns[0:i], cb.columns[i+1:]...)
@@ -360,6 +508,10 @@ func (cb *ColumnBuffer) Delete(column string) error {
 
 // Replace the value of the column by v.
 func (cb *ColumnBuffer) Replace(column string, v types.Value) error {
+	if cb.frozen {
+		return ErrFrozen
+	}
+
 	for i := range cb.columns {
 		if cb.columns[i].Name == column {
 			cb.columns[i].Value = v
@@ -372,14 +524,80 @@ func (cb *ColumnBuffer) Replace(column string, v types.Value) error {
 
 // Copy every value of the row to the buffer.
 func (cb *ColumnBuffer) Copy(r Row) error {
+	if cb.frozen {
+		return ErrFrozen
+	}
+
 	return r.Iterate(func(column string, value types.Value) error {
 		cb.Add(strings.Clone(column), value)
 		return nil
 	})
 }
 
+// DeepCopy copies every value of the row to the buffer,
+// including the content of text and blob values, which may
+// point to a buffer reused by the row.
+func (cb *ColumnBuffer) DeepCopy(r Row) error {
+	if cb.frozen {
+		return ErrFrozen
+	}
+
+	return r.Iterate(func(column string, value types.Value) error {
+		cb.Add(strings.Clone(column), cloneValue(value))
+		return nil
+	})
+}
+
+// cloneValue copies the content of text and blob values.
+func cloneValue(v types.Value) types.Value {
+	switch v.Type() {
+	case types.TypeText:
+		return types.NewTextValue(strings.Clone(types.AsString(v)))
+	case types.TypeBlob:
+		return types.NewBlobValue(bytes.Clone(types.AsByteSlice(v)))
+	}
+
+	return v
+}
+
+// Clone returns a deep copy of the buffer, which doesn't share any memory with it.
+// The copy is never frozen.
+func (cb *ColumnBuffer) Clone() *ColumnBuffer {
+	cp := ColumnBuffer{
+		columns: make([]Column, len(cb.columns)),
+	}
+	for i, c := range cb.columns {
+		cp.columns[i] = Column{strings.Clone(c.Name), cloneValue(c.Value)}
+	}
+
+	return &cp
+}
+
+// Freeze makes the buffer immutable: the methods modifying it
+// return ErrFrozen, or panic if they don't return an error.
+// A frozen buffer can be shared safely between goroutines.
+func (cb *ColumnBuffer) Freeze() *ColumnBuffer {
+	cb.frozen = true
+	return cb
+}
+
+// Frozen returns true if the buffer is frozen.
+func (cb *ColumnBuffer) Frozen() bool {
+	return cb.frozen
+}
+
+func (cb *ColumnBuffer) mustNotBeFrozen() {
+	if cb.frozen {
+		panic(ErrFrozen)
+	}
+}
+
 // Apply a function to all the values of the buffer.
 func (cb *ColumnBuffer) Apply(fn func(column string, v types.Value) (types.Value, error)) error {
+	if cb.frozen {
+		return ErrFrozen
+	}
+
 	var err error
 
 	for i, c := range cb.columns {
@@ -398,7 +616,9 @@ func (cb ColumnBuffer) Len() int {
 }
 
 // Reset the buffer.
+// It panics if the buffer is frozen.
 func (cb *ColumnBuffer) Reset() {
+	cb.mustNotBeFrozen()
 	cb.columns = cb.columns[:0]
 }
 
@@ -412,7 +632,22 @@ func (cb *ColumnBuffer) ScanCSV(headers, columns []string) {
 	}
 }
 
+// An OrderedRow is a row whose columns keep their order when encoded
+// by MarshalJSON and MarshalMsgpack, instead of being sorted by name.
+type OrderedRow interface {
+	Row
+
+	// OrderedColumns returns true if the order of the columns must be kept.
+	OrderedColumns() bool
+}
+
+// SortColumns returns a row iterating over the columns of r sorted by name,
+// unless r is an OrderedRow keeping their order.
 func SortColumns(r Row) Row {
+	if o, ok := r.(OrderedRow); ok && o.OrderedColumns() {
+		return r
+	}
+
 	return &sortedRow{r}
 }
 
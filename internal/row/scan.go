@@ -2,8 +2,10 @@ package row
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"slices"
 	"strings"
 	"time"
 
@@ -28,6 +30,16 @@ func (e *ErrUnsupportedType) Error() string {
 	return fmt.Sprintf("unsupported type %T. %s", e.Value, e.Msg)
 }
 
+// A ValueUnmarshaler is a type that decodes a value into itself, like json.Unmarshaler.
+// UnmarshalChaiValue receives nil for NULL, or a bool, an int64, a float64,
+// a string, a []byte, a time.Time, or a [16]byte for UUIDs. Decimals are passed
+// as strings.
+type ValueUnmarshaler interface {
+	UnmarshalChaiValue(v any) error
+}
+
+var valueUnmarshalerType = reflect.TypeOf((*ValueUnmarshaler)(nil)).Elem()
+
 // A RowScanner can iterate over a row and scan all the columns.
 type RowScanner interface {
 	ScanRow(Row) error
@@ -62,8 +74,21 @@ func Scan(r Row, targets ...any) error {
 // The decoding of each struct field can be customized by the format string stored
 // under the "chai" key stored in the struct field's tag.
 // The content of the format string is used instead of the struct field name and passed
-// to the Get method.
+// to the Get method. The fields of embedded structs, and of structs tagged with
+// the inline option, are scanned like the fields of t. A map[string]T tagged with
+// the inline option receives the columns that are not mapped to any field.
+// Other maps are decoded from JSON text.
 func StructScan(r Row, t any) error {
+	return structScanPtr(r, t, false)
+}
+
+// StructScanStrict is like StructScan, but returns an error if a column of the row
+// is not mapped to a field of t, unless t has an inline map.
+func StructScanStrict(r Row, t any) error {
+	return structScanPtr(r, t, true)
+}
+
+func structScanPtr(r Row, t any, strict bool) error {
 	if cb, ok := t.(*ColumnBuffer); ok {
 		return cb.Copy(r)
 	}
@@ -82,38 +107,114 @@ func StructScan(r Row, t any) error {
 		ref.Set(reflect.New(ref.Type().Elem()))
 	}
 
-	return structScan(r, ref)
+	return structScan(r, ref, strict)
 }
 
-func structScan(r Row, ref reflect.Value) error {
+func structScan(r Row, ref reflect.Value, strict bool) error {
 	if ref.Type().Implements(reflect.TypeOf((*RowScanner)(nil)).Elem()) {
 		return ref.Interface().(RowScanner).ScanRow(r)
 	}
 
+	s := structScanner{r: r}
+	if err := s.scan(ref); err != nil {
+		return err
+	}
+
+	if s.scanned || (!s.rest.IsValid() && !strict) {
+		return nil
+	}
+
+	return r.Iterate(func(c string, v types.Value) error {
+		if slices.Contains(s.mapped, c) {
+			return nil
+		}
+
+		if !s.rest.IsValid() {
+			return errors.Errorf("column %q is not mapped to a field of %s", c, reflect.Indirect(ref).Type())
+		}
+
+		if s.rest.IsNil() {
+			s.rest.Set(reflect.MakeMap(s.rest.Type()))
+		}
+
+		newV := reflect.New(s.rest.Type().Elem())
+		if err := scanValue(v, newV); err != nil {
+			return errors.Wrapf(err, "column %s", c)
+		}
+
+		s.rest.SetMapIndex(reflect.ValueOf(c), newV.Elem())
+		return nil
+	})
+}
+
+// structScanner scans a row into the fields of a struct.
+type structScanner struct {
+	r Row
+
+	// columns mapped to a field
+	mapped []string
+	// true if a RowScanner may have read any column
+	scanned bool
+
+	// inline map receiving the unmapped columns
+	rest reflect.Value
+}
+
+func (s *structScanner) scan(ref reflect.Value) error {
 	sref := reflect.Indirect(ref)
 	stp := sref.Type()
 	l := sref.NumField()
 	for i := 0; i < l; i++ {
 		f := sref.Field(i)
 		sf := stp.Field(i)
-		if sf.Anonymous {
-			err := structScan(r, f)
-			if err != nil {
-				return err
-			}
+		ft, err := parseFieldTag(sf)
+		if err != nil {
+			return err
+		}
+		if ft.skip {
 			continue
 		}
-		var name string
-		if gtag, ok := sf.Tag.Lookup("chai"); ok {
-			if gtag == "-" {
+
+		if ft.inline {
+			if f.Kind() == reflect.Ptr {
+				if f.IsNil() {
+					if !f.CanSet() {
+						return errors.Errorf("cannot set embedded pointer to unexported struct %s", f.Type().Elem())
+					}
+					f.Set(reflect.New(f.Type().Elem()))
+				}
+				f = f.Elem()
+			}
+
+			if f.Kind() == reflect.Map {
+				if s.rest.IsValid() {
+					return errors.Errorf("%s has more than one inline map", stp)
+				}
+				s.rest = f
+				continue
+			}
+
+			if rs, ok := asRowScanner(f); ok {
+				// the columns read by a RowScanner are unknown
+				s.scanned = true
+				if err := rs.ScanRow(s.r); err != nil {
+					return err
+				}
 				continue
 			}
 
-			name = gtag
-		} else {
-			name = strings.ToLower(sf.Name)
+			if err := s.scan(f); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !f.CanSet() {
+			continue
 		}
-		v, err := r.Get(name)
+
+		s.mapped = append(s.mapped, ft.column)
+		v, err := s.r.Get(ft.column)
 		if errors.Is(err, types.ErrColumnNotFound) {
 			v = types.NewNullValue()
 		} else if err != nil {
@@ -121,13 +222,26 @@ func structScan(r Row, ref reflect.Value) error {
 		}
 
 		if err := scanValue(v, f); err != nil {
-			return err
+			return errors.Wrapf(err, "field %s", sf.Name)
 		}
 	}
 
 	return nil
 }
 
+// asRowScanner returns the RowScanner implemented by ref or its address, if any.
+func asRowScanner(ref reflect.Value) (RowScanner, bool) {
+	if ref.CanAddr() {
+		ref = ref.Addr()
+	}
+	if !ref.CanInterface() {
+		return nil, false
+	}
+
+	rs, ok := ref.Interface().(RowScanner)
+	return rs, ok
+}
+
 // MapScan decodes the row into a map.
 func MapScan(r Row, t any) error {
 	ref := reflect.ValueOf(t)
@@ -178,6 +292,10 @@ func scanValue(v types.Value, ref reflect.Value) error {
 		return NewErrUnsupportedType(ref, "parameter is not a valid reference")
 	}
 
+	if ok, err := unmarshalValue(v, ref); ok {
+		return err
+	}
+
 	if v.Type() == types.TypeNull {
 		if ref.Type().Kind() != reflect.Ptr {
 			return nil
@@ -201,6 +319,10 @@ func scanValue(v types.Value, ref reflect.Value) error {
 
 	ref = reflect.Indirect(ref)
 
+	if ok, err := unmarshalValue(v, ref); ok {
+		return err
+	}
+
 	// if the user passed a **ptr
 	// make sure it points to a valid value
 	// or create one
@@ -246,6 +368,14 @@ func scanValue(v types.Value, ref reflect.Value) error {
 		ref.SetUint(uint64(x))
 		return nil
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if ref.Type() == durationType && v.Type() == types.TypeText {
+			d, err := time.ParseDuration(types.AsString(v))
+			if err != nil {
+				return err
+			}
+			ref.SetInt(int64(d))
+			return nil
+		}
 		v, err := v.CastAs(types.TypeBigint)
 		if err != nil {
 			return err
@@ -282,25 +412,39 @@ func scanValue(v types.Value, ref reflect.Value) error {
 
 		ref.Set(reflect.ValueOf(v.V()))
 		return nil
+	case reflect.Map:
+		return scanJSON(v, ref)
+	case reflect.Struct:
+		if ref.Type() != timeType {
+			return scanJSON(v, ref)
+		}
 	case reflect.Slice:
 		if ref.Type().Elem().Kind() == reflect.Uint8 {
-			if v.Type() != types.TypeText && v.Type() != types.TypeBlob {
-				return fmt.Errorf("cannot scan value of type %s to byte slice", v.Type())
-			}
-			if v.Type() == types.TypeText {
+			switch v.Type() {
+			case types.TypeText:
 				ref.SetBytes([]byte(types.AsString(v)))
-			} else {
+			case types.TypeBlob:
 				ref.SetBytes(types.AsByteSlice(v))
+			case types.TypeUUID:
+				u := v.(types.UUIDValue)
+				ref.SetBytes(u[:])
+			default:
+				return fmt.Errorf("cannot scan value of type %s to byte slice", v.Type())
 			}
 			return nil
 		}
-		return NewErrUnsupportedType(ref.Interface(), "Invalid type")
+		return scanJSON(v, ref)
 	case reflect.Array:
 		if ref.Type().Elem().Kind() == reflect.Uint8 {
-			if v.Type() != types.TypeText && v.Type() != types.TypeBlob {
+			switch v.Type() {
+			case types.TypeText, types.TypeBlob:
+				reflect.Copy(ref, reflect.ValueOf(v.V()))
+			case types.TypeUUID:
+				u := v.(types.UUIDValue)
+				reflect.Copy(ref, reflect.ValueOf(u[:]))
+			default:
 				return fmt.Errorf("cannot scan value of type %s to byte slice", v.Type())
 			}
-			reflect.Copy(ref, reflect.ValueOf(v.V()))
 			return nil
 		}
 		return NewErrUnsupportedType(ref.Interface(), "Invalid type")
@@ -327,6 +471,58 @@ func scanValue(v types.Value, ref reflect.Value) error {
 	return NewErrUnsupportedType(ref.Interface(), "Invalid type")
 }
 
+// scanJSON decodes the JSON text v into a map, a struct or a slice.
+func scanJSON(v types.Value, ref reflect.Value) error {
+	if v.Type() != types.TypeText {
+		return fmt.Errorf("cannot scan value of type %s to %s", v.Type(), ref.Type())
+	}
+
+	x := reflect.New(ref.Type())
+	if err := json.Unmarshal([]byte(types.AsString(v)), x.Interface()); err != nil {
+		return err
+	}
+
+	ref.Set(x.Elem())
+	return nil
+}
+
+// unmarshalValue calls the UnmarshalChaiValue method of ref, or of its address,
+// if it implements ValueUnmarshaler. A nil pointer is allocated, unless v is NULL.
+func unmarshalValue(v types.Value, ref reflect.Value) (bool, error) {
+	if ref.Kind() != reflect.Ptr && ref.CanAddr() {
+		ref = ref.Addr()
+	}
+	if ref.Kind() != reflect.Ptr || !ref.Type().Implements(valueUnmarshalerType) {
+		return false, nil
+	}
+
+	if ref.IsNil() {
+		if v.Type() == types.TypeNull {
+			return true, nil
+		}
+		if !ref.CanSet() {
+			return false, nil
+		}
+		ref.Set(reflect.New(ref.Type().Elem()))
+	}
+
+	return true, ref.Interface().(ValueUnmarshaler).UnmarshalChaiValue(goValue(v))
+}
+
+// goValue converts v to the Go value passed to ValueUnmarshaler.
+func goValue(v types.Value) any {
+	switch v.Type() {
+	case types.TypeInteger:
+		return types.AsInt64(v)
+	case types.TypeBlob:
+		// copy the byte slice to avoid keeping a reference
+		// to the underlying buffer which could be reused
+		return bytes.Clone(types.AsByteSlice(v))
+	}
+
+	return v.V()
+}
+
 // ScanRow scans a row into dest which must be either a struct pointer, a map or a map pointer.
 func ScanRow(r Row, t any) error {
 	ref := reflect.ValueOf(t)
@@ -342,7 +538,7 @@ func ScanRow(r Row, t any) error {
 		if ref.IsNil() {
 			ref.Set(reflect.New(ref.Type().Elem()))
 		}
-		return structScan(r, ref)
+		return structScan(r, ref, false)
 	default:
 		return errors.New("target must be a either a pointer to struct, a map or a map pointer")
 	}
@@ -1,10 +1,14 @@
 package row_test
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/testutil"
 	"github.com/chaisql/chai/internal/types"
 	"github.com/stretchr/testify/require"
 )
@@ -120,4 +124,157 @@ func TestScan(t *testing.T) {
 		err := row.StructScan(d, &b)
 		require.Error(t, err)
 	})
+
+	t.Run("ValueUnmarshaler", func(t *testing.T) {
+		var s struct {
+			A upper
+			B *upper
+			C *upper
+			D upper
+		}
+
+		d := row.NewColumnBuffer().
+			Add("a", types.NewTextValue("foo")).
+			Add("b", types.NewIntegerValue(10)).
+			Add("c", types.NewNullValue()).
+			Add("d", types.NewNullValue())
+		err := row.StructScan(d, &s)
+		require.NoError(t, err)
+		require.Equal(t, upper("FOO"), s.A)
+		require.Equal(t, upper("INT64:10"), *s.B)
+		require.Nil(t, s.C)
+		require.Equal(t, upper("NULL"), s.D)
+
+		var u upper
+		err = row.ScanValue(types.NewBlobValue([]byte("bar")), &u)
+		require.NoError(t, err)
+		require.Equal(t, upper("BAR"), u)
+
+		var p *upper
+		err = row.ScanValue(types.NewTextValue("baz"), &p)
+		require.NoError(t, err)
+		require.Equal(t, upper("BAZ"), *p)
+
+		err = row.ScanValue(types.NewDoubleValue(1.5), &u)
+		require.EqualError(t, err, "unexpected float64")
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		type Base struct {
+			ID int `chai:"id"`
+		}
+		type audit struct {
+			TTL time.Duration
+		}
+		var s struct {
+			*Base
+			Audit  audit             `chai:",inline"`
+			Name   string            `chai:"full_name,omitempty"`
+			Labels map[string]string `chai:"labels"`
+			Extra  map[string]any    `chai:",inline"`
+		}
+
+		d := row.NewColumnBuffer().
+			Add("id", types.NewIntegerValue(1)).
+			Add("ttl", types.NewTextValue("1m30s")).
+			Add("full_name", types.NewTextValue("foo")).
+			Add("labels", types.NewTextValue(`{"env":"prod"}`)).
+			Add("x", types.NewBooleanValue(true))
+		err := row.StructScan(d, &s)
+		require.NoError(t, err)
+		require.Equal(t, 1, s.ID)
+		require.Equal(t, 90*time.Second, s.Audit.TTL)
+		require.Equal(t, "foo", s.Name)
+		require.Equal(t, map[string]string{"env": "prod"}, s.Labels)
+		require.Equal(t, map[string]any{"x": true}, s.Extra)
+
+		d = row.NewColumnBuffer().Add("ttl", types.NewBigintValue(int64(time.Second)))
+		err = row.StructScan(d, &s)
+		require.NoError(t, err)
+		require.Equal(t, time.Second, s.Audit.TTL)
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		type item struct {
+			Name string `json:"name"`
+			Qty  int    `json:"qty"`
+		}
+		type order struct {
+			ID       int
+			Shipping struct {
+				City string `json:"city"`
+			}
+			Items []item
+			Notes []string
+			Paid  *time.Time
+		}
+
+		var o order
+		o.ID = 1
+		o.Shipping.City = "Lyon"
+		o.Items = []item{{"foo", 2}, {"bar", 1}}
+
+		d, err := row.NewFromStruct(&o)
+		require.NoError(t, err)
+		testutil.RequireRowEqual(t, row.NewColumnBuffer().
+			Add("id", types.NewBigintValue(1)).
+			Add("shipping", types.NewTextValue(`{"city":"Lyon"}`)).
+			Add("items", types.NewTextValue(`[{"name":"foo","qty":2},{"name":"bar","qty":1}]`)).
+			Add("notes", types.NewNullValue()), d)
+
+		var got order
+		err = row.StructScan(d, &got)
+		require.NoError(t, err)
+		require.Equal(t, o, got)
+
+		d = row.NewColumnBuffer().Add("items", types.NewIntegerValue(1))
+		err = row.StructScan(d, &got)
+		require.EqualError(t, err, "field Items: cannot scan value of type integer to []row_test.item")
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		type a struct {
+			A int
+			B int `chai:"-"`
+		}
+
+		var s a
+		d := row.NewColumnBuffer().
+			Add("a", types.NewIntegerValue(1)).
+			Add("b", types.NewIntegerValue(2))
+		err := row.StructScan(d, &s)
+		require.NoError(t, err)
+		require.Equal(t, a{A: 1}, s)
+
+		err = row.StructScanStrict(d, &s)
+		require.EqualError(t, err, `column "b" is not mapped to a field of row_test.a`)
+
+		var m struct {
+			a
+			Rest map[string]int `chai:",inline"`
+		}
+		err = row.StructScanStrict(d, &m)
+		require.NoError(t, err)
+		require.Equal(t, map[string]int{"b": 2}, m.Rest)
+	})
+}
+
+// upper is scanned as the upper case version of texts and blobs.
+type upper string
+
+func (u *upper) UnmarshalChaiValue(v any) error {
+	switch x := v.(type) {
+	case nil:
+		*u = "NULL"
+	case string:
+		*u = upper(strings.ToUpper(x))
+	case []byte:
+		*u = upper(strings.ToUpper(string(x)))
+	case int64:
+		*u = upper("INT64:" + strconv.FormatInt(x, 10))
+	default:
+		return fmt.Errorf("unexpected %T", v)
+	}
+
+	return nil
 }
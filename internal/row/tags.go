@@ -0,0 +1,116 @@
+package row
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+// A fieldTag describes how a struct field is mapped to columns.
+// It is read from the "chai" key of the struct field's tag, of the form
+// "name,option,...", where the options are:
+//   - omitempty: the field is omitted if it is false, 0, a nil pointer,
+//     a nil interface or an empty array, slice, map or string.
+//   - omitzero: the field is omitted if it is the zero value of its type,
+//     or if it has an IsZero method returning true, like time.Time.
+//   - inline: the fields of a struct, or the entries of a map[string]T,
+//     are mapped to columns, like the fields of embedded structs.
+//     When scanning, an inline map receives the columns not mapped to other fields.
+//
+// The tag "-" ignores the field.
+type fieldTag struct {
+	column    string
+	omitEmpty bool
+	omitZero  bool
+	inline    bool
+	skip      bool
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// parseFieldTag returns the mapping of a struct field.
+// By default, the column is the lowercased name of the field, and embedded
+// structs are inlined.
+func parseFieldTag(sf reflect.StructField) (fieldTag, error) {
+	tag, ok := sf.Tag.Lookup("chai")
+	if tag == "-" {
+		return fieldTag{skip: true}, nil
+	}
+
+	name, opts, _ := strings.Cut(tag, ",")
+	ft := fieldTag{column: name}
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		switch opt {
+		case "omitempty":
+			ft.omitEmpty = true
+		case "omitzero":
+			ft.omitZero = true
+		case "inline":
+			ft.inline = true
+		default:
+			return ft, errors.Errorf("unknown option %q in the tag of field %s", opt, sf.Name)
+		}
+	}
+
+	// embedded structs without a column name are inlined
+	if sf.Anonymous && (!ok || name == "") {
+		ft.inline = true
+	}
+
+	if ft.inline {
+		t := sf.Type
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		isMap := t.Kind() == reflect.Map && t.Key().Kind() == reflect.String
+		if (t.Kind() != reflect.Struct || t == timeType) && !isMap {
+			if sf.Anonymous {
+				ft.inline = false
+			} else {
+				return ft, errors.Errorf("cannot inline field %s of type %s", sf.Name, sf.Type)
+			}
+		}
+	}
+
+	if ft.column == "" {
+		ft.column = strings.ToLower(sf.Name)
+	}
+
+	return ft, nil
+}
+
+// omit returns true if the value of the field must be omitted.
+func (ft *fieldTag) omit(f reflect.Value) bool {
+	if ft.omitZero {
+		if z, ok := f.Interface().(interface{ IsZero() bool }); ok {
+			if f.Kind() != reflect.Ptr || !f.IsNil() {
+				return z.IsZero()
+			}
+		}
+		if f.IsZero() {
+			return true
+		}
+	}
+
+	if ft.omitEmpty {
+		switch f.Kind() {
+		case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+			return f.Len() == 0
+		case reflect.Bool,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+			reflect.Float32, reflect.Float64,
+			reflect.Interface, reflect.Pointer:
+			return f.IsZero()
+		}
+	}
+
+	return false
+}
@@ -25,6 +25,24 @@ func (p *Parser) parseAlterTableRenameStatement(tableName string) (_ *statement.
 	return &stmt, nil
 }
 
+func (p *Parser) parseAlterTableSwapStatement(tableName string) (_ *statement.AlterTableSwapStmt, err error) {
+	var stmt statement.AlterTableSwapStmt
+	stmt.TableName = tableName
+
+	// Parse "WITH".
+	if err := p.ParseTokens(scanner.WITH); err != nil {
+		return nil, err
+	}
+
+	// Parse other table name.
+	stmt.OtherTableName, err = p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	return &stmt, nil
+}
+
 func (p *Parser) parseAlterTableAddColumnStatement(tableName string) (*statement.AlterTableAddColumnStmt, error) {
 	var stmt statement.AlterTableAddColumnStmt
 	stmt.TableName = tableName
@@ -71,7 +89,9 @@ func (p *Parser) parseAlterStatement() (statement.Statement, error) {
 		return p.parseAlterTableRenameStatement(tableName)
 	case scanner.ADD_KEYWORD:
 		return p.parseAlterTableAddColumnStatement(tableName)
+	case scanner.SWAP:
+		return p.parseAlterTableSwapStatement(tableName)
 	}
 
-	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"ADD", "RENAME"}, pos)
+	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"ADD", "RENAME", "SWAP"}, pos)
 }
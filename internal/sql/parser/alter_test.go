@@ -22,6 +22,9 @@ func TestParserAlterTable(t *testing.T) {
 		{"With error / missing TABLE keyword", "ALTER foo RENAME TO bar", nil, true},
 		{"With error / two identifiers for table name", "ALTER TABLE foo baz RENAME TO bar", nil, true},
 		{"With error / two identifiers for new table name", "ALTER TABLE foo RENAME TO bar baz", nil, true},
+		{"Swap", "ALTER TABLE foo SWAP WITH bar", &statement.AlterTableSwapStmt{TableName: "foo", OtherTableName: "bar"}, false},
+		{"With error / missing WITH keyword", "ALTER TABLE foo SWAP bar", nil, true},
+		{"With error / missing table name to swap with", "ALTER TABLE foo SWAP WITH", nil, true},
 	}
 
 	for _, test := range tests {
@@ -83,6 +86,17 @@ func TestParserAlterTableAddColumn(t *testing.T) {
 				DefaultValue: expr.Constraint(expr.LiteralValue{Value: types.NewIntegerValue(0)}),
 			},
 		}, false},
+		{"With collation", "ALTER TABLE foo ADD COLUMN bar TEXT NOT NULL COLLATE nocase", &statement.AlterTableAddColumnStmt{
+			TableName: "foo",
+			ColumnConstraint: &database.ColumnConstraint{
+				Column:    "bar",
+				Type:      types.TypeText,
+				IsNotNull: true,
+				Collation: "nocase",
+			},
+		}, false},
+		{"With collation / non text column", "ALTER TABLE foo ADD COLUMN bar INT COLLATE nocase", nil, true},
+		{"With collation / unknown collation", "ALTER TABLE foo ADD COLUMN bar TEXT COLLATE 'not a locale'", nil, true},
 		{"With error / missing COLUMN keyword", "ALTER TABLE foo ADD bar", nil, true},
 		{"With error / missing column name", "ALTER TABLE foo ADD COLUMN", nil, true},
 	}
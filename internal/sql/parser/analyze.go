@@ -0,0 +1,28 @@
+package parser
+
+import (
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/sql/scanner"
+)
+
+// ANALYZE is not a keyword.
+
+// parseAnalyzeStatement parses a string of the form "ANALYZE [table_name]".
+func (p *Parser) parseAnalyzeStatement() (statement.Statement, error) {
+	// Parse "ANALYZE".
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if !isContextualKeyword(tok, lit, "ANALYZE") {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"ANALYZE"}, pos)
+	}
+
+	var stmt statement.AnalyzeStmt
+
+	tok, _, lit = p.ScanIgnoreWhitespace()
+	if tok == scanner.IDENT {
+		stmt.TableName = lit
+	} else {
+		p.Unscan()
+	}
+
+	return &stmt, nil
+}
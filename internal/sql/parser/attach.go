@@ -0,0 +1,67 @@
+package parser
+
+import (
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/sql/scanner"
+)
+
+// ATTACH, DETACH and DATABASE are not keywords either.
+
+// parseAttachStatement parses a string of the form "ATTACH [DATABASE] 'path' AS name".
+func (p *Parser) parseAttachStatement() (statement.Statement, error) {
+	// Parse "ATTACH".
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if !isContextualKeyword(tok, lit, "ATTACH") {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"ATTACH"}, pos)
+	}
+
+	p.parseOptionalDatabaseKeyword()
+
+	var stmt statement.AttachStmt
+
+	// Parse path.
+	tok, pos, lit = p.ScanIgnoreWhitespace()
+	if tok != scanner.STRING {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"path"}, pos)
+	}
+	stmt.Path = lit
+
+	// Parse "AS name".
+	if err := p.ParseTokens(scanner.AS); err != nil {
+		return nil, err
+	}
+
+	var err error
+	stmt.Name, err = p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	return &stmt, nil
+}
+
+// parseDetachStatement parses a string of the form "DETACH [DATABASE] name".
+func (p *Parser) parseDetachStatement() (statement.Statement, error) {
+	// Parse "DETACH".
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if !isContextualKeyword(tok, lit, "DETACH") {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"DETACH"}, pos)
+	}
+
+	p.parseOptionalDatabaseKeyword()
+
+	name, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	return &statement.DetachStmt{Name: name}, nil
+}
+
+// parseOptionalDatabaseKeyword skips the DATABASE keyword if present.
+func (p *Parser) parseOptionalDatabaseKeyword() {
+	tok, _, lit := p.ScanIgnoreWhitespace()
+	if !isContextualKeyword(tok, lit, "DATABASE") {
+		p.Unscan()
+	}
+}
@@ -0,0 +1,86 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/sql/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserAttach(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected statement.Statement
+		errored  bool
+	}{
+		{"Attach", "ATTACH 'other.db' AS other", &statement.AttachStmt{Path: "other.db", Name: "other"}, false},
+		{"Attach database", "attach database 'other.db' as other", &statement.AttachStmt{Path: "other.db", Name: "other"}, false},
+		{"Attach without name", "ATTACH 'other.db'", nil, true},
+		{"Attach without path", "ATTACH AS other", nil, true},
+		{"Attach with identifier", "ATTACH other AS other", nil, true},
+		{"Detach", "DETACH other", &statement.DetachStmt{Name: "other"}, false},
+		{"Detach database", "DETACH DATABASE other", &statement.DetachStmt{Name: "other"}, false},
+		{"Detach without name", "DETACH", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
+
+func TestParserQualifiedTableName(t *testing.T) {
+	tests := []struct {
+		name          string
+		s             string
+		database      string
+		table         string
+		errored       bool
+		tableNameFunc func(statement.Statement) (string, string)
+	}{
+		{"Select", "SELECT * FROM other.test", "other", "test", false, func(s statement.Statement) (string, string) {
+			core := s.(*statement.SelectStmt).CompoundSelect[0]
+			return core.Database, core.TableName
+		}},
+		{"Select main", "SELECT * FROM main.test", "", "test", false, func(s statement.Statement) (string, string) {
+			core := s.(*statement.SelectStmt).CompoundSelect[0]
+			return core.Database, core.TableName
+		}},
+		{"Insert", "INSERT INTO other.test (a) VALUES (1)", "other", "test", false, func(s statement.Statement) (string, string) {
+			return s.(*statement.InsertStmt).Database, s.(*statement.InsertStmt).TableName
+		}},
+		{"Update", "UPDATE other.test SET a = 1", "other", "test", false, func(s statement.Statement) (string, string) {
+			return s.(*statement.UpdateStmt).Database, s.(*statement.UpdateStmt).TableName
+		}},
+		{"Delete", "DELETE FROM other.test", "other", "test", false, func(s statement.Statement) (string, string) {
+			return s.(*statement.DeleteStmt).Database, s.(*statement.DeleteStmt).TableName
+		}},
+		{"Missing table", "SELECT * FROM other.", "", "", true, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+
+			db, table := test.tableNameFunc(q.Statements[0])
+			require.Equal(t, test.database, db)
+			require.Equal(t, test.table, table)
+		})
+	}
+}
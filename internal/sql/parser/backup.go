@@ -0,0 +1,30 @@
+package parser
+
+import (
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/sql/scanner"
+)
+
+// BACKUP is not a keyword.
+
+// parseBackupStatement parses a string of the form "BACKUP TO 'path'".
+func (p *Parser) parseBackupStatement() (statement.Statement, error) {
+	// Parse "BACKUP".
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if !isContextualKeyword(tok, lit, "BACKUP") {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"BACKUP"}, pos)
+	}
+
+	// Parse "TO".
+	if err := p.ParseTokens(scanner.TO); err != nil {
+		return nil, err
+	}
+
+	// Parse path.
+	tok, pos, lit = p.ScanIgnoreWhitespace()
+	if tok != scanner.STRING {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"path"}, pos)
+	}
+
+	return &statement.BackupStmt{Path: lit}, nil
+}
@@ -0,0 +1,37 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/sql/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserBackup(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected statement.Statement
+		errored  bool
+	}{
+		{"Backup", "BACKUP TO 'backup.db'", &statement.BackupStmt{Path: "backup.db"}, false},
+		{"Lowercase", "backup to 'backup.db'", &statement.BackupStmt{Path: "backup.db"}, false},
+		{"Without TO", "BACKUP 'backup.db'", nil, true},
+		{"Without path", "BACKUP TO", nil, true},
+		{"With identifier", "BACKUP TO backup", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
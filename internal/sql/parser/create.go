@@ -3,12 +3,17 @@ package parser
 import (
 	"fmt"
 	"math"
+	"strings"
 
+	"github.com/chaisql/chai/internal/collation"
 	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/expr/functions"
 	"github.com/chaisql/chai/internal/query/statement"
 	"github.com/chaisql/chai/internal/sql/scanner"
+	"github.com/chaisql/chai/internal/stringutil"
 	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
 )
 
 // parseCreateStatement parses a create string and returns a Statement AST row.
@@ -30,10 +35,50 @@ func (p *Parser) parseCreateStatement() (statement.Statement, error) {
 		return p.parseCreateIndexStatement(true)
 	case scanner.INDEX:
 		return p.parseCreateIndexStatement(false)
+	case scanner.FULLTEXT:
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.INDEX {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"INDEX"}, pos)
+		}
+
+		stmt, err := p.parseCreateIndexStatement(false)
+		if err != nil {
+			return nil, err
+		}
+		stmt.Info.Fulltext = true
+
+		stmt.Info.Analyzer, err = p.parseFulltextOptions()
+		if err != nil {
+			return nil, err
+		}
+		return stmt, nil
+	case scanner.TEMP:
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.INDEX {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"INDEX"}, pos)
+		}
+
+		stmt, err := p.parseCreateIndexStatement(false)
+		if err != nil {
+			return nil, err
+		}
+		stmt.Temporary = true
+		return stmt, nil
 	case scanner.SEQUENCE:
 		return p.parseCreateSequenceStatement()
 	}
 
+	if isContextualKeyword(tok, lit, "SPATIAL") {
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.INDEX {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"INDEX"}, pos)
+		}
+
+		stmt, err := p.parseCreateIndexStatement(false)
+		if err != nil {
+			return nil, err
+		}
+		stmt.Info.Spatial = true
+		return stmt, nil
+	}
+
 	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"TABLE", "INDEX", "SEQUENCE"}, pos)
 }
 
@@ -61,9 +106,58 @@ func (p *Parser) parseCreateTableStatement() (*statement.CreateTableStmt, error)
 		return nil, err
 	}
 
+	// parse table options
+	err = p.parseTableOptions(&stmt.Info)
+	if err != nil {
+		return nil, err
+	}
+
 	return &stmt, err
 }
 
+// parseTableOptions parses the optional WITH (ttl_column = 'name', archive_table = 'name')
+// clause of a CREATE TABLE statement.
+func (p *Parser) parseTableOptions(info *database.TableInfo) error {
+	if ok, err := p.parseOptional(scanner.WITH, scanner.LPAREN); !ok || err != nil {
+		return err
+	}
+
+	for {
+		opt, err := p.parseIdent()
+		if err != nil {
+			return err
+		}
+
+		var dst *string
+		var expected string
+		switch {
+		case strings.EqualFold(opt, "ttl_column"):
+			dst, expected = &info.TTLColumn, "column name"
+		case strings.EqualFold(opt, "archive_table"):
+			dst, expected = &info.ArchiveTable, "table name"
+		default:
+			return &ParseError{Message: fmt.Sprintf("unknown table option %q", opt)}
+		}
+
+		if err := p.ParseTokens(scanner.EQ); err != nil {
+			return err
+		}
+
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		if tok != scanner.STRING {
+			return newParseError(scanner.Tokstr(tok, lit), []string{expected}, pos)
+		}
+		*dst = lit
+
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+			p.Unscan()
+			break
+		}
+	}
+
+	return p.ParseTokens(scanner.RPAREN)
+}
+
 func (p *Parser) parseConstraints(stmt *statement.CreateTableStmt) error {
 	// Parse ( token.
 	tok, pos, lit := p.ScanIgnoreWhitespace()
@@ -146,11 +240,18 @@ func (p *Parser) parseColumnDefinition() (*database.ColumnConstraint, []*databas
 		return nil, nil, err
 	}
 
-	cc.Type, err = p.parseType()
+	cc.Type, cc.Bits, err = p.parseTypeWithWidth()
 	if err != nil {
 		return nil, nil, err
 	}
 
+	if cc.Type == types.TypeDecimal {
+		cc.Precision, cc.Scale, err = p.parseDecimalModifiers()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
 	var tcs []*database.TableConstraint
 
 LOOP:
@@ -252,6 +353,24 @@ LOOP:
 				Unique:  true,
 				Columns: []string{cc.Column},
 			})
+		case scanner.COLLATE:
+			// if it has already a collation we return an error
+			if cc.Collation != "" {
+				return nil, nil, newParseError(scanner.Tokstr(tok, lit), []string{"CONSTRAINT", ")"}, pos)
+			}
+
+			if cc.Type != types.TypeText {
+				return nil, nil, &ParseError{Message: fmt.Sprintf("cannot use a collation on column %q of type %s", cc.Column, cc.Type)}
+			}
+
+			cc.Collation, err = p.parseCollationName()
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if _, err := collation.Lookup(cc.Collation); err != nil {
+				return nil, nil, &ParseError{Message: err.Error()}
+			}
 		case scanner.CHECK:
 			e, cols, err := p.parseCheckConstraint()
 			if err != nil {
@@ -262,6 +381,26 @@ LOOP:
 				Check:   expr.Constraint(e),
 				Columns: cols,
 			})
+		case scanner.IDENT:
+			// ENUM is not a keyword, to keep it usable as an identifier
+			if !strings.EqualFold(lit, "ENUM") {
+				p.Unscan()
+				break LOOP
+			}
+
+			// if it has already an enum we return an error
+			if len(cc.Enum) > 0 {
+				return nil, nil, newParseError(scanner.Tokstr(tok, lit), []string{"CONSTRAINT", ")"}, pos)
+			}
+
+			if cc.Type != types.TypeText {
+				return nil, nil, &ParseError{Message: fmt.Sprintf("cannot use ENUM on column %q of type %s", cc.Column, cc.Type)}
+			}
+
+			cc.Enum, err = p.parseEnumValues()
+			if err != nil {
+				return nil, nil, err
+			}
 		default:
 			p.Unscan()
 			break LOOP
@@ -271,6 +410,33 @@ LOOP:
 	return &cc, tcs, nil
 }
 
+// parseEnumValues parses the list of values of an ENUM constraint.
+func (p *Parser) parseEnumValues() ([]string, error) {
+	if err := p.ParseTokens(scanner.LPAREN); err != nil {
+		return nil, err
+	}
+
+	var values []string
+	for {
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		if tok != scanner.STRING {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"STRING"}, pos)
+		}
+		if stringutil.Contains(values, lit) {
+			return nil, &ParseError{Message: fmt.Sprintf("duplicate ENUM value %q", lit)}
+		}
+		values = append(values, lit)
+
+		tok, pos, lit = p.ScanIgnoreWhitespace()
+		if tok == scanner.RPAREN {
+			return values, nil
+		}
+		if tok != scanner.COMMA {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{",", ")"}, pos)
+		}
+	}
+}
+
 func (p *Parser) parseTableConstraint(stmt *statement.CreateTableStmt) (*database.TableConstraint, error) {
 	var err error
 
@@ -342,7 +508,7 @@ func (p *Parser) parseTableConstraint(stmt *statement.CreateTableStmt) (*databas
 }
 
 // parseCreateIndexStatement parses a create index string and returns a Statement AST row.
-// This function assumes the CREATE INDEX or CREATE UNIQUE INDEX tokens have already been consumed.
+// This function assumes the CREATE [UNIQUE|FULLTEXT|SPATIAL|TEMP] INDEX tokens have already been consumed.
 func (p *Parser) parseCreateIndexStatement(unique bool) (*statement.CreateIndexStmt, error) {
 	var err error
 	var stmt statement.CreateIndexStmt
@@ -376,21 +542,175 @@ func (p *Parser) parseCreateIndexStatement(unique bool) (*statement.CreateIndexS
 		return nil, err
 	}
 
-	columns, order, err := p.parseColumnList()
-	if err != nil {
+	if err := p.ParseTokens(scanner.LPAREN); err != nil {
 		return nil, err
 	}
-	if len(columns) == 0 {
-		tok, pos, lit := p.ScanIgnoreWhitespace()
-		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"("}, pos)
+
+	// Parse the list of indexed columns or expressions.
+	for i := 0; ; i++ {
+		if i > 0 {
+			if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+				p.Unscan()
+				break
+			}
+		}
+
+		column, e, err := p.parseIndexedExpr()
+		if err != nil {
+			return nil, err
+		}
+
+		stmt.Info.Columns = append(stmt.Info.Columns, column)
+		if e != nil {
+			if stmt.Info.Exprs == nil {
+				stmt.Info.Exprs = make([]database.TableExpression, i, i+1)
+			}
+			stmt.Info.Exprs = append(stmt.Info.Exprs, expr.Constraint(e))
+		} else if stmt.Info.Exprs != nil {
+			stmt.Info.Exprs = append(stmt.Info.Exprs, nil)
+		}
+
+		// Parse optional ASC/DESC token.
+		desc, err := p.parseOptional(scanner.DESC)
+		if err != nil {
+			return nil, err
+		}
+		if desc {
+			stmt.Info.KeySortOrder = stmt.Info.KeySortOrder.SetDesc(i)
+		} else if _, err := p.parseOptional(scanner.ASC); err != nil {
+			return nil, err
+		}
 	}
 
-	stmt.Info.Columns = columns
-	stmt.Info.KeySortOrder = order
+	if err := p.ParseTokens(scanner.RPAREN); err != nil {
+		return nil, err
+	}
+
+	// Parse optional NULLS [NOT] DISTINCT
+	stmt.Info.NullsNotDistinct, err = p.parseNullsDistinct()
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse optional USING HASH
+	stmt.Info.Hash, err = p.parseIndexMethod()
+	if err != nil {
+		return nil, err
+	}
 
 	return &stmt, nil
 }
 
+// parseNullsDistinct parses the optional NULLS [NOT] DISTINCT clause of a CREATE INDEX statement
+// and returns whether NULL values must be considered equal by unique indexes.
+func (p *Parser) parseNullsDistinct() (bool, error) {
+	if tok, _, lit := p.ScanIgnoreWhitespace(); !isContextualKeyword(tok, lit, "NULLS") {
+		p.Unscan()
+		return false, nil
+	}
+
+	not, err := p.parseOptional(scanner.NOT)
+	if err != nil {
+		return false, err
+	}
+
+	if err := p.ParseTokens(scanner.DISTINCT); err != nil {
+		return false, err
+	}
+
+	return not, nil
+}
+
+// parseIndexMethod parses the optional USING HASH clause of a CREATE INDEX statement
+// and returns whether the index is a hash index.
+func (p *Parser) parseIndexMethod() (bool, error) {
+	if tok, _, lit := p.ScanIgnoreWhitespace(); !isContextualKeyword(tok, lit, "USING") {
+		p.Unscan()
+		return false, nil
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); !isContextualKeyword(tok, lit, "HASH") {
+		return false, newParseError(scanner.Tokstr(tok, lit), []string{"HASH"}, pos)
+	}
+
+	return true, nil
+}
+
+// parseIndexedExpr parses an element of the key of an index, which is either
+// a column or an expression of the columns of the table, such as lower(email).
+// For expressions, it returns their SQL representation along with the expression.
+func (p *Parser) parseIndexedExpr() (string, expr.Expr, error) {
+	e, err := p.ParseExpr()
+	if err != nil {
+		return "", nil, err
+	}
+
+	for {
+		pe, ok := e.(expr.Parentheses)
+		if !ok {
+			break
+		}
+		e = pe.E
+	}
+
+	if c, ok := e.(*expr.Column); ok {
+		return c.Name, nil, nil
+	}
+
+	// the value of an indexed expression must only depend on the row
+	var hasColumn bool
+	expr.Walk(e, func(e expr.Expr) bool {
+		switch e.(type) {
+		case *expr.Column:
+			hasColumn = true
+		case expr.NextValueFor, expr.PositionalParam, expr.NamedParam, expr.AggregatorBuilder, *functions.Now:
+			err = &ParseError{Message: fmt.Sprintf("%s cannot be used in an index expression", e)}
+			return false
+		}
+
+		return true
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	if !hasColumn {
+		return "", nil, &ParseError{Message: fmt.Sprintf("index expression %s must refer to at least one column", e)}
+	}
+
+	return e.String(), e, nil
+}
+
+// parseFulltextOptions parses the optional WITH (analyzer = 'name') clause
+// of a CREATE FULLTEXT INDEX statement and returns the name of the analyzer.
+func (p *Parser) parseFulltextOptions() (string, error) {
+	if ok, err := p.parseOptional(scanner.WITH, scanner.LPAREN); !ok || err != nil {
+		return "", err
+	}
+
+	opt, err := p.parseIdent()
+	if err != nil {
+		return "", err
+	}
+	if !strings.EqualFold(opt, "analyzer") {
+		return "", &ParseError{Message: fmt.Sprintf("unknown full-text index option %q", opt)}
+	}
+
+	if err := p.ParseTokens(scanner.EQ); err != nil {
+		return "", err
+	}
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok != scanner.STRING {
+		return "", newParseError(scanner.Tokstr(tok, lit), []string{"analyzer name"}, pos)
+	}
+
+	if err := p.ParseTokens(scanner.RPAREN); err != nil {
+		return "", err
+	}
+
+	return lit, nil
+}
+
 // This function assumes the CREATE SEQUENCE tokens have already been consumed.
 func (p *Parser) parseCreateSequenceStatement() (*statement.CreateSequenceStmt, error) {
 	var stmt statement.CreateSequenceStmt
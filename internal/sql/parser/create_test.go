@@ -5,8 +5,10 @@ import (
 	"testing"
 
 	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/expr"
 	"github.com/chaisql/chai/internal/query/statement"
 	"github.com/chaisql/chai/internal/sql/parser"
+	"github.com/chaisql/chai/internal/tree"
 	"github.com/stretchr/testify/require"
 )
 
@@ -29,9 +31,43 @@ func TestParserCreateIndex(t *testing.T) {
 			Info: database.IndexInfo{
 				IndexName: "idx", Owner: database.Owner{TableName: "test"}, Columns: []string{"foo"}, Unique: true,
 			}, IfNotExists: true}, false},
+		{"Unique nulls not distinct", "CREATE UNIQUE INDEX idx ON test (foo) NULLS NOT DISTINCT", &statement.CreateIndexStmt{
+			Info: database.IndexInfo{
+				IndexName: "idx", Owner: database.Owner{TableName: "test"}, Columns: []string{"foo"}, Unique: true, NullsNotDistinct: true,
+			}}, false},
+		{"Unique nulls distinct", "CREATE UNIQUE INDEX idx ON test (foo) NULLS DISTINCT", &statement.CreateIndexStmt{
+			Info: database.IndexInfo{
+				IndexName: "idx", Owner: database.Owner{TableName: "test"}, Columns: []string{"foo"}, Unique: true,
+			}}, false},
+		{"Nulls without DISTINCT", "CREATE UNIQUE INDEX idx ON test (foo) NULLS NOT", nil, true},
 		{"No name", "CREATE UNIQUE INDEX ON test (foo)", &statement.CreateIndexStmt{
 			Info: database.IndexInfo{Owner: database.Owner{TableName: "test"}, Columns: []string{"foo"}, Unique: true}}, false},
 		{"No name with IF NOT EXISTS", "CREATE UNIQUE INDEX IF NOT EXISTS ON test (foo)", nil, true},
+		{"Temp", "CREATE TEMP INDEX idx ON test (foo)", &statement.CreateIndexStmt{
+			Info: database.IndexInfo{
+				IndexName: "idx", Owner: database.Owner{TableName: "test"}, Columns: []string{"foo"},
+			}, Temporary: true}, false},
+		{"Temp without INDEX", "CREATE TEMP TABLE test (foo)", nil, true},
+		{"Fulltext", "CREATE FULLTEXT INDEX idx ON test (foo)", &statement.CreateIndexStmt{
+			Info: database.IndexInfo{
+				IndexName: "idx", Owner: database.Owner{TableName: "test"}, Columns: []string{"foo"}, Fulltext: true,
+			}}, false},
+		{"Fulltext with analyzer", "CREATE FULLTEXT INDEX idx ON test (foo) WITH (analyzer = 'english')", &statement.CreateIndexStmt{
+			Info: database.IndexInfo{
+				IndexName: "idx", Owner: database.Owner{TableName: "test"}, Columns: []string{"foo"}, Fulltext: true, Analyzer: "english",
+			}}, false},
+		{"Fulltext with unknown option", "CREATE FULLTEXT INDEX idx ON test (foo) WITH (foo = 'english')", nil, true},
+		{"Fulltext with invalid analyzer", "CREATE FULLTEXT INDEX idx ON test (foo) WITH (analyzer = english)", nil, true},
+		{"Spatial", "CREATE SPATIAL INDEX idx ON test (foo)", &statement.CreateIndexStmt{
+			Info: database.IndexInfo{
+				IndexName: "idx", Owner: database.Owner{TableName: "test"}, Columns: []string{"foo"}, Spatial: true,
+			}}, false},
+		{"Spatial without INDEX", "CREATE SPATIAL TABLE test (foo)", nil, true},
+		{"Hash", "CREATE INDEX idx ON test (foo, bar) USING HASH", &statement.CreateIndexStmt{
+			Info: database.IndexInfo{
+				IndexName: "idx", Owner: database.Owner{TableName: "test"}, Columns: []string{"foo", "bar"}, Hash: true,
+			}}, false},
+		{"Unknown index method", "CREATE INDEX idx ON test (foo) USING BTREE", nil, true},
 		{"More than 1 path", "CREATE INDEX idx ON test (foo, bar)",
 			&statement.CreateIndexStmt{
 				Info: database.IndexInfo{
@@ -45,6 +81,30 @@ func TestParserCreateIndex(t *testing.T) {
 			},
 			false},
 		{"No fields", "CREATE INDEX idx ON test", nil, true},
+		{"Expression", "CREATE INDEX idx ON test (lower(foo), bar DESC)",
+			&statement.CreateIndexStmt{
+				Info: database.IndexInfo{
+					IndexName:    "idx",
+					Owner:        database.Owner{TableName: "test"},
+					Columns:      []string{"LOWER(foo)", "bar"},
+					Exprs:        []database.TableExpression{expr.Constraint(parser.MustParseExpr("lower(foo)")), nil},
+					KeySortOrder: tree.SortOrder(0).SetDesc(1),
+				},
+			},
+			false},
+		{"Column after expression", "CREATE INDEX idx ON test (foo, (a + b))",
+			&statement.CreateIndexStmt{
+				Info: database.IndexInfo{
+					IndexName: "idx",
+					Owner:     database.Owner{TableName: "test"},
+					Columns:   []string{"foo", "a + b"},
+					Exprs:     []database.TableExpression{nil, expr.Constraint(parser.MustParseExpr("a + b"))},
+				},
+			},
+			false},
+		{"Expression without column", "CREATE INDEX idx ON test (1 + 1)", nil, true},
+		{"Expression with param", "CREATE INDEX idx ON test (lower(?))", nil, true},
+		{"Expression with aggregate", "CREATE INDEX idx ON test (count(foo))", nil, true},
 	}
 
 	for _, test := range tests {
@@ -1,8 +1,6 @@
 package parser
 
 import (
-	"github.com/cockroachdb/errors"
-
 	"github.com/chaisql/chai/internal/query/statement"
 	"github.com/chaisql/chai/internal/sql/scanner"
 )
@@ -18,11 +16,15 @@ func (p *Parser) parseDeleteStatement() (statement.Statement, error) {
 	}
 
 	// Parse table name
-	stmt.TableName, err = p.parseIdent()
+	stmt.Database, stmt.TableName, err = p.parseTableName()
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse index hint: "USE INDEX (idx)", "IGNORE INDEX (idx)" or "NO INDEX"
+	stmt.IndexHint, err = p.parseIndexHint()
 	if err != nil {
-		pErr := errors.Unwrap(err).(*ParseError)
-		pErr.Expected = []string{"table_name"}
-		return nil, pErr
+		return nil, err
 	}
 
 	// Parse condition: "WHERE EXPR".
@@ -31,8 +33,8 @@ func (p *Parser) parseDeleteStatement() (statement.Statement, error) {
 		return nil, err
 	}
 
-	// Parse order by: "ORDER BY path [ASC|DESC]?"
-	stmt.OrderBy, stmt.OrderByDirection, err = p.parseOrderBy()
+	// Parse order by: "ORDER BY path [COLLATE collation]? [ASC|DESC]? [, ...]"
+	stmt.OrderBy, stmt.OrderByCollation, stmt.OrderByDirection, stmt.OrderByThen, err = p.parseOrderBy()
 	if err != nil {
 		return nil, err
 	}
@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/environment"
 	"github.com/chaisql/chai/internal/expr"
 	"github.com/chaisql/chai/internal/expr/functions"
@@ -42,7 +43,7 @@ func (p *Parser) parseExprWithMinPrecedence(precedence int, allowed ...scanner.T
 
 	// Parse a non-binary expression type to start.
 	// This variable will always be the root of the expression tree.
-	e, err = p.parseUnaryExpr(allowed...)
+	e, err = p.parseCollatedUnaryExpr(allowed...)
 	if err != nil {
 		return nil, err
 	}
@@ -61,7 +62,7 @@ func (p *Parser) parseExprWithMinPrecedence(precedence int, allowed ...scanner.T
 
 		var rhs expr.Expr
 
-		if rhs, err = p.parseUnaryExpr(allowed...); err != nil {
+		if rhs, err = p.parseCollatedUnaryExpr(allowed...); err != nil {
 			return nil, err
 		}
 
@@ -161,6 +162,8 @@ func (p *Parser) parseOperator(minPrecedence int, allowed ...scanner.Token) (fun
 		return expr.Is, op, nil
 	case scanner.LIKE:
 		return expr.Like, op, nil
+	case scanner.MATCH:
+		return expr.Match, op, nil
 	case scanner.CONCAT:
 		return expr.Concat, op, nil
 	case scanner.BETWEEN:
@@ -181,6 +184,72 @@ func (p *Parser) parseOperator(minPrecedence int, allowed ...scanner.Token) (fun
 	return nil, 0, nil
 }
 
+// parseCollatedUnaryExpr parses a non-binary expression,
+// optionally followed by a COLLATE clause: "expr COLLATE collation".
+func (p *Parser) parseCollatedUnaryExpr(allowed ...scanner.Token) (expr.Expr, error) {
+	e, err := p.parseUnaryExpr(allowed...)
+	if err != nil || !tokenIsAllowed(scanner.COLLATE, allowed...) {
+		return e, err
+	}
+
+	for {
+		tok, _, lit := p.ScanIgnoreWhitespace()
+		if tok == scanner.IDENT && strings.EqualFold(lit, "AT") {
+			e, err = p.parseAtTimeZone(e)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if tok != scanner.COLLATE {
+			p.Unscan()
+			return e, nil
+		}
+
+		name, err := p.parseCollationName()
+		if err != nil {
+			return nil, err
+		}
+
+		e, err = expr.NewCollate(e, name)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseAtTimeZone parses the TIME ZONE zone part of an AT TIME ZONE expression.
+// TIME and ZONE are not keywords, to keep them usable as identifiers.
+func (p *Parser) parseAtTimeZone(e expr.Expr) (expr.Expr, error) {
+	for _, word := range []string{"TIME", "ZONE"} {
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		if tok != scanner.IDENT || !strings.EqualFold(lit, word) {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{word}, pos)
+		}
+	}
+
+	zone, err := p.parseUnaryExpr()
+	if err != nil {
+		return nil, err
+	}
+	if zone == nil {
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"time zone"}, pos)
+	}
+
+	return expr.NewAtTimeZone(e, zone), nil
+}
+
+// parseCollationName parses the name of a collation, as an identifier or a string.
+func (p *Parser) parseCollationName() (string, error) {
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok != scanner.IDENT && tok != scanner.STRING {
+		return "", newParseError(scanner.Tokstr(tok, lit), []string{"collation name"}, pos)
+	}
+
+	return lit, nil
+}
+
 // parseUnaryExpr parses an non-binary expression.
 func (p *Parser) parseUnaryExpr(allowed ...scanner.Token) (expr.Expr, error) {
 	tok, pos, lit := p.ScanIgnoreWhitespace()
@@ -191,9 +260,13 @@ func (p *Parser) parseUnaryExpr(allowed ...scanner.Token) (expr.Expr, error) {
 	}
 
 	switch tok {
-	case scanner.CAST:
+	case scanner.CAST, scanner.TRY_CAST:
 		p.Unscan()
 		return p.parseCastExpression()
+	case scanner.REPLACE:
+		// REPLACE is a keyword but also the name of a function
+		p.Unscan()
+		return p.parseFunction()
 	case scanner.IDENT:
 		tok1, _, _ := p.ScanIgnoreWhitespace()
 		// if the next token is a left parenthesis, this is a function
@@ -242,11 +315,11 @@ func (p *Parser) parseUnaryExpr(allowed ...scanner.Token) (expr.Expr, error) {
 		}
 		return expr.LiteralValue{Value: types.NewTextValue(lit)}, nil
 	case scanner.NUMBER:
-		v, err := strconv.ParseFloat(lit, 64)
+		l, err := numberLiteral(lit)
 		if err != nil {
 			return nil, errors.WithStack(&ParseError{Message: "unable to parse number", Pos: pos})
 		}
-		return expr.LiteralValue{Value: types.NewDoubleValue(v)}, nil
+		return l, nil
 	case scanner.ADD, scanner.SUB:
 		sign := tok
 		tok, pos, lit = p.Scan()
@@ -261,8 +334,8 @@ func (p *Parser) parseUnaryExpr(allowed ...scanner.Token) (expr.Expr, error) {
 		v, err := strconv.ParseInt(lit, 10, 64)
 		if err != nil {
 			// The literal may be too large to fit into an int64, parse as Float64
-			if v, err := strconv.ParseFloat(lit, 64); err == nil {
-				return expr.LiteralValue{Value: types.NewDoubleValue(v)}, nil
+			if l, err := numberLiteral(lit); err == nil {
+				return l, nil
 			}
 			return nil, errors.WithStack(&ParseError{Message: "unable to parse integer", Pos: pos})
 		}
@@ -320,6 +393,23 @@ func (p *Parser) parseUnaryExpr(allowed ...scanner.Token) (expr.Expr, error) {
 	}
 }
 
+// numberLiteral returns the literal of a number that isn't an integer.
+// It is a double, but its decimal value is kept so that
+// arithmetic with decimals is exact.
+func numberLiteral(lit string) (expr.LiteralValue, error) {
+	v, err := strconv.ParseFloat(lit, 64)
+	if err != nil {
+		return expr.LiteralValue{}, err
+	}
+
+	l := expr.LiteralValue{Value: types.NewDoubleValue(v)}
+	if d, err := types.ParseDecimal(lit); err == nil {
+		l.Exact = &d
+	}
+
+	return l, nil
+}
+
 // parseInteger parses an integer.
 func (p *Parser) parseInteger() (int64, error) {
 	tok, pos, lit := p.ScanIgnoreWhitespace()
@@ -354,6 +444,37 @@ func (p *Parser) parseIdent() (string, error) {
 	return lit, nil
 }
 
+// parseTableName parses a table name, optionally qualified
+// with the name of an attached database: "[database.]table".
+// The main database is designated by an empty name.
+func (p *Parser) parseTableName() (string, string, error) {
+	table, err := p.parseIdent()
+	if err != nil {
+		pErr := errors.Unwrap(err).(*ParseError)
+		pErr.Expected = []string{"table_name"}
+		return "", "", pErr
+	}
+
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.DOT {
+		p.Unscan()
+		return "", table, nil
+	}
+
+	db := table
+	table, err = p.parseIdent()
+	if err != nil {
+		pErr := errors.Unwrap(err).(*ParseError)
+		pErr.Expected = []string{"table_name"}
+		return "", "", pErr
+	}
+
+	if db == database.MainDatabase {
+		db = ""
+	}
+
+	return db, table, nil
+}
+
 // parseIdentList parses a comma delimited list of identifiers.
 func (p *Parser) parseIdentList() ([]string, error) {
 	// Parse first (required) identifier.
@@ -379,6 +500,43 @@ func (p *Parser) parseIdentList() ([]string, error) {
 }
 
 func (p *Parser) parseType() (types.Type, error) {
+	t, _, err := p.parseTypeWithWidth()
+	return t, err
+}
+
+// parseTypeWithWidth parses a type name, and returns the width in bits
+// of the explicitly sized integer types TINYINT, INT16, INT32 and INT64,
+// or zero for the other types.
+func (p *Parser) parseTypeWithWidth() (types.Type, int, error) {
+	t, bits, err := p.parseIntegerWidth()
+	if err != nil || bits > 0 {
+		return t, bits, err
+	}
+
+	t, err = p.parseTypeName()
+	return t, 0, err
+}
+
+// parseIntegerWidth parses an explicitly sized integer type, if any.
+// INT16, INT32 and INT64 are not keywords, to keep them usable as identifiers.
+func (p *Parser) parseIntegerWidth() (types.Type, int, error) {
+	tok, _, lit := p.ScanIgnoreWhitespace()
+	switch {
+	case tok == scanner.TYPETINYINT:
+		return types.TypeInteger, 8, nil
+	case tok == scanner.IDENT && strings.EqualFold(lit, "INT16"):
+		return types.TypeInteger, 16, nil
+	case tok == scanner.IDENT && strings.EqualFold(lit, "INT32"):
+		return types.TypeInteger, 32, nil
+	case tok == scanner.IDENT && strings.EqualFold(lit, "INT64"):
+		return types.TypeBigint, 64, nil
+	}
+
+	p.Unscan()
+	return 0, 0, nil
+}
+
+func (p *Parser) parseTypeName() (types.Type, error) {
 	tok, pos, lit := p.ScanIgnoreWhitespace()
 	switch tok {
 	case scanner.TYPEBLOB, scanner.TYPEBYTES:
@@ -387,6 +545,8 @@ func (p *Parser) parseType() (types.Type, error) {
 		return types.TypeBoolean, nil
 	case scanner.TYPEREAL:
 		return types.TypeDouble, nil
+	case scanner.TYPEDECIMAL, scanner.TYPENUMERIC:
+		return types.TypeDecimal, nil
 	case scanner.TYPEDOUBLE:
 		tok, _, _ := p.ScanIgnoreWhitespace()
 		if tok == scanner.PRECISION {
@@ -394,7 +554,7 @@ func (p *Parser) parseType() (types.Type, error) {
 		}
 		p.Unscan()
 		return types.TypeDouble, nil
-	case scanner.TYPEINTEGER, scanner.TYPEINT, scanner.TYPEINT2, scanner.TYPETINYINT,
+	case scanner.TYPEINTEGER, scanner.TYPEINT, scanner.TYPEINT2,
 		scanner.TYPEMEDIUMINT, scanner.TYPESMALLINT:
 		return types.TypeInteger, nil
 	case scanner.TYPEINT8, scanner.TYPEBIGINT:
@@ -418,11 +578,54 @@ func (p *Parser) parseType() (types.Type, error) {
 		}
 
 		return types.TypeText, nil
+	case scanner.IDENT:
+		// UUID is not a keyword, to avoid reserving a common column name.
+		if strings.EqualFold(lit, "UUID") {
+			return types.TypeUUID, nil
+		}
 	}
 
 	return 0, newParseError(scanner.Tokstr(tok, lit), []string{"type"}, pos)
 }
 
+// maximum precision of a DECIMAL column.
+const maxDecimalPrecision = 1000
+
+// parseDecimalModifiers parses the optional precision and scale
+// of a DECIMAL column: "(precision [, scale])". The scale is 0 by default.
+func (p *Parser) parseDecimalModifiers() (precision, scale int, err error) {
+	if ok, err := p.parseOptional(scanner.LPAREN); !ok || err != nil {
+		return 0, 0, err
+	}
+
+	prec, err := p.parseInteger()
+	if err != nil {
+		return 0, 0, err
+	}
+	if prec < 1 || prec > maxDecimalPrecision {
+		return 0, 0, &ParseError{Message: fmt.Sprintf("DECIMAL precision %d must be between 1 and %d", prec, maxDecimalPrecision)}
+	}
+
+	var sc int64
+	if ok, err := p.parseOptional(scanner.COMMA); err != nil {
+		return 0, 0, err
+	} else if ok {
+		sc, err = p.parseInteger()
+		if err != nil {
+			return 0, 0, err
+		}
+		if sc < 0 || sc > prec {
+			return 0, 0, &ParseError{Message: fmt.Sprintf("DECIMAL scale %d must be between 0 and the precision %d", sc, prec)}
+		}
+	}
+
+	if err := p.ParseTokens(scanner.RPAREN); err != nil {
+		return 0, 0, err
+	}
+
+	return int(prec), int(sc), nil
+}
+
 // parsePath parses a path to a specific value.
 func (p *Parser) parseColumn() (*expr.Column, error) {
 	// parse first mandatory ident
@@ -476,9 +679,17 @@ func (p *Parser) parseExprList(leftToken, rightToken scanner.Token) (expr.Litera
 // an optional coma-separated list of expressions and a closing parenthesis.
 func (p *Parser) parseFunction() (expr.Expr, error) {
 	// Parse function name.
-	funcName, err := p.parseIdent()
-	if err != nil {
-		return nil, err
+	var funcName string
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.REPLACE {
+		funcName = tok.String()
+	} else {
+		p.Unscan()
+
+		var err error
+		funcName, err = p.parseIdent()
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Parse required ( token.
@@ -488,7 +699,7 @@ func (p *Parser) parseFunction() (expr.Expr, error) {
 
 	// Check if the function is called without arguments.
 	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.RPAREN {
-		def, err := functions.GetFunc(funcName)
+		def, err := p.getFunc(funcName)
 		if err != nil {
 			return nil, err
 		}
@@ -518,17 +729,40 @@ func (p *Parser) parseFunction() (expr.Expr, error) {
 		return nil, err
 	}
 
-	def, err := functions.GetFunc(funcName)
+	def, err := p.getFunc(funcName)
 	if err != nil {
 		return nil, err
 	}
 	return def.Function(exprs...)
 }
 
-// parseCastExpression parses a string of the form CAST(expr AS type).
+// getFunc returns the definition of a function, looking it up
+// in the functions of the parser if any.
+func (p *Parser) getFunc(name string) (functions.Definition, error) {
+	if p.functions != nil {
+		return p.functions.GetFunc(name)
+	}
+
+	return functions.GetFunc(name)
+}
+
+// parseCastExpression parses a string of the form CAST(expr AS type [FORMAT 'format'])
+// or TRY_CAST(expr AS type [FORMAT 'format']).
 func (p *Parser) parseCastExpression() (expr.Expr, error) {
-	// Parse required CAST and ( tokens.
-	if err := p.ParseTokens(scanner.CAST, scanner.LPAREN); err != nil {
+	var c expr.Cast
+
+	// Parse required CAST or TRY_CAST token.
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	switch tok {
+	case scanner.CAST:
+	case scanner.TRY_CAST:
+		c.Try = true
+	default:
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"CAST", "TRY_CAST"}, pos)
+	}
+
+	// Parse required ( token.
+	if err := p.ParseTokens(scanner.LPAREN); err != nil {
 		return nil, err
 	}
 
@@ -537,6 +771,7 @@ func (p *Parser) parseCastExpression() (expr.Expr, error) {
 	if err != nil {
 		return nil, err
 	}
+	c.Expr = e
 
 	// Parse required AS token.
 	if err := p.ParseTokens(scanner.AS); err != nil {
@@ -544,17 +779,29 @@ func (p *Parser) parseCastExpression() (expr.Expr, error) {
 	}
 
 	// Parse required typename.
-	tp, err := p.parseType()
+	c.CastAs, c.Bits, err = p.parseTypeWithWidth()
 	if err != nil {
 		return nil, err
 	}
 
+	// Parse optional FORMAT clause.
+	// FORMAT is not a keyword, to avoid reserving a common column name.
+	if tok, _, lit := p.ScanIgnoreWhitespace(); tok == scanner.IDENT && strings.EqualFold(lit, "FORMAT") {
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		if tok != scanner.STRING {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"string"}, pos)
+		}
+		c.Format = lit
+	} else {
+		p.Unscan()
+	}
+
 	// Parse required ) token.
 	if err := p.ParseTokens(scanner.RPAREN); err != nil {
 		return nil, err
 	}
 
-	return &expr.Cast{Expr: e, CastAs: tp}, nil
+	return &c, nil
 }
 
 // tokenIsAllowed is a helper function that determines if a token is allowed.
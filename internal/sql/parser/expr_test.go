@@ -13,6 +13,12 @@ import (
 )
 
 func TestParserExpr(t *testing.T) {
+	collate := func(e expr.Expr, collation string) expr.Expr {
+		c, err := expr.NewCollate(e, collation)
+		require.NoError(t, err)
+		return c
+	}
+
 	tests := []struct {
 		name     string
 		s        string
@@ -82,6 +88,7 @@ func TestParserExpr(t *testing.T) {
 		{"IS NOT", "age IS NOT NULL", expr.IsNot(&expr.Column{Name: "age"}, testutil.NullValue()), false},
 		{"LIKE", "name LIKE 'foo'", expr.Like(&expr.Column{Name: "name"}, testutil.TextValue("foo")), false},
 		{"NOT LIKE", "name NOT LIKE 'foo'", expr.NotLike(&expr.Column{Name: "name"}, testutil.TextValue("foo")), false},
+		{"MATCH", "body MATCH 'foo bar'", expr.Match(&expr.Column{Name: "body"}, testutil.TextValue("foo bar")), false},
 		{"NOT =", "name NOT = 'foo'", nil, true},
 		{"precedence", "4 > 1 + 2", expr.Gt(
 			testutil.IntegerValue(4),
@@ -112,6 +119,19 @@ func TestParserExpr(t *testing.T) {
 
 		// unary operators
 		{"CAST", "CAST(a AS TEXT)", &expr.Cast{Expr: &expr.Column{Name: "a"}, CastAs: types.TypeText}, false},
+		{"TRY_CAST", "TRY_CAST(a AS TEXT)", &expr.Cast{Expr: &expr.Column{Name: "a"}, CastAs: types.TypeText, Try: true}, false},
+		{"CAST with FORMAT", "CAST(a AS TEXT format 'hex')", &expr.Cast{Expr: &expr.Column{Name: "a"}, CastAs: types.TypeText, Format: "hex"}, false},
+		{"TRY_CAST with FORMAT", "TRY_CAST(a AS TIMESTAMP FORMAT '%Y')", &expr.Cast{Expr: &expr.Column{Name: "a"}, CastAs: types.TypeTimestamp, Format: "%Y", Try: true}, false},
+		{"CAST as UUID", "CAST(a AS uuid)", &expr.Cast{Expr: &expr.Column{Name: "a"}, CastAs: types.TypeUUID}, false},
+		{"CAST with invalid FORMAT", "CAST(a AS TEXT FORMAT hex)", nil, true},
+		{"COLLATE", "a COLLATE nocase", collate(&expr.Column{Name: "a"}, "nocase"), false},
+		{"COLLATE with string", `a COLLATE "de_DE"`, collate(&expr.Column{Name: "a"}, "de_DE"), false},
+		{"COLLATE in comparison", "a COLLATE nocase = 'A'",
+			expr.Eq(collate(&expr.Column{Name: "a"}, "nocase"), collate(testutil.TextValue("A"), "nocase")), false},
+		{"COLLATE on the right of a comparison", "a < 'A' COLLATE nocase",
+			expr.Lt(collate(&expr.Column{Name: "a"}, "nocase"), collate(testutil.TextValue("A"), "nocase")), false},
+		{"COLLATE with unknown collation", "a COLLATE 'not a locale'", nil, true},
+		{"COLLATE without name", "a COLLATE", nil, true},
 		{"NOT", "NOT 10", expr.Not(testutil.IntegerValue(10)), false},
 		{"NOT", "NOT NOT", nil, true},
 		{"NOT", "NOT NOT 10", expr.Not(expr.Not(testutil.IntegerValue(10))), false},
@@ -124,6 +144,7 @@ func TestParserExpr(t *testing.T) {
 		{"count(*) function", "count(*)", functions.NewCount(expr.Wildcard{}), false},
 		{"count (*) function with spaces", "count      (*)", functions.NewCount(expr.Wildcard{}), false},
 		{"packaged function", "floor(1.2)", testutil.FunctionExpr(t, "floor", testutil.DoubleValue(1.2)), false},
+		{"keyword function", "replace('a', 'b', 'c')", testutil.FunctionExpr(t, "replace", testutil.TextValue("a"), testutil.TextValue("b"), testutil.TextValue("c")), false},
 	}
 
 	for _, test := range tests {
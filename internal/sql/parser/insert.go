@@ -5,13 +5,13 @@ import (
 
 	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/query"
 	"github.com/chaisql/chai/internal/query/statement"
 	"github.com/chaisql/chai/internal/sql/scanner"
-	"github.com/cockroachdb/errors"
 )
 
 // parseInsertStatement parses an insert string and returns a Statement AST row.
-func (p *Parser) parseInsertStatement() (*statement.InsertStmt, error) {
+func (p *Parser) parseInsertStatement() (statement.Statement, error) {
 	stmt := statement.NewInsertStatement()
 	var err error
 
@@ -21,12 +21,16 @@ func (p *Parser) parseInsertStatement() (*statement.InsertStmt, error) {
 	}
 
 	// Parse table name
-	stmt.TableName, err = p.parseIdent()
+	stmt.Database, stmt.TableName, err = p.parseTableName()
 	if err != nil {
-		pErr := errors.UnwrapAll(err).(*ParseError)
-		pErr.Expected = []string{"table_name"}
-		return nil, pErr
+		return nil, err
+	}
+
+	// Parse "FROM READER param"
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.FROM {
+		return p.parseInsertFromReader(stmt.Database, stmt.TableName)
 	}
+	p.Unscan()
 
 	// Parse path list: (a, b, c)
 	stmt.Columns, err = p.parseSimpleColumnList()
@@ -67,6 +71,28 @@ func (p *Parser) parseInsertStatement() (*statement.InsertStmt, error) {
 	return stmt, nil
 }
 
+// parseInsertFromReader parses the reader of an INSERT INTO ... FROM READER statement,
+// which must be a parameter. READER is not a keyword.
+func (p *Parser) parseInsertFromReader(database, table string) (*query.InsertFromReaderStmt, error) {
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if !isContextualKeyword(tok, lit, "READER") {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"READER"}, pos)
+	}
+
+	tok, pos, lit = p.ScanIgnoreWhitespace()
+	if tok != scanner.POSITIONALPARAM && tok != scanner.NAMEDPARAM {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"?", "$name"}, pos)
+	}
+	p.Unscan()
+
+	r, err := p.parseUnaryExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	return &query.InsertFromReaderStmt{Database: database, TableName: table, Reader: r}, nil
+}
+
 // parseColumnList parses a list of columns in the form: (column, column, ...), if exists.
 // If the list is empty, it returns an error.
 func (p *Parser) parseSimpleColumnList() ([]string, error) {
@@ -232,3 +232,33 @@ func TestParserInsert(t *testing.T) {
 		})
 	}
 }
+
+func TestParserInsertFromReader(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected statement.Statement
+		fails    bool
+	}{
+		{"Positional param", "INSERT INTO test FROM READER ?",
+			&query.InsertFromReaderStmt{TableName: "test", Reader: expr.PositionalParam(1)}, false},
+		{"Named param", "INSERT INTO db.test FROM READER $r",
+			&query.InsertFromReaderStmt{Database: "db", TableName: "test", Reader: expr.NamedParam("r")}, false},
+		{"No reader", "INSERT INTO test FROM ?", nil, true},
+		{"Not a param", "INSERT INTO test FROM READER 'a'", nil, true},
+		{"With columns", "INSERT INTO test (a) FROM READER ?", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.Equal(t, test.expected, q.Statements[0])
+		})
+	}
+}
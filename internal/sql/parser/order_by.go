@@ -4,29 +4,63 @@ import (
 	"errors"
 
 	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/query/statement"
 	"github.com/chaisql/chai/internal/sql/scanner"
 )
 
-func (p *Parser) parseOrderBy() (*expr.Column, scanner.Token, error) {
+func (p *Parser) parseOrderBy() (e expr.Expr, collation string, direction scanner.Token, then []statement.OrderByTerm, err error) {
 	// parse ORDER token
 	ok, err := p.parseOptional(scanner.ORDER, scanner.BY)
 	if err != nil || !ok {
-		return nil, 0, err
+		return nil, "", 0, nil, err
 	}
 
-	// parse col
-	col, err := p.parseColumn()
+	e, collation, direction, err = p.parseOrderByTerm()
 	if err != nil {
-		return nil, 0, err
+		return nil, "", 0, nil, err
+	}
+
+	// parse the following terms, if any
+	for {
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+			p.Unscan()
+			break
+		}
+
+		var t statement.OrderByTerm
+		t.Expr, t.Collation, t.Direction, err = p.parseOrderByTerm()
+		if err != nil {
+			return nil, "", 0, nil, err
+		}
+
+		then = append(then, t)
+	}
+
+	return e, collation, direction, then, nil
+}
+
+// parseOrderByTerm parses a term of the ORDER BY clause, with its optional
+// collation and direction.
+func (p *Parser) parseOrderByTerm() (e expr.Expr, collation string, direction scanner.Token, err error) {
+	// parse the expression, i.e. a column, rank() or c + 1
+	e, err = p.ParseExpr()
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	// the optional COLLATE "collation" clause is parsed with the expression,
+	// it applies to the whole term
+	if c, ok := e.(*expr.Collate); ok {
+		e, collation = c.Expr, c.Collation
 	}
 
 	// parse optional ASC or DESC
 	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.ASC || tok == scanner.DESC {
-		return col, tok, nil
+		return e, collation, tok, nil
 	}
 	p.Unscan()
 
-	return col, 0, nil
+	return e, collation, 0, nil
 }
 
 func (p *Parser) parseLimit() (expr.Expr, error) {
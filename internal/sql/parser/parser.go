@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/expr/functions"
 	"github.com/chaisql/chai/internal/query"
 	"github.com/chaisql/chai/internal/query/statement"
 	"github.com/chaisql/chai/internal/sql/scanner"
@@ -18,6 +19,7 @@ type Parser struct {
 	s             *scanner.Scanner
 	orderedParams int
 	namedParams   int
+	functions     functions.Lookup
 }
 
 // NewParser returns a new instance of Parser.
@@ -25,6 +27,13 @@ func NewParser(r io.Reader) *Parser {
 	return &Parser{s: scanner.NewScanner(r)}
 }
 
+// WithFunctions makes the parser look functions up in l
+// instead of only in the builtin functions.
+func (p *Parser) WithFunctions(l functions.Lookup) *Parser {
+	p.functions = l
+	return p
+}
+
 // ParseQuery parses a query string and returns its AST representation.
 func ParseQuery(s string) (query.Query, error) {
 	return NewParser(strings.NewReader(s)).ParseQuery()
@@ -127,10 +136,31 @@ func (p *Parser) ParseStatement() (statement.Statement, error) {
 		return p.parseReIndexStatement()
 	case scanner.ROLLBACK:
 		return p.parseRollbackStatement()
+	case scanner.SAVEPOINT:
+		return p.parseSavepointStatement()
+	case scanner.RELEASE:
+		return p.parseReleaseStatement()
+	case scanner.IDENT:
+		switch {
+		case isContextualKeyword(tok, lit, "SHOW"):
+			return p.parseShowStatement()
+		case isContextualKeyword(tok, lit, "DESCRIBE"):
+			return p.parseDescribeStatement()
+		case isContextualKeyword(tok, lit, "ATTACH"):
+			return p.parseAttachStatement()
+		case isContextualKeyword(tok, lit, "DETACH"):
+			return p.parseDetachStatement()
+		case isContextualKeyword(tok, lit, "ANALYZE"):
+			return p.parseAnalyzeStatement()
+		case isContextualKeyword(tok, lit, "BACKUP"):
+			return p.parseBackupStatement()
+		case isContextualKeyword(tok, lit, "VACUUM"):
+			return p.parseVacuumStatement()
+		}
 	}
 
 	return nil, newParseError(scanner.Tokstr(tok, lit), []string{
-		"ALTER", "BEGIN", "COMMIT", "SELECT", "DELETE", "UPDATE", "INSERT", "CREATE", "DROP", "EXPLAIN", "REINDEX", "ROLLBACK",
+		"ALTER", "BEGIN", "COMMIT", "SELECT", "DELETE", "UPDATE", "INSERT", "CREATE", "DROP", "EXPLAIN", "REINDEX", "ROLLBACK", "SAVEPOINT", "RELEASE", "SHOW", "DESCRIBE", "ATTACH", "DETACH", "ANALYZE", "BACKUP", "VACUUM",
 	}, pos)
 }
 
@@ -1,9 +1,12 @@
 package parser
 
 import (
+	"fmt"
+
 	"github.com/chaisql/chai/internal/expr"
 	"github.com/chaisql/chai/internal/query/statement"
 	"github.com/chaisql/chai/internal/sql/scanner"
+	"github.com/chaisql/chai/internal/stream/table"
 	"github.com/cockroachdb/errors"
 )
 
@@ -18,8 +21,8 @@ func (p *Parser) parseSelectStatement() (*statement.SelectStmt, error) {
 		return nil, err
 	}
 
-	// Parse order by: "ORDER BY path [ASC|DESC]?"
-	stmt.OrderBy, stmt.OrderByDirection, err = p.parseOrderBy()
+	// Parse order by: "ORDER BY path [COLLATE collation]? [ASC|DESC]? [, ...]"
+	stmt.OrderBy, stmt.OrderByCollation, stmt.OrderByDirection, stmt.OrderByThen, err = p.parseOrderBy()
 	if err != nil {
 		return nil, err
 	}
@@ -92,11 +95,25 @@ func (p *Parser) parseSelectCore() (*statement.SelectCoreStmt, error) {
 	}
 
 	// Parse "FROM".
-	stmt.TableName, err = p.parseFrom()
+	stmt.Database, stmt.TableName, err = p.parseFrom()
 	if err != nil {
 		return nil, err
 	}
 
+	// Parse index hint: "USE INDEX (idx)", "IGNORE INDEX (idx)" or "NO INDEX"
+	if stmt.TableName != "" {
+		stmt.IndexHint, err = p.parseIndexHint()
+		if err != nil {
+			return nil, err
+		}
+
+		// Parse parallelism: "PARALLEL n"
+		stmt.Parallelism, err = p.parseParallelism()
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Parse condition: "WHERE expr".
 	stmt.WhereExpr, err = p.parseCondition()
 	if err != nil {
@@ -168,20 +185,75 @@ func (p *Parser) parseProjectedExpr() (expr.Expr, error) {
 	return ne, nil
 }
 
-func (p *Parser) parseFrom() (string, error) {
+func (p *Parser) parseFrom() (string, string, error) {
 	if ok, err := p.parseOptional(scanner.FROM); !ok || err != nil {
-		return "", err
+		return "", "", err
 	}
 
-	// Parse table name
-	ident, err := p.parseIdent()
+	return p.parseTableName()
+}
+
+// parseIndexHint parses the optional index hint following a table name:
+// "USE INDEX (idx, ...)", "IGNORE INDEX (idx, ...)" or "NO INDEX".
+func (p *Parser) parseIndexHint() (*table.IndexHint, error) {
+	var hint table.IndexHint
+
+	tok, _, lit := p.ScanIgnoreWhitespace()
+	switch {
+	case isContextualKeyword(tok, lit, "USE"):
+		hint.Kind = table.UseIndex
+	case tok == scanner.IGNORE:
+		hint.Kind = table.IgnoreIndex
+	case tok == scanner.NO:
+		if err := p.ParseTokens(scanner.INDEX); err != nil {
+			return nil, err
+		}
+
+		hint.Kind = table.NoIndex
+		return &hint, nil
+	default:
+		p.Unscan()
+		return nil, nil
+	}
+
+	if err := p.ParseTokens(scanner.INDEX, scanner.LPAREN); err != nil {
+		return nil, err
+	}
+
+	var err error
+	hint.Indexes, err = p.parseIdentList()
 	if err != nil {
-		pErr := errors.Unwrap(err).(*ParseError)
-		pErr.Expected = []string{"table_name"}
-		return ident, pErr
+		return nil, err
+	}
+
+	if err := p.ParseTokens(scanner.RPAREN); err != nil {
+		return nil, err
+	}
+
+	return &hint, nil
+}
+
+// parseParallelism parses the optional number of workers used to scan the table:
+// "PARALLEL n".
+func (p *Parser) parseParallelism() (int, error) {
+	tok, _, lit := p.ScanIgnoreWhitespace()
+	if !isContextualKeyword(tok, lit, "PARALLEL") {
+		p.Unscan()
+		return 0, nil
+	}
+
+	_, pos, lit := p.ScanIgnoreWhitespace()
+	p.Unscan()
+
+	n, err := p.parseInteger()
+	if err != nil {
+		return 0, err
+	}
+	if n < 1 || n > table.MaxParallelism {
+		return 0, newParseError(lit, []string{fmt.Sprintf("integer between 1 and %d", table.MaxParallelism)}, pos)
 	}
 
-	return ident, nil
+	return int(n), nil
 }
 
 func (p *Parser) parseGroupBy() (expr.Expr, error) {
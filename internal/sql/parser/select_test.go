@@ -42,6 +42,12 @@ func TestParserSelect(t *testing.T) {
 		return e
 	}
 
+	collate := func(e expr.Expr, collation string) expr.Expr {
+		c, err := expr.NewCollate(e, collation)
+		require.NoError(t, err)
+		return c
+	}
+
 	parseNamedExpr := func(t *testing.T, s string, name ...string) *expr.NamedExpr {
 		ne := expr.NamedExpr{
 			Expr:     parseExpr(s),
@@ -133,6 +139,34 @@ func TestParserSelect(t *testing.T) {
 				Pipe(rows.TempTreeSortReverse(parseExpr("a"))),
 			true, false,
 		},
+		{"WithOrderBy COLLATE", "SELECT * FROM test WHERE age = 10 ORDER BY a COLLATE \"de_DE\" DESC",
+			stream.New(table.Scan("test")).
+				Pipe(rows.Filter(parseExpr("age = 10"))).
+				Pipe(rows.Project(expr.Wildcard{})).
+				Pipe(rows.TempTreeSortReverse(collate(parseExpr("a"), "de_DE"))),
+			true, false,
+		},
+		{"WithOrderBy expression", "SELECT * FROM test WHERE age = 10 ORDER BY a + 1 DESC",
+			stream.New(table.Scan("test")).
+				Pipe(rows.Filter(parseExpr("age = 10"))).
+				Pipe(rows.Project(expr.Wildcard{})).
+				Pipe(rows.TempTreeSortReverse(parseExpr("a + 1"))),
+			true, false,
+		},
+		{"WithOrderBy multiple", "SELECT * FROM test WHERE age = 10 ORDER BY a, b DESC",
+			stream.New(table.Scan("test")).
+				Pipe(rows.Filter(parseExpr("age = 10"))).
+				Pipe(rows.Project(expr.Wildcard{})).
+				Pipe(rows.TempTreeSort(parseExpr("a")).ThenBy(parseExpr("b"), true)),
+			true, false,
+		},
+		{"WithOrderBy multiple DESC", "SELECT * FROM test WHERE age = 10 ORDER BY a DESC, b",
+			stream.New(table.Scan("test")).
+				Pipe(rows.Filter(parseExpr("age = 10"))).
+				Pipe(rows.Project(expr.Wildcard{})).
+				Pipe(rows.TempTreeSortReverse(parseExpr("a")).ThenBy(parseExpr("b"), false)),
+			true, false,
+		},
 		{"WithLimit", "SELECT * FROM test WHERE age = 10 LIMIT 20",
 			stream.New(table.Scan("test")).
 				Pipe(rows.Filter(parseExpr("age = 10"))).
@@ -352,6 +386,22 @@ func TestParserSelect(t *testing.T) {
 			)),
 			true, false,
 		},
+		{"WithNoIndexHint", "SELECT * FROM test NO INDEX",
+			stream.New(&table.ScanOperator{TableName: "test", Hint: &table.IndexHint{Kind: table.NoIndex}}).
+				Pipe(rows.Project(expr.Wildcard{})),
+			true, false,
+		},
+		{"WithParallelism", "SELECT * FROM test NO INDEX PARALLEL 4 WHERE age > 1",
+			stream.New(&table.ScanOperator{TableName: "test", Hint: &table.IndexHint{Kind: table.NoIndex}, Parallelism: 4}).
+				Pipe(rows.Filter(parseExpr("age > 1"))).
+				Pipe(rows.Project(expr.Wildcard{})),
+			true, false,
+		},
+		{"WithParallelism/Zero", "SELECT * FROM test PARALLEL 0", nil, true, true},
+		{"WithParallelism/TooMany", "SELECT * FROM test PARALLEL 65", nil, true, true},
+		{"WithParallelism/Missing", "SELECT * FROM test PARALLEL", nil, true, true},
+		{"WithIndexHint/Empty", "SELECT * FROM test USE INDEX ()", nil, true, true},
+		{"WithIndexHint/NoParens", "SELECT * FROM test IGNORE INDEX idx", nil, true, true},
 		{"WithMultipleCompoundOps/4", "SELECT * FROM a UNION ALL SELECT * FROM b UNION SELECT * FROM c UNION ALL SELECT * FROM d",
 			stream.New(stream.Concat(
 				stream.New(stream.Union(
@@ -0,0 +1,64 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/sql/scanner"
+)
+
+// SHOW, DESCRIBE, TABLES and INDEXES are not keywords,
+// to avoid reserving common column names.
+
+// isContextualKeyword reports whether the token is an identifier matching the given keyword.
+func isContextualKeyword(tok scanner.Token, lit, keyword string) bool {
+	return tok == scanner.IDENT && strings.EqualFold(lit, keyword)
+}
+
+// parseShowStatement parses a string of the form "SHOW TABLES" or "SHOW INDEXES [FROM|ON table]".
+func (p *Parser) parseShowStatement() (statement.Statement, error) {
+	// Parse "SHOW".
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if !isContextualKeyword(tok, lit, "SHOW") {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"SHOW"}, pos)
+	}
+
+	tok, pos, lit = p.ScanIgnoreWhitespace()
+	switch {
+	case isContextualKeyword(tok, lit, "TABLES"):
+		return &statement.ShowTablesStmt{}, nil
+	case isContextualKeyword(tok, lit, "INDEXES"):
+		var stmt statement.ShowIndexesStmt
+
+		// Parse optional "FROM table" or "ON table".
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.FROM || tok == scanner.ON {
+			var err error
+			stmt.TableName, err = p.parseIdent()
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			p.Unscan()
+		}
+
+		return &stmt, nil
+	}
+
+	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"TABLES", "INDEXES"}, pos)
+}
+
+// parseDescribeStatement parses a string of the form "DESCRIBE table".
+func (p *Parser) parseDescribeStatement() (statement.Statement, error) {
+	// Parse "DESCRIBE".
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if !isContextualKeyword(tok, lit, "DESCRIBE") {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"DESCRIBE"}, pos)
+	}
+
+	tableName, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	return &statement.DescribeStmt{TableName: tableName}, nil
+}
@@ -0,0 +1,44 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/sql/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserShow(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected statement.Statement
+		errored  bool
+	}{
+		{"Tables", "SHOW TABLES", &statement.ShowTablesStmt{}, false},
+		{"Lowercase", "show tables", &statement.ShowTablesStmt{}, false},
+		{"Indexes", "SHOW INDEXES", &statement.ShowIndexesStmt{}, false},
+		{"Indexes FROM", "SHOW INDEXES FROM test", &statement.ShowIndexesStmt{TableName: "test"}, false},
+		{"Indexes ON", "SHOW INDEXES ON test", &statement.ShowIndexesStmt{TableName: "test"}, false},
+		{"Indexes without table", "SHOW INDEXES FROM", nil, true},
+		{"Unknown object", "SHOW COLUMNS", nil, true},
+		{"Nothing", "SHOW", nil, true},
+		{"Describe", "DESCRIBE test", &statement.DescribeStmt{TableName: "test"}, false},
+		{"Describe with quotes", "DESCRIBE `my table`", &statement.DescribeStmt{TableName: "my table"}, false},
+		{"Describe without table", "DESCRIBE", nil, true},
+		{"Unknown statement", "DESCRIPTION test", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
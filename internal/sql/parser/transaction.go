@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/query"
 	"github.com/chaisql/chai/internal/query/statement"
 	"github.com/chaisql/chai/internal/sql/scanner"
@@ -13,28 +14,60 @@ func (p *Parser) parseBeginStatement() (statement.Statement, error) {
 		return nil, err
 	}
 
+	stmt := query.BeginStmt{Writable: true}
+
+	// parse optional DEFERRED, IMMEDIATE or EXCLUSIVE, which are not keywords.
+	// IMMEDIATE and EXCLUSIVE both acquire the write lock immediately:
+	// readers are never blocked by writers.
+	tok, _, lit := p.ScanIgnoreWhitespace()
+	switch {
+	case isContextualKeyword(tok, lit, "DEFERRED"):
+		stmt.Deferred = true
+	case isContextualKeyword(tok, lit, "IMMEDIATE"), isContextualKeyword(tok, lit, "EXCLUSIVE"):
+	default:
+		p.Unscan()
+	}
+
 	// parse optional TRANSACTION token
 	_, _ = p.parseOptional(scanner.TRANSACTION)
 
-	// parse optional READ token
-	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.READ {
+	// parse optional READ ONLY or READ WRITE
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.READ {
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		switch tok {
+		case scanner.ONLY:
+			stmt.Writable = false
+		case scanner.WRITE:
+		default:
+			return query.BeginStmt{}, newParseError(scanner.Tokstr(tok, lit), []string{"ONLY", "WRITE"}, pos)
+		}
+	} else {
 		p.Unscan()
-		return query.BeginStmt{Writable: true}, nil
 	}
 
-	// parse ONLY token
-	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.ONLY {
-		return query.BeginStmt{Writable: false}, nil
+	// parse optional WITH DURABILITY FULL|RELAXED
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.WITH {
+		p.Unscan()
+		return stmt, nil
 	}
 
-	p.Unscan()
+	// DURABILITY, FULL and RELAXED are not keywords
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if !isContextualKeyword(tok, lit, "DURABILITY") {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"DURABILITY"}, pos)
+	}
 
-	// parse WRITE token
-	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.WRITE {
-		return query.BeginStmt{}, newParseError(scanner.Tokstr(tok, lit), []string{"ONLY", "WRITE"}, pos)
+	tok, pos, lit = p.ScanIgnoreWhitespace()
+	switch {
+	case isContextualKeyword(tok, lit, "FULL"):
+		stmt.Durability = database.FullDurability
+	case isContextualKeyword(tok, lit, "RELAXED"):
+		stmt.Durability = database.RelaxedDurability
+	default:
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"FULL", "RELAXED"}, pos)
 	}
 
-	return query.BeginStmt{Writable: true}, nil
+	return stmt, nil
 }
 
 // parseRollbackStatement parses a ROLLBACK statement.
@@ -47,7 +80,54 @@ func (p *Parser) parseRollbackStatement() (statement.Statement, error) {
 	// parse optional TRANSACTION token
 	_, _ = p.parseOptional(scanner.TRANSACTION)
 
-	return query.RollbackStmt{}, nil
+	// parse optional TO [SAVEPOINT] name
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.TO {
+		p.Unscan()
+		return query.RollbackStmt{}, nil
+	}
+
+	// parse optional SAVEPOINT token
+	_, _ = p.parseOptional(scanner.SAVEPOINT)
+
+	name, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	return query.RollbackToSavepointStmt{Name: name}, nil
+}
+
+// parseSavepointStatement parses a SAVEPOINT statement.
+func (p *Parser) parseSavepointStatement() (statement.Statement, error) {
+	// Parse "SAVEPOINT".
+	if err := p.ParseTokens(scanner.SAVEPOINT); err != nil {
+		return nil, err
+	}
+
+	name, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	return query.SavepointStmt{Name: name}, nil
+}
+
+// parseReleaseStatement parses a RELEASE statement.
+func (p *Parser) parseReleaseStatement() (statement.Statement, error) {
+	// Parse "RELEASE".
+	if err := p.ParseTokens(scanner.RELEASE); err != nil {
+		return nil, err
+	}
+
+	// parse optional SAVEPOINT token
+	_, _ = p.parseOptional(scanner.SAVEPOINT)
+
+	name, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	return query.ReleaseSavepointStmt{Name: name}, nil
 }
 
 // parseCommitStatement parses a COMMIT statement.
@@ -3,6 +3,7 @@ package parser_test
 import (
 	"testing"
 
+	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/query"
 	"github.com/chaisql/chai/internal/query/statement"
 	"github.com/chaisql/chai/internal/sql/parser"
@@ -21,10 +22,29 @@ func TestParserTransactions(t *testing.T) {
 		{"BEGIN READ WRITE", query.BeginStmt{Writable: true}, false},
 		{"BEGIN READ", query.BeginStmt{}, true},
 		{"BEGIN WRITE", query.BeginStmt{}, true},
+		{"BEGIN WITH DURABILITY RELAXED", query.BeginStmt{Writable: true, Durability: database.RelaxedDurability}, false},
+		{"BEGIN TRANSACTION READ WRITE WITH DURABILITY full", query.BeginStmt{Writable: true, Durability: database.FullDurability}, false},
+		{"BEGIN READ ONLY WITH DURABILITY RELAXED", query.BeginStmt{Writable: false, Durability: database.RelaxedDurability}, false},
+		{"BEGIN DEFERRED", query.BeginStmt{Writable: true, Deferred: true}, false},
+		{"BEGIN DEFERRED TRANSACTION WITH DURABILITY RELAXED", query.BeginStmt{Writable: true, Deferred: true, Durability: database.RelaxedDurability}, false},
+		{"BEGIN IMMEDIATE", query.BeginStmt{Writable: true}, false},
+		{"BEGIN exclusive TRANSACTION", query.BeginStmt{Writable: true}, false},
+		{"BEGIN TRANSACTION DEFERRED", nil, true},
+		{"BEGIN WITH DURABILITY", nil, true},
+		{"BEGIN WITH DURABILITY FAST", nil, true},
+		{"BEGIN WITH RELAXED", nil, true},
 		{"ROLLBACK", query.RollbackStmt{}, false},
 		{"ROLLBACK TRANSACTION", query.RollbackStmt{}, false},
 		{"COMMIT", query.CommitStmt{}, false},
 		{"COMMIT TRANSACTION", query.CommitStmt{}, false},
+		{"SAVEPOINT foo", query.SavepointStmt{Name: "foo"}, false},
+		{"SAVEPOINT", nil, true},
+		{"ROLLBACK TO foo", query.RollbackToSavepointStmt{Name: "foo"}, false},
+		{"ROLLBACK TO SAVEPOINT foo", query.RollbackToSavepointStmt{Name: "foo"}, false},
+		{"ROLLBACK TRANSACTION TO SAVEPOINT foo", query.RollbackToSavepointStmt{Name: "foo"}, false},
+		{"ROLLBACK TO", nil, true},
+		{"RELEASE foo", query.ReleaseSavepointStmt{Name: "foo"}, false},
+		{"RELEASE SAVEPOINT foo", query.ReleaseSavepointStmt{Name: "foo"}, false},
 	}
 
 	for _, test := range tests {
@@ -17,11 +17,15 @@ func (p *Parser) parseUpdateStatement() (*statement.UpdateStmt, error) {
 	}
 
 	// Parse table name
-	stmt.TableName, err = p.parseIdent()
+	stmt.Database, stmt.TableName, err = p.parseTableName()
 	if err != nil {
-		pErr := errors.Unwrap(err).(*ParseError)
-		pErr.Expected = []string{"table_name"}
-		return nil, pErr
+		return nil, err
+	}
+
+	// Parse index hint: "USE INDEX (idx)", "IGNORE INDEX (idx)" or "NO INDEX"
+	stmt.IndexHint, err = p.parseIndexHint()
+	if err != nil {
+		return nil, err
 	}
 
 	// Parse clause: SET.
@@ -0,0 +1,19 @@
+package parser
+
+import (
+	"github.com/chaisql/chai/internal/query/statement"
+	"github.com/chaisql/chai/internal/sql/scanner"
+)
+
+// VACUUM is not a keyword.
+
+// parseVacuumStatement parses the "VACUUM" statement.
+func (p *Parser) parseVacuumStatement() (statement.Statement, error) {
+	// Parse "VACUUM".
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if !isContextualKeyword(tok, lit, "VACUUM") {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"VACUUM"}, pos)
+	}
+
+	return &statement.VacuumStmt{}, nil
+}
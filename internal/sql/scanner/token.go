@@ -59,6 +59,7 @@ const (
 	ISN      // IS NOT
 	LIKE     // LIKE
 	NLIKE    // NOT LIKE
+	MATCH    // MATCH
 	CONCAT   // ||
 	BETWEEN  // BETWEEN
 	operatorEnd
@@ -87,6 +88,7 @@ const (
 	CACHE
 	CAST
 	CHECK
+	COLLATE
 	COLUMN
 	COMMIT
 	CONFLICT
@@ -103,6 +105,7 @@ const (
 	EXPLAIN
 	FOR
 	FROM
+	FULLTEXT
 	GROUP
 	IF
 	IGNORE
@@ -126,17 +129,22 @@ const (
 	PRIMARY
 	READ
 	REINDEX
+	RELEASE
 	RENAME
 	REPLACE
 	RETURNING
 	ROLLBACK
+	SAVEPOINT
 	SELECT
 	SEQUENCE
 	SET
 	START
+	SWAP
 	TABLE
+	TEMP
 	TO
 	TRANSACTION
+	TRY_CAST
 	UNION
 	UNIQUE
 	UPDATE
@@ -153,12 +161,14 @@ const (
 	TYPEBOOLEAN
 	TYPEBYTES
 	TYPECHARACTER
+	TYPEDECIMAL
 	TYPEDOUBLE
 	TYPEINT
 	TYPEINT2
 	TYPEINT8
 	TYPEINTEGER
 	TYPEMEDIUMINT
+	TYPENUMERIC
 	TYPEREAL
 	TYPESMALLINT
 	TYPETEXT
@@ -209,6 +219,7 @@ var tokens = [...]string{
 	IN:       "IN",
 	IS:       "IS",
 	LIKE:     "LIKE",
+	MATCH:    "MATCH",
 
 	LPAREN:      "(",
 	RPAREN:      ")",
@@ -232,6 +243,7 @@ var tokens = [...]string{
 	CACHE:       "CACHE",
 	CAST:        "CAST",
 	CHECK:       "CHECK",
+	COLLATE:     "COLLATE",
 	COLUMN:      "COLUMN",
 	COMMIT:      "COMMIT",
 	CONFLICT:    "CONFLICT",
@@ -250,6 +262,7 @@ var tokens = [...]string{
 	KEY:         "KEY",
 	FOR:         "FOR",
 	FROM:        "FROM",
+	FULLTEXT:    "FULLTEXT",
 	IF:          "IF",
 	IGNORE:      "IGNORE",
 	INCREMENT:   "INCREMENT",
@@ -271,17 +284,22 @@ var tokens = [...]string{
 	PRIMARY:     "PRIMARY",
 	READ:        "READ",
 	REINDEX:     "REINDEX",
+	RELEASE:     "RELEASE",
 	RENAME:      "RENAME",
 	RETURNING:   "RETURNING",
 	REPLACE:     "REPLACE",
 	ROLLBACK:    "ROLLBACK",
+	SAVEPOINT:   "SAVEPOINT",
 	START:       "START",
 	SELECT:      "SELECT",
 	SET:         "SET",
 	SEQUENCE:    "SEQUENCE",
+	SWAP:        "SWAP",
 	TABLE:       "TABLE",
+	TEMP:        "TEMP",
 	TO:          "TO",
 	TRANSACTION: "TRANSACTION",
+	TRY_CAST:    "TRY_CAST",
 	UNION:       "UNION",
 	UNIQUE:      "UNIQUE",
 	UPDATE:      "UPDATE",
@@ -297,12 +315,14 @@ var tokens = [...]string{
 	TYPEBOOLEAN:   "BOOLEAN",
 	TYPEBYTES:     "BYTES",
 	TYPECHARACTER: "CHARACTER",
+	TYPEDECIMAL:   "DECIMAL",
 	TYPEDOUBLE:    "DOUBLE",
 	TYPEINT:       "INT",
 	TYPEINT2:      "INT2",
 	TYPEINT8:      "INT8",
 	TYPEINTEGER:   "INTEGER",
 	TYPEMEDIUMINT: "MEDIUMINT",
+	TYPENUMERIC:   "NUMERIC",
 	TYPEREAL:      "REAL",
 	TYPESMALLINT:  "SMALLINT",
 	TYPETEXT:      "TEXT",
@@ -330,7 +350,7 @@ func (tok Token) Precedence() int {
 		return 2
 	case NOT:
 		return 3
-	case EQ, NEQ, IS, ISN, IN, NIN, LIKE, NLIKE, EQREGEX, NEQREGEX, BETWEEN:
+	case EQ, NEQ, IS, ISN, IN, NIN, LIKE, NLIKE, MATCH, EQREGEX, NEQREGEX, BETWEEN:
 		return 4
 	case LT, LTE, GT, GTE:
 		return 5
@@ -5,7 +5,6 @@ import (
 
 	"github.com/chaisql/chai/internal/environment"
 	"github.com/chaisql/chai/internal/stream"
-	"github.com/chaisql/chai/internal/types"
 	"github.com/cockroachdb/errors"
 )
 
@@ -58,13 +57,9 @@ func (op *DeleteOperator) Iterate(in *environment.Environment, fn func(out *envi
 			return err
 		}
 
-		vs := make([]types.Value, 0, len(info.Columns))
-		for _, column := range info.Columns {
-			v, err := old.Get(column)
-			if err != nil {
-				v = types.NewNullValue()
-			}
-			vs = append(vs, v)
+		vs, err := info.KeyValues(tx, old)
+		if err != nil {
+			return err
 		}
 
 		key, err := table.Info.EncodeKey(old.Key())
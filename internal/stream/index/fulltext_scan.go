@@ -0,0 +1,104 @@
+package index
+
+import (
+	"fmt"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/stream"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+)
+
+// A FulltextScanOperator iterates over the rows of a table
+// containing all the terms of a query, using a full-text index.
+type FulltextScanOperator struct {
+	stream.BaseOperator
+
+	// IndexName references the full-text index used to perform the scan.
+	IndexName string
+	// Query is the text searched in the index.
+	Query expr.Expr
+}
+
+// FulltextScan creates an iterator that iterates over the rows matching the query.
+func FulltextScan(name string, query expr.Expr) *FulltextScanOperator {
+	return &FulltextScanOperator{IndexName: name, Query: query}
+}
+
+func (it *FulltextScanOperator) Clone() stream.Operator {
+	return &FulltextScanOperator{
+		BaseOperator: it.BaseOperator.Clone(),
+		IndexName:    it.IndexName,
+		Query:        expr.Clone(it.Query),
+	}
+}
+
+// Iterate over the rows matching the query, in primary key order.
+func (it *FulltextScanOperator) Iterate(in *environment.Environment, fn func(out *environment.Environment) error) error {
+	tx := in.GetTx()
+
+	index, err := tx.Catalog.GetIndex(tx, it.IndexName)
+	if err != nil {
+		return err
+	}
+
+	info, err := tx.Catalog.GetIndexInfo(it.IndexName)
+	if err != nil {
+		return err
+	}
+
+	table, err := tx.Catalog.GetTable(tx, info.Owner.TableName)
+	if err != nil {
+		return err
+	}
+
+	q, err := it.Query.Eval(in)
+	if err != nil {
+		return err
+	}
+	if q.Type() != types.TypeText {
+		return nil
+	}
+
+	terms, err := index.Analyze(types.AsString(q))
+	if err != nil {
+		return err
+	}
+
+	keys, err := index.Search(terms)
+	if err != nil {
+		return err
+	}
+
+	var newEnv environment.Environment
+	newEnv.SetOuter(in)
+
+	var ptr database.LazyRow
+
+	newEnv.SetRow(&ptr)
+
+	for _, k := range keys {
+		if err := in.Err(); err != nil {
+			return err
+		}
+
+		ptr.ResetWith(table, tree.NewEncodedKey(k))
+
+		err = fn(&newEnv)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (it *FulltextScanOperator) Columns(env *environment.Environment) ([]string, error) {
+	return tableColumns(env, it.IndexName)
+}
+
+func (it *FulltextScanOperator) String() string {
+	return fmt.Sprintf("index.FulltextScan(%q, %v)", it.IndexName, it.Query)
+}
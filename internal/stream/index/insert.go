@@ -5,7 +5,6 @@ import (
 
 	"github.com/chaisql/chai/internal/environment"
 	"github.com/chaisql/chai/internal/stream"
-	"github.com/chaisql/chai/internal/types"
 	"github.com/cockroachdb/errors"
 )
 
@@ -32,6 +31,11 @@ func (op *InsertOperator) Clone() stream.Operator {
 func (op *InsertOperator) Iterate(in *environment.Environment, fn func(out *environment.Environment) error) error {
 	tx := in.GetTx()
 
+	// the index is rebuilt once the rows are inserted
+	if tx.IsIndexDeferred(op.indexName) {
+		return op.Prev.Iterate(in, fn)
+	}
+
 	idx, err := tx.Catalog.GetIndex(tx, op.indexName)
 	if err != nil {
 		return err
@@ -53,13 +57,9 @@ func (op *InsertOperator) Iterate(in *environment.Environment, fn func(out *envi
 			return errors.New("missing row")
 		}
 
-		vs := make([]types.Value, 0, len(info.Columns))
-		for _, column := range info.Columns {
-			v, err := r.Get(column)
-			if err != nil {
-				v = types.NewNullValue()
-			}
-			vs = append(vs, v)
+		vs, err := info.KeyValues(tx, r)
+		if err != nil {
+			return err
 		}
 
 		encKey, err := tinfo.EncodeKey(r.Key())
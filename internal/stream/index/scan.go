@@ -1,15 +1,18 @@
 package index
 
 import (
+	"slices"
 	"strconv"
 	"strings"
 
 	"github.com/cockroachdb/errors"
 
 	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/encoding"
 	"github.com/chaisql/chai/internal/environment"
 	"github.com/chaisql/chai/internal/stream"
 	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
 )
 
 // A ScanOperator iterates over the objects of an index.
@@ -49,17 +52,19 @@ func (op *ScanOperator) Clone() stream.Operator {
 func (it *ScanOperator) Iterate(in *environment.Environment, fn func(out *environment.Environment) error) error {
 	tx := in.GetTx()
 
-	index, err := tx.Catalog.GetIndex(tx, it.IndexName)
+	index, info, err := tx.GetIndex(it.IndexName)
 	if err != nil {
 		return err
 	}
 
-	info, err := tx.Catalog.GetIndexInfo(it.IndexName)
+	table, err := tx.Catalog.GetTable(tx, info.Owner.TableName)
 	if err != nil {
 		return err
 	}
 
-	table, err := tx.Catalog.GetTable(tx, info.Owner.TableName)
+	// the values of the indexed columns are read from the index entries,
+	// the table is only read if other columns are accessed.
+	covered, err := coveredColumns(table.Info, info)
 	if err != nil {
 		return err
 	}
@@ -71,12 +76,25 @@ func (it *ScanOperator) Iterate(in *environment.Environment, fn func(out *enviro
 
 	newEnv.SetRow(&ptr)
 
-	if len(it.Ranges) == 0 {
-		return index.IterateOnRange(nil, it.Reverse, func(key *tree.Key) error {
-			ptr.ResetWith(table, key)
+	columns := covered.names()
+	values := make([]types.Value, len(covered))
+	iterate := func(vs []types.Value, key *tree.Key) error {
+		if err := in.Err(); err != nil {
+			return err
+		}
 
-			return fn(&newEnv)
-		})
+		err := covered.values(values, vs, key)
+		if err != nil {
+			return err
+		}
+
+		ptr.ResetWithValues(table, key, columns, values)
+
+		return fn(&newEnv)
+	}
+
+	if len(it.Ranges) == 0 {
+		return index.IterateValuesOnRange(nil, it.Reverse, iterate)
 	}
 
 	ranges, err := it.Ranges.Eval(in)
@@ -85,16 +103,18 @@ func (it *ScanOperator) Iterate(in *environment.Environment, fn func(out *enviro
 	}
 
 	for _, rng := range ranges {
-		r, err := rng.ToTreeRange(&table.Info.ColumnConstraints, info.Columns)
+		var r *tree.Range
+		if info.Hash {
+			// hash indexes are only read with exact ranges
+			r, err = index.HashRange(rng.Min)
+		} else {
+			r, err = rng.ToTreeRange(&table.Info.ColumnConstraints, info.Columns)
+		}
 		if err != nil {
 			return err
 		}
 
-		err = index.IterateOnRange(r, it.Reverse, func(key *tree.Key) error {
-			ptr.ResetWith(table, key)
-
-			return fn(&newEnv)
-		})
+		err = index.IterateValuesOnRange(r, it.Reverse, iterate)
 		if errors.Is(err, stream.ErrStreamClosed) {
 			err = nil
 		}
@@ -106,10 +126,120 @@ func (it *ScanOperator) Iterate(in *environment.Environment, fn func(out *enviro
 	return nil
 }
 
+// A coveredColumn is a column of the table whose value can be read
+// from an index entry, either from the indexed values or from the primary key.
+type coveredColumn struct {
+	name string
+	tp   types.Type
+	// scale of a DECIMAL(precision, scale) column, which keys don't preserve,
+	// or -1.
+	scale int
+	// position of the value in the indexed values,
+	// or in the primary key if pk is true.
+	pos int
+	pk  bool
+}
+
+type coveredColumnList []coveredColumn
+
+// coveredColumns returns the columns of the table whose values are stored as is
+// in the entries of the index, in the order of the table.
+// Expressions and collated columns are not covered, as the index
+// only stores the result of the expression or a collation key.
+// Hash indexes only cover the columns of the primary key.
+func coveredColumns(ti *database.TableInfo, info *database.IndexInfo) (coveredColumnList, error) {
+	collations, err := ti.Collations(info.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	var list coveredColumnList
+	for _, cc := range ti.ColumnConstraints.Ordered {
+		c := coveredColumn{name: cc.Column, tp: cc.Type, scale: -1, pos: -1}
+		if cc.Type == types.TypeDecimal && cc.Precision > 0 {
+			c.scale = cc.Scale
+		}
+
+		for i, col := range info.Columns {
+			if col == cc.Column && !info.Hash && !info.IsExpr(i) && (collations == nil || collations[i] == nil) {
+				c.pos = i
+				break
+			}
+		}
+
+		if c.pos == -1 && ti.PrimaryKey != nil {
+			c.pos = slices.Index(ti.PrimaryKey.Columns, cc.Column)
+			c.pk = true
+		}
+
+		if c.pos != -1 {
+			list = append(list, c)
+		}
+	}
+
+	return list, nil
+}
+
+func (l coveredColumnList) names() []string {
+	names := make([]string, len(l))
+	for i, c := range l {
+		names[i] = c.name
+	}
+
+	return names
+}
+
+// values fills dst with the value of each covered column,
+// converted to the type of the column.
+func (l coveredColumnList) values(dst []types.Value, indexed []types.Value, key *tree.Key) error {
+	var pk []types.Value
+
+	for i, c := range l {
+		var v types.Value
+		if c.pk {
+			if pk == nil {
+				var err error
+				pk, err = key.Decode()
+				if err != nil {
+					return err
+				}
+			}
+			v = pk[c.pos]
+		} else {
+			v = indexed[c.pos]
+		}
+
+		switch {
+		case c.tp == types.TypeTimestamp && v.Type().IsInteger():
+			// timestamps are encoded as integers in keys
+			v = types.NewTimestampValue(encoding.ConvertToTimestamp(types.AsInt64(v)))
+		case c.tp != types.TypeAny && v.Type() != c.tp && v.Type() != types.TypeNull:
+			var err error
+			v, err = v.CastAs(c.tp)
+			if err != nil {
+				return err
+			}
+		}
+
+		if d, ok := v.(types.DecimalValue); ok && c.scale >= 0 {
+			v = d.Rescale(int32(c.scale))
+		}
+
+		dst[i] = v
+	}
+
+	return nil
+}
+
 func (it *ScanOperator) Columns(env *environment.Environment) ([]string, error) {
+	return tableColumns(env, it.IndexName)
+}
+
+// tableColumns returns the columns of the table of the index.
+func tableColumns(env *environment.Environment, indexName string) ([]string, error) {
 	tx := env.GetTx()
 
-	idxInfo, err := tx.Catalog.GetIndexInfo(it.IndexName)
+	idxInfo, err := tx.GetIndexInfo(indexName)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,149 @@
+package index
+
+import (
+	"fmt"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/geo"
+	"github.com/chaisql/chai/internal/stream"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+)
+
+// A SpatialScanOperator iterates over the rows of a table whose point
+// may be within a distance of a geometry, using a spatial index.
+// The index returns the points of the cells surrounding the geometry,
+// the condition must still be evaluated against each row.
+type SpatialScanOperator struct {
+	stream.BaseOperator
+
+	// IndexName references the spatial index used to perform the scan.
+	IndexName string
+	// Geometry is the geometry the points are searched around.
+	Geometry expr.Expr
+	// Distance is the maximum distance from the geometry, 0 if nil.
+	Distance expr.Expr
+}
+
+// SpatialScan creates an iterator that iterates over the rows whose point
+// may be within distance of the geometry. The distance may be nil.
+func SpatialScan(name string, geometry, distance expr.Expr) *SpatialScanOperator {
+	return &SpatialScanOperator{IndexName: name, Geometry: geometry, Distance: distance}
+}
+
+func (it *SpatialScanOperator) Clone() stream.Operator {
+	return &SpatialScanOperator{
+		BaseOperator: it.BaseOperator.Clone(),
+		IndexName:    it.IndexName,
+		Geometry:     expr.Clone(it.Geometry),
+		Distance:     expr.Clone(it.Distance),
+	}
+}
+
+// Iterate over the rows whose point may be within distance of the geometry,
+// in primary key order. If the area is too large to be looked up in the index,
+// all the rows of the table are returned.
+func (it *SpatialScanOperator) Iterate(in *environment.Environment, fn func(out *environment.Environment) error) error {
+	tx := in.GetTx()
+
+	index, err := tx.Catalog.GetIndex(tx, it.IndexName)
+	if err != nil {
+		return err
+	}
+
+	info, err := tx.Catalog.GetIndexInfo(it.IndexName)
+	if err != nil {
+		return err
+	}
+
+	table, err := tx.Catalog.GetTable(tx, info.Owner.TableName)
+	if err != nil {
+		return err
+	}
+
+	box, ok, err := it.box(in)
+	if err != nil || !ok {
+		return err
+	}
+
+	var newEnv environment.Environment
+	newEnv.SetOuter(in)
+
+	keys, ok, err := index.SearchBox(box)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return table.IterateOnRange(nil, false, func(key *tree.Key, r database.Row) error {
+			if err := in.Err(); err != nil {
+				return err
+			}
+
+			newEnv.SetRow(r)
+
+			return fn(&newEnv)
+		})
+	}
+
+	var ptr database.LazyRow
+
+	newEnv.SetRow(&ptr)
+
+	for _, k := range keys {
+		if err := in.Err(); err != nil {
+			return err
+		}
+
+		ptr.ResetWith(table, tree.NewEncodedKey(k))
+
+		err = fn(&newEnv)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// box returns the area to look up in the index.
+// It returns false if no row can match, i.e. if the geometry is NULL.
+func (it *SpatialScanOperator) box(env *environment.Environment) (geo.Box, bool, error) {
+	v, err := it.Geometry.Eval(env)
+	if err != nil || v.Type() != types.TypeText {
+		return geo.Box{}, false, err
+	}
+
+	g, err := geo.Parse(types.AsString(v))
+	if err != nil {
+		return geo.Box{}, false, err
+	}
+
+	if it.Distance == nil {
+		return g.BBox(), true, nil
+	}
+
+	d, err := it.Distance.Eval(env)
+	if err != nil || !d.Type().IsNumber() {
+		return geo.Box{}, false, err
+	}
+	d, err = d.CastAs(types.TypeDouble)
+	if err != nil {
+		return geo.Box{}, false, err
+	}
+
+	return g.BBox().Expand(types.AsFloat64(d)), true, nil
+}
+
+func (it *SpatialScanOperator) Columns(env *environment.Environment) ([]string, error) {
+	return tableColumns(env, it.IndexName)
+}
+
+func (it *SpatialScanOperator) String() string {
+	if it.Distance == nil {
+		return fmt.Sprintf("index.SpatialScan(%q, %v)", it.IndexName, it.Geometry)
+	}
+
+	return fmt.Sprintf("index.SpatialScan(%q, %v, %v)", it.IndexName, it.Geometry, it.Distance)
+}
@@ -0,0 +1,122 @@
+package index
+
+import (
+	"fmt"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/expr/glob"
+	"github.com/chaisql/chai/internal/fulltext"
+	"github.com/chaisql/chai/internal/stream"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+)
+
+// A TrigramScanOperator iterates over the rows of a table that may match
+// a LIKE pattern, using a full-text index built with the trigram analyzer.
+// The index only returns the rows containing all the trigrams of the pattern,
+// the pattern must still be evaluated against each row.
+type TrigramScanOperator struct {
+	stream.BaseOperator
+
+	// IndexName references the full-text index used to perform the scan.
+	IndexName string
+	// Pattern is the LIKE pattern.
+	Pattern expr.Expr
+}
+
+// TrigramScan creates an iterator that iterates over the rows that may match the pattern.
+func TrigramScan(name string, pattern expr.Expr) *TrigramScanOperator {
+	return &TrigramScanOperator{IndexName: name, Pattern: pattern}
+}
+
+func (it *TrigramScanOperator) Clone() stream.Operator {
+	return &TrigramScanOperator{
+		BaseOperator: it.BaseOperator.Clone(),
+		IndexName:    it.IndexName,
+		Pattern:      expr.Clone(it.Pattern),
+	}
+}
+
+// Iterate over the rows that may match the pattern, in primary key order.
+// If the pattern doesn't contain any trigram, i.e. '%ab%', all the rows
+// of the table are returned.
+func (it *TrigramScanOperator) Iterate(in *environment.Environment, fn func(out *environment.Environment) error) error {
+	tx := in.GetTx()
+
+	index, err := tx.Catalog.GetIndex(tx, it.IndexName)
+	if err != nil {
+		return err
+	}
+
+	info, err := tx.Catalog.GetIndexInfo(it.IndexName)
+	if err != nil {
+		return err
+	}
+
+	table, err := tx.Catalog.GetTable(tx, info.Owner.TableName)
+	if err != nil {
+		return err
+	}
+
+	p, err := it.Pattern.Eval(in)
+	if err != nil {
+		return err
+	}
+	if p.Type() != types.TypeText {
+		return nil
+	}
+
+	var terms []string
+	for _, l := range glob.LikeLiterals(types.AsString(p)) {
+		terms = append(terms, fulltext.SubstringTrigrams(l)...)
+	}
+
+	var newEnv environment.Environment
+	newEnv.SetOuter(in)
+
+	if len(terms) == 0 {
+		return table.IterateOnRange(nil, false, func(key *tree.Key, r database.Row) error {
+			if err := in.Err(); err != nil {
+				return err
+			}
+
+			newEnv.SetRow(r)
+
+			return fn(&newEnv)
+		})
+	}
+
+	keys, err := index.Search(terms)
+	if err != nil {
+		return err
+	}
+
+	var ptr database.LazyRow
+
+	newEnv.SetRow(&ptr)
+
+	for _, k := range keys {
+		if err := in.Err(); err != nil {
+			return err
+		}
+
+		ptr.ResetWith(table, tree.NewEncodedKey(k))
+
+		err = fn(&newEnv)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (it *TrigramScanOperator) Columns(env *environment.Environment) ([]string, error) {
+	return tableColumns(env, it.IndexName)
+}
+
+func (it *TrigramScanOperator) String() string {
+	return fmt.Sprintf("index.TrigramScan(%q, %v)", it.IndexName, it.Pattern)
+}
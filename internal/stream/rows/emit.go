@@ -25,6 +25,10 @@ func (op *EmitOperator) Iterate(in *environment.Environment, fn func(out *enviro
 	newEnv.SetOuter(in)
 
 	for _, e := range op.Rows {
+		if err := in.Err(); err != nil {
+			return err
+		}
+
 		r, err := e.Eval(in)
 		if err != nil {
 			return err
@@ -46,9 +50,20 @@ func (it *EmitOperator) Columns(env *environment.Environment) ([]string, error)
 }
 
 func (op *EmitOperator) Clone() stream.Operator {
+	// the expressions are cloned, since the optimizer replaces
+	// the parameters of the rows by their values
+	rows := make([]expr.Row, len(op.Rows))
+	for i, r := range op.Rows {
+		rows[i] = expr.Row{
+			Columns: r.Columns,
+			Exprs:   expr.LiteralExprList(r.Exprs).Clone().(expr.LiteralExprList),
+		}
+	}
+
 	return &EmitOperator{
 		BaseOperator: op.BaseOperator.Clone(),
-		Rows:         op.Rows,
+		Rows:         rows,
+		columns:      op.columns,
 	}
 }
 
@@ -0,0 +1,342 @@
+package rows
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/stream"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// MaxHashGroups is the maximum number of groups aggregated in memory
+// by a HashAggregateOperator. The rows of the other groups are spilled
+// to a temporary tree and aggregated once sorted.
+// Groups are also spilled once the estimated size of the groups held in memory
+// exceeds the work memory of the transaction.
+const MaxHashGroups = 10_000
+
+// A HashAggregateOperator aggregates the rows of an unsorted stream
+// by looking up the group of each row in a hash table.
+type HashAggregateOperator struct {
+	stream.BaseOperator
+	Builders []expr.AggregatorBuilder
+	E        expr.Expr
+	// Desc outputs the groups in descending order.
+	Desc bool
+}
+
+// HashAggregate consumes the incoming stream and outputs one value per group,
+// in the order of the groupBy expression.
+// Unlike GroupAggregate, the stream doesn't need to be sorted.
+func HashAggregate(groupBy expr.Expr, builders ...expr.AggregatorBuilder) *HashAggregateOperator {
+	return &HashAggregateOperator{E: groupBy, Builders: builders}
+}
+
+// HashAggregateReverse does the same as HashAggregate but outputs the groups in descending order.
+func HashAggregateReverse(groupBy expr.Expr, builders ...expr.AggregatorBuilder) *HashAggregateOperator {
+	return &HashAggregateOperator{E: groupBy, Builders: builders, Desc: true}
+}
+
+func (op *HashAggregateOperator) Clone() stream.Operator {
+	builders := make([]expr.AggregatorBuilder, len(op.Builders))
+	for i, b := range op.Builders {
+		builders[i] = expr.Clone(b).(expr.AggregatorBuilder)
+	}
+	return &HashAggregateOperator{
+		BaseOperator: op.BaseOperator.Clone(),
+		Builders:     builders,
+		E:            expr.Clone(op.E),
+		Desc:         op.Desc,
+	}
+}
+
+// a hashGroup holds the aggregators of a group and a copy of its last row,
+// as aggregators may keep values of the rows they read.
+type hashGroup struct {
+	key  []byte
+	ga   *groupAggregator
+	last environment.Environment
+	br   database.BasicRow
+}
+
+func (op *HashAggregateOperator) Iterate(in *environment.Environment, fn func(out *environment.Environment) error) error {
+	groupExpr := op.E.String()
+	groups := make(map[string]*hashGroup)
+
+	// rows of the groups that don't fit in memory
+	var spill *tree.Tree
+	var counter int64
+
+	// estimated size of the groups held in memory
+	budget := in.GetTx().WorkMemory()
+	var size int
+	var buf []byte
+
+	var cleanup func() error
+	defer func() {
+		if cleanup != nil {
+			_ = cleanup()
+		}
+	}()
+
+	err := op.Prev.Iterate(in, func(out *environment.Environment) error {
+		group, err := op.E.Eval(out)
+		if errors.Is(err, types.ErrColumnNotFound) {
+			group = types.NewNullValue()
+			err = nil
+		}
+		if err != nil {
+			return err
+		}
+
+		key, err := types.EncodeValueAsKey(nil, group, false)
+		if err != nil {
+			return err
+		}
+
+		r, ok := out.GetRow()
+		if !ok {
+			return errors.New("missing row")
+		}
+
+		g, ok := groups[string(key)]
+		if !ok && (len(groups) >= MaxHashGroups || size > budget) {
+			if spill == nil {
+				var order tree.SortOrder
+				if op.Desc {
+					order = order.SetDesc(0)
+				}
+
+				tns := in.GetTx().Catalog.GetFreeTransientNamespace()
+				spill, cleanup, err = tree.NewTransient(in.GetDB().Engine.NewTransientSession(), tns, order)
+				if err != nil {
+					return err
+				}
+			}
+
+			buf, err := encodeTempRow(nil, r)
+			if err != nil {
+				return errors.Wrap(err, "failed to encode row")
+			}
+
+			counter++
+			return spill.Put(tree.NewKey(group, types.NewBigintValue(counter)), buf)
+		}
+
+		if !ok {
+			// the size of a group is estimated from its key
+			// and the encoded size of its first row
+			buf, err = encodeTempRow(buf[:0], r)
+			if err != nil {
+				return errors.Wrap(err, "failed to encode row")
+			}
+			size += len(key) + len(buf)
+
+			// the group is decoded from its key, which doesn't share
+			// the buffers of the row, unless it is a decimal whose
+			// scale isn't preserved by the key
+			v, _ := types.DecodeValue(key)
+			if _, ok := group.(types.DecimalValue); ok {
+				v = group
+			}
+			g = &hashGroup{
+				key: key,
+				ga:  newGroupAggregator(v, groupExpr, op.Builders),
+			}
+			g.last.SetOuter(in)
+			groups[string(key)] = g
+		}
+
+		cb := row.NewColumnBuffer()
+		err = cb.DeepCopy(r)
+		if err != nil {
+			return err
+		}
+		g.br.ResetWith("", nil, cb)
+		g.last.SetRow(&g.br)
+
+		return g.ga.Aggregate(&g.last)
+	})
+	if err != nil {
+		return err
+	}
+
+	// if there is no group, we create a default group so that aggregators will
+	// return their default initial value, as GroupAggregate does.
+	if len(groups) == 0 {
+		e, err := newGroupAggregator(nil, "", op.Builders).Flush(in)
+		if err != nil {
+			return err
+		}
+		return fn(e)
+	}
+
+	// compare returns the order of two encoded groups in the output.
+	compare := func(a, b []byte) int {
+		if op.Desc {
+			return encoding.Compare(b, a)
+		}
+		return encoding.Compare(a, b)
+	}
+
+	sorted := make([]*hashGroup, 0, len(groups))
+	for _, g := range groups {
+		sorted = append(sorted, g)
+	}
+	slices.SortFunc(sorted, func(a, b *hashGroup) int {
+		return compare(a.key, b.key)
+	})
+
+	// emitBefore outputs the groups held in memory that precede
+	// the given group, or all of them if key is nil.
+	var next int
+	emitBefore := func(key []byte) error {
+		for ; next < len(sorted); next++ {
+			g := sorted[next]
+			if key != nil && compare(g.key, key) >= 0 {
+				return nil
+			}
+
+			e, err := g.ga.Flush(&g.last)
+			if err != nil {
+				return err
+			}
+			err = fn(e)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if spill != nil {
+		err = op.aggregateSpilled(in, spill, emitBefore, fn)
+		if err != nil {
+			return err
+		}
+	}
+
+	return emitBefore(nil)
+}
+
+// aggregateSpilled aggregates the sorted rows of the groups that didn't fit in memory
+// and outputs each group after the groups held in memory that precede it.
+func (op *HashAggregateOperator) aggregateSpilled(in *environment.Environment, spill *tree.Tree, emitBefore func(key []byte) error, fn func(out *environment.Environment) error) error {
+	groupExpr := op.E.String()
+
+	var ga *groupAggregator
+	var lastKey []byte
+
+	var env environment.Environment
+	env.SetOuter(in)
+	var br database.BasicRow
+
+	err := spill.IterateOnRange(nil, false, func(k *tree.Key, data []byte) error {
+		if err := in.Err(); err != nil {
+			return err
+		}
+
+		kv, err := k.Decode()
+		if err != nil {
+			return err
+		}
+
+		key, err := types.EncodeValueAsKey(nil, kv[0], false)
+		if err != nil {
+			return err
+		}
+
+		r := decodeTempRow(bytes.Clone(data))
+
+		if ga == nil || !bytes.Equal(key, lastKey) {
+			if ga != nil {
+				e, err := ga.Flush(&env)
+				if err != nil {
+					return err
+				}
+				err = fn(e)
+				if err != nil {
+					return err
+				}
+			}
+
+			err = emitBefore(key)
+			if err != nil {
+				return err
+			}
+
+			// the group is decoded from its key, as the key of the tree
+			// may point to a buffer reused by the iteration.
+			// Decimals are evaluated again, as keys don't preserve their scale
+			v, _ := types.DecodeValue(key)
+			if _, ok := v.(types.DecimalValue); ok {
+				var genv environment.Environment
+				genv.SetOuter(in)
+				var gbr database.BasicRow
+				gbr.ResetWith("", nil, r)
+				genv.SetRow(&gbr)
+
+				v, err = op.E.Eval(&genv)
+				if err != nil {
+					return err
+				}
+			}
+			ga = newGroupAggregator(v, groupExpr, op.Builders)
+			lastKey = key
+		}
+
+		br.ResetWith("", nil, r)
+		env.SetRow(&br)
+
+		return ga.Aggregate(&env)
+	})
+	if err != nil || ga == nil {
+		return err
+	}
+
+	e, err := ga.Flush(&env)
+	if err != nil {
+		return err
+	}
+	return fn(e)
+}
+
+func (op *HashAggregateOperator) Columns(env *environment.Environment) ([]string, error) {
+	columns := make([]string, 0, len(op.Builders)+1)
+	columns = append(columns, op.E.String())
+
+	for _, agg := range op.Builders {
+		columns = append(columns, agg.String())
+	}
+
+	return columns, nil
+}
+
+func (op *HashAggregateOperator) String() string {
+	var sb strings.Builder
+
+	sb.WriteString("rows.HashAggregate")
+	if op.Desc {
+		sb.WriteString("Reverse")
+	}
+	sb.WriteString("(")
+	sb.WriteString(op.E.String())
+
+	for _, agg := range op.Builders {
+		sb.WriteString(", ")
+		sb.WriteString(agg.(fmt.Stringer).String())
+	}
+
+	sb.WriteString(")")
+	return sb.String()
+}
@@ -0,0 +1,149 @@
+package rows_test
+
+import (
+	"testing"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/expr"
+	"github.com/chaisql/chai/internal/expr/functions"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/sql/parser"
+	"github.com/chaisql/chai/internal/stream"
+	"github.com/chaisql/chai/internal/stream/rows"
+	"github.com/chaisql/chai/internal/testutil"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashAggregate(t *testing.T) {
+	// emits the rows {"a": n} for each value in order
+	emit := func(values ...int) *rows.EmitOperator {
+		var rs []expr.Row
+		for _, v := range values {
+			rs = append(rs, expr.Row{
+				Columns: []string{"a"},
+				Exprs:   []expr.Expr{expr.LiteralValue{Value: types.NewIntegerValue(int32(v))}},
+			})
+		}
+		return rows.Emit([]string{"a"}, rs...)
+	}
+
+	// runs the stream in a transaction with the given work memory, if positive
+	runWith := func(t *testing.T, s *stream.Stream, workMemory int) []row.Row {
+		t.Helper()
+
+		db := testutil.NewTestDB(t)
+		conn := testutil.NewTestConn(t, db)
+		tx, err := conn.BeginTx(&database.TxOptions{WorkMemory: workMemory})
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		var env environment.Environment
+		env.DB = db
+		env.Tx = tx
+
+		var got []row.Row
+		err = s.Iterate(&env, func(env *environment.Environment) error {
+			r, ok := env.GetRow()
+			require.True(t, ok)
+			var fb row.ColumnBuffer
+			fb.Copy(r)
+			got = append(got, &fb)
+			return nil
+		})
+		require.NoError(t, err)
+		return got
+	}
+
+	run := func(t *testing.T, s *stream.Stream) []row.Row {
+		t.Helper()
+
+		return runWith(t, s, 0)
+	}
+
+	count := &functions.Count{Expr: parser.MustParseExpr("a")}
+
+	t.Run("Unsorted", func(t *testing.T) {
+		s := stream.New(emit(3, 1, 2, 3, 1, 3)).Pipe(rows.HashAggregate(parser.MustParseExpr("a"), count))
+
+		got := run(t, s)
+		want := testutil.MakeRows(t, `{"a": 1, "COUNT(a)": 2}`, `{"a": 2, "COUNT(a)": 1}`, `{"a": 3, "COUNT(a)": 3}`)
+		require.Equal(t, len(want), len(got))
+		for i := range want {
+			testutil.RequireRowEqual(t, want[i], got[i])
+		}
+	})
+
+	t.Run("Reverse", func(t *testing.T) {
+		s := stream.New(emit(3, 1, 2, 3, 1, 3)).Pipe(rows.HashAggregateReverse(parser.MustParseExpr("a"), count))
+
+		got := run(t, s)
+		want := testutil.MakeRows(t, `{"a": 3, "COUNT(a)": 3}`, `{"a": 2, "COUNT(a)": 1}`, `{"a": 1, "COUNT(a)": 2}`)
+		require.Equal(t, len(want), len(got))
+		for i := range want {
+			testutil.RequireRowEqual(t, want[i], got[i])
+		}
+	})
+
+	t.Run("No input", func(t *testing.T) {
+		s := stream.New(emit()).Pipe(rows.HashAggregate(parser.MustParseExpr("a"), count))
+
+		got := run(t, s)
+		require.Len(t, got, 1)
+		testutil.RequireRowEqual(t, testutil.MakeRow(t, `{"COUNT(a)": 0}`), got[0])
+	})
+
+	t.Run("Spill", func(t *testing.T) {
+		// more groups than can be held in memory, each of them seen twice,
+		// in an order that interleaves the groups held in memory and the spilled ones
+		var values []int
+		n := rows.MaxHashGroups + 100
+		for i := 0; i < n; i++ {
+			values = append(values, (i*7919)%n)
+		}
+		values = append(values, values...)
+
+		for _, desc := range []bool{false, true} {
+			op := rows.HashAggregate(parser.MustParseExpr("a"), count)
+			op.Desc = desc
+
+			got := run(t, stream.New(emit(values...)).Pipe(op))
+			require.Len(t, got, n)
+
+			for i, r := range got {
+				want := i
+				if desc {
+					want = n - 1 - i
+				}
+
+				var a, c int
+				require.NoError(t, row.Scan(r, &a, &c))
+				require.Equal(t, want, a)
+				require.Equal(t, 2, c)
+			}
+		}
+	})
+
+	t.Run("Spill above work memory", func(t *testing.T) {
+		var values []int
+		for i := 0; i < 100; i++ {
+			values = append(values, (i*37)%50)
+		}
+
+		got := runWith(t, stream.New(emit(values...)).Pipe(rows.HashAggregate(parser.MustParseExpr("a"), count)), 128)
+		require.Len(t, got, 50)
+
+		for i, r := range got {
+			var a, c int
+			require.NoError(t, row.Scan(r, &a, &c))
+			require.Equal(t, i, a)
+			require.Equal(t, 2, c)
+		}
+	})
+
+	t.Run("String", func(t *testing.T) {
+		require.Equal(t, `rows.HashAggregate(a % 2, a(), b())`, rows.HashAggregate(parser.MustParseExpr("a % 2"), makeAggregatorBuilders("a()", "b()")...).String())
+		require.Equal(t, `rows.HashAggregateReverse(a % 2)`, rows.HashAggregateReverse(parser.MustParseExpr("a % 2")).String())
+	})
+}
@@ -1,9 +1,14 @@
 package rows
 
 import (
+	"bytes"
+	"container/heap"
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/encoding"
 	"github.com/chaisql/chai/internal/environment"
 	"github.com/chaisql/chai/internal/expr"
 	"github.com/chaisql/chai/internal/row"
@@ -18,10 +23,30 @@ type TempTreeSortOperator struct {
 	stream.BaseOperator
 	Expr expr.Expr
 	Desc bool
+	// Then holds the expressions used to order the values
+	// that are equal on the previous ones.
+	Then []SortKey
+	// Limit, if set, is the number of values read by the next operators
+	// after skipping the first Offset values. Only the first Limit + Offset values
+	// are kept while consuming the stream.
+	Limit  expr.Expr
+	Offset expr.Expr
+}
+
+// maximum number of values sorted in memory when the operator is limited.
+// Above it, values are sorted using a temporary tree.
+const maxTopKSize = 10_000
+
+// A SortKey is an expression used by TempTreeSortOperator
+// to order values that are equal on the previous keys.
+type SortKey struct {
+	Expr expr.Expr
+	Desc bool
 }
 
 // TempTreeSort consumes every value of the stream, sorts them by the given expr and outputs them in order.
-// It creates a temporary index and uses it to sort the stream.
+// Values are sorted in memory until they exceed the work memory of the transaction,
+// after which they are spilled to a temporary index used to sort the stream.
 func TempTreeSort(e expr.Expr) *TempTreeSortOperator {
 	return &TempTreeSortOperator{Expr: e}
 }
@@ -31,80 +56,118 @@ func TempTreeSortReverse(e expr.Expr) *TempTreeSortOperator {
 	return &TempTreeSortOperator{Expr: e, Desc: true}
 }
 
+// ThenBy adds an expression used to sort the values that are equal on the previous ones.
+func (op *TempTreeSortOperator) ThenBy(e expr.Expr, desc bool) *TempTreeSortOperator {
+	op.Then = append(op.Then, SortKey{Expr: e, Desc: desc})
+	return op
+}
+
 func (op *TempTreeSortOperator) Clone() stream.Operator {
+	var then []SortKey
+	for _, k := range op.Then {
+		then = append(then, SortKey{Expr: expr.Clone(k.Expr), Desc: k.Desc})
+	}
+
 	return &TempTreeSortOperator{
 		BaseOperator: op.BaseOperator.Clone(),
 		Expr:         expr.Clone(op.Expr),
 		Desc:         op.Desc,
+		Then:         then,
+		Limit:        expr.Clone(op.Limit),
+		Offset:       expr.Clone(op.Offset),
 	}
 }
 
+// WithLimit sets the number of values read after the sort,
+// allowing the operator to only keep the first limit + offset values.
+// The offset may be nil.
+func (op *TempTreeSortOperator) WithLimit(limit, offset expr.Expr) *TempTreeSortOperator {
+	op.Limit = limit
+	op.Offset = offset
+	return op
+}
+
 func (op *TempTreeSortOperator) Iterate(in *environment.Environment, fn func(out *environment.Environment) error) error {
+	if op.Limit != nil {
+		k, err := op.keptValues(in)
+		if err != nil {
+			return err
+		}
+
+		if k <= maxTopKSize {
+			return op.iterateTopK(in, k, fn)
+		}
+	}
+
 	db := in.GetDB()
 
 	catalog := in.GetTx().Catalog
 	tns := catalog.GetFreeTransientNamespace()
-	tr, cleanup, err := tree.NewTransient(db.Engine.NewTransientSession(), tns, 0)
-	if err != nil {
-		return err
+
+	// with a single key, the tree is iterated in reverse to sort in descending order.
+	// with several keys, the direction of each key is encoded in the tree.
+	var order tree.SortOrder
+	reverse := op.Desc
+	if len(op.Then) > 0 {
+		reverse = false
+		order = op.sortOrder()
 	}
-	defer cleanup()
 
-	var counter int64
+	// values are sorted in memory until their size exceeds the work memory.
+	// they are then spilled to a temporary tree, which writes them to the
+	// engine in sorted batches and merges them when iterated.
+	budget := in.GetTx().WorkMemory()
+	var buffered []sortedValue
+	var size int
+
+	var tr *tree.Tree
+	var cleanup func() error
+	defer func() {
+		if cleanup != nil {
+			_ = cleanup()
+		}
+	}()
 
-	var buf []byte
-	err = op.Prev.Iterate(in, func(out *environment.Environment) error {
-		buf = buf[:0]
+	var counter int64
 
-		// evaluate the sort expression
-		v, err := op.Expr.Eval(out)
+	values := make([]types.Value, len(op.Then)+4)
+	err := op.Prev.Iterate(in, func(out *environment.Environment) error {
+		data, err := op.encodeRow(out, catalog, values, counter, nil)
 		if err != nil {
-			if !errors.Is(err, types.ErrColumnNotFound) {
-				return err
-			}
+			return err
+		}
+		counter++
 
-			v = nil
+		enc, err := tree.NewKey(values...).Encode(tns, order)
+		if err != nil {
+			return err
 		}
 
-		if v == nil {
-			// the expression might be pointing to the original row.
-			v, err = op.Expr.Eval(out.GetOuter())
-			if err != nil {
-				// the only valid error here is a missing column.
-				if !errors.Is(err, types.ErrColumnNotFound) {
-					return err
-				}
-			}
+		if tr != nil {
+			return tr.Put(tree.NewEncodedKey(enc), data)
 		}
 
-		r, ok := out.GetDatabaseRow()
-		if !ok {
-			return errors.New("missing row")
+		buffered = append(buffered, sortedValue{enc: enc, data: data})
+		size += len(enc) + len(data)
+		if size <= budget {
+			return nil
 		}
 
-		buf, err = encodeTempRow(buf, r)
+		tr, cleanup, err = tree.NewTransient(db.Engine.NewTransientSession(), tns, order)
 		if err != nil {
-			return errors.Wrap(err, "failed to encode row")
+			return err
 		}
 
-		var encKey []byte
-		key := r.Key()
-		if key != nil {
-			info, err := catalog.GetTableInfo(r.TableName())
-			if err != nil {
-				return err
-			}
-			encKey, err = info.EncodeKey(key)
+		sortValues(buffered)
+		for _, sv := range buffered {
+			err = tr.Put(tree.NewEncodedKey(sv.enc), sv.data)
 			if err != nil {
 				return err
 			}
 		}
+		buffered = nil
 
-		tk := tree.NewKey(v, types.NewTextValue(r.TableName()), types.NewBlobValue(encKey), types.NewBigintValue(counter))
-
-		counter++
-
-		return tr.Put(tk, buf)
+		return nil
 	})
 	if err != nil {
 		return err
@@ -113,35 +176,325 @@ func (op *TempTreeSortOperator) Iterate(in *environment.Environment, fn func(out
 	var newEnv environment.Environment
 	newEnv.SetOuter(in)
 	var br database.BasicRow
-	return tr.IterateOnRange(nil, op.Desc, func(k *tree.Key, data []byte) error {
+	output := func(k *tree.Key, data []byte) error {
+		if err := in.Err(); err != nil {
+			return err
+		}
+
 		kv, err := k.Decode()
 		if err != nil {
 			return err
 		}
 
-		var tableName string
-		tf := kv[1]
-		if tf.Type() != types.TypeNull {
-			tableName = types.AsString(tf)
+		op.resetRow(&br, kv[len(op.Then):], data)
+		newEnv.SetRow(&br)
+
+		return fn(&newEnv)
+	}
+
+	if tr != nil {
+		return tr.IterateOnRange(nil, reverse, output)
+	}
+
+	sortValues(buffered)
+	if reverse {
+		slices.Reverse(buffered)
+	}
+
+	for _, sv := range buffered {
+		err = output(tree.NewEncodedKey(sv.enc), sv.data)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sortValues sorts the values by their encoded sort key.
+func sortValues(values []sortedValue) {
+	slices.SortFunc(values, func(a, b sortedValue) int {
+		return encoding.Compare(a.enc, b.enc)
+	})
+}
+
+// sortOrder returns the order of the tree keys encoding the direction of each sort key.
+func (op *TempTreeSortOperator) sortOrder() tree.SortOrder {
+	var order tree.SortOrder
+	if op.Desc {
+		order = order.SetDesc(0)
+	}
+	for i, k := range op.Then {
+		if k.Desc {
+			order = order.SetDesc(i + 1)
+		}
+	}
+
+	return order
+}
+
+// encodeRow evaluates the sort expressions against the row of the environment
+// and stores them in values, followed by the table name, the primary key of the row
+// and the counter, used to keep the order of values with equal sort keys.
+// It returns the encoded row.
+func (op *TempTreeSortOperator) encodeRow(out *environment.Environment, catalog *database.Catalog, values []types.Value, counter int64, buf []byte) ([]byte, error) {
+	// evaluate the sort expressions
+	v, err := evalSortExpr(op.Expr, out)
+	if err != nil {
+		return nil, err
+	}
+	values[0] = v
+
+	for i, k := range op.Then {
+		values[i+1], err = evalSortExpr(k.Expr, out)
+		if err != nil {
+			return nil, err
 		}
+	}
+
+	r, ok := out.GetDatabaseRow()
+	if !ok {
+		return nil, errors.New("missing row")
+	}
+
+	buf, err = encodeTempRow(buf, r)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode row")
+	}
 
-		var key *tree.Key
-		kf := kv[2]
-		if kf.Type() != types.TypeNull {
-			key = tree.NewEncodedKey(types.AsByteSlice(kf))
+	var encKey []byte
+	key := r.Key()
+	if key != nil {
+		info, err := catalog.GetTableInfo(r.TableName())
+		if err != nil {
+			return nil, err
+		}
+		encKey, err = info.EncodeKey(key)
+		if err != nil {
+			return nil, err
 		}
+	}
 
-		r := decodeTempRow(data)
+	n := len(op.Then) + 1
+	values[n] = types.NewTextValue(r.TableName())
+	values[n+1] = types.NewBlobValue(encKey)
+	values[n+2] = types.NewBigintValue(counter)
 
-		br.ResetWith(tableName, key, r)
+	return buf, nil
+}
 
-		newEnv.SetRow(&br)
+// resetRow resets the row with the end of a sort key, starting with its last
+// sort expression, and with its encoded row.
+func (op *TempTreeSortOperator) resetRow(br *database.BasicRow, kv []types.Value, data []byte) {
+	var tableName string
+	tf := kv[1]
+	if tf.Type() != types.TypeNull {
+		tableName = types.AsString(tf)
+	}
 
-		return fn(&newEnv)
+	var key *tree.Key
+	kf := kv[2]
+	if kf.Type() != types.TypeNull {
+		key = tree.NewEncodedKey(types.AsByteSlice(kf))
+	}
+
+	br.ResetWith(tableName, key, decodeTempRow(data))
+}
+
+// keptValues returns the number of values that must be kept
+// to return the first Limit values after skipping Offset values.
+func (op *TempTreeSortOperator) keptValues(in *environment.Environment) (int64, error) {
+	limit, err := evalCount(op.Limit, in, "limit")
+	if err != nil {
+		return 0, err
+	}
+	if limit <= 0 {
+		return 0, nil
+	}
+
+	var offset int64
+	if op.Offset != nil {
+		offset, err = evalCount(op.Offset, in, "offset")
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return limit + max(offset, 0), nil
+}
+
+// evalCount evaluates a LIMIT or OFFSET expression.
+func evalCount(e expr.Expr, in *environment.Environment, clause string) (int64, error) {
+	v, err := e.Eval(in)
+	if err != nil {
+		return 0, err
+	}
+
+	if !v.Type().IsNumber() {
+		return 0, fmt.Errorf("%s expression must evaluate to a number, got %q", clause, v.Type())
+	}
+
+	v, err = v.CastAs(types.TypeBigint)
+	if err != nil {
+		return 0, err
+	}
+
+	return types.AsInt64(v), nil
+}
+
+// iterateTopK consumes the stream while keeping the first k values
+// in a bounded heap, then outputs them in order.
+func (op *TempTreeSortOperator) iterateTopK(in *environment.Environment, k int64, fn func(out *environment.Environment) error) error {
+	if k == 0 {
+		return nil
+	}
+
+	catalog := in.GetTx().Catalog
+	order := op.sortOrder()
+
+	var h topKHeap
+	var counter int64
+	values := make([]types.Value, len(op.Then)+4)
+	err := op.Prev.Iterate(in, func(out *environment.Environment) error {
+		data, err := op.encodeRow(out, catalog, values, counter, nil)
+		if err != nil {
+			return err
+		}
+		counter++
+
+		enc, err := tree.NewKey(values...).Encode(0, order)
+		if err != nil {
+			return err
+		}
+
+		sv := sortedValue{enc: enc, data: data}
+		if int64(len(h)) == k {
+			// the heap holds the last of the kept values at its root
+			if encoding.Compare(enc, h[0].enc) >= 0 {
+				return nil
+			}
+
+			heap.Pop(&h)
+		}
+
+		// the primary key may point to a buffer reused by the stream
+		n := len(op.Then)
+		sv.key = []types.Value{values[n], values[n+1], types.NewBlobValue(bytes.Clone(types.AsByteSlice(values[n+2])))}
+		heap.Push(&h, sv)
+		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	sorted := make([]sortedValue, len(h))
+	for i := len(sorted) - 1; i >= 0; i-- {
+		sorted[i] = heap.Pop(&h).(sortedValue)
+	}
+
+	var newEnv environment.Environment
+	newEnv.SetOuter(in)
+	var br database.BasicRow
+	for _, sv := range sorted {
+		op.resetRow(&br, sv.key, sv.data)
+		newEnv.SetRow(&br)
+
+		err = fn(&newEnv)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// a sortedValue is a value held in memory by a sort,
+// along with its encoded sort key.
+type sortedValue struct {
+	enc  []byte
+	key  []types.Value // last sort expression, table name and primary key
+	data []byte
+}
+
+// topKHeap is a max-heap of sorted values.
+type topKHeap []sortedValue
+
+func (h topKHeap) Len() int           { return len(h) }
+func (h topKHeap) Less(i, j int) bool { return encoding.Compare(h[i].enc, h[j].enc) > 0 }
+func (h topKHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *topKHeap) Push(x any)        { *h = append(*h, x.(sortedValue)) }
+func (h *topKHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
+
+// evalSortExpr evaluates the sort key of the expression against the row of the environment,
+// or against the original row if the column is not found.
+func evalSortExpr(e expr.Expr, out *environment.Environment) (types.Value, error) {
+	v, err := expr.SortKey(e, out)
+	if err != nil {
+		if !errors.Is(err, types.ErrColumnNotFound) {
+			return nil, err
+		}
+
+		v = nil
+	}
+
+	if v == nil {
+		// the expression might be pointing to the original row.
+		v, err = expr.SortKey(e, out.GetOuter())
+		if err != nil {
+			// the only valid error here is a missing column.
+			if !errors.Is(err, types.ErrColumnNotFound) {
+				return nil, err
+			}
+		}
+	}
+
+	return v, nil
 }
 
 func (op *TempTreeSortOperator) String() string {
+	s := op.sortString()
+	if op.Limit == nil {
+		return s
+	}
+
+	// i.e. rows.TempTreeSort(a LIMIT 10 OFFSET 5)
+	var sb strings.Builder
+	sb.WriteString(s[:len(s)-1])
+	sb.WriteString(" LIMIT ")
+	sb.WriteString(op.Limit.String())
+	if op.Offset != nil {
+		sb.WriteString(" OFFSET ")
+		sb.WriteString(op.Offset.String())
+	}
+	sb.WriteString(")")
+	return sb.String()
+}
+
+func (op *TempTreeSortOperator) sortString() string {
+	if len(op.Then) > 0 {
+		var sb strings.Builder
+		sb.WriteString("rows.TempTreeSort(")
+		sb.WriteString(op.Expr.String())
+		if op.Desc {
+			sb.WriteString(" DESC")
+		}
+		for _, k := range op.Then {
+			sb.WriteString(", ")
+			sb.WriteString(k.Expr.String())
+			if k.Desc {
+				sb.WriteString(" DESC")
+			}
+		}
+		sb.WriteString(")")
+		return sb.String()
+	}
+
 	if op.Desc {
 		return fmt.Sprintf("rows.TempTreeSortReverse(%s)", op.Expr)
 	}
@@ -150,18 +503,26 @@ func (op *TempTreeSortOperator) String() string {
 }
 
 func encodeTempRow(buf []byte, r row.Row) ([]byte, error) {
-	var values []types.Value
 	err := r.Iterate(func(column string, v types.Value) error {
-		values = append(values, types.NewTextValue(column))
-		values = append(values, types.NewIntegerValue(int32(v.Type())))
-		values = append(values, v)
-		return nil
+		var err error
+		buf, err = types.EncodeValuesAsKey(buf, types.NewTextValue(column), types.NewIntegerValue(int32(v.Type())))
+		if err != nil {
+			return err
+		}
+
+		// the key encoding of decimals doesn't preserve their scale
+		if d, ok := v.(types.DecimalValue); ok {
+			buf, err = d.Encode(buf)
+		} else {
+			buf, err = types.EncodeValueAsKey(buf, v, false)
+		}
+		return err
 	})
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to iterate row")
 	}
 
-	return types.EncodeValuesAsKey(buf, values...)
+	return buf, nil
 }
 
 func decodeTempRow(b []byte) row.Row {
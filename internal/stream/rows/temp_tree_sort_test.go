@@ -1,9 +1,11 @@
 package rows_test
 
 import (
+	"slices"
 	"testing"
 
 	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/database/catalogstore"
 	"github.com/chaisql/chai/internal/environment"
 	"github.com/chaisql/chai/internal/expr"
 	"github.com/chaisql/chai/internal/row"
@@ -105,5 +107,123 @@ func TestTempTreeSort(t *testing.T) {
 
 	t.Run("String", func(t *testing.T) {
 		require.Equal(t, `rows.TempTreeSort(a)`, rows.TempTreeSort(parser.MustParseExpr("a")).String())
+		require.Equal(t, `rows.TempTreeSort(a, b DESC)`, rows.TempTreeSort(parser.MustParseExpr("a")).ThenBy(parser.MustParseExpr("b"), true).String())
+		require.Equal(t, `rows.TempTreeSort(a DESC, b)`, rows.TempTreeSortReverse(parser.MustParseExpr("a")).ThenBy(parser.MustParseExpr("b"), false).String())
+		require.Equal(t, `rows.TempTreeSortReverse(a LIMIT 10)`, rows.TempTreeSortReverse(parser.MustParseExpr("a")).WithLimit(parser.MustParseExpr("10"), nil).String())
+		require.Equal(t, `rows.TempTreeSort(a, b DESC LIMIT 10 OFFSET 5)`, rows.TempTreeSort(parser.MustParseExpr("a")).ThenBy(parser.MustParseExpr("b"), true).WithLimit(parser.MustParseExpr("10"), parser.MustParseExpr("5")).String())
 	})
 }
+
+func TestTempTreeSortLimit(t *testing.T) {
+	tests := []struct {
+		name   string
+		sort   *rows.TempTreeSortOperator
+		limit  string
+		offset string
+		want   []int
+	}{
+		{"ASC", rows.TempTreeSort(parser.MustParseExpr("a")), "3", "", []int{0, 1, 1}},
+		{"DESC", rows.TempTreeSortReverse(parser.MustParseExpr("a")), "3", "", []int{5, 4, 3}},
+		{"Offset", rows.TempTreeSort(parser.MustParseExpr("a")), "2", "3", []int{0, 1, 1, 2, 3}},
+		{"Several keys", rows.TempTreeSort(parser.MustParseExpr("a")).ThenBy(parser.MustParseExpr("b"), true), "3", "", []int{0, 1, 1}},
+		{"Limit above the number of rows", rows.TempTreeSort(parser.MustParseExpr("a")), "100", "", []int{0, 1, 1, 2, 3, 4, 5}},
+		{"Zero", rows.TempTreeSort(parser.MustParseExpr("a")), "0", "", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, tx, cleanup := testutil.NewTestTx(t)
+			defer cleanup()
+
+			testutil.MustExec(t, db, tx, "CREATE TABLE test(a int, b int)")
+			testutil.MustExec(t, db, tx, "INSERT INTO test VALUES (3, 1), (1, 2), (5, 3), (0, 4), (4, 5), (1, 6), (2, 7)")
+
+			var env environment.Environment
+			env.DB = db
+			env.Tx = tx
+
+			var offset expr.Expr
+			if test.offset != "" {
+				offset = parser.MustParseExpr(test.offset)
+			}
+
+			s := stream.New(table.Scan("test")).Pipe(test.sort.WithLimit(parser.MustParseExpr(test.limit), offset))
+
+			var got []int
+			var lastB int
+			err := s.Iterate(&env, func(env *environment.Environment) error {
+				r, ok := env.GetRow()
+				require.True(t, ok)
+
+				var a, b int
+				require.NoError(t, row.Scan(r, &a, &b))
+				// rows with equal values of a are sorted by b
+				if len(got) > 0 && got[len(got)-1] == a && len(test.sort.Then) > 0 {
+					require.Less(t, b, lastB)
+				}
+				got = append(got, a)
+				lastB = b
+				return nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestTempTreeSortSpill(t *testing.T) {
+	// a work memory small enough to spill after a few rows
+	db, err := database.Open(":memory:", &database.Options{
+		CatalogLoader: catalogstore.LoadCatalog,
+		WorkMemory:    256,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	conn := testutil.NewTestConn(t, db)
+	tx, err := conn.BeginTx(&database.TxOptions{})
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	testutil.MustExec(t, db, tx, "CREATE TABLE test(a int, b int)")
+	for i := 0; i < 100; i++ {
+		testutil.MustExec(t, db, tx, "INSERT INTO test VALUES (?, ?)", environment.Param{Value: (i * 37) % 50}, environment.Param{Value: i})
+	}
+
+	tests := []struct {
+		name string
+		sort *rows.TempTreeSortOperator
+		desc bool
+	}{
+		{"ASC", rows.TempTreeSort(parser.MustParseExpr("a")), false},
+		{"DESC", rows.TempTreeSortReverse(parser.MustParseExpr("a")), true},
+		{"Several keys", rows.TempTreeSort(parser.MustParseExpr("a")).ThenBy(parser.MustParseExpr("b"), true), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var env environment.Environment
+			env.DB = db
+			env.Tx = tx
+
+			var got []int
+			err := stream.New(table.Scan("test")).Pipe(test.sort).Iterate(&env, func(env *environment.Environment) error {
+				r, ok := env.GetRow()
+				require.True(t, ok)
+
+				var a, b int
+				require.NoError(t, row.Scan(r, &a, &b))
+				got = append(got, a)
+				return nil
+			})
+			require.NoError(t, err)
+			require.Len(t, got, 100)
+			require.True(t, slices.IsSortedFunc(got, func(x, y int) int {
+				if test.desc {
+					return y - x
+				}
+				return x - y
+			}))
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package table
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/cockroachdb/errors"
+)
+
+// IndexHintKind describes how an IndexHint restricts the indexes
+// the planner can use.
+type IndexHintKind uint8
+
+// List of index hints.
+const (
+	// UseIndex restricts the planner to the listed indexes.
+	UseIndex IndexHintKind = iota + 1
+	// IgnoreIndex prevents the planner from using the listed indexes.
+	IgnoreIndex
+	// NoIndex prevents the planner from using any index.
+	NoIndex
+)
+
+// An IndexHint restricts the indexes used to read a table,
+// i.e. SELECT * FROM foo USE INDEX (foo_a_idx).
+// The primary key of the table is not considered an index,
+// its ranges can only be used if the hint isn't UseIndex.
+type IndexHint struct {
+	Kind    IndexHintKind
+	Indexes []string
+}
+
+// AllowsIndex returns whether the index can be used to read the table.
+func (h *IndexHint) AllowsIndex(name string) bool {
+	if h == nil {
+		return true
+	}
+
+	switch h.Kind {
+	case UseIndex:
+		return slices.Contains(h.Indexes, name)
+	case IgnoreIndex:
+		return !slices.Contains(h.Indexes, name)
+	}
+
+	return false
+}
+
+// AllowsPrimaryKey returns whether the ranges of the primary key
+// can be used to read the table.
+func (h *IndexHint) AllowsPrimaryKey() bool {
+	return h == nil || h.Kind != UseIndex
+}
+
+// Validate ensures the indexes of the hint exist and belong to the table.
+func (h *IndexHint) Validate(tx *database.Transaction, tableName string) error {
+	if h == nil {
+		return nil
+	}
+
+	for _, name := range h.Indexes {
+		if slices.ContainsFunc(tx.Connection().TempIndexes(tx, tableName), func(info *database.IndexInfo) bool {
+			return info.IndexName == name
+		}) {
+			continue
+		}
+
+		info, err := tx.Catalog.GetIndexInfo(name)
+		if err != nil {
+			return err
+		}
+		if info.Owner.TableName != tableName {
+			return errors.Errorf("index %s does not belong to table %s", name, tableName)
+		}
+	}
+
+	return nil
+}
+
+func (h *IndexHint) Clone() *IndexHint {
+	if h == nil {
+		return nil
+	}
+
+	return &IndexHint{Kind: h.Kind, Indexes: slices.Clone(h.Indexes)}
+}
+
+func (h *IndexHint) String() string {
+	var s strings.Builder
+
+	switch h.Kind {
+	case UseIndex:
+		s.WriteString("USE INDEX (")
+	case IgnoreIndex:
+		s.WriteString("IGNORE INDEX (")
+	case NoIndex:
+		return "NO INDEX"
+	}
+
+	s.WriteString(strings.Join(h.Indexes, ", "))
+	s.WriteString(")")
+
+	return s.String()
+}
@@ -0,0 +1,386 @@
+package table
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/row"
+	"github.com/chaisql/chai/internal/stream"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// MaxParallelism is the maximum number of workers
+// that can be used to scan a table.
+const MaxParallelism = 64
+
+// number of rows sent at once by a worker,
+// and number of batches a worker can produce in advance.
+const (
+	parallelBatchSize   = 256
+	parallelBatchBuffer = 8
+)
+
+// A ParallelScanOperator splits a table in ranges of its primary key
+// and runs a stream of operators, i.e. filters and projections,
+// on the rows of each range in a separate goroutine.
+// Rows are returned in the order of the primary key, as with a sequential scan.
+type ParallelScanOperator struct {
+	stream.BaseOperator
+	TableName string
+	Workers   int
+	// Stream is the list of operators run by each worker
+	// on the rows of its range.
+	Stream *stream.Stream
+}
+
+// ParallelScan creates an operator that scans the table using n workers,
+// each of them running the given operators.
+func ParallelScan(tableName string, n int, ops ...stream.Operator) *ParallelScanOperator {
+	var s *stream.Stream
+	for _, op := range ops {
+		s = s.Pipe(op)
+	}
+
+	return &ParallelScanOperator{TableName: tableName, Workers: n, Stream: s}
+}
+
+func (op *ParallelScanOperator) Clone() stream.Operator {
+	var s *stream.Stream
+	if op.Stream != nil {
+		s = op.Stream.Clone()
+	}
+
+	return &ParallelScanOperator{
+		BaseOperator: op.BaseOperator.Clone(),
+		TableName:    op.TableName,
+		Workers:      op.Workers,
+		Stream:       s,
+	}
+}
+
+// Iterate over the rows of the table. Tables are scanned sequentially
+// by read/write transactions, whose changes cannot be read concurrently,
+// if their primary key cannot be split, and if the engine cannot fork
+// the session of the transaction.
+// Each worker reads the snapshot of the transaction with its own session,
+// as sessions are not safe for concurrent use.
+func (op *ParallelScanOperator) Iterate(in *environment.Environment, fn func(out *environment.Environment) error) error {
+	tx := in.GetTx()
+
+	table, err := tx.Catalog.GetTable(tx, op.TableName)
+	if err != nil {
+		return err
+	}
+
+	var ranges []*database.Range
+	if !tx.Writable && op.Workers > 1 {
+		ranges, err = splitTable(table, op.Workers)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the sessions are closed once the workers are stopped
+	var sessions []engine.Session
+	defer func() {
+		for _, sess := range sessions {
+			_ = sess.Close()
+		}
+	}()
+	if len(ranges) >= 2 {
+		for range ranges {
+			sess, ok := tx.ForkSession()
+			if !ok {
+				ranges = nil
+				break
+			}
+			sessions = append(sessions, sess)
+		}
+	}
+
+	if len(ranges) < 2 {
+		err = op.partitionStream(table, nil).Iterate(in, fn)
+		if errors.Is(err, stream.ErrStreamClosed) {
+			err = nil
+		}
+		return err
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+
+	results := make([]chan []parallelRow, len(ranges))
+	errs := make([]error, len(ranges))
+	for i, rng := range ranges {
+		results[i] = make(chan []parallelRow, parallelBatchBuffer)
+
+		wg.Add(1)
+		go func(i int, rng *database.Range) {
+			defer wg.Done()
+			defer close(results[i])
+
+			t := database.Table{
+				Tx:   table.Tx,
+				Tree: tree.New(sessions[i], table.Tree.Namespace, table.Tree.Order),
+				Info: table.Info,
+			}
+			errs[i] = op.scanPartition(in, &t, rng, results[i], done)
+		}(i, rng)
+	}
+
+	// stop the workers before returning, as the transaction
+	// may be closed once the iteration is over.
+	defer func() {
+		close(done)
+		wg.Wait()
+	}()
+
+	var newEnv environment.Environment
+	newEnv.SetOuter(in)
+	var br database.BasicRow
+
+	// ranges are consumed in order
+	for i := range ranges {
+		for batch := range results[i] {
+			for _, r := range batch {
+				br.ResetWith(r.tableName, r.key, r.row)
+				newEnv.SetRow(&br)
+
+				err := fn(&newEnv)
+				if errors.Is(err, stream.ErrStreamClosed) {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		if errs[i] != nil {
+			return errs[i]
+		}
+	}
+
+	return nil
+}
+
+// a parallelRow is a row copied by a worker,
+// as the buffers of the scan are reused.
+type parallelRow struct {
+	tableName string
+	key       *tree.Key
+	row       *row.ColumnBuffer
+}
+
+// scanPartition runs the stream on the rows of the range and sends copies
+// of the rows it returns in batches, until the done channel is closed.
+func (op *ParallelScanOperator) scanPartition(in *environment.Environment, table *database.Table, rng *database.Range, results chan<- []parallelRow, done <-chan struct{}) error {
+	var env environment.Environment
+	env.SetOuter(in)
+
+	send := func(batch []parallelRow) error {
+		select {
+		case results <- batch:
+			return nil
+		case <-done:
+			return errors.WithStack(stream.ErrStreamClosed)
+		}
+	}
+
+	batch := make([]parallelRow, 0, parallelBatchSize)
+	err := op.partitionStream(table, rng).Iterate(&env, func(out *environment.Environment) error {
+		r, ok := out.GetDatabaseRow()
+		if !ok {
+			return errors.New("missing row")
+		}
+
+		pr, err := copyRow(r)
+		if err != nil {
+			return err
+		}
+
+		batch = append(batch, pr)
+		if len(batch) < parallelBatchSize {
+			return nil
+		}
+
+		err = send(batch)
+		batch = make([]parallelRow, 0, parallelBatchSize)
+		return err
+	})
+	if err == nil && len(batch) > 0 {
+		err = send(batch)
+	}
+	if errors.Is(err, stream.ErrStreamClosed) {
+		err = nil
+	}
+
+	return err
+}
+
+// partitionStream returns a copy of the stream reading the rows of the range.
+func (op *ParallelScanOperator) partitionStream(table *database.Table, rng *database.Range) *stream.Stream {
+	src := &partitionScanOperator{table: table, rng: rng}
+	if op.Stream == nil {
+		return stream.New(src)
+	}
+
+	s := op.Stream.Clone()
+	stream.InsertBefore(s.First(), src)
+	return s
+}
+
+func copyRow(r database.Row) (parallelRow, error) {
+	pr := parallelRow{
+		tableName: r.TableName(),
+		row:       row.NewColumnBuffer(),
+	}
+
+	if k := r.Key(); k != nil {
+		if k.Encoded != nil {
+			pr.key = tree.NewEncodedKey(bytes.Clone(k.Encoded))
+		} else {
+			pr.key = k
+		}
+	}
+
+	err := pr.row.DeepCopy(r)
+	return pr, err
+}
+
+func (op *ParallelScanOperator) Columns(env *environment.Environment) ([]string, error) {
+	if op.Stream == nil {
+		return Scan(op.TableName).Columns(env)
+	}
+
+	return op.Stream.Columns(env)
+}
+
+func (op *ParallelScanOperator) String() string {
+	if op.Stream == nil {
+		return fmt.Sprintf("table.ParallelScan(%s, %d)", strconv.Quote(op.TableName), op.Workers)
+	}
+
+	return fmt.Sprintf("table.ParallelScan(%s, %d, %s)", strconv.Quote(op.TableName), op.Workers, op.Stream)
+}
+
+// splitTable splits the table in at most n ranges of its primary key,
+// based on the first and last values of the first column of the key.
+// It returns nil if the first column isn't an integer or is sorted
+// in descending order.
+func splitTable(t *database.Table, n int) ([]*database.Range, error) {
+	if pk := t.Info.PrimaryKey; pk != nil && pk.SortOrder.IsDesc(0) {
+		return nil, nil
+	}
+
+	first, err := firstKeyValue(t, false)
+	if err != nil || first == nil {
+		return nil, err
+	}
+	last, err := firstKeyValue(t, true)
+	if err != nil || last == nil {
+		return nil, err
+	}
+
+	typ := first.Type()
+	if (typ != types.TypeInteger && typ != types.TypeBigint) || last.Type() != typ {
+		return nil, nil
+	}
+
+	lo, hi := types.AsInt64(first), types.AsInt64(last)
+
+	// the span may overflow for bigint keys
+	span := uint64(hi - lo)
+	if hi < lo || span < uint64(n) {
+		return nil, nil
+	}
+
+	value := func(i int64) types.Value {
+		if typ == types.TypeInteger {
+			return types.NewIntegerValue(int32(i))
+		}
+		return types.NewBigintValue(i)
+	}
+
+	step := span / uint64(n)
+	ranges := make([]*database.Range, n)
+	for i := range ranges {
+		min := lo + int64(uint64(i)*step)
+		max := hi
+		if i < n-1 {
+			max = lo + int64(uint64(i+1)*step) - 1
+		}
+
+		ranges[i] = &database.Range{
+			Min: []types.Value{value(min)},
+			Max: []types.Value{value(max)},
+		}
+	}
+
+	return ranges, nil
+}
+
+// firstKeyValue returns the first value of the first or last key of the table,
+// or nil if the table is empty.
+func firstKeyValue(t *database.Table, reverse bool) (types.Value, error) {
+	var v types.Value
+	err := t.Tree.IterateOnRange(nil, reverse, func(k *tree.Key, _ []byte) error {
+		values, err := k.Decode()
+		if err != nil {
+			return err
+		}
+
+		v = values[0]
+		return errors.WithStack(stream.ErrStreamClosed)
+	})
+	if errors.Is(err, stream.ErrStreamClosed) {
+		err = nil
+	}
+
+	return v, err
+}
+
+// partitionScanOperator iterates over the rows of a table in a range.
+type partitionScanOperator struct {
+	stream.BaseOperator
+	table *database.Table
+	rng   *database.Range
+}
+
+func (op *partitionScanOperator) Clone() stream.Operator {
+	return &partitionScanOperator{
+		BaseOperator: op.BaseOperator.Clone(),
+		table:        op.table,
+		rng:          op.rng,
+	}
+}
+
+func (op *partitionScanOperator) Iterate(in *environment.Environment, fn func(out *environment.Environment) error) error {
+	var newEnv environment.Environment
+	newEnv.SetOuter(in)
+
+	return op.table.IterateOnRange(op.rng, false, func(key *tree.Key, r database.Row) error {
+		if err := in.Err(); err != nil {
+			return err
+		}
+
+		newEnv.SetRow(r)
+
+		return fn(&newEnv)
+	})
+}
+
+func (op *partitionScanOperator) Columns(env *environment.Environment) ([]string, error) {
+	return Scan(op.table.Info.TableName).Columns(env)
+}
+
+func (op *partitionScanOperator) String() string {
+	return fmt.Sprintf("table.Scan(%s)", strconv.Quote(op.table.Info.TableName))
+}
@@ -20,6 +20,12 @@ type ScanOperator struct {
 	// If set, the operator will scan this table.
 	// It not set, it will get the scan from the catalog.
 	Table *database.Table
+	// Hint restricts the indexes the planner can use
+	// to replace this operator, if set.
+	Hint *IndexHint
+	// Parallelism is the number of workers the planner can use
+	// to scan the table, if greater than 1.
+	Parallelism int
 }
 
 // Scan creates an iterator that iterates over each object of the given table that match the given ranges.
@@ -40,6 +46,8 @@ func (op *ScanOperator) Clone() stream.Operator {
 		Ranges:       op.Ranges.Clone(),
 		Reverse:      op.Reverse,
 		Table:        op.Table,
+		Hint:         op.Hint.Clone(),
+		Parallelism:  op.Parallelism,
 	}
 }
 
@@ -71,6 +79,10 @@ func (it *ScanOperator) Iterate(in *environment.Environment, fn func(out *enviro
 
 	for _, rng := range ranges {
 		err = table.IterateOnRange(rng, it.Reverse, func(key *tree.Key, r database.Row) error {
+			if err := in.Err(); err != nil {
+				return err
+			}
+
 			newEnv.SetRow(r)
 
 			return fn(&newEnv)
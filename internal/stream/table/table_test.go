@@ -1,8 +1,12 @@
 package table_test
 
 import (
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/chaisql/chai/internal/database"
 	"github.com/chaisql/chai/internal/environment"
 	"github.com/chaisql/chai/internal/row"
 	"github.com/chaisql/chai/internal/stream"
@@ -190,3 +194,92 @@ func TestTableScan(t *testing.T) {
 		require.Equal(t, `table.ScanReverse("test", [{"min": (1), "max": (2), "exclusive": true}, {"min": (10), "exact": true}, {"min": (100)}])`, op.String())
 	})
 }
+
+// TestParallelScanConcurrent runs parallel scans from several transactions
+// and pinned snapshot readers of the memory engine while rows are written.
+// It is meant to be run with -race.
+func TestParallelScanConcurrent(t *testing.T) {
+	db := testutil.NewTestDB(t)
+
+	insert := func(from, to int, b func(i int) int) {
+		var sb strings.Builder
+		sb.WriteString("INSERT INTO test (a, b) VALUES ")
+		for i := from; i < to; i++ {
+			if i > from {
+				sb.WriteString(", ")
+			}
+			fmt.Fprintf(&sb, "(%d, %d)", i, b(i))
+		}
+
+		conn, err := db.Connect()
+		require.NoError(t, err)
+		defer conn.Close()
+
+		tx, err := conn.BeginTx(&database.TxOptions{})
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		testutil.MustExec(t, db, tx, sb.String())
+		require.NoError(t, tx.Commit())
+	}
+
+	conn := testutil.NewTestConn(t, db)
+	tx, err := conn.BeginTx(&database.TxOptions{})
+	require.NoError(t, err)
+	testutil.MustExec(t, db, tx, "CREATE TABLE test(a INT PRIMARY KEY, b INT)")
+	require.NoError(t, tx.Commit())
+
+	insert(0, 1000, func(i int) int { return i })
+
+	snap, err := db.Snapshot("test")
+	require.NoError(t, err)
+	defer snap.Release()
+
+	var wg sync.WaitGroup
+	counts := make([]int, 8)
+	errs := make([]error, 8)
+	for i := range counts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			errs[i] = func() error {
+				conn, err := db.Connect()
+				if err != nil {
+					return err
+				}
+				defer conn.Close()
+
+				opts := database.TxOptions{ReadOnly: true}
+				if i%2 == 0 {
+					opts.Snapshot = snap
+				}
+				tx, err := conn.BeginTx(&opts)
+				if err != nil {
+					return err
+				}
+				defer tx.Rollback()
+
+				res, err := testutil.Query(db, tx, "SELECT a FROM test PARALLEL 4 WHERE b % 2 = 0")
+				if err != nil {
+					return err
+				}
+				defer res.Close()
+
+				return res.Iterate(func(database.Row) error {
+					counts[i]++
+					return nil
+				})
+			}()
+		}()
+	}
+
+	// the new rows are not matched by the scans
+	insert(1000, 1100, func(i int) int { return 1 })
+
+	wg.Wait()
+	for i := range counts {
+		require.NoError(t, errs[i])
+		require.Equal(t, 500, counts[i])
+	}
+}
@@ -0,0 +1,145 @@
+package table
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/environment"
+	"github.com/chaisql/chai/internal/stream"
+	"github.com/chaisql/chai/internal/tree"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/cockroachdb/errors"
+)
+
+// A UnionOperator reads the rows of a table returned by several streams,
+// i.e. scans of the table or of its indexes, and returns each row only once,
+// in the order of the primary key.
+type UnionOperator struct {
+	stream.BaseOperator
+	TableName string
+	Streams   []*stream.Stream
+}
+
+// Union creates an operator that returns the union of the rows
+// of the table returned by the streams, deduplicated by primary key.
+func Union(tableName string, s ...*stream.Stream) *UnionOperator {
+	return &UnionOperator{TableName: tableName, Streams: s}
+}
+
+func (op *UnionOperator) Clone() stream.Operator {
+	streams := make([]*stream.Stream, len(op.Streams))
+	for i, s := range op.Streams {
+		streams[i] = s.Clone()
+	}
+
+	return &UnionOperator{
+		BaseOperator: op.BaseOperator.Clone(),
+		TableName:    op.TableName,
+		Streams:      streams,
+	}
+}
+
+// Iterate over the streams and store the primary key of each row in a temporary tree,
+// then iterate over the temporary tree and read the rows from the table.
+func (it *UnionOperator) Iterate(in *environment.Environment, fn func(out *environment.Environment) error) (err error) {
+	tx := in.GetTx()
+
+	table, err := tx.Catalog.GetTable(tx, it.TableName)
+	if err != nil {
+		return err
+	}
+
+	var temp *tree.Tree
+	var cleanup func() error
+
+	defer func() {
+		if cleanup != nil {
+			e := cleanup()
+			if err == nil {
+				err = e
+			}
+		}
+	}()
+
+	for _, s := range it.Streams {
+		err := s.Iterate(in, func(out *environment.Environment) error {
+			r, ok := out.GetRow()
+			if !ok {
+				return errors.New("missing row")
+			}
+
+			dr, ok := r.(database.Row)
+			if !ok || dr.Key() == nil {
+				return errors.New("missing row key")
+			}
+
+			if temp == nil {
+				tns := tx.Catalog.GetFreeTransientNamespace()
+				temp, cleanup, err = tree.NewTransient(in.GetDB().Engine.NewTransientSession(), tns, 0)
+				if err != nil {
+					return err
+				}
+			}
+
+			encKey, err := table.Info.EncodeKey(dr.Key())
+			if err != nil {
+				return err
+			}
+
+			// rows returned by several streams are stored only once
+			return temp.Put(tree.NewKey(types.NewBlobValue(encKey)), nil)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if temp == nil {
+		// no row matched
+		return nil
+	}
+
+	var newEnv environment.Environment
+	newEnv.SetOuter(in)
+
+	var ptr database.LazyRow
+	newEnv.SetRow(&ptr)
+
+	err = temp.IterateOnRange(nil, false, func(k *tree.Key, _ []byte) error {
+		if err := in.Err(); err != nil {
+			return err
+		}
+
+		values, err := k.Decode()
+		if err != nil {
+			return err
+		}
+
+		ptr.ResetWith(table, tree.NewEncodedKey(types.AsByteSlice(values[0])))
+
+		return fn(&newEnv)
+	})
+	if errors.Is(err, stream.ErrStreamClosed) {
+		err = nil
+	}
+	return err
+}
+
+func (it *UnionOperator) Columns(env *environment.Environment) ([]string, error) {
+	return Scan(it.TableName).Columns(env)
+}
+
+func (it *UnionOperator) String() string {
+	var s strings.Builder
+
+	s.WriteString("table.Union(")
+	s.WriteString(strconv.Quote(it.TableName))
+	for _, st := range it.Streams {
+		s.WriteString(", ")
+		s.WriteString(st.String())
+	}
+	s.WriteRune(')')
+
+	return s.String()
+}
@@ -46,6 +46,7 @@ func (op *ValidateOperator) Iterate(in *environment.Environment, fn func(out *en
 
 	var br database.BasicRow
 	var eo database.EncodedRow
+	eo.SetBlobReader(tx.BlobReader())
 	return op.Prev.Iterate(in, func(out *environment.Environment) error {
 		buf = buf[:0]
 		newEnv.SetOuter(out)
@@ -97,12 +97,24 @@ func (it *UnionOperator) Iterate(in *environment.Environment, fn func(out *envir
 				}
 			}
 
-			key := tree.NewKey(row.Flatten(r)...)
+			values := row.Flatten(r)
+			key := tree.NewKey(values...)
 			buf, err = types.EncodeValuesAsKey(buf, types.NewBlobValue(encKey), types.NewTextValue(tableName))
 			if err != nil {
 				return err
 			}
 
+			// the key encoding of decimals doesn't preserve their scale:
+			// they are stored in the value as well
+			for _, v := range values {
+				if d, ok := v.(types.DecimalValue); ok {
+					buf, err = d.Encode(buf)
+					if err != nil {
+						return err
+					}
+				}
+			}
+
 			err = temp.Put(key, buf)
 			if err == nil || errors.Is(err, database.ErrIndexDuplicateValue) {
 				return nil
@@ -125,6 +137,10 @@ func (it *UnionOperator) Iterate(in *environment.Environment, fn func(out *envir
 	var basicRow database.BasicRow
 	// iterate over the temporary index
 	return temp.IterateOnRange(nil, false, func(key *tree.Key, value []byte) error {
+		if err := in.Err(); err != nil {
+			return err
+		}
+
 		kv, err := key.Decode()
 		if err != nil {
 			return err
@@ -133,14 +149,21 @@ func (it *UnionOperator) Iterate(in *environment.Environment, fn func(out *envir
 		var tableName string
 		var pk *tree.Key
 
-		obj := row.Unflatten(kv)
-
 		if len(value) > 1 {
 			ser := types.DecodeValues(value)
 			pk = tree.NewEncodedKey(types.AsByteSlice(ser[0]))
 			tableName = types.AsString(ser[1])
+
+			decimals := ser[2:]
+			for i, v := range kv {
+				if _, ok := v.(types.DecimalValue); ok && len(decimals) > 0 {
+					kv[i], decimals = decimals[0], decimals[1:]
+				}
+			}
 		}
 
+		obj := row.Unflatten(kv)
+
 		basicRow.ResetWith(tableName, pk, obj)
 
 		newEnv.SetRow(&basicRow)
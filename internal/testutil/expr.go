@@ -38,9 +38,14 @@ func BigintValue(v int64) expr.LiteralValue {
 	return expr.LiteralValue{Value: types.NewBigintValue(v)}
 }
 
-// DoubleValue creates a literal value of type Double.
+// DoubleValue creates a literal value of type Double,
+// with its decimal value, as returned by the parser.
 func DoubleValue(v float64) expr.LiteralValue {
-	return expr.LiteralValue{Value: types.NewDoubleValue(v)}
+	l := expr.LiteralValue{Value: types.NewDoubleValue(v)}
+	if d, err := types.NewDecimalValueFromFloat(v); err == nil {
+		l.Exact = &d
+	}
+	return l
 }
 
 // TextValue creates a literal value of type Text.
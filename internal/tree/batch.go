@@ -0,0 +1,55 @@
+package tree
+
+import "github.com/chaisql/chai/internal/engine"
+
+// A Batch accumulates writes to a tree, applied at once by Apply.
+// It reduces the overhead of writing several keys at once,
+// such as the entries of a row in full-text and spatial indexes.
+// Writes of the batch are not visible to the reads of the tree
+// until the batch is applied.
+type Batch struct {
+	t *Tree
+	b engine.Batch
+}
+
+// NewBatch returns an empty batch writing to the tree.
+func (t *Tree) NewBatch() *Batch {
+	return &Batch{t: t}
+}
+
+// Put adds or replaces a key-value pair of the tree when the batch is applied.
+func (b *Batch) Put(key *Key, value []byte) error {
+	if len(value) == 0 {
+		value = defaultValue
+	}
+	k, err := key.Encode(b.t.Namespace, b.t.Order)
+	if err != nil {
+		return err
+	}
+
+	return b.b.Put(k, value)
+}
+
+// Delete removes a key from the tree when the batch is applied.
+// Deleting a key that doesn't exist is not an error.
+func (b *Batch) Delete(key *Key) error {
+	k, err := key.Encode(b.t.Namespace, b.t.Order)
+	if err != nil {
+		return err
+	}
+
+	b.b.Delete(k)
+	return nil
+}
+
+// Apply writes the keys of the batch to the session of the tree
+// and resets the batch.
+func (b *Batch) Apply() error {
+	err := engine.ApplyBatch(b.t.Session, &b.b)
+	if err != nil {
+		return err
+	}
+
+	b.b.Reset()
+	return nil
+}
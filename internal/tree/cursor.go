@@ -0,0 +1,111 @@
+package tree
+
+import (
+	"github.com/chaisql/chai/internal/engine"
+)
+
+// A Cursor iterates over the keys of a tree, in order.
+// Unlike IterateOnRange, it can be repositioned with SeekGE and SeekLT,
+// which lets lookups and merge joins probe the tree repeatedly
+// with a single iterator.
+type Cursor struct {
+	t   *Tree
+	it  engine.Iterator
+	key Key
+}
+
+// NewCursor returns a cursor over all the keys of the tree.
+// The cursor is not positioned: call First, Last or one of the seek methods
+// before reading the current key.
+func (t *Tree) NewCursor() (*Cursor, error) {
+	start, err := t.buildFirstKey()
+	if err != nil {
+		return nil, err
+	}
+
+	it, err := t.Session.Iterator(&engine.IterOptions{
+		LowerBound: start,
+		UpperBound: t.buildLastKey(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cursor{t: t, it: it}, nil
+}
+
+// First moves the cursor to the first key of the tree.
+func (c *Cursor) First() bool {
+	return c.it.First()
+}
+
+// Last moves the cursor to the last key of the tree.
+func (c *Cursor) Last() bool {
+	return c.it.Last()
+}
+
+// Next moves the cursor to the next key.
+func (c *Cursor) Next() bool {
+	return c.it.Next()
+}
+
+// Prev moves the cursor to the previous key.
+func (c *Cursor) Prev() bool {
+	return c.it.Prev()
+}
+
+// Valid returns whether the cursor is positioned on a key.
+func (c *Cursor) Valid() bool {
+	return c.it.Valid()
+}
+
+// SeekGE moves the cursor to the first key greater than or equal to the given key.
+// The key can be a prefix of the keys of the tree.
+func (c *Cursor) SeekGE(key *Key) (bool, error) {
+	k, err := key.Encode(c.t.Namespace, c.t.Order)
+	if err != nil {
+		return false, err
+	}
+
+	return c.it.SeekGE(k), nil
+}
+
+// SeekLT moves the cursor to the last key less than the given key.
+func (c *Cursor) SeekLT(key *Key) (bool, error) {
+	k, err := key.Encode(c.t.Namespace, c.t.Order)
+	if err != nil {
+		return false, err
+	}
+
+	return c.it.SeekLT(k), nil
+}
+
+// Key returns the current key. It is only valid until the cursor moves.
+func (c *Cursor) Key() *Key {
+	c.key.Encoded = c.it.Key()
+	c.key.values = nil
+	return &c.key
+}
+
+// Value returns the value of the current key.
+func (c *Cursor) Value() ([]byte, error) {
+	v, err := c.it.Value()
+	if err != nil {
+		return nil, err
+	}
+	if len(v) == 0 || v[0] == 0 {
+		return nil, nil
+	}
+
+	return v, nil
+}
+
+// Err returns the error encountered while moving the cursor, if any.
+func (c *Cursor) Err() error {
+	return c.it.Error()
+}
+
+// Close releases the resources of the cursor.
+func (c *Cursor) Close() error {
+	return c.it.Close()
+}
@@ -1,6 +1,7 @@
 package tree
 
 import (
+	"bytes"
 	"strings"
 
 	"github.com/chaisql/chai/internal/encoding"
@@ -25,6 +26,18 @@ func NewEncodedKey(enc []byte) *Key {
 	}
 }
 
+// Clone returns a copy of the key which doesn't share its encoded form.
+func (k *Key) Clone() *Key {
+	if k == nil {
+		return nil
+	}
+
+	return &Key{
+		values:  k.values,
+		Encoded: bytes.Clone(k.Encoded),
+	}
+}
+
 func (k *Key) Encode(ns Namespace, order SortOrder) ([]byte, error) {
 	if k.Encoded != nil {
 		return k.Encoded, nil
@@ -344,3 +344,62 @@ func TestTreeIterateOnRange(t *testing.T) {
 		}
 	}
 }
+
+func TestTreeCursor(t *testing.T) {
+	tr := testutil.NewTestTree(t, 10)
+
+	for i := int32(0); i < 10; i++ {
+		err := tr.Put(tree.NewKey(types.NewIntegerValue(i*2)), []byte{byte(i + 1)})
+		require.NoError(t, err)
+	}
+
+	c, err := tr.NewCursor()
+	require.NoError(t, err)
+	defer c.Close()
+
+	current := func() int32 {
+		t.Helper()
+
+		require.True(t, c.Valid())
+		values, err := c.Key().Decode()
+		require.NoError(t, err)
+		return types.AsInt32(values[0])
+	}
+
+	require.True(t, c.First())
+	require.Equal(t, int32(0), current())
+
+	ok, err := c.SeekGE(tree.NewKey(types.NewIntegerValue(5)))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, int32(6), current())
+	v, err := c.Value()
+	require.NoError(t, err)
+	require.Equal(t, []byte{4}, v)
+
+	require.True(t, c.Next())
+	require.Equal(t, int32(8), current())
+
+	// seeking backwards reuses the same iterator
+	ok, err = c.SeekLT(tree.NewKey(types.NewIntegerValue(5)))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, int32(4), current())
+
+	ok, err = c.SeekGE(tree.NewKey(types.NewIntegerValue(4)))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, int32(4), current())
+
+	ok, err = c.SeekGE(tree.NewKey(types.NewIntegerValue(19)))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	ok, err = c.SeekLT(tree.NewKey(types.NewIntegerValue(0)))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.True(t, c.Last())
+	require.Equal(t, int32(18), current())
+	require.NoError(t, c.Err())
+}
@@ -26,7 +26,7 @@ func (BigintTypeDef) Decode(src []byte) (Value, int) {
 }
 
 func (BigintTypeDef) IsComparableWith(other Type) bool {
-	return other == TypeBigint || other == TypeInteger || other == TypeDouble
+	return other.IsNumber()
 }
 
 func (BigintTypeDef) IsIndexComparableWith(other Type) bool {
@@ -86,11 +86,15 @@ func (v BigintValue) CastAs(target Type) (Value, error) {
 		return v, nil
 	case TypeInteger:
 		if int64(v) > math.MaxInt32 || int64(v) < math.MinInt32 {
-			return nil, errors.Errorf("integer out of range")
+			return nil, outOfRange("integer")
 		}
 		return NewIntegerValue(int32(v)), nil
+	case TypeBoolean:
+		return NewBooleanValue(int64(v) != 0), nil
 	case TypeDouble:
 		return NewDoubleValue(float64(v)), nil
+	case TypeDecimal:
+		return NewDecimalValueFromInt(int64(v)), nil
 	case TypeText:
 		return NewTextValue(v.String()), nil
 	}
@@ -104,7 +108,9 @@ func (v BigintValue) EQ(other Value) (bool, error) {
 	case TypeBigint, TypeInteger:
 		return int64(v) == AsInt64(other), nil
 	case TypeDouble:
-		return float64(int64(v)) == AsFloat64(other), nil
+		return compareFloats(float64(int64(v)), AsFloat64(other)) == 0, nil
+	case TypeDecimal:
+		return NewDecimalValueFromInt(int64(v)).EQ(other)
 	default:
 		return false, nil
 	}
@@ -116,7 +122,9 @@ func (v BigintValue) GT(other Value) (bool, error) {
 	case TypeBigint, TypeInteger:
 		return int64(v) > AsInt64(other), nil
 	case TypeDouble:
-		return float64(int64(v)) > AsFloat64(other), nil
+		return compareFloats(float64(int64(v)), AsFloat64(other)) > 0, nil
+	case TypeDecimal:
+		return NewDecimalValueFromInt(int64(v)).GT(other)
 	default:
 		return false, nil
 	}
@@ -128,7 +136,9 @@ func (v BigintValue) GTE(other Value) (bool, error) {
 	case TypeBigint, TypeInteger:
 		return int64(v) >= AsInt64(other), nil
 	case TypeDouble:
-		return float64(int64(v)) >= AsFloat64(other), nil
+		return compareFloats(float64(int64(v)), AsFloat64(other)) >= 0, nil
+	case TypeDecimal:
+		return NewDecimalValueFromInt(int64(v)).GTE(other)
 	default:
 		return false, nil
 	}
@@ -140,7 +150,9 @@ func (v BigintValue) LT(other Value) (bool, error) {
 	case TypeBigint, TypeInteger:
 		return int64(v) < AsInt64(other), nil
 	case TypeDouble:
-		return float64(int64(v)) <= AsFloat64(other), nil
+		return compareFloats(float64(int64(v)), AsFloat64(other)) <= 0, nil
+	case TypeDecimal:
+		return NewDecimalValueFromInt(int64(v)).LT(other)
 	default:
 		return false, nil
 	}
@@ -152,7 +164,9 @@ func (v BigintValue) LTE(other Value) (bool, error) {
 	case TypeBigint, TypeInteger:
 		return int64(v) <= AsInt64(other), nil
 	case TypeDouble:
-		return float64(int64(v)) <= AsFloat64(other), nil
+		return compareFloats(float64(int64(v)), AsFloat64(other)) <= 0, nil
+	case TypeDecimal:
+		return NewDecimalValueFromInt(int64(v)).LTE(other)
 	default:
 		return false, nil
 	}
@@ -177,12 +191,14 @@ func (v BigintValue) Add(other Numeric) (Value, error) {
 		xa := int64(v)
 		xb := AsInt64(other)
 		if isAddOverflow(xa, xb, math.MinInt64, math.MaxInt64) {
-			return nil, errors.New("bigint out of range")
+			return nil, outOfRange("bigint")
 		}
 		xr := xa + xb
 		return NewBigintValue(xr), nil
 	case TypeDouble:
 		return NewDoubleValue(float64(int64(v)) + AsFloat64(other)), nil
+	case TypeDecimal:
+		return NewDecimalValueFromInt(int64(v)).Add(other)
 	}
 
 	return NewNullValue(), nil
@@ -194,12 +210,14 @@ func (v BigintValue) Sub(other Numeric) (Value, error) {
 		xa := int64(v)
 		xb := AsInt64(other)
 		if isSubOverflow(xa, xb, math.MinInt64, math.MaxInt64) {
-			return nil, errors.New("bigint out of range")
+			return nil, outOfRange("bigint")
 		}
 		xr := xa - xb
 		return NewBigintValue(xr), nil
 	case TypeDouble:
 		return NewDoubleValue(float64(int64(v)) - AsFloat64(other)), nil
+	case TypeDecimal:
+		return NewDecimalValueFromInt(int64(v)).Sub(other)
 	}
 
 	return NewNullValue(), nil
@@ -214,12 +232,14 @@ func (v BigintValue) Mul(other Numeric) (Value, error) {
 			return NewBigintValue(0), nil
 		}
 		if isMulOverflow(xa, xb, math.MinInt64, math.MaxInt64) {
-			return nil, errors.New("bigint out of range")
+			return nil, outOfRange("bigint")
 		}
 		xr := xa * xb
 		return NewBigintValue(xr), nil
 	case TypeDouble:
 		return NewDoubleValue(float64(int64(v)) * AsFloat64(other)), nil
+	case TypeDecimal:
+		return NewDecimalValueFromInt(int64(v)).Mul(other)
 	}
 
 	return NewNullValue(), nil
@@ -233,6 +253,9 @@ func (v BigintValue) Div(other Numeric) (Value, error) {
 		if xb == 0 {
 			return NewNullValue(), nil
 		}
+		if isDivOverflow(xa, xb, math.MinInt64) {
+			return nil, outOfRange("bigint")
+		}
 
 		return NewBigintValue(xa / xb), nil
 	case TypeDouble:
@@ -243,6 +266,8 @@ func (v BigintValue) Div(other Numeric) (Value, error) {
 		}
 
 		return NewDoubleValue(xa / xb), nil
+	case TypeDecimal:
+		return NewDecimalValueFromInt(int64(v)).Div(other)
 	}
 
 	return NewNullValue(), nil
@@ -267,6 +292,8 @@ func (v BigintValue) Mod(other Numeric) (Value, error) {
 		}
 
 		return NewDoubleValue(mod), nil
+	case TypeDecimal:
+		return NewDecimalValueFromInt(int64(v)).Mod(other)
 	}
 
 	return NewNullValue(), nil
@@ -94,6 +94,11 @@ func (v BlobValue) CastAs(target Type) (Value, error) {
 		return v, nil
 	case TypeText:
 		return NewTextValue(base64.StdEncoding.EncodeToString([]byte(v))), nil
+	case TypeUUID:
+		if len(v) != 16 {
+			return nil, errors.Errorf("cannot cast blob of %d bytes as uuid", len(v))
+		}
+		return NewUUIDValue([16]byte(v)), nil
 	}
 
 	return nil, errors.Errorf("cannot cast %s as %s", v.Type(), target)
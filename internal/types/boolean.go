@@ -87,6 +87,24 @@ func (v BooleanValue) CastAs(target Type) (Value, error) {
 		}
 
 		return NewIntegerValue(0), nil
+	case TypeBigint:
+		if bool(v) {
+			return NewBigintValue(1), nil
+		}
+
+		return NewBigintValue(0), nil
+	case TypeDouble:
+		if bool(v) {
+			return NewDoubleValue(1), nil
+		}
+
+		return NewDoubleValue(0), nil
+	case TypeDecimal:
+		if bool(v) {
+			return NewDecimalValueFromInt(1), nil
+		}
+
+		return NewDecimalValueFromInt(0), nil
 	case TypeText:
 		return NewTextValue(v.String()), nil
 	}
@@ -22,6 +22,10 @@ func TestCastAs(t *testing.T) {
 	tsV := types.NewTimestampValue(now)
 	textV := types.NewTextValue("foo")
 	blobV := types.NewBlobValue([]byte("asdine"))
+	decimalV, err := types.ParseDecimal("10.5")
+	require.NoError(t, err)
+	uuidV, err := types.NewTextValue("123e4567-e89b-12d3-a456-426614174000").CastAs(types.TypeUUID)
+	require.NoError(t, err)
 
 	check := func(t *testing.T, targetType types.Type, tests []test) {
 		t.Helper()
@@ -46,11 +50,17 @@ func TestCastAs(t *testing.T) {
 			{boolV, boolV, false},
 			{integerV, boolV, false},
 			{types.NewIntegerValue(0), types.NewBooleanValue(false), false},
-			{doubleV, nil, true},
+			{doubleV, boolV, false},
+			{types.NewDoubleValue(0), types.NewBooleanValue(false), false},
+			{types.NewBigintValue(10), boolV, false},
+			{types.NewBigintValue(0), types.NewBooleanValue(false), false},
+			{decimalV, boolV, false},
+			{tsV, nil, true},
 			{textV, nil, true},
 			{types.NewTextValue("true"), boolV, false},
 			{types.NewTextValue("false"), types.NewBooleanValue(false), false},
 			{blobV, nil, true},
+			{uuidV, nil, true},
 		})
 	})
 
@@ -65,18 +75,53 @@ func TestCastAs(t *testing.T) {
 			{types.NewTextValue("10.5"), integerV, false},
 			{blobV, nil, true},
 			{types.NewDoubleValue(math.MaxInt64 + 1), nil, true},
+			{types.NewDoubleValue(math.MinInt32 - 1), nil, true},
+			{types.NewDoubleValue(math.NaN()), nil, true},
+			{types.NewTextValue("1e10"), nil, true},
+			{tsV, nil, true},
+			{uuidV, nil, true},
+		})
+	})
+
+	t.Run("bigint", func(t *testing.T) {
+		check(t, types.TypeBigint, []test{
+			{boolV, types.NewBigintValue(1), false},
+			{integerV, types.NewBigintValue(10), false},
+			{doubleV, types.NewBigintValue(10), false},
+			{types.NewTextValue("10.5"), types.NewBigintValue(10), false},
+			{types.NewTextValue("1e100"), nil, true},
+			{types.NewDoubleValue(-math.MaxFloat64), nil, true},
+			{blobV, nil, true},
+			{tsV, nil, true},
+			{uuidV, nil, true},
 		})
 	})
 
 	t.Run("double", func(t *testing.T) {
 		check(t, types.TypeDouble, []test{
-			{boolV, nil, true},
+			{boolV, types.NewDoubleValue(1), false},
+			{types.NewBooleanValue(false), types.NewDoubleValue(0), false},
 			{integerV, types.NewDoubleValue(10), false},
 			{doubleV, doubleV, false},
+			{decimalV, doubleV, false},
 			{textV, nil, true},
 			{types.NewTextValue("10"), types.NewDoubleValue(10), false},
 			{types.NewTextValue("10.5"), doubleV, false},
 			{blobV, nil, true},
+			{tsV, nil, true},
+			{uuidV, nil, true},
+		})
+	})
+
+	t.Run("decimal", func(t *testing.T) {
+		check(t, types.TypeDecimal, []test{
+			{boolV, types.NewDecimalValueFromInt(1), false},
+			{types.NewBooleanValue(false), types.NewDecimalValueFromInt(0), false},
+			{integerV, types.NewDecimalValueFromInt(10), false},
+			{decimalV, decimalV, false},
+			{blobV, nil, true},
+			{tsV, nil, true},
+			{uuidV, nil, true},
 		})
 	})
 
@@ -84,9 +129,12 @@ func TestCastAs(t *testing.T) {
 		check(t, types.TypeTimestamp, []test{
 			{boolV, nil, true},
 			{integerV, nil, true},
+			{types.NewBigintValue(10), nil, true},
 			{doubleV, nil, true},
+			{decimalV, nil, true},
 			{types.NewTextValue(now.Format(time.RFC3339Nano)), tsV, false},
 			{blobV, nil, true},
+			{uuidV, nil, true},
 		})
 	})
 
@@ -105,9 +153,61 @@ func TestCastAs(t *testing.T) {
 			{boolV, nil, true},
 			{integerV, nil, true},
 			{doubleV, nil, true},
+			{decimalV, nil, true},
+			{tsV, nil, true},
 			{types.NewTextValue("YXNkaW5l"), types.NewBlobValue([]byte{0x61, 0x73, 0x64, 0x69, 0x6e, 0x65}), false},
 			{types.NewTextValue("not base64"), nil, true},
 			{blobV, blobV, false},
 		})
 	})
 }
+
+func TestCastWithFormat(t *testing.T) {
+	ts := types.NewTimestampValue(time.Date(2023, 4, 5, 6, 7, 8, 9000, time.UTC))
+	blob := types.NewBlobValue([]byte("asdine"))
+
+	tests := []struct {
+		v       types.Value
+		target  types.Type
+		format  string
+		want    types.Value
+		wantErr string
+	}{
+		{blob, types.TypeText, "hex", types.NewTextValue("617364696e65"), ""},
+		{blob, types.TypeText, "BASE64", types.NewTextValue("YXNkaW5l"), ""},
+		{blob, types.TypeText, "base32", nil, `unknown blob format "base32", expected base64 or hex`},
+		{types.NewTextValue("617364696e65"), types.TypeBlob, "hex", blob, ""},
+		{types.NewTextValue("YXNkaW5l"), types.TypeBlob, "base64", blob, ""},
+		{types.NewTextValue("zz"), types.TypeBlob, "hex", nil, `cannot cast "zz" as blob`},
+		{ts, types.TypeText, "%Y-%m-%d %H:%M:%S", types.NewTextValue("2023-04-05 06:07:08"), ""},
+		{ts, types.TypeText, "%d/%m/%y %I%p %%", types.NewTextValue("05/04/23 06AM %"), ""},
+		{ts, types.TypeText, "%a %d %b %Y, %H:%M:%S.%f", types.NewTextValue("Wed 05 Apr 2023, 06:07:08.000009"), ""},
+		{ts, types.TypeText, "%Y-%m-%dT%H:%M:%SZ", types.NewTextValue("2023-04-05T06:07:08Z"), ""},
+		{types.NewTextValue("05/04/2023 06:07:08"), types.TypeTimestamp, "%d/%m/%Y %H:%M:%S", types.NewTimestampValue(time.Date(2023, 4, 5, 6, 7, 8, 0, time.UTC)), ""},
+		{types.NewTextValue("2023-04-05 06:07:08.000009 +0200"), types.TypeTimestamp, "%Y-%m-%d %H:%M:%S.%f %z", types.NewTimestampValue(time.Date(2023, 4, 5, 4, 7, 8, 9000, time.UTC)), ""},
+		{types.NewTextValue("2023-04-05"), types.TypeTimestamp, "%d/%m/%Y", nil, `cannot cast "2023-04-05" as timestamp with format "%d/%m/%Y"`},
+		{ts, types.TypeText, "%Q", nil, `unknown directive %Q`},
+		{ts, types.TypeText, "%Y%", nil, `missing directive`},
+		{ts, types.TypeText, "year %Y", nil, `unsupported character 'y'`},
+		{ts, types.TypeText, "%S%f", nil, `%f must follow a dot or a comma`},
+		{types.NewIntegerValue(10), types.TypeText, "hex", nil, "cannot cast integer as text with a format"},
+		{types.NewNullValue(), types.TypeText, "hex", types.NewNullValue(), ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			got, err := types.CastWithFormat(test.v, test.target, test.format)
+			if test.wantErr != "" {
+				require.ErrorContains(t, err, test.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			if test.target == types.TypeTimestamp {
+				require.True(t, types.AsTime(test.want).Equal(types.AsTime(got)), "want %v, got %v", test.want, got)
+				return
+			}
+			require.Equal(t, test.want, got)
+		})
+	}
+}
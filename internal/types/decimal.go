@@ -0,0 +1,475 @@
+package types
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/cockroachdb/errors"
+)
+
+// DecimalDivisionScale is the minimum number of digits after
+// the decimal point of the result of a division of decimals.
+const DecimalDivisionScale = 16
+
+var bigTen = big.NewInt(10)
+
+var _ TypeDefinition = DecimalTypeDef{}
+
+type DecimalTypeDef struct{}
+
+func (DecimalTypeDef) New(v any) Value {
+	return v.(DecimalValue)
+}
+
+func (DecimalTypeDef) Type() Type {
+	return TypeDecimal
+}
+
+func (DecimalTypeDef) Decode(src []byte) (Value, int) {
+	coef, scale, n := encoding.DecodeDecimal(src)
+	return NewDecimalValue(coef, scale), n
+}
+
+func (DecimalTypeDef) IsComparableWith(other Type) bool {
+	return other.IsNumber()
+}
+
+func (DecimalTypeDef) IsIndexComparableWith(other Type) bool {
+	return other.IsNumber()
+}
+
+var _ Numeric = DecimalValue{}
+
+// DecimalValue is an exact decimal number, equal to coef * 10^-scale.
+// Its scale is the number of digits after the decimal point.
+type DecimalValue struct {
+	coef  *big.Int
+	scale int32
+}
+
+// NewDecimalValue returns a SQL DECIMAL value equal to coef * 10^-scale.
+// A negative scale is normalized to zero.
+func NewDecimalValue(coef *big.Int, scale int32) DecimalValue {
+	if scale < 0 {
+		coef = new(big.Int).Mul(coef, pow10(-scale))
+		scale = 0
+	}
+
+	return DecimalValue{coef: coef, scale: scale}
+}
+
+// NewDecimalValueFromInt returns a SQL DECIMAL value equal to x.
+func NewDecimalValueFromInt(x int64) DecimalValue {
+	return DecimalValue{coef: big.NewInt(x)}
+}
+
+// NewDecimalValueFromFloat returns a SQL DECIMAL value equal to
+// the shortest decimal representation of x.
+func NewDecimalValueFromFloat(x float64) (DecimalValue, error) {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return DecimalValue{}, errors.Errorf("cannot convert %v to decimal", x)
+	}
+
+	return ParseDecimal(strconv.FormatFloat(x, 'f', -1, 64))
+}
+
+// ParseDecimal parses a decimal number written in plain or scientific notation,
+// e.g. "-12.50" or "1.25e3".
+func ParseDecimal(s string) (DecimalValue, error) {
+	orig := s
+
+	var exp int64
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		var err error
+		exp, err = strconv.ParseInt(s[i+1:], 10, 32)
+		if err != nil {
+			return DecimalValue{}, errors.Errorf("invalid decimal %q", orig)
+		}
+		s = s[:i]
+	}
+
+	var scale int64
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		scale = int64(len(s) - i - 1)
+		s = s[:i] + s[i+1:]
+	}
+
+	digits := strings.TrimLeft(s, "+-")
+	if digits == "" || len(s)-len(digits) > 1 || strings.TrimLeft(digits, "0123456789") != "" {
+		return DecimalValue{}, errors.Errorf("invalid decimal %q", orig)
+	}
+
+	scale -= exp
+	if scale > math.MaxInt32 || scale < math.MinInt32 {
+		return DecimalValue{}, errors.Errorf("decimal %q out of range", orig)
+	}
+
+	coef, _ := new(big.Int).SetString(s, 10)
+	return NewDecimalValue(coef, int32(scale)), nil
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+func (v DecimalValue) c() *big.Int {
+	if v.coef == nil {
+		return new(big.Int)
+	}
+
+	return v.coef
+}
+
+// Coef returns the coefficient of v, such that v = coef * 10^-scale.
+func (v DecimalValue) Coef() *big.Int {
+	return new(big.Int).Set(v.c())
+}
+
+// Scale returns the number of digits after the decimal point.
+func (v DecimalValue) Scale() int32 {
+	return v.scale
+}
+
+// Precision returns the number of significant digits of v.
+func (v DecimalValue) Precision() int {
+	c := v.c()
+	if c.Sign() == 0 {
+		return 1
+	}
+
+	return len(new(big.Int).Abs(c).Text(10))
+}
+
+// Rescale returns v with the given scale, rounding half away from zero.
+func (v DecimalValue) Rescale(scale int32) DecimalValue {
+	if scale < 0 {
+		scale = 0
+	}
+
+	switch {
+	case scale == v.scale:
+		return v
+	case scale > v.scale:
+		return DecimalValue{coef: new(big.Int).Mul(v.c(), pow10(scale-v.scale)), scale: scale}
+	}
+
+	return DecimalValue{coef: divRound(v.c(), pow10(v.scale-scale)), scale: scale}
+}
+
+// Round rounds v half away from zero to n decimal places.
+// A negative n rounds to the left of the decimal point.
+func (v DecimalValue) Round(n int32) DecimalValue {
+	if n >= 0 {
+		return v.Rescale(n)
+	}
+
+	p := pow10(-n)
+	c := divRound(v.Rescale(0).c(), p)
+	return DecimalValue{coef: c.Mul(c, p)}
+}
+
+// Floor returns the greatest integral decimal lower than or equal to v.
+func (v DecimalValue) Floor() DecimalValue {
+	// Div rounds towards negative infinity for positive divisors
+	return DecimalValue{coef: new(big.Int).Div(v.c(), pow10(v.scale))}
+}
+
+// Ceil returns the least integral decimal greater than or equal to v.
+func (v DecimalValue) Ceil() DecimalValue {
+	c := new(big.Int).Neg(v.c())
+	c.Div(c, pow10(v.scale))
+	return DecimalValue{coef: c.Neg(c)}
+}
+
+// Abs returns the absolute value of v.
+func (v DecimalValue) Abs() DecimalValue {
+	return DecimalValue{coef: new(big.Int).Abs(v.c()), scale: v.scale}
+}
+
+// Sign returns -1, 0 or 1 depending on the sign of v.
+func (v DecimalValue) Sign() int {
+	return v.c().Sign()
+}
+
+// divRound returns a / b, rounded half away from zero.
+func divRound(a, b *big.Int) *big.Int {
+	q, r := new(big.Int).QuoRem(a, b, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+
+	// round if |2r| >= |b|
+	r.Abs(r).Lsh(r, 1)
+	if r.CmpAbs(b) >= 0 {
+		if a.Sign()*b.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+
+	return q
+}
+
+// Constrain rounds v to the given scale and ensures it has
+// at most precision digits, as required by a DECIMAL(precision, scale) column.
+func (v DecimalValue) Constrain(precision, scale int) (DecimalValue, error) {
+	v = v.Rescale(int32(scale))
+
+	if v.c().Sign() != 0 && v.Precision() > precision {
+		return DecimalValue{}, errors.Errorf("decimal %s out of range for DECIMAL(%d, %d)", v, precision, scale)
+	}
+
+	return v, nil
+}
+
+func (v DecimalValue) V() any {
+	return v.String()
+}
+
+func (v DecimalValue) Type() Type {
+	return TypeDecimal
+}
+
+func (v DecimalValue) TypeDef() TypeDefinition {
+	return DecimalTypeDef{}
+}
+
+func (v DecimalValue) IsZero() (bool, error) {
+	return v.c().Sign() == 0, nil
+}
+
+// String returns v in plain notation, with exactly scale digits after the decimal point.
+func (v DecimalValue) String() string {
+	c := v.c()
+	s := new(big.Int).Abs(c).Text(10)
+
+	if v.scale > 0 {
+		if len(s) <= int(v.scale) {
+			s = strings.Repeat("0", int(v.scale)-len(s)+1) + s
+		}
+		s = s[:len(s)-int(v.scale)] + "." + s[len(s)-int(v.scale):]
+	}
+
+	if c.Sign() < 0 {
+		return "-" + s
+	}
+
+	return s
+}
+
+func (v DecimalValue) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// MarshalJSON returns v as a JSON number, without loss of precision.
+func (v DecimalValue) MarshalJSON() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+func (v DecimalValue) Encode(dst []byte) ([]byte, error) {
+	return encoding.EncodeDecimal(dst, v.c(), v.scale), nil
+}
+
+func (v DecimalValue) EncodeAsKey(dst []byte) ([]byte, error) {
+	return encoding.EncodeDecimalKey(dst, v.c(), v.scale), nil
+}
+
+// Float64 returns the nearest float64 value of v.
+func (v DecimalValue) Float64() float64 {
+	f, _ := strconv.ParseFloat(v.String(), 64)
+	return f
+}
+
+func (v DecimalValue) CastAs(target Type) (Value, error) {
+	switch target {
+	case TypeDecimal:
+		return v, nil
+	case TypeBoolean:
+		return NewBooleanValue(v.c().Sign() != 0), nil
+	case TypeInteger:
+		i := v.Rescale(0).c()
+		if !i.IsInt64() || i.Int64() < math.MinInt32 || i.Int64() > math.MaxInt32 {
+			return nil, outOfRange("integer")
+		}
+		return NewIntegerValue(int32(i.Int64())), nil
+	case TypeBigint:
+		i := v.Rescale(0).c()
+		if !i.IsInt64() {
+			return nil, outOfRange("bigint")
+		}
+		return NewBigintValue(i.Int64()), nil
+	case TypeDouble:
+		return NewDoubleValue(v.Float64()), nil
+	case TypeText:
+		return NewTextValue(v.String()), nil
+	}
+
+	return nil, errors.Errorf("cannot cast %s as %s", v.Type(), target)
+}
+
+// compare returns -1, 0 or 1 depending on whether v is lower, equal or greater than other.
+// If other is a double, v is converted to a double.
+// ok is false if other is not a number.
+func (v DecimalValue) compare(other Value) (cmp int, ok bool) {
+	switch other.Type() {
+	case TypeInteger, TypeBigint:
+		return v.cmp(NewDecimalValueFromInt(AsInt64(other))), true
+	case TypeDecimal:
+		return v.cmp(other.(DecimalValue)), true
+	case TypeDouble:
+		return compareFloats(v.Float64(), AsFloat64(other)), true
+	}
+
+	return 0, false
+}
+
+func (v DecimalValue) cmp(other DecimalValue) int {
+	s := max(v.scale, other.scale)
+	return v.Rescale(s).c().Cmp(other.Rescale(s).c())
+}
+
+func (v DecimalValue) EQ(other Value) (bool, error) {
+	cmp, ok := v.compare(other)
+	return ok && cmp == 0, nil
+}
+
+func (v DecimalValue) GT(other Value) (bool, error) {
+	cmp, ok := v.compare(other)
+	return ok && cmp > 0, nil
+}
+
+func (v DecimalValue) GTE(other Value) (bool, error) {
+	cmp, ok := v.compare(other)
+	return ok && cmp >= 0, nil
+}
+
+func (v DecimalValue) LT(other Value) (bool, error) {
+	cmp, ok := v.compare(other)
+	return ok && cmp < 0, nil
+}
+
+func (v DecimalValue) LTE(other Value) (bool, error) {
+	cmp, ok := v.compare(other)
+	return ok && cmp <= 0, nil
+}
+
+func (v DecimalValue) Between(a, b Value) (bool, error) {
+	if !a.Type().IsNumber() || !b.Type().IsNumber() {
+		return false, nil
+	}
+
+	ok, err := a.LTE(v)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	return b.GTE(v)
+}
+
+// asDecimal converts integers and decimals to decimals.
+func asDecimal(v Value) (DecimalValue, bool) {
+	switch v.Type() {
+	case TypeInteger, TypeBigint:
+		return NewDecimalValueFromInt(AsInt64(v)), true
+	case TypeDecimal:
+		return v.(DecimalValue), true
+	}
+
+	return DecimalValue{}, false
+}
+
+// Add returns v + other. The scale of the result is the largest
+// scale of the operands. Operations with doubles return doubles.
+func (v DecimalValue) Add(other Numeric) (Value, error) {
+	if other.Type() == TypeDouble {
+		return NewDoubleValue(v.Float64() + AsFloat64(other)), nil
+	}
+
+	d, ok := asDecimal(other)
+	if !ok {
+		return NewNullValue(), nil
+	}
+
+	s := max(v.scale, d.scale)
+	return NewDecimalValue(new(big.Int).Add(v.Rescale(s).c(), d.Rescale(s).c()), s), nil
+}
+
+// Sub returns v - other, with the largest scale of the operands.
+func (v DecimalValue) Sub(other Numeric) (Value, error) {
+	if other.Type() == TypeDouble {
+		return NewDoubleValue(v.Float64() - AsFloat64(other)), nil
+	}
+
+	d, ok := asDecimal(other)
+	if !ok {
+		return NewNullValue(), nil
+	}
+
+	s := max(v.scale, d.scale)
+	return NewDecimalValue(new(big.Int).Sub(v.Rescale(s).c(), d.Rescale(s).c()), s), nil
+}
+
+// Mul returns v * other, whose scale is the sum of the scales of the operands.
+func (v DecimalValue) Mul(other Numeric) (Value, error) {
+	if other.Type() == TypeDouble {
+		return NewDoubleValue(v.Float64() * AsFloat64(other)), nil
+	}
+
+	d, ok := asDecimal(other)
+	if !ok {
+		return NewNullValue(), nil
+	}
+
+	return NewDecimalValue(new(big.Int).Mul(v.c(), d.c()), v.scale+d.scale), nil
+}
+
+// Div returns v / other, rounded half away from zero to the largest
+// scale of the operands, and at least DecimalDivisionScale.
+func (v DecimalValue) Div(other Numeric) (Value, error) {
+	if other.Type() == TypeDouble {
+		xb := AsFloat64(other)
+		if xb == 0 {
+			return NewNullValue(), nil
+		}
+
+		return NewDoubleValue(v.Float64() / xb), nil
+	}
+
+	d, ok := asDecimal(other)
+	if !ok {
+		return NewNullValue(), nil
+	}
+	if d.c().Sign() == 0 {
+		return nil, errors.New("division by zero")
+	}
+
+	// v / d = (cv / cd) * 10^(sd - sv)
+	s := max(v.scale, d.scale, DecimalDivisionScale)
+	a := new(big.Int).Mul(v.c(), pow10(s-v.scale+d.scale))
+	return NewDecimalValue(divRound(a, d.c()), s), nil
+}
+
+// Mod returns the remainder of v / other, which has the sign of v
+// and the largest scale of the operands.
+func (v DecimalValue) Mod(other Numeric) (Value, error) {
+	if other.Type() == TypeDouble {
+		xr := math.Mod(v.Float64(), AsFloat64(other))
+		if math.IsNaN(xr) {
+			return NewNullValue(), nil
+		}
+
+		return NewDoubleValue(xr), nil
+	}
+
+	d, ok := asDecimal(other)
+	if !ok || d.c().Sign() == 0 {
+		return NewNullValue(), nil
+	}
+
+	s := max(v.scale, d.scale)
+	return NewDecimalValue(new(big.Int).Rem(v.Rescale(s).c(), d.Rescale(s).c()), s), nil
+}
@@ -0,0 +1,164 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func mustParseDecimal(t *testing.T, s string) types.DecimalValue {
+	t.Helper()
+
+	d, err := types.ParseDecimal(s)
+	require.NoError(t, err)
+	return d
+}
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		fails    bool
+	}{
+		{"0", "0", false},
+		{"12.50", "12.50", false},
+		{"-0.05", "-0.05", false},
+		{"+3", "3", false},
+		{".5", "0.5", false},
+		{"1.25e3", "1250", false},
+		{"1.25e-3", "0.00125", false},
+		{"123456789012345678901234567890.123456789", "123456789012345678901234567890.123456789", false},
+		{"", "", true},
+		{"-", "", true},
+		{"1.2.3", "", true},
+		{"--1", "", true},
+		{"1e", "", true},
+		{"abc", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			d, err := types.ParseDecimal(test.input)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, d.String())
+		})
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	tests := []struct {
+		name     string
+		op       func(a, b types.Numeric) (types.Value, error)
+		a, b     types.Numeric
+		expected string
+	}{
+		{"add", types.Numeric.Add, mustParseDecimal(t, "0.1"), mustParseDecimal(t, "0.2"), "0.3"},
+		{"add scale", types.Numeric.Add, mustParseDecimal(t, "1.5"), mustParseDecimal(t, "2.25"), "3.75"},
+		{"add integer", types.Numeric.Add, mustParseDecimal(t, "1.50"), types.NewIntegerValue(2), "3.50"},
+		{"integer add", types.Numeric.Add, types.NewBigintValue(2), mustParseDecimal(t, "1.50"), "3.50"},
+		{"sub", types.Numeric.Sub, mustParseDecimal(t, "1.00"), mustParseDecimal(t, "0.01"), "0.99"},
+		{"integer sub", types.Numeric.Sub, types.NewIntegerValue(1), mustParseDecimal(t, "0.01"), "0.99"},
+		{"mul", types.Numeric.Mul, mustParseDecimal(t, "1.10"), mustParseDecimal(t, "1.1"), "1.210"},
+		{"div", types.Numeric.Div, mustParseDecimal(t, "1"), mustParseDecimal(t, "3"), "0.3333333333333333"},
+		{"div round", types.Numeric.Div, mustParseDecimal(t, "2"), mustParseDecimal(t, "3"), "0.6666666666666667"},
+		{"div negative", types.Numeric.Div, mustParseDecimal(t, "-2"), mustParseDecimal(t, "3"), "-0.6666666666666667"},
+		{"div scale", types.Numeric.Div, mustParseDecimal(t, "10.00000000000000000"), types.NewIntegerValue(4), "2.50000000000000000"},
+		{"mod", types.Numeric.Mod, mustParseDecimal(t, "-5.5"), mustParseDecimal(t, "2"), "-1.5"},
+		{"double", types.Numeric.Add, mustParseDecimal(t, "1.5"), types.NewDoubleValue(1), "2.5"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := test.op(test.a, test.b)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, res.String())
+		})
+	}
+
+	t.Run("division by zero", func(t *testing.T) {
+		_, err := mustParseDecimal(t, "1").Div(mustParseDecimal(t, "0.00"))
+		require.Error(t, err)
+	})
+}
+
+func TestDecimalConstrain(t *testing.T) {
+	d, err := mustParseDecimal(t, "12.345").Constrain(5, 2)
+	require.NoError(t, err)
+	require.Equal(t, "12.35", d.String())
+
+	d, err = mustParseDecimal(t, "-12.345").Constrain(5, 2)
+	require.NoError(t, err)
+	require.Equal(t, "-12.35", d.String())
+
+	d, err = mustParseDecimal(t, "7").Constrain(5, 2)
+	require.NoError(t, err)
+	require.Equal(t, "7.00", d.String())
+
+	_, err = mustParseDecimal(t, "1234.5").Constrain(5, 2)
+	require.Error(t, err)
+}
+
+func TestDecimalCompare(t *testing.T) {
+	ok, err := mustParseDecimal(t, "1.50").EQ(mustParseDecimal(t, "1.5"))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = mustParseDecimal(t, "2.00").EQ(types.NewIntegerValue(2))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = types.NewIntegerValue(2).LT(mustParseDecimal(t, "2.01"))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = mustParseDecimal(t, "-0.1").GT(types.NewDoubleValue(-0.2))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestDecimalEncoding(t *testing.T) {
+	// sorted values
+	values := []string{
+		"-123456.789", "-100", "-12.5", "-12.25", "-12", "-0.125", "-0.12", "-0.0001",
+		"0",
+		"0.0001", "0.12", "0.125", "1", "1.05", "1.5", "9.99", "10", "12.25", "100", "1e30",
+	}
+
+	var prev []byte
+	for i, s := range values {
+		d := mustParseDecimal(t, s)
+
+		key, err := d.EncodeAsKey(nil)
+		require.NoError(t, err)
+		if i > 0 {
+			require.Negative(t, encoding.Compare(prev, key), "%s < %s", values[i-1], s)
+		}
+		prev = key
+
+		// keys don't depend on the scale
+		rescaled, err := d.Rescale(d.Scale() + 3).EncodeAsKey(nil)
+		require.NoError(t, err)
+		require.Equal(t, key, rescaled)
+
+		// keys are decoded without trailing zeros
+		v, n := types.DecimalTypeDef{}.Decode(key)
+		require.Equal(t, len(key), n)
+		ok, err := v.EQ(d)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		// values keep their scale
+		d = d.Rescale(d.Scale() + 2)
+		enc, err := d.Encode(nil)
+		require.NoError(t, err)
+		v, n = types.DecimalTypeDef{}.Decode(enc)
+		require.Equal(t, len(enc), n)
+		require.Equal(t, d.String(), v.String())
+	}
+}
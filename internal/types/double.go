@@ -26,7 +26,7 @@ func (DoubleTypeDef) Decode(src []byte) (Value, int) {
 }
 
 func (DoubleTypeDef) IsComparableWith(other Type) bool {
-	return other == TypeDouble || other == TypeInteger || other == TypeBigint
+	return other.IsNumber()
 }
 
 func (DoubleTypeDef) IsIndexComparableWith(other Type) bool {
@@ -58,8 +58,59 @@ func (v DoubleValue) IsZero() (bool, error) {
 	return v == 0, nil
 }
 
+// compareFloats returns -1, 0 or 1 if a is lower than, equal to or greater than b.
+// Unlike the Go operators, it defines a total order where NaN is equal
+// to itself and greater than any other value, including +Inf.
+// This is also the order of the encoded keys.
+func compareFloats(a, b float64) int {
+	aNaN, bNaN := math.IsNaN(a), math.IsNaN(b)
+	switch {
+	case aNaN && bNaN:
+		return 0
+	case aNaN:
+		return 1
+	case bNaN:
+		return -1
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+// nonFiniteText returns the text representation of NaN and infinite values:
+// NaN, Infinity and -Infinity. They are accepted when converting texts to doubles.
+func nonFiniteText(f float64) (string, bool) {
+	switch {
+	case math.IsNaN(f):
+		return "NaN", true
+	case math.IsInf(f, 1):
+		return "Infinity", true
+	case math.IsInf(f, -1):
+		return "-Infinity", true
+	}
+
+	return "", false
+}
+
+// IsFinite returns false if v is a NaN or infinite double.
+func IsFinite(v Value) bool {
+	if v.Type() != TypeDouble {
+		return true
+	}
+
+	_, ok := nonFiniteText(AsFloat64(v))
+	return !ok
+}
+
 func (v DoubleValue) String() string {
 	f := AsFloat64(v)
+	if s, ok := nonFiniteText(f); ok {
+		return s
+	}
+
 	abs := math.Abs(f)
 	fmt := byte('f')
 	if abs != 0 {
@@ -82,8 +133,15 @@ func (v DoubleValue) MarshalText() ([]byte, error) {
 	return []byte(v.String()), nil
 }
 
+// MarshalJSON encodes the double as a JSON number. NaN and infinite values,
+// which can't be represented by JSON numbers, are encoded as the strings
+// "NaN", "Infinity" and "-Infinity".
 func (v DoubleValue) MarshalJSON() ([]byte, error) {
 	f := AsFloat64(v)
+	if s, ok := nonFiniteText(f); ok {
+		return strconv.AppendQuote(nil, s), nil
+	}
+
 	abs := math.Abs(f)
 	fmt := byte('f')
 	if abs != 0 {
@@ -110,19 +168,26 @@ func (v DoubleValue) CastAs(target Type) (Value, error) {
 	switch target {
 	case TypeDouble:
 		return v, nil
+	case TypeBoolean:
+		return NewBooleanValue(float64(v) != 0), nil
 	case TypeInteger:
 		f := float64(v)
-		if f > 0 && (int32(f) < 0 || f >= math.MaxInt32) {
-			return nil, errors.New("integer out of range")
+		if math.IsNaN(f) || f >= math.MaxInt32+1 || f <= math.MinInt32-1 {
+			return nil, outOfRange("integer")
 		}
 		return NewIntegerValue(int32(v)), nil
 	case TypeBigint:
 		f := float64(v)
-		if f > 0 && (int64(f) < 0 || f >= math.MaxInt64) {
-			return nil, errors.New("integer out of range")
+		if math.IsNaN(f) || f >= math.MaxInt64 || f < math.MinInt64 {
+			return nil, outOfRange("integer")
 		}
 		return NewBigintValue(int64(v)), nil
+	case TypeDecimal:
+		return NewDecimalValueFromFloat(float64(v))
 	case TypeText:
+		if s, ok := nonFiniteText(float64(v)); ok {
+			return NewTextValue(s), nil
+		}
 		enc, err := v.MarshalJSON()
 		if err != nil {
 			return nil, err
@@ -137,9 +202,11 @@ func (v DoubleValue) EQ(other Value) (bool, error) {
 	t := other.Type()
 	switch t {
 	case TypeDouble:
-		return float64(v) == AsFloat64(other), nil
+		return compareFloats(float64(v), AsFloat64(other)) == 0, nil
 	case TypeInteger, TypeBigint:
-		return float64(v) == float64(AsInt64(other)), nil
+		return compareFloats(float64(v), float64(AsInt64(other))) == 0, nil
+	case TypeDecimal:
+		return compareFloats(float64(v), other.(DecimalValue).Float64()) == 0, nil
 	default:
 		return false, nil
 	}
@@ -149,9 +216,11 @@ func (v DoubleValue) GT(other Value) (bool, error) {
 	t := other.Type()
 	switch t {
 	case TypeDouble:
-		return float64(v) > AsFloat64(other), nil
+		return compareFloats(float64(v), AsFloat64(other)) > 0, nil
 	case TypeInteger, TypeBigint:
-		return float64(v) > float64(AsInt64(other)), nil
+		return compareFloats(float64(v), float64(AsInt64(other))) > 0, nil
+	case TypeDecimal:
+		return compareFloats(float64(v), other.(DecimalValue).Float64()) > 0, nil
 	default:
 		return false, nil
 	}
@@ -161,9 +230,11 @@ func (v DoubleValue) GTE(other Value) (bool, error) {
 	t := other.Type()
 	switch t {
 	case TypeDouble:
-		return float64(v) >= AsFloat64(other), nil
+		return compareFloats(float64(v), AsFloat64(other)) >= 0, nil
 	case TypeInteger, TypeBigint:
-		return float64(v) >= float64(AsInt64(other)), nil
+		return compareFloats(float64(v), float64(AsInt64(other))) >= 0, nil
+	case TypeDecimal:
+		return compareFloats(float64(v), other.(DecimalValue).Float64()) >= 0, nil
 	default:
 		return false, nil
 	}
@@ -173,9 +244,11 @@ func (v DoubleValue) LT(other Value) (bool, error) {
 	t := other.Type()
 	switch t {
 	case TypeDouble:
-		return float64(v) < AsFloat64(other), nil
+		return compareFloats(float64(v), AsFloat64(other)) < 0, nil
 	case TypeInteger, TypeBigint:
-		return float64(v) < float64(AsInt64(other)), nil
+		return compareFloats(float64(v), float64(AsInt64(other))) < 0, nil
+	case TypeDecimal:
+		return compareFloats(float64(v), other.(DecimalValue).Float64()) < 0, nil
 	default:
 		return false, nil
 	}
@@ -185,9 +258,11 @@ func (v DoubleValue) LTE(other Value) (bool, error) {
 	t := other.Type()
 	switch t {
 	case TypeDouble:
-		return float64(v) <= AsFloat64(other), nil
+		return compareFloats(float64(v), AsFloat64(other)) <= 0, nil
 	case TypeInteger, TypeBigint:
-		return float64(v) <= float64(AsInt64(other)), nil
+		return compareFloats(float64(v), float64(AsInt64(other))) <= 0, nil
+	case TypeDecimal:
+		return compareFloats(float64(v), other.(DecimalValue).Float64()) <= 0, nil
 	default:
 		return false, nil
 	}
@@ -212,6 +287,8 @@ func (v DoubleValue) Add(other Numeric) (Value, error) {
 		return NewDoubleValue(float64(v) + float64(AsInt64(other))), nil
 	case TypeDouble:
 		return NewDoubleValue(float64(v) + AsFloat64(other)), nil
+	case TypeDecimal:
+		return NewDoubleValue(float64(v) + other.(DecimalValue).Float64()), nil
 	}
 
 	return NewNullValue(), nil
@@ -223,6 +300,8 @@ func (v DoubleValue) Sub(other Numeric) (Value, error) {
 		return NewDoubleValue(float64(v) - float64(AsInt64(other))), nil
 	case TypeDouble:
 		return NewDoubleValue(float64(v) - AsFloat64(other)), nil
+	case TypeDecimal:
+		return NewDoubleValue(float64(v) - other.(DecimalValue).Float64()), nil
 	}
 
 	return NewNullValue(), nil
@@ -234,6 +313,8 @@ func (v DoubleValue) Mul(other Numeric) (Value, error) {
 		return NewDoubleValue(float64(v) * float64(AsInt64(other))), nil
 	case TypeDouble:
 		return NewDoubleValue(float64(v) * AsFloat64(other)), nil
+	case TypeDecimal:
+		return NewDoubleValue(float64(v) * other.(DecimalValue).Float64()), nil
 	}
 
 	return NewNullValue(), nil
@@ -254,6 +335,13 @@ func (v DoubleValue) Div(other Numeric) (Value, error) {
 			return NewNullValue(), nil
 		}
 
+		return NewDoubleValue(float64(v) / xb), nil
+	case TypeDecimal:
+		xb := other.(DecimalValue).Float64()
+		if xb == 0 {
+			return NewNullValue(), nil
+		}
+
 		return NewDoubleValue(float64(v) / xb), nil
 	}
 
@@ -277,6 +365,13 @@ func (v DoubleValue) Mod(other Numeric) (Value, error) {
 			return NewNullValue(), nil
 		}
 
+		return NewDoubleValue(xr), nil
+	case TypeDecimal:
+		xr := math.Mod(float64(v), other.(DecimalValue).Float64())
+		if math.IsNaN(xr) {
+			return NewNullValue(), nil
+		}
+
 		return NewDoubleValue(xr), nil
 	}
 
@@ -0,0 +1,84 @@
+package types_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/chaisql/chai/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoubleNonFinite(t *testing.T) {
+	nan := types.NewDoubleValue(math.NaN())
+	inf := types.NewDoubleValue(math.Inf(1))
+	ninf := types.NewDoubleValue(math.Inf(-1))
+
+	// sorted values
+	values := []types.Value{
+		ninf,
+		types.NewDoubleValue(-math.MaxFloat64),
+		types.NewBigintValue(-1),
+		types.NewDoubleValue(0),
+		types.NewDoubleValue(1.5),
+		types.NewDoubleValue(math.MaxFloat64),
+		inf,
+		nan,
+	}
+
+	for i := range values {
+		for j := range values {
+			lt, err := values[i].LT(values[j])
+			require.NoError(t, err)
+			require.Equal(t, i < j, lt, "%s < %s", values[i], values[j])
+
+			eq, err := values[i].EQ(values[j])
+			require.NoError(t, err)
+			require.Equal(t, i == j, eq, "%s = %s", values[i], values[j])
+		}
+	}
+
+	var prev []byte
+	for i, v := range values {
+		key, err := v.CastAs(types.TypeDouble)
+		require.NoError(t, err)
+		enc, err := key.EncodeAsKey(nil)
+		require.NoError(t, err)
+		if i > 0 {
+			require.Negative(t, encoding.Compare(prev, enc), "%s < %s", values[i-1], v)
+		}
+		prev = enc
+	}
+
+	// all NaN values are encoded the same way
+	a, err := nan.EncodeAsKey(nil)
+	require.NoError(t, err)
+	b, err := types.NewDoubleValue(-math.NaN()).EncodeAsKey(nil)
+	require.NoError(t, err)
+	require.Equal(t, a, b)
+
+	for _, test := range []struct {
+		v    types.Value
+		text string
+	}{
+		{nan, "NaN"},
+		{inf, "Infinity"},
+		{ninf, "-Infinity"},
+	} {
+		require.Equal(t, test.text, test.v.String())
+
+		j, err := test.v.MarshalJSON()
+		require.NoError(t, err)
+		require.Equal(t, `"`+test.text+`"`, string(j))
+
+		txt, err := test.v.CastAs(types.TypeText)
+		require.NoError(t, err)
+		require.Equal(t, test.text, types.AsString(txt))
+
+		d, err := txt.CastAs(types.TypeDouble)
+		require.NoError(t, err)
+		eq, err := d.EQ(test.v)
+		require.NoError(t, err)
+		require.True(t, eq)
+	}
+}
@@ -17,8 +17,10 @@ var encodedTypeToTypeDefs = map[byte]TypeDefinition{
 	encoding.Uint32Value:  IntegerTypeDef{},
 	encoding.Uint64Value:  BigintTypeDef{},
 	encoding.Float64Value: DoubleTypeDef{},
+	encoding.DecimalValue: DecimalTypeDef{},
 	encoding.TextValue:    TextTypeDef{},
 	encoding.BlobValue:    BlobTypeDef{},
+	encoding.UUIDValue:    UUIDTypeDef{},
 }
 
 func DecodeValue(b []byte) (v Value, n int) {
@@ -0,0 +1,143 @@
+package types
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+// CastWithFormat converts v to the target type, using format to control
+// the textual representation of the value. Formats are supported when converting:
+//   - blobs from and to texts: "base64", which is the format used by CastAs, or "hex".
+//   - timestamps from and to texts: a strftime-like pattern, e.g. "%Y-%m-%d %H:%M:%S".
+//
+// The supported directives are %Y, %y, %m, %d, %j, %H, %I, %p, %M, %S, %f (microseconds,
+// after a dot or a comma), %b, %B, %a, %A, %z and %%. Timestamps are formatted in UTC.
+func CastWithFormat(v Value, target Type, format string) (Value, error) {
+	return CastWithFormatIn(v, target, format, time.UTC)
+}
+
+// CastWithFormatIn converts v to the target type like CastWithFormat,
+// formatting the timestamps in loc, unless they were already associated
+// with a location, and interpreting the texts without zone offset
+// parsed as timestamps in loc.
+func CastWithFormatIn(v Value, target Type, format string, loc *time.Location) (Value, error) {
+	src := v.Type()
+
+	switch {
+	case src == TypeNull:
+		return v, nil
+	case src == TypeBlob && target == TypeText:
+		switch strings.ToLower(format) {
+		case "base64":
+			return NewTextValue(base64.StdEncoding.EncodeToString(AsByteSlice(v))), nil
+		case "hex":
+			return NewTextValue(hex.EncodeToString(AsByteSlice(v))), nil
+		}
+
+		return nil, errors.Errorf("unknown blob format %q, expected base64 or hex", format)
+	case src == TypeText && target == TypeBlob:
+		var b []byte
+		var err error
+
+		switch strings.ToLower(format) {
+		case "base64":
+			b, err = base64.StdEncoding.DecodeString(AsString(v))
+		case "hex":
+			b, err = hex.DecodeString(AsString(v))
+		default:
+			return nil, errors.Errorf("unknown blob format %q, expected base64 or hex", format)
+		}
+		if err != nil {
+			return nil, errors.Errorf("cannot cast %q as blob: %w", AsString(v), err)
+		}
+
+		return NewBlobValue(b), nil
+	case src == TypeTimestamp && target == TypeText:
+		layout, err := timeLayout(format)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewTextValue(time.Time(LocalTimestamp(v, loc)).Format(layout)), nil
+	case src == TypeText && target == TypeTimestamp:
+		layout, err := timeLayout(format)
+		if err != nil {
+			return nil, err
+		}
+
+		t, err := time.ParseInLocation(layout, AsString(v), loc)
+		if err != nil {
+			return nil, errors.Errorf("cannot cast %q as timestamp with format %q", AsString(v), format)
+		}
+		if m := t.UnixMicro(); m > maxTime || m < minTime {
+			return nil, errors.New("timestamp out of range")
+		}
+
+		return NewTimestampValue(t), nil
+	}
+
+	return nil, errors.Errorf("cannot cast %s as %s with a format", src, target)
+}
+
+var timeDirectives = map[byte]string{
+	'Y': "2006",
+	'y': "06",
+	'm': "01",
+	'd': "02",
+	'j': "002",
+	'H': "15",
+	'I': "03",
+	'p': "PM",
+	'M': "04",
+	'S': "05",
+	'f': "000000",
+	'b': "Jan",
+	'B': "January",
+	'a': "Mon",
+	'A': "Monday",
+	'z': "-0700",
+}
+
+// timeLayout converts a strftime-like format to a Go time layout.
+func timeLayout(format string) (string, error) {
+	var sb strings.Builder
+
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if c != '%' {
+			// Go layouts can't escape characters,
+			// reject those that could be interpreted as part of the layout.
+			if c >= '0' && c <= '9' || (c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') && c != 'T' && c != 'Z' {
+				return "", fmt.Errorf("invalid format %q: unsupported character %q", format, c)
+			}
+			sb.WriteByte(c)
+			continue
+		}
+
+		i++
+		if i == len(format) {
+			return "", fmt.Errorf("invalid format %q: missing directive after %%", format)
+		}
+		if format[i] == '%' {
+			sb.WriteByte('%')
+			continue
+		}
+
+		d, ok := timeDirectives[format[i]]
+		if !ok {
+			return "", fmt.Errorf("invalid format %q: unknown directive %%%c", format, format[i])
+		}
+		if format[i] == 'f' && (i < 2 || format[i-2] != '.' && format[i-2] != ',') {
+			return "", fmt.Errorf("invalid format %q: %%f must follow a dot or a comma", format)
+		}
+
+		sb.WriteString(d)
+	}
+
+	return sb.String(), nil
+}
@@ -1,6 +1,7 @@
 package types
 
 import (
+	"fmt"
 	"math"
 	"strconv"
 
@@ -23,20 +24,46 @@ func (IntegerTypeDef) Type() Type {
 func (IntegerTypeDef) Decode(src []byte) (Value, int) {
 	x, n := encoding.DecodeInt(src)
 	if x < math.MinInt32 || x > math.MaxInt32 {
-		panic(errors.New("integer out of range"))
+		panic(outOfRange("integer"))
 	}
 
 	return NewIntegerValue(int32(x)), n
 }
 
 func (IntegerTypeDef) IsComparableWith(other Type) bool {
-	return other == TypeInteger || other == TypeBigint || other == TypeDouble
+	return other.IsNumber()
 }
 
 func (IntegerTypeDef) IsIndexComparableWith(other Type) bool {
 	return other == TypeInteger || other == TypeBigint
 }
 
+// ConstrainInteger returns an error matching ErrOutOfRange if the integer v
+// doesn't fit in a signed integer of the given number of bits.
+func ConstrainInteger(v Value, bits int) error {
+	x := AsInt64(v)
+	if bits >= 64 {
+		return nil
+	}
+
+	max := int64(1)<<(bits-1) - 1
+	if x < -max-1 || x > max {
+		return rangeError(fmt.Sprintf("%d out of range for %s", x, IntegerTypeName(bits)))
+	}
+
+	return nil
+}
+
+// IntegerTypeName returns the SQL name of the integer type of the given width.
+// INT8 is an alias of BIGINT, so 8-bit integers are named TINYINT.
+func IntegerTypeName(bits int) string {
+	if bits == 8 {
+		return "TINYINT"
+	}
+
+	return fmt.Sprintf("INT%d", bits)
+}
+
 var _ Numeric = NewIntegerValue(0)
 var _ Integral = NewIntegerValue(0)
 var _ Value = NewIntegerValue(0)
@@ -94,6 +121,8 @@ func (v IntegerValue) CastAs(target Type) (Value, error) {
 		return NewBigintValue(int64(v)), nil
 	case TypeDouble:
 		return NewDoubleValue(float64(v)), nil
+	case TypeDecimal:
+		return NewDecimalValueFromInt(int64(v)), nil
 	case TypeText:
 		return NewTextValue(v.String()), nil
 	}
@@ -109,7 +138,9 @@ func (v IntegerValue) EQ(other Value) (bool, error) {
 	case TypeBigint:
 		return int64(v) == AsInt64(other), nil
 	case TypeDouble:
-		return float64(int32(v)) == AsFloat64(other), nil
+		return compareFloats(float64(int32(v)), AsFloat64(other)) == 0, nil
+	case TypeDecimal:
+		return NewDecimalValueFromInt(int64(v)).EQ(other)
 	default:
 		return false, nil
 	}
@@ -123,7 +154,9 @@ func (v IntegerValue) GT(other Value) (bool, error) {
 	case TypeBigint:
 		return int64(v) > AsInt64(other), nil
 	case TypeDouble:
-		return float64(int32(v)) > AsFloat64(other), nil
+		return compareFloats(float64(int32(v)), AsFloat64(other)) > 0, nil
+	case TypeDecimal:
+		return NewDecimalValueFromInt(int64(v)).GT(other)
 	default:
 		return false, nil
 	}
@@ -137,7 +170,9 @@ func (v IntegerValue) GTE(other Value) (bool, error) {
 	case TypeBigint:
 		return int64(v) >= AsInt64(other), nil
 	case TypeDouble:
-		return float64(int32(v)) >= AsFloat64(other), nil
+		return compareFloats(float64(int32(v)), AsFloat64(other)) >= 0, nil
+	case TypeDecimal:
+		return NewDecimalValueFromInt(int64(v)).GTE(other)
 	default:
 		return false, nil
 	}
@@ -151,7 +186,9 @@ func (v IntegerValue) LT(other Value) (bool, error) {
 	case TypeBigint:
 		return int64(v) < AsInt64(other), nil
 	case TypeDouble:
-		return float64(int32(v)) <= AsFloat64(other), nil
+		return compareFloats(float64(int32(v)), AsFloat64(other)) <= 0, nil
+	case TypeDecimal:
+		return NewDecimalValueFromInt(int64(v)).LT(other)
 	default:
 		return false, nil
 	}
@@ -165,7 +202,9 @@ func (v IntegerValue) LTE(other Value) (bool, error) {
 	case TypeBigint:
 		return int64(v) <= AsInt64(other), nil
 	case TypeDouble:
-		return float64(int32(v)) <= AsFloat64(other), nil
+		return compareFloats(float64(int32(v)), AsFloat64(other)) <= 0, nil
+	case TypeDecimal:
+		return NewDecimalValueFromInt(int64(v)).LTE(other)
 	default:
 		return false, nil
 	}
@@ -190,7 +229,7 @@ func (v IntegerValue) Add(other Numeric) (Value, error) {
 		xa := int32(v)
 		xb := AsInt32(other)
 		if isAddOverflow(xa, xb, math.MinInt32, math.MaxInt32) {
-			return nil, errors.New("integer out of range")
+			return nil, outOfRange("integer")
 		}
 
 		xr := xa + xb
@@ -199,13 +238,15 @@ func (v IntegerValue) Add(other Numeric) (Value, error) {
 		xa := int64(v)
 		xb := AsInt64(other)
 		if isAddOverflow(xa, xb, math.MinInt64, math.MaxInt64) {
-			return nil, errors.New("bigint out of range")
+			return nil, outOfRange("bigint")
 		}
 
 		xr := xa + xb
 		return NewBigintValue(xr), nil
 	case TypeDouble:
 		return NewDoubleValue(float64(int32(v)) + AsFloat64(other)), nil
+	case TypeDecimal:
+		return NewDecimalValueFromInt(int64(v)).Add(other)
 	}
 
 	return NewNullValue(), nil
@@ -217,7 +258,7 @@ func (v IntegerValue) Sub(other Numeric) (Value, error) {
 		xa := int32(v)
 		xb := AsInt32(other)
 		if isSubOverflow(xa, xb, math.MinInt32, math.MaxInt32) {
-			return nil, errors.New("integer out of range")
+			return nil, outOfRange("integer")
 		}
 
 		xr := xa - xb
@@ -226,12 +267,14 @@ func (v IntegerValue) Sub(other Numeric) (Value, error) {
 		xa := int64(v)
 		xb := AsInt64(other)
 		if isSubOverflow(xa, xb, math.MinInt64, math.MaxInt64) {
-			return nil, errors.New("bigint out of range")
+			return nil, outOfRange("bigint")
 		}
 		xr := xa - xb
 		return NewBigintValue(xr), nil
 	case TypeDouble:
 		return NewDoubleValue(float64(int32(v)) - AsFloat64(other)), nil
+	case TypeDecimal:
+		return NewDecimalValueFromInt(int64(v)).Sub(other)
 	}
 
 	return NewNullValue(), nil
@@ -243,7 +286,7 @@ func (v IntegerValue) Mul(other Numeric) (Value, error) {
 		xa := int32(v)
 		xb := AsInt32(other)
 		if isMulOverflow(xa, xb, math.MinInt32, math.MaxInt32) {
-			return nil, errors.New("integer out of range")
+			return nil, outOfRange("integer")
 		}
 		xr := xa * xb
 
@@ -252,13 +295,15 @@ func (v IntegerValue) Mul(other Numeric) (Value, error) {
 		xa := int64(v)
 		xb := AsInt64(other)
 		if isMulOverflow(xa, xb, math.MinInt64, math.MaxInt64) {
-			return nil, errors.New("integer out of range")
+			return nil, outOfRange("bigint")
 		}
 
 		xr := xa * xb
 		return NewBigintValue(xr), nil
 	case TypeDouble:
 		return NewDoubleValue(float64(int32(v)) * AsFloat64(other)), nil
+	case TypeDecimal:
+		return NewDecimalValueFromInt(int64(v)).Mul(other)
 	}
 
 	return NewNullValue(), nil
@@ -272,6 +317,9 @@ func (v IntegerValue) Div(other Numeric) (Value, error) {
 		if xb == 0 {
 			return nil, errors.New("division by zero")
 		}
+		if isDivOverflow(xa, xb, math.MinInt32) {
+			return nil, outOfRange("integer")
+		}
 
 		return NewIntegerValue(xa / xb), nil
 	case TypeBigint:
@@ -290,6 +338,8 @@ func (v IntegerValue) Div(other Numeric) (Value, error) {
 		}
 
 		return NewDoubleValue(xa / xb), nil
+	case TypeDecimal:
+		return NewDecimalValueFromInt(int64(v)).Div(other)
 	}
 
 	return NewNullValue(), nil
@@ -322,6 +372,8 @@ func (v IntegerValue) Mod(other Numeric) (Value, error) {
 		}
 
 		return NewDoubleValue(mod), nil
+	case TypeDecimal:
+		return NewDecimalValueFromInt(int64(v)).Mod(other)
 	}
 
 	return NewNullValue(), nil
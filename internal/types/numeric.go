@@ -38,17 +38,20 @@ type Integral interface {
 }
 
 func isMulOverflow[T int32 | int64](left, right, min, max T) bool {
-	if right > 0 {
-		if left > max/right {
-			return true
-		}
-	} else {
-		if left < min/right {
-			return true
-		}
+	if left == 0 || right == 0 {
+		return false
+	}
+	if (left == -1 && right == min) || (right == -1 && left == min) {
+		return true
 	}
 
-	return false
+	return (left*right)/right != left
+}
+
+// isDivOverflow reports whether left / right overflows,
+// which only happens when dividing the minimum value by -1.
+func isDivOverflow[T int32 | int64](left, right, min T) bool {
+	return left == min && right == -1
 }
 
 func isAddOverflow[T int32 | int64](left, right, min, max T) bool {
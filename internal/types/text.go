@@ -28,7 +28,7 @@ func (TextTypeDef) Decode(src []byte) (Value, int) {
 }
 
 func (TextTypeDef) IsComparableWith(other Type) bool {
-	return other == TypeNull || other == TypeText || other == TypeBoolean || other == TypeInteger || other == TypeBigint || other == TypeDouble || other == TypeTimestamp || other == TypeBlob
+	return other == TypeNull || other == TypeText || other == TypeBoolean || other == TypeInteger || other == TypeBigint || other == TypeDouble || other == TypeTimestamp || other == TypeBlob || other == TypeUUID
 }
 
 func (t TextTypeDef) IsIndexComparableWith(other Type) bool {
@@ -98,7 +98,7 @@ func (v TextValue) CastAs(target Type) (Value, error) {
 			if err != nil {
 				return nil, errors.Errorf(`cannot cast %q as integer: %w`, v.V(), intErr)
 			}
-			i = int64(f)
+			return NewDoubleValue(f).CastAs(TypeInteger)
 		}
 		return NewIntegerValue(int32(i)), nil
 	case TypeBigint:
@@ -109,7 +109,7 @@ func (v TextValue) CastAs(target Type) (Value, error) {
 			if err != nil {
 				return nil, fmt.Errorf(`cannot cast %q as bigint: %w`, v.V(), intErr)
 			}
-			i = int64(f)
+			return NewDoubleValue(f).CastAs(TypeBigint)
 		}
 		return NewBigintValue(i), nil
 	case TypeDouble:
@@ -118,12 +118,24 @@ func (v TextValue) CastAs(target Type) (Value, error) {
 			return nil, fmt.Errorf(`cannot cast %q as double: %w`, v.V(), err)
 		}
 		return NewDoubleValue(f), nil
+	case TypeDecimal:
+		d, err := ParseDecimal(strings.TrimSpace(string(v)))
+		if err != nil {
+			return nil, fmt.Errorf(`cannot cast %q as decimal: %w`, v.V(), err)
+		}
+		return d, nil
 	case TypeTimestamp:
 		t, err := ParseTimestamp(string(v))
 		if err != nil {
 			return nil, fmt.Errorf(`cannot cast %q as timestamp: %w`, v.V(), err)
 		}
 		return NewTimestampValue(t), nil
+	case TypeUUID:
+		u, err := ParseUUID(strings.TrimSpace(string(v)))
+		if err != nil {
+			return nil, fmt.Errorf(`cannot cast %q as uuid: %w`, v.V(), err)
+		}
+		return u, nil
 	case TypeBlob:
 		s := string(v)
 		b, err := base64.StdEncoding.DecodeString(s)
@@ -148,6 +160,8 @@ func (v TextValue) EQ(other Value) (bool, error) {
 			return false, err
 		}
 		return ts.Equal(AsTime(other)), nil
+	case TypeUUID:
+		return other.EQ(v)
 	default:
 		return false, nil
 	}
@@ -164,6 +178,8 @@ func (v TextValue) GT(other Value) (bool, error) {
 			return false, err
 		}
 		return ts.After(AsTime(other)), nil
+	case TypeUUID:
+		return other.LT(v)
 	default:
 		return false, nil
 	}
@@ -181,6 +197,8 @@ func (v TextValue) GTE(other Value) (bool, error) {
 		}
 		t2 := AsTime(other)
 		return t1.After(t2) || t1.Equal(t2), nil
+	case TypeUUID:
+		return other.LTE(v)
 	default:
 		return false, nil
 	}
@@ -197,6 +215,8 @@ func (v TextValue) LT(other Value) (bool, error) {
 			return false, err
 		}
 		return ts.Before(AsTime(other)), nil
+	case TypeUUID:
+		return other.GT(v)
 	default:
 		return false, nil
 	}
@@ -214,6 +234,8 @@ func (v TextValue) LTE(other Value) (bool, error) {
 		}
 		t2 := AsTime(other)
 		return t1.Before(t2) || t1.Equal(t2), nil
+	case TypeUUID:
+		return other.GTE(v)
 	default:
 		return false, nil
 	}
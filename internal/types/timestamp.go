@@ -1,6 +1,7 @@
 package types
 
 import (
+	"fmt"
 	"math"
 	"strconv"
 	"time"
@@ -43,6 +44,11 @@ var (
 	minTime = math.MinInt64 + epoch
 )
 
+// A TimestampValue is an instant, stored in UTC with a microsecond precision.
+// The zone offset of the time it is created from is not preserved:
+// two timestamps are equal if they describe the same instant.
+// A timestamp can however be associated with a location using In,
+// which is only used to format it.
 type TimestampValue time.Time
 
 // NewTimestampValue returns a SQL TIMESTAMP value.
@@ -50,6 +56,17 @@ func NewTimestampValue(x time.Time) TimestampValue {
 	return TimestampValue(x.UTC())
 }
 
+// In returns the same instant, formatted in the given location.
+func (v TimestampValue) In(loc *time.Location) TimestampValue {
+	return TimestampValue(time.Time(v).In(loc))
+}
+
+// Text returns the RFC 3339 representation of the timestamp,
+// with the offset of its location.
+func (v TimestampValue) Text() string {
+	return time.Time(v).Format(time.RFC3339Nano)
+}
+
 func (v TimestampValue) V() any {
 	return time.Time(v)
 }
@@ -67,7 +84,7 @@ func (v TimestampValue) IsZero() (bool, error) {
 }
 
 func (v TimestampValue) String() string {
-	return strconv.Quote(time.Time(v).Format(time.RFC3339Nano))
+	return strconv.Quote(v.Text())
 }
 
 func (v TimestampValue) MarshalText() ([]byte, error) {
@@ -91,7 +108,7 @@ func (v TimestampValue) CastAs(target Type) (Value, error) {
 	case TypeTimestamp:
 		return v, nil
 	case TypeText:
-		return NewTextValue(v.String()), nil
+		return NewTextValue(v.Text()), nil
 	}
 
 	return nil, errors.Errorf("cannot cast %s as %s", v.Type(), target)
@@ -198,8 +215,16 @@ func (v TimestampValue) Between(a, b Value) (bool, error) {
 	return b.GTE(v)
 }
 
+// ParseTimestamp parses s as a timestamp. Timestamps without
+// zone offset are interpreted in UTC.
 func ParseTimestamp(s string) (time.Time, error) {
-	c := carbon.Parse(s, "UTC")
+	return ParseTimestampIn(s, time.UTC)
+}
+
+// ParseTimestampIn parses s as a timestamp. Timestamps without
+// zone offset are interpreted in loc.
+func ParseTimestampIn(s string, loc *time.Location) (time.Time, error) {
+	c := carbon.SetLocation(loc).Parse(s)
 	if c.Error != nil {
 		return time.Time{}, errors.New("invalid timestamp")
 	}
@@ -212,3 +237,33 @@ func ParseTimestamp(s string) (time.Time, error) {
 
 	return ts, nil
 }
+
+// CastIn converts v to the target type like CastAs, interpreting
+// the texts without zone offset converted to timestamps in loc,
+// and formatting the timestamps converted to texts in loc,
+// unless they were already associated with a location.
+func CastIn(v Value, target Type, loc *time.Location) (Value, error) {
+	switch {
+	case v.Type() == TypeText && target == TypeTimestamp:
+		t, err := ParseTimestampIn(AsString(v), loc)
+		if err != nil {
+			return nil, fmt.Errorf(`cannot cast %q as timestamp: %w`, v.V(), err)
+		}
+		return NewTimestampValue(t), nil
+	case v.Type() == TypeTimestamp && target == TypeText:
+		return NewTextValue(LocalTimestamp(v, loc).Text()), nil
+	}
+
+	return v.CastAs(target)
+}
+
+// LocalTimestamp returns the timestamp v formatted in loc,
+// unless it was already associated with a location other than UTC using In.
+func LocalTimestamp(v Value, loc *time.Location) TimestampValue {
+	t := AsTime(v)
+	if t.Location() != time.UTC || loc == nil {
+		return TimestampValue(t)
+	}
+
+	return TimestampValue(t.In(loc))
+}
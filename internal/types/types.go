@@ -11,8 +11,24 @@ var (
 	// ErrColumnNotFound must be returned by row implementations, when calling the Get method and
 	// the column doesn't exist.
 	ErrColumnNotFound = errors.New("column not found")
+
+	// ErrOutOfRange is returned when the result of a conversion or of
+	// an arithmetic operation doesn't fit in its type.
+	ErrOutOfRange = errors.New("out of range")
 )
 
+// outOfRange returns an error matching ErrOutOfRange.
+func outOfRange(typ string) error {
+	return rangeError(typ + " out of range")
+}
+
+// rangeError is an error message matching ErrOutOfRange.
+type rangeError string
+
+func (e rangeError) Error() string { return string(e) }
+
+func (e rangeError) Is(target error) bool { return target == ErrOutOfRange }
+
 // Type represents a type supported by the database.
 type Type uint8
 
@@ -28,6 +44,8 @@ const (
 	TypeTimestamp
 	TypeText
 	TypeBlob
+	TypeDecimal
+	TypeUUID
 )
 
 func (t Type) Def() TypeDefinition {
@@ -48,6 +66,10 @@ func (t Type) Def() TypeDefinition {
 		return TextTypeDef{}
 	case TypeBlob:
 		return BlobTypeDef{}
+	case TypeDecimal:
+		return DecimalTypeDef{}
+	case TypeUUID:
+		return UUIDTypeDef{}
 	}
 
 	return nil
@@ -71,6 +93,10 @@ func (t Type) String() string {
 		return "blob"
 	case TypeText:
 		return "text"
+	case TypeDecimal:
+		return "decimal"
+	case TypeUUID:
+		return "uuid"
 	}
 
 	panic(fmt.Sprintf("unsupported type %#v", t))
@@ -94,6 +120,10 @@ func (t Type) MinEnctype() byte {
 		return encoding.TextValue
 	case TypeBlob:
 		return encoding.BlobValue
+	case TypeDecimal:
+		return encoding.DecimalValue
+	case TypeUUID:
+		return encoding.UUIDValue
 	default:
 		panic(fmt.Sprintf("unsupported type %v", t))
 	}
@@ -117,6 +147,10 @@ func (t Type) MinEnctypeDesc() byte {
 		return encoding.DESC_TextValue
 	case TypeBlob:
 		return encoding.DESC_BlobValue
+	case TypeDecimal:
+		return encoding.DESC_DecimalValue
+	case TypeUUID:
+		return encoding.DESC_UUIDValue
 	default:
 		panic(fmt.Sprintf("unsupported type %v", t))
 	}
@@ -140,6 +174,10 @@ func (t Type) MaxEnctype() byte {
 		return encoding.TextValue + 1
 	case TypeBlob:
 		return encoding.BlobValue + 1
+	case TypeDecimal:
+		return encoding.DecimalValue + 1
+	case TypeUUID:
+		return encoding.UUIDValue + 1
 	default:
 		panic(fmt.Sprintf("unsupported type %v", t))
 	}
@@ -161,14 +199,18 @@ func (t Type) MaxEnctypeDesc() byte {
 		return encoding.DESC_TextValue + 1
 	case TypeBlob:
 		return encoding.DESC_BlobValue + 1
+	case TypeDecimal:
+		return encoding.DESC_DecimalValue + 1
+	case TypeUUID:
+		return encoding.DESC_UUIDValue + 1
 	default:
 		panic(fmt.Sprintf("unsupported type %v", t))
 	}
 }
 
-// IsNumber returns true if t is either an integer or a float.
+// IsNumber returns true if t is either an integer, a float or a decimal.
 func (t Type) IsNumber() bool {
-	return t == TypeInteger || t == TypeBigint || t == TypeDouble
+	return t == TypeInteger || t == TypeBigint || t == TypeDouble || t == TypeDecimal
 }
 
 func (t Type) IsInteger() bool {
@@ -193,6 +235,11 @@ func (t Type) IsComparableWith(other Type) bool {
 		return true
 	}
 
+	// UUIDs can be compared with their text representation
+	if (t == TypeUUID && other == TypeText) || (t == TypeText && other == TypeUUID) {
+		return true
+	}
+
 	return false
 }
 
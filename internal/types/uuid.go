@@ -0,0 +1,198 @@
+package types
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strconv"
+
+	"github.com/chaisql/chai/internal/encoding"
+	"github.com/cockroachdb/errors"
+)
+
+var _ TypeDefinition = UUIDTypeDef{}
+
+type UUIDTypeDef struct{}
+
+func (UUIDTypeDef) New(v any) Value {
+	return NewUUIDValue(v.([16]byte))
+}
+
+func (UUIDTypeDef) Type() Type {
+	return TypeUUID
+}
+
+func (UUIDTypeDef) Decode(src []byte) (Value, int) {
+	x, n := encoding.DecodeUUID(src)
+	return NewUUIDValue(x), n
+}
+
+func (UUIDTypeDef) IsComparableWith(other Type) bool {
+	return other == TypeUUID || other == TypeText
+}
+
+func (UUIDTypeDef) IsIndexComparableWith(other Type) bool {
+	return other == TypeUUID
+}
+
+var _ Value = NewUUIDValue([16]byte{})
+
+type UUIDValue [16]byte
+
+// NewUUIDValue returns a SQL UUID value.
+func NewUUIDValue(x [16]byte) UUIDValue {
+	return UUIDValue(x)
+}
+
+// ParseUUID parses a UUID in its canonical form
+// xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx.
+// Upper case digits, surrounding braces and the form without hyphens
+// are also accepted.
+func ParseUUID(s string) (UUIDValue, error) {
+	var u UUIDValue
+
+	if len(s) == 38 && s[0] == '{' && s[37] == '}' {
+		s = s[1:37]
+	}
+
+	switch len(s) {
+	case 32:
+	case 36:
+		if s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+			return u, errors.Errorf("invalid UUID %q", s)
+		}
+		s = s[:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	default:
+		return u, errors.Errorf("invalid UUID %q", s)
+	}
+
+	_, err := hex.Decode(u[:], []byte(s))
+	if err != nil {
+		return u, errors.Errorf("invalid UUID %q", s)
+	}
+
+	return u, nil
+}
+
+// Version returns the version of the UUID, as stored in its 13th hex digit.
+func (v UUIDValue) Version() int {
+	return int(v[6] >> 4)
+}
+
+// Text returns the canonical form of the UUID.
+func (v UUIDValue) Text() string {
+	var buf [36]byte
+
+	hex.Encode(buf[:8], v[:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], v[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], v[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], v[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], v[10:])
+
+	return string(buf[:])
+}
+
+func (v UUIDValue) V() any {
+	return [16]byte(v)
+}
+
+func (v UUIDValue) Type() Type {
+	return TypeUUID
+}
+
+func (v UUIDValue) TypeDef() TypeDefinition {
+	return UUIDTypeDef{}
+}
+
+func (v UUIDValue) IsZero() (bool, error) {
+	return v == UUIDValue{}, nil
+}
+
+func (v UUIDValue) String() string {
+	return strconv.Quote(v.Text())
+}
+
+func (v UUIDValue) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+func (v UUIDValue) MarshalJSON() ([]byte, error) {
+	return v.MarshalText()
+}
+
+func (v UUIDValue) Encode(dst []byte) ([]byte, error) {
+	return encoding.EncodeUUID(dst, v), nil
+}
+
+func (v UUIDValue) EncodeAsKey(dst []byte) ([]byte, error) {
+	return v.Encode(dst)
+}
+
+func (v UUIDValue) CastAs(target Type) (Value, error) {
+	switch target {
+	case TypeUUID:
+		return v, nil
+	case TypeText:
+		return NewTextValue(v.Text()), nil
+	case TypeBlob:
+		return NewBlobValue(bytes.Clone(v[:])), nil
+	}
+
+	return nil, errors.Errorf("cannot cast %s as %s", v.Type(), target)
+}
+
+// compare returns the result of the comparison of v with other.
+// Texts are parsed as UUIDs. ok is false if other is neither a UUID
+// nor a text.
+func (v UUIDValue) compare(other Value) (cmp int, ok bool, err error) {
+	switch other.Type() {
+	case TypeUUID:
+		o := other.(UUIDValue)
+		return bytes.Compare(v[:], o[:]), true, nil
+	case TypeText:
+		o, err := ParseUUID(AsString(other))
+		if err != nil {
+			return 0, false, err
+		}
+		return bytes.Compare(v[:], o[:]), true, nil
+	}
+
+	return 0, false, nil
+}
+
+func (v UUIDValue) EQ(other Value) (bool, error) {
+	cmp, ok, err := v.compare(other)
+	return ok && cmp == 0, err
+}
+
+func (v UUIDValue) GT(other Value) (bool, error) {
+	cmp, ok, err := v.compare(other)
+	return ok && cmp > 0, err
+}
+
+func (v UUIDValue) GTE(other Value) (bool, error) {
+	cmp, ok, err := v.compare(other)
+	return ok && cmp >= 0, err
+}
+
+func (v UUIDValue) LT(other Value) (bool, error) {
+	cmp, ok, err := v.compare(other)
+	return ok && cmp < 0, err
+}
+
+func (v UUIDValue) LTE(other Value) (bool, error) {
+	cmp, ok, err := v.compare(other)
+	return ok && cmp <= 0, err
+}
+
+func (v UUIDValue) Between(a, b Value) (bool, error) {
+	ok, err := v.GTE(a)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	return v.LTE(b)
+}
@@ -0,0 +1,39 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/chaisql/chai/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUUID(t *testing.T) {
+	tests := []struct {
+		input string
+		fails bool
+	}{
+		{"0190a7b4-7c3e-7abc-8def-0123456789ab", false},
+		{"0190A7B4-7C3E-7ABC-8DEF-0123456789AB", false},
+		{"{0190a7b4-7c3e-7abc-8def-0123456789ab}", false},
+		{"0190a7b47c3e7abc8def0123456789ab", false},
+		{"", true},
+		{"0190a7b4-7c3e-7abc-8def-0123456789a", true},
+		{"0190a7b4-7c3e-7abc-8def-0123456789abc", true},
+		{"0190a7b4+7c3e-7abc-8def-0123456789ab", true},
+		{"0190a7b4-7c3e-7abc-8def-0123456789ag", true},
+		{"{0190a7b4-7c3e-7abc-8def-0123456789ab", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			u, err := types.ParseUUID(test.input)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "0190a7b4-7c3e-7abc-8def-0123456789ab", u.Text())
+			require.Equal(t, 7, u.Version())
+		})
+	}
+}
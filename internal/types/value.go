@@ -18,6 +18,13 @@ type Value interface {
 	TypeDef() TypeDefinition
 	Encode(dst []byte) ([]byte, error)
 	EncodeAsKey(dst []byte) ([]byte, error)
+	// CastAs converts the value to the type t.
+	// Booleans and numbers (integers, bigints, doubles and decimals)
+	// convert to each other: true is 1 and false is 0, and a number
+	// is true unless it is zero. Values of every type convert to and from
+	// texts, and uuids to and from blobs. Other conversions are rejected:
+	// timestamps, blobs and uuids don't convert to or from booleans
+	// and numbers, nor timestamps to or from blobs and uuids.
 	CastAs(t Type) (Value, error)
 }
 
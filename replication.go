@@ -0,0 +1,64 @@
+package chai
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/chaisql/chai/internal/database"
+)
+
+// ErrReadOnlyReplica is returned when writing to a database following another one.
+var ErrReadOnlyReplica = database.ErrReadOnlyReplica
+
+// ServeReplicas accepts the connections of followers on l and streams them
+// the transactions committed to the database, starting after the last commit
+// they applied, until the context is done.
+// The database must be opened with Options.WALArchiveDir set.
+func (db *DB) ServeReplicas(ctx context.Context, l net.Listener) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stop := context.AfterFunc(ctx, func() {
+		_ = l.Close()
+	})
+	defer stop()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer conn.Close()
+
+			stop := context.AfterFunc(ctx, func() {
+				_ = conn.Close()
+			})
+			defer stop()
+
+			_ = db.DB.ServeReplica(ctx, conn)
+		}()
+	}
+}
+
+// Follow turns the database into a read-only replica of the leader connected to conn,
+// e.g. with net.Dial, applying the transactions committed by the leader as they are received.
+// Writes fail with ErrReadOnlyReplica while the database follows the leader.
+// The database must initially be a backup of the leader, made while its WAL archive was enabled,
+// or a replica that followed it previously: the leader only sends the transactions
+// committed after the last commit of the replica.
+// Follow returns once the context is done or the connection is closed.
+func (db *DB) Follow(ctx context.Context, conn io.ReadWriter) error {
+	return db.DB.Follow(ctx, conn)
+}
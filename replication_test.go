@@ -0,0 +1,102 @@
+package chai_test
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplication(t *testing.T) {
+	dir := t.TempDir()
+
+	leader, err := chai.OpenWith(filepath.Join(dir, "leader"), &chai.Options{
+		WALArchiveDir: filepath.Join(dir, "archive"),
+	})
+	require.NoError(t, err)
+	defer leader.Close()
+
+	err = leader.Exec(`
+		CREATE TABLE test(a INTEGER PRIMARY KEY);
+		INSERT INTO test (a) VALUES (1), (2);
+	`)
+	require.NoError(t, err)
+
+	// the replica starts as a backup of the leader
+	var backup bytes.Buffer
+	err = leader.Backup(context.Background(), &backup)
+	require.NoError(t, err)
+	err = chai.RestoreBackup(filepath.Join(dir, "replica"), &backup)
+	require.NoError(t, err)
+
+	// committed after the backup, sent when the replica connects
+	err = leader.Exec("INSERT INTO test (a) VALUES (3)")
+	require.NoError(t, err)
+
+	replica, err := chai.Open(filepath.Join(dir, "replica"))
+	require.NoError(t, err)
+	defer replica.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	served := make(chan error, 1)
+	go func() {
+		served <- leader.ServeReplicas(ctx, l)
+	}()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	followed := make(chan error, 1)
+	go func() {
+		followed <- replica.Follow(ctx, conn)
+	}()
+
+	count := func(table string) int {
+		r, err := replica.QueryRow("SELECT COUNT(*) FROM " + table)
+		if err != nil {
+			return -1
+		}
+
+		var n int
+		err = r.Scan(&n)
+		if err != nil {
+			return -1
+		}
+		return n
+	}
+
+	require.Eventually(t, func() bool { return count("test") == 3 }, 5*time.Second, 10*time.Millisecond)
+
+	err = leader.Exec(`
+		INSERT INTO test (a) VALUES (4);
+		CREATE TABLE other(a INTEGER PRIMARY KEY);
+		INSERT INTO other (a) VALUES (1);
+	`)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return count("test") == 4 && count("other") == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	r, err := replica.QueryRow("SELECT * FROM test ORDER BY a DESC")
+	require.NoError(t, err)
+	testutil.RequireJSONEq(t, r, `{"a": 4}`)
+
+	err = replica.Exec("INSERT INTO test (a) VALUES (5)")
+	require.ErrorIs(t, err, chai.ErrReadOnlyReplica)
+
+	cancel()
+	require.ErrorIs(t, <-followed, context.Canceled)
+	require.NoError(t, <-served)
+
+	// once the replica stops following the leader, it can be written to
+	err = replica.Exec("INSERT INTO test (a) VALUES (5)")
+	require.NoError(t, err)
+}
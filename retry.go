@@ -0,0 +1,96 @@
+package chai
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+// RetryPolicy controls how UpdateWithRetry runs a transaction again
+// after a transient failure.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times the transaction is run,
+	// including the first one. If zero, DefaultRetryPolicy.MaxAttempts is used.
+	// If negative, the transaction is retried until it succeeds
+	// or the context is done.
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the first retry. It is doubled
+	// after every retry, up to MaxBackoff. Each delay is randomized
+	// between half and all of its value, so that concurrent writers
+	// don't retry at the same time.
+	// If zero, DefaultRetryPolicy.InitialBackoff is used.
+	InitialBackoff time.Duration
+
+	// MaxBackoff is the maximum delay between two attempts.
+	// If zero, DefaultRetryPolicy.MaxBackoff is used.
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy is used by UpdateWithRetry when no policy is given,
+// and fills the zero fields of the given policies.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    10,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     100 * time.Millisecond,
+}
+
+// IsRetryableError reports whether the transaction that returned err
+// failed because of a concurrent transaction and can be run again.
+func IsRetryableError(err error) bool {
+	return errors.Is(err, ErrBusy)
+}
+
+// UpdateWithRetry starts a read-write transaction, runs fn and commits it,
+// like Connection.Update. If the transaction fails with an error for which
+// IsRetryableError returns true, it is rolled back and fn is run again
+// in a new transaction after a backoff, according to the policy.
+// If policy is nil, DefaultRetryPolicy is used.
+// fn may be called several times and must not have side effects
+// outside of the transaction. The queries of fn use ctx.
+func (db *DB) UpdateWithRetry(ctx context.Context, fn func(tx *Tx) error, policy *RetryPolicy) error {
+	p := DefaultRetryPolicy
+	if policy != nil {
+		if policy.MaxAttempts != 0 {
+			p.MaxAttempts = policy.MaxAttempts
+		}
+		if policy.InitialBackoff != 0 {
+			p.InitialBackoff = policy.InitialBackoff
+		}
+		if policy.MaxBackoff != 0 {
+			p.MaxBackoff = policy.MaxBackoff
+		}
+	}
+
+	conn, err := db.WithContext(ctx).Connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	backoff := p.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		err = conn.Update(fn)
+		if err == nil || !IsRetryableError(err) {
+			return err
+		}
+		if p.MaxAttempts > 0 && attempt >= p.MaxAttempts {
+			return errors.Wrapf(err, "transaction failed after %d attempts", attempt)
+		}
+
+		wait := backoff/2 + rand.N(backoff/2+1)
+		select {
+		case <-ctx.Done():
+			return errors.WithSecondaryError(context.Cause(ctx), err)
+		case <-time.After(wait):
+		}
+
+		backoff = min(2*backoff, p.MaxBackoff)
+	}
+}
@@ -0,0 +1,104 @@
+package chai_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/chaisql/chai"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateWithRetry(t *testing.T) {
+	db, err := chai.OpenWith(":memory:", &chai.Options{BusyTimeout: -1})
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE test(a INTEGER PRIMARY KEY)")
+	require.NoError(t, err)
+
+	policy := &chai.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+
+	t.Run("Retry on busy", func(t *testing.T) {
+		conn, err := db.Connect()
+		require.NoError(t, err)
+		defer conn.Close()
+
+		// hold the write lock for a few attempts
+		tx, err := conn.Begin(true)
+		require.NoError(t, err)
+		go func() {
+			time.Sleep(30 * time.Millisecond)
+			_ = tx.Rollback()
+		}()
+
+		var calls int
+		err = db.UpdateWithRetry(context.Background(), func(tx *chai.Tx) error {
+			calls++
+			return tx.Exec("INSERT INTO test (a) VALUES (1)")
+		}, &chai.RetryPolicy{MaxAttempts: -1})
+		require.NoError(t, err)
+		require.Equal(t, 1, calls)
+
+		require.NoError(t, db.Exec("DELETE FROM test"))
+	})
+
+	t.Run("Context", func(t *testing.T) {
+		conn, err := db.Connect()
+		require.NoError(t, err)
+		defer conn.Close()
+
+		tx, err := conn.Begin(true)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+		defer cancel()
+
+		err = db.UpdateWithRetry(ctx, func(tx *chai.Tx) error {
+			return nil
+		}, &chai.RetryPolicy{MaxAttempts: -1})
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("Give up", func(t *testing.T) {
+		var calls int
+		err := db.UpdateWithRetry(context.Background(), func(tx *chai.Tx) error {
+			calls++
+			return chai.ErrBusy
+		}, policy)
+		require.ErrorIs(t, err, chai.ErrBusy)
+		require.Equal(t, 3, calls)
+	})
+
+	t.Run("Other errors", func(t *testing.T) {
+		var calls int
+		boom := errors.New("boom")
+		err := db.UpdateWithRetry(context.Background(), func(tx *chai.Tx) error {
+			calls++
+			return boom
+		}, policy)
+		require.ErrorIs(t, err, boom)
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("Success after retries", func(t *testing.T) {
+		var calls int
+		err := db.UpdateWithRetry(context.Background(), func(tx *chai.Tx) error {
+			calls++
+			if calls < 3 {
+				return chai.ErrBusy
+			}
+			return tx.Exec("INSERT INTO test (a) VALUES (1)")
+		}, policy)
+		require.NoError(t, err)
+		require.Equal(t, 3, calls)
+
+		r, err := db.QueryRow("SELECT COUNT(*) FROM test")
+		require.NoError(t, err)
+		var n int
+		require.NoError(t, r.Scan(&n))
+		require.Equal(t, 1, n)
+	})
+}
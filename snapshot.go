@@ -0,0 +1,152 @@
+package chai
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/chaisql/chai/internal/database"
+	"github.com/chaisql/chai/internal/database/catalogstore"
+	"github.com/chaisql/chai/internal/engine"
+	"github.com/chaisql/chai/internal/expr/functions"
+	"github.com/chaisql/chai/internal/kv"
+	"github.com/chaisql/chai/internal/query"
+	"github.com/cockroachdb/errors"
+)
+
+// SaveSnapshot writes the committed data of an in-memory database to a file,
+// which can be restored later with LoadSnapshot.
+// The file is replaced atomically: if writing fails, the previous file is kept.
+func (db *DB) SaveSnapshot(path string) error {
+	mem, ok := db.DB.Engine.(*kv.MemoryEngine)
+	if !ok {
+		return errors.New("snapshots can only be saved from in-memory databases")
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	err = mem.SaveSnapshot(f)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	err = f.Sync()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// LoadSnapshot opens an in-memory database with the data
+// of a file written by SaveSnapshot.
+// Changes made to the database are not written to the file
+// unless SaveSnapshot is called again.
+func LoadSnapshot(path string) (*DB, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	mem := kv.NewMemoryEngine()
+	err = mem.LoadSnapshot(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot load snapshot %q", path)
+	}
+
+	fns := functions.NewRegistry()
+	db, err := database.Open(":memory:", &database.Options{
+		CatalogLoader: catalogstore.NewLoader(fns.Deferred()),
+		OpenEngine: func(string) (engine.Engine, error) {
+			return mem, nil
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &DB{
+		DB:        db,
+		cache:     query.NewCache(queryCacheSize),
+		functions: fns,
+	}, nil
+}
+
+// A Snapshot is a consistent view of a database, as it was when the snapshot
+// was created. It stays valid until it is released, whatever the number
+// of transactions started and committed meanwhile. It can be used for instance
+// to export a database without blocking writers for the duration of the export.
+// Snapshots can be used concurrently by several goroutines.
+type Snapshot struct {
+	db   *DB
+	snap *database.Snapshot
+}
+
+// Snapshot pins the current state of the database. The name identifies the snapshot
+// in error messages. The snapshot must be released once unused: it prevents
+// the database from discarding the data it reads and from being closed.
+func (db *DB) Snapshot(name string) (*Snapshot, error) {
+	snap, err := db.DB.Snapshot(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Snapshot{db: db, snap: snap}, nil
+}
+
+// Name returns the name of the snapshot.
+func (s *Snapshot) Name() string {
+	return s.snap.Name
+}
+
+// Release the snapshot. The transactions reading it must be rolled back first.
+// Calling Release more than once does nothing.
+func (s *Snapshot) Release() error {
+	return s.snap.Release()
+}
+
+// View starts a read-only transaction reading the snapshot,
+// runs fn and rolls the transaction back.
+func (s *Snapshot) View(fn func(tx *Tx) error) error {
+	conn, err := s.db.Connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	tx, err := conn.BeginSnapshot(s)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	return fn(tx)
+}
+
+// BeginSnapshot starts a read-only transaction reading the snapshot
+// instead of the current state of the database.
+// The returned transaction must be closed by calling Rollback.
+func (c *Connection) BeginSnapshot(s *Snapshot) (*Tx, error) {
+	_, err := c.Conn.BeginTx(&database.TxOptions{
+		ReadOnly: true,
+		Snapshot: s.snap,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tx{
+		conn: c,
+	}, nil
+}
@@ -0,0 +1,113 @@
+package chai_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/chaisql/chai"
+	"github.com/chaisql/chai/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	db, err := chai.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test(a INTEGER PRIMARY KEY, b TEXT);
+		CREATE INDEX test_b ON test(b);
+		INSERT INTO test (a, b) VALUES (1, 'foo'), (2, 'bar');
+	`)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "snapshot")
+	err = db.SaveSnapshot(path)
+	require.NoError(t, err)
+
+	// changes made after the snapshot are not saved
+	err = db.Exec("INSERT INTO test (a, b) VALUES (3, 'baz')")
+	require.NoError(t, err)
+
+	restored, err := chai.LoadSnapshot(path)
+	require.NoError(t, err)
+	defer restored.Close()
+
+	r, err := restored.QueryRow("SELECT COUNT(*) AS n FROM test")
+	require.NoError(t, err)
+	testutil.RequireJSONEq(t, r, `{"n": 2}`)
+
+	r, err = restored.QueryRow("SELECT a FROM test WHERE b = 'bar'")
+	require.NoError(t, err)
+	testutil.RequireJSONEq(t, r, `{"a": 2}`)
+
+	// only in-memory databases can be saved
+	ondisk, err := chai.Open(filepath.Join(t.TempDir(), "db"))
+	require.NoError(t, err)
+	defer ondisk.Close()
+
+	err = ondisk.SaveSnapshot(path)
+	require.Error(t, err)
+}
+
+func TestPinnedSnapshot(t *testing.T) {
+	for _, path := range []string{":memory:", filepath.Join(t.TempDir(), "db")} {
+		t.Run(path, func(t *testing.T) {
+			db, err := chai.Open(path)
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec("CREATE TABLE test(a INTEGER PRIMARY KEY)")
+			require.NoError(t, err)
+			err = db.Exec("INSERT INTO test (a) VALUES (1), (2)")
+			require.NoError(t, err)
+
+			snap, err := db.Snapshot("export")
+			require.NoError(t, err)
+			require.Equal(t, "export", snap.Name())
+
+			// changes committed after the snapshot, including schema changes,
+			// are not visible from it
+			err = db.Exec("INSERT INTO test (a) VALUES (3)")
+			require.NoError(t, err)
+			err = db.Exec("CREATE TABLE other(a INTEGER PRIMARY KEY)")
+			require.NoError(t, err)
+
+			count := func(tx *chai.Tx) int {
+				var n int
+				r, err := tx.QueryRow("SELECT COUNT(*) FROM test")
+				require.NoError(t, err)
+				require.NoError(t, r.Scan(&n))
+				return n
+			}
+
+			for i := 0; i < 2; i++ {
+				err = snap.View(func(tx *chai.Tx) error {
+					require.Equal(t, 2, count(tx))
+
+					_, err := tx.QueryRow("SELECT * FROM other")
+					require.Error(t, err)
+
+					// snapshots are read-only
+					require.Error(t, tx.Exec("INSERT INTO test (a) VALUES (4)"))
+					return nil
+				})
+				require.NoError(t, err)
+			}
+
+			conn, err := db.Connect()
+			require.NoError(t, err)
+			defer conn.Close()
+			err = conn.View(func(tx *chai.Tx) error {
+				require.Equal(t, 3, count(tx))
+				return nil
+			})
+			require.NoError(t, err)
+
+			require.NoError(t, snap.Release())
+			require.NoError(t, snap.Release())
+			err = snap.View(func(tx *chai.Tx) error { return nil })
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+-- setup:
+CREATE TABLE test(a int primary key, b text);
+CREATE INDEX test_b_idx ON test(b);
+INSERT INTO test (a, b) VALUES (1, 'old');
+CREATE TABLE test_new(a int primary key, b text, c double);
+CREATE INDEX test_new_c_idx ON test_new(c);
+INSERT INTO test_new (a, b, c) VALUES (1, 'new', 1.5), (2, 'new', 2.5);
+
+-- test: swap
+ALTER TABLE test_new SWAP WITH test;
+SELECT * FROM test;
+/* result:
+{"a": 1, "b": "new", "c": 1.5}
+{"a": 2, "b": "new", "c": 2.5}
+*/
+
+-- test: swap indexes
+ALTER TABLE test_new SWAP WITH test;
+SELECT name, owner_table_name AS owner FROM __chai_catalog WHERE type = "index" ORDER BY name;
+/* result:
+{"name": "test_b_idx", "owner": "test_new"}
+{"name": "test_new_c_idx", "owner": "test"}
+*/
+
+-- test: swap back
+ALTER TABLE test_new SWAP WITH test;
+ALTER TABLE test SWAP WITH test_new;
+SELECT * FROM test;
+/* result:
+{"a": 1, "b": "old"}
+*/
+
+-- test: swap in a transaction
+BEGIN;
+ALTER TABLE test_new SWAP WITH test;
+ROLLBACK;
+SELECT * FROM test;
+/* result:
+{"a": 1, "b": "old"}
+*/
+
+-- test: non-existing
+ALTER TABLE test SWAP WITH unknown;
+-- error:
+
+-- test: same table
+ALTER TABLE test SWAP WITH test;
+-- error:
+
+-- test: bad syntax: no WITH
+ALTER TABLE test SWAP test_new;
+-- error:
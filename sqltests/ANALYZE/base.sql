@@ -0,0 +1,63 @@
+-- setup:
+CREATE TABLE test(id int PRIMARY KEY, a int, b text);
+
+CREATE TABLE other(id int PRIMARY KEY);
+
+INSERT INTO
+    test (id, a, b)
+VALUES
+    (1, 1, 'foo'),
+    (2, 1, 'bar'),
+    (3, 2, null),
+    (4, 2, 'foo'),
+    (5, 3, null);
+
+-- test: table
+ANALYZE test;
+SELECT table_name, column_name, row_count, null_count, ndv FROM __chai_statistics;
+/* result:
+{"table_name": "test", "column_name": "a", "row_count": 5, "null_count": 0, "ndv": 3}
+{"table_name": "test", "column_name": "b", "row_count": 5, "null_count": 2, "ndv": 2}
+{"table_name": "test", "column_name": "id", "row_count": 5, "null_count": 0, "ndv": 5}
+*/
+
+-- test: all tables
+ANALYZE;
+SELECT table_name, column_name, row_count FROM __chai_statistics;
+/* result:
+{"table_name": "other", "column_name": "id", "row_count": 0}
+{"table_name": "test", "column_name": "a", "row_count": 5}
+{"table_name": "test", "column_name": "b", "row_count": 5}
+{"table_name": "test", "column_name": "id", "row_count": 5}
+*/
+
+-- test: analyze again
+ANALYZE test;
+INSERT INTO test (id, a, b) VALUES (6, 4, 'baz');
+ANALYZE test;
+SELECT column_name, row_count, ndv FROM __chai_statistics;
+/* result:
+{"column_name": "a", "row_count": 6, "ndv": 4}
+{"column_name": "b", "row_count": 6, "ndv": 3}
+{"column_name": "id", "row_count": 6, "ndv": 6}
+*/
+
+-- test: drop table
+ANALYZE;
+DROP TABLE test;
+SELECT table_name, column_name FROM __chai_statistics;
+/* result:
+{"table_name": "other", "column_name": "id"}
+*/
+
+-- test: rename table
+ANALYZE;
+ALTER TABLE test RENAME TO test2;
+SELECT table_name, column_name FROM __chai_statistics;
+/* result:
+{"table_name": "other", "column_name": "id"}
+*/
+
+-- test: unknown table
+ANALYZE unknown;
+-- error:
@@ -0,0 +1,64 @@
+-- setup:
+CREATE TABLE test (a TEXT, b INT);
+
+-- test: expression
+CREATE INDEX ON test(lower(a), b DESC);
+SELECT name, sql FROM __chai_catalog WHERE type = "index";
+/* result:
+{
+  "name": "test_expr_b_idx",
+  "sql": "CREATE INDEX test_expr_b_idx ON test (LOWER(a), b DESC)"
+}
+*/
+
+-- test: undeclared column
+CREATE INDEX ON test(lower(c));
+-- error:
+
+-- test: constant expression
+CREATE INDEX ON test(lower('a'));
+-- error:
+
+-- test: full-text
+CREATE FULLTEXT INDEX ON test(lower(a));
+-- error:
+
+-- test: unique
+CREATE UNIQUE INDEX ON test(lower(a));
+INSERT INTO test (a, b) VALUES ('Foo', 1);
+INSERT INTO test (a, b) VALUES ('FOO', 2);
+-- error:
+
+-- test: existing rows
+INSERT INTO test (a, b) VALUES ('Foo', 1), ('bar', 2);
+CREATE INDEX test_lower_a ON test(lower(a));
+SELECT b FROM test WHERE lower(a) = 'foo';
+/* result:
+{
+  "b": 1
+}
+*/
+
+-- test: update and delete
+CREATE INDEX test_lower_a ON test(lower(a));
+INSERT INTO test (a, b) VALUES ('Foo', 1), ('bar', 2);
+UPDATE test SET a = 'Baz' WHERE b = 1;
+DELETE FROM test WHERE b = 2;
+SELECT b FROM test WHERE lower(a) = 'baz';
+/* result:
+{
+  "b": 1
+}
+*/
+
+-- test: update and delete: old values
+CREATE INDEX test_lower_a ON test(lower(a));
+INSERT INTO test (a, b) VALUES ('Foo', 1), ('bar', 2);
+UPDATE test SET a = 'Baz' WHERE b = 1;
+DELETE FROM test WHERE b = 2;
+SELECT COUNT(*) AS n FROM test WHERE lower(a) = 'foo';
+/* result:
+{
+  "n": 0
+}
+*/
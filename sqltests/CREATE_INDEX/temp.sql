@@ -0,0 +1,74 @@
+-- setup:
+CREATE TABLE test (a int, b int);
+INSERT INTO test (a, b) VALUES (1, 10), (2, 20), (3, 30);
+
+-- test: not stored in the catalog
+CREATE TEMP INDEX test_a_idx ON test(a);
+SELECT COUNT(*) AS n FROM __chai_catalog WHERE type = "index";
+/* result:
+{
+  "n": 0
+}
+*/
+
+-- test: used by the planner
+CREATE TEMP INDEX test_a_idx ON test(a);
+EXPLAIN SELECT * FROM test WHERE a = 2;
+/* result:
+{
+  "plan": 'index.Scan("test_a_idx", [{"min": (2), "exact": true}])'
+}
+*/
+
+-- test: query results
+CREATE TEMP INDEX ON test(a);
+SELECT b FROM test WHERE a = 2;
+/* result:
+{
+  "b": 20
+}
+*/
+
+-- test: rebuilt after writes
+CREATE TEMP INDEX ON test(a);
+SELECT b FROM test WHERE a = 4;
+INSERT INTO test (a, b) VALUES (4, 40);
+DELETE FROM test WHERE a = 2;
+SELECT b FROM test WHERE a >= 2;
+/* result:
+{
+  "b": 30
+}
+{
+  "b": 40
+}
+*/
+
+-- test: conflict
+CREATE TEMP INDEX test_a_idx ON test(a);
+CREATE TEMP INDEX test_a_idx ON test(b);
+-- error:
+
+-- test: IF NOT EXISTS
+CREATE TEMP INDEX test_a_idx ON test(a);
+CREATE TEMP INDEX IF NOT EXISTS test_a_idx ON test(b);
+EXPLAIN SELECT * FROM test WHERE a = 2;
+/* result:
+{
+  "plan": 'index.Scan("test_a_idx", [{"min": (2), "exact": true}])'
+}
+*/
+
+-- test: unknown column
+CREATE TEMP INDEX test_c_idx ON test(c);
+-- error:
+
+-- test: drop
+CREATE TEMP INDEX test_a_idx ON test(a);
+DROP INDEX test_a_idx;
+EXPLAIN SELECT * FROM test WHERE a = 2;
+/* result:
+{
+  "plan": 'table.Scan("test") | rows.Filter(a = 2)'
+}
+*/
@@ -0,0 +1,45 @@
+-- test: basic
+CREATE TABLE test(a INT PRIMARY KEY, status TEXT ENUM('open', 'closed') NOT NULL DEFAULT 'open');
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (a INTEGER NOT NULL, status TEXT ENUM(\"open\", \"closed\") NOT NULL DEFAULT \"open\", CONSTRAINT test_pk PRIMARY KEY (a))"
+}
+*/
+
+-- test: lowercase
+CREATE TABLE test(status TEXT enum('open'));
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (status TEXT ENUM(\"open\"))"
+}
+*/
+
+-- test: column named enum
+CREATE TABLE test(enum TEXT ENUM('a', 'b'));
+INSERT INTO test (enum) VALUES ('b');
+SELECT enum FROM test;
+/* result:
+{
+  "enum": "b"
+}
+*/
+
+-- test: non text column
+CREATE TABLE test(a INT ENUM('1', '2'));
+-- error: cannot use ENUM on column "a" of type integer at line 1, char 1
+
+-- test: duplicate value
+CREATE TABLE test(a TEXT ENUM('x', 'x'));
+-- error: duplicate ENUM value "x" at line 1, char 1
+
+-- test: empty list
+CREATE TABLE test(a TEXT ENUM());
+-- error:
+
+-- test: twice
+CREATE TABLE test(a TEXT ENUM('x') ENUM('y'));
+-- error:
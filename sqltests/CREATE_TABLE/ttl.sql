@@ -0,0 +1,37 @@
+-- test: ttl column
+CREATE TABLE test (a INT, expires_at TIMESTAMP) WITH (ttl_column = 'expires_at');
+SELECT name, type, sql FROM __chai_catalog WHERE name = "test";
+/* result:
+{
+  name: "test",
+  type: "table",
+  sql: "CREATE TABLE test (a INTEGER, expires_at TIMESTAMP) WITH (ttl_column = 'expires_at')"
+}
+*/
+
+-- test: ttl column: undeclared column
+CREATE TABLE test (a INT) WITH (ttl_column = 'expires_at');
+-- error: TTL column "expires_at" does not exist
+
+-- test: ttl column: not a timestamp
+CREATE TABLE test (a INT, expires_at TEXT) WITH (ttl_column = 'expires_at');
+-- error: TTL column "expires_at" must be of type timestamp, got text
+
+-- test: unknown table option
+CREATE TABLE test (a INT) WITH (foo = 'a');
+-- error: unknown table option "foo" at line 1, char 1
+
+-- test: archive table
+CREATE TABLE test (a INT, expires_at TIMESTAMP) WITH (ttl_column = 'expires_at', archive_table = 'test_archive');
+SELECT name, type, sql FROM __chai_catalog WHERE name = "test";
+/* result:
+{
+  name: "test",
+  type: "table",
+  sql: "CREATE TABLE test (a INTEGER, expires_at TIMESTAMP) WITH (ttl_column = 'expires_at', archive_table = 'test_archive')"
+}
+*/
+
+-- test: archive table without ttl column
+CREATE TABLE test (a INT) WITH (archive_table = 'test_archive');
+-- error: archive table requires a TTL column
@@ -112,6 +112,16 @@ SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
 }
 */
 
+-- test: integer widths
+CREATE TABLE test (a tinyint, b INT16, c int32, d INT64);
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (a TINYINT, b INT16, c INT32, d INT64)"
+}
+*/
+
 -- test: BOOLEAN ALIAS: BOOL
 CREATE TABLE test (a BOOL);
 SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
@@ -141,3 +151,57 @@ SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
   "sql": "CREATE TABLE test (a TEXT)"
 }
 */
+
+-- test: DECIMAL
+CREATE TABLE test (a DECIMAL);
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (a DECIMAL)"
+}
+*/
+
+-- test: DECIMAL(p, s)
+CREATE TABLE test (a DECIMAL(10, 2), b DECIMAL(5));
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (a DECIMAL(10, 2), b DECIMAL(5, 0))"
+}
+*/
+
+-- test: DECIMAL ALIAS: NUMERIC
+CREATE TABLE test (a NUMERIC(4, 1));
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (a DECIMAL(4, 1))"
+}
+*/
+
+-- test: DECIMAL with invalid scale
+CREATE TABLE test (a DECIMAL(2, 3));
+-- error:
+
+-- test: UUID
+CREATE TABLE test (a UUID);
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (a UUID)"
+}
+*/
+
+-- test: UUID as column name
+CREATE TABLE test (uuid UUID PRIMARY KEY);
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (uuid UUID NOT NULL, CONSTRAINT test_pk PRIMARY KEY (uuid))"
+}
+*/
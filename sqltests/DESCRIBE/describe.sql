@@ -0,0 +1,76 @@
+-- test: columns
+CREATE TABLE test(
+    id INT PRIMARY KEY,
+    name TEXT NOT NULL COLLATE nocase,
+    score DOUBLE DEFAULT 1.5,
+    data BLOB
+);
+DESCRIBE test;
+/* result:
+{
+  "name": "id",
+  "type": "INTEGER",
+  "nullable": false,
+  "default": null,
+  "collation": null,
+  "primary_key": true
+}
+{
+  "name": "name",
+  "type": "TEXT",
+  "nullable": false,
+  "default": null,
+  "collation": "nocase",
+  "primary_key": false
+}
+{
+  "name": "score",
+  "type": "DOUBLE",
+  "nullable": true,
+  "default": "1.5",
+  "collation": null,
+  "primary_key": false
+}
+{
+  "name": "data",
+  "type": "BLOB",
+  "nullable": true,
+  "default": null,
+  "collation": null,
+  "primary_key": false
+}
+*/
+
+-- test: composite primary key
+CREATE TABLE test(a INT, b INT, c INT, PRIMARY KEY (a, b));
+DESCRIBE test;
+/* result:
+{
+  "name": "a",
+  "type": "INTEGER",
+  "nullable": false,
+  "default": null,
+  "collation": null,
+  "primary_key": true
+}
+{
+  "name": "b",
+  "type": "INTEGER",
+  "nullable": false,
+  "default": null,
+  "collation": null,
+  "primary_key": true
+}
+{
+  "name": "c",
+  "type": "INTEGER",
+  "nullable": true,
+  "default": null,
+  "collation": null,
+  "primary_key": false
+}
+*/
+
+-- test: unknown table
+DESCRIBE unknown;
+-- error:
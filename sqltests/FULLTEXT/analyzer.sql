@@ -0,0 +1,59 @@
+-- setup:
+CREATE TABLE docs(id INT PRIMARY KEY, body TEXT, title TEXT);
+CREATE FULLTEXT INDEX docs_body_idx ON docs(body) WITH (analyzer = 'english');
+CREATE FULLTEXT INDEX docs_title_idx ON docs(title) WITH (analyzer = 'trigram');
+INSERT INTO docs (id, body, title) VALUES
+    (1, 'The fox jumps over the dog', 'Foxes'),
+    (2, 'Two dogs were jumping', 'Dogs and cats'),
+    (3, 'The cat sleeps', 'Sleeping cats');
+
+-- test: catalog
+SELECT name, sql FROM __chai_catalog WHERE type = "index" ORDER BY name;
+/* result:
+{
+  "name": "docs_body_idx",
+  "sql": "CREATE FULLTEXT INDEX docs_body_idx ON docs (body) WITH (analyzer = 'english')"
+}
+{
+  "name": "docs_title_idx",
+  "sql": "CREATE FULLTEXT INDEX docs_title_idx ON docs (title) WITH (analyzer = 'trigram')"
+}
+*/
+
+-- test: english stemming
+SELECT id FROM docs WHERE body MATCH 'jumped';
+/* result:
+{ "id": 1 }
+{ "id": 2 }
+*/
+
+-- test: english plurals
+SELECT id FROM docs WHERE body MATCH 'dog';
+/* result:
+{ "id": 1 }
+{ "id": 2 }
+*/
+
+-- test: english stop words
+SELECT id FROM docs WHERE body MATCH 'the cat';
+/* result:
+{ "id": 3 }
+*/
+
+-- test: english without index scan
+SELECT id FROM docs WHERE body MATCH 'sleeping' OR id = 2;
+/* result:
+{ "id": 2 }
+{ "id": 3 }
+*/
+
+-- test: trigrams
+SELECT id FROM docs WHERE title MATCH 'ats';
+/* result:
+{ "id": 2 }
+{ "id": 3 }
+*/
+
+-- test: unknown analyzer
+CREATE FULLTEXT INDEX docs_idx ON docs(body) WITH (analyzer = 'klingon');
+-- error: unknown analyzer "klingon"
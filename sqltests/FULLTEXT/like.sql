@@ -0,0 +1,57 @@
+-- setup:
+CREATE TABLE docs(id INT PRIMARY KEY, title TEXT);
+CREATE FULLTEXT INDEX docs_title_idx ON docs(title) WITH (analyzer = 'trigram');
+INSERT INTO docs (id, title) VALUES
+    (1, 'The Quick Fox'),
+    (2, 'Quicksand'),
+    (3, 'Sleeping cats'),
+    (4, 'quick-fix');
+
+-- test: infix
+SELECT id FROM docs WHERE title LIKE '%quick%';
+/* result:
+{ "id": 1 }
+{ "id": 2 }
+{ "id": 4 }
+*/
+
+-- test: infix / explain
+EXPLAIN SELECT id FROM docs WHERE title LIKE '%quick%';
+/* result:
+{
+  "plan": 'index.TrigramScan("docs_title_idx", "%quick%") | rows.Filter(title LIKE "%quick%") | rows.Project(id)'
+}
+*/
+
+-- test: several terms
+SELECT id FROM docs WHERE title LIKE '%quick fox%';
+/* result:
+{ "id": 1 }
+*/
+
+-- test: rows containing all the trigrams are verified
+SELECT id FROM docs WHERE title LIKE '%fox quick%';
+/* result:
+*/
+
+-- test: wildcards
+SELECT id FROM docs WHERE title LIKE 'q_ick%f_x';
+/* result:
+{ "id": 4 }
+*/
+
+-- test: pattern without trigrams
+EXPLAIN SELECT id FROM docs WHERE title LIKE '%ck%';
+/* result:
+{
+  "plan": 'table.Scan("docs") | rows.Filter(title LIKE "%ck%") | rows.Project(id)'
+}
+*/
+
+-- test: primary key
+EXPLAIN SELECT id FROM docs WHERE id = 1 AND title LIKE '%quick%';
+/* result:
+{
+  "plan": 'table.Scan("docs", [{"min": (1), "exact": true}]) | rows.Filter(title LIKE "%quick%") | rows.Project(id)'
+}
+*/
@@ -0,0 +1,88 @@
+-- setup:
+CREATE TABLE docs(id INT PRIMARY KEY, body TEXT);
+CREATE FULLTEXT INDEX docs_body_idx ON docs(body);
+INSERT INTO docs (id, body) VALUES
+    (1, 'The quick brown fox'),
+    (2, 'The lazy dog'),
+    (3, 'fox fox fox'),
+    (4, 'A fox and a dog play together in the garden all day long');
+
+-- test: catalog
+SELECT name, sql FROM __chai_catalog WHERE type = "index";
+/* result:
+{
+  "name": "docs_body_idx",
+  "sql": "CREATE FULLTEXT INDEX docs_body_idx ON docs (body)"
+}
+*/
+
+-- test: single term
+SELECT id FROM docs WHERE body MATCH 'fox';
+/* result:
+{ "id": 1 }
+{ "id": 3 }
+{ "id": 4 }
+*/
+
+-- test: all terms must match
+SELECT id FROM docs WHERE body MATCH 'DOG, fox!';
+/* result:
+{ "id": 4 }
+*/
+
+-- test: no match
+SELECT id FROM docs WHERE body MATCH 'cat';
+/* result:
+*/
+
+-- test: with other conditions
+SELECT id FROM docs WHERE body MATCH 'fox' AND id > 1;
+/* result:
+{ "id": 3 }
+{ "id": 4 }
+*/
+
+-- test: rank
+SELECT id FROM docs WHERE body MATCH 'fox' ORDER BY rank() DESC;
+/* result:
+{ "id": 3 }
+{ "id": 1 }
+{ "id": 4 }
+*/
+
+-- test: rank ascending
+SELECT id FROM docs WHERE body MATCH 'dog' ORDER BY rank();
+/* result:
+{ "id": 4 }
+{ "id": 2 }
+*/
+
+-- test: update
+UPDATE docs SET body = 'a cat' WHERE id = 3;
+SELECT id FROM docs WHERE body MATCH 'fox' ORDER BY rank() DESC;
+/* result:
+{ "id": 1 }
+{ "id": 4 }
+*/
+
+-- test: delete
+DELETE FROM docs WHERE body MATCH 'dog';
+SELECT id FROM docs;
+/* result:
+{ "id": 1 }
+{ "id": 3 }
+*/
+
+-- test: rank without MATCH
+SELECT id FROM docs ORDER BY rank();
+-- error:
+
+-- test: non-text column
+CREATE TABLE nums(a INT);
+CREATE FULLTEXT INDEX ON nums(a);
+-- error:
+
+-- test: multiple columns
+CREATE TABLE texts(a TEXT, b TEXT);
+CREATE FULLTEXT INDEX ON texts(a, b);
+-- error:
@@ -0,0 +1,24 @@
+-- setup:
+CREATE TABLE docs(id INT PRIMARY KEY, body TEXT);
+INSERT INTO docs (id, body) VALUES
+    (1, 'The quick brown fox'),
+    (2, 'The lazy dog'),
+    (3, NULL);
+
+-- test: match without index
+SELECT id FROM docs WHERE body MATCH 'FOX';
+/* result:
+{ "id": 1 }
+*/
+
+-- test: match in projection
+SELECT id, body MATCH 'dog' AS m FROM docs;
+/* result:
+{ "id": 1, "m": false }
+{ "id": 2, "m": true }
+{ "id": 3, "m": null }
+*/
+
+-- test: rank requires an index
+SELECT id FROM docs WHERE body MATCH 'fox' ORDER BY rank();
+-- error:
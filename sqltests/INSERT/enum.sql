@@ -0,0 +1,58 @@
+-- setup:
+CREATE TABLE test(a INT PRIMARY KEY, status TEXT ENUM('open', 'closed', 'archived') DEFAULT 'open');
+CREATE INDEX test_status ON test(status);
+INSERT INTO test (a, status) VALUES (1, 'closed'), (2, 'open'), (3, NULL);
+INSERT INTO test (a) VALUES (4);
+
+-- test: select
+SELECT a, status FROM test ORDER BY a;
+/* result:
+{
+  "a": 1,
+  "status": "closed"
+}
+{
+  "a": 2,
+  "status": "open"
+}
+{
+  "a": 3,
+  "status": null
+}
+{
+  "a": 4,
+  "status": "open"
+}
+*/
+
+-- test: index
+SELECT a FROM test WHERE status = 'open' ORDER BY a;
+/* result:
+{
+  "a": 2
+}
+{
+  "a": 4
+}
+*/
+
+-- test: invalid value
+INSERT INTO test (a, status) VALUES (5, 'pending');
+-- error: ENUM constraint error: [status]
+
+-- test: values are case sensitive
+INSERT INTO test (a, status) VALUES (5, 'OPEN');
+-- error: ENUM constraint error: [status]
+
+-- test: update
+UPDATE test SET status = 'archived' WHERE a = 1;
+SELECT status FROM test WHERE a = 1;
+/* result:
+{
+  "status": "archived"
+}
+*/
+
+-- test: invalid update
+UPDATE test SET status = 'deleted' WHERE a = 1;
+-- error: ENUM constraint error: [status]
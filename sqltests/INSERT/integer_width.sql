@@ -0,0 +1,64 @@
+-- setup:
+CREATE TABLE test (a TINYINT, b INT16, c INT32, d INT64);
+
+-- test: in range
+INSERT INTO test (a, b, c, d) VALUES (-128, 32767, -2147483648, 9223372036854775807);
+SELECT * FROM test;
+/* result:
+{
+  "a": -128,
+  "b": 32767,
+  "c": -2147483648,
+  "d": 9223372036854775807
+}
+*/
+
+-- test: types
+INSERT INTO test (a, b, c, d) VALUES (1, 1, 1, 1);
+SELECT typeof(a) AS a, typeof(b) AS b, typeof(c) AS c, typeof(d) AS d FROM test;
+/* result:
+{
+  "a": "integer",
+  "b": "integer",
+  "c": "integer",
+  "d": "bigint"
+}
+*/
+
+-- test: TINYINT out of range
+INSERT INTO test (a) VALUES (128);
+-- error: column a: 128 out of range for TINYINT
+
+-- test: INT16 out of range
+INSERT INTO test (b) VALUES (-32769);
+-- error: column b: -32769 out of range for INT16
+
+-- test: INT32 out of range
+INSERT INTO test (c) VALUES (2147483648);
+-- error: integer out of range
+
+-- test: update out of range
+INSERT INTO test (a) VALUES (127);
+UPDATE test SET a = a + 1;
+-- error: column a: 128 out of range for TINYINT
+
+-- test: column named int16
+CREATE TABLE foo (int16 INT16);
+INSERT INTO foo (int16) VALUES (1);
+SELECT int16 FROM foo;
+/* result:
+{
+  "int16": 1
+}
+*/
+
+-- test: INT8 is an alias of BIGINT
+CREATE TABLE big (a INT8);
+INSERT INTO big (a) VALUES (9223372036854775807);
+SELECT a, typeof(a) AS t FROM big;
+/* result:
+{
+  "a": 9223372036854775807,
+  "t": "bigint"
+}
+*/
@@ -0,0 +1,39 @@
+-- setup:
+CREATE TABLE test (a int, b int, c int);
+CREATE UNIQUE INDEX test_a_idx ON test (a) NULLS NOT DISTINCT;
+CREATE UNIQUE INDEX test_b_c_idx ON test (b, c) NULLS DISTINCT;
+
+-- test: catalog
+SELECT name, sql FROM __chai_catalog WHERE type = "index" ORDER BY name;
+/* result:
+{
+  "name": "test_a_idx",
+  "sql": "CREATE UNIQUE INDEX test_a_idx ON test (a) NULLS NOT DISTINCT"
+}
+{
+  "name": "test_b_c_idx",
+  "sql": "CREATE UNIQUE INDEX test_b_c_idx ON test (b, c)"
+}
+*/
+
+-- test: NULLS NOT DISTINCT
+INSERT INTO test (a, b) VALUES (NULL, 1);
+INSERT INTO test (a, b) VALUES (NULL, 2);
+-- error: UNIQUE constraint error: [a]
+
+-- test: NULLS DISTINCT
+INSERT INTO test (a, b) VALUES (1, 1), (2, 1);
+SELECT a, b, c FROM test;
+/* result:
+{a: 1, b: 1, c: NULL}
+{a: 2, b: 1, c: NULL}
+*/
+
+-- test: NULLS NOT DISTINCT / update
+INSERT INTO test (a, b) VALUES (NULL, 1), (1, 2);
+UPDATE test SET a = NULL WHERE b = 2;
+-- error: UNIQUE constraint error: [a]
+
+-- test: NULLS NOT DISTINCT on a non-unique index
+CREATE INDEX test_c_idx ON test (c) NULLS NOT DISTINCT;
+-- error: only unique indexes accept NULLS NOT DISTINCT
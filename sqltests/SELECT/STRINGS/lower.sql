@@ -70,3 +70,15 @@ SELECT LOWER(CAST(d as TEXT)) FROM test;
     "LOWER(CAST(d AS text))": "42.42" 
 }
 */
+
+-- test: locale
+SELECT LOWER('TITLE', 'tr') AS l;
+/* result:
+{
+    "l": "tıtle"
+}
+*/
+
+-- test: invalid locale
+SELECT LOWER(a, 'not a locale') FROM test;
+-- error:
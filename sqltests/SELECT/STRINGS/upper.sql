@@ -71,3 +71,11 @@ SELECT UPPER(CAST(d as TEXT)) FROM test;
 }
 */
 
+
+-- test: locale
+SELECT UPPER('istanbul', 'tr') AS u;
+/* result:
+{
+    "u": "İSTANBUL"
+}
+*/
@@ -0,0 +1,28 @@
+-- setup:
+CREATE TABLE test(a int, b text);
+INSERT INTO test (a, b) VALUES (1, 'x'), (2, 'y'), (3, 'x'), (4, NULL);
+
+-- test: ARRAY_AGG
+SELECT ARRAY_AGG(a) FROM test
+/* result:
+{"ARRAY_AGG(a)": "[1, 2, 3, 4]"}
+*/
+
+-- test: ARRAY_AGG with NULL
+SELECT ARRAY_AGG(b) AS b FROM test
+/* result:
+{"b": "[\"x\", \"y\", \"x\", null]"}
+*/
+
+-- test: ARRAY_AGG with GROUP BY
+SELECT b, ARRAY_AGG(a) AS a FROM test WHERE b IS NOT NULL GROUP BY b
+/* result:
+{"b": "x", "a": "[1, 3]"}
+{"b": "y", "a": "[2]"}
+*/
+
+-- test: array functions on ARRAY_AGG
+SELECT array_length(ARRAY_AGG(a)) AS n FROM test
+/* result:
+{"n": 4}
+*/
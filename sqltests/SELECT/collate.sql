@@ -0,0 +1,136 @@
+-- setup:
+CREATE TABLE test(a TEXT COLLATE nocase, b TEXT, c INT);
+INSERT INTO test (a, b, c) VALUES ('Foo', 'Foo', 1), ('bar', 'bar', 2), ('BAZ', 'BAZ', 3), ('foo', 'foo', 4);
+
+-- test: catalog
+SELECT name, sql FROM __chai_catalog WHERE type = "table" AND name = "test";
+/* result:
+{
+  "name": "test",
+  "sql": "CREATE TABLE test (a TEXT COLLATE nocase, b TEXT, c INTEGER)"
+}
+*/
+
+-- test: column collation
+SELECT c FROM test WHERE a = 'FOO';
+/* result:
+{
+    c: 1
+}
+{
+    c: 4
+}
+*/
+
+-- test: column collation on the right
+SELECT c FROM test WHERE 'bAr' = a;
+/* result:
+{
+    c: 2
+}
+*/
+
+-- test: range
+SELECT c FROM test WHERE a > 'BAR' AND a < 'FOO';
+/* result:
+{
+    c: 3
+}
+*/
+
+-- test: explicit collation
+SELECT c FROM test WHERE b COLLATE nocase = 'FOO';
+/* result:
+{
+    c: 1
+}
+{
+    c: 4
+}
+*/
+
+-- test: explicit collation on the right
+SELECT c FROM test WHERE b = 'FOO' COLLATE nocase;
+/* result:
+{
+    c: 1
+}
+{
+    c: 4
+}
+*/
+
+-- test: explicit binary collation
+SELECT c FROM test WHERE a COLLATE binary = 'foo';
+/* result:
+{
+    c: 4
+}
+*/
+
+-- test: order by column collation
+SELECT a FROM test WHERE c < 4 ORDER BY a;
+/* result:
+{
+    a: "bar"
+}
+{
+    a: "BAZ"
+}
+{
+    a: "Foo"
+}
+*/
+
+-- test: projection
+SELECT 'ABC' COLLATE nocase = 'abc' AS x, 'ABC' = 'abc' AS y;
+/* result:
+{
+    x: true,
+    y: false
+}
+*/
+
+-- test: locale collation
+SELECT 'Äpfel' COLLATE "de_DE" < 'Birne' AS x, 'Äpfel' < 'Birne' AS y;
+/* result:
+{
+    x: true,
+    y: false
+}
+*/
+
+-- test: unknown collation
+SELECT c FROM test WHERE a COLLATE "not a locale" = 'foo';
+-- error:
+
+-- test: collation on non text column
+CREATE TABLE test2(a INT COLLATE nocase);
+-- error:
+
+-- test: unknown column collation
+CREATE TABLE test2(a TEXT COLLATE "not a locale");
+-- error:
+
+-- test: projection keeps the value
+SELECT b COLLATE nocase AS x, 'BAR' COLLATE "de_DE" AS y FROM test WHERE c = 1;
+/* result:
+{
+    x: "Foo",
+    y: "BAR"
+}
+*/
+
+-- test: sort by a projected collation
+SELECT b COLLATE nocase AS x FROM test WHERE c < 4 ORDER BY b COLLATE nocase;
+/* result:
+{
+    x: "bar"
+}
+{
+    x: "BAZ"
+}
+{
+    x: "Foo"
+}
+*/
@@ -0,0 +1,64 @@
+-- setup:
+CREATE TABLE test(k BIGINT PRIMARY KEY, a BIGINT, b TEXT COLLATE nocase, c DOUBLE, d TEXT);
+CREATE INDEX test_a_c ON test(a, c DESC);
+CREATE INDEX test_b ON test(b);
+INSERT INTO test (k, a, b, c, d) VALUES (1, 10, 'Foo', 1.5, 'x'), (2, 20, 'bar', 2.5, 'y'), (3, 30, 'BAZ', NULL, 'z');
+
+-- test: indexed columns and primary key
+SELECT k, a, c FROM test WHERE a >= 20;
+/* result:
+{
+    k: 2,
+    a: 20,
+    c: 2.5
+}
+{
+    k: 3,
+    a: 30,
+    c: null
+}
+*/
+
+-- test: column types are preserved
+SELECT typeof(k) AS k, typeof(a) AS a, typeof(c) AS c FROM test WHERE a = 10;
+/* result:
+{
+    k: "bigint",
+    a: "bigint",
+    c: "double"
+}
+*/
+
+-- test: non-indexed column
+SELECT a, d FROM test WHERE a > 10;
+/* result:
+{
+    a: 20,
+    d: "y"
+}
+{
+    a: 30,
+    d: "z"
+}
+*/
+
+-- test: wildcard
+SELECT * FROM test WHERE a = 20;
+/* result:
+{
+    k: 2,
+    a: 20,
+    b: "bar",
+    c: 2.5,
+    d: "y"
+}
+*/
+
+-- test: collated column
+SELECT k, b FROM test WHERE b = 'foo';
+/* result:
+{
+    k: 1,
+    b: "Foo"
+}
+*/
@@ -0,0 +1,165 @@
+-- setup:
+CREATE TABLE test(id INT PRIMARY KEY, price DECIMAL(10, 2));
+INSERT INTO test (id, price) VALUES (1, 19.99), (2, '0.10'), (3, 100), (4, -2.5), (5, 0.125);
+
+-- suite: no index
+
+-- suite: with index
+CREATE INDEX ON test(price);
+
+-- test: values are rounded to the scale of the column
+SELECT id, CAST(price AS TEXT) AS price FROM test ORDER BY id;
+/* result:
+{
+    id: 1,
+    price: "19.99"
+}
+{
+    id: 2,
+    price: "0.10"
+}
+{
+    id: 3,
+    price: "100.00"
+}
+{
+    id: 4,
+    price: "-2.50"
+}
+{
+    id: 5,
+    price: "0.13"
+}
+*/
+
+-- test: order
+SELECT id FROM test ORDER BY price;
+/* result:
+{
+    id: 4
+}
+{
+    id: 2
+}
+{
+    id: 5
+}
+{
+    id: 1
+}
+{
+    id: 3
+}
+*/
+
+-- test: order desc
+SELECT id FROM test ORDER BY price DESC;
+/* result:
+{
+    id: 3
+}
+{
+    id: 1
+}
+{
+    id: 5
+}
+{
+    id: 2
+}
+{
+    id: 4
+}
+*/
+
+-- test: filter
+SELECT id FROM test WHERE price > 0.1 AND price <= 19.99 ORDER BY id;
+/* result:
+{
+    id: 1
+}
+{
+    id: 5
+}
+*/
+
+-- test: equality ignores the scale
+SELECT id FROM test WHERE price = 100;
+/* result:
+{
+    id: 3
+}
+*/
+
+-- test: exact sum
+SELECT CAST(SUM(price) AS TEXT) AS total FROM test;
+/* result:
+{
+    total: "117.72"
+}
+*/
+
+-- test: out of range
+INSERT INTO test (id, price) VALUES (6, 123456789);
+-- error:
+
+-- test: order keeps the scale
+SELECT price FROM test WHERE id > 2 ORDER BY price;
+/* result:
+{
+    price: "-2.50"
+}
+{
+    price: "0.13"
+}
+{
+    price: "100.00"
+}
+*/
+
+-- test: index scan keeps the scale
+SELECT price FROM test WHERE price > 99;
+/* result:
+{
+    price: "100.00"
+}
+*/
+
+-- test: group by keeps the scale
+SELECT price, COUNT(*) AS n FROM test WHERE id IN (3, 4) GROUP BY price;
+/* result:
+{
+    price: "-2.50",
+    n: 1
+}
+{
+    price: "100.00",
+    n: 1
+}
+*/
+
+-- test: distinct keeps the scale
+SELECT DISTINCT price FROM test WHERE id = 3;
+/* result:
+{
+    price: "100.00"
+}
+*/
+
+-- test: union keeps the scale
+SELECT price FROM test WHERE id = 3 UNION SELECT price FROM test WHERE id = 3;
+/* result:
+{
+    price: "100.00"
+}
+*/
+
+-- test: arithmetic with a literal is exact
+SELECT price + 0.2 AS a, price * 0.1 AS b, typeof(price - 0.25) AS t FROM test WHERE id = 2;
+/* result:
+{
+    a: "0.30",
+    b: "0.010",
+    t: "decimal"
+}
+*/
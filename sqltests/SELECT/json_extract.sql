@@ -0,0 +1,72 @@
+-- setup:
+CREATE TABLE test (a INT PRIMARY KEY, doc TEXT, other TEXT);
+INSERT INTO test (a, doc, other) VALUES
+    (1, '{"name": "foo", "tags": ["x", "y"], "meta": {"v": 1}}', 'bar'),
+    (2, '{"name": "baz", "tags": [], "meta": {"v": 2.5}}', NULL),
+    (3, NULL, 'qux');
+
+-- test: extract from a column
+SELECT a, json_extract(doc, '$.name') AS name, json_extract(doc, '$.meta.v') AS v, json_type(doc, '$.tags') AS t FROM test;
+/* result:
+{
+  "a": 1,
+  "name": "foo",
+  "v": 1,
+  "t": "array"
+}
+{
+  "a": 2,
+  "name": "baz",
+  "v": 2.5,
+  "t": "array"
+}
+{
+  "a": 3,
+  "name": null,
+  "v": null,
+  "t": null
+}
+*/
+
+-- test: type of the document
+SELECT a, json_type(doc) AS t FROM test;
+/* result:
+{
+  "a": 1,
+  "t": "object"
+}
+{
+  "a": 2,
+  "t": "object"
+}
+{
+  "a": 3,
+  "t": null
+}
+*/
+
+-- test: filter
+SELECT a FROM test WHERE json_extract(doc, '$.tags[1]') = 'y';
+/* result:
+{
+  "a": 1
+}
+*/
+
+-- test: whole document and missing path
+SELECT json_extract(doc, '$') AS d, json_extract(doc, '$.missing') AS m FROM test WHERE a = 2;
+/* result:
+{
+  "d": "{\"name\": \"baz\", \"tags\": [], \"meta\": {\"v\": 2.5}}",
+  "m": null
+}
+*/
+
+-- test: path from a column
+SELECT json_extract(doc, other) FROM test WHERE a = 1;
+-- error: invalid JSON path "bar"
+
+-- test: malformed document
+INSERT INTO test (a, doc) VALUES (4, '{"name": ');
+SELECT json_extract(doc, '$.name') AS name FROM test WHERE a = 4;
+-- error: malformed JSON: Malformed JSON error
@@ -0,0 +1,58 @@
+-- setup:
+CREATE TABLE test(a TEXT, b INT);
+INSERT INTO test (a, b) VALUES ('Zebra', 1), ('Äpfel', 2), ('apfel', 3), ('Birne', 4);
+
+-- test: binary order
+SELECT a FROM test ORDER BY a;
+/* result:
+{
+    a: "Birne"
+}
+{
+    a: "Zebra"
+}
+{
+    a: "apfel"
+}
+{
+    a: "Äpfel"
+}
+*/
+
+-- test: german collation
+SELECT a FROM test ORDER BY a COLLATE "de_DE";
+/* result:
+{
+    a: "apfel"
+}
+{
+    a: "Äpfel"
+}
+{
+    a: "Birne"
+}
+{
+    a: "Zebra"
+}
+*/
+
+-- test: swedish collation
+SELECT a FROM test ORDER BY a COLLATE "sv" DESC;
+/* result:
+{
+    a: "Äpfel"
+}
+{
+    a: "Zebra"
+}
+{
+    a: "Birne"
+}
+{
+    a: "apfel"
+}
+*/
+
+-- test: unknown collation
+SELECT a FROM test ORDER BY a COLLATE "not a locale";
+-- error:
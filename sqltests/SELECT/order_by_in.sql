@@ -0,0 +1,46 @@
+-- setup:
+CREATE TABLE test(a INT, b INT);
+CREATE INDEX on test(a, b);
+INSERT INTO test (a, b) VALUES (1, 4), (3, 1), (1, 2), (3, 3);
+
+-- test: IN on the sorted column
+SELECT a, b FROM test WHERE a IN (3, 1) ORDER BY a, b;
+/* result:
+{
+    a: 1,
+    b: 2
+}
+{
+    a: 1,
+    b: 4
+}
+{
+    a: 3,
+    b: 1
+}
+{
+    a: 3,
+    b: 3
+}
+*/
+
+-- test: IN before the sorted column
+SELECT a, b FROM test WHERE a IN (3, 1) ORDER BY b;
+/* result:
+{
+    a: 3,
+    b: 1
+}
+{
+    a: 1,
+    b: 2
+}
+{
+    a: 3,
+    b: 3
+}
+{
+    a: 1,
+    b: 4
+}
+*/
@@ -0,0 +1,139 @@
+-- setup:
+CREATE TABLE test(a INT, b DOUBLE, c TEXT);
+INSERT INTO test (a, b, c) VALUES (50, 2, 'x'), (100, 3, 'y'), (10, 1, 'z'), (100, 4, 'w');
+
+-- test: asc, asc
+SELECT a, b FROM test ORDER BY a, b;
+/* result:
+{
+    a: 10,
+    b: 1.0
+}
+{
+    a: 50,
+    b: 2.0
+}
+{
+    a: 100,
+    b: 3.0
+}
+{
+    a: 100,
+    b: 4.0
+}
+*/
+
+-- test: asc, desc
+SELECT a, b FROM test ORDER BY a ASC, b DESC;
+/* result:
+{
+    a: 10,
+    b: 1.0
+}
+{
+    a: 50,
+    b: 2.0
+}
+{
+    a: 100,
+    b: 4.0
+}
+{
+    a: 100,
+    b: 3.0
+}
+*/
+
+-- test: desc, asc
+SELECT a, b FROM test ORDER BY a DESC, b;
+/* result:
+{
+    a: 100,
+    b: 3.0
+}
+{
+    a: 100,
+    b: 4.0
+}
+{
+    a: 50,
+    b: 2.0
+}
+{
+    a: 10,
+    b: 1.0
+}
+*/
+
+-- test: explain
+EXPLAIN SELECT a, b FROM test ORDER BY a DESC, b;
+/* result:
+{
+    plan: "table.Scan(\"test\") | rows.Project(a, b) | rows.TempTreeSort(a DESC, b)"
+}
+*/
+
+-- test: with index
+CREATE INDEX on test(a ASC, b DESC);
+SELECT a, b FROM test ORDER BY a, b DESC;
+/* result:
+{
+    a: 10,
+    b: 1.0
+}
+{
+    a: 50,
+    b: 2.0
+}
+{
+    a: 100,
+    b: 4.0
+}
+{
+    a: 100,
+    b: 3.0
+}
+*/
+
+-- test: with index / reverse
+CREATE INDEX on test(a ASC, b DESC);
+SELECT a, b FROM test ORDER BY a DESC, b;
+/* result:
+{
+    a: 100,
+    b: 3.0
+}
+{
+    a: 100,
+    b: 4.0
+}
+{
+    a: 50,
+    b: 2.0
+}
+{
+    a: 10,
+    b: 1.0
+}
+*/
+
+-- test: three terms
+SELECT a, c FROM test ORDER BY a DESC, b DESC, c;
+/* result:
+{
+    a: 100,
+    c: "w"
+}
+{
+    a: 100,
+    c: "y"
+}
+{
+    a: 50,
+    c: "x"
+}
+{
+    a: 10,
+    c: "z"
+}
+*/
@@ -0,0 +1,73 @@
+-- setup:
+CREATE TABLE test(a int, b double, g text);
+INSERT INTO test (a, b, g) VALUES
+    (2, 2.0, 'x'),
+    (4, 4.0, 'x'),
+    (4, NULL, 'x'),
+    (4, 4.0, 'x'),
+    (5, 5.0, 'y'),
+    (5, 5.0, 'y'),
+    (7, 7.0, 'y'),
+    (9, 9.0, 'z');
+
+-- test: variance
+SELECT VAR_POP(a) AS vp, VAR_SAMP(a) AS vs, STDDEV_POP(a) AS sp, STDDEV_SAMP(a) AS ss FROM test
+/* result:
+{"vp": 4.0, "vs": 4.571428571428571, "sp": 2.0, "ss": 2.138089935299395}
+*/
+
+-- test: variance ignores NULL
+SELECT VAR_POP(b) AS v FROM test WHERE g = 'x'
+/* result:
+{"v": 0.8888888888888888}
+*/
+
+-- test: variance with GROUP BY
+SELECT g, VAR_POP(a) AS vp, VAR_SAMP(a) AS vs FROM test GROUP BY g
+/* result:
+{"g": "x", "vp": 0.75, "vs": 1.0}
+{"g": "y", "vp": 0.8888888888888887, "vs": 1.333333333333333}
+{"g": "z", "vp": 0.0, "vs": NULL}
+*/
+
+-- test: variance of empty set
+SELECT VAR_POP(a) AS vp, STDDEV_SAMP(a) AS ss FROM test WHERE a > 100
+/* result:
+{"vp": NULL, "ss": NULL}
+*/
+
+-- test: median
+SELECT MEDIAN(a) AS m FROM test
+/* result:
+{"m": 4.5}
+*/
+
+-- test: median with GROUP BY
+SELECT g, MEDIAN(b) AS m FROM test GROUP BY g
+/* result:
+{"g": "x", "m": 4.0}
+{"g": "y", "m": 5.0}
+{"g": "z", "m": 9.0}
+*/
+
+-- test: percentile_cont
+SELECT PERCENTILE_CONT(a, 0.25) AS p25, PERCENTILE_CONT(a, 0.9) AS p90, PERCENTILE_CONT(a, 1) AS p100 FROM test
+/* result:
+{"p25": 4.0, "p90": 7.6, "p100": 9.0}
+*/
+
+-- test: percentile_disc
+SELECT PERCENTILE_DISC(a, 0) AS p0, PERCENTILE_DISC(a, 0.5) AS p50, PERCENTILE_DISC(a, 0.9) AS p90 FROM test
+/* result:
+{"p0": 2, "p50": 4, "p90": 9}
+*/
+
+-- test: percentile of empty set
+SELECT PERCENTILE_DISC(a, 0.5) AS p FROM test WHERE a > 100
+/* result:
+{"p": NULL}
+*/
+
+-- test: percentile with invalid fraction
+SELECT PERCENTILE_CONT(a, 2) FROM test
+-- error:
@@ -0,0 +1,92 @@
+-- setup:
+CREATE TABLE test(id UUID PRIMARY KEY, name TEXT, ref UUID);
+INSERT INTO test (id, name, ref) VALUES
+    ('0190a7b4-7c3e-7abc-8def-0123456789ab', 'a', '3f2504e0-4f89-41d3-9a0c-0305e82c3301'),
+    ('0190A7B4-7C3E-7ABC-8DEF-0123456789AA', 'b', NULL),
+    ('{00000000-0000-4000-8000-000000000001}', 'c', '3f2504e0-4f89-41d3-9a0c-0305e82c3301'),
+    ('f47ac10b58cc4372a5670e02b2c3d479', 'd', '00000000-0000-4000-8000-000000000001');
+
+-- suite: no index
+
+-- suite: with index
+CREATE INDEX ON test(ref);
+
+-- test: values are stored in canonical form
+SELECT id, name FROM test;
+/* result:
+{
+    id: "00000000-0000-4000-8000-000000000001",
+    name: "c"
+}
+{
+    id: "0190a7b4-7c3e-7abc-8def-0123456789aa",
+    name: "b"
+}
+{
+    id: "0190a7b4-7c3e-7abc-8def-0123456789ab",
+    name: "a"
+}
+{
+    id: "f47ac10b-58cc-4372-a567-0e02b2c3d479",
+    name: "d"
+}
+*/
+
+-- test: primary key lookup with a text literal
+SELECT name FROM test WHERE id = 'F47AC10B-58CC-4372-A567-0E02B2C3D479';
+/* result:
+{
+    name: "d"
+}
+*/
+
+-- test: range
+SELECT name FROM test WHERE id > '0190a7b4-7c3e-7abc-8def-0123456789aa' ORDER BY id DESC;
+/* result:
+{
+    name: "d"
+}
+{
+    name: "a"
+}
+*/
+
+-- test: filter
+SELECT name FROM test WHERE ref = '3f2504e0-4f89-41d3-9a0c-0305e82c3301' ORDER BY name;
+/* result:
+{
+    name: "a"
+}
+{
+    name: "c"
+}
+*/
+
+-- test: time-ordered primary keys
+CREATE TABLE gen(id UUID PRIMARY KEY, n INT);
+INSERT INTO gen (id, n) VALUES (uuidv7(), 1);
+INSERT INTO gen (id, n) VALUES (uuidv7(), 2);
+INSERT INTO gen (id, n) VALUES (uuidv7(), 3);
+SELECT n, typeof(id) AS t FROM gen;
+/* result:
+{
+    n: 1,
+    t: "uuid"
+}
+{
+    n: 2,
+    t: "uuid"
+}
+{
+    n: 3,
+    t: "uuid"
+}
+*/
+
+-- test: duplicate
+INSERT INTO test (id, name) VALUES ('0190a7b4-7c3e-7abc-8def-0123456789ab', 'e');
+-- error:
+
+-- test: invalid
+INSERT INTO test (id, name) VALUES ('not-a-uuid', 'e');
+-- error:
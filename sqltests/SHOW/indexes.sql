@@ -0,0 +1,82 @@
+-- setup:
+CREATE TABLE foo(a INT, b TEXT UNIQUE, c TEXT);
+CREATE INDEX foo_a_c_idx ON foo(a, c DESC);
+CREATE FULLTEXT INDEX foo_c_ft ON foo(c);
+CREATE TABLE bar(a INT);
+CREATE INDEX bar_a_idx ON bar(a);
+
+-- test: all indexes
+SHOW INDEXES;
+/* result:
+{
+  "name": "bar_a_idx",
+  "table_name": "bar",
+  "columns": "a",
+  "unique": false,
+  "fulltext": false,
+  "temporary": false
+}
+{
+  "name": "foo_a_c_idx",
+  "table_name": "foo",
+  "columns": "a, c DESC",
+  "unique": false,
+  "fulltext": false,
+  "temporary": false
+}
+{
+  "name": "foo_b_idx",
+  "table_name": "foo",
+  "columns": "b",
+  "unique": true,
+  "fulltext": false,
+  "temporary": false
+}
+{
+  "name": "foo_c_ft",
+  "table_name": "foo",
+  "columns": "c",
+  "unique": false,
+  "fulltext": true,
+  "temporary": false
+}
+*/
+
+-- test: indexes of a table
+SHOW INDEXES FROM bar;
+/* result:
+{
+  "name": "bar_a_idx",
+  "table_name": "bar",
+  "columns": "a",
+  "unique": false,
+  "fulltext": false,
+  "temporary": false
+}
+*/
+
+-- test: temporary indexes
+CREATE TEMP INDEX bar_a_temp ON bar(a);
+SHOW INDEXES ON bar;
+/* result:
+{
+  "name": "bar_a_idx",
+  "table_name": "bar",
+  "columns": "a",
+  "unique": false,
+  "fulltext": false,
+  "temporary": false
+}
+{
+  "name": "bar_a_temp",
+  "table_name": "bar",
+  "columns": "a",
+  "unique": false,
+  "fulltext": false,
+  "temporary": true
+}
+*/
+
+-- test: unknown table
+SHOW INDEXES FROM baz;
+-- error:
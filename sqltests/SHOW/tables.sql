@@ -0,0 +1,29 @@
+-- test: no tables
+SHOW TABLES;
+/* result:
+*/
+
+-- test: tables
+CREATE TABLE foo(a INT);
+CREATE TABLE bar(a INT);
+CREATE SEQUENCE seq;
+SHOW TABLES;
+/* result:
+{
+  "name": "bar"
+}
+{
+  "name": "foo"
+}
+*/
+
+-- test: dropped table
+CREATE TABLE foo(a INT);
+CREATE TABLE bar(a INT);
+DROP TABLE foo;
+SHOW TABLES;
+/* result:
+{
+  "name": "bar"
+}
+*/
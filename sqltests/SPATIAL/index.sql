@@ -0,0 +1,100 @@
+-- setup:
+CREATE TABLE places(id INT PRIMARY KEY, name TEXT, loc TEXT);
+CREATE SPATIAL INDEX places_loc_idx ON places(loc);
+INSERT INTO places (id, name, loc) VALUES
+    (1, 'home', 'POINT(0 0)'),
+    (2, 'bakery', 'POINT(0.3 0.4)'),
+    (3, 'park', 'POINT(1 1)'),
+    (4, 'station', 'POINT(-0.5 0)'),
+    (5, 'airport', 'POINT(40 -12)'),
+    (6, 'unknown', NULL);
+
+-- test: catalog
+SELECT name, sql FROM __chai_catalog WHERE type = "index" ORDER BY name;
+/* result:
+{
+  "name": "places_loc_idx",
+  "sql": "CREATE SPATIAL INDEX places_loc_idx ON places (loc)"
+}
+*/
+
+-- test: st_dwithin
+SELECT id FROM places WHERE st_dwithin(loc, 'POINT(0 0)', 0.5);
+/* result:
+{ "id": 1 }
+{ "id": 2 }
+{ "id": 4 }
+*/
+
+-- test: st_dwithin / explain
+EXPLAIN SELECT id FROM places WHERE st_dwithin(loc, 'POINT(0 0)', 0.5);
+/* result:
+{
+  "plan": 'index.SpatialScan("places_loc_idx", "POINT(0 0)", 0.5) | rows.Filter(st_dwithin(loc, "POINT(0 0)", 0.5)) | rows.Project(id)'
+}
+*/
+
+-- test: st_dwithin / swapped arguments
+SELECT id FROM places WHERE st_dwithin(st_point(1, 1), loc, 0.1);
+/* result:
+{ "id": 3 }
+*/
+
+-- test: st_dwithin / large distance
+SELECT id FROM places WHERE st_dwithin(loc, 'POINT(0 0)', 100000);
+/* result:
+{ "id": 1 }
+{ "id": 2 }
+{ "id": 3 }
+{ "id": 4 }
+{ "id": 5 }
+*/
+
+-- test: st_contains
+SELECT id FROM places WHERE st_contains(st_makeenvelope(0, 0, 1, 1), loc);
+/* result:
+{ "id": 1 }
+{ "id": 2 }
+{ "id": 3 }
+*/
+
+-- test: st_contains / explain
+EXPLAIN SELECT id FROM places WHERE st_contains('POLYGON((0 0, 1 0, 1 1, 0 0))', loc);
+/* result:
+{
+  "plan": 'index.SpatialScan("places_loc_idx", "POLYGON((0 0, 1 0, 1 1, 0 0))") | rows.Filter(st_contains("POLYGON((0 0, 1 0, 1 1, 0 0))", loc)) | rows.Project(id)'
+}
+*/
+
+-- test: update
+UPDATE places SET loc = 'POINT(40 -12.1)' WHERE id = 1;
+SELECT id FROM places WHERE st_dwithin(loc, 'POINT(40 -12)', 0.5);
+/* result:
+{ "id": 1 }
+{ "id": 5 }
+*/
+
+-- test: delete
+DELETE FROM places WHERE id = 2;
+SELECT id FROM places WHERE st_dwithin(loc, 'POINT(0 0)', 0.5);
+/* result:
+{ "id": 1 }
+{ "id": 4 }
+*/
+
+-- test: primary key
+EXPLAIN SELECT id FROM places WHERE id = 1 AND st_dwithin(loc, 'POINT(0 0)', 0.5);
+/* result:
+{
+  "plan": 'table.Scan("places", [{"min": (1), "exact": true}]) | rows.Filter(st_dwithin(loc, "POINT(0 0)", 0.5)) | rows.Project(id)'
+}
+*/
+
+-- test: only points are indexed
+INSERT INTO places (id, name, loc) VALUES (7, 'zone', 'POLYGON((0 0, 1 0, 1 1, 0 0))');
+-- error: error while inserting index value: spatial indexes only accept POINT values, got POLYGON((0 0, 1 0, 1 1, 0 0))
+
+-- test: non-text column
+CREATE TABLE test(a INT);
+CREATE SPATIAL INDEX ON test(a);
+-- error: cannot create a spatial index on column "a" of type integer
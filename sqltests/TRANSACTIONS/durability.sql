@@ -0,0 +1,30 @@
+-- setup:
+CREATE TABLE test(a INT PRIMARY KEY, b INT);
+
+-- test: relaxed durability
+BEGIN WITH DURABILITY RELAXED;
+INSERT INTO test (a, b) VALUES (1, 1);
+COMMIT;
+SELECT * FROM test;
+/* result:
+{
+    a: 1,
+    b: 1
+}
+*/
+
+-- test: full durability
+BEGIN TRANSACTION READ WRITE WITH DURABILITY FULL;
+INSERT INTO test (a, b) VALUES (1, 1);
+COMMIT;
+SELECT * FROM test;
+/* result:
+{
+    a: 1,
+    b: 1
+}
+*/
+
+-- test: unknown durability
+BEGIN WITH DURABILITY FAST;
+-- error:
@@ -0,0 +1,75 @@
+-- setup:
+CREATE TABLE test(a INT PRIMARY KEY, b INT);
+INSERT INTO test (a, b) VALUES (1, 1);
+
+-- test: deferred
+BEGIN DEFERRED TRANSACTION;
+SELECT * FROM test;
+INSERT INTO test (a, b) VALUES (2, 2);
+COMMIT;
+SELECT * FROM test;
+/* result:
+{
+    a: 1,
+    b: 1
+}
+{
+    a: 2,
+    b: 2
+}
+*/
+
+-- test: deferred without writes
+BEGIN DEFERRED;
+SELECT * FROM test;
+COMMIT;
+SELECT * FROM test;
+/* result:
+{
+    a: 1,
+    b: 1
+}
+*/
+
+-- test: deferred rollback
+BEGIN DEFERRED;
+SAVEPOINT sp;
+INSERT INTO test (a, b) VALUES (2, 2);
+ROLLBACK TO sp;
+INSERT INTO test (a, b) VALUES (3, 3);
+COMMIT;
+SELECT * FROM test;
+/* result:
+{
+    a: 1,
+    b: 1
+}
+{
+    a: 3,
+    b: 3
+}
+*/
+
+-- test: immediate
+BEGIN IMMEDIATE;
+INSERT INTO test (a, b) VALUES (2, 2);
+ROLLBACK;
+SELECT * FROM test;
+/* result:
+{
+    a: 1,
+    b: 1
+}
+*/
+
+-- test: exclusive
+BEGIN EXCLUSIVE TRANSACTION;
+UPDATE test SET b = 10;
+COMMIT;
+SELECT * FROM test;
+/* result:
+{
+    a: 1,
+    b: 10
+}
+*/
@@ -0,0 +1,108 @@
+-- setup:
+CREATE TABLE test(a INT PRIMARY KEY, b INT);
+INSERT INTO test (a, b) VALUES (1, 1);
+
+-- test: rollback to savepoint
+BEGIN;
+INSERT INTO test (a, b) VALUES (2, 2);
+SAVEPOINT sp;
+INSERT INTO test (a, b) VALUES (3, 3);
+UPDATE test SET b = 10 WHERE a = 1;
+DELETE FROM test WHERE a = 2;
+ROLLBACK TO SAVEPOINT sp;
+INSERT INTO test (a, b) VALUES (4, 4);
+COMMIT;
+SELECT * FROM test;
+/* result:
+{
+    a: 1,
+    b: 1
+}
+{
+    a: 2,
+    b: 2
+}
+{
+    a: 4,
+    b: 4
+}
+*/
+
+-- test: nested savepoints
+BEGIN;
+SAVEPOINT a;
+INSERT INTO test (a, b) VALUES (2, 2);
+SAVEPOINT b;
+INSERT INTO test (a, b) VALUES (3, 3);
+ROLLBACK TO b;
+INSERT INTO test (a, b) VALUES (3, 30);
+ROLLBACK TO a;
+INSERT INTO test (a, b) VALUES (5, 5);
+COMMIT;
+SELECT * FROM test;
+/* result:
+{
+    a: 1,
+    b: 1
+}
+{
+    a: 5,
+    b: 5
+}
+*/
+
+-- test: release savepoint
+BEGIN;
+SAVEPOINT sp;
+INSERT INTO test (a, b) VALUES (2, 2);
+RELEASE SAVEPOINT sp;
+COMMIT;
+SELECT * FROM test;
+/* result:
+{
+    a: 1,
+    b: 1
+}
+{
+    a: 2,
+    b: 2
+}
+*/
+
+-- test: rollback after savepoint
+BEGIN;
+SAVEPOINT sp;
+INSERT INTO test (a, b) VALUES (2, 2);
+ROLLBACK TO sp;
+INSERT INTO test (a, b) VALUES (3, 3);
+ROLLBACK;
+SELECT * FROM test;
+/* result:
+{
+    a: 1,
+    b: 1
+}
+*/
+
+-- test: rollback to released savepoint
+BEGIN;
+SAVEPOINT sp;
+RELEASE sp;
+ROLLBACK TO sp;
+-- error:
+
+-- test: savepoint outside of transaction
+SAVEPOINT sp;
+-- error:
+
+-- test: schema changes
+BEGIN;
+SAVEPOINT sp;
+CREATE TABLE foo(a INT);
+INSERT INTO foo (a) VALUES (1);
+ROLLBACK TO sp;
+CREATE TABLE foo(b INT);
+COMMIT;
+SELECT * FROM foo;
+/* result:
+*/
@@ -0,0 +1,33 @@
+-- setup:
+CREATE TABLE test (a INT PRIMARY KEY, doc TEXT);
+INSERT INTO test (a, doc) VALUES (1, '{"name": "foo", "tags": ["x"], "meta": {"v": 1, "w": 2}}');
+
+-- test: merge patch
+UPDATE test SET doc = json_merge_patch(doc, '{"tags": null, "meta": {"v": 2}}') WHERE a = 1;
+SELECT doc FROM test;
+/* result:
+{
+  "doc": "{\"name\":\"foo\",\"meta\":{\"v\":2,\"w\":2}}"
+}
+*/
+
+-- test: patch
+UPDATE test SET doc = json_patch(doc, '[{"op": "add", "path": "/tags/-", "value": "y"}, {"op": "replace", "path": "/meta/v", "value": 2}]') WHERE a = 1;
+SELECT doc FROM test;
+/* result:
+{
+  "doc": "{\"name\":\"foo\",\"tags\":[\"x\",\"y\"],\"meta\":{\"v\":2,\"w\":2}}"
+}
+*/
+
+-- test: failed patch
+UPDATE test SET doc = json_patch(doc, '[{"op": "test", "path": "/name", "value": "bar"}]') WHERE a = 1;
+-- error: json_patch: operation 0 (test "/name"): test failed
+
+-- test: diff
+SELECT json_diff(doc, '{"name": "bar", "tags": ["x"], "meta": {"v": 1, "w": 2}}') AS changes FROM test;
+/* result:
+{
+  "changes": "[{\"op\":\"replace\",\"path\":\"/name\",\"value\":\"bar\",\"old_value\":\"foo\"}]"
+}
+*/
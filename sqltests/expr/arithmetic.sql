@@ -99,3 +99,40 @@ NULL
 ! 1000000000 * 1000000000
 
 ! 1000000000000000000 * 1000000000000000000 * 1000000000000000000
+
+-- test: overflow
+> 2147483647 * 0
+0
+
+> 0 * -2147483648
+0
+
+> -2147483647 * -1
+2147483647
+
+! 2147483647 + 1
+'integer out of range'
+
+! -2147483647 - 2
+'integer out of range'
+
+! 65536 * 65536
+'integer out of range'
+
+! 65536 * -65536
+'integer out of range'
+
+! -2147483648 / -1
+'integer out of range'
+
+! -2147483648 * -1
+'integer out of range'
+
+! 9223372036854775807 + 1
+'bigint out of range'
+
+! 4294967296 * 4294967296
+'bigint out of range'
+
+! -9223372036854775808 / -1
+'bigint out of range'
@@ -14,6 +14,25 @@ true
 ! CAST (1 AS BLOB)
 'cannot cast integer as blob'
 
+! CAST (1 AS TIMESTAMP)
+'cannot cast integer as timestamp'
+
+-- test: source(BIGINT)
+> CAST (3000000000 AS BOOL)
+true
+
+> CAST (CAST (0 AS BIGINT) AS BOOL)
+false
+
+> CAST (3000000000 AS DOUBLE)
+3000000000.0
+
+! CAST (3000000000 AS BLOB)
+'cannot cast bigint as blob'
+
+! CAST (3000000000 AS TIMESTAMP)
+'cannot cast bigint as timestamp'
+
 -- test: source(DOUBLE)
 > CAST (1.1 AS DOUBLE)
 1.1
@@ -21,8 +40,11 @@ true
 > CAST (1.1 AS INTEGER)
 1
 
-! CAST (1.1 AS BOOL)
-'cannot cast double as bool'
+> CAST (1.1 AS BOOL)
+true
+
+> CAST (0.0 AS BOOL)
+false
 
 > CAST (1.1 AS TEXT)
 '1.1'
@@ -30,6 +52,9 @@ true
 ! CAST (1.1 AS BLOB)
 'cannot cast double as blob'
 
+! CAST (1.1 AS TIMESTAMP)
+'cannot cast double as timestamp'
+
 -- test: source(BOOL)
 > CAST (true AS BOOL)
 true
@@ -40,8 +65,11 @@ true
 > CAST (false AS INTEGER)
 0
 
-! CAST (true AS DOUBLE)
-'cannot cast boolean as double'
+> CAST (true AS DOUBLE)
+1.0
+
+> CAST (true AS BIGINT)
+1
 
 > CAST (true AS TEXT)
 'true'
@@ -49,6 +77,9 @@ true
 ! CAST (true AS BLOB)
 'cannot cast boolean as blob'
 
+! CAST (true AS TIMESTAMP)
+'cannot cast boolean as timestamp'
+
 -- test: source(TEXT)
 > CAST ('a' AS TEXT)
 'a'
@@ -90,5 +121,106 @@ false
 ! CAST ('\xAF' AS DOUBLE)
 'cannot cast blob as double'
 
+! CAST ('\xAF' AS BOOL)
+'cannot cast blob as boolean'
+
+! CAST ('\xAF' AS TIMESTAMP)
+'cannot cast blob as timestamp'
+
+-- test: source(TIMESTAMP)
+! CAST (CAST ('2023-04-05' AS TIMESTAMP) AS INTEGER)
+'cannot cast timestamp as integer'
+
+! CAST (CAST ('2023-04-05' AS TIMESTAMP) AS DOUBLE)
+'cannot cast timestamp as double'
+
+! CAST (CAST ('2023-04-05' AS TIMESTAMP) AS BOOL)
+'cannot cast timestamp as boolean'
+
+! CAST (CAST ('2023-04-05' AS TIMESTAMP) AS BLOB)
+'cannot cast timestamp as blob'
+
 > CAST ('\x617364696e65' AS TEXT)
 'YXNkaW5l'
+
+-- test: integer out of range
+! CAST (3000000000.0 AS INTEGER)
+'integer out of range'
+
+! CAST (-3000000000.0 AS INTEGER)
+'integer out of range'
+
+! CAST ('3000000000' AS INTEGER)
+'integer out of range'
+
+-- test: integer widths
+> CAST (127 AS TINYINT)
+127
+
+> typeof(CAST (127 AS TINYINT))
+'integer'
+
+> CAST ('-32768' AS INT16)
+-32768
+
+> typeof(CAST (1 AS INT64))
+'bigint'
+
+! CAST (128 AS TINYINT)
+'128 out of range for TINYINT'
+
+! CAST (-129 AS TINYINT)
+'-129 out of range for TINYINT'
+
+! CAST (40000.5 AS INT16)
+'40000 out of range for INT16'
+
+> TRY_CAST (40000 AS INT16)
+NULL
+
+> CAST (NULL AS TINYINT)
+NULL
+
+-- test: TRY_CAST
+> TRY_CAST ('100' AS INTEGER)
+100
+
+> TRY_CAST ('a' AS INTEGER)
+NULL
+
+> TRY_CAST (1.1 AS BLOB)
+NULL
+
+> TRY_CAST ('3000000000' AS INTEGER)
+NULL
+
+> TRY_CAST (NULL AS INTEGER)
+NULL
+
+-- test: FORMAT
+> CAST ('\xAABB' AS TEXT FORMAT 'hex')
+'aabb'
+
+> CAST ('\xAABB' AS TEXT FORMAT 'base64')
+'qrs='
+
+> CAST ('aabb' AS BLOB FORMAT 'hex')
+'\xAABB'
+
+> CAST ('2023-04-05 06:07:08' AS TIMESTAMP) = CAST ('05/04/2023 06:07:08' AS TIMESTAMP FORMAT '%d/%m/%Y %H:%M:%S')
+true
+
+> CAST (CAST ('2023-04-05 06:07:08' AS TIMESTAMP) AS TEXT FORMAT '%Y%m%d')
+'20230405'
+
+! CAST ('zz' AS BLOB FORMAT 'hex')
+'cannot cast "zz" as blob'
+
+! CAST ('05/04/2023' AS TIMESTAMP FORMAT '%Y-%m-%d')
+'cannot cast "05/04/2023" as timestamp with format "%Y-%m-%d"'
+
+! CAST (1 AS TEXT FORMAT 'hex')
+'cannot cast integer as text with a format'
+
+> TRY_CAST ('zz' AS BLOB FORMAT 'hex')
+NULL
@@ -0,0 +1,56 @@
+-- test: cast
+> CAST (CAST ('12.50' AS DECIMAL) AS TEXT)
+'12.50'
+
+> CAST (CAST (12 AS DECIMAL) AS TEXT)
+'12'
+
+> CAST (CAST (0.1 AS DECIMAL) AS TEXT)
+'0.1'
+
+> CAST (CAST ('2.5' AS NUMERIC) AS INTEGER)
+3
+
+> CAST (CAST ('-2.5' AS DECIMAL) AS INTEGER)
+-3
+
+> CAST (CAST ('2.5' AS DECIMAL) AS DOUBLE)
+2.5
+
+> CAST ('2.50' AS DECIMAL) = 2.5
+true
+
+! CAST ('abc' AS DECIMAL)
+'cannot cast "abc" as decimal'
+
+-- test: arithmetic
+> CAST (CAST ('0.1' AS DECIMAL) + CAST ('0.2' AS DECIMAL) AS TEXT)
+'0.3'
+
+> CAST (CAST ('1.10' AS DECIMAL) * 3 AS TEXT)
+'3.30'
+
+> CAST (CAST ('10' AS DECIMAL) / 4 AS TEXT)
+'2.5000000000000000'
+
+> CAST (1 - CAST ('0.01' AS DECIMAL) AS TEXT)
+'0.99'
+
+> CAST ('1.5' AS DECIMAL) + 1.0
+2.5
+
+! CAST ('1' AS DECIMAL) / 0
+'division by zero'
+
+-- test: functions
+> CAST (round(CAST ('2.345' AS DECIMAL), 2) AS TEXT)
+'2.35'
+
+> CAST (floor(CAST ('-2.5' AS DECIMAL)) AS TEXT)
+'-3'
+
+> CAST (ceil(CAST ('2.1' AS DECIMAL)) AS TEXT)
+'3'
+
+> CAST (abs(CAST ('-2.10' AS DECIMAL)) AS TEXT)
+'2.10'
@@ -0,0 +1,39 @@
+-- test: at time zone
+> CAST (CAST ('2023-04-05 06:07:08' AS TIMESTAMP) AT TIME ZONE 'Europe/Paris' AS TEXT)
+'2023-04-05T08:07:08+02:00'
+
+> CAST (CAST ('2023-01-05 06:07:08' AS TIMESTAMP) AT TIME ZONE 'Europe/Paris' AS TEXT)
+'2023-01-05T07:07:08+01:00'
+
+> CAST (CAST ('2023-04-05T06:07:08+02:00' AS TIMESTAMP) AS TEXT)
+'2023-04-05T04:07:08Z'
+
+> CAST (CAST ('2023-04-05 06:07:08' AS TIMESTAMP) at time zone '-05:30' AS TEXT)
+'2023-04-05T00:37:08-05:30'
+
+> CAST (CAST ('2023-04-05 06:07:08' AS TIMESTAMP) AT TIME ZONE 'UTC' AS TEXT)
+'2023-04-05T06:07:08Z'
+
+> CAST ('2023-04-05 06:07:08' AT TIME ZONE 'Asia/Tokyo' AS TEXT)
+'2023-04-05T06:07:08+09:00'
+
+> CAST ('2023-04-05 06:07:08' AT TIME ZONE 'Asia/Tokyo' AS TIMESTAMP) = CAST ('2023-04-04 21:07:08' AS TIMESTAMP)
+true
+
+> CAST (CAST ('2023-04-05 06:07:08' AS TIMESTAMP) AT TIME ZONE '+0200' AS TEXT FORMAT '%H:%M %z')
+'08:07 +0200'
+
+> typeof('2023-04-05 06:07:08' AT TIME ZONE 'UTC')
+'timestamp'
+
+> NULL AT TIME ZONE 'UTC'
+NULL
+
+! CAST ('2023-04-05 06:07:08' AS TIMESTAMP) AT TIME ZONE 'Mars/Olympus'
+'unknown time zone "Mars/Olympus"'
+
+! CAST ('2023-04-05 06:07:08' AS TIMESTAMP) AT TIME ZONE '+25:00'
+'unknown time zone "+25:00"'
+
+! 1 AT TIME ZONE 'UTC'
+'cannot use AT TIME ZONE with integer'
@@ -0,0 +1,52 @@
+-- test: cast
+> CAST ('0190A7B4-7C3E-7ABC-8DEF-0123456789AB' AS UUID)
+'0190a7b4-7c3e-7abc-8def-0123456789ab'
+
+> CAST ('{0190a7b4-7c3e-7abc-8def-0123456789ab}' AS UUID)
+'0190a7b4-7c3e-7abc-8def-0123456789ab'
+
+> CAST ('0190a7b47c3e7abc8def0123456789ab' AS UUID)
+'0190a7b4-7c3e-7abc-8def-0123456789ab'
+
+> typeof(CAST ('0190a7b4-7c3e-7abc-8def-0123456789ab' AS UUID))
+'uuid'
+
+> CAST (CAST ('0190a7b4-7c3e-7abc-8def-0123456789ab' AS UUID) AS TEXT)
+'0190a7b4-7c3e-7abc-8def-0123456789ab'
+
+> CAST (CAST ('\x0190a7b47c3e7abc8def0123456789ab' AS UUID) AS TEXT)
+'0190a7b4-7c3e-7abc-8def-0123456789ab'
+
+> CAST ('0190a7b4-7c3e-7abc-8def-0123456789ab' AS UUID) = '0190A7B4-7C3E-7ABC-8DEF-0123456789AB'
+true
+
+> CAST ('0190a7b4-7c3e-7abc-8def-0123456789ab' AS UUID) < CAST ('0190a7b4-7c3e-7abc-8def-0123456789ac' AS UUID)
+true
+
+! CAST ('0190a7b4-7c3e-7abc-8def' AS UUID)
+'cannot cast "0190a7b4-7c3e-7abc-8def" as uuid'
+
+! CAST ('0190a7b4_7c3e_7abc_8def_0123456789ab' AS UUID)
+'cannot cast "0190a7b4_7c3e_7abc_8def_0123456789ab" as uuid'
+
+! CAST ('\x0190' AS UUID)
+'cannot cast blob of 2 bytes as uuid'
+
+-- test: generators
+> typeof(uuid())
+'uuid'
+
+> typeof(uuidv7())
+'uuid'
+
+> uuid() = uuid()
+false
+
+> uuidv7() < uuidv7()
+true
+
+> substr(CAST (uuid() AS TEXT), 15, 1)
+'4'
+
+> substr(CAST (uuidv7() AS TEXT), 15, 1)
+'7'
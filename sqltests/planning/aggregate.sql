@@ -0,0 +1,61 @@
+-- setup:
+CREATE TABLE test(a int, b int);
+
+CREATE INDEX test_a ON test(a);
+
+INSERT INTO
+    test (a, b)
+VALUES
+    (1, 3),
+    (2, 1),
+    (3, 3),
+    (4, 2),
+    (5, 1);
+
+-- test: group by a non-indexed column
+EXPLAIN SELECT b, COUNT(*) FROM test GROUP BY b;
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.HashAggregate(b, COUNT(*)) | rows.Project(b, COUNT(*))'
+}
+*/
+
+-- test: group by a non-indexed column / results
+SELECT b, COUNT(*) FROM test GROUP BY b;
+/* result:
+{ "b": 1, "COUNT(*)": 2 }
+{ "b": 2, "COUNT(*)": 1 }
+{ "b": 3, "COUNT(*)": 2 }
+*/
+
+-- test: group by a non-indexed column in descending order
+EXPLAIN SELECT b, COUNT(*) FROM test GROUP BY b ORDER BY b DESC;
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.HashAggregateReverse(b, COUNT(*)) | rows.Project(b, COUNT(*))'
+}
+*/
+
+-- test: group by a non-indexed column in descending order / results
+SELECT b, COUNT(*) FROM test GROUP BY b ORDER BY b DESC;
+/* result:
+{ "b": 3, "COUNT(*)": 2 }
+{ "b": 2, "COUNT(*)": 1 }
+{ "b": 1, "COUNT(*)": 2 }
+*/
+
+-- test: group by an indexed column
+EXPLAIN SELECT a, COUNT(*) FROM test GROUP BY a;
+/* result:
+{
+    "plan": 'index.Scan("test_a") | rows.GroupAggregate(a, COUNT(*)) | rows.Project(a, COUNT(*))'
+}
+*/
+
+-- test: group by and order by different columns
+EXPLAIN SELECT b, COUNT(*) FROM test GROUP BY b ORDER BY COUNT(*);
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.HashAggregate(b, COUNT(*)) | rows.Project(b, COUNT(*)) | rows.TempTreeSort(COUNT(*))'
+}
+*/
@@ -0,0 +1,83 @@
+-- setup:
+CREATE TABLE test(a TEXT COLLATE nocase, b TEXT, c INT);
+CREATE INDEX test_a ON test(a);
+CREATE INDEX test_b ON test(b);
+INSERT INTO test (a, b, c) VALUES ('Foo', 'Foo', 1), ('bar', 'bar', 2), ('BAZ', 'BAZ', 3);
+
+-- test: nocase index
+EXPLAIN SELECT * FROM test WHERE a = 'FOO';
+/* result:
+{
+    "plan": 'index.Scan("test_a", [{"min": ("foo"), "exact": true}])'
+}
+*/
+
+-- test: nocase index results
+SELECT c FROM test WHERE a = 'FOO';
+/* result:
+{
+    c: 1
+}
+*/
+
+-- test: nocase index range
+SELECT c FROM test WHERE a >= 'BAR' AND a < 'BAZZ';
+/* result:
+{
+    c: 2
+}
+{
+    c: 3
+}
+*/
+
+-- test: nocase index order
+EXPLAIN SELECT a FROM test ORDER BY a;
+/* result:
+{
+    "plan": 'index.Scan("test_a") | rows.Project(a)'
+}
+*/
+
+-- test: nocase index order results
+SELECT a FROM test ORDER BY a DESC;
+/* result:
+{
+    a: "Foo"
+}
+{
+    a: "BAZ"
+}
+{
+    a: "bar"
+}
+*/
+
+-- test: binary comparison on a nocase index
+EXPLAIN SELECT * FROM test WHERE a COLLATE binary = 'Foo';
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.Filter(a COLLATE binary = "Foo")'
+}
+*/
+
+-- test: nocase comparison on a binary index
+EXPLAIN SELECT * FROM test WHERE b COLLATE nocase = 'FOO';
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.Filter(b COLLATE nocase = "foo")'
+}
+*/
+
+-- test: nocase comparison on a binary index results
+SELECT c FROM test WHERE b COLLATE nocase = 'FOO';
+/* result:
+{
+    c: 1
+}
+*/
+
+-- test: unique nocase index
+CREATE UNIQUE INDEX test_a_unique ON test(a);
+INSERT INTO test (a, b, c) VALUES ('FOO', 'FOO', 4);
+-- error:
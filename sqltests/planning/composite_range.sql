@@ -0,0 +1,97 @@
+-- setup:
+CREATE TABLE test(a int, b int, c int);
+
+CREATE INDEX test_a_b ON test(a, b);
+
+INSERT INTO
+    test (a, b, c)
+VALUES
+    (1, 2, 1),
+    (1, 3, 2),
+    (1, 5, 1),
+    (1, 7, 2),
+    (1, 8, 1),
+    (2, 5, 2);
+
+-- test: BETWEEN
+EXPLAIN SELECT * FROM test WHERE a = 1 AND b BETWEEN 3 AND 7;
+/* result:
+{
+    "plan": 'index.Scan("test_a_b", [{"min": (1, 3), "max": (1, 7)}])'
+}
+*/
+
+-- test: BETWEEN / results
+SELECT a, b FROM test WHERE a = 1 AND b BETWEEN 3 AND 7;
+/* result:
+{ "a": 1, "b": 3 }
+{ "a": 1, "b": 5 }
+{ "a": 1, "b": 7 }
+*/
+
+-- test: inclusive bounds
+EXPLAIN SELECT * FROM test WHERE a = 1 AND b >= 3 AND b <= 7;
+/* result:
+{
+    "plan": 'index.Scan("test_a_b", [{"min": (1, 3), "max": (1, 7)}])'
+}
+*/
+
+-- test: exclusive bounds
+EXPLAIN SELECT * FROM test WHERE a = 1 AND b > 3 AND b < 7;
+/* result:
+{
+    "plan": 'index.Scan("test_a_b", [{"min": (1, 3), "max": (1, 7), "exclusive": true}])'
+}
+*/
+
+-- test: exclusive bounds / results
+SELECT a, b FROM test WHERE a = 1 AND b > 3 AND b < 7;
+/* result:
+{ "a": 1, "b": 5 }
+*/
+
+-- test: mixed bounds
+EXPLAIN SELECT * FROM test WHERE a = 1 AND b < 7 AND b >= 3;
+/* result:
+{
+    "plan": 'index.Scan("test_a_b", [{"min": (1, 3), "max": (1, 7)}]) | rows.Filter(b < 7)'
+}
+*/
+
+-- test: mixed bounds / results
+SELECT a, b FROM test WHERE a = 1 AND b < 7 AND b >= 3;
+/* result:
+{ "a": 1, "b": 3 }
+{ "a": 1, "b": 5 }
+*/
+
+-- test: residual filter
+EXPLAIN SELECT * FROM test WHERE a = 1 AND b >= 3 AND b <= 7 AND c = 2;
+/* result:
+{
+    "plan": 'index.Scan("test_a_b", [{"min": (1, 3), "max": (1, 7)}]) | rows.Filter(c = 2)'
+}
+*/
+
+-- test: residual filter / results
+SELECT a, b FROM test WHERE a = 1 AND b >= 3 AND b <= 7 AND c = 2;
+/* result:
+{ "a": 1, "b": 3 }
+{ "a": 1, "b": 7 }
+*/
+
+-- test: first column
+EXPLAIN SELECT * FROM test WHERE a > 0 AND a < 2;
+/* result:
+{
+    "plan": 'index.Scan("test_a_b", [{"min": (0), "max": (2), "exclusive": true}])'
+}
+*/
+
+-- test: first column / results
+SELECT a, b FROM test WHERE a > 0 AND a < 2 AND b > 6;
+/* result:
+{ "a": 1, "b": 7 }
+{ "a": 1, "b": 8 }
+*/
@@ -0,0 +1,89 @@
+-- setup:
+CREATE TABLE test(a TEXT, b TEXT, c INT);
+CREATE INDEX test_lower_a ON test(lower(a));
+CREATE INDEX test_c_1 ON test(c + 1);
+INSERT INTO test (a, b, c) VALUES ('Foo', 'x', 1), ('bar', 'y', 2), ('BAZ', 'z', 3);
+
+-- test: equality
+EXPLAIN SELECT * FROM test WHERE lower(a) = 'foo';
+/* result:
+{
+    "plan": 'index.Scan("test_lower_a", [{"min": ("foo"), "exact": true}])'
+}
+*/
+
+-- test: equality with the literal on the left
+EXPLAIN SELECT * FROM test WHERE 'foo' = lower(a);
+/* result:
+{
+    "plan": 'index.Scan("test_lower_a", [{"min": ("foo"), "exact": true}])'
+}
+*/
+
+-- test: equality results
+SELECT c FROM test WHERE lower(a) = 'baz';
+/* result:
+{
+    c: 3
+}
+*/
+
+-- test: other expression
+EXPLAIN SELECT * FROM test WHERE upper(a) = 'FOO';
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.Filter(UPPER(a) = "FOO")'
+}
+*/
+
+-- test: range
+EXPLAIN SELECT * FROM test WHERE lower(a) > 'foo';
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.Filter(LOWER(a) > "foo")'
+}
+*/
+
+-- test: numeric
+EXPLAIN SELECT * FROM test WHERE c + 1 = 2;
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.Filter(c + 1 = 2)'
+}
+*/
+
+-- test: order by
+EXPLAIN SELECT * FROM test ORDER BY lower(a);
+/* result:
+{
+    "plan": 'index.Scan("test_lower_a")'
+}
+*/
+
+-- test: order by results
+SELECT a FROM test ORDER BY lower(a) DESC;
+/* result:
+{
+    a: "Foo"
+}
+{
+    a: "BAZ"
+}
+{
+    a: "bar"
+}
+*/
+
+-- test: order by numeric expression
+SELECT c FROM test ORDER BY c + 1 DESC;
+/* result:
+{
+    c: 3
+}
+{
+    c: 2
+}
+{
+    c: 1
+}
+*/
@@ -0,0 +1,113 @@
+-- setup:
+CREATE TABLE test(id int PRIMARY KEY, a int, b text, c int);
+
+CREATE INDEX test_a ON test(a) USING HASH;
+
+CREATE INDEX test_b_c ON test(b, c) USING HASH;
+
+INSERT INTO
+    test (id, a, b, c)
+VALUES
+    (1, 1, 'foo', 1),
+    (2, 1, 'bar', 2),
+    (3, 2, 'foo', 2),
+    (4, 2, 'bar', 3),
+    (5, 3, 'foo', 3);
+
+-- test: catalog
+SELECT sql FROM __chai_catalog WHERE name = "test_a";
+/* result:
+{
+  "sql": "CREATE INDEX test_a ON test (a) USING HASH"
+}
+*/
+
+-- test: =
+EXPLAIN SELECT * FROM test WHERE a = 2;
+/* result:
+{
+    "plan": 'index.Scan("test_a", [{"min": (2), "exact": true}]) | rows.Filter(a = 2)'
+}
+*/
+
+-- test: = / results
+SELECT id FROM test WHERE a = 2;
+/* result:
+{ "id": 3 }
+{ "id": 4 }
+*/
+
+-- test: IN
+EXPLAIN SELECT * FROM test WHERE a IN (1, 3);
+/* result:
+{
+    "plan": 'index.Scan("test_a", [{"min": (1), "exact": true}, {"min": (3), "exact": true}]) | rows.Filter(a IN (1, 3))'
+}
+*/
+
+-- test: IN / results
+SELECT id FROM test WHERE a IN (1, 3);
+/* result:
+{ "id": 1 }
+{ "id": 2 }
+{ "id": 5 }
+*/
+
+-- test: range
+EXPLAIN SELECT * FROM test WHERE a > 2;
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.Filter(a > 2)'
+}
+*/
+
+-- test: composite
+EXPLAIN SELECT * FROM test WHERE b = 'foo' AND c = 2;
+/* result:
+{
+    "plan": 'index.Scan("test_b_c", [{"min": ("foo", 2), "exact": true}]) | rows.Filter(b = "foo") | rows.Filter(c = 2)'
+}
+*/
+
+-- test: composite / results
+SELECT id FROM test WHERE b = 'foo' AND c = 2;
+/* result:
+{ "id": 3 }
+*/
+
+-- test: composite with missing column
+EXPLAIN SELECT * FROM test WHERE b = 'foo';
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.Filter(b = "foo")'
+}
+*/
+
+-- test: order by
+EXPLAIN SELECT * FROM test ORDER BY a;
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.TempTreeSort(a)'
+}
+*/
+
+-- test: update
+UPDATE test SET a = 4 WHERE id = 3;
+SELECT id FROM test WHERE a = 2;
+/* result:
+{ "id": 4 }
+*/
+
+-- test: delete
+DELETE FROM test WHERE a = 2;
+SELECT id FROM test WHERE a = 2;
+/* result:
+*/
+
+-- test: unique
+CREATE UNIQUE INDEX test_c ON test(c) USING HASH;
+-- error: hash indexes cannot be unique, full-text or spatial
+
+-- test: unknown method
+CREATE INDEX test_c ON test(c) USING BTREE;
+-- error:
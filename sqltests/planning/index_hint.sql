@@ -0,0 +1,119 @@
+-- setup:
+CREATE TABLE test(id int PRIMARY KEY, a int, b int);
+
+CREATE INDEX test_a ON test(a);
+
+CREATE INDEX test_b ON test(b);
+
+INSERT INTO
+    test (id, a, b)
+VALUES
+    (1, 1, 1),
+    (2, 1, 2),
+    (3, 2, 2),
+    (4, 2, 3),
+    (5, 3, 3);
+
+-- test: no hint
+EXPLAIN SELECT * FROM test WHERE a = 1 AND b = 2;
+/* result:
+{
+    "plan": 'index.Scan("test_a", [{"min": (1), "exact": true}]) | rows.Filter(b = 2)'
+}
+*/
+
+-- test: USE INDEX
+EXPLAIN SELECT * FROM test USE INDEX (test_b) WHERE a = 1 AND b = 2;
+/* result:
+{
+    "plan": 'index.Scan("test_b", [{"min": (2), "exact": true}]) | rows.Filter(a = 1)'
+}
+*/
+
+-- test: USE INDEX / results
+SELECT id FROM test USE INDEX (test_b) WHERE a = 1 AND b = 2;
+/* result:
+{ "id": 2 }
+*/
+
+-- test: USE INDEX over the primary key
+EXPLAIN SELECT * FROM test USE INDEX (test_a) WHERE id = 1 AND a = 1;
+/* result:
+{
+    "plan": 'index.Scan("test_a", [{"min": (1), "exact": true}]) | rows.Filter(id = 1)'
+}
+*/
+
+-- test: USE INDEX with an index that cannot be used
+EXPLAIN SELECT * FROM test USE INDEX (test_a) WHERE b = 2;
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.Filter(b = 2)'
+}
+*/
+
+-- test: IGNORE INDEX
+EXPLAIN SELECT * FROM test IGNORE INDEX (test_a) WHERE a = 1 AND b = 2;
+/* result:
+{
+    "plan": 'index.Scan("test_b", [{"min": (2), "exact": true}]) | rows.Filter(a = 1)'
+}
+*/
+
+-- test: IGNORE INDEX / several indexes
+EXPLAIN SELECT * FROM test IGNORE INDEX (test_a, test_b) WHERE a = 1 AND b = 2;
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.Filter(a = 1) | rows.Filter(b = 2)'
+}
+*/
+
+-- test: NO INDEX
+EXPLAIN SELECT * FROM test NO INDEX WHERE a = 1;
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.Filter(a = 1)'
+}
+*/
+
+-- test: NO INDEX / primary key
+EXPLAIN SELECT * FROM test NO INDEX WHERE id = 1 AND a = 1;
+/* result:
+{
+    "plan": 'table.Scan("test", [{"min": (1), "exact": true}]) | rows.Filter(a = 1)'
+}
+*/
+
+-- test: NO INDEX / ORDER BY
+EXPLAIN SELECT * FROM test NO INDEX ORDER BY a;
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.TempTreeSort(a)'
+}
+*/
+
+-- test: UPDATE
+UPDATE test USE INDEX (test_b) SET a = 10 WHERE a = 1 AND b = 2;
+SELECT id, a FROM test NO INDEX WHERE a = 10;
+/* result:
+{ "id": 2, "a": 10 }
+*/
+
+-- test: DELETE
+DELETE FROM test IGNORE INDEX (test_a) WHERE a = 2;
+SELECT id FROM test;
+/* result:
+{ "id": 1 }
+{ "id": 2 }
+{ "id": 5 }
+*/
+
+-- test: unknown index
+SELECT * FROM test USE INDEX (test_c);
+-- error: "test_c" not found
+
+-- test: index of another table
+CREATE TABLE other(a int);
+CREATE INDEX other_a ON other(a);
+SELECT * FROM test IGNORE INDEX (other_a);
+-- error: index other_a does not belong to table test
@@ -0,0 +1,110 @@
+-- setup:
+CREATE TABLE test(id int PRIMARY KEY, a int, b int, c int);
+
+CREATE INDEX test_a ON test(a);
+
+CREATE INDEX test_b ON test(b);
+
+INSERT INTO
+    test (id, a, b, c)
+VALUES
+    (1, 1, 1, 1),
+    (2, 1, 2, 2),
+    (3, 2, 2, 3),
+    (4, 2, 3, 4),
+    (5, 3, 3, 5);
+
+-- test: =
+EXPLAIN SELECT * FROM test WHERE a = 1 OR b = 3;
+/* result:
+{
+    "plan": 'table.Union("test", index.Scan("test_a", [{"min": (1), "exact": true}]), index.Scan("test_b", [{"min": (3), "exact": true}])) | rows.Filter(a = 1 OR b = 3)'
+}
+*/
+
+-- test: = / results
+SELECT id FROM test WHERE a = 1 OR b = 3;
+/* result:
+{ "id": 1 }
+{ "id": 2 }
+{ "id": 4 }
+{ "id": 5 }
+*/
+
+-- test: overlapping conditions / results
+SELECT id FROM test WHERE a = 2 OR b = 2;
+/* result:
+{ "id": 2 }
+{ "id": 3 }
+{ "id": 4 }
+*/
+
+-- test: primary key
+EXPLAIN SELECT * FROM test WHERE id = 5 OR a > 1;
+/* result:
+{
+    "plan": 'table.Union("test", table.Scan("test", [{"min": (5), "exact": true}]), index.Scan("test_a", [{"min": (1), "exclusive": true}])) | rows.Filter(id = 5 OR a > 1)'
+}
+*/
+
+-- test: several conditions
+EXPLAIN SELECT * FROM test WHERE a = 1 OR b = 3 OR id IN (3, 4);
+/* result:
+{
+    "plan": 'table.Union("test", index.Scan("test_a", [{"min": (1), "exact": true}]), index.Scan("test_b", [{"min": (3), "exact": true}]), table.Scan("test", [{"min": (3), "exact": true}, {"min": (4), "exact": true}])) | rows.Filter(a = 1 OR b = 3 OR id IN (3, 4))'
+}
+*/
+
+-- test: several conditions / results
+SELECT id FROM test WHERE a = 3 OR b = 1 OR id IN (3, 4);
+/* result:
+{ "id": 1 }
+{ "id": 3 }
+{ "id": 4 }
+{ "id": 5 }
+*/
+
+-- test: non-indexed column
+EXPLAIN SELECT * FROM test WHERE a = 1 OR c = 3;
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.Filter(a = 1 OR c = 3)'
+}
+*/
+
+-- test: with another indexed condition
+EXPLAIN SELECT * FROM test WHERE (a = 1 OR b = 3) AND b = 2;
+/* result:
+{
+    "plan": 'index.Scan("test_b", [{"min": (2), "exact": true}]) | rows.Filter((a = 1 OR b = 3))'
+}
+*/
+
+-- test: with another condition
+EXPLAIN SELECT * FROM test WHERE (a = 1 OR b = 3) AND c = 2;
+/* result:
+{
+    "plan": 'table.Union("test", index.Scan("test_a", [{"min": (1), "exact": true}]), index.Scan("test_b", [{"min": (3), "exact": true}])) | rows.Filter((a = 1 OR b = 3)) | rows.Filter(c = 2)'
+}
+*/
+
+-- test: with another condition / results
+SELECT id FROM test WHERE (a = 1 OR b = 3) AND c = 2;
+/* result:
+{ "id": 2 }
+*/
+
+-- test: NO INDEX
+EXPLAIN SELECT * FROM test NO INDEX WHERE a = 1 OR b = 3;
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.Filter(a = 1 OR b = 3)'
+}
+*/
+
+-- test: delete
+DELETE FROM test WHERE a = 1 OR b = 3;
+SELECT id FROM test;
+/* result:
+{ "id": 3 }
+*/
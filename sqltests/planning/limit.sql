@@ -0,0 +1,71 @@
+-- setup:
+CREATE TABLE test(a int, b int, c int);
+
+CREATE INDEX test_a ON test(a);
+
+INSERT INTO
+    test (a, b, c)
+VALUES
+    (1, 5, 1),
+    (2, 4, 2),
+    (3, 3, 3),
+    (4, 2, 4),
+    (5, 1, 5);
+
+-- test: sort by a non-indexed column
+EXPLAIN SELECT * FROM test ORDER BY b LIMIT 2;
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.TempTreeSort(b LIMIT 2) | rows.Take(2)'
+}
+*/
+
+-- test: sort by a non-indexed column with offset
+EXPLAIN SELECT * FROM test ORDER BY b DESC LIMIT 2 OFFSET 1;
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.TempTreeSortReverse(b LIMIT 2 OFFSET 1) | rows.Skip(1) | rows.Take(2)'
+}
+*/
+
+-- test: sort without limit
+EXPLAIN SELECT * FROM test ORDER BY b;
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.TempTreeSort(b)'
+}
+*/
+
+-- test: sort by an indexed column
+EXPLAIN SELECT * FROM test ORDER BY a DESC LIMIT 2;
+/* result:
+{
+    "plan": 'index.ScanReverse("test_a") | rows.Take(2)'
+}
+*/
+
+-- test: top rows
+SELECT a, b FROM test ORDER BY b LIMIT 2;
+/* result:
+{
+    "a": 5,
+    "b": 1
+}
+{
+    "a": 4,
+    "b": 2
+}
+*/
+
+-- test: top rows with offset
+SELECT a, b FROM test ORDER BY b DESC LIMIT 2 OFFSET 1;
+/* result:
+{
+    "a": 2,
+    "b": 4
+}
+{
+    "a": 3,
+    "b": 3
+}
+*/
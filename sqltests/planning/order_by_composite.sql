@@ -84,3 +84,27 @@ EXPLAIN SELECT * FROM test WHERE b = 10 ORDER BY a DESC;
     "plan": 'index.ScanReverse("test_a_b") | rows.Filter(b = 10)'
 }
 */
+
+-- test: filtering and sorting: = on both columns
+EXPLAIN SELECT * FROM test WHERE a = 10 AND b > 2 ORDER BY b;
+/* result:
+{
+    "plan": 'index.Scan("test_a_b", [{"min": (10, 2), "exclusive": true}])'
+}
+*/
+
+-- test: filtering and sorting: IN on the sorted column
+EXPLAIN SELECT * FROM test WHERE a IN (3, 1) ORDER BY a;
+/* result:
+{
+    "plan": 'index.Scan("test_a_b", [{"min": (3), "exact": true}, {"min": (1), "exact": true}]) | rows.TempTreeSort(a)'
+}
+*/
+
+-- test: filtering and sorting: IN before the sorted column
+EXPLAIN SELECT * FROM test WHERE a IN (3, 1) ORDER BY b;
+/* result:
+{
+    "plan": 'index.Scan("test_a_b", [{"min": (3), "exact": true}, {"min": (1), "exact": true}]) | rows.TempTreeSort(b)'
+}
+*/
@@ -0,0 +1,69 @@
+-- setup:
+CREATE TABLE test(a int, b int, c int);
+
+CREATE INDEX test_a_b ON test(a ASC, b DESC);
+
+INSERT INTO
+    test (a, b, c)
+VALUES
+    (1, 1, 1),
+    (2, 2, 2),
+    (3, 3, 3),
+    (4, 4, 4),
+    (5, 5, 5);
+
+-- test: same directions as the index
+EXPLAIN SELECT * FROM test ORDER BY a, b DESC;
+/* result:
+{
+    "plan": 'index.Scan("test_a_b")'
+}
+*/
+
+-- test: inverted directions
+EXPLAIN SELECT * FROM test ORDER BY a DESC, b;
+/* result:
+{
+    "plan": 'index.ScanReverse("test_a_b")'
+}
+*/
+
+-- test: incompatible directions
+EXPLAIN SELECT * FROM test ORDER BY a, b;
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.TempTreeSort(a, b)'
+}
+*/
+
+-- test: non-indexed column
+EXPLAIN SELECT * FROM test ORDER BY a, c;
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.TempTreeSort(a, c)'
+}
+*/
+
+-- test: columns in a different order
+EXPLAIN SELECT * FROM test ORDER BY b DESC, a;
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.TempTreeSort(b DESC, a)'
+}
+*/
+
+-- test: filtering and sorting: >
+EXPLAIN SELECT * FROM test WHERE a > 2 ORDER BY a DESC, b;
+/* result:
+{
+    "plan": 'index.ScanReverse("test_a_b", [{"min": (2), "exclusive": true}])'
+}
+*/
+
+-- test: filtering and sorting on the second column: =
+EXPLAIN SELECT * FROM test WHERE a = 2 ORDER BY b;
+/* result:
+{
+    "plan": 'index.ScanReverse("test_a_b", [{"min": (2), "exact": true}])'
+}
+*/
@@ -0,0 +1,84 @@
+-- setup:
+CREATE TABLE test(a int PRIMARY KEY, b int, c text);
+
+CREATE INDEX test_b ON test(b);
+
+INSERT INTO
+    test (a, b, c)
+VALUES
+    (1, 10, 'a'),
+    (2, 20, 'b'),
+    (3, 30, 'c'),
+    (4, 40, 'd'),
+    (5, 50, 'e'),
+    (6, 60, 'f'),
+    (7, 70, 'g'),
+    (8, 80, 'h');
+
+-- test: filter and projection
+EXPLAIN SELECT c FROM test PARALLEL 4 WHERE c > 'b';
+/* result:
+{
+    "plan": 'table.ParallelScan("test", 4, rows.Filter(c > "b") | rows.Project(c))'
+}
+*/
+
+-- test: projection followed by a sort
+EXPLAIN SELECT c FROM test PARALLEL 4 WHERE c > 'b' ORDER BY c DESC;
+/* result:
+{
+    "plan": 'table.ParallelScan("test", 4, rows.Filter(c > "b")) | rows.Project(c) | rows.TempTreeSortReverse(c)'
+}
+*/
+
+-- test: index scan
+EXPLAIN SELECT * FROM test PARALLEL 4 WHERE b > 30;
+/* result:
+{
+    "plan": 'index.Scan("test_b", [{"min": (30), "exclusive": true}])'
+}
+*/
+
+-- test: rows are returned in order
+SELECT a, c FROM test PARALLEL 3 WHERE c != 'c';
+/* result:
+{
+    "a": 1,
+    "c": "a"
+}
+{
+    "a": 2,
+    "c": "b"
+}
+{
+    "a": 4,
+    "c": "d"
+}
+{
+    "a": 5,
+    "c": "e"
+}
+{
+    "a": 6,
+    "c": "f"
+}
+{
+    "a": 7,
+    "c": "g"
+}
+{
+    "a": 8,
+    "c": "h"
+}
+*/
+
+-- test: limit
+SELECT a FROM test PARALLEL 4 WHERE a > 2 LIMIT 2;
+/* result:
+{
+    "a": 3
+}
+{
+    "a": 4
+}
+*/
@@ -0,0 +1,107 @@
+-- setup:
+CREATE TABLE test(id int PRIMARY KEY, a int, b int);
+
+CREATE INDEX test_a ON test(a);
+
+CREATE INDEX test_b ON test(b);
+
+INSERT INTO
+    test (id, a, b)
+VALUES
+    (1, 1, 1),
+    (2, 1, 2),
+    (3, 1, 3),
+    (4, 1, 4),
+    (5, 2, 5),
+    (6, 2, 6),
+    (7, 2, 7),
+    (8, 2, 8);
+
+-- test: without statistics
+EXPLAIN SELECT * FROM test WHERE a = 1;
+/* result:
+{
+    "plan": 'index.Scan("test_a", [{"min": (1), "exact": true}])'
+}
+*/
+
+-- test: low selectivity
+ANALYZE test;
+EXPLAIN SELECT * FROM test WHERE a = 1;
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.Filter(a = 1)'
+}
+*/
+
+-- test: low selectivity / results
+ANALYZE test;
+SELECT id FROM test WHERE a = 1;
+/* result:
+{ "id": 1 }
+{ "id": 2 }
+{ "id": 3 }
+{ "id": 4 }
+*/
+
+-- test: high selectivity
+ANALYZE test;
+EXPLAIN SELECT * FROM test WHERE b = 3;
+/* result:
+{
+    "plan": 'index.Scan("test_b", [{"min": (3), "exact": true}])'
+}
+*/
+
+-- test: most selective index
+ANALYZE test;
+EXPLAIN SELECT * FROM test WHERE a = 1 AND b = 3;
+/* result:
+{
+    "plan": 'index.Scan("test_b", [{"min": (3), "exact": true}]) | rows.Filter(a = 1)'
+}
+*/
+
+-- test: most selective index without statistics
+EXPLAIN SELECT * FROM test WHERE a = 1 AND b = 3;
+/* result:
+{
+    "plan": 'index.Scan("test_a", [{"min": (1), "exact": true}]) | rows.Filter(b = 3)'
+}
+*/
+
+-- test: range
+ANALYZE test;
+EXPLAIN SELECT * FROM test WHERE b < 2;
+/* result:
+{
+    "plan": 'index.Scan("test_b", [{"max": (2), "exclusive": true}])'
+}
+*/
+
+-- test: wide range
+ANALYZE test;
+EXPLAIN SELECT * FROM test WHERE b > 2;
+/* result:
+{
+    "plan": 'table.Scan("test") | rows.Filter(b > 2)'
+}
+*/
+
+-- test: order by
+ANALYZE test;
+EXPLAIN SELECT * FROM test WHERE a > 0 ORDER BY a;
+/* result:
+{
+    "plan": 'index.Scan("test_a", [{"min": (0), "exclusive": true}])'
+}
+*/
+
+-- test: USE INDEX
+ANALYZE test;
+EXPLAIN SELECT * FROM test USE INDEX (test_a) WHERE a = 1;
+/* result:
+{
+    "plan": 'index.Scan("test_a", [{"min": (1), "exact": true}])'
+}
+*/
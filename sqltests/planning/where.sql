@@ -84,4 +84,13 @@ EXPLAIN SELECT * FROM test WHERE a IN (1, b + 3);
  {
     "plan": 'table.Scan("test") | rows.Filter(a IN (1, b + 3))'
  }
-*/
\ No newline at end of file
+*/
+-- test: MATCH with full-text index
+CREATE TABLE docs(id INT PRIMARY KEY, body TEXT);
+CREATE FULLTEXT INDEX docs_body_idx ON docs(body);
+EXPLAIN SELECT * FROM docs WHERE body MATCH 'fox' AND id > 1;
+/* result:
+{
+    "plan": 'index.FulltextScan("docs_body_idx", "fox") | rows.Filter(id > 1)'
+}
+*/
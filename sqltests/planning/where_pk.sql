@@ -49,3 +49,67 @@ EXPLAIN SELECT * FROM test WHERE a < 10 AND b > 5;
     "plan": 'table.Scan("test", [{"max": (10), "exclusive": true}]) | rows.Filter(b > 5)'
 }
 */
+
+-- test: prefix equality
+EXPLAIN SELECT * FROM test WHERE a = 2;
+/* result:
+{
+    "plan": 'table.Scan("test", [{"min": (2), "exact": true}])'
+}
+*/
+
+-- test: prefix equality / results
+SELECT * FROM test WHERE a = 2;
+/* result:
+{
+    "a": 2,
+    "b": 2,
+    "c": 2
+}
+*/
+
+-- test: prefix equality and >
+EXPLAIN SELECT * FROM test WHERE a = 2 AND b > 1;
+/* result:
+{
+    "plan": 'table.Scan("test", [{"min": (2, 1), "exclusive": true}])'
+}
+*/
+
+-- test: BETWEEN
+EXPLAIN SELECT * FROM test WHERE a BETWEEN 2 AND 4;
+/* result:
+{
+    "plan": 'table.Scan("test", [{"min": (2), "max": (4)}])'
+}
+*/
+
+-- test: BETWEEN / results
+SELECT a FROM test WHERE a BETWEEN 2 AND 4;
+/* result:
+{ "a": 2 }
+{ "a": 3 }
+{ "a": 4 }
+*/
+
+-- test: > and <
+EXPLAIN SELECT * FROM test WHERE a > 1 AND a < 4;
+/* result:
+{
+    "plan": 'table.Scan("test", [{"min": (1), "max": (4), "exclusive": true}])'
+}
+*/
+
+-- test: > and < / results
+SELECT a FROM test WHERE a > 1 AND a < 4;
+/* result:
+{ "a": 2 }
+{ "a": 3 }
+*/
+
+-- test: <= / results
+SELECT a FROM test WHERE a <= 2;
+/* result:
+{ "a": 1 }
+{ "a": 2 }
+*/
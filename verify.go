@@ -0,0 +1,32 @@
+package chai
+
+import (
+	"context"
+
+	"github.com/chaisql/chai/internal/database"
+)
+
+// VerifyOptions configures how Verify checks the database.
+type VerifyOptions = database.VerifyOptions
+
+// A VerifyReport lists the problems found by Verify,
+// for every table and index of the database.
+type VerifyReport = database.VerifyReport
+
+// A TableReport describes the rows of a table checked by Verify.
+type TableReport = database.TableReport
+
+// A CorruptedRow is a row of a table that failed the check.
+type CorruptedRow = database.CorruptedRow
+
+// An IndexReport describes the entries of an index checked by Verify.
+type IndexReport = database.IndexReport
+
+// Verify checks that every row of the database can be decoded and
+// that every index contains exactly the entries built from the rows of its table.
+// The check runs in a single read transaction, writes can continue meanwhile.
+// If opts.Repair is set, the indexes failing the check are rebuilt.
+// Corrupted rows are reported but never modified.
+func (db *DB) Verify(ctx context.Context, opts *VerifyOptions) (*VerifyReport, error) {
+	return db.DB.Verify(ctx, opts)
+}